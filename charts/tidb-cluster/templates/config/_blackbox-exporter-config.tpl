@@ -0,0 +1,16 @@
+modules:
+  tcp_connect:
+    prober: tcp
+    timeout: 5s
+  http_2xx:
+    prober: http
+    timeout: 5s
+    http:
+      preferred_ip_protocol: ip4
+      tls_config:
+        insecure_skip_verify: true
+      {{- if .Values.enableTLSCluster }}
+        ca_file: /var/run/secrets/kubernetes.io/serviceaccount/ca.crt
+        cert_file: /var/lib/cluster-client-tls/client.crt
+        key_file: /var/lib/cluster-client-tls/client.key
+      {{- end }}
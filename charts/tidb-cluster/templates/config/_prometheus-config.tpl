@@ -1,7 +1,11 @@
+{{- $cluster := include "cluster.name" . }}
+{{- $shards := int (.Values.monitor.prometheus.shards | default 1) }}
+{{- /* Shard is set in the context of the config of the extra shards, the monitor pod is the first shard */}}
+{{- $shard := int (.Shard | default 0) -}}
 global:
   scrape_interval: 15s
   evaluation_interval: 15s
-{{- if .Values.monitor.prometheus.alertmanagerURL }}
+{{- if and (eq $shard 0) .Values.monitor.prometheus.alertmanagerURL }}
 alerting:
   alertmanagers:
   - static_configs:
@@ -19,8 +23,16 @@ scrape_configs:
         names:
         - {{ .Release.Namespace }}
     {{- end }}
+    {{- if .Values.enableTLSCluster }}
+    scheme: https
+    {{- end }}
     tls_config:
       insecure_skip_verify: true
+    {{- if .Values.enableTLSCluster }}
+      ca_file: /var/run/secrets/kubernetes.io/serviceaccount/ca.crt
+      cert_file: /var/lib/cluster-client-tls/client.crt
+      key_file: /var/lib/cluster-client-tls/client.key
+    {{- end }}
     relabel_configs:
     - source_labels: [__meta_kubernetes_pod_label_app_kubernetes_io_instance]
       action: keep
@@ -37,6 +49,16 @@ scrape_configs:
       regex: ([^:]+)(?::\d+)?;(\d+)
       replacement: $1:$2
       target_label: __address__
+    {{- if gt $shards 1 }}
+    # the targets are split across the prometheus shards by the hash of their addresses
+    - source_labels: [__address__]
+      action: hashmod
+      modulus: {{ $shards }}
+      target_label: __tmp_hash
+    - source_labels: [__tmp_hash]
+      action: keep
+      regex: {{ $shard }}
+    {{- end }}
     - source_labels: [__meta_kubernetes_namespace]
       action: replace
       target_label: kubernetes_namespace
@@ -52,5 +74,56 @@ scrape_configs:
     - source_labels: [__meta_kubernetes_pod_label_app_kubernetes_io_instance]
       action: replace
       target_label: cluster
+{{- if and (eq $shard 0) .Values.monitor.blackboxExporter.create }}
+  # the availability of the endpoints is probed through the services by the blackbox exporter sidecar,
+  # so an endpoint unreachable from the clients is reported even if the pods are scraped successfully
+  - job_name: 'blackbox-tcp'
+    scrape_interval: 15s
+    metrics_path: /probe
+    params:
+      module: [tcp_connect]
+    static_configs:
+    - targets:
+      - {{ $cluster }}-tidb.{{ .Release.Namespace }}:4000
+      - {{ $cluster }}-pd.{{ .Release.Namespace }}:2379
+      labels:
+        cluster: {{ .Release.Name }}
+    relabel_configs:
+    - source_labels: [__address__]
+      target_label: __param_target
+    - source_labels: [__param_target]
+      target_label: instance
+    - target_label: __address__
+      replacement: 127.0.0.1:9115
+  - job_name: 'blackbox-http'
+    scrape_interval: 15s
+    metrics_path: /probe
+    params:
+      module: [http_2xx]
+    static_configs:
+    - targets:
+      - {{ if .Values.enableTLSCluster }}https{{ else }}http{{ end }}://{{ $cluster }}-pd.{{ .Release.Namespace }}:2379/pd/health
+      - {{ if .Values.enableTLSCluster }}https{{ else }}http{{ end }}://{{ $cluster }}-tidb-peer.{{ .Release.Namespace }}:10080/status
+      labels:
+        cluster: {{ .Release.Name }}
+    relabel_configs:
+    - source_labels: [__address__]
+      target_label: __param_target
+    - source_labels: [__param_target]
+      target_label: instance
+    - target_label: __address__
+      replacement: 127.0.0.1:9115
+{{- end }}
+{{- if eq $shard 0 }}
+{{- if gt $shards 1 }}
+# the series scraped by the other shards are read from them, so grafana and the rules of the first
+# shard see the whole cluster
+remote_read:
+{{- range $i := untilStep 1 $shards 1 }}
+  - url: http://{{ $cluster }}-prometheus-shard-{{ $i }}.{{ $.Release.Namespace }}:9090/api/v1/read
+    read_recent: true
+{{- end }}
+{{- end }}
 rule_files:
   - '/prometheus-rules/rules/*.rules.yml'
+{{- end }}
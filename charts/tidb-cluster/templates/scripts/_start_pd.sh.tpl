@@ -33,6 +33,11 @@ POD_NAME=${POD_NAME:-$HOSTNAME}
 # the general form of variable PEER_SERVICE_NAME is: "<clusterName>-pd-peer"
 cluster_name=`echo ${PEER_SERVICE_NAME} | sed 's/-pd-peer//'`
 domain="${POD_NAME}.${PEER_SERVICE_NAME}.${NAMESPACE}.svc"
+# the FQDN under the cluster domain is resolvable from the other Kubernetes clusters
+if [[ -n "${CLUSTER_DOMAIN:-}" ]]
+then
+    domain="${domain}.${CLUSTER_DOMAIN}"
+fi
 discovery_url="${cluster_name}-discovery.${NAMESPACE}.svc:10261"
 encoded_domain_url=`echo ${domain}:2380 | base64 | tr "\n" " " | sed "s/ //g"`
 
@@ -69,7 +74,11 @@ ARGS="--data-dir=/var/lib/pd \
 --config=/etc/pd/pd.toml \
 "
 
-if [[ -f /var/lib/pd/join ]]
+if [[ "${PD_FORCE_NEW_CLUSTER:-}" == "true" && "${POD_NAME}" == "${SET_NAME}-0" && -d /var/lib/pd/member/wal ]]
+then
+    # the recovery mode: the first member becomes a single member cluster from its own data
+    ARGS="${ARGS} --force-new-cluster"
+elif [[ -f /var/lib/pd/join ]]
 then
     # The content of the join file is:
     #   demo-pd-0=http://demo-pd-0.demo-pd-peer.demo.svc:2380,demo-pd-1=http://demo-pd-1.demo-pd-peer.demo.svc:2380
@@ -87,6 +96,12 @@ then
     ARGS="${ARGS}${result}"
 fi
 
+if [[ "${PD_MODE:-}" == "ms" ]]
+then
+    # the tso and scheduling services run in their own members, pd serves the api only
+    ARGS="services api ${ARGS}"
+fi
+
 echo "starting pd-server ..."
 sleep $((RANDOM % 10))
 echo "/pd-server ${ARGS}"
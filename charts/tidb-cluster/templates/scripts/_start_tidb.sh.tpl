@@ -26,12 +26,29 @@ then
     tail -f /dev/null
 fi
 
+pd_url="${CLUSTER_NAME}-pd:2379"
+if [[ X${ACROSS_K8S:-} == Xtrue ]]
+then
+    # the pd members may be in the other Kubernetes clusters
+    discovery_url="${CLUSTER_NAME}-discovery:10261"
+    until pd_url=$(wget -qO- -T 3 http://${discovery_url}/pdaddr/${CLUSTER_NAME} 2>/dev/null); do
+        echo "waiting for discovery service to return pd addresses ..."
+        sleep $((RANDOM % 5))
+    done
+fi
+
 ARGS="--store=tikv \
 --host=0.0.0.0 \
---path=${CLUSTER_NAME}-pd:2379 \
+--path=${pd_url} \
 --config=/etc/tidb/tidb.toml
 "
 
+if [[ -n "${CLUSTER_DOMAIN:-}" ]]
+then
+    # the FQDN under the cluster domain is resolvable from the other Kubernetes clusters
+    ARGS="${ARGS} --advertise-address=${HOSTNAME}.${CLUSTER_NAME}-tidb-peer.${NAMESPACE}.svc.${CLUSTER_DOMAIN}"
+fi
+
 if [[ X${BINLOG_ENABLED:-} == Xtrue ]]
 then
     ARGS="${ARGS} --enable-binlog=true"
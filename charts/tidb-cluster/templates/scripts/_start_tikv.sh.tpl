@@ -32,8 +32,23 @@ SCHEME={{ if .Values.enableTLSCluster }}"https"{{ else }}"http"{{ end }}
 
 # Use HOSTNAME if POD_NAME is unset for backward compatibility.
 POD_NAME=${POD_NAME:-$HOSTNAME}
-ARGS="--pd=${SCHEME}://${CLUSTER_NAME}-pd:2379 \
---advertise-addr=${POD_NAME}.${HEADLESS_SERVICE_NAME}.${NAMESPACE}.svc:20160 \
+pd_url="${SCHEME}://${CLUSTER_NAME}-pd:2379"
+if [[ X${ACROSS_K8S:-} == Xtrue ]]
+then
+    # the pd members may be in the other Kubernetes clusters
+    discovery_url="${CLUSTER_NAME}-discovery.${NAMESPACE}.svc:10261"
+    until pd_url=$(wget -qO- -T 3 http://${discovery_url}/pdaddr/${CLUSTER_NAME} 2>/dev/null); do
+        echo "waiting for discovery service to return pd addresses ..."
+        sleep $((RANDOM % 5))
+    done
+fi
+domain="${POD_NAME}.${HEADLESS_SERVICE_NAME}.${NAMESPACE}.svc"
+if [[ -n "${CLUSTER_DOMAIN:-}" ]]
+then
+    domain="${domain}.${CLUSTER_DOMAIN}"
+fi
+ARGS="--pd=${pd_url} \
+--advertise-addr=${domain}:20160 \
 --addr=0.0.0.0:20160 \
 --status-addr=0.0.0.0:20180 \
 --data-dir=/var/lib/tikv \
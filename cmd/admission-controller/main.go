@@ -19,8 +19,8 @@ import (
 	"os/signal"
 	"syscall"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	"github.com/pingcap/tidb-operator/pkg/version"
 	"github.com/pingcap/tidb-operator/pkg/webhook"
 	"k8s.io/apiserver/pkg/util/logs"
@@ -39,11 +39,17 @@ func init() {
 	flag.BoolVar(&printVersion, "version", false, "Show version and quit")
 	flag.StringVar(&certFile, "tlsCertFile", "/etc/webhook/certs/cert.pem", "File containing the x509 Certificate for HTTPS.")
 	flag.StringVar(&keyFile, "tlsKeyFile", "/etc/webhook/certs/key.pem", "File containing the x509 private key to --tlsCertFile.")
+	log.AddFlags(flag.CommandLine)
+
 	flag.Parse()
 }
 
 func main() {
 
+	if err := log.Init(flag.CommandLine); err != nil {
+		log.Fatalf("failed to init the logger: %v", err)
+	}
+	defer log.Flush()
 	logs.InitLogs()
 	defer logs.FlushLogs()
 
@@ -55,17 +61,17 @@ func main() {
 
 	cfg, err := rest.InClusterConfig()
 	if err != nil {
-		glog.Fatalf("failed to get config: %v", err)
+		log.Fatalf("failed to get config: %v", err)
 	}
 
 	cli, err := versioned.NewForConfig(cfg)
 	if err != nil {
-		glog.Fatalf("failed to create Clientset: %v", err)
+		log.Fatalf("failed to create Clientset: %v", err)
 	}
 
 	kubeCli, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
-		glog.Fatalf("failed to get kubernetes Clientset: %v", err)
+		log.Fatalf("failed to get kubernetes Clientset: %v", err)
 	}
 
 	webhookServer := webhook.NewWebHookServer(kubeCli, cli, certFile, keyFile)
@@ -80,17 +86,17 @@ func main() {
 
 		// Graceful shutdown the server
 		if err := webhookServer.Shutdown(); err != nil {
-			glog.Errorf("fail to shutdown server %v", err)
+			log.Errorf("fail to shutdown server %v", err)
 		}
 
 		done <- true
 	}()
 
 	if err := webhookServer.Run(); err != nil {
-		glog.Errorf("stop http server %v", err)
+		log.Errorf("stop http server %v", err)
 	}
 
 	<-done
 
-	glog.Infof("webhook server terminate safely.")
+	log.Infof("webhook server terminate safely.")
 }
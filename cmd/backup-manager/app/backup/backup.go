@@ -15,18 +15,22 @@ package backup
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/golang/glog"
 	"github.com/mholt/archiver"
 	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/constants"
 	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/util"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/log"
 )
 
 // BackupOpts contains the input arguments to the backup command
@@ -38,6 +42,9 @@ type BackupOpts struct {
 	User        string
 	StorageType string
 	BackupName  string
+	// bucketPrefix is the bucket and path prefix of the backup data in the
+	// backend storage, it is read from the Backup object rather than flags
+	bucketPrefix string
 }
 
 func (bo *BackupOpts) String() string {
@@ -53,6 +60,24 @@ func (bo *BackupOpts) getBackupRelativePath() string {
 	return fmt.Sprintf("%s_%s/%s", bo.Namespace, bo.TcName, backupName)
 }
 
+// getRemotePath returns the path of the backup data in the backend storage
+func (bo *BackupOpts) getRemotePath(relativePath string) string {
+	return path.Join(bo.bucketPrefix, relativePath)
+}
+
+// getBucketPrefix returns the bucket and path prefix of the backup data in a
+// storage, the backup data in ceph is stored in the bucket named by the cluster
+func getBucketPrefix(storageType v1alpha1.BackupStorageType, provider *v1alpha1.StorageProvider) string {
+	switch {
+	case storageType == v1alpha1.BackupStorageTypeS3 && provider.S3 != nil:
+		return path.Join(provider.S3.Bucket, provider.S3.Prefix)
+	case storageType == v1alpha1.BackupStorageTypeGCS && provider.GCS != nil:
+		return path.Join(provider.GCS.Bucket, provider.GCS.Prefix)
+	default:
+		return ""
+	}
+}
+
 func (bo *BackupOpts) getDestBucketURI(remotePath string) string {
 	return fmt.Sprintf("%s://%s", bo.StorageType, remotePath)
 }
@@ -98,7 +123,7 @@ func (bo *BackupOpts) dumpTidbClusterData() (string, error) {
 
 	output, err := exec.Command("/mydumper", args...).CombinedOutput()
 	if err != nil {
-		return bfPath, fmt.Errorf("cluster %s, execute mydumper command %v failed, output: %s, err: %v", bo, args, string(output), err)
+		return bfPath, fmt.Errorf("cluster %s, execute mydumper command failed, output: %s, err: %v", bo, string(output), err)
 	}
 	return bfPath, nil
 }
@@ -112,7 +137,7 @@ func (bo *BackupOpts) backupDataToRemote(source, bucketURI string) error {
 		return fmt.Errorf("cluster %s, execute rclone copyto command for upload backup data %s failed, output: %s, err: %v", bo, bucketURI, string(output), err)
 	}
 
-	glog.Infof("upload cluster %s backup data to %s successfully, now move it to permanent URL %s", bo, tmpDestBucket, destBucket)
+	log.Infof("upload cluster %s backup data to %s successfully, now move it to permanent URL %s", bo, tmpDestBucket, destBucket)
 
 	// the backup was a success
 	// remove .tmp extension
@@ -130,10 +155,120 @@ func (bo *BackupOpts) cleanRemoteBackupData(bucket string) error {
 		return fmt.Errorf("cluster %s, execute rclone deletefile command failed, output: %s, err: %v", bo, string(output), err)
 	}
 
-	glog.Infof("cluster %s backup %s was deleted successfully", bo, bucket)
+	log.Infof("cluster %s backup %s was deleted successfully", bo, bucket)
+	return nil
+}
+
+func (bo *BackupOpts) cleanRemoteBackupDir(bucket string) error {
+	destBucket := util.NormalizeBucketURI(bucket)
+	output, err := exec.Command("rclone", constants.RcloneConfigArg, "purge", destBucket).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("cluster %s, execute rclone purge command failed, output: %s, err: %v", bo, string(output), err)
+	}
+
+	log.Infof("cluster %s backup %s was deleted successfully", bo, bucket)
+	return nil
+}
+
+// getCurrentTS get the current tso of tidb cluster from the output of SHOW MASTER STATUS
+func (bo *BackupOpts) getCurrentTS(db *sql.DB) (string, error) {
+	var file, position, doDB, ignoreDB, gtid string
+	row := db.QueryRow("SHOW MASTER STATUS")
+	err := row.Scan(&file, &position, &doDB, &ignoreDB, &gtid)
+	if err != nil {
+		return position, fmt.Errorf("query cluster %s current ts failed, err: %v", bo, err)
+	}
+	return position, nil
+}
+
+func (bo *BackupOpts) getPDAddress() string {
+	return fmt.Sprintf("%s:%d", controller.PDMemberName(bo.TcName), constants.PDPort)
+}
+
+// backupDataByBR takes a snapshot of tidb cluster at backupTS by BR and
+// uploads it to remotePath directly, it returns whether the backup data is
+// verified by checksum
+func (bo *BackupOpts) backupDataByBR(remotePath, backupTS string, br *v1alpha1.BRConfig, checksum bool, filters []string, run commandRunner) (bool, error) {
+	args := []string{
+		"backup",
+		"full",
+		fmt.Sprintf("--pd=%s", bo.getPDAddress()),
+		fmt.Sprintf("--storage=%s", bo.getStorageURI(remotePath)),
+		fmt.Sprintf("--backupts=%s", backupTS),
+		fmt.Sprintf("--checksum=%t", checksum),
+	}
+	if br.LastBackupTS != "" {
+		args = append(args, fmt.Sprintf("--lastbackupts=%s", br.LastBackupTS))
+	}
+	for _, filter := range filters {
+		args = append(args, fmt.Sprintf("--filter=%s", filter))
+	}
+	args = append(args, bo.getStorageArgs()...)
+	crypterArgs, err := util.GetCrypterArgs(constants.CrypterKeyFile)
+	if err != nil {
+		return false, fmt.Errorf("cluster %s, %v", bo, err)
+	}
+	args = append(args, crypterArgs...)
+
+	output, err := run(exec.Command("/br", args...))
+	if err != nil {
+		return false, fmt.Errorf("cluster %s, execute br command failed, output: %s, err: %v", bo, string(output), err)
+	}
+	return util.IsChecksumVerified(checksum, output), nil
+}
+
+// exportDataByDumpling exports the data of tidb cluster at snapshot by dumpling
+// and uploads the exported files to remotePath directly
+func (bo *BackupOpts) exportDataByDumpling(remotePath, snapshot string, dumpling *v1alpha1.DumplingConfig, filters []string, run commandRunner) error {
+	fileType := dumpling.FileType
+	if fileType == "" {
+		fileType = v1alpha1.DumplingFileTypeSQL
+	}
+	var threads int32 = constants.DefaultDumplingThreads
+	if dumpling.Threads != nil {
+		threads = *dumpling.Threads
+	}
+	// dumpling only accepts the password by flag, so the args must not be
+	// logged or reported in the error, which is recorded in backup's status
+	args := []string{
+		fmt.Sprintf("--host=%s", bo.TidbSvc),
+		"--port=4000",
+		fmt.Sprintf("--user=%s", bo.User),
+		fmt.Sprintf("--password=%s", bo.Password),
+		fmt.Sprintf("--output=%s", bo.getStorageURI(remotePath)),
+		fmt.Sprintf("--filetype=%s", fileType),
+		fmt.Sprintf("--threads=%d", threads),
+		fmt.Sprintf("--snapshot=%s", snapshot),
+	}
+	args = append(args, bo.getStorageArgs()...)
+	if dumpling.Compress != "" {
+		args = append(args, fmt.Sprintf("--compress=%s", dumpling.Compress))
+	}
+	if len(filters) == 0 {
+		filters = constants.DefaultDumplingTableFilter
+	}
+	for _, filter := range filters {
+		args = append(args, fmt.Sprintf("--filter=%s", filter))
+	}
+
+	output, err := run(exec.Command("/dumpling", args...))
+	if err != nil {
+		return fmt.Errorf("cluster %s, execute dumpling command failed, output: %s, err: %v", bo, string(output), err)
+	}
 	return nil
 }
 
+// getStorageURI returns the URI of remotePath used by br and dumpling, ceph is
+// accessed as a S3 compatible storage
+func (bo *BackupOpts) getStorageURI(remotePath string) string {
+	return util.GetStorageURI(bo.StorageType, remotePath)
+}
+
+// getStorageArgs returns the args of br and dumpling to access the backend storage
+func (bo *BackupOpts) getStorageArgs() []string {
+	return util.GetStorageArgs(bo.StorageType)
+}
+
 func (bo *BackupOpts) getDSN(db string) string {
 	return fmt.Sprintf("%s:%s@(%s:4000)/%s?charset=utf8", bo.User, bo.Password, bo.TidbSvc, db)
 }
@@ -195,6 +330,23 @@ func getBackupSize(backupPath string) (int64, error) {
 	return size, nil
 }
 
+// getRemoteBackupSize get the total size of the backup data in backend storage
+func getRemoteBackupSize(bucketURI string) (int64, error) {
+	var size struct {
+		Count int64 `json:"count"`
+		Bytes int64 `json:"bytes"`
+	}
+	remoteBucket := util.NormalizeBucketURI(bucketURI)
+	out, err := exec.Command("rclone", constants.RcloneConfigArg, "size", "--json", remoteBucket).CombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get backup %s size, output: %s, err: %v", bucketURI, string(out), err)
+	}
+	if err := json.Unmarshal(out, &size); err != nil {
+		return 0, fmt.Errorf("failed to parse backup %s size %s, err: %v", bucketURI, string(out), err)
+	}
+	return size.Bytes, nil
+}
+
 // archiveBackupData archive backup data by destFile's extension name
 func archiveBackupData(backupDir, destFile string) error {
 	if exist := util.IsDirExist(backupDir); !exist {
@@ -0,0 +1,301 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/constants"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// logBackupTaskStatus is the status of a log backup task printed by `br log status --json`
+type logBackupTaskStatus struct {
+	Name       string `json:"name"`
+	Checkpoint uint64 `json:"checkpoint"`
+}
+
+// getLogBackupRelativePath returns the relative path of the log backup data,
+// the path keeps the same for all the commands of the same log backup task
+func (bo *BackupOpts) getLogBackupRelativePath() string {
+	return fmt.Sprintf("%s_%s/log-%s", bo.Namespace, bo.TcName, bo.BackupName)
+}
+
+func (bm *BackupManager) performLogBackup(backup *v1alpha1.Backup, db *sql.DB) error {
+	switch backup.GetLogBackupCommand() {
+	case v1alpha1.LogBackupCommandStop:
+		return bm.stopLogBackup(backup)
+	case v1alpha1.LogBackupCommandTruncate:
+		return bm.truncateLogBackup(backup)
+	default:
+		return bm.startLogBackup(backup, db)
+	}
+}
+
+func (bm *BackupManager) startLogBackup(backup *v1alpha1.Backup, db *sql.DB) error {
+	remotePath := bm.getRemotePath(bm.getLogBackupRelativePath())
+	bucketURI := bm.getDestBucketURI(remotePath)
+
+	task, err := bm.getLogBackupTask()
+	if err != nil {
+		log.Errorf("get cluster %s log backup task failed, err: %s", bm, err)
+		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "GetLogBackupTaskFailed",
+			Message: err.Error(),
+		})
+	}
+	if task != nil {
+		// the task was started by the former job, which is restarted or recreated
+		// to refresh the status, so only the status of the task is reported
+		log.Infof("cluster %s log backup task %s is running, resume reporting its status", bm, bm.BackupName)
+		logBackup := &v1alpha1.LogBackupStatus{}
+		if backup.Status.LogBackup != nil {
+			logBackup = backup.Status.LogBackup.DeepCopy()
+		}
+		if logBackup.StartTs == "" {
+			logBackup.StartTs = strconv.FormatUint(task.Checkpoint, 10)
+		}
+		if backup.Status.BackupPath == "" {
+			backup.Status.BackupPath = bucketURI
+			backup.Status.TimeStarted = metav1.Now()
+			backup.Status.LogBackup = logBackup
+			err := bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+				Type:   v1alpha1.BackupRunning,
+				Status: corev1.ConditionTrue,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		bm.reportLogBackupStatus(backup, bucketURI, logBackup)
+		return nil
+	}
+
+	startTS := backup.Spec.LogBackup.StartTS
+	if backup.Status.LogBackup != nil && backup.Status.LogBackup.CheckpointTs != "" {
+		// resume the task from the last checkpoint, so that there is no gap in the log data
+		startTS = backup.Status.LogBackup.CheckpointTs
+	}
+	if startTS == "" {
+		ts, err := bm.getCurrentTS(db)
+		if err != nil {
+			log.Errorf("get cluster %s current ts failed, err: %s", bm, err)
+			return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+				Type:    v1alpha1.BackupFailed,
+				Status:  corev1.ConditionTrue,
+				Reason:  "GetCommitTsFailed",
+				Message: err.Error(),
+			})
+		}
+		startTS = ts
+	}
+
+	args := []string{
+		"log",
+		"start",
+		fmt.Sprintf("--task-name=%s", bm.BackupName),
+		fmt.Sprintf("--pd=%s", bm.getPDAddress()),
+		fmt.Sprintf("--storage=%s", bm.getStorageURI(remotePath)),
+		fmt.Sprintf("--start-ts=%s", startTS),
+	}
+	args = append(args, bm.getStorageArgs()...)
+	output, err := runCommand(exec.Command("/br", args...))
+	if err != nil {
+		log.Errorf("start cluster %s log backup failed, output: %s, err: %s", bm, string(output), err)
+		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "StartLogBackupFailed",
+			Message: fmt.Sprintf("cluster %s, execute br command failed, output: %s, err: %v", bm, string(output), err),
+		})
+	}
+	log.Infof("start cluster %s log backup to %s from ts %s success", bm, remotePath, startTS)
+
+	logBackup := &v1alpha1.LogBackupStatus{
+		StartTs:        startTS,
+		LastUpdateTime: metav1.Now(),
+	}
+	if backup.Status.LogBackup != nil {
+		logBackup.TruncateUntil = backup.Status.LogBackup.TruncateUntil
+	}
+	backup.Status.BackupPath = bucketURI
+	backup.Status.TimeStarted = metav1.Now()
+	backup.Status.LogBackup = logBackup
+	err = bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+		Type:   v1alpha1.BackupRunning,
+		Status: corev1.ConditionTrue,
+	})
+	if err != nil {
+		return err
+	}
+
+	bm.reportLogBackupStatus(backup, bucketURI, logBackup)
+	return nil
+}
+
+// reportLogBackupStatus reports the checkpoint of the task for LogBackupStatusReportDuration.
+// The task keeps running in TiKV after the job completes, the controller recreates
+// the job periodically to refresh the status.
+func (bm *BackupManager) reportLogBackupStatus(backup *v1alpha1.Backup, bucketURI string, logBackup *v1alpha1.LogBackupStatus) {
+	size := backup.Status.BackupSize
+	ticker := time.NewTicker(constants.ProgressReportInterval)
+	defer ticker.Stop()
+	timeout := time.After(constants.LogBackupStatusReportDuration)
+	for {
+		select {
+		case <-timeout:
+			return
+		case <-ticker.C:
+		}
+		checkpointTS, err := bm.getLogBackupCheckpointTS()
+		if err != nil {
+			log.Errorf("get cluster %s log backup checkpoint failed, err: %s", bm, err)
+			continue
+		}
+		if s, err := getRemoteBackupSize(bucketURI); err != nil {
+			log.Warningf("get cluster %s log backup %s size failed, err: %s", bm, bucketURI, err)
+		} else {
+			size = s
+		}
+		logBackup.CheckpointTs = checkpointTS
+		logBackup.LastUpdateTime = metav1.Now()
+		if err := bm.ProgressUpdater.UpdateLogBackup(backup, logBackup, size); err != nil {
+			log.Errorf("update cluster %s log backup status failed, err: %s", bm, err)
+		}
+	}
+}
+
+// getLogBackupTask returns the status of the log backup task, it returns nil
+// if the task is not found in the cluster
+func (bo *BackupOpts) getLogBackupTask() (*logBackupTaskStatus, error) {
+	args := []string{
+		"log",
+		"status",
+		fmt.Sprintf("--task-name=%s", bo.BackupName),
+		fmt.Sprintf("--pd=%s", bo.getPDAddress()),
+		"--json",
+	}
+	output, err := exec.Command("/br", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("cluster %s, execute br command failed, output: %s, err: %v", bo, string(output), err)
+	}
+	return parseLogBackupTask(output, bo.BackupName)
+}
+
+// parseLogBackupTask returns the task of the name in the output of `br log status --json`
+func parseLogBackupTask(output []byte, name string) (*logBackupTaskStatus, error) {
+	var tasks []logBackupTaskStatus
+	if err := json.Unmarshal(output, &tasks); err != nil {
+		return nil, fmt.Errorf("parse log backup status %s failed, err: %v", string(output), err)
+	}
+	for i := range tasks {
+		if tasks[i].Name == name {
+			return &tasks[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (bo *BackupOpts) getLogBackupCheckpointTS() (string, error) {
+	task, err := bo.getLogBackupTask()
+	if err != nil {
+		return "", err
+	}
+	if task == nil {
+		return "", fmt.Errorf("cluster %s, log backup task %s is not found", bo, bo.BackupName)
+	}
+	return strconv.FormatUint(task.Checkpoint, 10), nil
+}
+
+// stopLogBackupTask stops the log backup task in the tidb cluster
+func (bo *BackupOpts) stopLogBackupTask() error {
+	args := []string{
+		"log",
+		"stop",
+		fmt.Sprintf("--task-name=%s", bo.BackupName),
+		fmt.Sprintf("--pd=%s", bo.getPDAddress()),
+	}
+	output, err := runCommand(exec.Command("/br", args...))
+	if err != nil {
+		return fmt.Errorf("cluster %s, execute br command failed, output: %s, err: %v", bo, string(output), err)
+	}
+	return nil
+}
+
+func (bm *BackupManager) stopLogBackup(backup *v1alpha1.Backup) error {
+	if err := bm.stopLogBackupTask(); err != nil {
+		log.Errorf("stop cluster %s log backup failed, err: %s", bm, err)
+		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "StopLogBackupFailed",
+			Message: err.Error(),
+		})
+	}
+	log.Infof("stop cluster %s log backup success", bm)
+
+	return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+		Type:   v1alpha1.BackupRunning,
+		Status: corev1.ConditionFalse,
+		Reason: "LogBackupStopped",
+	})
+}
+
+func (bm *BackupManager) truncateLogBackup(backup *v1alpha1.Backup) error {
+	truncateUntil := backup.Spec.LogBackup.TruncateUntil
+	remotePath := bm.getRemotePath(bm.getLogBackupRelativePath())
+	bucketURI := bm.getDestBucketURI(remotePath)
+	args := []string{
+		"log",
+		"truncate",
+		fmt.Sprintf("--until=%s", truncateUntil),
+		fmt.Sprintf("--storage=%s", bm.getStorageURI(remotePath)),
+		"-y",
+	}
+	args = append(args, bm.getStorageArgs()...)
+	output, err := runCommand(exec.Command("/br", args...))
+	if err != nil {
+		log.Errorf("truncate cluster %s log backup failed, output: %s, err: %s", bm, string(output), err)
+		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "TruncateLogBackupFailed",
+			Message: fmt.Sprintf("cluster %s, execute br command failed, output: %s, err: %v", bm, string(output), err),
+		})
+	}
+	log.Infof("truncate cluster %s log backup %s until %s success", bm, remotePath, truncateUntil)
+
+	size, err := getRemoteBackupSize(bucketURI)
+	if err != nil {
+		log.Warningf("get cluster %s log backup %s size failed, err: %s", bm, bucketURI, err)
+		size = backup.Status.BackupSize
+	}
+	logBackup := &v1alpha1.LogBackupStatus{}
+	if backup.Status.LogBackup != nil {
+		logBackup = backup.Status.LogBackup.DeepCopy()
+	}
+	logBackup.TruncateUntil = truncateUntil
+	logBackup.LastUpdateTime = metav1.Now()
+	return bm.ProgressUpdater.UpdateLogBackup(backup, logBackup, size)
+}
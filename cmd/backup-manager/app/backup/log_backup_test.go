@@ -0,0 +1,74 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParseLogBackupTask(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name             string
+		output           string
+		expectErr        bool
+		expectFound      bool
+		expectCheckpoint uint64
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		task, err := parseLogBackupTask([]byte(test.output), "log-backup")
+		if test.expectErr {
+			g.Expect(err).To(HaveOccurred())
+			return
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+		if !test.expectFound {
+			g.Expect(task).To(BeNil())
+			return
+		}
+		g.Expect(task).NotTo(BeNil())
+		g.Expect(task.Checkpoint).To(Equal(test.expectCheckpoint))
+	}
+
+	tests := []testcase{
+		{
+			name:             "task is running",
+			output:           `[{"name":"other","checkpoint":300},{"name":"log-backup","checkpoint":400}]`,
+			expectFound:      true,
+			expectCheckpoint: 400,
+		},
+		{
+			name:   "task is not found",
+			output: `[{"name":"other","checkpoint":300}]`,
+		},
+		{
+			name:   "no task",
+			output: `[]`,
+		},
+		{
+			name:      "invalid output",
+			output:    `no task`,
+			expectErr: true,
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
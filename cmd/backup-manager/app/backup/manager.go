@@ -16,23 +16,30 @@ package backup
 import (
 	"database/sql"
 	"fmt"
+	"os"
+	"path"
 	"strings"
 	"time"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/constants"
 	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/util"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
 	listers "github.com/pingcap/tidb-operator/pkg/client/listers/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
 )
 
 // BackupManager mainly used to manage backup related work
 type BackupManager struct {
-	backupLister  listers.BackupLister
-	StatusUpdater controller.BackupConditionUpdaterInterface
+	backupLister    listers.BackupLister
+	StatusUpdater   controller.BackupConditionUpdaterInterface
+	ProgressUpdater controller.BackupProgressUpdaterInterface
+	cli             versioned.Interface
+	dynamicCli      dynamic.Interface
 	BackupOpts
 }
 
@@ -40,10 +47,16 @@ type BackupManager struct {
 func NewBackupManager(
 	backupLister listers.BackupLister,
 	statusUpdater controller.BackupConditionUpdaterInterface,
+	progressUpdater controller.BackupProgressUpdaterInterface,
+	cli versioned.Interface,
+	dynamicCli dynamic.Interface,
 	backupOpts BackupOpts) *BackupManager {
 	return &BackupManager{
 		backupLister,
 		statusUpdater,
+		progressUpdater,
+		cli,
+		dynamicCli,
 		backupOpts,
 	}
 }
@@ -65,9 +78,211 @@ func (bm *BackupManager) ProcessBackup() error {
 		})
 	}
 	defer db.Close()
+	bm.bucketPrefix = getBucketPrefix(backup.Spec.StorageType, &backup.Spec.StorageProvider)
+	if backup.Spec.LogBackup != nil {
+		return bm.performLogBackup(backup.DeepCopy(), db)
+	}
+	if backup.Spec.VolumeSnapshot != nil {
+		return bm.performVolumeSnapshotBackup(backup.DeepCopy(), db)
+	}
+	if backup.Spec.BR != nil {
+		return bm.performBRBackup(backup.DeepCopy(), db)
+	}
+	if backup.Spec.Dumpling != nil {
+		return bm.performDumplingBackup(backup.DeepCopy(), db)
+	}
 	return bm.performBackup(backup.DeepCopy(), db)
 }
 
+func (bm *BackupManager) performBRBackup(backup *v1alpha1.Backup, db *sql.DB) error {
+	started := time.Now()
+
+	err := bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+		Type:   v1alpha1.BackupRunning,
+		Status: corev1.ConditionTrue,
+	})
+	if err != nil {
+		return err
+	}
+
+	commitTs, err := bm.getCurrentTS(db)
+	if err != nil {
+		log.Errorf("get cluster %s commitTs failed, err: %s", bm, err)
+		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "GetCommitTsFailed",
+			Message: err.Error(),
+		})
+	}
+	log.Infof("get cluster %s commitTs %s success", bm, commitTs)
+
+	remotePath := bm.getRemotePath(bm.getBackupRelativePath())
+	bucketURI := bm.getDestBucketURI(remotePath)
+	// record the backup path before taking the backup, so that the data of
+	// a failed backup can also be cleaned up
+	backup.Status.BackupPath = bucketURI
+	totalBytes, err := bm.getTotalDataSize(db)
+	if err != nil {
+		// the progress is reported by br, the total data size is only used as a fallback
+		log.Warningf("get cluster %s data size failed, err: %s", bm, err)
+	}
+	checksumVerified, err := bm.backupDataByBR(remotePath, commitTs, backup.Spec.BR, backup.IsChecksumEnabled(), backup.GetTableFilter(), bm.progressRunner(backup, bucketURI, totalBytes))
+	if err != nil {
+		log.Errorf("backup cluster %s data by br failed, err: %s", bm, err)
+		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "BackupDataByBRFailed",
+			Message: err.Error(),
+		})
+	}
+	log.Infof("backup cluster %s data to %s by br success", bm, remotePath)
+
+	size, err := getRemoteBackupSize(bucketURI)
+	if err != nil {
+		log.Errorf("get cluster %s backup %s size failed, err: %s", bm, bucketURI, err)
+		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "GetBackupSizeFailed",
+			Message: err.Error(),
+		})
+	}
+	log.Infof("get cluster %s backup %s size %d success", bm, bucketURI, size)
+
+	finish := time.Now()
+
+	backup.Status.BackupPath = bucketURI
+	backup.Status.TimeStarted = metav1.Time{Time: started}
+	backup.Status.TimeCompleted = metav1.Time{Time: finish}
+	backup.Status.BackupSize = size
+	backup.Status.CommitTs = commitTs
+	backup.Status.ChecksumVerified = checksumVerified
+	backup.Status.Progress = &v1alpha1.BackupProgress{
+		Percentage:     100,
+		BackedUpBytes:  size,
+		LastUpdateTime: metav1.Time{Time: finish},
+	}
+
+	return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+		Type:   v1alpha1.BackupComplete,
+		Status: corev1.ConditionTrue,
+	})
+}
+
+func (bm *BackupManager) performDumplingBackup(backup *v1alpha1.Backup, db *sql.DB) error {
+	started := time.Now()
+
+	err := bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+		Type:   v1alpha1.BackupRunning,
+		Status: corev1.ConditionTrue,
+	})
+	if err != nil {
+		return err
+	}
+
+	oldTikvGCTime, err := bm.getTikvGCLifeTime(db)
+	if err != nil {
+		log.Errorf("cluster %s get %s failed, err: %s", bm, constants.TikvGCVariable, err)
+		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "GetTikvGCLifeTimeFailed",
+			Message: err.Error(),
+		})
+	}
+	log.Infof("cluster %s %s is %s", bm, constants.TikvGCVariable, oldTikvGCTime)
+
+	err = bm.setTikvGCLifeTime(db, constants.TikvGCLifeTime)
+	if err != nil {
+		log.Errorf("cluster %s set tikv GC life time to %s failed, err: %s", bm, constants.TikvGCLifeTime, err)
+		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "SetTikvGCLifeTimeFailed",
+			Message: err.Error(),
+		})
+	}
+	log.Infof("set cluster %s %s to %s success", bm, constants.TikvGCVariable, constants.TikvGCLifeTime)
+
+	commitTs, err := bm.getCurrentTS(db)
+	if err != nil {
+		log.Errorf("get cluster %s commitTs failed, err: %s", bm, err)
+		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "GetCommitTsFailed",
+			Message: err.Error(),
+		})
+	}
+	log.Infof("get cluster %s commitTs %s success", bm, commitTs)
+
+	remotePath := bm.getRemotePath(bm.getBackupRelativePath())
+	bucketURI := bm.getDestBucketURI(remotePath)
+	// record the backup path before exporting, so that the data of a failed
+	// export can also be cleaned up
+	backup.Status.BackupPath = bucketURI
+	totalBytes, err := bm.getTotalDataSize(db)
+	if err != nil {
+		// the progress is estimated without the total data size
+		log.Warningf("get cluster %s data size failed, err: %s", bm, err)
+	}
+	err = bm.exportDataByDumpling(remotePath, commitTs, backup.Spec.Dumpling, backup.GetTableFilter(), bm.progressRunner(backup, bucketURI, totalBytes))
+	if err != nil {
+		log.Errorf("export cluster %s data by dumpling failed, err: %s", bm, err)
+		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "ExportDataByDumplingFailed",
+			Message: err.Error(),
+		})
+	}
+	log.Infof("export cluster %s data to %s by dumpling success", bm, remotePath)
+
+	err = bm.setTikvGCLifeTime(db, oldTikvGCTime)
+	if err != nil {
+		log.Errorf("cluster %s reset tikv GC life time to %s failed, err: %s", bm, oldTikvGCTime, err)
+		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "ResetTikvGCLifeTimeFailed",
+			Message: err.Error(),
+		})
+	}
+	log.Infof("reset cluster %s %s to %s success", bm, constants.TikvGCVariable, oldTikvGCTime)
+
+	size, err := getRemoteBackupSize(bucketURI)
+	if err != nil {
+		log.Errorf("get cluster %s backup %s size failed, err: %s", bm, bucketURI, err)
+		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "GetBackupSizeFailed",
+			Message: err.Error(),
+		})
+	}
+	log.Infof("get cluster %s backup %s size %d success", bm, bucketURI, size)
+
+	finish := time.Now()
+
+	backup.Status.BackupPath = bucketURI
+	backup.Status.TimeStarted = metav1.Time{Time: started}
+	backup.Status.TimeCompleted = metav1.Time{Time: finish}
+	backup.Status.BackupSize = size
+	backup.Status.CommitTs = commitTs
+	backup.Status.Progress = &v1alpha1.BackupProgress{
+		Percentage:     100,
+		BackedUpBytes:  size,
+		LastUpdateTime: metav1.Time{Time: finish},
+	}
+
+	return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+		Type:   v1alpha1.BackupComplete,
+		Status: corev1.ConditionTrue,
+	})
+}
+
 func (bm *BackupManager) performBackup(backup *v1alpha1.Backup, db *sql.DB) error {
 	started := time.Now()
 
@@ -81,7 +296,7 @@ func (bm *BackupManager) performBackup(backup *v1alpha1.Backup, db *sql.DB) erro
 
 	oldTikvGCTime, err := bm.getTikvGCLifeTime(db)
 	if err != nil {
-		glog.Errorf("cluster %s get %s failed, err: %s", bm, constants.TikvGCVariable, err)
+		log.Errorf("cluster %s get %s failed, err: %s", bm, constants.TikvGCVariable, err)
 		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
 			Type:    v1alpha1.BackupFailed,
 			Status:  corev1.ConditionTrue,
@@ -89,11 +304,11 @@ func (bm *BackupManager) performBackup(backup *v1alpha1.Backup, db *sql.DB) erro
 			Message: err.Error(),
 		})
 	}
-	glog.Infof("cluster %s %s is %s", bm, constants.TikvGCVariable, oldTikvGCTime)
+	log.Infof("cluster %s %s is %s", bm, constants.TikvGCVariable, oldTikvGCTime)
 
 	err = bm.setTikvGCLifeTime(db, constants.TikvGCLifeTime)
 	if err != nil {
-		glog.Errorf("cluster %s set tikv GC life time to %s failed, err: %s", bm, constants.TikvGCLifeTime, err)
+		log.Errorf("cluster %s set tikv GC life time to %s failed, err: %s", bm, constants.TikvGCLifeTime, err)
 		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
 			Type:    v1alpha1.BackupFailed,
 			Status:  corev1.ConditionTrue,
@@ -101,11 +316,11 @@ func (bm *BackupManager) performBackup(backup *v1alpha1.Backup, db *sql.DB) erro
 			Message: err.Error(),
 		})
 	}
-	glog.Infof("set cluster %s %s to %s success", bm, constants.TikvGCVariable, constants.TikvGCLifeTime)
+	log.Infof("set cluster %s %s to %s success", bm, constants.TikvGCVariable, constants.TikvGCLifeTime)
 
 	backupFullPath, err := bm.dumpTidbClusterData()
 	if err != nil {
-		glog.Errorf("dump cluster %s data failed, err: %s", bm, err)
+		log.Errorf("dump cluster %s data failed, err: %s", bm, err)
 		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
 			Type:    v1alpha1.BackupFailed,
 			Status:  corev1.ConditionTrue,
@@ -113,11 +328,11 @@ func (bm *BackupManager) performBackup(backup *v1alpha1.Backup, db *sql.DB) erro
 			Message: err.Error(),
 		})
 	}
-	glog.Infof("dump cluster %s data to %s success", bm, backupFullPath)
+	log.Infof("dump cluster %s data to %s success", bm, backupFullPath)
 
 	err = bm.setTikvGCLifeTime(db, oldTikvGCTime)
 	if err != nil {
-		glog.Errorf("cluster %s reset tikv GC life time to %s failed, err: %s", bm, oldTikvGCTime, err)
+		log.Errorf("cluster %s reset tikv GC life time to %s failed, err: %s", bm, oldTikvGCTime, err)
 		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
 			Type:    v1alpha1.BackupFailed,
 			Status:  corev1.ConditionTrue,
@@ -125,13 +340,13 @@ func (bm *BackupManager) performBackup(backup *v1alpha1.Backup, db *sql.DB) erro
 			Message: err.Error(),
 		})
 	}
-	glog.Infof("reset cluster %s %s to %s success", bm, constants.TikvGCVariable, oldTikvGCTime)
+	log.Infof("reset cluster %s %s to %s success", bm, constants.TikvGCVariable, oldTikvGCTime)
 
 	// TODO: Concurrent get file size and upload backup data to speed up processing time
 	archiveBackupPath := backupFullPath + constants.DefaultArchiveExtention
 	err = archiveBackupData(backupFullPath, archiveBackupPath)
 	if err != nil {
-		glog.Errorf("archive cluster %s backup data %s failed, err: %s", bm, archiveBackupPath, err)
+		log.Errorf("archive cluster %s backup data %s failed, err: %s", bm, archiveBackupPath, err)
 		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
 			Type:    v1alpha1.BackupFailed,
 			Status:  corev1.ConditionTrue,
@@ -139,11 +354,11 @@ func (bm *BackupManager) performBackup(backup *v1alpha1.Backup, db *sql.DB) erro
 			Message: err.Error(),
 		})
 	}
-	glog.Infof("archive cluster %s backup data %s success", bm, archiveBackupPath)
+	log.Infof("archive cluster %s backup data %s success", bm, archiveBackupPath)
 
 	size, err := getBackupSize(archiveBackupPath)
 	if err != nil {
-		glog.Errorf("get cluster %s archived backup file %s size %d failed, err: %s", bm, archiveBackupPath, size, err)
+		log.Errorf("get cluster %s archived backup file %s size %d failed, err: %s", bm, archiveBackupPath, size, err)
 		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
 			Type:    v1alpha1.BackupFailed,
 			Status:  corev1.ConditionTrue,
@@ -151,11 +366,11 @@ func (bm *BackupManager) performBackup(backup *v1alpha1.Backup, db *sql.DB) erro
 			Message: err.Error(),
 		})
 	}
-	glog.Infof("get cluster %s archived backup file %s size %d success", bm, archiveBackupPath, size)
+	log.Infof("get cluster %s archived backup file %s size %d success", bm, archiveBackupPath, size)
 
 	commitTs, err := getCommitTsFromMetadata(backupFullPath)
 	if err != nil {
-		glog.Errorf("get cluster %s commitTs failed, err: %s", bm, err)
+		log.Errorf("get cluster %s commitTs failed, err: %s", bm, err)
 		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
 			Type:    v1alpha1.BackupFailed,
 			Status:  corev1.ConditionTrue,
@@ -163,21 +378,39 @@ func (bm *BackupManager) performBackup(backup *v1alpha1.Backup, db *sql.DB) erro
 			Message: err.Error(),
 		})
 	}
-	glog.Infof("get cluster %s commitTs %s success", bm, commitTs)
+	log.Infof("get cluster %s commitTs %s success", bm, commitTs)
 
-	remotePath := strings.TrimPrefix(archiveBackupPath, constants.BackupRootPath+"/")
-	bucketURI := bm.getDestBucketURI(remotePath)
-	err = bm.backupDataToRemote(archiveBackupPath, bucketURI)
-	if err != nil {
-		glog.Errorf("backup cluster %s data to %s failed, err: %s", bm, bm.StorageType, err)
-		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
-			Type:    v1alpha1.BackupFailed,
-			Status:  corev1.ConditionTrue,
-			Reason:  "BackupDataToRemoteFailed",
-			Message: err.Error(),
-		})
+	relativePath := strings.TrimPrefix(archiveBackupPath, constants.BackupRootPath+"/")
+	var bucketURI string
+	if backup.Spec.StorageType == v1alpha1.BackupStorageTypePVC {
+		// the archive is kept in the pvc of the backup job, the dumped files are
+		// removed to save the space of the pvc
+		if err := os.RemoveAll(backupFullPath); err != nil {
+			log.Errorf("remove cluster %s dumped data %s failed, err: %s", bm, backupFullPath, err)
+			return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+				Type:    v1alpha1.BackupFailed,
+				Status:  corev1.ConditionTrue,
+				Reason:  "RemoveDumpedDataFailed",
+				Message: err.Error(),
+			})
+		}
+		backup.Status.BackupPVC = backup.GetBackupPVCName()
+		bucketURI = bm.getDestBucketURI(path.Join(backup.Status.BackupPVC, relativePath))
+		log.Infof("keep cluster %s backup data in pvc %s", bm, backup.Status.BackupPVC)
+	} else {
+		bucketURI = bm.getDestBucketURI(bm.getRemotePath(relativePath))
+		err = bm.backupDataToRemote(archiveBackupPath, bucketURI)
+		if err != nil {
+			log.Errorf("backup cluster %s data to %s failed, err: %s", bm, bm.StorageType, err)
+			return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+				Type:    v1alpha1.BackupFailed,
+				Status:  corev1.ConditionTrue,
+				Reason:  "BackupDataToRemoteFailed",
+				Message: err.Error(),
+			})
+		}
+		log.Infof("backup cluster %s data to %s success", bm, bm.StorageType)
 	}
-	glog.Infof("backup cluster %s data to %s success", bm, bm.StorageType)
 
 	finish := time.Now()
 
@@ -186,6 +419,11 @@ func (bm *BackupManager) performBackup(backup *v1alpha1.Backup, db *sql.DB) erro
 	backup.Status.TimeCompleted = metav1.Time{Time: finish}
 	backup.Status.BackupSize = size
 	backup.Status.CommitTs = commitTs
+	backup.Status.Progress = &v1alpha1.BackupProgress{
+		Percentage:     100,
+		BackedUpBytes:  size,
+		LastUpdateTime: metav1.Time{Time: finish},
+	}
 
 	return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
 		Type:   v1alpha1.BackupComplete,
@@ -204,8 +442,27 @@ func (bm *BackupManager) ProcessCleanBackup() error {
 }
 
 func (bm *BackupManager) performCleanBackup(backup *v1alpha1.Backup) error {
+	if backup.Spec.VolumeSnapshot != nil {
+		// the backup taken by volume snapshots has no data in the backend storage
+		if err := bm.deleteVolumeSnapshots(backup, backup.Status.VolumeSnapshots); err != nil {
+			log.Errorf("clean cluster %s volume snapshots failed, err: %s", bm, err)
+			return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+				Type:    v1alpha1.BackupFailed,
+				Status:  corev1.ConditionTrue,
+				Reason:  "CleanVolumeSnapshotFailed",
+				Message: err.Error(),
+			})
+		}
+
+		log.Infof("clean cluster %s volume snapshots success", bm)
+		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:   v1alpha1.BackupClean,
+			Status: corev1.ConditionTrue,
+		})
+	}
+
 	if backup.Status.BackupPath == "" {
-		glog.Errorf("cluster %s backup path is empty", bm)
+		log.Errorf("cluster %s backup path is empty", bm)
 		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
 			Type:    v1alpha1.BackupFailed,
 			Status:  corev1.ConditionTrue,
@@ -214,9 +471,23 @@ func (bm *BackupManager) performCleanBackup(backup *v1alpha1.Backup) error {
 		})
 	}
 
-	err := bm.cleanRemoteBackupData(backup.Status.BackupPath)
+	if backup.Spec.LogBackup != nil {
+		// stop the task first, otherwise TiKV keeps writing the logs to the storage,
+		// the task may have been stopped already, so only log the error here
+		if err := bm.stopLogBackupTask(); err != nil {
+			log.Warningf("stop cluster %s log backup failed, err: %s", bm, err)
+		}
+	}
+
+	var err error
+	if backup.Spec.BR != nil || backup.Spec.Dumpling != nil || backup.Spec.LogBackup != nil {
+		// the backup taken by br or dumpling is a directory rather than an archive file
+		err = bm.cleanRemoteBackupDir(backup.Status.BackupPath)
+	} else {
+		err = bm.cleanRemoteBackupData(backup.Status.BackupPath)
+	}
 	if err != nil {
-		glog.Errorf("clean cluster %s backup %s failed, err: %s", bm, backup.Status.BackupPath, err)
+		log.Errorf("clean cluster %s backup %s failed, err: %s", bm, backup.Status.BackupPath, err)
 		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
 			Type:    v1alpha1.BackupFailed,
 			Status:  corev1.ConditionTrue,
@@ -225,7 +496,17 @@ func (bm *BackupManager) performCleanBackup(backup *v1alpha1.Backup) error {
 		})
 	}
 
-	glog.Infof("clean cluster %s backup %s success", bm, backup.Status.BackupPath)
+	if err := bm.cleanSecondaryStorages(backup); err != nil {
+		log.Errorf("clean cluster %s backup in the secondary storages failed, err: %s", bm, err)
+		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "CleanSecondaryStorageFailed",
+			Message: err.Error(),
+		})
+	}
+
+	log.Infof("clean cluster %s backup %s success", bm, backup.Status.BackupPath)
 	return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
 		Type:   v1alpha1.BackupClean,
 		Status: corev1.ConditionTrue,
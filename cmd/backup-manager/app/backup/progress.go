@@ -0,0 +1,161 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/constants"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// percentageRegexp matches the percentage of the progress bar printed by br,
+// e.g. "Full backup <-------------/.................> 45.23%"
+var percentageRegexp = regexp.MustCompile(`(\d+(?:\.\d+)?)%`)
+
+// commandRunner runs cmd and returns its combined output
+type commandRunner func(cmd *exec.Cmd) ([]byte, error)
+
+// runCommand is the commandRunner which reports nothing
+func runCommand(cmd *exec.Cmd) ([]byte, error) {
+	return cmd.CombinedOutput()
+}
+
+// maxPendingProgress is the max size of the unparsed output kept by
+// progressWriter, which is enough for a percentage split across writes
+const maxPendingProgress = 64
+
+// progressWriter collects the output of a command and records the last
+// percentage printed by it
+type progressWriter struct {
+	mu         sync.Mutex
+	output     bytes.Buffer
+	percentage float64
+	// pending is the output after the last percentage or line break, a
+	// percentage may be split across writes of the command
+	pending []byte
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	pw.pending = append(pw.pending, p...)
+	if matches := percentageRegexp.FindAllSubmatchIndex(pw.pending, -1); len(matches) > 0 {
+		last := matches[len(matches)-1]
+		if percentage, err := strconv.ParseFloat(string(pw.pending[last[2]:last[3]]), 64); err == nil && percentage <= 100 {
+			pw.percentage = percentage
+		}
+		pw.pending = pw.pending[last[1]:]
+	}
+	if i := bytes.LastIndexAny(pw.pending, "\r\n"); i >= 0 {
+		pw.pending = pw.pending[i+1:]
+	}
+	if len(pw.pending) > maxPendingProgress {
+		pw.pending = pw.pending[len(pw.pending)-maxPendingProgress:]
+	}
+	return pw.output.Write(p)
+}
+
+func (pw *progressWriter) getPercentage() float64 {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	return pw.percentage
+}
+
+func (pw *progressWriter) getOutput() []byte {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	return pw.output.Bytes()
+}
+
+// getTotalDataSize gets the estimated data size of the user tables in tidb cluster
+func (bo *BackupOpts) getTotalDataSize(db *sql.DB) (int64, error) {
+	var size sql.NullInt64
+	sql := "SELECT SUM(DATA_LENGTH + INDEX_LENGTH) FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA NOT IN ('mysql', 'INFORMATION_SCHEMA', 'PERFORMANCE_SCHEMA', 'METRICS_SCHEMA', 'INSPECTION_SCHEMA')"
+	err := db.QueryRow(sql).Scan(&size)
+	if err != nil {
+		return 0, fmt.Errorf("query cluster %s data size failed, sql: %s, err: %v", bo, sql, err)
+	}
+	return size.Int64, nil
+}
+
+// progressRunner returns a commandRunner which reports the progress of the
+// backup to backup's status periodically until the command exits. The
+// percentage printed by the command is preferred, otherwise it is estimated
+// by the size of the uploaded data and totalBytes.
+func (bm *BackupManager) progressRunner(backup *v1alpha1.Backup, bucketURI string, totalBytes int64) commandRunner {
+	return func(cmd *exec.Cmd) ([]byte, error) {
+		started := time.Now()
+		pw := &progressWriter{}
+		cmd.Stdout = pw
+		cmd.Stderr = pw
+		if err := cmd.Start(); err != nil {
+			return nil, err
+		}
+		done := make(chan error, 1)
+		go func() {
+			done <- cmd.Wait()
+		}()
+
+		ticker := time.NewTicker(constants.ProgressReportInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case err := <-done:
+				return pw.getOutput(), err
+			case <-ticker.C:
+				backedUpBytes, err := getRemoteBackupSize(bucketURI)
+				if err != nil {
+					log.Warningf("get cluster %s backup %s size failed, err: %s", bm, bucketURI, err)
+				}
+				progress := getProgress(pw.getPercentage(), backedUpBytes, totalBytes, time.Since(started))
+				if err := bm.ProgressUpdater.Update(backup, progress); err != nil {
+					log.Errorf("update cluster %s backup %s progress failed, err: %s", bm, bm.BackupName, err)
+				}
+			}
+		}
+	}
+}
+
+// getProgress returns the progress of a backup which has uploaded backedUpBytes
+// in elapsed, percentage is the one printed by the command or 0 if unknown
+func getProgress(percentage float64, backedUpBytes, totalBytes int64, elapsed time.Duration) *v1alpha1.BackupProgress {
+	if percentage == 0 && totalBytes > 0 {
+		percentage = float64(backedUpBytes) * 100 / float64(totalBytes)
+		if percentage > constants.MaxEstimatedPercentage {
+			// the estimated total size is not accurate, never report the backup is completed
+			percentage = constants.MaxEstimatedPercentage
+		}
+	}
+
+	progress := &v1alpha1.BackupProgress{
+		Percentage:     int32(percentage),
+		BackedUpBytes:  backedUpBytes,
+		LastUpdateTime: metav1.Now(),
+	}
+	if percentage > 0 {
+		remaining := time.Duration(float64(elapsed) * (100 - percentage) / percentage)
+		progress.EstimatedRemainingTime = remaining.Round(time.Second).String()
+	}
+	return progress
+}
@@ -0,0 +1,167 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestProgressWriter(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name             string
+		writes           []string
+		expectPercentage float64
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		pw := &progressWriter{}
+		for _, w := range test.writes {
+			n, err := pw.Write([]byte(w))
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(n).To(Equal(len(w)))
+		}
+		g.Expect(pw.getPercentage()).To(Equal(test.expectPercentage))
+		g.Expect(string(pw.getOutput())).To(Equal(strings.Join(test.writes, "")))
+	}
+
+	tests := []testcase{
+		{
+			name:             "progress bar",
+			writes:           []string{"Full backup <-------------/.................> 45.23%\r"},
+			expectPercentage: 45.23,
+		},
+		{
+			name:             "the last percentage is recorded",
+			writes:           []string{"Full backup <---/.....> 10%\rFull backup <----/....> 20%\r", "Full backup <-----/...> 30%\r"},
+			expectPercentage: 30,
+		},
+		{
+			name:             "percentage split across writes",
+			writes:           []string{"Full backup <---/.....> 10%\rFull backup <----/....> 45.", "23%\r"},
+			expectPercentage: 45.23,
+		},
+		{
+			name:             "percentage sign split across writes",
+			writes:           []string{"Full backup <---/.....> 4", "5", "%"},
+			expectPercentage: 45,
+		},
+		{
+			name:             "partial line without percentage",
+			writes:           []string{"Full backup <---/.....> 10%\r[INFO] [backup.go:200] [\"backup", " started\"]\n"},
+			expectPercentage: 10,
+		},
+		{
+			name:             "numbers without percentage sign are ignored",
+			writes:           []string{"Full backup <---/.....> 10%\r[INFO] [client.go:12", "]\n", "3%"},
+			expectPercentage: 3,
+		},
+		{
+			name:   "lines without progress",
+			writes: []string{"[INFO] [backup.go:200] [\"backup started\"]\n", "[INFO] [client.go:100] [\"backup ranges\"] [count=12]\n"},
+		},
+		{
+			name:             "percentage over 100 is ignored",
+			writes:           []string{"Full backup <---/.....> 10%\r", "[WARN] [\"disk usage\"] [usage=150%]\n"},
+			expectPercentage: 10,
+		},
+		{
+			name:             "percentage of 100",
+			writes:           []string{"Full backup <---------> 100.00%\n"},
+			expectPercentage: 100,
+		},
+		{
+			name:             "long output without line break",
+			writes:           []string{strings.Repeat(".", 1000), " 50%"},
+			expectPercentage: 50,
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func TestGetProgress(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name             string
+		percentage       float64
+		backedUpBytes    int64
+		totalBytes       int64
+		expectPercentage int32
+		expectRemaining  string
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		progress := getProgress(test.percentage, test.backedUpBytes, test.totalBytes, time.Minute)
+		g.Expect(progress.Percentage).To(Equal(test.expectPercentage))
+		g.Expect(progress.BackedUpBytes).To(Equal(test.backedUpBytes))
+		g.Expect(progress.EstimatedRemainingTime).To(Equal(test.expectRemaining))
+		g.Expect(progress.LastUpdateTime.IsZero()).To(BeFalse())
+	}
+
+	tests := []testcase{
+		{
+			name:             "percentage printed by the command",
+			percentage:       25,
+			backedUpBytes:    100,
+			totalBytes:       1000,
+			expectPercentage: 25,
+			expectRemaining:  "3m0s",
+		},
+		{
+			name:             "percentage estimated by the uploaded data",
+			backedUpBytes:    500,
+			totalBytes:       1000,
+			expectPercentage: 50,
+			expectRemaining:  "1m0s",
+		},
+		{
+			name:             "estimated percentage is capped",
+			backedUpBytes:    2000,
+			totalBytes:       1000,
+			expectPercentage: 99,
+			expectRemaining:  "1s",
+		},
+		{
+			name:             "completed",
+			percentage:       100,
+			backedUpBytes:    1000,
+			totalBytes:       1000,
+			expectPercentage: 100,
+			expectRemaining:  "0s",
+		},
+		{
+			name:          "unknown total size",
+			backedUpBytes: 500,
+		},
+		{
+			name:       "nothing is uploaded",
+			totalBytes: 1000,
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
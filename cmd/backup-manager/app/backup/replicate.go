@@ -0,0 +1,177 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"fmt"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/constants"
+	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/util"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ProcessReplicateBackup used to replicate the data of a complete backup to
+// the secondary storages
+func (bm *BackupManager) ProcessReplicateBackup() error {
+	backup, err := bm.backupLister.Backups(bm.Namespace).Get(bm.BackupName)
+	if err != nil {
+		return fmt.Errorf("can't find cluster %s backup %s CRD object, err: %v", bm, bm.BackupName, err)
+	}
+
+	bm.StorageType = string(backup.Spec.StorageType)
+	bm.bucketPrefix = getBucketPrefix(backup.Spec.StorageType, &backup.Spec.StorageProvider)
+	return bm.performReplicateBackup(backup.DeepCopy())
+}
+
+// performReplicateBackup copies the backup data to the secondary storages one
+// by one, the data is kept at the same relative path as in the primary storage.
+// A failed storage doesn't stop the others, the result of each storage is
+// recorded in the status and the failed ones are listed in the condition.
+func (bm *BackupManager) performReplicateBackup(backup *v1alpha1.Backup) error {
+	relativePath, err := bm.getRelativeBackupPath(backup.Status.BackupPath)
+	if err != nil {
+		log.Errorf("cluster %s backup path is invalid, err: %s", bm, err)
+		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupReplicated,
+			Status:  corev1.ConditionFalse,
+			Reason:  "InvalidBackupPath",
+			Message: err.Error(),
+		})
+	}
+
+	var failed []string
+	backup.Status.SecondaryStorages = nil
+	for i, storage := range backup.Spec.SecondaryStorages {
+		remotePath := path.Join(getBucketPrefix(storage.StorageType, &storage.StorageProvider), relativePath)
+		backup.Status.SecondaryStorages = append(backup.Status.SecondaryStorages, v1alpha1.SecondaryStorageStatus{
+			Name:       storage.Name,
+			BackupPath: fmt.Sprintf("%s://%s", storage.StorageType, remotePath),
+			Phase:      v1alpha1.SecondaryStorageReplicating,
+		})
+		status := &backup.Status.SecondaryStorages[i]
+		err := bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupReplicated,
+			Status:  corev1.ConditionFalse,
+			Reason:  "Replicating",
+			Message: fmt.Sprintf("replicating to %s", storage.Name),
+		})
+		if err != nil {
+			return err
+		}
+
+		dest := fmt.Sprintf("%s:%s", getSecondaryStorageRemote(i), remotePath)
+		if err := bm.copyToSecondaryStorage(backup.Status.BackupPath, dest); err != nil {
+			log.Errorf("replicate cluster %s backup to %s failed, err: %s", bm, storage.Name, err)
+			status.Phase = v1alpha1.SecondaryStorageFailed
+			status.Message = err.Error()
+			failed = append(failed, storage.Name)
+			continue
+		}
+		log.Infof("replicate cluster %s backup to %s success", bm, status.BackupPath)
+		status.Phase = v1alpha1.SecondaryStorageComplete
+		status.TimeCompleted = metav1.Time{Time: time.Now()}
+	}
+
+	if len(failed) > 0 {
+		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupReplicated,
+			Status:  corev1.ConditionFalse,
+			Reason:  "ReplicateFailed",
+			Message: fmt.Sprintf("failed to replicate to %s", strings.Join(failed, ", ")),
+		})
+	}
+	return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+		Type:   v1alpha1.BackupReplicated,
+		Status: corev1.ConditionTrue,
+	})
+}
+
+// cleanSecondaryStorages removes the backup data replicated to the secondary
+// storages, the data of the failed replications may be partial or missing, so
+// the errors of them are only logged
+func (bm *BackupManager) cleanSecondaryStorages(backup *v1alpha1.Backup) error {
+	isDir := backup.Spec.BR != nil || backup.Spec.Dumpling != nil
+	for _, status := range backup.Status.SecondaryStorages {
+		if status.BackupPath == "" {
+			continue
+		}
+		index := -1
+		for i, storage := range backup.Spec.SecondaryStorages {
+			if storage.Name == status.Name {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			log.Warningf("cluster %s secondary storage %s is removed from the spec, skip cleaning %s", bm, status.Name, status.BackupPath)
+			continue
+		}
+
+		parts := strings.SplitN(status.BackupPath, "://", 2)
+		if len(parts) != 2 {
+			log.Warningf("cluster %s secondary storage %s backup path %s is invalid, skip cleaning it", bm, status.Name, status.BackupPath)
+			continue
+		}
+		// the remote of the storage is accessed by the same URI form as the primary storage
+		bucketURI := fmt.Sprintf("%s://%s", getSecondaryStorageRemote(index), parts[1])
+		var err error
+		if isDir {
+			err = bm.cleanRemoteBackupDir(bucketURI)
+		} else {
+			err = bm.cleanRemoteBackupData(bucketURI)
+		}
+		if err != nil {
+			if status.Phase != v1alpha1.SecondaryStorageComplete {
+				log.Warningf("clean cluster %s backup %s of the failed replication failed, err: %s", bm, status.BackupPath, err)
+				continue
+			}
+			return fmt.Errorf("clean backup %s in secondary storage %s failed, err: %v", status.BackupPath, status.Name, err)
+		}
+	}
+	return nil
+}
+
+// getRelativeBackupPath returns the path of the backup data relative to the
+// bucket and path prefix of the primary storage
+func (bo *BackupOpts) getRelativeBackupPath(backupPath string) (string, error) {
+	remotePath := strings.TrimPrefix(backupPath, bo.StorageType+"://")
+	if remotePath == backupPath || !strings.HasPrefix(remotePath, bo.bucketPrefix) {
+		return "", fmt.Errorf("backup path %s is not in the %s storage %s", backupPath, bo.StorageType, bo.bucketPrefix)
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(remotePath, bo.bucketPrefix), "/"), nil
+}
+
+// copyToSecondaryStorage copies the backup data at bucketURI to dest, the data
+// is either an archive file or a directory, rclone copyto handles both
+func (bo *BackupOpts) copyToSecondaryStorage(bucketURI, dest string) error {
+	source := util.NormalizeBucketURI(bucketURI)
+	output, err := exec.Command("rclone", constants.RcloneConfigArg, "copyto", source, dest).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("cluster %s, execute rclone copyto command for replicate backup data %s failed, output: %s, err: %v", bo, bucketURI, string(output), err)
+	}
+	return nil
+}
+
+// getSecondaryStorageRemote returns the name of the rclone remote of the
+// index-th secondary storage, it is defined by the env of the job
+func getSecondaryStorageRemote(index int) string {
+	return fmt.Sprintf("%s%d", constants.SecondaryStorageRemotePrefix, index)
+}
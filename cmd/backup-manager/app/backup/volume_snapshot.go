@@ -0,0 +1,500 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/constants"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// awsSnapshot is the snapshot printed by `aws ec2 create-snapshot`
+type awsSnapshot struct {
+	SnapshotID string `json:"SnapshotId"`
+}
+
+// getSnapshotVolumes returns the volumes to snapshot, they are collected
+// by tidb-operator and passed to the backup job in env SNAPSHOT_VOLUMES
+func getSnapshotVolumes() ([]v1alpha1.VolumeSnapshotStatus, error) {
+	data := os.Getenv("SNAPSHOT_VOLUMES")
+	if data == "" {
+		return nil, fmt.Errorf("env SNAPSHOT_VOLUMES is empty")
+	}
+	var volumes []v1alpha1.VolumeSnapshotStatus
+	if err := json.Unmarshal([]byte(data), &volumes); err != nil {
+		return nil, fmt.Errorf("parse snapshot volumes %s failed, err: %v", data, err)
+	}
+	if len(volumes) == 0 {
+		return nil, fmt.Errorf("snapshot volumes %s is empty", data)
+	}
+	return volumes, nil
+}
+
+// getScheduleLimits returns the schedule limits of PD which are set to 0 to pause the scheduling
+func getScheduleLimits(pdClient pdapi.PDClient) (map[string]uint64, error) {
+	config, err := pdClient.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	schedule := config.Schedule
+	return map[string]uint64{
+		"leader-schedule-limit":  schedule.LeaderScheduleLimit,
+		"region-schedule-limit":  schedule.RegionScheduleLimit,
+		"replica-schedule-limit": schedule.ReplicaScheduleLimit,
+		"merge-schedule-limit":   schedule.MergeScheduleLimit,
+	}, nil
+}
+
+// pauseSchedule stops PD from moving regions between the TiKV stores by setting
+// the schedule limits to 0, so that the snapshots of all the volumes keep the
+// same regions. The former limits are recorded in the backup status before they
+// are changed, so that they can be restored even if the job is killed.
+func (bm *BackupManager) pauseSchedule(backup *v1alpha1.Backup, pdClient pdapi.PDClient) error {
+	limits, err := getScheduleLimits(pdClient)
+	if err != nil {
+		return err
+	}
+	if err := bm.ProgressUpdater.UpdatePausedScheduleLimits(backup, limits); err != nil {
+		return err
+	}
+	backup.Status.PausedScheduleLimits = limits
+
+	paused := map[string]interface{}{}
+	for key := range limits {
+		paused[key] = 0
+	}
+	return pdClient.UpdateScheduleConfig(paused)
+}
+
+// resumeSchedule restores the schedule limits recorded by pauseSchedule, it does
+// nothing if the scheduling is not paused by the backup
+func (bm *BackupManager) resumeSchedule(backup *v1alpha1.Backup, pdClient pdapi.PDClient) error {
+	if len(backup.Status.PausedScheduleLimits) == 0 {
+		return nil
+	}
+	limits := map[string]interface{}{}
+	for key, limit := range backup.Status.PausedScheduleLimits {
+		limits[key] = limit
+	}
+	if err := pdClient.UpdateScheduleConfig(limits); err != nil {
+		return err
+	}
+	if err := bm.ProgressUpdater.UpdatePausedScheduleLimits(backup, nil); err != nil {
+		return err
+	}
+	backup.Status.PausedScheduleLimits = nil
+	return nil
+}
+
+// getMaxAllocID returns the largest id allocated by PD among the stores, regions
+// and peers of the cluster, which are all allocated by the same allocator of PD
+func getMaxAllocID(pdClient pdapi.PDClient) (uint64, error) {
+	var maxID uint64
+	stores, err := pdClient.GetStores()
+	if err != nil {
+		return 0, err
+	}
+	for _, store := range stores.Stores {
+		if store.Store != nil && store.Store.GetId() > maxID {
+			maxID = store.Store.GetId()
+		}
+	}
+	regions, err := pdClient.GetRegions()
+	if err != nil {
+		return 0, err
+	}
+	for _, region := range regions.Regions {
+		if region.ID > maxID {
+			maxID = region.ID
+		}
+		for _, peer := range region.Peers {
+			if peer.ID > maxID {
+				maxID = peer.ID
+			}
+		}
+	}
+	return maxID, nil
+}
+
+// getAWSArgs returns the region args of the aws command, the region is read
+// from spec.s3 of the backup
+func getAWSArgs() []string {
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return []string{fmt.Sprintf("--region=%s", region)}
+	}
+	return nil
+}
+
+// createAWSSnapshot creates an EBS snapshot of the volume, the snapshot is taken
+// in the background after the command returns
+func (bo *BackupOpts) createAWSSnapshot(volume v1alpha1.VolumeSnapshotStatus, commitTs string, tags map[string]string) (string, error) {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	tagSpecs := []string{
+		fmt.Sprintf("{Key=tidb-cluster,Value=%s}", bo),
+		fmt.Sprintf("{Key=tidb-backup,Value=%s}", bo.BackupName),
+		fmt.Sprintf("{Key=tidb-commit-ts,Value=%s}", commitTs),
+	}
+	for _, key := range keys {
+		tagSpecs = append(tagSpecs, fmt.Sprintf("{Key=%s,Value=%s}", key, tags[key]))
+	}
+
+	args := []string{
+		"ec2",
+		"create-snapshot",
+		fmt.Sprintf("--volume-id=%s", volume.VolumeID),
+		fmt.Sprintf("--description=backup %s of cluster %s pvc %s", bo.BackupName, bo, volume.PVCName),
+		fmt.Sprintf("--tag-specifications=ResourceType=snapshot,Tags=[%s]", strings.Join(tagSpecs, ",")),
+		"--output=json",
+	}
+	args = append(args, getAWSArgs()...)
+	output, err := exec.Command("aws", args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("cluster %s, execute aws command %v failed, output: %s, err: %v", bo, args, string(output), err)
+	}
+	snapshot := &awsSnapshot{}
+	if err := json.Unmarshal(output, snapshot); err != nil {
+		return "", fmt.Errorf("cluster %s, parse aws snapshot %s failed, err: %v", bo, string(output), err)
+	}
+	return snapshot.SnapshotID, nil
+}
+
+// waitAWSSnapshots waits for the EBS snapshots to be completed
+func (bo *BackupOpts) waitAWSSnapshots(snapshots []v1alpha1.VolumeSnapshotStatus) error {
+	args := []string{
+		"ec2",
+		"wait",
+		"snapshot-completed",
+		"--snapshot-ids",
+	}
+	for _, snapshot := range snapshots {
+		args = append(args, snapshot.SnapshotID)
+	}
+	args = append(args, getAWSArgs()...)
+	output, err := runCommand(exec.Command("aws", args...))
+	if err != nil {
+		return fmt.Errorf("cluster %s, execute aws command %v failed, output: %s, err: %v", bo, args, string(output), err)
+	}
+	return nil
+}
+
+// createCSISnapshot creates a VolumeSnapshot of the pvc, the snapshot is taken
+// by the CSI driver of the volume. The VolumeSnapshot is not owned by the backup,
+// so that it is retained if the clean policy of the backup is Retain.
+func (bm *BackupManager) createCSISnapshot(backup *v1alpha1.Backup, volume v1alpha1.VolumeSnapshotStatus, commitTs string) (string, error) {
+	name := fmt.Sprintf("%s-%s", bm.BackupName, volume.PVCName)
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetAPIVersion(controller.VolumeSnapshotGVR.GroupVersion().String())
+	snapshot.SetKind("VolumeSnapshot")
+	snapshot.SetName(name)
+	snapshot.SetNamespace(bm.Namespace)
+	snapshot.SetLabels(label.NewBackup().Instance(bm.TcName).Backup(bm.BackupName).Labels())
+	snapshot.SetAnnotations(map[string]string{label.AnnBackupCommitTs: commitTs})
+	err := unstructured.SetNestedField(snapshot.Object, backup.Spec.VolumeSnapshot.VolumeSnapshotClassName, "spec", "volumeSnapshotClassName")
+	if err != nil {
+		return "", err
+	}
+	err = unstructured.SetNestedField(snapshot.Object, volume.PVCName, "spec", "source", "persistentVolumeClaimName")
+	if err != nil {
+		return "", err
+	}
+
+	_, err = bm.dynamicCli.Resource(controller.VolumeSnapshotGVR).Namespace(bm.Namespace).Create(snapshot, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return "", fmt.Errorf("cluster %s, create volume snapshot %s of pvc %s failed, err: %v", bm, name, volume.PVCName, err)
+	}
+	return name, nil
+}
+
+// waitCSISnapshots waits for the VolumeSnapshots to be ready to use, and records
+// the VolumeSnapshotContents bound to them
+func (bm *BackupManager) waitCSISnapshots(snapshots []v1alpha1.VolumeSnapshotStatus) error {
+	for i := range snapshots {
+		name := snapshots[i].SnapshotName
+		err := wait.PollImmediateInfinite(constants.VolumeSnapshotPollInterval, func() (bool, error) {
+			snapshot, err := bm.dynamicCli.Resource(controller.VolumeSnapshotGVR).Namespace(bm.Namespace).Get(name, metav1.GetOptions{})
+			if err != nil {
+				log.Warningf("get cluster %s volume snapshot %s failed, err: %s", bm, name, err)
+				return false, nil
+			}
+			if message, found, _ := unstructured.NestedString(snapshot.Object, "status", "error", "message"); found && message != "" {
+				return false, fmt.Errorf("cluster %s, volume snapshot %s failed, err: %s", bm, name, message)
+			}
+			ready, _, _ := unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+			if !ready {
+				return false, nil
+			}
+			content, _, _ := unstructured.NestedString(snapshot.Object, "status", "boundVolumeSnapshotContentName")
+			snapshots[i].SnapshotID = content
+			return true, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteCSISnapshots deletes the VolumeSnapshots of the backup, whether the
+// snapshots in the storage are deleted depends on the deletion policy of the VolumeSnapshotClass
+func (bm *BackupManager) deleteCSISnapshots(snapshots []v1alpha1.VolumeSnapshotStatus) error {
+	for _, snapshot := range snapshots {
+		if snapshot.SnapshotName == "" {
+			continue
+		}
+		err := bm.dynamicCli.Resource(controller.VolumeSnapshotGVR).Namespace(bm.Namespace).Delete(snapshot.SnapshotName, &metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("cluster %s, delete volume snapshot %s failed, err: %v", bm, snapshot.SnapshotName, err)
+		}
+	}
+	return nil
+}
+
+// waitVolumeSnapshots waits for the snapshots of the backup to be completed
+func (bm *BackupManager) waitVolumeSnapshots(backup *v1alpha1.Backup, snapshots []v1alpha1.VolumeSnapshotStatus) error {
+	if backup.GetVolumeSnapshotProvider() == v1alpha1.VolumeSnapshotProviderCSI {
+		return bm.waitCSISnapshots(snapshots)
+	}
+	return bm.waitAWSSnapshots(snapshots)
+}
+
+// deleteVolumeSnapshots deletes the snapshots of the backup
+func (bm *BackupManager) deleteVolumeSnapshots(backup *v1alpha1.Backup, snapshots []v1alpha1.VolumeSnapshotStatus) error {
+	if backup.GetVolumeSnapshotProvider() == v1alpha1.VolumeSnapshotProviderCSI {
+		return bm.deleteCSISnapshots(snapshots)
+	}
+	return bm.deleteAWSSnapshots(snapshots)
+}
+
+// deleteAWSSnapshots deletes the EBS snapshots of the backup
+func (bo *BackupOpts) deleteAWSSnapshots(snapshots []v1alpha1.VolumeSnapshotStatus) error {
+	for _, snapshot := range snapshots {
+		if snapshot.SnapshotID == "" {
+			continue
+		}
+		args := []string{
+			"ec2",
+			"delete-snapshot",
+			fmt.Sprintf("--snapshot-id=%s", snapshot.SnapshotID),
+		}
+		args = append(args, getAWSArgs()...)
+		output, err := runCommand(exec.Command("aws", args...))
+		if err != nil && !strings.Contains(string(output), "InvalidSnapshot.NotFound") {
+			return fmt.Errorf("cluster %s, execute aws command %v failed, output: %s, err: %v", bo, args, string(output), err)
+		}
+	}
+	return nil
+}
+
+// performVolumeSnapshotBackup takes the snapshots of all the volumes while the
+// scheduling of PD is paused, the commitTs got in the meantime is recorded as the
+// resolved ts of the backup
+func (bm *BackupManager) performVolumeSnapshotBackup(backup *v1alpha1.Backup, db *sql.DB) error {
+	started := time.Now()
+
+	err := bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+		Type:   v1alpha1.BackupRunning,
+		Status: corev1.ConditionTrue,
+	})
+	if err != nil {
+		return err
+	}
+
+	volumes, err := getSnapshotVolumes()
+	if err != nil {
+		log.Errorf("get cluster %s snapshot volumes failed, err: %s", bm, err)
+		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "GetSnapshotVolumesFailed",
+			Message: err.Error(),
+		})
+	}
+
+	tc, err := bm.cli.PingcapV1alpha1().TidbClusters(bm.Namespace).Get(bm.TcName, metav1.GetOptions{})
+	if err != nil {
+		log.Errorf("get cluster %s failed, err: %s", bm, err)
+		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "GetTidbClusterFailed",
+			Message: err.Error(),
+		})
+	}
+	pdClient := pdapi.NewDefaultPDControl().GetPDClient(pdapi.Namespace(bm.Namespace), bm.TcName, tc.Spec.EnableTLSCluster)
+	cluster, err := pdClient.GetCluster()
+	if err != nil {
+		log.Errorf("get cluster %s id failed, err: %s", bm, err)
+		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "GetClusterIDFailed",
+			Message: err.Error(),
+		})
+	}
+	backup.Status.ClusterID = strconv.FormatUint(cluster.GetId(), 10)
+
+	// the scheduling paused by the former job which is killed is resumed first,
+	// otherwise the paused limits would be taken as the former limits
+	if err := bm.resumeSchedule(backup, pdClient); err != nil {
+		log.Errorf("resume cluster %s schedule paused by the former job failed, err: %s", bm, err)
+		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "ResumeScheduleFailed",
+			Message: err.Error(),
+		})
+	}
+
+	// the scheduling is resumed on every return, it does nothing if it has been resumed
+	defer func() {
+		if err := bm.resumeSchedule(backup, pdClient); err != nil {
+			log.Errorf("resume cluster %s schedule failed, err: %s", bm, err)
+		}
+	}()
+	if err := bm.pauseSchedule(backup, pdClient); err != nil {
+		log.Errorf("pause cluster %s schedule failed, err: %s", bm, err)
+		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "PauseScheduleFailed",
+			Message: err.Error(),
+		})
+	}
+	log.Infof("pause cluster %s schedule success, the former schedule limits are %v", bm, backup.Status.PausedScheduleLimits)
+
+	commitTs, snapshots, err := bm.createVolumeSnapshots(backup, db, volumes)
+	// the snapshots are point-in-time once they are created, so the scheduling
+	// is resumed before waiting for them to be completed
+	if resumeErr := bm.resumeSchedule(backup, pdClient); resumeErr != nil {
+		log.Errorf("resume cluster %s schedule failed, err: %s", bm, resumeErr)
+		if err == nil {
+			// record the snapshots so that they can be cleaned up
+			backup.Status.VolumeSnapshots = snapshots
+			return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+				Type:    v1alpha1.BackupFailed,
+				Status:  corev1.ConditionTrue,
+				Reason:  "ResumeScheduleFailed",
+				Message: resumeErr.Error(),
+			})
+		}
+	} else {
+		log.Infof("resume cluster %s schedule success", bm)
+	}
+	// record the snapshots before waiting for them, so that the snapshots of
+	// a failed backup can also be cleaned up
+	backup.Status.VolumeSnapshots = snapshots
+	if err != nil {
+		log.Errorf("create cluster %s volume snapshots failed, err: %s", bm, err)
+		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "CreateVolumeSnapshotFailed",
+			Message: err.Error(),
+		})
+	}
+	log.Infof("create cluster %s volume snapshots at commitTs %s success", bm, commitTs)
+
+	// the ids found after the snapshots are taken cover the ones in the snapshots
+	maxAllocID, err := getMaxAllocID(pdClient)
+	if err != nil {
+		log.Errorf("get cluster %s max allocated id failed, err: %s", bm, err)
+		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "GetMaxAllocIDFailed",
+			Message: err.Error(),
+		})
+	}
+	backup.Status.MaxAllocID = maxAllocID
+	log.Infof("get cluster %s max allocated id %d success", bm, maxAllocID)
+
+	if err := bm.waitVolumeSnapshots(backup, snapshots); err != nil {
+		log.Errorf("wait cluster %s volume snapshots failed, err: %s", bm, err)
+		return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "WaitVolumeSnapshotFailed",
+			Message: err.Error(),
+		})
+	}
+	log.Infof("cluster %s volume snapshots are completed", bm)
+
+	finish := time.Now()
+
+	backup.Status.TimeStarted = metav1.Time{Time: started}
+	backup.Status.TimeCompleted = metav1.Time{Time: finish}
+	backup.Status.CommitTs = commitTs
+	backup.Status.Progress = &v1alpha1.BackupProgress{
+		Percentage:     100,
+		LastUpdateTime: metav1.Time{Time: finish},
+	}
+
+	return bm.StatusUpdater.Update(backup, &v1alpha1.BackupCondition{
+		Type:   v1alpha1.BackupComplete,
+		Status: corev1.ConditionTrue,
+	})
+}
+
+// createVolumeSnapshots gets the commitTs and creates the snapshots of the volumes,
+// the snapshots already created are returned even if it fails
+func (bm *BackupManager) createVolumeSnapshots(backup *v1alpha1.Backup, db *sql.DB, volumes []v1alpha1.VolumeSnapshotStatus) (string, []v1alpha1.VolumeSnapshotStatus, error) {
+	commitTs, err := bm.getCurrentTS(db)
+	if err != nil {
+		return "", nil, err
+	}
+	log.Infof("get cluster %s commitTs %s success", bm, commitTs)
+
+	snapshots := make([]v1alpha1.VolumeSnapshotStatus, 0, len(volumes))
+	for _, volume := range volumes {
+		if backup.GetVolumeSnapshotProvider() == v1alpha1.VolumeSnapshotProviderCSI {
+			snapshotName, err := bm.createCSISnapshot(backup, volume, commitTs)
+			if err != nil {
+				return commitTs, snapshots, err
+			}
+			log.Infof("create cluster %s volume snapshot %s of pvc %s success", bm, snapshotName, volume.PVCName)
+			volume.SnapshotName = snapshotName
+		} else {
+			snapshotID, err := bm.createAWSSnapshot(volume, commitTs, backup.Spec.VolumeSnapshot.Tags)
+			if err != nil {
+				return commitTs, snapshots, err
+			}
+			log.Infof("create cluster %s snapshot %s of volume %s success", bm, snapshotID, volume.VolumeID)
+			volume.SnapshotID = snapshotID
+		}
+		snapshots = append(snapshots, volume)
+	}
+	return commitTs, snapshots, nil
+}
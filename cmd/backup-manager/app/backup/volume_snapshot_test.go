@@ -0,0 +1,223 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned/fake"
+	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPauseAndResumeSchedule(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	formerLimits := map[string]uint64{
+		"leader-schedule-limit":  4,
+		"region-schedule-limit":  2048,
+		"replica-schedule-limit": 64,
+		"merge-schedule-limit":   8,
+	}
+
+	type testcase struct {
+		name               string
+		pausedLimits       map[string]uint64
+		updateBackupFailed bool
+		expectPauseErr     bool
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		backup := &v1alpha1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "ns"},
+		}
+		backup.Status.PausedScheduleLimits = test.pausedLimits
+		backupInformer := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0).Pingcap().V1alpha1().Backups()
+		g.Expect(backupInformer.Informer().GetIndexer().Add(backup)).To(Succeed())
+		progressUpdater := controller.NewFakeBackupProgressUpdater(backupInformer)
+		if test.updateBackupFailed {
+			progressUpdater.SetUpdateBackupError(errors.New("update backup failed"), 0)
+		}
+		bm := &BackupManager{
+			ProgressUpdater: progressUpdater,
+			BackupOpts:      BackupOpts{Namespace: "ns", TcName: "demo", BackupName: "backup"},
+		}
+
+		var updates []map[string]interface{}
+		pdClient := pdapi.NewFakePDClient()
+		pdClient.AddReaction(pdapi.GetConfigActionType, func(action *pdapi.Action) (interface{}, error) {
+			return &server.Config{
+				Schedule: server.ScheduleConfig{
+					LeaderScheduleLimit:  formerLimits["leader-schedule-limit"],
+					RegionScheduleLimit:  formerLimits["region-schedule-limit"],
+					ReplicaScheduleLimit: formerLimits["replica-schedule-limit"],
+					MergeScheduleLimit:   formerLimits["merge-schedule-limit"],
+				},
+			}, nil
+		})
+		pdClient.AddReaction(pdapi.UpdateScheduleConfigActionType, func(action *pdapi.Action) (interface{}, error) {
+			updates = append(updates, action.Config)
+			return nil, nil
+		})
+
+		if test.pausedLimits != nil {
+			// the scheduling paused by the killed job is resumed first
+			g.Expect(bm.resumeSchedule(backup, pdClient)).To(Succeed())
+			g.Expect(updates).To(HaveLen(1))
+			for key, limit := range test.pausedLimits {
+				g.Expect(updates[0]).To(HaveKeyWithValue(key, limit))
+			}
+			g.Expect(backup.Status.PausedScheduleLimits).To(BeNil())
+			updates = nil
+		}
+
+		err := bm.pauseSchedule(backup, pdClient)
+		if test.expectPauseErr {
+			g.Expect(err).To(HaveOccurred())
+			g.Expect(updates).To(BeEmpty())
+			g.Expect(backup.Status.PausedScheduleLimits).To(BeNil())
+			return
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(backup.Status.PausedScheduleLimits).To(Equal(formerLimits))
+		stored, err := backupInformer.Lister().Backups("ns").Get("backup")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(stored.Status.PausedScheduleLimits).To(Equal(formerLimits))
+		g.Expect(updates).To(HaveLen(1))
+		for key := range formerLimits {
+			g.Expect(updates[0]).To(HaveKeyWithValue(key, 0))
+		}
+
+		g.Expect(bm.resumeSchedule(backup, pdClient)).To(Succeed())
+		g.Expect(updates).To(HaveLen(2))
+		for key, limit := range formerLimits {
+			g.Expect(updates[1]).To(HaveKeyWithValue(key, limit))
+		}
+		g.Expect(backup.Status.PausedScheduleLimits).To(BeNil())
+		stored, err = backupInformer.Lister().Backups("ns").Get("backup")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(stored.Status.PausedScheduleLimits).To(BeNil())
+
+		// resuming again does nothing
+		g.Expect(bm.resumeSchedule(backup, pdClient)).To(Succeed())
+		g.Expect(updates).To(HaveLen(2))
+	}
+
+	tests := []testcase{
+		{
+			name: "pause and resume",
+		},
+		{
+			name:         "resume the schedule paused by the killed job",
+			pausedLimits: map[string]uint64{"leader-schedule-limit": 6, "region-schedule-limit": 1024, "replica-schedule-limit": 32, "merge-schedule-limit": 4},
+		},
+		{
+			name:               "the former limits are not recorded",
+			updateBackupFailed: true,
+			expectPauseErr:     true,
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func TestGetMaxAllocID(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name             string
+		stores           []uint64
+		regions          map[uint64][]uint64
+		getRegionsErr    error
+		expectErr        bool
+		expectMaxAllocID uint64
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		pdClient := pdapi.NewFakePDClient()
+		pdClient.AddReaction(pdapi.GetStoresActionType, func(action *pdapi.Action) (interface{}, error) {
+			storesInfo := &pdapi.StoresInfo{}
+			for _, id := range test.stores {
+				storesInfo.Stores = append(storesInfo.Stores, &pdapi.StoreInfo{
+					Store: &pdapi.MetaStore{Store: &metapb.Store{Id: id}},
+				})
+			}
+			return storesInfo, nil
+		})
+		pdClient.AddReaction(pdapi.GetRegionsActionType, func(action *pdapi.Action) (interface{}, error) {
+			if test.getRegionsErr != nil {
+				return nil, test.getRegionsErr
+			}
+			regionsInfo := &pdapi.RegionsInfo{}
+			for id, peers := range test.regions {
+				region := &pdapi.RegionInfo{ID: id}
+				for _, peer := range peers {
+					region.Peers = append(region.Peers, pdapi.RegionPeer{ID: peer})
+				}
+				regionsInfo.Regions = append(regionsInfo.Regions, region)
+			}
+			return regionsInfo, nil
+		})
+
+		maxAllocID, err := getMaxAllocID(pdClient)
+		if test.expectErr {
+			g.Expect(err).To(HaveOccurred())
+			return
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(maxAllocID).To(Equal(test.expectMaxAllocID))
+	}
+
+	tests := []testcase{
+		{
+			name:             "peer id is the largest",
+			stores:           []uint64{1, 4, 5},
+			regions:          map[uint64][]uint64{2: {3, 6, 7}, 8: {9, 10, 11}},
+			expectMaxAllocID: 11,
+		},
+		{
+			name:             "region id is the largest",
+			stores:           []uint64{1, 4, 5},
+			regions:          map[uint64][]uint64{2: {3, 6, 7}, 20: {9, 10, 11}},
+			expectMaxAllocID: 20,
+		},
+		{
+			name:             "store id is the largest",
+			stores:           []uint64{1, 4, 30},
+			regions:          map[uint64][]uint64{2: {3, 6, 7}},
+			expectMaxAllocID: 30,
+		},
+		{
+			name:          "get regions failed",
+			stores:        []uint64{1},
+			getRegionsErr: errors.New("get regions failed"),
+			expectErr:     true,
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"flag"
 
 	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/cmd"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	"github.com/spf13/pflag"
 	utilflag "k8s.io/apiserver/pkg/util/flag"
 	"k8s.io/apiserver/pkg/util/logs"
@@ -25,7 +26,9 @@ import (
 func Run() error {
 	logs.InitLogs()
 	defer logs.FlushLogs()
+	defer log.Flush()
 
+	log.AddFlags(flag.CommandLine)
 	// fix glog parse error
 	flag.CommandLine.Parse([]string{})
 
@@ -15,15 +15,16 @@ package cmd
 
 import (
 	"context"
+	"os"
 
 	// registry mysql drive
 	_ "github.com/go-sql-driver/mysql"
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/backup"
 	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/constants"
 	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/util"
 	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/tools/cache"
 	cmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
@@ -38,6 +39,8 @@ func NewBackupCommand() *cobra.Command {
 		Short: "Backup specific tidb cluster.",
 		Run: func(cmd *cobra.Command, args []string) {
 			util.ValidCmdFlags(cmd.CommandPath(), cmd.LocalFlags())
+			// the password is passed by env rather than flag, so that it is not exposed in the args
+			bo.Password = os.Getenv(constants.TidbPasswordEnv)
 			cmdutil.CheckErr(runBackup(bo, kubecfg))
 		},
 	}
@@ -45,7 +48,6 @@ func NewBackupCommand() *cobra.Command {
 	cmd.Flags().StringVarP(&bo.Namespace, "namespace", "n", "", "Tidb cluster's namespace")
 	cmd.Flags().StringVarP(&bo.TcName, "tidbcluster", "t", "", "Tidb cluster name")
 	cmd.Flags().StringVarP(&bo.TidbSvc, "tidbservice", "s", "", "Tidb cluster access service address")
-	cmd.Flags().StringVarP(&bo.Password, "password", "p", "", "Password to use when connecting to tidb cluster")
 	cmd.Flags().StringVarP(&bo.User, "user", "u", "", "User for login tidb cluster")
 	cmd.Flags().StringVarP(&bo.StorageType, "storageType", "S", "", "Backend storage type")
 	cmd.Flags().StringVarP(&bo.BackupName, "backupName", "b", "", "Backup CRD object name")
@@ -59,9 +61,12 @@ func runBackup(backupOpts backup.BackupOpts, kubecfg string) error {
 		informers.WithNamespace(backupOpts.Namespace),
 	}
 	informerFactory := informers.NewSharedInformerFactoryWithOptions(cli, constants.ResyncDuration, options...)
+	dynamicCli, err := util.NewDynamicCli(kubecfg)
+	cmdutil.CheckErr(err)
 	recorder := util.NewEventRecorder(kubeCli, "backup")
 	backupInformer := informerFactory.Pingcap().V1alpha1().Backups()
 	statusUpdater := controller.NewRealBackupConditionUpdater(cli, backupInformer.Lister(), recorder)
+	progressUpdater := controller.NewRealBackupProgressUpdater(cli, backupInformer.Lister())
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -70,7 +75,7 @@ func runBackup(backupOpts backup.BackupOpts, kubecfg string) error {
 	// waiting for the shared informer's store has synced.
 	cache.WaitForCacheSync(ctx.Done(), backupInformer.Informer().HasSynced)
 
-	glog.Infof("start to process backup %s", backupOpts)
-	bm := backup.NewBackupManager(backupInformer.Lister(), statusUpdater, backupOpts)
+	log.Infof("start to process backup %s", backupOpts)
+	bm := backup.NewBackupManager(backupInformer.Lister(), statusUpdater, progressUpdater, cli, dynamicCli, backupOpts)
 	return bm.ProcessBackup()
 }
@@ -18,12 +18,12 @@ import (
 
 	// registry mysql drive
 	_ "github.com/go-sql-driver/mysql"
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/backup"
 	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/constants"
 	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/util"
 	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/tools/cache"
 	cmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
@@ -56,9 +56,12 @@ func runClean(backupOpts backup.BackupOpts, kubecfg string) error {
 	}
 	informerFactory := informers.NewSharedInformerFactoryWithOptions(cli, constants.ResyncDuration, options...)
 
+	dynamicCli, err := util.NewDynamicCli(kubecfg)
+	cmdutil.CheckErr(err)
 	recorder := util.NewEventRecorder(kubeCli, "backup")
 	backupInformer := informerFactory.Pingcap().V1alpha1().Backups()
 	statusUpdater := controller.NewRealBackupConditionUpdater(cli, backupInformer.Lister(), recorder)
+	progressUpdater := controller.NewRealBackupProgressUpdater(cli, backupInformer.Lister())
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -67,7 +70,7 @@ func runClean(backupOpts backup.BackupOpts, kubecfg string) error {
 	// waiting for the shared informer's store has synced.
 	cache.WaitForCacheSync(ctx.Done(), backupInformer.Informer().HasSynced)
 
-	glog.Infof("start to clean backup %s", backupOpts)
-	bm := backup.NewBackupManager(backupInformer.Lister(), statusUpdater, backupOpts)
+	log.Infof("start to clean backup %s", backupOpts)
+	bm := backup.NewBackupManager(backupInformer.Lister(), statusUpdater, progressUpdater, cli, dynamicCli, backupOpts)
 	return bm.ProcessCleanBackup()
 }
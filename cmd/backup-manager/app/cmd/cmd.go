@@ -14,6 +14,9 @@
 package cmd
 
 import (
+	"flag"
+
+	"github.com/pingcap/tidb-operator/pkg/log"
 	"github.com/spf13/cobra"
 )
 
@@ -25,6 +28,10 @@ func NewBackupMgrCommand() *cobra.Command {
 		Short: "Helper for backup manage",
 		Long:  "Dump tidb cluster data, as well as backup and restore tidb cluster data",
 		Run:   runHelp,
+		// the go flags of the logger are parsed by cobra with the other flags
+		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
+			return log.Init(flag.CommandLine)
+		},
 	}
 
 	cmds.PersistentFlags().StringVarP(&kubecfg, "kubeconfig", "k", "", "Path to kubeconfig file, omit this if run in cluster.")
@@ -32,6 +39,7 @@ func NewBackupMgrCommand() *cobra.Command {
 	cmds.AddCommand(NewBackupCommand())
 	cmds.AddCommand(NewRestoreCommand())
 	cmds.AddCommand(NewCleanCommand())
+	cmds.AddCommand(NewReplicateCommand())
 	return cmds
 }
 
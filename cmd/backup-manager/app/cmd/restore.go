@@ -15,15 +15,16 @@ package cmd
 
 import (
 	"context"
+	"os"
 
 	// registry mysql drive
 	_ "github.com/go-sql-driver/mysql"
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/constants"
 	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/restore"
 	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/util"
 	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/tools/cache"
 	cmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
@@ -38,13 +39,14 @@ func NewRestoreCommand() *cobra.Command {
 		Short: "Restore specific tidb cluster.",
 		Run: func(cmd *cobra.Command, args []string) {
 			util.ValidCmdFlags(cmd.CommandPath(), cmd.LocalFlags())
+			// the password is passed by env rather than flag, so that it is not exposed in the args
+			ro.Password = os.Getenv(constants.TidbPasswordEnv)
 			cmdutil.CheckErr(runRestore(ro, kubecfg))
 		},
 	}
 
 	cmd.Flags().StringVarP(&ro.Namespace, "namespace", "n", "", "Tidb cluster's namespace")
 	cmd.Flags().StringVarP(&ro.TcName, "tidbcluster", "t", "", "Tidb cluster name")
-	cmd.Flags().StringVarP(&ro.Password, "password", "p", "", "Password to use when connecting to tidb cluster")
 	cmd.Flags().StringVarP(&ro.TidbSvc, "tidbservice", "s", "", "Tidb cluster access service address")
 	cmd.Flags().StringVarP(&ro.User, "user", "u", "", "User for login tidb cluster")
 	cmd.Flags().StringVarP(&ro.RestoreName, "restoreName", "r", "", "Restore CRD object name")
@@ -63,6 +65,7 @@ func runRestore(restoreOpts restore.RestoreOpts, kubecfg string) error {
 	recorder := util.NewEventRecorder(kubeCli, "restore")
 	restoreInformer := informerFactory.Pingcap().V1alpha1().Restores()
 	statusUpdater := controller.NewRealRestoreConditionUpdater(cli, restoreInformer.Lister(), recorder)
+	progressUpdater := controller.NewRealRestoreProgressUpdater(cli, restoreInformer.Lister())
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -71,7 +74,7 @@ func runRestore(restoreOpts restore.RestoreOpts, kubecfg string) error {
 	// waiting for the shared informer's store has synced.
 	cache.WaitForCacheSync(ctx.Done(), restoreInformer.Informer().HasSynced)
 
-	glog.Infof("start to process restore %s", restoreOpts)
-	rm := restore.NewRestoreManager(restoreInformer.Lister(), statusUpdater, restoreOpts)
+	log.Infof("start to process restore %s", restoreOpts)
+	rm := restore.NewRestoreManager(restoreInformer.Lister(), statusUpdater, progressUpdater, kubeCli, cli, restoreOpts)
 	return rm.ProcessRestore()
 }
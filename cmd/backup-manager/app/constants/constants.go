@@ -31,20 +31,100 @@ const (
 	// TikvGCVariable is the tikv gc life time variable name
 	TikvGCVariable = "tikv_gc_life_time"
 
+	// PDPort is the client port of pd service
+	PDPort = 2379
+
+	// TidbStatusPort is the status port of tidb service
+	TidbStatusPort = 10080
+
+	// TidbPasswordEnv is the env which holds the password of tidb, it is set from
+	// the tidb secret, so that the password is not exposed in the args of the job
+	TidbPasswordEnv = "TIDB_PASSWORD"
+
 	// TidbMetaDB is the database name for store meta info
 	TidbMetaDB = "mysql"
 
 	// TidBMetaTable is the table name for store meta info
 	TidbMetaTable = "tidb"
 
+	// DefaultDumplingThreads is the default number of dumpling's export threads
+	DefaultDumplingThreads = 16
+
+	// LightningConfigFile is the name of lightning's config file
+	LightningConfigFile = "tidb-lightning.toml"
+
+	// LightningCheckpointFile is the name of lightning's checkpoint file
+	LightningCheckpointFile = "tidb_lightning_checkpoint.pb"
+
+	// ProgressReportInterval is the interval of reporting the progress of a running backup
+	ProgressReportInterval = 30 * time.Second
+
+	// LogBackupStatusReportDuration is how long the job of a started log backup task
+	// reports the checkpoint of the task before it completes
+	LogBackupStatusReportDuration = 5 * time.Minute
+
+	// VolumeSnapshotPollInterval is the interval of checking whether the VolumeSnapshots are ready to use
+	VolumeSnapshotPollInterval = 10 * time.Second
+
+	// TidbClusterPollInterval is the interval of checking whether the restored tidb cluster is ready
+	TidbClusterPollInterval = 10 * time.Second
+
+	// TidbClusterReadyTimeout is the timeout of waiting for the restored tidb cluster to be ready
+	TidbClusterReadyTimeout = 30 * time.Minute
+
+	// PDRecoverAllocIDMargin is added to the largest id allocated by PD of the backed up
+	// cluster to get the id from which the recovered PD allocates ids, it covers the ids
+	// allocated by PD in batches which are not found in the regions yet
+	PDRecoverAllocIDMargin = 100000000
+
+	// ClusterClientTLSPath is the mount path of the client certificate of the tidb cluster,
+	// it is the same as defined in pkg/backup/constants
+	ClusterClientTLSPath = "/var/lib/tls"
+
+	// MaxWarmUpFailedTables is the max number of the tables failed to be warmed up recorded in restore's status
+	MaxWarmUpFailedTables = 10
+
+	// MaxEstimatedPercentage is the max percentage of the progress estimated by the uploaded data size
+	MaxEstimatedPercentage = 99
+
 	// DefaultArchiveExtention represent the data archive type
 	DefaultArchiveExtention = ".tgz"
 
+	// BackupDataMountPath is the mount path of the pvc which keeps the data of
+	// a backup with pvc storage, it is the same as defined in pkg/backup/constants
+	BackupDataMountPath = "/backup-data"
+
 	// RcloneConfigFile represents the path to the file that contains rclone
 	// configs. This path should be the same as defined in docker entrypoint
 	// script from backup-manager/entrypoint.sh. /tmp/rclone.conf
 	RcloneConfigFile = "/tmp/rclone.conf"
 
+	// GCSCredentialsFile represents the path to the JSON key of the GCP service
+	// account, it is created by the entrypoint script from backup-manager/entrypoint.sh
+	GCSCredentialsFile = "/tmp/google-credentials.json"
+
+	// LogBackupGCSCredentialsFile represents the path to the JSON key of the GCP service
+	// account to access the log backup replayed by the point-in-time restore
+	LogBackupGCSCredentialsFile = "/tmp/log-backup-google-credentials.json"
+
+	// LogBackupStorageEnvPrefix is the prefix of the env of the storage of the log
+	// backup, it is the same as defined in pkg/backup/constants
+	LogBackupStorageEnvPrefix = "LOG_BACKUP_"
+
+	// CrypterKeyFile represents the path to the key of BR's client-side encryption, it is
+	// written from the BR_CRYPTER_KEY env so that the key is not exposed in the args of br
+	CrypterKeyFile = "/tmp/br-crypter.key"
+
 	// RcloneConfigArg represents the config argument to rclone cmd
 	RcloneConfigArg = "--config=" + RcloneConfigFile
+
+	// SecondaryStorageRemotePrefix is the name prefix of the rclone remotes of
+	// the secondary storages, it is the same as defined in pkg/backup/constants
+	SecondaryStorageRemotePrefix = "secondary"
 )
+
+// DefaultDumplingTableFilter exports all tables excluding the system schemas
+var DefaultDumplingTableFilter = []string{
+	"*.*",
+	"!/^(mysql|test|INFORMATION_SCHEMA|PERFORMANCE_SCHEMA|METRICS_SCHEMA|INSPECTION_SCHEMA)$/.*",
+}
@@ -15,22 +15,29 @@ package restore
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
-	"github.com/golang/glog"
+	"github.com/pingcap/tidb-operator/pkg/log"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
 	listers "github.com/pingcap/tidb-operator/pkg/client/listers/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 )
 
 // RestoreManager mainly used to manage backup related work
 type RestoreManager struct {
-	restoreLister listers.RestoreLister
-	StatusUpdater controller.RestoreConditionUpdaterInterface
+	restoreLister   listers.RestoreLister
+	StatusUpdater   controller.RestoreConditionUpdaterInterface
+	ProgressUpdater controller.RestoreProgressUpdaterInterface
+	kubeCli         kubernetes.Interface
+	cli             versioned.Interface
 	RestoreOpts
 }
 
@@ -38,10 +45,16 @@ type RestoreManager struct {
 func NewRestoreManager(
 	restoreLister listers.RestoreLister,
 	statusUpdater controller.RestoreConditionUpdaterInterface,
+	progressUpdater controller.RestoreProgressUpdaterInterface,
+	kubeCli kubernetes.Interface,
+	cli versioned.Interface,
 	backupOpts RestoreOpts) *RestoreManager {
 	return &RestoreManager{
 		restoreLister,
 		statusUpdater,
+		progressUpdater,
+		kubeCli,
+		cli,
 		backupOpts,
 	}
 }
@@ -53,8 +66,12 @@ func (rm *RestoreManager) ProcessRestore() error {
 		return fmt.Errorf("can't find cluster %s restore %s CRD object, err: %v", rm, rm.RestoreName, err)
 	}
 
+	if restore.Spec.VolumeSnapshot != nil {
+		// the backup taken by volume snapshots has no backup path
+		return rm.performVolumeSnapshotRestore(restore.DeepCopy())
+	}
 	if rm.BackupPath == "" {
-		glog.Errorf("backup %s path is empty", rm.BackupName)
+		log.Errorf("backup %s path is empty", rm.BackupName)
 		return rm.StatusUpdater.Update(restore, &v1alpha1.RestoreCondition{
 			Type:    v1alpha1.RestoreFailed,
 			Status:  corev1.ConditionTrue,
@@ -62,9 +79,163 @@ func (rm *RestoreManager) ProcessRestore() error {
 			Message: fmt.Sprintf("backup %s path is empty", rm.BackupName),
 		})
 	}
+	if restore.Spec.PitrRestoredTs != "" {
+		return rm.performPitrRestore(restore.DeepCopy())
+	}
+	if restore.Spec.Lightning != nil {
+		return rm.performLightningRestore(restore.DeepCopy())
+	}
+	if backupPaths := os.Getenv("BR_BACKUP_PATHS"); backupPaths != "" {
+		// the backup taken by br is restored by br, after its base backups if it is incremental
+		return rm.performBRRestore(restore.DeepCopy(), strings.Split(backupPaths, ","))
+	}
 	return rm.performRestore(restore.DeepCopy())
 }
 
+func (rm *RestoreManager) performBRRestore(restore *v1alpha1.Restore, backupPaths []string) error {
+	started := time.Now()
+
+	err := rm.StatusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+		Type:   v1alpha1.RestoreRunning,
+		Status: corev1.ConditionTrue,
+	})
+	if err != nil {
+		return err
+	}
+
+	// the restored data is verified only if the data of every backup is verified
+	checksumVerified := true
+	for _, backupPath := range backupPaths {
+		verified, err := rm.restoreDataByBR(backupPath, restore.IsChecksumEnabled(), restore.Spec.TableFilter)
+		if err != nil {
+			log.Errorf("restore cluster %s from backup %s by br failed, err: %s", rm, backupPath, err)
+			return rm.StatusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+				Type:    v1alpha1.RestoreFailed,
+				Status:  corev1.ConditionTrue,
+				Reason:  "RestoreDataByBRFailed",
+				Message: err.Error(),
+			})
+		}
+		log.Infof("restore cluster %s from backup %s by br success", rm, backupPath)
+		checksumVerified = checksumVerified && verified
+	}
+
+	rm.warmUpTables(restore)
+
+	finish := time.Now()
+
+	restore.Status.TimeStarted = metav1.Time{Time: started}
+	restore.Status.TimeCompleted = metav1.Time{Time: finish}
+	restore.Status.ChecksumVerified = checksumVerified
+
+	return rm.StatusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+		Type:   v1alpha1.RestoreComplete,
+		Status: corev1.ConditionTrue,
+	})
+}
+
+func (rm *RestoreManager) performPitrRestore(restore *v1alpha1.Restore) error {
+	started := time.Now()
+
+	err := rm.StatusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+		Type:   v1alpha1.RestoreRunning,
+		Status: corev1.ConditionTrue,
+	})
+	if err != nil {
+		return err
+	}
+
+	logBackupPath := os.Getenv("LOG_BACKUP_PATH")
+	if logBackupPath == "" {
+		log.Errorf("cluster %s log backup path is empty", rm)
+		return rm.StatusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+			Type:    v1alpha1.RestoreFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "LogBackupPathIsEmpty",
+			Message: fmt.Sprintf("log backup %s path is empty", restore.Spec.LogBackup),
+		})
+	}
+
+	restoredTs := restore.Spec.PitrRestoredTs
+	checksumVerified, err := rm.restoreDataByBRPoint(logBackupPath, restoredTs, restore.IsChecksumEnabled(), restore.Spec.TableFilter)
+	if err != nil {
+		log.Errorf("restore cluster %s from backup %s and log backup %s to %s by br failed, err: %s", rm, rm.BackupPath, logBackupPath, restoredTs, err)
+		return rm.StatusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+			Type:    v1alpha1.RestoreFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "RestorePointByBRFailed",
+			Message: err.Error(),
+		})
+	}
+	log.Infof("restore cluster %s from backup %s and log backup %s to %s by br success", rm, rm.BackupPath, logBackupPath, restoredTs)
+
+	rm.warmUpTables(restore)
+
+	finish := time.Now()
+
+	restore.Status.TimeStarted = metav1.Time{Time: started}
+	restore.Status.TimeCompleted = metav1.Time{Time: finish}
+	restore.Status.RestoredTs = restoredTs
+	restore.Status.ChecksumVerified = checksumVerified
+
+	return rm.StatusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+		Type:   v1alpha1.RestoreComplete,
+		Status: corev1.ConditionTrue,
+	})
+}
+
+func (rm *RestoreManager) performLightningRestore(restore *v1alpha1.Restore) error {
+	started := time.Now()
+
+	err := rm.StatusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+		Type:   v1alpha1.RestoreRunning,
+		Status: corev1.ConditionTrue,
+	})
+	if err != nil {
+		return err
+	}
+
+	workDir := rm.getLightningWorkDir()
+	cfgFile, err := rm.writeLightningConfig(workDir, restore.Spec.Lightning, restore.IsChecksumEnabled(), restore.Spec.TableFilter)
+	if err != nil {
+		log.Errorf("write cluster %s lightning config failed, err: %s", rm, err)
+		return rm.StatusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+			Type:    v1alpha1.RestoreFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "WriteLightningConfigFailed",
+			Message: err.Error(),
+		})
+	}
+	log.Infof("write cluster %s lightning config %s success", rm, cfgFile)
+
+	// lightning doesn't verify the checksum of the data imported by the tidb backend
+	checksum := restore.IsChecksumEnabled() && restore.Spec.Lightning.Backend == v1alpha1.LightningBackendLocal
+	checksumVerified, err := rm.importDataByLightning(cfgFile, checksum)
+	if err != nil {
+		log.Errorf("restore cluster %s from backup %s by lightning failed, err: %s", rm, rm.BackupPath, err)
+		return rm.StatusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+			Type:    v1alpha1.RestoreFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "ImportDataByLightningFailed",
+			Message: fmt.Sprintf("import backup %s data by lightning failed, err: %v", rm.BackupPath, err),
+		})
+	}
+	log.Infof("restore cluster %s from backup %s by lightning success", rm, rm.BackupPath)
+
+	rm.warmUpTables(restore)
+
+	finish := time.Now()
+
+	restore.Status.TimeStarted = metav1.Time{Time: started}
+	restore.Status.TimeCompleted = metav1.Time{Time: finish}
+	restore.Status.ChecksumVerified = checksumVerified
+
+	return rm.StatusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+		Type:   v1alpha1.RestoreComplete,
+		Status: corev1.ConditionTrue,
+	})
+}
+
 func (rm *RestoreManager) performRestore(restore *v1alpha1.Restore) error {
 	started := time.Now()
 
@@ -78,7 +249,7 @@ func (rm *RestoreManager) performRestore(restore *v1alpha1.Restore) error {
 
 	restoreDataPath := rm.getRestoreDataPath()
 	if err := rm.downloadBackupData(restoreDataPath); err != nil {
-		glog.Errorf("download cluster %s backup %s data failed, err: %s", rm, rm.BackupPath, err)
+		log.Errorf("download cluster %s backup %s data failed, err: %s", rm, rm.BackupPath, err)
 		return rm.StatusUpdater.Update(restore, &v1alpha1.RestoreCondition{
 			Type:    v1alpha1.RestoreFailed,
 			Status:  corev1.ConditionTrue,
@@ -86,12 +257,12 @@ func (rm *RestoreManager) performRestore(restore *v1alpha1.Restore) error {
 			Message: fmt.Sprintf("download backup %s data failed, err: %v", rm.BackupPath, err),
 		})
 	}
-	glog.Infof("download cluster %s backup %s data success", rm, rm.BackupPath)
+	log.Infof("download cluster %s backup %s data success", rm, rm.BackupPath)
 
 	restoreDataDir := filepath.Dir(restoreDataPath)
 	unarchiveDataPath, err := unarchiveBackupData(restoreDataPath, restoreDataDir)
 	if err != nil {
-		glog.Errorf("unarchive cluster %s backup %s data failed, err: %s", rm, restoreDataPath, err)
+		log.Errorf("unarchive cluster %s backup %s data failed, err: %s", rm, restoreDataPath, err)
 		return rm.StatusUpdater.Update(restore, &v1alpha1.RestoreCondition{
 			Type:    v1alpha1.RestoreFailed,
 			Status:  corev1.ConditionTrue,
@@ -99,11 +270,11 @@ func (rm *RestoreManager) performRestore(restore *v1alpha1.Restore) error {
 			Message: fmt.Sprintf("unarchive backup %s data failed, err: %v", restoreDataPath, err),
 		})
 	}
-	glog.Infof("unarchive cluster %s backup %s data success", rm, restoreDataPath)
+	log.Infof("unarchive cluster %s backup %s data success", rm, restoreDataPath)
 
 	err = rm.loadTidbClusterData(unarchiveDataPath)
 	if err != nil {
-		glog.Errorf("restore cluster %s from backup %s failed, err: %s", rm, rm.BackupPath, err)
+		log.Errorf("restore cluster %s from backup %s failed, err: %s", rm, rm.BackupPath, err)
 		return rm.StatusUpdater.Update(restore, &v1alpha1.RestoreCondition{
 			Type:    v1alpha1.RestoreFailed,
 			Status:  corev1.ConditionTrue,
@@ -111,7 +282,9 @@ func (rm *RestoreManager) performRestore(restore *v1alpha1.Restore) error {
 			Message: fmt.Sprintf("loader backup %s data failed, err: %v", restoreDataPath, err),
 		})
 	}
-	glog.Infof("restore cluster %s from backup %s success", rm, rm.BackupPath)
+	log.Infof("restore cluster %s from backup %s success", rm, rm.BackupPath)
+
+	rm.warmUpTables(restore)
 
 	finish := time.Now()
 
@@ -15,13 +15,19 @@ package restore
 
 import (
 	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 
+	"github.com/BurntSushi/toml"
 	"github.com/mholt/archiver"
 	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/constants"
 	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/util"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
 )
 
 // RestoreOpts contains the input arguments to the restore command
@@ -52,6 +58,13 @@ func (ro *RestoreOpts) downloadBackupData(localPath string) error {
 	}
 
 	remoteBucket := util.NormalizeBucketURI(ro.BackupPath)
+	pvcScheme := string(v1alpha1.BackupStorageTypePVC) + "://"
+	if strings.HasPrefix(ro.BackupPath, pvcScheme) {
+		// the backup data is in the mounted pvc, e.g. pvc://backup-demo1/ns_tc/backup.tgz
+		// is /backup-data/ns_tc/backup.tgz
+		pvcPath := strings.TrimPrefix(ro.BackupPath, pvcScheme)
+		remoteBucket = filepath.Join(constants.BackupDataMountPath, pvcPath[strings.Index(pvcPath, "/")+1:])
+	}
 	rcCopy := exec.Command("rclone", constants.RcloneConfigArg, "copyto", remoteBucket, localPath)
 	if err := rcCopy.Start(); err != nil {
 		return fmt.Errorf("cluster %s, start rclone copyto command for download backup data %s falied, err: %v", ro, ro.BackupPath, err)
@@ -77,11 +90,320 @@ func (ro *RestoreOpts) loadTidbClusterData(restorePath string) error {
 
 	output, err := exec.Command("/loader", args...).CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("cluster %s, execute loader command %v failed, output: %s, err: %v", ro, args, string(output), err)
+		return fmt.Errorf("cluster %s, execute loader command failed, output: %s, err: %v", ro, string(output), err)
 	}
 	return nil
 }
 
+// lightningConfig is the subset of tidb-lightning's config file used by restore
+type lightningConfig struct {
+	Lightning    lightningSection    `toml:"lightning"`
+	Checkpoint   checkpointSection   `toml:"checkpoint"`
+	TikvImporter tikvImporterSection `toml:"tikv-importer"`
+	Mydumper     mydumperSection     `toml:"mydumper"`
+	Tidb         tidbSection         `toml:"tidb"`
+	PostRestore  postRestoreSection  `toml:"post-restore"`
+}
+
+type lightningSection struct {
+	Level             string `toml:"level"`
+	CheckRequirements bool   `toml:"check-requirements"`
+}
+
+type checkpointSection struct {
+	Enable           bool   `toml:"enable"`
+	Driver           string `toml:"driver"`
+	DSN              string `toml:"dsn"`
+	KeepAfterSuccess bool   `toml:"keep-after-success"`
+}
+
+type tikvImporterSection struct {
+	Backend     string `toml:"backend"`
+	SortedKVDir string `toml:"sorted-kv-dir,omitempty"`
+}
+
+type mydumperSection struct {
+	DataSourceDir string   `toml:"data-source-dir"`
+	Filter        []string `toml:"filter,omitempty"`
+}
+
+type postRestoreSection struct {
+	Checksum string `toml:"checksum"`
+}
+
+type tidbSection struct {
+	Host       string `toml:"host"`
+	Port       int    `toml:"port"`
+	User       string `toml:"user"`
+	Password   string `toml:"password"`
+	StatusPort int    `toml:"status-port"`
+	PDAddr     string `toml:"pd-addr"`
+}
+
+// getLightningWorkDir returns the dir on restore's PV which stores lightning's
+// config, checkpoint and sorted kv files. The PV outlives the restore job, so a
+// failed import can resume from the checkpoint by creating the restore again.
+func (ro *RestoreOpts) getLightningWorkDir() string {
+	NsClusterName := fmt.Sprintf("%s_%s", ro.Namespace, ro.TcName)
+	return filepath.Join(constants.BackupRootPath, NsClusterName, "lightning", filepath.Base(ro.BackupPath))
+}
+
+func (ro *RestoreOpts) getPDAddress() string {
+	return fmt.Sprintf("%s:%d", controller.PDMemberName(ro.TcName), constants.PDPort)
+}
+
+// writeLightningConfig writes the config file of lightning to workDir and
+// returns the path of it
+func (ro *RestoreOpts) writeLightningConfig(workDir string, lightning *v1alpha1.LightningConfig, checksum bool, filters []string) (string, error) {
+	if err := util.EnsureDirectoryExist(workDir); err != nil {
+		return "", err
+	}
+
+	backend := lightning.Backend
+	if backend == "" {
+		backend = v1alpha1.LightningBackendTiDB
+	}
+	storageType, remotePath, err := parseBucketURI(ro.BackupPath)
+	if err != nil {
+		return "", err
+	}
+	dataSourceDir := fmt.Sprintf("s3://%s?%s", remotePath, getS3Query("").Encode())
+	if storageType == string(v1alpha1.BackupStorageTypeGCS) {
+		dataSourceDir = fmt.Sprintf("gcs://%s?%s", remotePath, getGCSQuery("", constants.GCSCredentialsFile).Encode())
+	}
+	cfg := lightningConfig{
+		Lightning: lightningSection{
+			Level:             "info",
+			CheckRequirements: true,
+		},
+		Checkpoint: checkpointSection{
+			Enable:           true,
+			Driver:           "file",
+			DSN:              filepath.Join(workDir, constants.LightningCheckpointFile),
+			KeepAfterSuccess: false,
+		},
+		TikvImporter: tikvImporterSection{
+			Backend: string(backend),
+		},
+		Mydumper: mydumperSection{
+			DataSourceDir: dataSourceDir,
+			Filter:        filters,
+		},
+		Tidb: tidbSection{
+			Host:       ro.TidbSvc,
+			Port:       4000,
+			User:       ro.User,
+			Password:   ro.Password,
+			StatusPort: constants.TidbStatusPort,
+			PDAddr:     ro.getPDAddress(),
+		},
+	}
+	cfg.PostRestore.Checksum = "off"
+	if checksum {
+		cfg.PostRestore.Checksum = "required"
+	}
+	if backend == v1alpha1.LightningBackendLocal {
+		cfg.TikvImporter.SortedKVDir = filepath.Join(workDir, "sorted-kv")
+	}
+
+	cfgFile := filepath.Join(workDir, constants.LightningConfigFile)
+	f, err := os.Create(cfgFile)
+	if err != nil {
+		return "", fmt.Errorf("cluster %s, create lightning config file %s failed, err: %v", ro, cfgFile, err)
+	}
+	defer f.Close()
+	if err := toml.NewEncoder(f).Encode(cfg); err != nil {
+		return "", fmt.Errorf("cluster %s, encode lightning config file %s failed, err: %v", ro, cfgFile, err)
+	}
+	return cfgFile, nil
+}
+
+// getS3Query returns the query parameters of the storage URI to access the S3
+// compatible storage, the options are read from the env prefixed by envPrefix
+func getS3Query(envPrefix string) url.Values {
+	query := url.Values{}
+	if endpoint := os.Getenv(envPrefix + "S3_ENDPOINT"); endpoint != "" {
+		query.Set("endpoint", endpoint)
+	}
+	if provider := os.Getenv(envPrefix + "S3_PROVIDER"); provider != "" {
+		query.Set("provider", strings.ToLower(provider))
+	}
+	if region := os.Getenv(envPrefix + "AWS_REGION"); region != "" {
+		query.Set("region", region)
+	}
+	if forcePathStyle := os.Getenv(envPrefix + "S3_FORCE_PATH_STYLE"); forcePathStyle != "" {
+		query.Set("force-path-style", forcePathStyle)
+	}
+	return query
+}
+
+// getGCSQuery returns the query parameters of the storage URI to access the
+// google cloud storage by the JSON key in credentialsFile
+func getGCSQuery(envPrefix, credentialsFile string) url.Values {
+	query := url.Values{}
+	if os.Getenv(envPrefix+"GCS_SERVICE_ACCOUNT_JSON_KEY") != "" {
+		query.Set("credentials-file", credentialsFile)
+	}
+	return query
+}
+
+// importDataByLightning imports the dumpling export of BackupPath by lightning,
+// lightning resumes from the checkpoint in workDir if a former import failed.
+// It returns whether the imported data is verified by checksum.
+func (ro *RestoreOpts) importDataByLightning(cfgFile string, checksum bool) (bool, error) {
+	args := []string{
+		fmt.Sprintf("--config=%s", cfgFile),
+	}
+
+	output, err := exec.Command("/tidb-lightning", args...).CombinedOutput()
+	if err != nil {
+		return false, fmt.Errorf("cluster %s, execute lightning command failed, output: %s, err: %v", ro, string(output), err)
+	}
+	return util.IsChecksumVerified(checksum, output), nil
+}
+
+// parseBucketURI returns the storage type and the remote path of bucketURI,
+// e.g. s3://bucket/path
+func parseBucketURI(bucketURI string) (string, string, error) {
+	index := strings.Index(bucketURI, "://")
+	if index <= 0 {
+		return "", "", fmt.Errorf("backup path %s is not a bucket URI", bucketURI)
+	}
+	return bucketURI[:index], bucketURI[index+len("://"):], nil
+}
+
+// getBRStorage returns the storage URI and args of br to access bucketURI,
+// the storage of ceph is accessed as a S3 compatible storage
+func getBRStorage(bucketURI string) (string, []string, error) {
+	storageType, remotePath, err := parseBucketURI(bucketURI)
+	if err != nil {
+		return "", nil, err
+	}
+	return util.GetStorageURI(storageType, remotePath), util.GetStorageArgs(storageType), nil
+}
+
+// getLogBackupStorage returns the storage URI of br to access the log backup of
+// logBackupPath. The log backup may be kept in another storage than the backup,
+// so the options of its storage, read from the env prefixed by LOG_BACKUP_, are
+// set as the query parameters of the URI, which take precedence over the args of br.
+func getLogBackupStorage(logBackupPath, credentialsFile string) (string, error) {
+	storageType, remotePath, err := parseBucketURI(logBackupPath)
+	if err != nil {
+		return "", err
+	}
+	prefix := constants.LogBackupStorageEnvPrefix
+	var query url.Values
+	if storageType == string(v1alpha1.BackupStorageTypeGCS) {
+		query = getGCSQuery(prefix, credentialsFile)
+		if key := os.Getenv(prefix + "GCS_SERVICE_ACCOUNT_JSON_KEY"); key != "" {
+			if err := ioutil.WriteFile(credentialsFile, []byte(key), 0600); err != nil {
+				return "", fmt.Errorf("write log backup gcs credentials file %s failed, err: %v", credentialsFile, err)
+			}
+		}
+	} else {
+		query = getS3Query(prefix)
+		if accessKey := os.Getenv(prefix + "AWS_ACCESS_KEY_ID"); accessKey != "" {
+			// br only accepts the credentials of a S3 storage by its args or URI,
+			// the args of br are never logged
+			query.Set("access-key", accessKey)
+			query.Set("secret-access-key", os.Getenv(prefix+"AWS_SECRET_ACCESS_KEY"))
+		}
+	}
+	storage := util.GetStorageURI(storageType, remotePath)
+	if len(query) == 0 {
+		return storage, nil
+	}
+	return fmt.Sprintf("%s?%s", storage, query.Encode()), nil
+}
+
+// restoreDataByBR restores the br backup of backupPath, the incremental
+// backup is restored on top of the restored data of its base backup. It
+// returns whether the restored data is verified by checksum.
+func (ro *RestoreOpts) restoreDataByBR(backupPath string, checksum bool, filters []string) (bool, error) {
+	args, err := ro.getBRArgs(backupPath, checksum, filters, constants.CrypterKeyFile)
+	if err != nil {
+		return false, err
+	}
+
+	output, err := exec.Command("/br", args...).CombinedOutput()
+	if err != nil {
+		return false, fmt.Errorf("cluster %s, execute br command failed, output: %s, err: %v", ro, string(output), err)
+	}
+	return util.IsChecksumVerified(checksum, output), nil
+}
+
+// getBRArgs returns the args of `br restore full`, the backup encrypted by
+// br is decrypted by the key written to crypterKeyFile
+func (ro *RestoreOpts) getBRArgs(backupPath string, checksum bool, filters []string, crypterKeyFile string) ([]string, error) {
+	storage, storageArgs, err := getBRStorage(backupPath)
+	if err != nil {
+		return nil, fmt.Errorf("cluster %s, %v", ro, err)
+	}
+	args := []string{
+		"restore",
+		"full",
+		fmt.Sprintf("--pd=%s", ro.getPDAddress()),
+		fmt.Sprintf("--storage=%s", storage),
+		fmt.Sprintf("--checksum=%t", checksum),
+	}
+	for _, filter := range filters {
+		args = append(args, fmt.Sprintf("--filter=%s", filter))
+	}
+	args = append(args, storageArgs...)
+	crypterArgs, err := util.GetCrypterArgs(crypterKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("cluster %s, %v", ro, err)
+	}
+	return append(args, crypterArgs...), nil
+}
+
+// restoreDataByBRPoint restores the br backup of BackupPath and replays the
+// logs of logBackupPath until restoredTs, it returns whether the restored
+// data is verified by checksum
+func (ro *RestoreOpts) restoreDataByBRPoint(logBackupPath, restoredTs string, checksum bool, filters []string) (bool, error) {
+	args, err := ro.getBRPointArgs(logBackupPath, restoredTs, checksum, filters, constants.CrypterKeyFile)
+	if err != nil {
+		return false, err
+	}
+
+	output, err := exec.Command("/br", args...).CombinedOutput()
+	if err != nil {
+		return false, fmt.Errorf("cluster %s, execute br command failed, output: %s, err: %v", ro, string(output), err)
+	}
+	return util.IsChecksumVerified(checksum, output), nil
+}
+
+// getBRPointArgs returns the args of `br restore point`, the backup encrypted by
+// br is decrypted by the key written to crypterKeyFile
+func (ro *RestoreOpts) getBRPointArgs(logBackupPath, restoredTs string, checksum bool, filters []string, crypterKeyFile string) ([]string, error) {
+	fullBackupStorage, storageArgs, err := getBRStorage(ro.BackupPath)
+	if err != nil {
+		return nil, fmt.Errorf("cluster %s, %v", ro, err)
+	}
+	logBackupStorage, err := getLogBackupStorage(logBackupPath, constants.LogBackupGCSCredentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("cluster %s, %v", ro, err)
+	}
+	args := []string{
+		"restore",
+		"point",
+		fmt.Sprintf("--pd=%s", ro.getPDAddress()),
+		fmt.Sprintf("--full-backup-storage=%s", fullBackupStorage),
+		fmt.Sprintf("--storage=%s", logBackupStorage),
+		fmt.Sprintf("--restored-ts=%s", restoredTs),
+		fmt.Sprintf("--checksum=%t", checksum),
+	}
+	for _, filter := range filters {
+		args = append(args, fmt.Sprintf("--filter=%s", filter))
+	}
+	args = append(args, storageArgs...)
+	crypterArgs, err := util.GetCrypterArgs(crypterKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("cluster %s, %v", ro, err)
+	}
+	return append(args, crypterArgs...), nil
+}
+
 // unarchiveBackupData unarchive backup data to dest dir
 func unarchiveBackupData(backupFile, destDir string) (string, error) {
 	var unarchiveBackupPath string
@@ -0,0 +1,249 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restore
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestGetBRPointArgs(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name          string
+		crypterMethod string
+		crypterKey    string
+		expectErr     bool
+		expectArgs    []string
+		expectKey     string
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		dir, err := ioutil.TempDir("", "restore")
+		g.Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		keyFile := filepath.Join(dir, "br-crypter.key")
+
+		os.Setenv("BR_CRYPTER_METHOD", test.crypterMethod)
+		os.Setenv("BR_CRYPTER_KEY", test.crypterKey)
+		defer os.Unsetenv("BR_CRYPTER_METHOD")
+		defer os.Unsetenv("BR_CRYPTER_KEY")
+
+		ro := &RestoreOpts{Namespace: "ns", TcName: "demo", BackupPath: "s3://bucket/ns_demo/backup"}
+		args, err := ro.getBRPointArgs("s3://bucket/ns_demo/log-backup", "400", true, nil, keyFile)
+		if test.expectErr {
+			g.Expect(err).To(HaveOccurred())
+			return
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(args).To(ContainElement("--full-backup-storage=s3://bucket/ns_demo/backup"))
+		g.Expect(args).To(ContainElement("--storage=s3://bucket/ns_demo/log-backup"))
+		g.Expect(args).To(ContainElement("--restored-ts=400"))
+		for _, arg := range test.expectArgs {
+			g.Expect(args).To(ContainElement(arg))
+		}
+		for _, arg := range args {
+			g.Expect(arg).NotTo(ContainSubstring("--crypter.key="))
+		}
+		if test.expectKey == "" {
+			g.Expect(args).NotTo(ContainElement(ContainSubstring("--crypter")))
+			return
+		}
+		g.Expect(args).To(ContainElement("--crypter.key-file=" + keyFile))
+		key, err := ioutil.ReadFile(keyFile)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(string(key)).To(Equal(test.expectKey))
+	}
+
+	tests := []testcase{
+		{
+			name: "not encrypted",
+		},
+		{
+			name:          "encrypted by br",
+			crypterMethod: "aes128-ctr",
+			crypterKey:    "0123456789abcdef0123456789abcdef",
+			expectArgs:    []string{"--crypter.method=aes128-ctr"},
+			expectKey:     "0123456789abcdef0123456789abcdef",
+		},
+		{
+			name:          "crypter key is empty",
+			crypterMethod: "aes128-ctr",
+			expectErr:     true,
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func TestGetPDRecoverArgs(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ro := &RestoreOpts{Namespace: "ns", TcName: "demo"}
+	args := ro.getPDRecoverArgs("6789", 1000, false)
+	g.Expect(args).To(Equal([]string{
+		"-endpoints=http://demo-pd:2379",
+		"-cluster-id=6789",
+		"-alloc-id=100001000",
+	}))
+
+	args = ro.getPDRecoverArgs("6789", 1000, true)
+	g.Expect(args).To(Equal([]string{
+		"-endpoints=https://demo-pd:2379",
+		"-cluster-id=6789",
+		"-alloc-id=100001000",
+		"-cacert=/var/lib/tls/ca.crt",
+		"-cert=/var/lib/tls/client.crt",
+		"-key=/var/lib/tls/client.key",
+	}))
+}
+
+func TestGetTruncateArgs(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	args := getTruncateArgs("demo-tikv-0.demo-tikv-peer.ns.svc:20160", "400", false)
+	g.Expect(args).To(Equal([]string{
+		"--host=demo-tikv-0.demo-tikv-peer.ns.svc:20160",
+		"reset-to-version",
+		"--version=400",
+	}))
+
+	args = getTruncateArgs("demo-tikv-0.demo-tikv-peer.ns.svc:20160", "400", true)
+	g.Expect(args).To(Equal([]string{
+		"--host=demo-tikv-0.demo-tikv-peer.ns.svc:20160",
+		"--ca-path=/var/lib/tls/ca.crt",
+		"--cert-path=/var/lib/tls/client.crt",
+		"--key-path=/var/lib/tls/client.key",
+		"reset-to-version",
+		"--version=400",
+	}))
+}
+
+func TestGetBRArgs(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ro := &RestoreOpts{Namespace: "ns", TcName: "demo"}
+	args, err := ro.getBRArgs("s3://bucket/ns_demo/inc-1", true, []string{"db.*"}, "")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(args[:2]).To(Equal([]string{"restore", "full"}))
+	g.Expect(args).To(ContainElement("--pd=demo-pd:2379"))
+	g.Expect(args).To(ContainElement("--storage=s3://bucket/ns_demo/inc-1"))
+	g.Expect(args).To(ContainElement("--checksum=true"))
+	g.Expect(args).To(ContainElement("--filter=db.*"))
+	g.Expect(args).NotTo(ContainElement(ContainSubstring("--crypter")))
+}
+
+func TestGetBRStorage(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	storage, _, err := getBRStorage("gcs://bucket/ns_demo/backup")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(storage).To(Equal("gcs://bucket/ns_demo/backup"))
+
+	_, _, err = getBRStorage("bucket/ns_demo/backup")
+	g.Expect(err).To(HaveOccurred())
+
+	ro := &RestoreOpts{Namespace: "ns", TcName: "demo"}
+	_, err = ro.getBRArgs("bucket/ns_demo/backup", true, nil, "")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestGetLogBackupStorage(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name          string
+		logBackupPath string
+		env           map[string]string
+		expectErr     bool
+		expectStorage string
+		expectKey     string
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		dir, err := ioutil.TempDir("", "restore")
+		g.Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		credentialsFile := filepath.Join(dir, "google-credentials.json")
+
+		for key, value := range test.env {
+			os.Setenv(key, value)
+			defer os.Unsetenv(key)
+		}
+
+		storage, err := getLogBackupStorage(test.logBackupPath, credentialsFile)
+		if test.expectErr {
+			g.Expect(err).To(HaveOccurred())
+			return
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(storage).To(Equal(strings.Replace(test.expectStorage, "$FILE", url.QueryEscape(credentialsFile), 1)))
+		if test.expectKey != "" {
+			key, err := ioutil.ReadFile(credentialsFile)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(string(key)).To(Equal(test.expectKey))
+		}
+	}
+
+	tests := []testcase{
+		{
+			name:          "storage of the job",
+			logBackupPath: "s3://bucket/ns_demo/log-backup",
+			expectStorage: "s3://bucket/ns_demo/log-backup",
+		},
+		{
+			name:          "s3 of its own",
+			logBackupPath: "s3://bucket/ns_demo/log-backup",
+			env: map[string]string{
+				"LOG_BACKUP_S3_PROVIDER":           "AWS",
+				"LOG_BACKUP_AWS_REGION":            "us-west-2",
+				"LOG_BACKUP_S3_ENDPOINT":           "https://s3.us-west-2.amazonaws.com",
+				"LOG_BACKUP_AWS_ACCESS_KEY_ID":     "access",
+				"LOG_BACKUP_AWS_SECRET_ACCESS_KEY": "secret",
+				// the credentials of the backup are not used
+				"AWS_ACCESS_KEY_ID": "backup-access",
+			},
+			expectStorage: "s3://bucket/ns_demo/log-backup?access-key=access&endpoint=https%3A%2F%2Fs3.us-west-2.amazonaws.com&provider=aws&region=us-west-2&secret-access-key=secret",
+		},
+		{
+			name:          "gcs of its own",
+			logBackupPath: "gcs://bucket/ns_demo/log-backup",
+			env: map[string]string{
+				"LOG_BACKUP_GCS_SERVICE_ACCOUNT_JSON_KEY": "{\"type\": \"service_account\"}",
+			},
+			expectStorage: "gcs://bucket/ns_demo/log-backup?credentials-file=$FILE",
+			expectKey:     "{\"type\": \"service_account\"}",
+		},
+		{
+			name:          "not a bucket URI",
+			logBackupPath: "bucket/ns_demo/log-backup",
+			expectErr:     true,
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restore
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/constants"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// getClusterTLSArgs returns the args of the client certificate of the cluster
+// in the form of the tool, e.g. --ca-path of tikv-ctl or -cacert of pd-recover
+func getClusterTLSArgs(caFlag, certFlag, keyFlag string) []string {
+	return []string{
+		fmt.Sprintf("%s=%s", caFlag, path.Join(constants.ClusterClientTLSPath, "ca.crt")),
+		fmt.Sprintf("%s=%s", certFlag, path.Join(constants.ClusterClientTLSPath, "client.crt")),
+		fmt.Sprintf("%s=%s", keyFlag, path.Join(constants.ClusterClientTLSPath, "client.key")),
+	}
+}
+
+// getPDRecoverArgs returns the args of pd-recover, the recovered PD allocates
+// ids above the largest id allocated by PD of the backed up cluster
+func (ro *RestoreOpts) getPDRecoverArgs(clusterID string, maxAllocID uint64, tlsEnabled bool) []string {
+	scheme := "http"
+	if tlsEnabled {
+		scheme = "https"
+	}
+	args := []string{
+		fmt.Sprintf("-endpoints=%s://%s", scheme, ro.getPDAddress()),
+		fmt.Sprintf("-cluster-id=%s", clusterID),
+		fmt.Sprintf("-alloc-id=%d", maxAllocID+constants.PDRecoverAllocIDMargin),
+	}
+	if tlsEnabled {
+		args = append(args, getClusterTLSArgs("-cacert", "-cert", "-key")...)
+	}
+	return args
+}
+
+// recoverPD sets the cluster id of the PD started with empty volumes to the one
+// of the backup, and restarts the PD pods to load it. The TiKV stores restored
+// from the snapshots register themselves with their former store ids afterwards.
+func (rm *RestoreManager) recoverPD(clusterID string, maxAllocID uint64, tlsEnabled bool) error {
+	args := rm.getPDRecoverArgs(clusterID, maxAllocID, tlsEnabled)
+	output, err := exec.Command("/pd-recover", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("cluster %s, execute pd-recover command %v failed, output: %s, err: %v", rm, args, string(output), err)
+	}
+
+	selector, err := label.New().Instance(rm.TcName).PD().Selector()
+	if err != nil {
+		return fmt.Errorf("cluster %s, generate pd selector failed, err: %v", rm, err)
+	}
+	pods, err := rm.kubeCli.CoreV1().Pods(rm.Namespace).List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return fmt.Errorf("cluster %s, list pd pods failed, err: %v", rm, err)
+	}
+	for _, pod := range pods.Items {
+		err := rm.kubeCli.CoreV1().Pods(rm.Namespace).Delete(pod.GetName(), &metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("cluster %s, restart pd pod %s failed, err: %v", rm, pod.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// getTruncateArgs returns the args of tikv-ctl which discards the data written
+// after the commitTs from the TiKV store
+func getTruncateArgs(storeAddr, commitTs string, tlsEnabled bool) []string {
+	args := []string{
+		fmt.Sprintf("--host=%s", storeAddr),
+	}
+	if tlsEnabled {
+		args = append(args, getClusterTLSArgs("--ca-path", "--cert-path", "--key-path")...)
+	}
+	return append(args, "reset-to-version", fmt.Sprintf("--version=%s", commitTs))
+}
+
+// truncateToCommitTs discards the data written after the commitTs of the backup
+// from all the TiKV stores. The volumes are snapshotted one after another while
+// the cluster is serving, so the data after the commitTs may be only found in
+// some of the stores, which breaks the consistency of the restored cluster.
+func (rm *RestoreManager) truncateToCommitTs(commitTs string, tlsEnabled bool) error {
+	pdClient := pdapi.NewDefaultPDControl().GetPDClient(pdapi.Namespace(rm.Namespace), rm.TcName, tlsEnabled)
+	stores, err := pdClient.GetStores()
+	if err != nil {
+		return fmt.Errorf("cluster %s, get stores failed, err: %v", rm, err)
+	}
+	for _, store := range stores.Stores {
+		if store.Store == nil || store.Store.GetState() == metapb.StoreState_Tombstone {
+			continue
+		}
+		args := getTruncateArgs(store.Store.GetAddress(), commitTs, tlsEnabled)
+		output, err := exec.Command("/tikv-ctl", args...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("cluster %s, execute tikv-ctl command %v failed, output: %s, err: %v", rm, args, string(output), err)
+		}
+		log.Infof("truncate cluster %s store %d to commitTs %s success", rm, store.Store.GetId(), commitTs)
+	}
+	return nil
+}
+
+// waitTidbCluster waits for the restored cluster to be ready, it times out if
+// the cluster is not ready in TidbClusterReadyTimeout, e.g. the restored volumes
+// can't be attached
+func (rm *RestoreManager) waitTidbCluster(ready func(tc *v1alpha1.TidbCluster) bool) error {
+	var getErr error
+	err := wait.PollImmediate(constants.TidbClusterPollInterval, constants.TidbClusterReadyTimeout, func() (bool, error) {
+		tc, err := rm.cli.PingcapV1alpha1().TidbClusters(rm.Namespace).Get(rm.TcName, metav1.GetOptions{})
+		if err != nil {
+			log.Warningf("get cluster %s failed, err: %s", rm, err)
+			getErr = err
+			return false, nil
+		}
+		getErr = nil
+		return ready(tc), nil
+	})
+	if err == wait.ErrWaitTimeout && getErr != nil {
+		return fmt.Errorf("cluster %s is not ready in %s, get cluster failed, err: %v", rm, constants.TidbClusterReadyTimeout, getErr)
+	}
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("cluster %s is not ready in %s", rm, constants.TidbClusterReadyTimeout)
+	}
+	return err
+}
+
+// performVolumeSnapshotRestore brings up the cluster whose TiKV and PD volumes
+// are provisioned from the volume snapshots by tidb-operator. PD is recovered
+// first if it is started with empty volumes, and the data of TiKV is truncated
+// to the commitTs of the backup before TiDB is ready.
+func (rm *RestoreManager) performVolumeSnapshotRestore(restore *v1alpha1.Restore) error {
+	started := time.Now()
+
+	err := rm.StatusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+		Type:   v1alpha1.RestoreRunning,
+		Status: corev1.ConditionTrue,
+	})
+	if err != nil {
+		return err
+	}
+
+	tc, err := rm.cli.PingcapV1alpha1().TidbClusters(rm.Namespace).Get(rm.TcName, metav1.GetOptions{})
+	if err != nil {
+		log.Errorf("get cluster %s failed, err: %s", rm, err)
+		return rm.StatusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+			Type:    v1alpha1.RestoreFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "GetTidbClusterFailed",
+			Message: err.Error(),
+		})
+	}
+	tlsEnabled := tc.Spec.EnableTLSCluster
+
+	if clusterID := os.Getenv("PD_RECOVER_CLUSTER_ID"); clusterID != "" {
+		maxAllocID, err := strconv.ParseUint(os.Getenv("PD_RECOVER_MAX_ALLOC_ID"), 10, 64)
+		if err == nil {
+			err = rm.recoverPD(clusterID, maxAllocID, tlsEnabled)
+		}
+		if err != nil {
+			log.Errorf("recover cluster %s pd failed, err: %s", rm, err)
+			return rm.StatusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+				Type:    v1alpha1.RestoreFailed,
+				Status:  corev1.ConditionTrue,
+				Reason:  "RecoverPDFailed",
+				Message: err.Error(),
+			})
+		}
+		log.Infof("recover cluster %s pd with cluster id %s success", rm, clusterID)
+	}
+
+	// the data is truncated once PD and TiKV are ready, the restored cluster
+	// must not be used until the restore is complete
+	err = rm.waitTidbCluster(func(tc *v1alpha1.TidbCluster) bool {
+		return tc.PDAllMembersReady() && tc.TiKVAllStoresReady()
+	})
+	if err != nil {
+		log.Errorf("wait cluster %s pd and tikv ready failed, err: %s", rm, err)
+		return rm.StatusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+			Type:    v1alpha1.RestoreFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "WaitTidbClusterReadyFailed",
+			Message: err.Error(),
+		})
+	}
+
+	commitTs := os.Getenv("BACKUP_COMMIT_TS")
+	if err := rm.truncateToCommitTs(commitTs, tlsEnabled); err != nil {
+		log.Errorf("truncate cluster %s to commitTs %s failed, err: %s", rm, commitTs, err)
+		return rm.StatusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+			Type:    v1alpha1.RestoreFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "TruncateToCommitTsFailed",
+			Message: err.Error(),
+		})
+	}
+	log.Infof("truncate cluster %s to commitTs %s success", rm, commitTs)
+
+	err = rm.waitTidbCluster(func(tc *v1alpha1.TidbCluster) bool {
+		return tc.PDAllMembersReady() && tc.TiKVAllStoresReady() && tc.TiDBAllMembersReady()
+	})
+	if err != nil {
+		log.Errorf("wait cluster %s ready failed, err: %s", rm, err)
+		return rm.StatusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+			Type:    v1alpha1.RestoreFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "WaitTidbClusterReadyFailed",
+			Message: err.Error(),
+		})
+	}
+	log.Infof("restore cluster %s from volume snapshots of backup %s success", rm, rm.BackupName)
+
+	rm.warmUpTables(restore)
+
+	finish := time.Now()
+
+	restore.Status.TimeStarted = metav1.Time{Time: started}
+	restore.Status.TimeCompleted = metav1.Time{Time: finish}
+
+	return rm.StatusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+		Type:   v1alpha1.RestoreComplete,
+		Status: corev1.ConditionTrue,
+	})
+}
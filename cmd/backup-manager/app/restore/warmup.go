@@ -0,0 +1,228 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/constants"
+	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/util"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// systemSchemas are the databases which are never warmed up
+const systemSchemas = "'mysql', 'INFORMATION_SCHEMA', 'PERFORMANCE_SCHEMA', 'METRICS_SCHEMA', 'INSPECTION_SCHEMA'"
+
+func (ro *RestoreOpts) getDSN(db string) string {
+	return fmt.Sprintf("%s:%s@(%s:4000)/%s?charset=utf8", ro.User, ro.Password, ro.TidbSvc, db)
+}
+
+// warmUpProgress records the progress of the warm-up shared by the workers
+type warmUpProgress struct {
+	mu     sync.Mutex
+	status v1alpha1.RestoreWarmUpStatus
+}
+
+func (wp *warmUpProgress) done(table string, err error) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	if err == nil {
+		wp.status.WarmedUpTables++
+		return
+	}
+	if len(wp.status.FailedTables) < constants.MaxWarmUpFailedTables {
+		wp.status.FailedTables = append(wp.status.FailedTables, table)
+	}
+}
+
+func (wp *warmUpProgress) snapshot() *v1alpha1.RestoreWarmUpStatus {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	status := wp.status.DeepCopy()
+	status.LastUpdateTime = metav1.Now()
+	return status
+}
+
+// warmUpTables warms up the restored tables by restore's spec.warmUp and
+// records the result in restore's status. It never fails the restore, the
+// tables which are failed or not warmed up before the timeout are left cold.
+func (rm *RestoreManager) warmUpTables(restore *v1alpha1.Restore) {
+	if restore.Spec.WarmUp == nil {
+		return
+	}
+
+	progress := &warmUpProgress{}
+	progress.status.TimeStarted = metav1.Now()
+	restore.Status.WarmUp = progress.snapshot()
+	err := rm.StatusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+		Type:   v1alpha1.RestoreWarmingUp,
+		Status: corev1.ConditionTrue,
+	})
+	if err != nil {
+		log.Errorf("update cluster %s restore %s warm-up condition failed, err: %s", rm, rm.RestoreName, err)
+	}
+
+	reason, err := rm.doWarmUp(restore, progress)
+	progress.status.TimeCompleted = metav1.Now()
+	restore.Status.WarmUp = progress.snapshot()
+	message := fmt.Sprintf("warmed up %d of %d tables", restore.Status.WarmUp.WarmedUpTables, restore.Status.WarmUp.TotalTables)
+	if err != nil {
+		log.Errorf("warm up cluster %s restored tables failed, err: %s", rm, err)
+		message = fmt.Sprintf("%s, err: %v", message, err)
+	} else {
+		log.Infof("warm up cluster %s restored tables success, %s", rm, message)
+	}
+	// the condition is updated along with the result of the restore
+	v1alpha1.UpdateRestoreCondition(&restore.Status, &v1alpha1.RestoreCondition{
+		Type:    v1alpha1.RestoreWarmingUp,
+		Status:  corev1.ConditionFalse,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+// doWarmUp warms up the tables with the configured concurrency and reports
+// the progress periodically, it returns the reason of the result
+func (rm *RestoreManager) doWarmUp(restore *v1alpha1.Restore, progress *warmUpProgress) (string, error) {
+	ctx := context.Background()
+	timeout, err := restore.GetWarmUpTimeout()
+	if err != nil {
+		return "InvalidWarmUpConfig", err
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	db, err := util.OpenDB(rm.getDSN(constants.TidbMetaDB))
+	if err != nil {
+		return "ConnectTidbFailed", err
+	}
+	defer db.Close()
+
+	tables, err := getWarmUpTables(ctx, db, restore.Spec.WarmUp.Databases)
+	if err != nil {
+		return "ListTablesFailed", err
+	}
+	progress.mu.Lock()
+	progress.status.TotalTables = int32(len(tables))
+	progress.mu.Unlock()
+
+	strategy := restore.GetWarmUpStrategy()
+	tableCh := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < restore.GetWarmUpConcurrency(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for table := range tableCh {
+				err := warmUpTable(ctx, db, strategy, table)
+				if err != nil && ctx.Err() == nil {
+					log.Warningf("warm up cluster %s table %s failed, err: %s", rm, table, err)
+				}
+				if ctx.Err() == nil {
+					progress.done(table, err)
+				}
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer wg.Wait()
+		defer close(tableCh)
+		for _, table := range tables {
+			select {
+			case tableCh <- table:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(constants.ProgressReportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			if ctx.Err() != nil {
+				return "WarmUpTimeout", fmt.Errorf("warm-up is not finished in %s", timeout)
+			}
+			return "WarmUpFinished", nil
+		case <-ticker.C:
+			if err := rm.ProgressUpdater.UpdateWarmUp(restore, progress.snapshot()); err != nil {
+				log.Errorf("update cluster %s restore %s warm-up progress failed, err: %s", rm, rm.RestoreName, err)
+			}
+		}
+	}
+}
+
+// getWarmUpTables lists the quoted names of the tables in databases, all the
+// databases except the system ones are listed if it is empty
+func getWarmUpTables(ctx context.Context, db *sql.DB, databases []string) ([]string, error) {
+	query := "SELECT TABLE_SCHEMA, TABLE_NAME FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_TYPE = 'BASE TABLE'"
+	var args []interface{}
+	if len(databases) == 0 {
+		query += fmt.Sprintf(" AND TABLE_SCHEMA NOT IN (%s)", systemSchemas)
+	} else {
+		query += fmt.Sprintf(" AND TABLE_SCHEMA IN (%s)", strings.TrimSuffix(strings.Repeat("?, ", len(databases)), ", "))
+		for _, database := range databases {
+			args = append(args, database)
+		}
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query tables failed, sql: %s, err: %v", query, err)
+	}
+	defer rows.Close()
+	var tables []string
+	for rows.Next() {
+		var schema, table string
+		if err := rows.Scan(&schema, &table); err != nil {
+			return nil, fmt.Errorf("scan tables failed, sql: %s, err: %v", query, err)
+		}
+		tables = append(tables, quoteTable(schema, table))
+	}
+	return tables, rows.Err()
+}
+
+// warmUpTable loads the data of the table into the caches of the cluster
+func warmUpTable(ctx context.Context, db *sql.DB, strategy v1alpha1.RestoreWarmUpStrategy, table string) error {
+	switch strategy {
+	case v1alpha1.RestoreWarmUpAnalyze:
+		_, err := db.ExecContext(ctx, "ANALYZE TABLE "+table)
+		return err
+	default:
+		var count int64
+		return db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(&count)
+	}
+}
+
+func quoteTable(schema, table string) string {
+	quote := func(name string) string {
+		return "`" + strings.Replace(name, "`", "``", -1) + "`"
+	}
+	return quote(schema) + "." + quote(table)
+}
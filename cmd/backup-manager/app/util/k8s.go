@@ -14,10 +14,11 @@
 package util
 
 import (
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	eventv1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
@@ -28,7 +29,7 @@ import (
 // NewEventRecorder return the specify source's recoder
 func NewEventRecorder(kubeCli kubernetes.Interface, source string) record.EventRecorder {
 	eventBroadcaster := record.NewBroadcaster()
-	eventBroadcaster.StartLogging(glog.Infof)
+	eventBroadcaster.StartLogging(log.Infof)
 	eventBroadcaster.StartRecordingToSink(&eventv1.EventSinkImpl{
 		Interface: eventv1.New(kubeCli.CoreV1().RESTClient()).Events("")})
 	recorder := eventBroadcaster.NewRecorder(v1alpha1.Scheme, corev1.EventSource{Component: source})
@@ -61,6 +62,20 @@ func NewKubeCli(kubeconfig string) (kubernetes.Interface, error) {
 	return kubeCli, nil
 }
 
+// NewDynamicCli create a dynamic cli Interface, it manages the objects of the
+// resources which have no typed client in tidb-operator, e.g. VolumeSnapshots
+func NewDynamicCli(kubeconfig string) (dynamic.Interface, error) {
+	cfg, err := newConfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	dynamicCli, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return dynamicCli, nil
+}
+
 // NewKubeAndCRCli create both kube cli and CR cli
 func NewKubeAndCRCli(kubeconfig string) (kubernetes.Interface, versioned.Interface, error) {
 	crCli, err := NewCRCli(kubeconfig)
@@ -16,15 +16,23 @@ package util
 import (
 	"database/sql"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"regexp"
 	"strings"
 
+	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/constants"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/spf13/pflag"
 	cmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
 )
 
 var (
 	cmdHelpMsg string
+
+	// checksumSkippedRegexp matches the logs of br and lightning which skip the checksum
+	// of some tables although it is enabled, e.g. the tables backed up without checksum
+	checksumSkippedRegexp = regexp.MustCompile(`(?i)skip(ping)? checksum`)
 )
 
 func validCmdFlagFunc(flag *pflag.Flag) {
@@ -60,15 +68,15 @@ func EnsureDirectoryExist(dirName string) error {
 	return nil
 }
 
-// OpenDB opens db
+// OpenDB opens db, the dsn is not included in the error as it contains the password
 func OpenDB(dsn string) (*sql.DB, error) {
 	db, err := sql.Open("mysql", dsn)
 	if err != nil {
-		return nil, fmt.Errorf("open dsn %s failed, err: %v", dsn, err)
+		return nil, fmt.Errorf("open db failed, err: %v", err)
 	}
 	if err := db.Ping(); err != nil {
 		db.Close()
-		return nil, fmt.Errorf("can't connect to mysql: %s, err: %v", dsn, err)
+		return nil, fmt.Errorf("can't connect to mysql, err: %v", err)
 	}
 	return db, nil
 }
@@ -95,3 +103,86 @@ func IsDirExist(path string) bool {
 func NormalizeBucketURI(bucket string) string {
 	return strings.Replace(bucket, "://", ":", 1)
 }
+
+// GetStorageURI returns the URI of remotePath used by br, dumpling and lightning,
+// ceph is accessed as a S3 compatible storage
+func GetStorageURI(storageType, remotePath string) string {
+	if storageType == string(v1alpha1.BackupStorageTypeGCS) {
+		return fmt.Sprintf("gcs://%s", remotePath)
+	}
+	return fmt.Sprintf("s3://%s", remotePath)
+}
+
+// GetStorageArgs returns the args of br and dumpling to access the backend storage
+func GetStorageArgs(storageType string) []string {
+	if storageType == string(v1alpha1.BackupStorageTypeGCS) {
+		return getGCSArgs()
+	}
+	return getS3Args()
+}
+
+// GetCrypterArgs returns the args of br to encrypt or decrypt the backup data by the
+// BR_CRYPTER_METHOD and BR_CRYPTER_KEY env, the key is written to keyFile and passed
+// by --crypter.key-file. It returns nil if the client-side encryption is not enabled.
+func GetCrypterArgs(keyFile string) ([]string, error) {
+	method := os.Getenv("BR_CRYPTER_METHOD")
+	if method == "" {
+		return nil, nil
+	}
+	key := os.Getenv("BR_CRYPTER_KEY")
+	if key == "" {
+		return nil, fmt.Errorf("the key of crypter method %s is empty", method)
+	}
+	if err := ioutil.WriteFile(keyFile, []byte(key), 0600); err != nil {
+		return nil, fmt.Errorf("write crypter key file %s failed, err: %v", keyFile, err)
+	}
+	return []string{
+		fmt.Sprintf("--crypter.method=%s", method),
+		fmt.Sprintf("--crypter.key-file=%s", keyFile),
+	}, nil
+}
+
+// IsChecksumVerified returns whether the data is verified by checksum by the output
+// of a successful br or lightning command run with the checksum enabled or not
+func IsChecksumVerified(checksum bool, output []byte) bool {
+	return checksum && !checksumSkippedRegexp.Match(output)
+}
+
+// getGCSArgs returns the args of br and dumpling to access the google cloud storage,
+// the application default credentials are used if there is no JSON key
+func getGCSArgs() []string {
+	var args []string
+	if os.Getenv("GCS_SERVICE_ACCOUNT_JSON_KEY") != "" {
+		args = append(args, fmt.Sprintf("--gcs.credentials-file=%s", constants.GCSCredentialsFile))
+	}
+	if storageClass := os.Getenv("GCS_STORAGE_CLASS"); storageClass != "" {
+		args = append(args, fmt.Sprintf("--gcs.storage-class=%s", storageClass))
+	}
+	if objectACL := os.Getenv("GCS_OBJECT_ACL"); objectACL != "" {
+		args = append(args, fmt.Sprintf("--gcs.predefined-acl=%s", objectACL))
+	}
+	return args
+}
+
+// getS3Args returns the args of br and dumpling to access the S3 compatible storage
+func getS3Args() []string {
+	args := []string{
+		fmt.Sprintf("--s3.endpoint=%s", os.Getenv("S3_ENDPOINT")),
+	}
+	if provider := os.Getenv("S3_PROVIDER"); provider != "" {
+		args = append(args, fmt.Sprintf("--s3.provider=%s", strings.ToLower(provider)))
+	}
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		args = append(args, fmt.Sprintf("--s3.region=%s", region))
+	}
+	if forcePathStyle := os.Getenv("S3_FORCE_PATH_STYLE"); forcePathStyle != "" {
+		args = append(args, fmt.Sprintf("--s3.force-path-style=%s", forcePathStyle))
+	}
+	if sse := os.Getenv("S3_SSE"); sse != "" {
+		args = append(args, fmt.Sprintf("--s3.sse=%s", sse))
+	}
+	if keyID := os.Getenv("S3_SSE_KMS_KEY_ID"); keyID != "" {
+		args = append(args, fmt.Sprintf("--s3.sse-kms-key-id=%s", keyID))
+	}
+	return args
+}
@@ -0,0 +1,64 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestIsChecksumVerified(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name           string
+		checksum       bool
+		output         string
+		expectVerified bool
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		g.Expect(IsChecksumVerified(test.checksum, []byte(test.output))).To(Equal(test.expectVerified))
+	}
+
+	tests := []testcase{
+		{
+			name:           "checksum of all tables passed",
+			checksum:       true,
+			output:         `[INFO] [restore.go:300] ["Full Restore success summary"] [total-ranges=12] [ranges-succeed=12]`,
+			expectVerified: true,
+		},
+		{
+			name:     "checksum is disabled",
+			checksum: false,
+			output:   `[INFO] [restore.go:300] ["Full Restore success summary"] [total-ranges=12] [ranges-succeed=12]`,
+		},
+		{
+			name:     "table backed up without checksum",
+			checksum: true,
+			output:   `[WARN] [client.go:800] ["table has no checksum, skipping checksum"] [db=test] [table=t]`,
+		},
+		{
+			name:     "checksum is skipped",
+			checksum: true,
+			output:   `[INFO] [restore.go:120] ["Skip checksum"] [table=t]`,
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
@@ -19,9 +19,9 @@ import (
 	"net/http"
 	_ "net/http/pprof"
 	"os"
+	"strings"
 	"time"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
 	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions"
 	"github.com/pingcap/tidb-operator/pkg/controller"
@@ -29,35 +29,57 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/controller/backupschedule"
 	"github.com/pingcap/tidb-operator/pkg/controller/restore"
 	"github.com/pingcap/tidb-operator/pkg/controller/tidbcluster"
+	"github.com/pingcap/tidb-operator/pkg/controller/tidbngmonitoring"
+	"github.com/pingcap/tidb-operator/pkg/grafana"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	"github.com/pingcap/tidb-operator/pkg/tracing"
 	"github.com/pingcap/tidb-operator/pkg/version"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apiserver/pkg/util/logs"
+	"k8s.io/client-go/dynamic"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/leaderelection"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	"k8s.io/client-go/tools/record"
+	// register the workqueue metrics, e.g. the depth and the processing latency of the queues
+	_ "k8s.io/kubernetes/pkg/util/workqueue/prometheus"
 )
 
 var (
-	printVersion       bool
-	workers            int
-	autoFailover       bool
-	pdFailoverPeriod   time.Duration
-	tikvFailoverPeriod time.Duration
-	tidbFailoverPeriod time.Duration
-	leaseDuration      = 15 * time.Second
-	renewDuration      = 5 * time.Second
-	retryPeriod        = 3 * time.Second
-	waitDuration       = 5 * time.Second
+	printVersion                  bool
+	workers                       int
+	tcWorkers                     int
+	backupWorkers                 int
+	restoreWorkers                int
+	enableTracing                 bool
+	grafanaURL                    string
+	autoFailover                  bool
+	pdFailoverPeriod              time.Duration
+	tikvFailoverPeriod            time.Duration
+	tidbFailoverPeriod            time.Duration
+	podForceDeletionOnNodeFailure bool
+	nodeFailureThreshold          time.Duration
+	orphanPVCGracePeriod          time.Duration
+	leaseDuration                 = 15 * time.Second
+	renewDuration                 = 5 * time.Second
+	retryPeriod                   = 3 * time.Second
+	waitDuration                  = 5 * time.Second
 )
 
 func init() {
 	flag.BoolVar(&printVersion, "V", false, "Show version and quit")
 	flag.BoolVar(&printVersion, "version", false, "Show version and quit")
 	flag.IntVar(&workers, "workers", 5, "The number of workers that are allowed to sync concurrently. Larger number = more responsive management, but more CPU (and network) load")
+	flag.IntVar(&tcWorkers, "tidbcluster-workers", 0, "The number of workers of the TidbCluster controller, defaults to the value of -workers if it's 0")
+	flag.IntVar(&backupWorkers, "backup-workers", 0, "The number of workers of the Backup controller, defaults to the value of -workers if it's 0")
+	flag.IntVar(&restoreWorkers, "restore-workers", 0, "The number of workers of the Restore controller, defaults to the value of -workers if it's 0")
 	flag.BoolVar(&controller.ClusterScoped, "cluster-scoped", true, "Whether tidb-operator should manage kubernetes cluster wide TiDB Clusters")
 	flag.StringVar(&controller.DefaultStorageClassName, "default-storage-class-name", "standard", "Default storage class name")
 	flag.StringVar(&controller.DefaultBackupStorageClassName, "default-backup-storage-class-name", "standard", "Default storage class name for backup and restore")
@@ -65,9 +87,19 @@ func init() {
 	flag.DurationVar(&pdFailoverPeriod, "pd-failover-period", time.Duration(5*time.Minute), "PD failover period default(5m)")
 	flag.DurationVar(&tikvFailoverPeriod, "tikv-failover-period", time.Duration(5*time.Minute), "TiKV failover period default(5m)")
 	flag.DurationVar(&tidbFailoverPeriod, "tidb-failover-period", time.Duration(5*time.Minute), "TiDB failover period")
+	flag.BoolVar(&podForceDeletionOnNodeFailure, "pod-force-deletion-on-node-failure", false, "Force delete the PD and TiKV pods stuck in Terminating on the confirmed failed nodes")
+	flag.DurationVar(&nodeFailureThreshold, "node-failure-threshold", time.Duration(10*time.Minute), "How long a node should be NotReady before its pods are force deleted")
+	flag.DurationVar(&orphanPVCGracePeriod, "orphan-pvc-grace-period", 0, "How long the PD and TiKV PVCs which are not used by any pod are kept before they are deleted, 0 keeps them forever")
 	flag.DurationVar(&controller.ResyncDuration, "resync-duration", time.Duration(30*time.Second), "Resync time of informer")
+	flag.BoolVar(&controller.ServerSideApply, "server-side-apply", false, "Update the generated StatefulSets, Services and ConfigMaps by the server-side apply, requires Kubernetes 1.16+")
+	flag.BoolVar(&controller.OpenShift, "openshift", false, "Adjust the generated pods for the security context constraints of OpenShift, it's enabled automatically if OpenShift is detected")
+	flag.BoolVar(&enableTracing, "tracing", false, "Trace the phases of the TidbCluster syncs, the spans are reported to the jaeger agent configured by the JAEGER_* environment variables")
+	flag.StringVar(&grafanaURL, "grafana-url", "", "The url of the Grafana to post the annotations of the upgrades, failovers and scaling to, the API key is read from the GRAFANA_API_KEY environment variable")
 	flag.BoolVar(&controller.TestMode, "test-mode", false, "whether tidb-operator run in test mode")
 	flag.StringVar(&controller.TidbBackupManagerImage, "tidb-backup-manager-image", "pingcap/tidb-backup-manager:latest", "The image of backup manager tool")
+	flag.IntVar(&controller.BackupJobHistoryLimit, "backup-job-history-limit", 0, "The number of the finished jobs of the Backups and Restores kept in each namespace, the older ones are deleted with their pods, 0 keeps all of them")
+	flag.DurationVar(&controller.BackupJobTTL, "backup-job-ttl", 0, "How long the finished jobs of the Backups and Restores are kept before they are deleted with their pods, 0 keeps them forever")
+	log.AddFlags(flag.CommandLine)
 
 	flag.Parse()
 }
@@ -77,6 +109,17 @@ func main() {
 		version.PrintVersionInfo()
 		os.Exit(0)
 	}
+	if err := log.Init(flag.CommandLine); err != nil {
+		log.Fatalf("failed to init the logger: %v", err)
+	}
+	defer log.Flush()
+	if enableTracing {
+		closer, err := tracing.Init("tidb-controller-manager")
+		if err != nil {
+			log.Fatalf("failed to init the tracer: %v", err)
+		}
+		defer closer.Close()
+	}
 	version.LogVersionInfo()
 
 	logs.InitLogs()
@@ -84,30 +127,55 @@ func main() {
 
 	hostName, err := os.Hostname()
 	if err != nil {
-		glog.Fatalf("failed to get hostname: %v", err)
+		log.Fatalf("failed to get hostname: %v", err)
 	}
 
 	ns := os.Getenv("NAMESPACE")
 	if ns == "" {
-		glog.Fatal("NAMESPACE environment variable not set")
+		log.Fatal("NAMESPACE environment variable not set")
 	}
 
 	cfg, err := rest.InClusterConfig()
 	if err != nil {
-		glog.Fatalf("failed to get config: %v", err)
+		log.Fatalf("failed to get config: %v", err)
 	}
 
 	cli, err := versioned.NewForConfig(cfg)
 	if err != nil {
-		glog.Fatalf("failed to create Clientset: %v", err)
+		log.Fatalf("failed to create Clientset: %v", err)
+	}
+	// the built-in types are decoded from protobuf, which is much cheaper than json for the
+	// informers of the pods and pvcs, the custom resources only support json
+	kubeCfg := rest.CopyConfig(cfg)
+	kubeCfg.AcceptContentTypes = strings.Join([]string{runtime.ContentTypeProtobuf, runtime.ContentTypeJSON}, ",")
+	kubeCfg.ContentType = runtime.ContentTypeProtobuf
+	kubeCli, err := kubernetes.NewForConfig(kubeCfg)
+	if err != nil {
+		log.Fatalf("failed to get kubernetes Clientset: %v", err)
+	}
+	if err := controller.CheckAPIResources(kubeCli.Discovery()); err != nil {
+		log.Fatal(err)
 	}
-	kubeCli, err := kubernetes.NewForConfig(cfg)
+	if !controller.OpenShift && controller.IsOpenShift(kubeCli.Discovery()) {
+		log.Info("OpenShift is detected, the generated pods are adjusted for the security context constraints")
+		controller.OpenShift = true
+	}
+	dynamicCli, err := dynamic.NewForConfig(cfg)
 	if err != nil {
-		glog.Fatalf("failed to get kubernetes Clientset: %v", err)
+		log.Fatalf("failed to get dynamic Clientset: %v", err)
 	}
 
 	var informerFactory informers.SharedInformerFactory
 	var kubeInformerFactory kubeinformers.SharedInformerFactory
+	// the pods, pvcs and statefulsets are only cached if they are managed by tidb-operator,
+	// so that the informers don't cache every pod of the kubernetes cluster
+	labelFilterKubeOptions := []kubeinformers.SharedInformerOption{
+		kubeinformers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.LabelSelector = labels.SelectorFromSet(labels.Set{
+				label.ManagedByLabelKey: label.New()[label.ManagedByLabelKey],
+			}).String()
+		}),
+	}
 	if controller.ClusterScoped {
 		informerFactory = informers.NewSharedInformerFactory(cli, controller.ResyncDuration)
 		kubeInformerFactory = kubeinformers.NewSharedInformerFactory(kubeCli, controller.ResyncDuration)
@@ -121,7 +189,9 @@ func main() {
 			kubeinformers.WithNamespace(ns),
 		}
 		kubeInformerFactory = kubeinformers.NewSharedInformerFactoryWithOptions(kubeCli, controller.ResyncDuration, kubeoptions...)
+		labelFilterKubeOptions = append(labelFilterKubeOptions, kubeoptions...)
 	}
+	labelFilterKubeInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(kubeCli, controller.ResyncDuration, labelFilterKubeOptions...)
 
 	rl := resourcelock.EndpointsLock{
 		EndpointsMeta: metav1.ObjectMeta{
@@ -135,38 +205,50 @@ func main() {
 		},
 	}
 
-	tcController := tidbcluster.NewController(kubeCli, cli, informerFactory, kubeInformerFactory, autoFailover, pdFailoverPeriod, tikvFailoverPeriod, tidbFailoverPeriod)
+	var annotator tidbcluster.StatusAnnotator
+	if grafanaURL != "" {
+		annotator = tidbcluster.NewGrafanaAnnotator(grafana.NewClient(grafanaURL, os.Getenv("GRAFANA_API_KEY")))
+	}
+	tcController := tidbcluster.NewController(kubeCli, cli, informerFactory, kubeInformerFactory, labelFilterKubeInformerFactory, autoFailover, pdFailoverPeriod, tikvFailoverPeriod, tidbFailoverPeriod, podForceDeletionOnNodeFailure, nodeFailureThreshold, orphanPVCGracePeriod, annotator)
 	backupController := backup.NewController(kubeCli, cli, informerFactory, kubeInformerFactory)
-	restoreController := restore.NewController(kubeCli, cli, informerFactory, kubeInformerFactory)
+	restoreController := restore.NewController(kubeCli, cli, dynamicCli, informerFactory, kubeInformerFactory)
 	bsController := backupschedule.NewController(kubeCli, cli, informerFactory, kubeInformerFactory)
+	tngmController := tidbngmonitoring.NewController(kubeCli, cli, informerFactory, kubeInformerFactory, labelFilterKubeInformerFactory)
 	controllerCtx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	// Start informer factories after all controller are initialized.
 	informerFactory.Start(controllerCtx.Done())
 	kubeInformerFactory.Start(controllerCtx.Done())
+	labelFilterKubeInformerFactory.Start(controllerCtx.Done())
 
 	// Wait for all started informers' cache were synced.
 	for v, synced := range informerFactory.WaitForCacheSync(wait.NeverStop) {
 		if !synced {
-			glog.Fatalf("error syncing informer for %v", v)
+			log.Fatalf("error syncing informer for %v", v)
 		}
 	}
 	for v, synced := range kubeInformerFactory.WaitForCacheSync(wait.NeverStop) {
 		if !synced {
-			glog.Fatalf("error syncing informer for %v", v)
+			log.Fatalf("error syncing informer for %v", v)
+		}
+	}
+	for v, synced := range labelFilterKubeInformerFactory.WaitForCacheSync(wait.NeverStop) {
+		if !synced {
+			log.Fatalf("error syncing informer for %v", v)
 		}
 	}
-	glog.Infof("cache of informer factories sync successfully")
+	log.Infof("cache of informer factories sync successfully")
 
 	onStarted := func(ctx context.Context) {
-		go wait.Forever(func() { backupController.Run(workers, ctx.Done()) }, waitDuration)
-		go wait.Forever(func() { restoreController.Run(workers, ctx.Done()) }, waitDuration)
+		go wait.Forever(func() { backupController.Run(workersOrDefault(backupWorkers), ctx.Done()) }, waitDuration)
+		go wait.Forever(func() { restoreController.Run(workersOrDefault(restoreWorkers), ctx.Done()) }, waitDuration)
 		go wait.Forever(func() { bsController.Run(workers, ctx.Done()) }, waitDuration)
-		wait.Forever(func() { tcController.Run(workers, ctx.Done()) }, waitDuration)
+		go wait.Forever(func() { tngmController.Run(workers, ctx.Done()) }, waitDuration)
+		wait.Forever(func() { tcController.Run(workersOrDefault(tcWorkers), ctx.Done()) }, waitDuration)
 	}
 	onStopped := func() {
-		glog.Fatalf("leader election lost")
+		log.Fatalf("leader election lost")
 	}
 
 	// leader election for multiple tidb-cloud-manager
@@ -183,5 +265,15 @@ func main() {
 		})
 	}, waitDuration)
 
-	glog.Fatal(http.ListenAndServe(":6060", nil))
+	http.Handle("/metrics", promhttp.Handler())
+	log.Fatal(http.ListenAndServe(":6060", nil))
+}
+
+// workersOrDefault returns the number of workers of a controller, the shared
+// -workers is used if it's not set for the controller
+func workersOrDefault(n int) int {
+	if n > 0 {
+		return n
+	}
+	return workers
 }
@@ -7,10 +7,11 @@ import (
 	"os"
 	"time"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
 	"github.com/pingcap/tidb-operator/pkg/discovery/server"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	"github.com/pingcap/tidb-operator/pkg/version"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apiserver/pkg/util/logs"
 	"k8s.io/client-go/rest"
@@ -19,12 +20,16 @@ import (
 var (
 	printVersion bool
 	port         int
+	stateless    bool
 )
 
 func init() {
 	flag.BoolVar(&printVersion, "V", false, "Show version and quit")
 	flag.BoolVar(&printVersion, "version", false, "Show version and quit")
 	flag.IntVar(&port, "port", 10261, "The port that the tidb discovery's http service runs on (default 10261)")
+	flag.BoolVar(&stateless, "stateless", false, "Bootstrap the pd cluster by its first member without the state in memory, which is required to run multiple replicas")
+	log.AddFlags(flag.CommandLine)
+
 	flag.Parse()
 }
 
@@ -35,20 +40,25 @@ func main() {
 	}
 	version.LogVersionInfo()
 
+	if err := log.Init(flag.CommandLine); err != nil {
+		log.Fatalf("failed to init the logger: %v", err)
+	}
+	defer log.Flush()
 	logs.InitLogs()
 	defer logs.FlushLogs()
 
 	cfg, err := rest.InClusterConfig()
 	if err != nil {
-		glog.Fatalf("failed to get config: %v", err)
+		log.Fatalf("failed to get config: %v", err)
 	}
 	cli, err := versioned.NewForConfig(cfg)
 	if err != nil {
-		glog.Fatalf("failed to create Clientset: %v", err)
+		log.Fatalf("failed to create Clientset: %v", err)
 	}
 
 	go wait.Forever(func() {
-		server.StartServer(cli, port)
+		server.StartServer(cli, port, stateless)
 	}, 5*time.Second)
-	glog.Fatal(http.ListenAndServe(":6060", nil))
+	http.Handle("/metrics", promhttp.Handler())
+	log.Fatal(http.ListenAndServe(":6060", nil))
 }
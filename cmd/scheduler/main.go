@@ -20,9 +20,9 @@ import (
 	"os"
 	"time"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
 	"github.com/pingcap/tidb-operator/pkg/features"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	"github.com/pingcap/tidb-operator/pkg/scheduler/server"
 	"github.com/pingcap/tidb-operator/pkg/version"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -41,6 +41,8 @@ func init() {
 	flag.BoolVar(&printVersion, "version", false, "Show version and quit")
 	flag.IntVar(&port, "port", 10262, "The port that the tidb scheduler's http service runs on (default 10262)")
 	features.DefaultFeatureGate.AddFlag(flag.CommandLine)
+	log.AddFlags(flag.CommandLine)
+
 	flag.Parse()
 }
 
@@ -51,24 +53,28 @@ func main() {
 	}
 	version.LogVersionInfo()
 
+	if err := log.Init(flag.CommandLine); err != nil {
+		log.Fatalf("failed to init the logger: %v", err)
+	}
+	defer log.Flush()
 	logs.InitLogs()
 	defer logs.FlushLogs()
 
 	cfg, err := rest.InClusterConfig()
 	if err != nil {
-		glog.Fatalf("failed to get config: %v", err)
+		log.Fatalf("failed to get config: %v", err)
 	}
 	kubeCli, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
-		glog.Fatalf("failed to get kubernetes Clientset: %v", err)
+		log.Fatalf("failed to get kubernetes Clientset: %v", err)
 	}
 	cli, err := versioned.NewForConfig(cfg)
 	if err != nil {
-		glog.Fatalf("failed to create Clientset: %v", err)
+		log.Fatalf("failed to create Clientset: %v", err)
 	}
 
 	go wait.Forever(func() {
 		server.StartServer(kubeCli, cli, port)
 	}, 5*time.Second)
-	glog.Fatal(http.ListenAndServe(":6060", nil))
+	log.Fatal(http.ListenAndServe(":6060", nil))
 }
@@ -1,18 +1,30 @@
 #!/bin/sh
 set -e
 
+if [[ -n "${GCS_SERVICE_ACCOUNT_JSON_KEY:-}" ]]; then
+    echo "Create google-credentials.json file."
+    cat <<EOF > /tmp/google-credentials.json
+    ${GCS_SERVICE_ACCOUNT_JSON_KEY}
+EOF
+    GCS_CREDENTIALS_FILE=/tmp/google-credentials.json
+fi
+
 echo "Create rclone.conf file."
 cat <<EOF > /tmp/rclone.conf
 [s3]
 type = s3
-env_auth = false
+env_auth = ${S3_ENV_AUTH:-"false"}
 provider = ${S3_PROVIDER:-"AWS"}
 access_key_id = ${AWS_ACCESS_KEY_ID}
 secret_access_key = ${AWS_SECRET_ACCESS_KEY:-$AWS_SECRET_KEY}
 region = ${AWS_REGION:-"us-east-1"}
 endpoint = ${S3_ENDPOINT}
+force_path_style = ${S3_FORCE_PATH_STYLE:-"true"}
+v2_auth = ${S3_V2_AUTH:-"false"}
 acl = ${AWS_ACL}
 storage_class = ${AWS_STORAGE_CLASS}
+server_side_encryption = ${S3_SSE}
+sse_kms_key_id = ${S3_SSE_KMS_KEY_ID}
 [ceph]
 type = s3
 env_auth = false
@@ -21,10 +33,13 @@ access_key_id = ${AWS_ACCESS_KEY_ID}
 secret_access_key = ${AWS_SECRET_ACCESS_KEY:-$AWS_SECRET_KEY}
 region = :default-placement
 endpoint = ${S3_ENDPOINT}
-[gs]
+server_side_encryption = ${S3_SSE}
+sse_kms_key_id = ${S3_SSE_KMS_KEY_ID}
+[gcs]
 type = google cloud storage
+env_auth = ${GCS_ENV_AUTH:-"false"}
 project_number = ${GCS_PROJECT_ID}
-service_account_file = /tmp/google-credentials.json
+service_account_file = ${GCS_CREDENTIALS_FILE}
 object_acl = ${GCS_OBJECT_ACL}
 bucket_acl = ${GCS_BUCKET_ACL}
 location =  ${GCS_LOCATION}
@@ -35,15 +50,6 @@ account = ${AZUREBLOB_ACCOUNT}
 key = ${AZUREBLOB_KEY}
 EOF
 
-if [[ -n "${GCS_SERVICE_ACCOUNT_JSON_KEY:-}" ]]; then
-    echo "Create google-credentials.json file."
-    cat <<EOF > /tmp/google-credentials.json
-    ${GCS_SERVICE_ACCOUNT_JSON_KEY}
-EOF
-else
-    touch /tmp/google-credentials.json
-fi
-
 BACKUP_BIN=/tidb-backup-manager
 
 # exec command
@@ -63,8 +69,13 @@ case "$1" in
         echo "$BACKUP_BIN clean $@"
         exec $BACKUP_BIN clean "$@"
         ;;
+    replicate)
+        shift 1
+        echo "$BACKUP_BIN replicate $@"
+        exec $BACKUP_BIN replicate "$@"
+        ;;
     *)
-        echo "Usage: $0 {backup|restore|clean}"
+        echo "Usage: $0 {backup|restore|clean|replicate}"
         echo "Now runs your command."
         echo "$@"
 
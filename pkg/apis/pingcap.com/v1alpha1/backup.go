@@ -30,11 +30,68 @@ func (bk *Backup) GetBackupJobName() string {
 	return fmt.Sprintf("backup-%s", bk.GetName())
 }
 
-// GetBackupPVCName return the backup pvc name
+// GetReplicateJobName return the replicate job name
+func (bk *Backup) GetReplicateJobName() string {
+	return fmt.Sprintf("replicate-%s", bk.GetName())
+}
+
+// GetBackupPVCName return the backup pvc name, each backup has its own pvc
+// which keeps the backup data if the storage type is pvc
 func (bk *Backup) GetBackupPVCName() string {
+	if bk.Spec.StorageType == BackupStorageTypePVC {
+		return fmt.Sprintf("backup-%s", bk.GetName())
+	}
 	return fmt.Sprintf("%s-backup-pvc", bk.Spec.Cluster)
 }
 
+// GetLogBackupCommand return the command of the log backup task, defaults to start
+func (bk *Backup) GetLogBackupCommand() LogBackupCommand {
+	if bk.Spec.LogBackup == nil || bk.Spec.LogBackup.Command == "" {
+		return LogBackupCommandStart
+	}
+	return bk.Spec.LogBackup.Command
+}
+
+// GetVolumeSnapshotProvider return the provider of the volume snapshots, defaults
+// to csi if the VolumeSnapshotClass is set, otherwise aws-ebs
+func (bk *Backup) GetVolumeSnapshotProvider() VolumeSnapshotProvider {
+	if bk.Spec.VolumeSnapshot == nil {
+		return VolumeSnapshotProviderAWSEBS
+	}
+	if bk.Spec.VolumeSnapshot.Provider != "" {
+		return bk.Spec.VolumeSnapshot.Provider
+	}
+	if bk.Spec.VolumeSnapshot.VolumeSnapshotClassName != "" {
+		return VolumeSnapshotProviderCSI
+	}
+	return VolumeSnapshotProviderAWSEBS
+}
+
+// IsChecksumEnabled return whether the backup data is verified by checksum, defaults to true
+func (bk *Backup) IsChecksumEnabled() bool {
+	return bk.Spec.Checksum == nil || *bk.Spec.Checksum
+}
+
+// GetTableFilter return the table filter rules of the backup, spec.tableFilter
+// overrides spec.dumpling.tableFilter
+func (bk *Backup) GetTableFilter() []string {
+	if len(bk.Spec.TableFilter) > 0 {
+		return bk.Spec.TableFilter
+	}
+	if bk.Spec.Dumpling != nil {
+		return bk.Spec.Dumpling.TableFilter
+	}
+	return nil
+}
+
+// GetCleanPolicy return the clean policy of the backup, defaults to Delete
+func (bk *Backup) GetCleanPolicy() CleanPolicyType {
+	if bk.Spec.CleanPolicy == "" {
+		return CleanPolicyTypeDelete
+	}
+	return bk.Spec.CleanPolicy
+}
+
 // GetBackupCondition get the specify type's BackupCondition from the given BackupStatus
 func GetBackupCondition(status *BackupStatus, conditionType BackupConditionType) (int, *BackupCondition) {
 	if status == nil {
@@ -99,3 +156,33 @@ func IsBackupClean(backup *Backup) bool {
 	_, condition := GetBackupCondition(&backup.Status, BackupClean)
 	return condition != nil && condition.Status == corev1.ConditionTrue
 }
+
+// NeedToCleanData returns true if the backup data should be removed from the
+// backend storage when the Backup is deleted
+func NeedToCleanData(backup *Backup) bool {
+	switch backup.GetCleanPolicy() {
+	case CleanPolicyTypeDelete:
+		return true
+	case CleanPolicyTypeOnFailure:
+		return IsBackupFailed(backup)
+	default:
+		return false
+	}
+}
+
+// NeedToReplicate returns true if the data of a complete Backup should be
+// replicated to the secondary storages and the replicate job is not created yet
+func NeedToReplicate(backup *Backup) bool {
+	if len(backup.Spec.SecondaryStorages) == 0 || !IsBackupComplete(backup) {
+		return false
+	}
+	_, condition := GetBackupCondition(&backup.Status, BackupReplicated)
+	return condition == nil
+}
+
+// IsBackupReplicated returns true if the backup data has been replicated to
+// all the secondary storages
+func IsBackupReplicated(backup *Backup) bool {
+	_, condition := GetBackupCondition(&backup.Status, BackupReplicated)
+	return condition != nil && condition.Status == corev1.ConditionTrue
+}
@@ -23,3 +23,24 @@ import (
 func (bs *BackupSchedule) GetBackupCRDName(timestamp time.Time) string {
 	return fmt.Sprintf("%s-%s", bs.GetName(), timestamp.UTC().Format(constants.TimeFormat))
 }
+
+// GetConcurrencyPolicy return the concurrency policy of the backup schedule, defaults to Forbid
+func (bs *BackupSchedule) GetConcurrencyPolicy() ConcurrencyPolicy {
+	if bs.Spec.ConcurrencyPolicy == "" {
+		return ConcurrencyPolicyForbid
+	}
+	return bs.Spec.ConcurrencyPolicy
+}
+
+// GetLocation returns the timezone the cron strings of the backup schedule are
+// evaluated in, defaults to UTC. Local is rejected as it's the timezone of the
+// controller rather than the user.
+func (bs *BackupSchedule) GetLocation() (*time.Location, error) {
+	switch bs.Spec.Timezone {
+	case "":
+		return time.UTC, nil
+	case "Local":
+		return nil, fmt.Errorf("timezone Local is not supported, use an IANA timezone name instead")
+	}
+	return time.LoadLocation(bs.Spec.Timezone)
+}
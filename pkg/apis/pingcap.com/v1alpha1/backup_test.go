@@ -0,0 +1,178 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNeedToCleanData(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name        string
+		cleanPolicy CleanPolicyType
+		failed      bool
+		expect      bool
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		backup := &Backup{}
+		backup.Spec.CleanPolicy = test.cleanPolicy
+		if test.failed {
+			UpdateBackupCondition(&backup.Status, &BackupCondition{
+				Type:   BackupFailed,
+				Status: corev1.ConditionTrue,
+			})
+		}
+		g.Expect(NeedToCleanData(backup)).To(Equal(test.expect))
+	}
+	tests := []testcase{
+		{
+			name:        "default clean policy",
+			cleanPolicy: "",
+			failed:      false,
+			expect:      true,
+		},
+		{
+			name:        "delete",
+			cleanPolicy: CleanPolicyTypeDelete,
+			failed:      false,
+			expect:      true,
+		},
+		{
+			name:        "retain",
+			cleanPolicy: CleanPolicyTypeRetain,
+			failed:      true,
+			expect:      false,
+		},
+		{
+			name:        "on failure, backup succeeded",
+			cleanPolicy: CleanPolicyTypeOnFailure,
+			failed:      false,
+			expect:      false,
+		},
+		{
+			name:        "on failure, backup failed",
+			cleanPolicy: CleanPolicyTypeOnFailure,
+			failed:      true,
+			expect:      true,
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func TestGetBackupPVCName(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	backup := &Backup{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo1-backup"},
+		Spec: BackupSpec{
+			Cluster:     "demo1",
+			StorageType: BackupStorageTypeS3,
+		},
+	}
+	g.Expect(backup.GetBackupPVCName()).To(Equal("demo1-backup-pvc"))
+
+	backup.Spec.StorageType = BackupStorageTypePVC
+	g.Expect(backup.GetBackupPVCName()).To(Equal("backup-demo1-backup"))
+}
+
+func TestGetLogBackupCommand(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	backup := &Backup{}
+	g.Expect(backup.GetLogBackupCommand()).To(Equal(LogBackupCommandStart))
+
+	backup.Spec.LogBackup = &LogBackupConfig{}
+	g.Expect(backup.GetLogBackupCommand()).To(Equal(LogBackupCommandStart))
+
+	backup.Spec.LogBackup.Command = LogBackupCommandTruncate
+	g.Expect(backup.GetLogBackupCommand()).To(Equal(LogBackupCommandTruncate))
+}
+
+func TestGetVolumeSnapshotProvider(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	backup := &Backup{}
+	g.Expect(backup.GetVolumeSnapshotProvider()).To(Equal(VolumeSnapshotProviderAWSEBS))
+
+	backup.Spec.VolumeSnapshot = &VolumeSnapshotConfig{}
+	g.Expect(backup.GetVolumeSnapshotProvider()).To(Equal(VolumeSnapshotProviderAWSEBS))
+
+	backup.Spec.VolumeSnapshot.VolumeSnapshotClassName = "csi-aws-vsc"
+	g.Expect(backup.GetVolumeSnapshotProvider()).To(Equal(VolumeSnapshotProviderCSI))
+
+	backup.Spec.VolumeSnapshot.Provider = VolumeSnapshotProviderAWSEBS
+	g.Expect(backup.GetVolumeSnapshotProvider()).To(Equal(VolumeSnapshotProviderAWSEBS))
+}
+
+func TestBackupIsChecksumEnabled(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	backup := &Backup{}
+	g.Expect(backup.IsChecksumEnabled()).To(BeTrue())
+
+	checksum := false
+	backup.Spec.Checksum = &checksum
+	g.Expect(backup.IsChecksumEnabled()).To(BeFalse())
+}
+
+func TestGetTableFilter(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	backup := &Backup{}
+	g.Expect(backup.GetTableFilter()).To(BeEmpty())
+
+	backup.Spec.Dumpling = &DumplingConfig{TableFilter: []string{"db1.*"}}
+	g.Expect(backup.GetTableFilter()).To(Equal([]string{"db1.*"}))
+
+	backup.Spec.TableFilter = []string{"db2.*", "!db2.tbl1"}
+	g.Expect(backup.GetTableFilter()).To(Equal([]string{"db2.*", "!db2.tbl1"}))
+}
+
+func TestNeedToReplicate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	backup := &Backup{}
+	UpdateBackupCondition(&backup.Status, &BackupCondition{
+		Type:   BackupComplete,
+		Status: corev1.ConditionTrue,
+	})
+	g.Expect(NeedToReplicate(backup)).To(BeFalse())
+
+	backup.Spec.SecondaryStorages = []SecondaryStorage{{Name: "us-east-1", StorageType: BackupStorageTypeS3}}
+	g.Expect(NeedToReplicate(backup)).To(BeTrue())
+
+	UpdateBackupCondition(&backup.Status, &BackupCondition{
+		Type:   BackupReplicated,
+		Status: corev1.ConditionFalse,
+	})
+	g.Expect(NeedToReplicate(backup)).To(BeFalse())
+	g.Expect(IsBackupReplicated(backup)).To(BeFalse())
+
+	UpdateBackupCondition(&backup.Status, &BackupCondition{
+		Type:   BackupReplicated,
+		Status: corev1.ConditionTrue,
+	})
+	g.Expect(IsBackupReplicated(backup)).To(BeTrue())
+}
@@ -58,6 +58,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&BackupScheduleList{},
 		&Restore{},
 		&RestoreList{},
+		&TidbNGMonitoring{},
+		&TidbNGMonitoringList{},
 	)
 
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
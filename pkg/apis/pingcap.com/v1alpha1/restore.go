@@ -15,6 +15,7 @@ package v1alpha1
 
 import (
 	"fmt"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -30,6 +31,42 @@ func (rs *Restore) GetRestorePVCName() string {
 	return fmt.Sprintf("%s-restore-pvc", rs.Spec.Cluster)
 }
 
+// IsChecksumEnabled return whether the restored data is verified by checksum, defaults to true
+func (rs *Restore) IsChecksumEnabled() bool {
+	return rs.Spec.Checksum == nil || *rs.Spec.Checksum
+}
+
+// GetWarmUpStrategy returns the way the restored tables are warmed up, defaults to scan
+func (rs *Restore) GetWarmUpStrategy() RestoreWarmUpStrategy {
+	if rs.Spec.WarmUp == nil || rs.Spec.WarmUp.Strategy == "" {
+		return RestoreWarmUpScan
+	}
+	return rs.Spec.WarmUp.Strategy
+}
+
+// GetWarmUpConcurrency returns the number of the tables warmed up at the same time, defaults to 4
+func (rs *Restore) GetWarmUpConcurrency() int {
+	if rs.Spec.WarmUp == nil || rs.Spec.WarmUp.Concurrency <= 0 {
+		return 4
+	}
+	return rs.Spec.WarmUp.Concurrency
+}
+
+// GetWarmUpTimeout returns the max duration of the warm-up, 0 means no limit
+func (rs *Restore) GetWarmUpTimeout() (time.Duration, error) {
+	if rs.Spec.WarmUp == nil || rs.Spec.WarmUp.Timeout == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(rs.Spec.WarmUp.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid warm-up timeout %s, err: %v", rs.Spec.WarmUp.Timeout, err)
+	}
+	if timeout < 0 {
+		return 0, fmt.Errorf("invalid warm-up timeout %s, it must not be negative", rs.Spec.WarmUp.Timeout)
+	}
+	return timeout, nil
+}
+
 // GetRestoreCondition get the specify type's RestoreCondition from the given RestoreStatus
 func GetRestoreCondition(status *RestoreStatus, conditionType RestoreConditionType) (int, *RestoreCondition) {
 	if status == nil {
@@ -77,6 +114,12 @@ func IsRestoreComplete(restore *Restore) bool {
 	return condition != nil && condition.Status == corev1.ConditionTrue
 }
 
+// IsRestoreFailed returns true if a Restore has failed
+func IsRestoreFailed(restore *Restore) bool {
+	_, condition := GetRestoreCondition(&restore.Status, RestoreFailed)
+	return condition != nil && condition.Status == corev1.ConditionTrue
+}
+
 // IsRestoreScheduled returns true if a Restore has successfully scheduled
 func IsRestoreScheduled(restore *Restore) bool {
 	_, condition := GetRestoreCondition(&restore.Status, RestoreScheduled)
@@ -0,0 +1,90 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestGetWarmUpConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name              string
+		warmUp            *RestoreWarmUpConfig
+		expectStrategy    RestoreWarmUpStrategy
+		expectConcurrency int
+		expectTimeout     time.Duration
+		expectErr         bool
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		restore := &Restore{}
+		restore.Spec.WarmUp = test.warmUp
+		g.Expect(restore.GetWarmUpStrategy()).To(Equal(test.expectStrategy))
+		g.Expect(restore.GetWarmUpConcurrency()).To(Equal(test.expectConcurrency))
+		timeout, err := restore.GetWarmUpTimeout()
+		if test.expectErr {
+			g.Expect(err).To(HaveOccurred())
+			return
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(timeout).To(Equal(test.expectTimeout))
+	}
+	tests := []testcase{
+		{
+			name:              "not set",
+			warmUp:            nil,
+			expectStrategy:    RestoreWarmUpScan,
+			expectConcurrency: 4,
+			expectTimeout:     0,
+		},
+		{
+			name:              "defaults",
+			warmUp:            &RestoreWarmUpConfig{},
+			expectStrategy:    RestoreWarmUpScan,
+			expectConcurrency: 4,
+			expectTimeout:     0,
+		},
+		{
+			name:              "analyze",
+			warmUp:            &RestoreWarmUpConfig{Strategy: RestoreWarmUpAnalyze, Concurrency: 8, Timeout: "30m"},
+			expectStrategy:    RestoreWarmUpAnalyze,
+			expectConcurrency: 8,
+			expectTimeout:     30 * time.Minute,
+		},
+		{
+			name:              "invalid timeout",
+			warmUp:            &RestoreWarmUpConfig{Timeout: "30"},
+			expectStrategy:    RestoreWarmUpScan,
+			expectConcurrency: 4,
+			expectErr:         true,
+		},
+		{
+			name:              "negative timeout",
+			warmUp:            &RestoreWarmUpConfig{Timeout: "-1h"},
+			expectStrategy:    RestoreWarmUpScan,
+			expectConcurrency: 4,
+			expectErr:         true,
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
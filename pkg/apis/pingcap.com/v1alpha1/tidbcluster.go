@@ -13,6 +13,11 @@
 
 package v1alpha1
 
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
 func (mt MemberType) String() string {
 	return string(mt)
 }
@@ -107,8 +112,117 @@ func (tc *TidbCluster) TiDBRealReplicas() int32 {
 	return tc.Spec.TiDB.Replicas + int32(len(tc.Status.TiDB.FailureMembers))
 }
 
+func (tc *TidbCluster) TiProxyEnabled() bool {
+	return tc.Spec.TiProxy != nil
+}
+
+func (tc *TidbCluster) PDMSEnabled() bool {
+	return tc.Spec.PD.Mode == PDModeMicroservice
+}
+
+// PDMSSpecFor returns the spec of the pd microservice, nil if it's not set
+func (tc *TidbCluster) PDMSSpecFor(memberType MemberType) *PDMSSpec {
+	switch memberType {
+	case PDTSOMemberType:
+		return tc.Spec.PD.TSO
+	case PDSchedulingMemberType:
+		return tc.Spec.PD.Scheduling
+	}
+	return nil
+}
+
+// PDMSStatusFor returns the status of the pd microservice
+func (tc *TidbCluster) PDMSStatusFor(memberType MemberType) *PDMSStatus {
+	switch memberType {
+	case PDTSOMemberType:
+		return &tc.Status.PD.TSO
+	case PDSchedulingMemberType:
+		return &tc.Status.PD.Scheduling
+	}
+	return nil
+}
+
+func (tc *TidbCluster) PDMSUpgrading() bool {
+	return tc.Status.PD.TSO.Phase == UpgradePhase || tc.Status.PD.Scheduling.Phase == UpgradePhase
+}
+
+func (tc *TidbCluster) RecoveryModeEnabled() bool {
+	return tc.Spec.RecoveryMode != nil
+}
+
+func (tc *TidbCluster) ServiceMeshEnabled() bool {
+	return tc.Spec.ServiceMesh != nil
+}
+
+// HoldApplicationUntilProxyStarts returns whether the components should wait for the service mesh proxy
+func (tc *TidbCluster) HoldApplicationUntilProxyStarts() bool {
+	if !tc.ServiceMeshEnabled() {
+		return false
+	}
+	hold := tc.Spec.ServiceMesh.HoldApplicationUntilProxyStarts
+	return hold == nil || *hold
+}
+
+// ServiceMeshExcludePeerPorts returns whether the peer ports should bypass the service mesh proxy
+func (tc *TidbCluster) ServiceMeshExcludePeerPorts() bool {
+	if !tc.ServiceMeshEnabled() {
+		return false
+	}
+	exclude := tc.Spec.ServiceMesh.ExcludePeerPorts
+	return exclude == nil || *exclude
+}
+
+// GetDataRetentionPolicy returns the retention policy of the PVCs of PD and TiKV when the
+// TidbCluster is deleted, defaults to Retain
+func (tc *TidbCluster) GetDataRetentionPolicy() DataRetentionPolicyType {
+	if tc.Spec.DataRetentionPolicy == "" {
+		return DataRetentionPolicyRetain
+	}
+	return tc.Spec.DataRetentionPolicy
+}
+
+// PDLocationLabels returns the location labels of PD for the topology spread constraints
+func (tc *TidbCluster) PDLocationLabels() []string {
+	var locationLabels []string
+	for _, constraint := range tc.Spec.TopologySpreadConstraints {
+		key := constraint.TopologyKey
+		// the slash is not allowed in the labels of PD, the hostname of the node is labeled as host
+		if key == corev1.LabelHostname {
+			key = "host"
+		}
+		locationLabels = append(locationLabels, key)
+	}
+	return locationLabels
+}
+
+func (tc *TidbCluster) DashboardEnabled() bool {
+	return tc.Spec.Dashboard != nil
+}
+
+func (tc *TidbCluster) TiProxyUpgrading() bool {
+	return tc.Status.TiProxy.Phase == UpgradePhase
+}
+
+func (tc *TidbCluster) TiProxyAllMembersReady() bool {
+	if !tc.TiProxyEnabled() || int(tc.Spec.TiProxy.Replicas) != len(tc.Status.TiProxy.Members) {
+		return false
+	}
+
+	for _, member := range tc.Status.TiProxy.Members {
+		if !member.Health {
+			return false
+		}
+	}
+
+	return true
+}
+
 func (tc *TidbCluster) PDIsAvailable() bool {
 	lowerLimit := tc.Spec.PD.Replicas/2 + 1
+	if tc.RecoveryModeEnabled() {
+		// the quorum of pd may be lost and recovered from a single member
+		lowerLimit = 1
+	}
 	if int32(len(tc.Status.PD.Members)) < lowerLimit {
 		return false
 	}
@@ -165,3 +279,40 @@ func (tc *TidbCluster) Scheme() string {
 	}
 	return "http"
 }
+
+// GetTidbClusterCondition get the specify type's TidbClusterCondition from the given TidbClusterStatus
+func GetTidbClusterCondition(status *TidbClusterStatus, conditionType TidbClusterConditionType) (int, *TidbClusterCondition) {
+	if status == nil {
+		return -1, nil
+	}
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == conditionType {
+			return i, &status.Conditions[i]
+		}
+	}
+	return -1, nil
+}
+
+// UpdateTidbClusterCondition updates existing TidbCluster condition or creates a new
+// one. Sets LastTransitionTime to now if the status has changed.
+// Returns true if TidbCluster condition has changed or has been added.
+func UpdateTidbClusterCondition(status *TidbClusterStatus, condition *TidbClusterCondition) bool {
+	condition.LastTransitionTime = metav1.Now()
+	conditionIndex, oldCondition := GetTidbClusterCondition(status, condition.Type)
+
+	if oldCondition == nil {
+		status.Conditions = append(status.Conditions, *condition)
+		return true
+	}
+	if condition.Status == oldCondition.Status {
+		condition.LastTransitionTime = oldCondition.LastTransitionTime
+	}
+
+	isUpdate := condition.Status == oldCondition.Status &&
+		condition.Reason == oldCondition.Reason &&
+		condition.Message == oldCondition.Message &&
+		condition.LastTransitionTime.Equal(&oldCondition.LastTransitionTime)
+
+	status.Conditions[conditionIndex] = *condition
+	return !isUpdate
+}
@@ -17,7 +17,7 @@ import (
 	"testing"
 
 	. "github.com/onsi/gomega"
-	apps "k8s.io/api/apps/v1beta1"
+	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -188,3 +188,27 @@ func newTidbCluster() *TidbCluster {
 		},
 	}
 }
+
+func TestPDLocationLabels(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbCluster()
+	g.Expect(tc.PDLocationLabels()).To(BeNil())
+
+	tc.Spec.TopologySpreadConstraints = []TopologySpreadConstraint{
+		{TopologyKey: "zone"},
+		{TopologyKey: "rack"},
+		{TopologyKey: corev1.LabelHostname},
+	}
+	g.Expect(tc.PDLocationLabels()).To(Equal([]string{"zone", "rack", "host"}))
+}
+
+func TestGetDataRetentionPolicy(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbCluster()
+	g.Expect(tc.GetDataRetentionPolicy()).To(Equal(DataRetentionPolicyRetain))
+
+	tc.Spec.DataRetentionPolicy = DataRetentionPolicyDelete
+	g.Expect(tc.GetDataRetentionPolicy()).To(Equal(DataRetentionPolicyDelete))
+}
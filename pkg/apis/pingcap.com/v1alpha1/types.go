@@ -14,8 +14,9 @@
 package v1alpha1
 
 import (
-	apps "k8s.io/api/apps/v1beta1"
+	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	extv1beta1 "k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
@@ -41,6 +42,12 @@ const (
 	TiDBMemberType MemberType = "tidb"
 	// TiKVMemberType is tikv container type
 	TiKVMemberType MemberType = "tikv"
+	// TiProxyMemberType is tiproxy container type
+	TiProxyMemberType MemberType = "tiproxy"
+	// PDTSOMemberType is pd tso microservice container type
+	PDTSOMemberType MemberType = "tso"
+	// PDSchedulingMemberType is pd scheduling microservice container type
+	PDSchedulingMemberType MemberType = "scheduling"
 	// SlowLogTailerMemberType is tidb log tailer container type
 	SlowLogTailerMemberType MemberType = "slowlog"
 	// UnknownMemberType is unknown container type
@@ -57,6 +64,19 @@ const (
 	UpgradePhase MemberPhase = "Upgrade"
 )
 
+// UpgradePodPhase is the phase of the pod being upgraded in a rolling upgrade
+type UpgradePodPhase string
+
+const (
+	// UpgradePodEvictingLeaders means the leaders on the member, i.e. the pd leader, the region
+	// leaders of the tikv store or the ddl owner of tidb, are being moved to the other members
+	UpgradePodEvictingLeaders UpgradePodPhase = "EvictingLeaders"
+	// UpgradePodRestarting means the pod is being recreated by the statefulset with the new revision
+	UpgradePodRestarting UpgradePodPhase = "Restarting"
+	// UpgradePodRejoining means the pod is recreated and waiting for the member to be healthy
+	UpgradePodRejoining UpgradePodPhase = "Rejoining"
+)
+
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -92,17 +112,108 @@ type TidbClusterSpec struct {
 	// Services list non-headless services type used in TidbCluster
 	Services        []Service                            `json:"services,omitempty"`
 	PVReclaimPolicy corev1.PersistentVolumeReclaimPolicy `json:"pvReclaimPolicy,omitempty"`
-	Timezone        string                               `json:"timezone,omitempty"`
+	// DataRetentionPolicy is the policy of the PVCs of PD and TiKV when the TidbCluster
+	// is deleted, defaults to Retain. The PVs are removed along with the PVCs only if
+	// the pvReclaimPolicy is Delete.
+	DataRetentionPolicy DataRetentionPolicyType `json:"dataRetentionPolicy,omitempty"`
+	Timezone            string                  `json:"timezone,omitempty"`
 	// Enable TLS connection between TiDB server compoments
 	EnableTLSCluster bool `json:"enableTLSCluster,omitempty"`
+	// TiProxy is the spec of the TiProxy members in front of TiDB, it is not deployed if it is nil
+	TiProxy *TiProxySpec `json:"tiproxy,omitempty"`
+	// Dashboard is the spec of the standalone TiDB Dashboard, it is not deployed if it is nil
+	Dashboard *DashboardSpec `json:"dashboard,omitempty"`
+	// RecoveryMode relaxes the safety checks of the operator to bring back a cluster which
+	// lost the majority of its PD or TiKV members, it should be removed once the cluster is recovered
+	RecoveryMode *RecoverySpec `json:"recoveryMode,omitempty"`
+	// TopologySpreadConstraints spread the PD and TiKV pods across the topology
+	// domains, from the largest domain to the smallest. The topology keys are
+	// configured as the location-labels of PD in the same order, the key
+	// kubernetes.io/hostname is configured as the label host.
+	TopologySpreadConstraints []TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	// ClusterDomain is the domain of the Kubernetes cluster, e.g. cluster.local.
+	// If it is set, the PD, TiKV and TiDB members advertise their FQDNs under
+	// this domain so that they are resolvable from the other Kubernetes clusters.
+	ClusterDomain string `json:"clusterDomain,omitempty"`
+	// AcrossK8s indicates that the members of the cluster are deployed in
+	// several Kubernetes clusters, the TiKV and TiDB members get the PD
+	// addresses from the discovery service instead of the local PD service.
+	AcrossK8s bool `json:"acrossK8s,omitempty"`
+	// Cluster is the TidbCluster in another Kubernetes cluster that the
+	// members of this TidbCluster join instead of bootstrapping a new cluster
+	Cluster *TidbClusterRef `json:"cluster,omitempty"`
+	// ServiceMesh indicates that the service mesh sidecars, e.g. the Istio proxies,
+	// are injected into the pods of the cluster
+	ServiceMesh *ServiceMeshSpec `json:"serviceMesh,omitempty"`
+}
+
+// DataRetentionPolicyType represents the retention policy of the data when a TidbCluster is deleted
+type DataRetentionPolicyType string
+
+const (
+	// DataRetentionPolicyRetain keeps the PVCs of PD and TiKV
+	DataRetentionPolicyRetain DataRetentionPolicyType = "Retain"
+	// DataRetentionPolicyDelete removes the PVCs of PD and TiKV
+	DataRetentionPolicyDelete DataRetentionPolicyType = "Delete"
+)
+
+// ServiceMeshSpec contains the settings of the pods with the injected service mesh sidecars
+type ServiceMeshSpec struct {
+	// HoldApplicationUntilProxyStarts delays the start of the components until the
+	// proxy is ready, otherwise the connections made by the start scripts, e.g. to
+	// the discovery service, fail before the proxy starts. Defaults to true.
+	HoldApplicationUntilProxyStarts *bool `json:"holdApplicationUntilProxyStarts,omitempty"`
+	// ExcludePeerPorts excludes the peer ports, i.e. 2380 of PD and 20160 of TiKV,
+	// from the redirection to the proxy, the peers address each other by the pod
+	// names of the headless services, which are not routed by the mesh. Defaults to true.
+	ExcludePeerPorts *bool `json:"excludePeerPorts,omitempty"`
+}
+
+// TidbClusterRef references a TidbCluster which may be in another Kubernetes cluster
+type TidbClusterRef struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// ClusterDomain is the domain of the Kubernetes cluster of the TidbCluster,
+	// it's empty if the TidbCluster is in the same Kubernetes cluster
+	ClusterDomain string `json:"clusterDomain,omitempty"`
+}
+
+// TopologySpreadConstraint spreads the pods across the domains of a topology
+type TopologySpreadConstraint struct {
+	// TopologyKey is the key of the node labels, the nodes with the same value
+	// of the label are in the same domain
+	TopologyKey string `json:"topologyKey"`
 }
 
 // TidbClusterStatus represents the current status of a tidb cluster.
 type TidbClusterStatus struct {
-	ClusterID string     `json:"clusterID,omitempty"`
-	PD        PDStatus   `json:"pd,omitempty"`
-	TiKV      TiKVStatus `json:"tikv,omitempty"`
-	TiDB      TiDBStatus `json:"tidb,omitempty"`
+	ClusterID string          `json:"clusterID,omitempty"`
+	PD        PDStatus        `json:"pd,omitempty"`
+	TiKV      TiKVStatus      `json:"tikv,omitempty"`
+	TiDB      TiDBStatus      `json:"tidb,omitempty"`
+	TiProxy   TiProxyStatus   `json:"tiproxy,omitempty"`
+	Dashboard DashboardStatus `json:"dashboard,omitempty"`
+	Recovery  RecoveryStatus  `json:"recovery,omitempty"`
+	// Conditions are the latest observations of the TidbCluster
+	Conditions []TidbClusterCondition `json:"conditions,omitempty"`
+}
+
+// TidbClusterConditionType represents a TidbCluster condition value.
+type TidbClusterConditionType string
+
+const (
+	// TidbClusterUpgradeHealthy means the cluster passed the health checks before a rolling upgrade,
+	// it's false if the upgrade is refused, the reason and the message tell the failed check
+	TidbClusterUpgradeHealthy TidbClusterConditionType = "UpgradeHealthy"
+)
+
+// TidbClusterCondition describes the observed state of a TidbCluster at a certain point.
+type TidbClusterCondition struct {
+	Type               TidbClusterConditionType `json:"type"`
+	Status             corev1.ConditionStatus   `json:"status"`
+	LastTransitionTime metav1.Time              `json:"lastTransitionTime"`
+	Reason             string                   `json:"reason"`
+	Message            string                   `json:"message"`
 }
 
 // PDSpec contains details of PD members
@@ -111,6 +222,90 @@ type PDSpec struct {
 	PodAttributesSpec
 	Replicas         int32  `json:"replicas"`
 	StorageClassName string `json:"storageClassName,omitempty"`
+	// Mode is the deploy mode of PD, in the microservice mode the TSO and
+	// scheduling services are split out of the PD members, which only serve
+	// the API then
+	Mode PDMode `json:"mode,omitempty"`
+	// TSO is the spec of the pd-tso members, it's required in the microservice mode
+	TSO *PDMSSpec `json:"tso,omitempty"`
+	// Scheduling is the spec of the pd-scheduling members, it's required in the microservice mode
+	Scheduling *PDMSSpec `json:"scheduling,omitempty"`
+	// MaxReplicas is the number of the replicas of each region, it's reconciled
+	// as the max-replicas of PD when it's set
+	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
+	// PlacementRules are the placement rule groups reconciled to PD, the
+	// groups removed from the spec are removed from PD as well
+	PlacementRules []PlacementRuleGroup `json:"placementRules,omitempty"`
+	// MinReadySeconds is the time an upgraded pd pod must stay ready before the next pod is
+	// upgraded, defaults to 0, i.e. the next pod is upgraded once the pod is ready
+	MinReadySeconds int32 `json:"minReadySeconds,omitempty"`
+}
+
+// PlacementRuleGroup is a group of PD placement rules
+type PlacementRuleGroup struct {
+	// ID of the group, the group "pd" holds the default rule of PD
+	ID string `json:"id"`
+	// Index decides the order the groups are applied in
+	Index int `json:"index,omitempty"`
+	// Override makes the group override the groups with lower index
+	Override bool            `json:"override,omitempty"`
+	Rules    []PlacementRule `json:"rules"`
+}
+
+// PlacementRule decides how the replicas of the regions in a key range are placed
+type PlacementRule struct {
+	ID       string `json:"id"`
+	Index    int    `json:"index,omitempty"`
+	Override bool   `json:"override,omitempty"`
+	// StartKey and EndKey are the hex encoded keys of the range, empty for the whole key space
+	StartKey string        `json:"startKey,omitempty"`
+	EndKey   string        `json:"endKey,omitempty"`
+	Role     PlacementRole `json:"role"`
+	// Count is the number of the replicas of the role, e.g. the number of the learners
+	Count            int                        `json:"count"`
+	LabelConstraints []PlacementLabelConstraint `json:"labelConstraints,omitempty"`
+	LocationLabels   []string                   `json:"locationLabels,omitempty"`
+	IsolationLevel   string                     `json:"isolationLevel,omitempty"`
+}
+
+// PlacementRole is the role of the replicas placed by a rule
+type PlacementRole string
+
+const (
+	// PlacementRoleVoter is the role of the replicas that can be elected as the leader
+	PlacementRoleVoter PlacementRole = "voter"
+	// PlacementRoleLeader is the role of the leader replica
+	PlacementRoleLeader PlacementRole = "leader"
+	// PlacementRoleFollower is the role of the follower replicas that can't be the leader
+	PlacementRoleFollower PlacementRole = "follower"
+	// PlacementRoleLearner is the role of the learner replicas that don't vote
+	PlacementRoleLearner PlacementRole = "learner"
+)
+
+// PlacementLabelConstraint selects the stores to place the replicas by the store labels
+type PlacementLabelConstraint struct {
+	Key string `json:"key"`
+	// Op is one of in, notIn, exists and notExists
+	Op     string   `json:"op"`
+	Values []string `json:"values,omitempty"`
+}
+
+// PDMode is the deploy mode of PD
+type PDMode string
+
+const (
+	// PDModeNormal runs all the services of PD in the PD members
+	PDModeNormal PDMode = ""
+	// PDModeMicroservice runs the TSO and scheduling services of PD in their own members
+	PDModeMicroservice PDMode = "ms"
+)
+
+// PDMSSpec contains details of the members of a PD microservice
+type PDMSSpec struct {
+	// Image defaults to the image of PD, the microservices are served by the same binary
+	ContainerSpec
+	PodAttributesSpec
+	Replicas int32 `json:"replicas"`
 }
 
 // TiDBSpec contains details of TiDB members
@@ -124,6 +319,19 @@ type TiDBSpec struct {
 	SeparateSlowLog  bool                  `json:"separateSlowLog,omitempty"`
 	SlowLogTailer    TiDBSlowLogTailerSpec `json:"slowLogTailer,omitempty"`
 	EnableTLSClient  bool                  `json:"enableTLSClient,omitempty"`
+	Service          *TiDBServiceSpec      `json:"service,omitempty"`
+	// MinReadySeconds is the time an upgraded tidb pod must stay ready before the next pod is
+	// upgraded, defaults to 0, i.e. the next pod is upgraded once the pod is ready
+	MinReadySeconds int32 `json:"minReadySeconds,omitempty"`
+}
+
+// TiDBServiceSpec contains the settings of the TiDB Service which the clients connect to
+type TiDBServiceSpec struct {
+	// DNSName is the hostname published by external-dns for the TiDB Service,
+	// the operator keeps it in the external-dns annotation of the Service, so
+	// the name stays the same when the Service is recreated. The annotation is
+	// left as it is when DNSName is cleared.
+	DNSName string `json:"dnsName,omitempty"`
 }
 
 // TiDBSlowLogTailerSpec represents an optional log tailer sidecar with TiDB
@@ -139,6 +347,132 @@ type TiKVSpec struct {
 	Privileged       bool   `json:"privileged,omitempty"`
 	StorageClassName string `json:"storageClassName,omitempty"`
 	MaxFailoverCount int32  `json:"maxFailoverCount,omitempty"`
+	// EvictLeader configures the leader eviction before a tikv pod is restarted in the rolling upgrade
+	EvictLeader *TiKVEvictLeaderSpec `json:"evictLeader,omitempty"`
+	// MinReadySeconds is the time an upgraded tikv pod must stay ready before the next pod is
+	// upgraded, defaults to 0, i.e. the next pod is upgraded once the pod is ready
+	MinReadySeconds int32 `json:"minReadySeconds,omitempty"`
+	// LivenessProbe adds a liveness probe of the tikv port to the tikv pods, no liveness probe is
+	// set if it is nil
+	LivenessProbe *TiKVLivenessProbe `json:"livenessProbe,omitempty"`
+}
+
+// TiKVLivenessProbe contains the thresholds of the liveness probe of the tikv pods. A store
+// replaying its raft logs on boot may not serve for many minutes, and the Kubernetes API the
+// operator is built with has no startupProbe, so the thresholds must cover the boot of the
+// largest store, or the pod is restarted in a loop
+type TiKVLivenessProbe struct {
+	// InitialDelaySeconds is the time after the tikv container is started before it is probed,
+	// defaults to 600
+	InitialDelaySeconds int32 `json:"initialDelaySeconds,omitempty"`
+	// PeriodSeconds is the interval of the probes, defaults to 10
+	PeriodSeconds int32 `json:"periodSeconds,omitempty"`
+	// FailureThreshold is the number of the consecutive failed probes after which the container
+	// is restarted, defaults to 3
+	FailureThreshold int32 `json:"failureThreshold,omitempty"`
+}
+
+// TiKVEvictLeaderSpec contains the thresholds of the leader eviction of the tikv upgrader
+type TiKVEvictLeaderSpec struct {
+	// MaxLeaderCount is the max number of the leaders remaining on the store for the pod to be
+	// restarted, defaults to 0, i.e. all the leaders are evicted
+	MaxLeaderCount int32 `json:"maxLeaderCount,omitempty"`
+	// SkipLeaderCount skips the leader eviction of the stores with less leaders than it, the
+	// pods of the stores are restarted directly, defaults to 0, i.e. the eviction is never skipped
+	SkipLeaderCount int32 `json:"skipLeaderCount,omitempty"`
+	// TimeoutSeconds is the max time the leaders are evicted for, the pod is restarted after
+	// the timeout even if the leaders are not evicted, defaults to 180
+	TimeoutSeconds int64 `json:"timeoutSeconds,omitempty"`
+	// PollIntervalSeconds is the interval the leader count of the store is checked during the
+	// eviction, defaults to the backoff of the operator
+	PollIntervalSeconds int64 `json:"pollIntervalSeconds,omitempty"`
+}
+
+// TiProxySpec contains details of TiProxy members, TiProxy routes the client
+// connections to the TiDB members and migrates them to the other members before
+// a TiDB member shuts down, e.g. during the rolling upgrade of TiDB
+type TiProxySpec struct {
+	ContainerSpec
+	PodAttributesSpec
+	Replicas int32 `json:"replicas"`
+	// ServiceType is the type of the service which the clients connect to, defaults to ClusterIP
+	ServiceType corev1.ServiceType `json:"serviceType,omitempty"`
+	// TiDBGracefulWaitSeconds is the time TiProxy is given to migrate the
+	// connections of a TiDB member before it is killed, the termination grace
+	// period of the TiDB pods is extended by it. It should be larger than the
+	// graceful-wait-before-shutdown of the TiDB config, defaults to 30.
+	TiDBGracefulWaitSeconds *int64 `json:"tidbGracefulWaitSeconds,omitempty"`
+	// Config is the TiProxy config in toml, which is appended to the config
+	// generated by the operator (the listen addresses, PD addresses and TLS),
+	// so it should only contain tables
+	Config string `json:"config,omitempty"`
+}
+
+// RecoverySpec describes how a cluster is recovered from a disaster. While it's set:
+//   - PD is regarded as available as long as one of its members is healthy
+//   - the auto failover of PD and TiKV is paused
+//   - the TiDB members are not synced, so that nothing is written to the cluster
+//     before it's recovered, e.g. when the TiKV volumes are restored from snapshots
+type RecoverySpec struct {
+	// PDForceNewCluster restarts the first PD member with --force-new-cluster, which makes
+	// it a single member cluster from its own data when the majority of PD is lost.
+	// The data of the other PD members must be cleaned before they are rejoined, and it
+	// should be unset once the first PD member is running.
+	PDForceNewCluster bool `json:"pdForceNewCluster,omitempty"`
+	// FailedStores are the IDs of the TiKV stores which are lost permanently, PD is asked
+	// to remove them by unsafe recovery, which recovers the regions that lost their
+	// majority from the remaining replicas
+	FailedStores []string `json:"failedStores,omitempty"`
+}
+
+// RecoveryPhase is the phase of the unsafe recovery
+type RecoveryPhase string
+
+const (
+	// RecoveryRunning means the unsafe recovery is submitted to PD and running
+	RecoveryRunning RecoveryPhase = "Running"
+	// RecoveryFinished means the unsafe recovery is finished
+	RecoveryFinished RecoveryPhase = "Finished"
+	// RecoveryFailed means the unsafe recovery is failed
+	RecoveryFailed RecoveryPhase = "Failed"
+)
+
+// RecoveryStatus is the status of the unsafe recovery of the failed stores
+type RecoveryStatus struct {
+	// FailedStores are the stores submitted to PD for the unsafe recovery
+	FailedStores []string      `json:"failedStores,omitempty"`
+	Phase        RecoveryPhase `json:"phase,omitempty"`
+	// Message is the info of the latest stage of the unsafe recovery reported by PD
+	Message string `json:"message,omitempty"`
+}
+
+// DashboardSpec contains details of the standalone TiDB Dashboard, which
+// connects to PD and TiDB so that PD doesn't have to be exposed for it
+type DashboardSpec struct {
+	ContainerSpec
+	PodAttributesSpec
+	// ServiceType is the type of the dashboard service, defaults to ClusterIP
+	ServiceType corev1.ServiceType `json:"serviceType,omitempty"`
+	// Ingress exposes the dashboard by an ingress if it is not nil
+	Ingress *DashboardIngressSpec `json:"ingress,omitempty"`
+	// PathPrefix is the public path prefix of the dashboard, e.g. when it is
+	// served under a sub-path of the ingress host, defaults to /dashboard
+	PathPrefix string `json:"pathPrefix,omitempty"`
+}
+
+// DashboardIngressSpec describes the ingress of the dashboard
+type DashboardIngressSpec struct {
+	// Hosts are the hosts of the ingress rules
+	Hosts []string `json:"hosts"`
+	// Annotations are added to the ingress, e.g. to select the ingress class
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// TLS terminates TLS at the ingress with the certificates of the secrets
+	TLS []extv1beta1.IngressTLS `json:"tls,omitempty"`
+}
+
+// DashboardStatus is the status of the standalone TiDB Dashboard
+type DashboardStatus struct {
+	StatefulSet *apps.StatefulSetStatus `json:"statefulSet,omitempty"`
 }
 
 // TiKVPromGatewaySpec runs as a sidecar with TiKVSpec
@@ -189,6 +523,46 @@ type PDStatus struct {
 	Members        map[string]PDMember        `json:"members,omitempty"`
 	Leader         PDMember                   `json:"leader,omitempty"`
 	FailureMembers map[string]PDFailureMember `json:"failureMembers,omitempty"`
+	// TSO is the status of the pd-tso members in the microservice mode
+	TSO PDMSStatus `json:"tso,omitempty"`
+	// Scheduling is the status of the pd-scheduling members in the microservice mode
+	Scheduling PDMSStatus `json:"scheduling,omitempty"`
+	// PlacementRuleGroups are the IDs of the placement rule groups synced to PD
+	PlacementRuleGroups []string `json:"placementRuleGroups,omitempty"`
+	// Upgrade is the progress of the rolling upgrade, it's nil if pd is not being upgraded
+	Upgrade *UpgradeProgress `json:"upgrade,omitempty"`
+}
+
+// UpgradeProgress is the progress of the rolling upgrade of the members of a component, the
+// pods are upgraded one by one from the largest ordinal
+type UpgradeProgress struct {
+	// Image is the image being rolled to
+	Image string `json:"image,omitempty"`
+	// PodName is the name of the pod being upgraded, it's empty if no pod is being upgraded
+	PodName string `json:"podName,omitempty"`
+	// Ordinal is the ordinal of the pod being upgraded
+	Ordinal int32 `json:"ordinal"`
+	// Phase is the phase of the pod being upgraded
+	Phase UpgradePodPhase `json:"phase,omitempty"`
+	// Upgraded is the number of the pods with the new revision
+	Upgraded int32 `json:"upgraded"`
+	// Pending is the number of the pods to be upgraded
+	Pending int32 `json:"pending"`
+}
+
+// PDMSStatus is the status of the members of a PD microservice
+type PDMSStatus struct {
+	Phase       MemberPhase             `json:"phase,omitempty"`
+	StatefulSet *apps.StatefulSetStatus `json:"statefulSet,omitempty"`
+	Members     map[string]PDMSMember   `json:"members,omitempty"`
+}
+
+// PDMSMember is the member of a PD microservice
+type PDMSMember struct {
+	Name   string `json:"name"`
+	Health bool   `json:"health"`
+	// Last time the health transitioned from one to another.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
 }
 
 // PDMember is PD member
@@ -219,6 +593,10 @@ type TiDBStatus struct {
 	Members                  map[string]TiDBMember        `json:"members,omitempty"`
 	FailureMembers           map[string]TiDBFailureMember `json:"failureMembers,omitempty"`
 	ResignDDLOwnerRetryCount int32                        `json:"resignDDLOwnerRetryCount,omitempty"`
+	// Selector is the label selector of the TiDB pods, used by the scale subresource
+	Selector string `json:"selector,omitempty"`
+	// Upgrade is the progress of the rolling upgrade, it's nil if tidb is not being upgraded
+	Upgrade *UpgradeProgress `json:"upgrade,omitempty"`
 }
 
 // TiDBMember is TiDB member
@@ -237,6 +615,21 @@ type TiDBFailureMember struct {
 	CreatedAt metav1.Time `json:"createdAt,omitempty"`
 }
 
+// TiProxyStatus is TiProxy status
+type TiProxyStatus struct {
+	Phase       MemberPhase              `json:"phase,omitempty"`
+	StatefulSet *apps.StatefulSetStatus  `json:"statefulSet,omitempty"`
+	Members     map[string]TiProxyMember `json:"members,omitempty"`
+}
+
+// TiProxyMember is TiProxy member
+type TiProxyMember struct {
+	Name   string `json:"name"`
+	Health bool   `json:"health"`
+	// Last time the health transitioned from one to another.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
 // TiKVStatus is TiKV status
 type TiKVStatus struct {
 	Synced          bool                        `json:"synced,omitempty"`
@@ -245,6 +638,8 @@ type TiKVStatus struct {
 	Stores          map[string]TiKVStore        `json:"stores,omitempty"`
 	TombstoneStores map[string]TiKVStore        `json:"tombstoneStores,omitempty"`
 	FailureStores   map[string]TiKVFailureStore `json:"failureStores,omitempty"`
+	// Upgrade is the progress of the rolling upgrade, it's nil if tikv is not being upgraded
+	Upgrade *UpgradeProgress `json:"upgrade,omitempty"`
 }
 
 // TiKVStores is either Up/Down/Offline/Tombstone
@@ -295,11 +690,79 @@ type BackupStorageType string
 const (
 	// BackupStorageTypeCeph represents the backend storage type is ceph.
 	BackupStorageTypeCeph BackupStorageType = "ceph"
+	// BackupStorageTypeS3 represents the backend storage type is s3 compatible.
+	BackupStorageTypeS3 BackupStorageType = "s3"
+	// BackupStorageTypeGCS represents the backend storage type is google cloud storage.
+	BackupStorageTypeGCS BackupStorageType = "gcs"
+	// BackupStorageTypePVC represents the backup data is kept in a pvc created
+	// by the controller for each backup.
+	BackupStorageTypePVC BackupStorageType = "pvc"
 )
 
 // StorageProvider defines the configuration for storing a backup in backend storage.
 type StorageProvider struct {
 	Ceph *CephStorageProvider `json:"ceph"`
+	S3   *S3StorageProvider   `json:"s3,omitempty"`
+	GCS  *GCSStorageProvider  `json:"gcs,omitempty"`
+}
+
+// S3StorageProvider represents a S3 compatible bucket for storing backups,
+// e.g. AWS S3, MinIO and Ceph RGW.
+type S3StorageProvider struct {
+	// Provider is the S3 compatible storage provider, e.g. AWS, Minio or Ceph, defaults to AWS.
+	Provider string `json:"provider,omitempty"`
+	// Region in which the bucket is located.
+	Region string `json:"region,omitempty"`
+	// Bucket in which to store the Backup.
+	Bucket string `json:"bucket"`
+	// Prefix is the path prefix of the backups in the bucket.
+	Prefix string `json:"prefix,omitempty"`
+	// Endpoint is the access address of the S3 compatible object storage,
+	// the AWS S3 endpoint of the region is used if it is empty.
+	Endpoint string `json:"endpoint,omitempty"`
+	// ForcePathStyle accesses the bucket by path style (endpoint/bucket)
+	// instead of virtual hosted style (bucket.endpoint), which is usually
+	// required by MinIO and Ceph RGW.
+	ForcePathStyle bool `json:"forcePathStyle,omitempty"`
+	// SignatureVersion is the signature version used to sign the requests,
+	// v2 or v4, defaults to v4. v2 only works with mydumper and loader.
+	SignatureVersion string `json:"signatureVersion,omitempty"`
+	// CASecretName is the name of secret which stores the CA bundle in key
+	// ca.crt used to verify the certificate of the endpoint.
+	CASecretName string `json:"caSecretName,omitempty"`
+	// SecretName is the name of secret which stores
+	// S3 compatible object store access key and secret key.
+	// It can be omitted if roleARN is set.
+	SecretName string `json:"secretName,omitempty"`
+	// RoleARN is the IAM role which the job pods assume to access the bucket
+	// instead of the access keys in secretName. It is set as the kube2iam
+	// annotation of the job pods. For IRSA, the service account of the job pods
+	// must be annotated with eks.amazonaws.com/role-arn by the administrator.
+	RoleARN string `json:"roleARN,omitempty"`
+}
+
+// GCSStorageProvider represents a google cloud storage bucket for storing backups.
+type GCSStorageProvider struct {
+	// ProjectID is the project which the bucket belongs to.
+	ProjectID string `json:"projectId"`
+	// Location in which the bucket is created.
+	Location string `json:"location,omitempty"`
+	// Bucket in which to store the Backup.
+	Bucket string `json:"bucket"`
+	// Prefix is the path prefix of the backups in the bucket.
+	Prefix string `json:"prefix,omitempty"`
+	// StorageClass is the storage class of the uploaded objects, defaults to MULTI_REGIONAL.
+	StorageClass string `json:"storageClass,omitempty"`
+	// ObjectACL is the access control list of the uploaded objects.
+	ObjectACL string `json:"objectAcl,omitempty"`
+	// SecretName is the name of secret which stores the JSON key of the GCP
+	// service account in key credentials. It can be omitted if gcpServiceAccount is set.
+	SecretName string `json:"secretName,omitempty"`
+	// GCPServiceAccount is the email of the GCP service account which the job pods
+	// act as by Workload Identity instead of the JSON key in secretName. The service
+	// account of the job pods must be annotated with iam.gke.io/gcp-service-account
+	// by the administrator.
+	GCPServiceAccount string `json:"gcpServiceAccount,omitempty"`
 }
 
 // cephStorageProvider represents an ceph compatible bucket for storing backups.
@@ -338,10 +801,181 @@ type BackupSpec struct {
 	StorageType BackupStorageType `json:"storageType"`
 	// StorageProvider configures where and how backups should be stored.
 	StorageProvider `json:",inline"`
-	// StorageClassName is the storage class for backup job's PV.
+	// StorageClassName is the storage class for backup job's PV, it is also
+	// the storage class of the pvc which keeps the backup data if storageType is pvc.
 	StorageClassName string `json:"storageClassName"`
 	// StorageSize is the request storage size for backup job
 	StorageSize string `json:"storageSize"`
+	// BR is the configs for BR, the backup is taken by BR instead of mydumper when it is set.
+	BR *BRConfig `json:"br,omitempty"`
+	// Dumpling is the configs for dumpling, the backup is a logical export taken
+	// by dumpling instead of mydumper when it is set.
+	Dumpling *DumplingConfig `json:"dumpling,omitempty"`
+	// LogBackup is the configs for the log backup task of BR, the backup
+	// continuously backs up the change logs of tidb cluster when it is set,
+	// which enables point-in-time recovery on top of a snapshot backup.
+	LogBackup *LogBackupConfig `json:"logBackup,omitempty"`
+	// VolumeSnapshot is the configs for the volume snapshot backup, the backup
+	// is a set of snapshots of the TiKV volumes taken via the cloud API when it is set.
+	VolumeSnapshot *VolumeSnapshotConfig `json:"volumeSnapshot,omitempty"`
+	// Checksum toggles the checksum verification of the data backed up by BR,
+	// defaults to true. Disabling it makes the backup faster but the integrity
+	// of the backup is not guaranteed.
+	Checksum *bool `json:"checksum,omitempty"`
+	// TableFilter is the table filter rules of the backup taken by BR or dumpling,
+	// e.g. "db1.*", "!db1.tbl1". It overrides spec.dumpling.tableFilter.
+	TableFilter []string `json:"tableFilter,omitempty"`
+	// CleanPolicy is the policy of cleaning the backup data in the backend
+	// storage when the Backup is deleted, defaults to Delete.
+	CleanPolicy CleanPolicyType `json:"cleanPolicy,omitempty"`
+	// Encryption configures the encryption of the backup data.
+	Encryption *BackupEncryption `json:"encryption,omitempty"`
+	// SecondaryStorages are the storages the backup data is replicated to by
+	// the replicate job after it is uploaded to the primary storage, e.g. the
+	// buckets in other regions for disaster recovery. The data in them is
+	// cleaned with the primary data according to cleanPolicy.
+	SecondaryStorages []SecondaryStorage `json:"secondaryStorages,omitempty"`
+	// ServiceAccount is the service account of the backup and clean job pods,
+	// defaults to tidb-backup-manager, or tidb-backup-manager-volume-snapshot
+	// for the backup by volume snapshots.
+	ServiceAccount string `json:"serviceAccount,omitempty"`
+	// Requests and Limits are the resource requirements of the backup job pod.
+	Requests *ResourceRequirement `json:"requests,omitempty"`
+	Limits   *ResourceRequirement `json:"limits,omitempty"`
+	// Affinity, NodeSelector and Tolerations constrain the nodes which the
+	// backup and clean job pods can be scheduled to.
+	Affinity     *corev1.Affinity    `json:"affinity,omitempty"`
+	NodeSelector map[string]string   `json:"nodeSelector,omitempty"`
+	Tolerations  []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
+// SecondaryStorage is a storage the backup data is replicated to. The storage
+// is accessed by the credentials in its secret, or the cloud identity of the
+// job pods if the secret is omitted, caSecretName is not supported.
+type SecondaryStorage struct {
+	// Name identifies the storage in the status, it must be unique in the backup.
+	Name string `json:"name"`
+	// StorageType is the storage type, s3, gcs or ceph.
+	StorageType BackupStorageType `json:"storageType"`
+	// StorageProvider configures where and how the backup data is stored.
+	StorageProvider `json:",inline"`
+}
+
+// ServerSideEncryptionType represents the server-side encryption algorithm of the backend storage
+type ServerSideEncryptionType string
+
+const (
+	// ServerSideEncryptionAES256 encrypts the backup data with the keys managed by the backend storage (SSE-S3)
+	ServerSideEncryptionAES256 ServerSideEncryptionType = "AES256"
+	// ServerSideEncryptionKMS encrypts the backup data with a key managed by KMS (SSE-KMS)
+	ServerSideEncryptionKMS ServerSideEncryptionType = "aws:kms"
+)
+
+// BackupEncryption contains the encryption configs of the backup data
+type BackupEncryption struct {
+	// SSE is the server-side encryption algorithm of the backend storage, AES256 or aws:kms.
+	SSE ServerSideEncryptionType `json:"sse,omitempty"`
+	// CrypterMethod is the cipher method of BR's client-side encryption,
+	// aes128-ctr, aes192-ctr or aes256-ctr. It only works with backups taken by BR.
+	CrypterMethod string `json:"crypterMethod,omitempty"`
+	// SecretName is the name of the secret which stores the encryption keys.
+	// The KMS key id of SSE-KMS is stored in key sse_kms_key_id, the AWS managed
+	// key is used if it is missing. The hex encoded key of BR's client-side
+	// encryption is stored in key crypter_key, it is required if crypterMethod is set.
+	// The secret must also exist in the namespace of the Restore which restores the backup.
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// CleanPolicyType represents the clean policy of the backup data when a Backup is deleted
+type CleanPolicyType string
+
+const (
+	// CleanPolicyTypeRetain keeps the backup data in the backend storage
+	CleanPolicyTypeRetain CleanPolicyType = "Retain"
+	// CleanPolicyTypeOnFailure removes the backup data only if the backup failed
+	CleanPolicyTypeOnFailure CleanPolicyType = "OnFailure"
+	// CleanPolicyTypeDelete always removes the backup data
+	CleanPolicyTypeDelete CleanPolicyType = "Delete"
+)
+
+// BRConfig contains config for BR
+type BRConfig struct {
+	// LastBackupTS is the commit ts of the base backup, it is required for
+	// an incremental backup and must match the commitTs of a complete backup.
+	LastBackupTS string `json:"lastBackupTS,omitempty"`
+}
+
+// LogBackupCommand is the command of the log backup task
+type LogBackupCommand string
+
+const (
+	// LogBackupCommandStart starts the log backup task
+	LogBackupCommandStart LogBackupCommand = "start"
+	// LogBackupCommandStop stops the log backup task
+	LogBackupCommandStop LogBackupCommand = "stop"
+	// LogBackupCommandTruncate truncates the log data before truncateUntil
+	LogBackupCommandTruncate LogBackupCommand = "truncate"
+)
+
+// LogBackupConfig contains config for the log backup task of BR
+type LogBackupConfig struct {
+	// Command is the command of the log backup task, start, stop or truncate,
+	// defaults to start. The job of the former command is replaced when it is changed.
+	Command LogBackupCommand `json:"command,omitempty"`
+	// StartTS is the ts from which the log data is backed up, defaults to the current ts.
+	StartTS string `json:"startTs,omitempty"`
+	// TruncateUntil is the ts before which the log data is removed by the truncate command.
+	TruncateUntil string `json:"truncateUntil,omitempty"`
+}
+
+// VolumeSnapshotProvider is the provider of the volume snapshots
+type VolumeSnapshotProvider string
+
+const (
+	// VolumeSnapshotProviderAWSEBS takes EBS snapshots of the TiKV volumes via the AWS API
+	VolumeSnapshotProviderAWSEBS VolumeSnapshotProvider = "aws-ebs"
+	// VolumeSnapshotProviderCSI creates VolumeSnapshot objects of the TiKV and PD
+	// pvcs, the snapshots are taken by the CSI driver of the volumes
+	VolumeSnapshotProviderCSI VolumeSnapshotProvider = "csi"
+)
+
+// VolumeSnapshotConfig contains config for the volume snapshot backup. The
+// credentials and region of the aws-ebs provider are read from spec.s3, so the
+// storageType of the backup must be s3.
+type VolumeSnapshotConfig struct {
+	// Provider is the provider of the volume snapshots, defaults to csi if
+	// volumeSnapshotClassName is set, otherwise aws-ebs.
+	Provider VolumeSnapshotProvider `json:"provider,omitempty"`
+	// VolumeSnapshotClassName is the VolumeSnapshotClass of the VolumeSnapshots
+	// created by the csi provider.
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+	// Tags are the tags added to the volume snapshots.
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// DumplingFileType is the format of the files exported by dumpling
+type DumplingFileType string
+
+const (
+	// DumplingFileTypeSQL exports data as sql statements
+	DumplingFileTypeSQL DumplingFileType = "sql"
+	// DumplingFileTypeCSV exports data as csv files
+	DumplingFileTypeCSV DumplingFileType = "csv"
+)
+
+// DumplingConfig contains config for dumpling
+type DumplingConfig struct {
+	// TableFilter is the table filter rules of the export, e.g. "db1.*", "!mysql.*".
+	// Defaults to all tables excluding the system schemas, it is overridden by
+	// spec.tableFilter of the backup.
+	TableFilter []string `json:"tableFilter,omitempty"`
+	// FileType is the format of the exported files, sql or csv, defaults to sql
+	FileType DumplingFileType `json:"fileType,omitempty"`
+	// Compress is the compression algorithm of the exported files, e.g. gzip.
+	// The files are not compressed if it is empty.
+	Compress string `json:"compress,omitempty"`
+	// Threads is the number of concurrent export threads, defaults to 16
+	Threads *int32 `json:"threads,omitempty"`
 }
 
 // BackupConditionType represents a valid condition of a Backup.
@@ -359,6 +993,10 @@ const (
 	BackupClean BackupConditionType = "Clean"
 	// BackupFailed means the backup has failed.
 	BackupFailed BackupConditionType = "Failed"
+	// BackupReplicated means the backup data has been replicated to all the
+	// secondary storages, it is false while the replicate job is running or
+	// if the data failed to be replicated to any of them.
+	BackupReplicated BackupConditionType = "Replicated"
 )
 
 // BackupCondition describes the observed state of a Backup at a certain point.
@@ -381,10 +1019,98 @@ type BackupStatus struct {
 	// BackupSize is the data size of the backup.
 	BackupSize int64 `json:"backupSize"`
 	// CommitTs is the snapshot time point of tidb cluster.
-	CommitTs   string            `json:"commitTs"`
+	CommitTs string `json:"commitTs"`
+	// BackupPVC is the name of the pvc which keeps the backup data if storageType is pvc.
+	BackupPVC string `json:"backupPVC,omitempty"`
+	// LogBackup is the status of the log backup task.
+	LogBackup *LogBackupStatus `json:"logBackup,omitempty"`
+	// ChecksumVerified is whether the data of the backup is verified by checksum,
+	// it is false if BR skips the checksum of some tables.
+	ChecksumVerified bool `json:"checksumVerified,omitempty"`
+	// VolumeSnapshots are the snapshots of the volumes taken by the volume snapshot backup.
+	VolumeSnapshots []VolumeSnapshotStatus `json:"volumeSnapshots,omitempty"`
+	// ClusterID is the id of the tidb cluster in PD recorded by the volume snapshot
+	// backup, it is used to recover PD if the PD volumes are not snapshotted.
+	ClusterID string `json:"clusterID,omitempty"`
+	// MaxAllocID is the largest id allocated by PD found after the volume snapshots
+	// are taken, the PD recovered for the restored cluster allocates ids above it.
+	MaxAllocID uint64 `json:"maxAllocID,omitempty"`
+	// PausedScheduleLimits are the schedule limits of PD before they are set to 0 by
+	// the volume snapshot backup, they are restored by the retried job if the
+	// scheduling is not resumed by the former one.
+	PausedScheduleLimits map[string]uint64 `json:"pausedScheduleLimits,omitempty"`
+	// SecondaryStorages is the replication status of the backup data in the secondary storages.
+	SecondaryStorages []SecondaryStorageStatus `json:"secondaryStorages,omitempty"`
+	// Progress is the progress of the running backup.
+	Progress   *BackupProgress   `json:"progress,omitempty"`
 	Conditions []BackupCondition `json:"conditions"`
 }
 
+// SecondaryStoragePhase is the replication phase of the backup data in a secondary storage
+type SecondaryStoragePhase string
+
+const (
+	// SecondaryStorageReplicating means the backup data is being copied to the storage
+	SecondaryStorageReplicating SecondaryStoragePhase = "Replicating"
+	// SecondaryStorageComplete means the backup data has been copied to the storage
+	SecondaryStorageComplete SecondaryStoragePhase = "Complete"
+	// SecondaryStorageFailed means the backup data failed to be copied to the storage
+	SecondaryStorageFailed SecondaryStoragePhase = "Failed"
+)
+
+// SecondaryStorageStatus represents the replication status of the backup data in a secondary storage.
+type SecondaryStorageStatus struct {
+	// Name is the name of the secondary storage.
+	Name string `json:"name"`
+	// BackupPath is the location of the backup in the secondary storage.
+	BackupPath string `json:"backupPath,omitempty"`
+	// Phase is the replication phase of the backup data.
+	Phase SecondaryStoragePhase `json:"phase"`
+	// Message is the reason of the failed replication.
+	Message string `json:"message,omitempty"`
+	// TimeCompleted is the time at which the replication was completed.
+	TimeCompleted metav1.Time `json:"timeCompleted,omitempty"`
+}
+
+// VolumeSnapshotStatus represents the snapshot of a TiKV or PD volume.
+type VolumeSnapshotStatus struct {
+	// PVCName is the name of the pvc of the volume.
+	PVCName string `json:"pvcName"`
+	// VolumeID is the id of the volume in the cloud provider, or the volume
+	// handle of the CSI driver.
+	VolumeID string `json:"volumeID"`
+	// SnapshotName is the name of the VolumeSnapshot created by the csi provider.
+	SnapshotName string `json:"snapshotName,omitempty"`
+	// SnapshotID is the id of the snapshot in the cloud provider, or the name of
+	// the VolumeSnapshotContent bound to the VolumeSnapshot of the csi provider.
+	SnapshotID string `json:"snapshotID,omitempty"`
+}
+
+// LogBackupStatus represents the current state of a log backup task.
+type LogBackupStatus struct {
+	// StartTs is the ts from which the log data is backed up.
+	StartTs string `json:"startTs,omitempty"`
+	// CheckpointTs is the ts before which all the log data has been backed up,
+	// it is refreshed periodically while the task is running.
+	CheckpointTs string `json:"checkpointTs,omitempty"`
+	// TruncateUntil is the ts before which the log data has been truncated.
+	TruncateUntil string `json:"truncateUntil,omitempty"`
+	// LastUpdateTime is the last time the status was updated.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+// BackupProgress represents the progress of a running backup.
+type BackupProgress struct {
+	// Percentage is the completed percentage of the backup, from 0 to 100.
+	Percentage int32 `json:"percentage"`
+	// BackedUpBytes is the size of the data that has been uploaded to the backend storage.
+	BackedUpBytes int64 `json:"backedUpBytes"`
+	// EstimatedRemainingTime is the estimated time to complete the backup, e.g. 5m30s.
+	EstimatedRemainingTime string `json:"estimatedRemainingTime,omitempty"`
+	// LastUpdateTime is the last time the progress was updated.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -411,7 +1137,19 @@ type BackupScheduleList struct {
 type BackupScheduleSpec struct {
 	// Schedule specifies the cron string used for backup scheduling.
 	Schedule string `json:"schedule"`
+	// IncrementalSchedule specifies the cron string used for incremental backup scheduling.
+	// It only takes effect when backupTemplate.br is set, each incremental backup is taken
+	// on top of the last complete backup created by this schedule.
+	IncrementalSchedule string `json:"incrementalSchedule,omitempty"`
+	// Timezone is the IANA name of the timezone the cron strings are evaluated
+	// in, e.g. Asia/Shanghai, defaults to UTC. A run whose time is skipped by a
+	// daylight saving transition is taken at the time shifted by the transition,
+	// e.g. 02:30 is taken at 03:30, and a run whose time is repeated is taken once.
+	Timezone string `json:"timezone,omitempty"`
 	// MaxBackups is to specify how many backups we want to keep.
+	// The data of the pruned backups is removed from the backend storage
+	// regardless of the cleanPolicy of backupTemplate. The base backups of the
+	// kept incremental backups are kept until the incremental ones are pruned.
 	MaxBackups int `json:"maxBackups"`
 	// BackupTemplate is the specification of the backup structure to get scheduled.
 	BackupTemplate BackupSpec `json:"backupTemplate"`
@@ -419,14 +1157,44 @@ type BackupScheduleSpec struct {
 	StorageClassName string `json:"storageClassName"`
 	// StorageSize is the request storage size for backup job
 	StorageSize string `json:"storageSize"`
+	// Pause means the backup schedule is paused, no backup is created while it
+	// is paused and the existing backups are still pruned by maxBackups. Only
+	// one backup is taken for the runs missed while it is paused after resumed.
+	Pause bool `json:"pause,omitempty"`
+	// ConcurrencyPolicy specifies how to handle a scheduled backup when the last
+	// backup is still running, Forbid or Replace, defaults to Forbid.
+	ConcurrencyPolicy ConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
 }
 
+// ConcurrencyPolicy is the policy of the backup schedule to handle a scheduled
+// backup when the last backup is still running
+type ConcurrencyPolicy string
+
+const (
+	// ConcurrencyPolicyForbid waits for the last backup to finish, only one
+	// backup is taken for the runs missed meanwhile
+	ConcurrencyPolicyForbid ConcurrencyPolicy = "Forbid"
+	// ConcurrencyPolicyReplace deletes the running last backup and takes the
+	// scheduled backup instead
+	ConcurrencyPolicyReplace ConcurrencyPolicy = "Replace"
+)
+
 // BackupScheduleStatus represents the current state of a BackupSchedule.
 type BackupScheduleStatus struct {
 	// LastBackup represents the last backup.
 	LastBackup string `json:"lastBackup"`
-	// LastBackupTime represents the last time the backup was successfully created.
+	// LastBackupTime represents the last time the backup was successfully created,
+	// it is moved forward to the missed scheduled time while the backup schedule is paused.
 	LastBackupTime *metav1.Time `json:"lastBackupTime"`
+	// LastIncrementalBackupTime represents the last time the incremental backup was successfully created.
+	LastIncrementalBackupTime *metav1.Time `json:"lastIncrementalBackupTime,omitempty"`
+	// ReclaimedBytes is the total size of the backup data reclaimed from the
+	// backend storage by the garbage collection of the backup schedule, the
+	// size of a pruned backup is counted after its data is cleaned up.
+	ReclaimedBytes int64 `json:"reclaimedBytes,omitempty"`
+	// ReclaimingBackups keeps the size of the backups pruned by the garbage
+	// collection whose data is still being cleaned up, keyed by their names.
+	ReclaimingBackups map[string]int64 `json:"reclaimingBackups,omitempty"`
 }
 
 // +genclient
@@ -464,6 +1232,9 @@ const (
 	RestoreComplete RestoreConditionType = "Complete"
 	// RestoreFailed means the Restore has failed.
 	RestoreFailed RestoreConditionType = "Failed"
+	// RestoreWarmingUp means the restored tables are being warmed up,
+	// its status is set to false with the result when the warm-up is done.
+	RestoreWarmingUp RestoreConditionType = "WarmingUp"
 )
 
 // RestoreCondition describes the observed state of a Restore at a certain point.
@@ -481,7 +1252,9 @@ type RestoreSpec struct {
 	Cluster string `json:"cluster"`
 	// Backup represents the backup object to be restored.
 	Backup string `json:"backup"`
-	// Namespace is the namespace of the backup.
+	// Namespace is the namespace of the backup. The storage and encryption
+	// secrets of the backup are referenced by the restore job, so they must
+	// exist in the namespace of the restore as well.
 	BackupNamespace string `json:"backupNamespace"`
 	// SecretName is the name of the secret which stores
 	// tidb cluster's username and password.
@@ -490,6 +1263,106 @@ type RestoreSpec struct {
 	StorageClassName string `json:"storageClassName"`
 	// StorageSize is the request storage size for restore job
 	StorageSize string `json:"storageSize"`
+	// CreateCluster is the spec of the tidb cluster to be restored, if it is set
+	// and the cluster does not exist, the cluster will be created first and
+	// the restore job will be started after the cluster is ready.
+	CreateCluster *TidbClusterSpec `json:"createCluster,omitempty"`
+	// Lightning is the configs for lightning, the backup is imported by
+	// lightning instead of loader when it is set. The backup must be a
+	// dumpling export.
+	Lightning *LightningConfig `json:"lightning,omitempty"`
+	// LogBackup is the log backup in BackupNamespace whose logs are replayed
+	// on the restored backup, it is required by PitrRestoredTs. The log backup
+	// is read with the credentials of its own storage, the IAM role or GCP
+	// service account of the restore job is the one of the restored backup.
+	LogBackup string `json:"logBackup,omitempty"`
+	// PitrRestoredTs is the ts which the cluster is restored to, the backup
+	// must be taken by br and covered by the logs of LogBackup, and the ts
+	// must be between the commitTs of the backup and the checkpointTs of LogBackup.
+	PitrRestoredTs string `json:"pitrRestoredTs,omitempty"`
+	// Checksum toggles the checksum verification of the data imported by
+	// lightning or restored by BR, defaults to true.
+	Checksum *bool `json:"checksum,omitempty"`
+	// TableFilter is the table filter rules of the data imported by lightning
+	// or restored by BR, e.g. "db1.*", "!db1.tbl1". Defaults to all the tables
+	// of the backup.
+	TableFilter []string `json:"tableFilter,omitempty"`
+	// VolumeSnapshot is the configs for the restore from the volume snapshots,
+	// the TiKV and PD pvcs of the cluster are provisioned from the snapshots of
+	// the volume snapshot backup when it is set. It requires spec.createCluster
+	// and the cluster must not exist.
+	VolumeSnapshot *VolumeSnapshotRestoreConfig `json:"volumeSnapshot,omitempty"`
+	// WarmUp is the configs for warming up the restored tables before the
+	// restore is completed, so that the cluster does not serve the first
+	// queries with cold caches. The tables are not warmed up if it is not set.
+	WarmUp *RestoreWarmUpConfig `json:"warmUp,omitempty"`
+	// ServiceAccount is the service account of the restore job pod,
+	// defaults to tidb-backup-manager, or tidb-backup-manager-volume-snapshot
+	// for the restore from volume snapshots.
+	ServiceAccount string `json:"serviceAccount,omitempty"`
+	// Requests and Limits are the resource requirements of the restore job pod.
+	Requests *ResourceRequirement `json:"requests,omitempty"`
+	Limits   *ResourceRequirement `json:"limits,omitempty"`
+	// Affinity, NodeSelector and Tolerations constrain the nodes which the
+	// restore job pod can be scheduled to.
+	Affinity     *corev1.Affinity    `json:"affinity,omitempty"`
+	NodeSelector map[string]string   `json:"nodeSelector,omitempty"`
+	Tolerations  []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
+// LightningBackend is the backend used by lightning to import data
+type LightningBackend string
+
+const (
+	// LightningBackendLocal sorts the data locally and ingests it into TiKV directly
+	LightningBackendLocal LightningBackend = "local"
+	// LightningBackendTiDB imports the data by executing sql statements on TiDB
+	LightningBackendTiDB LightningBackend = "tidb"
+)
+
+// LightningConfig contains config for lightning
+type LightningConfig struct {
+	// Backend is the import backend of lightning, local or tidb, defaults to tidb
+	Backend LightningBackend `json:"backend,omitempty"`
+}
+
+// RestoreWarmUpStrategy is the way the restored tables are warmed up
+type RestoreWarmUpStrategy string
+
+const (
+	// RestoreWarmUpScan scans every table by `SELECT COUNT(*)`, which loads
+	// the table data into the block cache of TiKV
+	RestoreWarmUpScan RestoreWarmUpStrategy = "scan"
+	// RestoreWarmUpAnalyze runs `ANALYZE TABLE` on every table, which also
+	// collects the statistics used by the optimizer of TiDB
+	RestoreWarmUpAnalyze RestoreWarmUpStrategy = "analyze"
+)
+
+// RestoreWarmUpConfig contains config for warming up the restored tables.
+// The warm-up never fails the restore, the tables which are failed or not
+// warmed up before the timeout are left cold.
+type RestoreWarmUpConfig struct {
+	// Strategy is the way the tables are warmed up, scan or analyze, defaults to scan.
+	Strategy RestoreWarmUpStrategy `json:"strategy,omitempty"`
+	// Databases are the databases whose tables are warmed up, defaults to
+	// all the databases except the system ones.
+	Databases []string `json:"databases,omitempty"`
+	// Concurrency is the number of the tables warmed up at the same time, defaults to 4.
+	Concurrency int `json:"concurrency,omitempty"`
+	// Timeout is the max duration of the warm-up, e.g. 30m, defaults to no limit.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// VolumeSnapshotRestoreConfig contains config for the restore from the volume
+// snapshots. The PD volumes are only restored if they are snapshotted and the
+// restored cluster has the same name and namespace as the backed up one,
+// otherwise PD is recovered with the cluster id of the backup. The data written
+// after the commitTs of the backup is discarded from the restored TiKV stores by
+// tikv-ctl reset-to-version, which requires TiKV v6.1.0 or later.
+type VolumeSnapshotRestoreConfig struct {
+	// CSIDriver is the CSI driver which provisions the volumes from the EBS
+	// snapshots of the aws-ebs provider, defaults to ebs.csi.aws.com.
+	CSIDriver string `json:"csiDriver,omitempty"`
 }
 
 // RestoreStatus represents the current status of a tidb cluster restore.
@@ -497,6 +1370,77 @@ type RestoreStatus struct {
 	// TimeStarted is the time at which the restore was started.
 	TimeStarted metav1.Time `json:"timeStarted"`
 	// TimeCompleted is the time at which the restore was completed.
-	TimeCompleted metav1.Time        `json:"timeCompleted"`
-	Conditions    []RestoreCondition `json:"conditions"`
+	TimeCompleted metav1.Time `json:"timeCompleted"`
+	// RestoredTs is the ts which the cluster is restored to by the point-in-time restore.
+	RestoredTs string `json:"restoredTs,omitempty"`
+	// ChecksumVerified is whether the restored data is verified by checksum, it is
+	// false if BR or lightning skips the checksum of some tables, e.g. the tables
+	// backed up without checksum or imported by the tidb backend of lightning.
+	ChecksumVerified bool `json:"checksumVerified,omitempty"`
+	// WarmUp is the progress of the warm-up of the restored tables.
+	WarmUp     *RestoreWarmUpStatus `json:"warmUp,omitempty"`
+	Conditions []RestoreCondition   `json:"conditions"`
+}
+
+// RestoreWarmUpStatus represents the progress of the warm-up of the restored tables.
+type RestoreWarmUpStatus struct {
+	// TotalTables is the number of the tables to be warmed up.
+	TotalTables int32 `json:"totalTables"`
+	// WarmedUpTables is the number of the tables which have been warmed up.
+	WarmedUpTables int32 `json:"warmedUpTables"`
+	// FailedTables are the tables failed to be warmed up, only the first
+	// few of them are recorded.
+	FailedTables []string `json:"failedTables,omitempty"`
+	// TimeStarted is the time at which the warm-up was started.
+	TimeStarted metav1.Time `json:"timeStarted,omitempty"`
+	// TimeCompleted is the time at which the warm-up was completed.
+	TimeCompleted metav1.Time `json:"timeCompleted,omitempty"`
+	// LastUpdateTime is the last time the progress was updated.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// TidbNGMonitoring runs ng-monitoring for a tidb cluster, which is the backend
+// of the continuous profiling and Top SQL features of TiDB Dashboard.
+type TidbNGMonitoring struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec   TidbNGMonitoringSpec   `json:"spec"`
+	Status TidbNGMonitoringStatus `json:"status"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// TidbNGMonitoringList contains a list of TidbNGMonitoring.
+type TidbNGMonitoringList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []TidbNGMonitoring `json:"items"`
+}
+
+// TidbNGMonitoringSpec describes the attributes that a user creates on ng-monitoring.
+type TidbNGMonitoringSpec struct {
+	// Cluster is the name of the tidb cluster monitored, it must be in the
+	// same namespace as the TidbNGMonitoring.
+	Cluster string `json:"cluster"`
+	ContainerSpec
+	PodAttributesSpec
+	// StorageClassName is the storage class of the PV storing the profiling
+	// and Top SQL data, the size is Requests.Storage.
+	StorageClassName string `json:"storageClassName,omitempty"`
+	// Retention is how long the Top SQL data is kept, it's the
+	// tsdb.retention-period of ng-monitoring, e.g. "1" for 1 month or "7d".
+	Retention string `json:"retention,omitempty"`
+	// Config is the ng-monitoring config in toml, which is appended to the
+	// config generated by the operator, so it should only contain tables.
+	Config string `json:"config,omitempty"`
+}
+
+// TidbNGMonitoringStatus represents the current status of ng-monitoring.
+type TidbNGMonitoringStatus struct {
+	StatefulSet *apps.StatefulSetStatus `json:"statefulSet,omitempty"`
 }
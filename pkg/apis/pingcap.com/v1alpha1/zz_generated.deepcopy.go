@@ -21,11 +21,28 @@ limitations under the License.
 package v1alpha1
 
 import (
-	v1beta1 "k8s.io/api/apps/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BRConfig) DeepCopyInto(out *BRConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BRConfig.
+func (in *BRConfig) DeepCopy() *BRConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(BRConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Backup) DeepCopyInto(out *Backup) {
 	*out = *in
@@ -71,6 +88,22 @@ func (in *BackupCondition) DeepCopy() *BackupCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupEncryption) DeepCopyInto(out *BackupEncryption) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupEncryption.
+func (in *BackupEncryption) DeepCopy() *BackupEncryption {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupEncryption)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupList) DeepCopyInto(out *BackupList) {
 	*out = *in
@@ -104,6 +137,23 @@ func (in *BackupList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupProgress) DeepCopyInto(out *BackupProgress) {
+	*out = *in
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupProgress.
+func (in *BackupProgress) DeepCopy() *BackupProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupSchedule) DeepCopyInto(out *BackupSchedule) {
 	*out = *in
@@ -189,6 +239,17 @@ func (in *BackupScheduleStatus) DeepCopyInto(out *BackupScheduleStatus) {
 		in, out := &in.LastBackupTime, &out.LastBackupTime
 		*out = (*in).DeepCopy()
 	}
+	if in.LastIncrementalBackupTime != nil {
+		in, out := &in.LastIncrementalBackupTime, &out.LastIncrementalBackupTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ReclaimingBackups != nil {
+		in, out := &in.ReclaimingBackups, &out.ReclaimingBackups
+		*out = make(map[string]int64, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -206,6 +267,77 @@ func (in *BackupScheduleStatus) DeepCopy() *BackupScheduleStatus {
 func (in *BackupSpec) DeepCopyInto(out *BackupSpec) {
 	*out = *in
 	in.StorageProvider.DeepCopyInto(&out.StorageProvider)
+	if in.BR != nil {
+		in, out := &in.BR, &out.BR
+		*out = new(BRConfig)
+		**out = **in
+	}
+	if in.Dumpling != nil {
+		in, out := &in.Dumpling, &out.Dumpling
+		*out = new(DumplingConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LogBackup != nil {
+		in, out := &in.LogBackup, &out.LogBackup
+		*out = new(LogBackupConfig)
+		**out = **in
+	}
+	if in.VolumeSnapshot != nil {
+		in, out := &in.VolumeSnapshot, &out.VolumeSnapshot
+		*out = new(VolumeSnapshotConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Checksum != nil {
+		in, out := &in.Checksum, &out.Checksum
+		*out = new(bool)
+		**out = **in
+	}
+	if in.TableFilter != nil {
+		in, out := &in.TableFilter, &out.TableFilter
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(BackupEncryption)
+		**out = **in
+	}
+	if in.SecondaryStorages != nil {
+		in, out := &in.SecondaryStorages, &out.SecondaryStorages
+		*out = make([]SecondaryStorage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Requests != nil {
+		in, out := &in.Requests, &out.Requests
+		*out = new(ResourceRequirement)
+		**out = **in
+	}
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = new(ResourceRequirement)
+		**out = **in
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -224,6 +356,35 @@ func (in *BackupStatus) DeepCopyInto(out *BackupStatus) {
 	*out = *in
 	in.TimeStarted.DeepCopyInto(&out.TimeStarted)
 	in.TimeCompleted.DeepCopyInto(&out.TimeCompleted)
+	if in.LogBackup != nil {
+		in, out := &in.LogBackup, &out.LogBackup
+		*out = new(LogBackupStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VolumeSnapshots != nil {
+		in, out := &in.VolumeSnapshots, &out.VolumeSnapshots
+		*out = make([]VolumeSnapshotStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.PausedScheduleLimits != nil {
+		in, out := &in.PausedScheduleLimits, &out.PausedScheduleLimits
+		*out = make(map[string]uint64, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SecondaryStorages != nil {
+		in, out := &in.SecondaryStorages, &out.SecondaryStorages
+		*out = make([]SecondaryStorageStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Progress != nil {
+		in, out := &in.Progress, &out.Progress
+		*out = new(BackupProgress)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]BackupCondition, len(*in))
@@ -287,255 +448,700 @@ func (in *ContainerSpec) DeepCopy() *ContainerSpec {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PDFailureMember) DeepCopyInto(out *PDFailureMember) {
+func (in *DashboardIngressSpec) DeepCopyInto(out *DashboardIngressSpec) {
 	*out = *in
-	in.CreatedAt.DeepCopyInto(&out.CreatedAt)
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = make([]extensionsv1beta1.IngressTLS, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PDFailureMember.
-func (in *PDFailureMember) DeepCopy() *PDFailureMember {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DashboardIngressSpec.
+func (in *DashboardIngressSpec) DeepCopy() *DashboardIngressSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(PDFailureMember)
+	out := new(DashboardIngressSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PDMember) DeepCopyInto(out *PDMember) {
+func (in *DashboardSpec) DeepCopyInto(out *DashboardSpec) {
 	*out = *in
-	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	in.ContainerSpec.DeepCopyInto(&out.ContainerSpec)
+	in.PodAttributesSpec.DeepCopyInto(&out.PodAttributesSpec)
+	if in.Ingress != nil {
+		in, out := &in.Ingress, &out.Ingress
+		*out = new(DashboardIngressSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PDMember.
-func (in *PDMember) DeepCopy() *PDMember {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DashboardSpec.
+func (in *DashboardSpec) DeepCopy() *DashboardSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(PDMember)
+	out := new(DashboardSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PDSpec) DeepCopyInto(out *PDSpec) {
+func (in *DashboardStatus) DeepCopyInto(out *DashboardStatus) {
 	*out = *in
-	in.ContainerSpec.DeepCopyInto(&out.ContainerSpec)
-	in.PodAttributesSpec.DeepCopyInto(&out.PodAttributesSpec)
+	if in.StatefulSet != nil {
+		in, out := &in.StatefulSet, &out.StatefulSet
+		*out = new(appsv1.StatefulSetStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PDSpec.
-func (in *PDSpec) DeepCopy() *PDSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DashboardStatus.
+func (in *DashboardStatus) DeepCopy() *DashboardStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(PDSpec)
+	out := new(DashboardStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PDStatus) DeepCopyInto(out *PDStatus) {
+func (in *DumplingConfig) DeepCopyInto(out *DumplingConfig) {
 	*out = *in
-	if in.StatefulSet != nil {
-		in, out := &in.StatefulSet, &out.StatefulSet
-		*out = new(v1beta1.StatefulSetStatus)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.Members != nil {
-		in, out := &in.Members, &out.Members
-		*out = make(map[string]PDMember, len(*in))
-		for key, val := range *in {
-			(*out)[key] = *val.DeepCopy()
-		}
+	if in.TableFilter != nil {
+		in, out := &in.TableFilter, &out.TableFilter
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
-	in.Leader.DeepCopyInto(&out.Leader)
-	if in.FailureMembers != nil {
-		in, out := &in.FailureMembers, &out.FailureMembers
-		*out = make(map[string]PDFailureMember, len(*in))
-		for key, val := range *in {
-			(*out)[key] = *val.DeepCopy()
-		}
+	if in.Threads != nil {
+		in, out := &in.Threads, &out.Threads
+		*out = new(int32)
+		**out = **in
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PDStatus.
-func (in *PDStatus) DeepCopy() *PDStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DumplingConfig.
+func (in *DumplingConfig) DeepCopy() *DumplingConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(PDStatus)
+	out := new(DumplingConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PodAttributesSpec) DeepCopyInto(out *PodAttributesSpec) {
+func (in *GCSStorageProvider) DeepCopyInto(out *GCSStorageProvider) {
 	*out = *in
-	if in.Affinity != nil {
-		in, out := &in.Affinity, &out.Affinity
-		*out = new(v1.Affinity)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.NodeSelector != nil {
-		in, out := &in.NodeSelector, &out.NodeSelector
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.Tolerations != nil {
-		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]v1.Toleration, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.Annotations != nil {
-		in, out := &in.Annotations, &out.Annotations
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.PodSecurityContext != nil {
-		in, out := &in.PodSecurityContext, &out.PodSecurityContext
-		*out = new(v1.PodSecurityContext)
-		(*in).DeepCopyInto(*out)
-	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodAttributesSpec.
-func (in *PodAttributesSpec) DeepCopy() *PodAttributesSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCSStorageProvider.
+func (in *GCSStorageProvider) DeepCopy() *GCSStorageProvider {
 	if in == nil {
 		return nil
 	}
-	out := new(PodAttributesSpec)
+	out := new(GCSStorageProvider)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ResourceRequirement) DeepCopyInto(out *ResourceRequirement) {
+func (in *LightningConfig) DeepCopyInto(out *LightningConfig) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceRequirement.
-func (in *ResourceRequirement) DeepCopy() *ResourceRequirement {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LightningConfig.
+func (in *LightningConfig) DeepCopy() *LightningConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(ResourceRequirement)
+	out := new(LightningConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Restore) DeepCopyInto(out *Restore) {
+func (in *LogBackupConfig) DeepCopyInto(out *LogBackupConfig) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Restore.
-func (in *Restore) DeepCopy() *Restore {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogBackupConfig.
+func (in *LogBackupConfig) DeepCopy() *LogBackupConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(Restore)
+	out := new(LogBackupConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *Restore) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RestoreCondition) DeepCopyInto(out *RestoreCondition) {
+func (in *LogBackupStatus) DeepCopyInto(out *LogBackupStatus) {
 	*out = *in
-	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreCondition.
-func (in *RestoreCondition) DeepCopy() *RestoreCondition {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogBackupStatus.
+func (in *LogBackupStatus) DeepCopy() *LogBackupStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(RestoreCondition)
+	out := new(LogBackupStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RestoreList) DeepCopyInto(out *RestoreList) {
+func (in *PDFailureMember) DeepCopyInto(out *PDFailureMember) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	out.ListMeta = in.ListMeta
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]Restore, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
+	in.CreatedAt.DeepCopyInto(&out.CreatedAt)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreList.
-func (in *RestoreList) DeepCopy() *RestoreList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PDFailureMember.
+func (in *PDFailureMember) DeepCopy() *PDFailureMember {
 	if in == nil {
 		return nil
 	}
-	out := new(RestoreList)
+	out := new(PDFailureMember)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *RestoreList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RestoreSpec) DeepCopyInto(out *RestoreSpec) {
+func (in *PDMSMember) DeepCopyInto(out *PDMSMember) {
 	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreSpec.
-func (in *RestoreSpec) DeepCopy() *RestoreSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PDMSMember.
+func (in *PDMSMember) DeepCopy() *PDMSMember {
 	if in == nil {
 		return nil
 	}
-	out := new(RestoreSpec)
+	out := new(PDMSMember)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RestoreStatus) DeepCopyInto(out *RestoreStatus) {
+func (in *PDMSSpec) DeepCopyInto(out *PDMSSpec) {
 	*out = *in
-	in.TimeStarted.DeepCopyInto(&out.TimeStarted)
-	in.TimeCompleted.DeepCopyInto(&out.TimeCompleted)
+	in.ContainerSpec.DeepCopyInto(&out.ContainerSpec)
+	in.PodAttributesSpec.DeepCopyInto(&out.PodAttributesSpec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PDMSSpec.
+func (in *PDMSSpec) DeepCopy() *PDMSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PDMSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PDMSStatus) DeepCopyInto(out *PDMSStatus) {
+	*out = *in
+	if in.StatefulSet != nil {
+		in, out := &in.StatefulSet, &out.StatefulSet
+		*out = new(appsv1.StatefulSetStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make(map[string]PDMSMember, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PDMSStatus.
+func (in *PDMSStatus) DeepCopy() *PDMSStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PDMSStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PDMember) DeepCopyInto(out *PDMember) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PDMember.
+func (in *PDMember) DeepCopy() *PDMember {
+	if in == nil {
+		return nil
+	}
+	out := new(PDMember)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PDSpec) DeepCopyInto(out *PDSpec) {
+	*out = *in
+	in.ContainerSpec.DeepCopyInto(&out.ContainerSpec)
+	in.PodAttributesSpec.DeepCopyInto(&out.PodAttributesSpec)
+	if in.TSO != nil {
+		in, out := &in.TSO, &out.TSO
+		*out = new(PDMSSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Scheduling != nil {
+		in, out := &in.Scheduling, &out.Scheduling
+		*out = new(PDMSSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaxReplicas != nil {
+		in, out := &in.MaxReplicas, &out.MaxReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PlacementRules != nil {
+		in, out := &in.PlacementRules, &out.PlacementRules
+		*out = make([]PlacementRuleGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PDSpec.
+func (in *PDSpec) DeepCopy() *PDSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PDSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PDStatus) DeepCopyInto(out *PDStatus) {
+	*out = *in
+	if in.StatefulSet != nil {
+		in, out := &in.StatefulSet, &out.StatefulSet
+		*out = new(appsv1.StatefulSetStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make(map[string]PDMember, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	in.Leader.DeepCopyInto(&out.Leader)
+	if in.FailureMembers != nil {
+		in, out := &in.FailureMembers, &out.FailureMembers
+		*out = make(map[string]PDFailureMember, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	in.TSO.DeepCopyInto(&out.TSO)
+	in.Scheduling.DeepCopyInto(&out.Scheduling)
+	if in.PlacementRuleGroups != nil {
+		in, out := &in.PlacementRuleGroups, &out.PlacementRuleGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(UpgradeProgress)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PDStatus.
+func (in *PDStatus) DeepCopy() *PDStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PDStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementLabelConstraint) DeepCopyInto(out *PlacementLabelConstraint) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementLabelConstraint.
+func (in *PlacementLabelConstraint) DeepCopy() *PlacementLabelConstraint {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementLabelConstraint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementRule) DeepCopyInto(out *PlacementRule) {
+	*out = *in
+	if in.LabelConstraints != nil {
+		in, out := &in.LabelConstraints, &out.LabelConstraints
+		*out = make([]PlacementLabelConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LocationLabels != nil {
+		in, out := &in.LocationLabels, &out.LocationLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementRule.
+func (in *PlacementRule) DeepCopy() *PlacementRule {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementRuleGroup) DeepCopyInto(out *PlacementRuleGroup) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]PlacementRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementRuleGroup.
+func (in *PlacementRuleGroup) DeepCopy() *PlacementRuleGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementRuleGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodAttributesSpec) DeepCopyInto(out *PodAttributesSpec) {
+	*out = *in
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PodSecurityContext != nil {
+		in, out := &in.PodSecurityContext, &out.PodSecurityContext
+		*out = new(v1.PodSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodAttributesSpec.
+func (in *PodAttributesSpec) DeepCopy() *PodAttributesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PodAttributesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoverySpec) DeepCopyInto(out *RecoverySpec) {
+	*out = *in
+	if in.FailedStores != nil {
+		in, out := &in.FailedStores, &out.FailedStores
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoverySpec.
+func (in *RecoverySpec) DeepCopy() *RecoverySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoverySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryStatus) DeepCopyInto(out *RecoveryStatus) {
+	*out = *in
+	if in.FailedStores != nil {
+		in, out := &in.FailedStores, &out.FailedStores
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryStatus.
+func (in *RecoveryStatus) DeepCopy() *RecoveryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceRequirement) DeepCopyInto(out *ResourceRequirement) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceRequirement.
+func (in *ResourceRequirement) DeepCopy() *ResourceRequirement {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceRequirement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Restore) DeepCopyInto(out *Restore) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Restore.
+func (in *Restore) DeepCopy() *Restore {
+	if in == nil {
+		return nil
+	}
+	out := new(Restore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Restore) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreCondition) DeepCopyInto(out *RestoreCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreCondition.
+func (in *RestoreCondition) DeepCopy() *RestoreCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreList) DeepCopyInto(out *RestoreList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Restore, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreList.
+func (in *RestoreList) DeepCopy() *RestoreList {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RestoreList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreSpec) DeepCopyInto(out *RestoreSpec) {
+	*out = *in
+	if in.CreateCluster != nil {
+		in, out := &in.CreateCluster, &out.CreateCluster
+		*out = new(TidbClusterSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Lightning != nil {
+		in, out := &in.Lightning, &out.Lightning
+		*out = new(LightningConfig)
+		**out = **in
+	}
+	if in.Checksum != nil {
+		in, out := &in.Checksum, &out.Checksum
+		*out = new(bool)
+		**out = **in
+	}
+	if in.TableFilter != nil {
+		in, out := &in.TableFilter, &out.TableFilter
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.VolumeSnapshot != nil {
+		in, out := &in.VolumeSnapshot, &out.VolumeSnapshot
+		*out = new(VolumeSnapshotRestoreConfig)
+		**out = **in
+	}
+	if in.WarmUp != nil {
+		in, out := &in.WarmUp, &out.WarmUp
+		*out = new(RestoreWarmUpConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Requests != nil {
+		in, out := &in.Requests, &out.Requests
+		*out = new(ResourceRequirement)
+		**out = **in
+	}
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = new(ResourceRequirement)
+		**out = **in
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreSpec.
+func (in *RestoreSpec) DeepCopy() *RestoreSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreStatus) DeepCopyInto(out *RestoreStatus) {
+	*out = *in
+	in.TimeStarted.DeepCopyInto(&out.TimeStarted)
+	in.TimeCompleted.DeepCopyInto(&out.TimeCompleted)
+	if in.WarmUp != nil {
+		in, out := &in.WarmUp, &out.WarmUp
+		*out = new(RestoreWarmUpStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]RestoreCondition, len(*in))
@@ -556,6 +1162,101 @@ func (in *RestoreStatus) DeepCopy() *RestoreStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreWarmUpConfig) DeepCopyInto(out *RestoreWarmUpConfig) {
+	*out = *in
+	if in.Databases != nil {
+		in, out := &in.Databases, &out.Databases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreWarmUpConfig.
+func (in *RestoreWarmUpConfig) DeepCopy() *RestoreWarmUpConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreWarmUpConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreWarmUpStatus) DeepCopyInto(out *RestoreWarmUpStatus) {
+	*out = *in
+	if in.FailedTables != nil {
+		in, out := &in.FailedTables, &out.FailedTables
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.TimeStarted.DeepCopyInto(&out.TimeStarted)
+	in.TimeCompleted.DeepCopyInto(&out.TimeCompleted)
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreWarmUpStatus.
+func (in *RestoreWarmUpStatus) DeepCopy() *RestoreWarmUpStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreWarmUpStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3StorageProvider) DeepCopyInto(out *S3StorageProvider) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3StorageProvider.
+func (in *S3StorageProvider) DeepCopy() *S3StorageProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(S3StorageProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecondaryStorage) DeepCopyInto(out *SecondaryStorage) {
+	*out = *in
+	in.StorageProvider.DeepCopyInto(&out.StorageProvider)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecondaryStorage.
+func (in *SecondaryStorage) DeepCopy() *SecondaryStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(SecondaryStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecondaryStorageStatus) DeepCopyInto(out *SecondaryStorageStatus) {
+	*out = *in
+	in.TimeCompleted.DeepCopyInto(&out.TimeCompleted)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecondaryStorageStatus.
+func (in *SecondaryStorageStatus) DeepCopy() *SecondaryStorageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SecondaryStorageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Service) DeepCopyInto(out *Service) {
 	*out = *in
@@ -572,6 +1273,32 @@ func (in *Service) DeepCopy() *Service {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceMeshSpec) DeepCopyInto(out *ServiceMeshSpec) {
+	*out = *in
+	if in.HoldApplicationUntilProxyStarts != nil {
+		in, out := &in.HoldApplicationUntilProxyStarts, &out.HoldApplicationUntilProxyStarts
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ExcludePeerPorts != nil {
+		in, out := &in.ExcludePeerPorts, &out.ExcludePeerPorts
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceMeshSpec.
+func (in *ServiceMeshSpec) DeepCopy() *ServiceMeshSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceMeshSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageProvider) DeepCopyInto(out *StorageProvider) {
 	*out = *in
@@ -580,6 +1307,16 @@ func (in *StorageProvider) DeepCopyInto(out *StorageProvider) {
 		*out = new(CephStorageProvider)
 		**out = **in
 	}
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(S3StorageProvider)
+		**out = **in
+	}
+	if in.GCS != nil {
+		in, out := &in.GCS, &out.GCS
+		*out = new(GCSStorageProvider)
+		**out = **in
+	}
 	return
 }
 
@@ -627,6 +1364,22 @@ func (in *TiDBMember) DeepCopy() *TiDBMember {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiDBServiceSpec) DeepCopyInto(out *TiDBServiceSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiDBServiceSpec.
+func (in *TiDBServiceSpec) DeepCopy() *TiDBServiceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TiDBServiceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TiDBSlowLogTailerSpec) DeepCopyInto(out *TiDBSlowLogTailerSpec) {
 	*out = *in
@@ -650,6 +1403,11 @@ func (in *TiDBSpec) DeepCopyInto(out *TiDBSpec) {
 	in.ContainerSpec.DeepCopyInto(&out.ContainerSpec)
 	in.PodAttributesSpec.DeepCopyInto(&out.PodAttributesSpec)
 	in.SlowLogTailer.DeepCopyInto(&out.SlowLogTailer)
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(TiDBServiceSpec)
+		**out = **in
+	}
 	return
 }
 
@@ -668,7 +1426,7 @@ func (in *TiDBStatus) DeepCopyInto(out *TiDBStatus) {
 	*out = *in
 	if in.StatefulSet != nil {
 		in, out := &in.StatefulSet, &out.StatefulSet
-		*out = new(v1beta1.StatefulSetStatus)
+		*out = new(appsv1.StatefulSetStatus)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.Members != nil {
@@ -685,32 +1443,69 @@ func (in *TiDBStatus) DeepCopyInto(out *TiDBStatus) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(UpgradeProgress)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiDBStatus.
+func (in *TiDBStatus) DeepCopy() *TiDBStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TiDBStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiKVEvictLeaderSpec) DeepCopyInto(out *TiKVEvictLeaderSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiKVEvictLeaderSpec.
+func (in *TiKVEvictLeaderSpec) DeepCopy() *TiKVEvictLeaderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TiKVEvictLeaderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiKVFailureStore) DeepCopyInto(out *TiKVFailureStore) {
+	*out = *in
+	in.CreatedAt.DeepCopyInto(&out.CreatedAt)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiDBStatus.
-func (in *TiDBStatus) DeepCopy() *TiDBStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiKVFailureStore.
+func (in *TiKVFailureStore) DeepCopy() *TiKVFailureStore {
 	if in == nil {
 		return nil
 	}
-	out := new(TiDBStatus)
+	out := new(TiKVFailureStore)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *TiKVFailureStore) DeepCopyInto(out *TiKVFailureStore) {
+func (in *TiKVLivenessProbe) DeepCopyInto(out *TiKVLivenessProbe) {
 	*out = *in
-	in.CreatedAt.DeepCopyInto(&out.CreatedAt)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiKVFailureStore.
-func (in *TiKVFailureStore) DeepCopy() *TiKVFailureStore {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiKVLivenessProbe.
+func (in *TiKVLivenessProbe) DeepCopy() *TiKVLivenessProbe {
 	if in == nil {
 		return nil
 	}
-	out := new(TiKVFailureStore)
+	out := new(TiKVLivenessProbe)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -737,6 +1532,16 @@ func (in *TiKVSpec) DeepCopyInto(out *TiKVSpec) {
 	*out = *in
 	in.ContainerSpec.DeepCopyInto(&out.ContainerSpec)
 	in.PodAttributesSpec.DeepCopyInto(&out.PodAttributesSpec)
+	if in.EvictLeader != nil {
+		in, out := &in.EvictLeader, &out.EvictLeader
+		*out = new(TiKVEvictLeaderSpec)
+		**out = **in
+	}
+	if in.LivenessProbe != nil {
+		in, out := &in.LivenessProbe, &out.LivenessProbe
+		*out = new(TiKVLivenessProbe)
+		**out = **in
+	}
 	return
 }
 
@@ -755,7 +1560,7 @@ func (in *TiKVStatus) DeepCopyInto(out *TiKVStatus) {
 	*out = *in
 	if in.StatefulSet != nil {
 		in, out := &in.StatefulSet, &out.StatefulSet
-		*out = new(v1beta1.StatefulSetStatus)
+		*out = new(appsv1.StatefulSetStatus)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.Stores != nil {
@@ -779,6 +1584,11 @@ func (in *TiKVStatus) DeepCopyInto(out *TiKVStatus) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(UpgradeProgress)
+		**out = **in
+	}
 	return
 }
 
@@ -810,6 +1620,74 @@ func (in *TiKVStore) DeepCopy() *TiKVStore {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiProxyMember) DeepCopyInto(out *TiProxyMember) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiProxyMember.
+func (in *TiProxyMember) DeepCopy() *TiProxyMember {
+	if in == nil {
+		return nil
+	}
+	out := new(TiProxyMember)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiProxySpec) DeepCopyInto(out *TiProxySpec) {
+	*out = *in
+	in.ContainerSpec.DeepCopyInto(&out.ContainerSpec)
+	in.PodAttributesSpec.DeepCopyInto(&out.PodAttributesSpec)
+	if in.TiDBGracefulWaitSeconds != nil {
+		in, out := &in.TiDBGracefulWaitSeconds, &out.TiDBGracefulWaitSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiProxySpec.
+func (in *TiProxySpec) DeepCopy() *TiProxySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TiProxySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiProxyStatus) DeepCopyInto(out *TiProxyStatus) {
+	*out = *in
+	if in.StatefulSet != nil {
+		in, out := &in.StatefulSet, &out.StatefulSet
+		*out = new(appsv1.StatefulSetStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make(map[string]TiProxyMember, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiProxyStatus.
+func (in *TiProxyStatus) DeepCopy() *TiProxyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TiProxyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TidbCluster) DeepCopyInto(out *TidbCluster) {
 	*out = *in
@@ -838,6 +1716,23 @@ func (in *TidbCluster) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TidbClusterCondition) DeepCopyInto(out *TidbClusterCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TidbClusterCondition.
+func (in *TidbClusterCondition) DeepCopy() *TidbClusterCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(TidbClusterCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TidbClusterList) DeepCopyInto(out *TidbClusterList) {
 	*out = *in
@@ -871,6 +1766,22 @@ func (in *TidbClusterList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TidbClusterRef) DeepCopyInto(out *TidbClusterRef) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TidbClusterRef.
+func (in *TidbClusterRef) DeepCopy() *TidbClusterRef {
+	if in == nil {
+		return nil
+	}
+	out := new(TidbClusterRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TidbClusterSpec) DeepCopyInto(out *TidbClusterSpec) {
 	*out = *in
@@ -883,6 +1794,36 @@ func (in *TidbClusterSpec) DeepCopyInto(out *TidbClusterSpec) {
 		*out = make([]Service, len(*in))
 		copy(*out, *in)
 	}
+	if in.TiProxy != nil {
+		in, out := &in.TiProxy, &out.TiProxy
+		*out = new(TiProxySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Dashboard != nil {
+		in, out := &in.Dashboard, &out.Dashboard
+		*out = new(DashboardSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RecoveryMode != nil {
+		in, out := &in.RecoveryMode, &out.RecoveryMode
+		*out = new(RecoverySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]TopologySpreadConstraint, len(*in))
+		copy(*out, *in)
+	}
+	if in.Cluster != nil {
+		in, out := &in.Cluster, &out.Cluster
+		*out = new(TidbClusterRef)
+		**out = **in
+	}
+	if in.ServiceMesh != nil {
+		in, out := &in.ServiceMesh, &out.ServiceMesh
+		*out = new(ServiceMeshSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -902,6 +1843,16 @@ func (in *TidbClusterStatus) DeepCopyInto(out *TidbClusterStatus) {
 	in.PD.DeepCopyInto(&out.PD)
 	in.TiKV.DeepCopyInto(&out.TiKV)
 	in.TiDB.DeepCopyInto(&out.TiDB)
+	in.TiProxy.DeepCopyInto(&out.TiProxy)
+	in.Dashboard.DeepCopyInto(&out.Dashboard)
+	in.Recovery.DeepCopyInto(&out.Recovery)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]TidbClusterCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -914,3 +1865,190 @@ func (in *TidbClusterStatus) DeepCopy() *TidbClusterStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TidbNGMonitoring) DeepCopyInto(out *TidbNGMonitoring) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TidbNGMonitoring.
+func (in *TidbNGMonitoring) DeepCopy() *TidbNGMonitoring {
+	if in == nil {
+		return nil
+	}
+	out := new(TidbNGMonitoring)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TidbNGMonitoring) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TidbNGMonitoringList) DeepCopyInto(out *TidbNGMonitoringList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]TidbNGMonitoring, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TidbNGMonitoringList.
+func (in *TidbNGMonitoringList) DeepCopy() *TidbNGMonitoringList {
+	if in == nil {
+		return nil
+	}
+	out := new(TidbNGMonitoringList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TidbNGMonitoringList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TidbNGMonitoringSpec) DeepCopyInto(out *TidbNGMonitoringSpec) {
+	*out = *in
+	in.ContainerSpec.DeepCopyInto(&out.ContainerSpec)
+	in.PodAttributesSpec.DeepCopyInto(&out.PodAttributesSpec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TidbNGMonitoringSpec.
+func (in *TidbNGMonitoringSpec) DeepCopy() *TidbNGMonitoringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TidbNGMonitoringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TidbNGMonitoringStatus) DeepCopyInto(out *TidbNGMonitoringStatus) {
+	*out = *in
+	if in.StatefulSet != nil {
+		in, out := &in.StatefulSet, &out.StatefulSet
+		*out = new(appsv1.StatefulSetStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TidbNGMonitoringStatus.
+func (in *TidbNGMonitoringStatus) DeepCopy() *TidbNGMonitoringStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TidbNGMonitoringStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopologySpreadConstraint) DeepCopyInto(out *TopologySpreadConstraint) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopologySpreadConstraint.
+func (in *TopologySpreadConstraint) DeepCopy() *TopologySpreadConstraint {
+	if in == nil {
+		return nil
+	}
+	out := new(TopologySpreadConstraint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeProgress) DeepCopyInto(out *UpgradeProgress) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeProgress.
+func (in *UpgradeProgress) DeepCopy() *UpgradeProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotConfig) DeepCopyInto(out *VolumeSnapshotConfig) {
+	*out = *in
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotConfig.
+func (in *VolumeSnapshotConfig) DeepCopy() *VolumeSnapshotConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotRestoreConfig) DeepCopyInto(out *VolumeSnapshotRestoreConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotRestoreConfig.
+func (in *VolumeSnapshotRestoreConfig) DeepCopy() *VolumeSnapshotRestoreConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotRestoreConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotStatus) DeepCopyInto(out *VolumeSnapshotStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotStatus.
+func (in *VolumeSnapshotStatus) DeepCopy() *VolumeSnapshotStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -16,14 +16,15 @@ package backup
 import (
 	"fmt"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/backup/constants"
 	backuputil "github.com/pingcap/tidb-operator/pkg/backup/util"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	batchlisters "k8s.io/client-go/listers/batch/v1"
 	corelisters "k8s.io/client-go/listers/core/v1"
@@ -39,6 +40,8 @@ type backupCleaner struct {
 	secretLister  corelisters.SecretLister
 	jobLister     batchlisters.JobLister
 	jobControl    controller.JobControlInterface
+	pvcLister     corelisters.PersistentVolumeClaimLister
+	pvcControl    controller.GeneralPVCControlInterface
 }
 
 // NewBackupCleaner returns a BackupCleaner
@@ -46,12 +49,16 @@ func NewBackupCleaner(
 	statusUpdater controller.BackupConditionUpdaterInterface,
 	secretLister corelisters.SecretLister,
 	jobLister batchlisters.JobLister,
-	jobControl controller.JobControlInterface) BackupCleaner {
+	jobControl controller.JobControlInterface,
+	pvcLister corelisters.PersistentVolumeClaimLister,
+	pvcControl controller.GeneralPVCControlInterface) BackupCleaner {
 	return &backupCleaner{
 		statusUpdater,
 		secretLister,
 		jobLister,
 		jobControl,
+		pvcLister,
+		pvcControl,
 	}
 }
 
@@ -63,7 +70,7 @@ func (bc *backupCleaner) Clean(backup *v1alpha1.Backup) error {
 	ns := backup.GetNamespace()
 	name := backup.GetName()
 
-	glog.Infof("start to clean backup %s/%s", ns, name)
+	log.Infof("start to clean backup %s/%s", ns, name)
 
 	cleanJobName := backup.GetCleanJobName()
 	_, err := bc.jobLister.Jobs(ns).Get(cleanJobName)
@@ -72,8 +79,13 @@ func (bc *backupCleaner) Clean(backup *v1alpha1.Backup) error {
 		return nil
 	}
 
-	if backup.Status.BackupPath == "" {
-		// the backup path is empty, so there is no need to clean up backup data
+	if backup.Spec.StorageType == v1alpha1.BackupStorageTypePVC && v1alpha1.NeedToCleanData(backup) {
+		return bc.cleanBackupPVC(backup)
+	}
+
+	if (backup.Status.BackupPath == "" && len(backup.Status.VolumeSnapshots) == 0) || !v1alpha1.NeedToCleanData(backup) {
+		// the backup path and volume snapshots are empty or the clean policy retains the backup data,
+		// so there is no need to clean up backup data
 		return bc.statusUpdater.Update(backup, &v1alpha1.BackupCondition{
 			Type:   v1alpha1.BackupClean,
 			Status: corev1.ConditionTrue,
@@ -108,14 +120,54 @@ func (bc *backupCleaner) Clean(backup *v1alpha1.Backup) error {
 	})
 }
 
+// cleanBackupPVC deletes the pvc which keeps the backup data of a backup with
+// pvc storage, no clean job is required
+func (bc *backupCleaner) cleanBackupPVC(backup *v1alpha1.Backup) error {
+	ns := backup.GetNamespace()
+	name := backup.GetName()
+
+	pvcName := backup.GetBackupPVCName()
+	pvc, err := bc.pvcLister.PersistentVolumeClaims(ns).Get(pvcName)
+	if err == nil {
+		if err := bc.pvcControl.DeletePVC(backup, pvc); err != nil {
+			errMsg := fmt.Errorf("delete backup %s/%s pvc %s failed, err: %v", ns, name, pvcName, err)
+			bc.statusUpdater.Update(backup, &v1alpha1.BackupCondition{
+				Type:    v1alpha1.BackupFailed,
+				Status:  corev1.ConditionTrue,
+				Reason:  "DeleteBackupPVCFailed",
+				Message: errMsg.Error(),
+			})
+			return errMsg
+		}
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("backup %s/%s get pvc %s failed, err: %v", ns, name, pvcName, err)
+	}
+
+	return bc.statusUpdater.Update(backup, &v1alpha1.BackupCondition{
+		Type:   v1alpha1.BackupClean,
+		Status: corev1.ConditionTrue,
+	})
+}
+
 func (bc *backupCleaner) makeCleanJob(backup *v1alpha1.Backup) (*batchv1.Job, string, error) {
 	ns := backup.GetNamespace()
 	name := backup.GetName()
 
-	storageEnv, reason, err := backuputil.GenerateStorageCertEnv(backup, bc.secretLister)
+	storageEnv, reason, err := backuputil.GenerateStorageCertEnv(backup, ns, bc.secretLister)
 	if err != nil {
 		return nil, reason, err
 	}
+	if len(backup.Status.SecondaryStorages) > 0 {
+		// the data replicated to the secondary storages is cleaned as well
+		for i := range backup.Spec.SecondaryStorages {
+			secondaryEnv, reason, err := backuputil.GenerateSecondaryStorageEnv(backup, i, bc.secretLister)
+			if err != nil {
+				return nil, reason, err
+			}
+			storageEnv = append(storageEnv, secondaryEnv...)
+		}
+	}
+	caVolumes, caVolumeMounts := backuputil.GenerateStorageCAVolume(backup)
 
 	args := []string{
 		"clean",
@@ -128,10 +180,11 @@ func (bc *backupCleaner) makeCleanJob(backup *v1alpha1.Backup) (*batchv1.Job, st
 
 	podSpec := &corev1.PodTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
-			Labels: backupLabel.Labels(),
+			Labels:      backupLabel.Labels(),
+			Annotations: backuputil.GenerateStoragePodAnnotations(backup),
 		},
 		Spec: corev1.PodSpec{
-			ServiceAccountName: constants.DefaultServiceAccountName,
+			ServiceAccountName: backuputil.GetServiceAccountName(backup.Spec.ServiceAccount, backup.Spec.VolumeSnapshot != nil),
 			Containers: []corev1.Container{
 				{
 					Name:            label.BackupJobLabelVal,
@@ -139,9 +192,14 @@ func (bc *backupCleaner) makeCleanJob(backup *v1alpha1.Backup) (*batchv1.Job, st
 					Args:            args,
 					ImagePullPolicy: corev1.PullAlways,
 					Env:             storageEnv,
+					VolumeMounts:    caVolumeMounts,
 				},
 			},
 			RestartPolicy: corev1.RestartPolicyNever,
+			Affinity:      backup.Spec.Affinity,
+			NodeSelector:  backup.Spec.NodeSelector,
+			Tolerations:   backup.Spec.Tolerations,
+			Volumes:       caVolumes,
 		},
 	}
 
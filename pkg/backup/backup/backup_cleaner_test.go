@@ -0,0 +1,139 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+// conditionRecorder records the conditions updated by the backup cleaner
+type conditionRecorder struct {
+	conditions []v1alpha1.BackupCondition
+}
+
+func (cr *conditionRecorder) Update(_ *v1alpha1.Backup, condition *v1alpha1.BackupCondition) error {
+	cr.conditions = append(cr.conditions, *condition)
+	return nil
+}
+
+func TestCleanBackupPVC(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name            string
+		cleanPolicy     v1alpha1.CleanPolicyType
+		pvcExist        bool
+		deletePVCErr    bool
+		expectErr       bool
+		expectPVCExist  bool
+		expectCondition v1alpha1.BackupCondition
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		backup := &v1alpha1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "backup",
+				Namespace:         "ns",
+				DeletionTimestamp: &metav1.Time{},
+			},
+			Spec: v1alpha1.BackupSpec{
+				StorageType: v1alpha1.BackupStorageTypePVC,
+				CleanPolicy: test.cleanPolicy,
+			},
+			Status: v1alpha1.BackupStatus{BackupPath: "/backup/backup"},
+		}
+		kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), 0)
+		jobInformer := kubeInformerFactory.Batch().V1().Jobs()
+		pvcInformer := kubeInformerFactory.Core().V1().PersistentVolumeClaims()
+		pvcControl := controller.NewFakeGeneralPVCControl(pvcInformer)
+		recorder := &conditionRecorder{}
+		bc := NewBackupCleaner(
+			recorder,
+			kubeInformerFactory.Core().V1().Secrets().Lister(),
+			jobInformer.Lister(),
+			controller.NewFakeJobControl(jobInformer),
+			pvcInformer.Lister(),
+			pvcControl,
+		)
+		if test.pvcExist {
+			g.Expect(pvcInformer.Informer().GetIndexer().Add(&corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: backup.GetBackupPVCName(), Namespace: "ns"},
+			})).To(Succeed())
+		}
+		if test.deletePVCErr {
+			pvcControl.SetDeletePVCError(errors.New("delete pvc failed"), 0)
+		}
+
+		err := bc.Clean(backup)
+		if test.expectErr {
+			g.Expect(err).To(HaveOccurred())
+		} else {
+			g.Expect(err).NotTo(HaveOccurred())
+		}
+		_, err = pvcInformer.Lister().PersistentVolumeClaims("ns").Get(backup.GetBackupPVCName())
+		g.Expect(err == nil).To(Equal(test.expectPVCExist))
+		g.Expect(recorder.conditions).To(HaveLen(1))
+		g.Expect(recorder.conditions[0].Type).To(Equal(test.expectCondition.Type))
+		g.Expect(recorder.conditions[0].Status).To(Equal(test.expectCondition.Status))
+		g.Expect(recorder.conditions[0].Reason).To(Equal(test.expectCondition.Reason))
+	}
+
+	tests := []testcase{
+		{
+			name:            "delete the backup pvc",
+			cleanPolicy:     v1alpha1.CleanPolicyTypeDelete,
+			pvcExist:        true,
+			expectCondition: v1alpha1.BackupCondition{Type: v1alpha1.BackupClean, Status: corev1.ConditionTrue},
+		},
+		{
+			name:            "backup pvc is already deleted",
+			cleanPolicy:     v1alpha1.CleanPolicyTypeDelete,
+			expectCondition: v1alpha1.BackupCondition{Type: v1alpha1.BackupClean, Status: corev1.ConditionTrue},
+		},
+		{
+			name:           "delete backup pvc failed",
+			cleanPolicy:    v1alpha1.CleanPolicyTypeDelete,
+			pvcExist:       true,
+			deletePVCErr:   true,
+			expectErr:      true,
+			expectPVCExist: true,
+			expectCondition: v1alpha1.BackupCondition{
+				Type:   v1alpha1.BackupFailed,
+				Status: corev1.ConditionTrue,
+				Reason: "DeleteBackupPVCFailed",
+			},
+		},
+		{
+			name:            "backup pvc is retained",
+			cleanPolicy:     v1alpha1.CleanPolicyTypeRetain,
+			pvcExist:        true,
+			expectPVCExist:  true,
+			expectCondition: v1alpha1.BackupCondition{Type: v1alpha1.BackupClean, Status: corev1.ConditionTrue},
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
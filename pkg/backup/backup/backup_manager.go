@@ -14,35 +14,51 @@
 package backup
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/backup"
 	"github.com/pingcap/tidb-operator/pkg/backup/constants"
 	backuputil "github.com/pingcap/tidb-operator/pkg/backup/util"
+	listers "github.com/pingcap/tidb-operator/pkg/client/listers/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/util"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	batchlisters "k8s.io/client-go/listers/batch/v1"
 	corelisters "k8s.io/client-go/listers/core/v1"
+	rbaclisters "k8s.io/client-go/listers/rbac/v1"
 )
 
 type backupManager struct {
-	backupCleaner BackupCleaner
-	statusUpdater controller.BackupConditionUpdaterInterface
-	secretLister  corelisters.SecretLister
-	jobLister     batchlisters.JobLister
-	jobControl    controller.JobControlInterface
-	pvcLister     corelisters.PersistentVolumeClaimLister
-	pvcControl    controller.GeneralPVCControlInterface
+	backupLister      listers.BackupLister
+	backupCleaner     BackupCleaner
+	statusUpdater     controller.BackupConditionUpdaterInterface
+	secretLister      corelisters.SecretLister
+	jobLister         batchlisters.JobLister
+	jobControl        controller.JobControlInterface
+	pvcLister         corelisters.PersistentVolumeClaimLister
+	pvcControl        controller.GeneralPVCControlInterface
+	pvLister          corelisters.PersistentVolumeLister
+	saLister          corelisters.ServiceAccountLister
+	saControl         controller.ServiceAccountControlInterface
+	roleLister        rbaclisters.RoleLister
+	roleBindingLister rbaclisters.RoleBindingLister
+	rbacControl       controller.RBACControlInterface
 }
 
 // NewBackupManager return backupManager
 func NewBackupManager(
+	backupLister listers.BackupLister,
 	backupCleaner BackupCleaner,
 	statusUpdater controller.BackupConditionUpdaterInterface,
 	secretLister corelisters.SecretLister,
@@ -50,8 +66,15 @@ func NewBackupManager(
 	jobControl controller.JobControlInterface,
 	pvcLister corelisters.PersistentVolumeClaimLister,
 	pvcControl controller.GeneralPVCControlInterface,
+	pvLister corelisters.PersistentVolumeLister,
+	saLister corelisters.ServiceAccountLister,
+	saControl controller.ServiceAccountControlInterface,
+	roleLister rbaclisters.RoleLister,
+	roleBindingLister rbaclisters.RoleBindingLister,
+	rbacControl controller.RBACControlInterface,
 ) backup.BackupManager {
 	return &backupManager{
+		backupLister,
 		backupCleaner,
 		statusUpdater,
 		secretLister,
@@ -59,6 +82,12 @@ func NewBackupManager(
 		jobControl,
 		pvcLister,
 		pvcControl,
+		pvLister,
+		saLister,
+		saControl,
+		roleLister,
+		roleBindingLister,
+		rbacControl,
 	}
 }
 
@@ -72,6 +101,10 @@ func (bm *backupManager) Sync(backup *v1alpha1.Backup) error {
 		return nil
 	}
 
+	if v1alpha1.NeedToReplicate(backup) {
+		return bm.syncReplicateJob(backup)
+	}
+
 	return bm.syncBackupJob(backup)
 }
 
@@ -80,10 +113,18 @@ func (bm *backupManager) syncBackupJob(backup *v1alpha1.Backup) error {
 	name := backup.GetName()
 	backupJobName := backup.GetBackupJobName()
 
-	_, err := bm.jobLister.Jobs(ns).Get(backupJobName)
+	job, err := bm.jobLister.Jobs(ns).Get(backupJobName)
 	if err == nil {
-		// already have a backup job running，return directly
-		return nil
+		if backup.Spec.LogBackup == nil || (!isLogBackupJobOutdated(backup, job) && !isLogBackupStatusOutdated(backup, job)) {
+			// already have a backup job running，return directly
+			return nil
+		}
+		// the command of the log backup task is changed, or its status is to be
+		// refreshed, replace the job of the former command
+		if err := bm.jobControl.DeleteJob(backup, job); err != nil {
+			return fmt.Errorf("backup %s/%s delete job %s of the former log backup command failed, err: %v", ns, name, backupJobName, err)
+		}
+		return controller.RequeueErrorf("backup %s/%s wait for job %s of the former log backup command deleted", ns, name, backupJobName)
 	}
 
 	if !errors.IsNotFound(err) {
@@ -91,7 +132,95 @@ func (bm *backupManager) syncBackupJob(backup *v1alpha1.Backup) error {
 	}
 
 	// not found backup job, so we need to create it
-	job, reason, err := bm.makeBackupJob(backup)
+	reason, err := bm.validateStorageType(backup)
+	if err != nil {
+		bm.statusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  reason,
+			Message: err.Error(),
+		})
+		return err
+	}
+
+	reason, err = bm.validateDumplingConfig(backup)
+	if err != nil {
+		bm.statusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  reason,
+			Message: err.Error(),
+		})
+		return err
+	}
+
+	reason, err = bm.validateLogBackupConfig(backup)
+	if err != nil {
+		bm.statusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  reason,
+			Message: err.Error(),
+		})
+		return err
+	}
+
+	reason, err = bm.validateVolumeSnapshotConfig(backup)
+	if err != nil {
+		bm.statusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  reason,
+			Message: err.Error(),
+		})
+		return err
+	}
+
+	reason, err = bm.validateChecksum(backup)
+	if err != nil {
+		bm.statusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  reason,
+			Message: err.Error(),
+		})
+		return err
+	}
+
+	reason, err = bm.validateTableFilter(backup)
+	if err != nil {
+		bm.statusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  reason,
+			Message: err.Error(),
+		})
+		return err
+	}
+
+	reason, err = bm.validateBaseBackup(backup)
+	if err != nil {
+		bm.statusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  reason,
+			Message: err.Error(),
+		})
+		return err
+	}
+
+	reason, err = bm.validateSecondaryStorages(backup)
+	if err != nil {
+		bm.statusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  reason,
+			Message: err.Error(),
+		})
+		return err
+	}
+
+	job, reason, err = bm.makeBackupJob(backup)
 	if err != nil {
 		bm.statusUpdater.Update(backup, &v1alpha1.BackupCondition{
 			Type:    v1alpha1.BackupFailed,
@@ -130,19 +259,415 @@ func (bm *backupManager) syncBackupJob(backup *v1alpha1.Backup) error {
 	})
 }
 
+// validateStorageType checks that the backup tool works with the storage type,
+// the pvc storage is only mounted by the backup job, so it doesn't work with BR
+// whose data is written by TiKV, and dumpling which writes to object storages
+func (bm *backupManager) validateStorageType(backup *v1alpha1.Backup) (string, error) {
+	ns := backup.GetNamespace()
+	name := backup.GetName()
+
+	if backup.Spec.StorageType != v1alpha1.BackupStorageTypePVC {
+		return "", nil
+	}
+	if backup.Spec.BR != nil || backup.Spec.Dumpling != nil || backup.Spec.LogBackup != nil || backup.Spec.VolumeSnapshot != nil {
+		return "PVCStorageNotSupported", fmt.Errorf("backup %s/%s storage type %s only works with mydumper", ns, name, backup.Spec.StorageType)
+	}
+	return "", nil
+}
+
+// validateDumplingConfig checks that the dumpling configs of a backup are valid
+func (bm *backupManager) validateDumplingConfig(backup *v1alpha1.Backup) (string, error) {
+	ns := backup.GetNamespace()
+	name := backup.GetName()
+
+	dumpling := backup.Spec.Dumpling
+	if dumpling == nil {
+		return "", nil
+	}
+	if backup.Spec.BR != nil {
+		return "ConflictBackupTool", fmt.Errorf("backup %s/%s spec.br and spec.dumpling can't be set at the same time", ns, name)
+	}
+	switch dumpling.FileType {
+	case "", v1alpha1.DumplingFileTypeSQL, v1alpha1.DumplingFileTypeCSV:
+	default:
+		return "InvalidDumplingFileType", fmt.Errorf("backup %s/%s spec.dumpling.fileType %s is invalid, must be sql or csv", ns, name, dumpling.FileType)
+	}
+	if dumpling.Threads != nil && *dumpling.Threads <= 0 {
+		return "InvalidDumplingThreads", fmt.Errorf("backup %s/%s spec.dumpling.threads %d must be positive", ns, name, *dumpling.Threads)
+	}
+	return "", nil
+}
+
+// validateLogBackupConfig checks that the log backup configs of a backup are valid
+func (bm *backupManager) validateLogBackupConfig(backup *v1alpha1.Backup) (string, error) {
+	ns := backup.GetNamespace()
+	name := backup.GetName()
+
+	logBackup := backup.Spec.LogBackup
+	if logBackup == nil {
+		return "", nil
+	}
+	if backup.Spec.BR != nil || backup.Spec.Dumpling != nil {
+		return "ConflictBackupTool", fmt.Errorf("backup %s/%s spec.logBackup can't be set with spec.br or spec.dumpling", ns, name)
+	}
+	switch backup.GetLogBackupCommand() {
+	case v1alpha1.LogBackupCommandStart, v1alpha1.LogBackupCommandStop:
+	case v1alpha1.LogBackupCommandTruncate:
+		if logBackup.TruncateUntil == "" {
+			return "TruncateUntilIsEmpty", fmt.Errorf("backup %s/%s spec.logBackup.truncateUntil is required by the truncate command", ns, name)
+		}
+	default:
+		return "InvalidLogBackupCommand", fmt.Errorf("backup %s/%s spec.logBackup.command %s is invalid, must be start, stop or truncate", ns, name, logBackup.Command)
+	}
+	return "", nil
+}
+
+// validateVolumeSnapshotConfig checks that the volume snapshot configs of a backup
+// are valid, the credentials and region of the aws-ebs provider are read from spec.s3
+func (bm *backupManager) validateVolumeSnapshotConfig(backup *v1alpha1.Backup) (string, error) {
+	ns := backup.GetNamespace()
+	name := backup.GetName()
+
+	if backup.Spec.VolumeSnapshot == nil {
+		return "", nil
+	}
+	if backup.Spec.BR != nil || backup.Spec.Dumpling != nil || backup.Spec.LogBackup != nil {
+		return "ConflictBackupTool", fmt.Errorf("backup %s/%s spec.volumeSnapshot can't be set with spec.br, spec.dumpling or spec.logBackup", ns, name)
+	}
+	switch backup.GetVolumeSnapshotProvider() {
+	case v1alpha1.VolumeSnapshotProviderAWSEBS:
+		if backup.Spec.StorageType != v1alpha1.BackupStorageTypeS3 {
+			return "VolumeSnapshotStorageNotSupported", fmt.Errorf("backup %s/%s spec.volumeSnapshot provider aws-ebs requires storage type s3, got %s", ns, name, backup.Spec.StorageType)
+		}
+	case v1alpha1.VolumeSnapshotProviderCSI:
+		if backup.Spec.VolumeSnapshot.VolumeSnapshotClassName == "" {
+			return "VolumeSnapshotClassIsEmpty", fmt.Errorf("backup %s/%s spec.volumeSnapshot.volumeSnapshotClassName is required by provider csi", ns, name)
+		}
+	default:
+		return "InvalidVolumeSnapshotProvider", fmt.Errorf("backup %s/%s spec.volumeSnapshot.provider %s is invalid, must be aws-ebs or csi", ns, name, backup.Spec.VolumeSnapshot.Provider)
+	}
+	if backup.Spec.Type == v1alpha1.BackupTypeInc {
+		return "VolumeSnapshotTypeNotSupported", fmt.Errorf("backup %s/%s spec.volumeSnapshot doesn't support incremental backup", ns, name)
+	}
+	return "", nil
+}
+
+// getSnapshotVolumes returns the volumes of the pvcs of the cluster which are
+// snapshotted by the volume snapshot backup. The aws-ebs provider snapshots the
+// TiKV volumes, the csi provider also snapshots the PD volumes to restore the
+// cluster from the VolumeSnapshots.
+func (bm *backupManager) getSnapshotVolumes(backup *v1alpha1.Backup) ([]v1alpha1.VolumeSnapshotStatus, string, error) {
+	ns := backup.GetNamespace()
+	name := backup.GetName()
+	provider := backup.GetVolumeSnapshotProvider()
+
+	components := []label.Label{label.New().Instance(backup.Spec.Cluster).TiKV()}
+	if provider == v1alpha1.VolumeSnapshotProviderCSI {
+		components = append(components, label.New().Instance(backup.Spec.Cluster).PD())
+	}
+	var pvcs []*corev1.PersistentVolumeClaim
+	for _, l := range components {
+		component := l[label.ComponentLabelKey]
+		selector, err := l.Selector()
+		if err != nil {
+			return nil, "InvalidPVCSelector", fmt.Errorf("backup %s/%s generate %s selector failed, err: %v", ns, name, component, err)
+		}
+		componentPVCs, err := bm.pvcLister.PersistentVolumeClaims(ns).List(selector)
+		if err != nil {
+			return nil, "ListPVCFailed", fmt.Errorf("backup %s/%s list %s pvcs failed, err: %v", ns, name, component, err)
+		}
+		if len(componentPVCs) == 0 {
+			return nil, "PVCNotFound", fmt.Errorf("backup %s/%s can't find any %s pvc of cluster %s", ns, name, component, backup.Spec.Cluster)
+		}
+		pvcs = append(pvcs, componentPVCs...)
+	}
+
+	volumes := make([]v1alpha1.VolumeSnapshotStatus, 0, len(pvcs))
+	for _, pvc := range pvcs {
+		pvName := pvc.Spec.VolumeName
+		if pvName == "" {
+			return nil, "PVCNotBound", fmt.Errorf("backup %s/%s pvc %s is not bound", ns, name, pvc.GetName())
+		}
+		pv, err := bm.pvLister.Get(pvName)
+		if err != nil {
+			return nil, "GetPVFailed", fmt.Errorf("backup %s/%s get pv %s of pvc %s failed, err: %v", ns, name, pvName, pvc.GetName(), err)
+		}
+		var volumeID string
+		if provider == v1alpha1.VolumeSnapshotProviderCSI {
+			if pv.Spec.CSI != nil {
+				volumeID = pv.Spec.CSI.VolumeHandle
+			}
+		} else {
+			volumeID = getAWSVolumeID(pv)
+		}
+		if volumeID == "" {
+			return nil, "VolumeNotSupported", fmt.Errorf("backup %s/%s pv %s of pvc %s is not supported by provider %s", ns, name, pvName, pvc.GetName(), provider)
+		}
+		volumes = append(volumes, v1alpha1.VolumeSnapshotStatus{
+			PVCName:  pvc.GetName(),
+			VolumeID: volumeID,
+		})
+	}
+	sort.Slice(volumes, func(i, j int) bool {
+		return volumes[i].PVCName < volumes[j].PVCName
+	})
+	return volumes, "", nil
+}
+
+// getAWSVolumeID returns the EBS volume id of the pv provisioned by the in-tree
+// plugin, e.g. aws://us-west-2a/vol-xxx, or by the EBS CSI driver
+func getAWSVolumeID(pv *corev1.PersistentVolume) string {
+	if ebs := pv.Spec.AWSElasticBlockStore; ebs != nil {
+		return ebs.VolumeID[strings.LastIndex(ebs.VolumeID, "/")+1:]
+	}
+	if csi := pv.Spec.CSI; csi != nil && csi.Driver == constants.AWSEBSCSIDriver {
+		return csi.VolumeHandle
+	}
+	return ""
+}
+
+// validateChecksum checks that the checksum is only enabled explicitly for the
+// backup taken by BR, mydumper and dumpling don't verify the exported data
+func (bm *backupManager) validateChecksum(backup *v1alpha1.Backup) (string, error) {
+	ns := backup.GetNamespace()
+	name := backup.GetName()
+
+	if backup.Spec.Checksum == nil || !*backup.Spec.Checksum || backup.Spec.BR != nil {
+		return "", nil
+	}
+	return "ChecksumNotSupported", fmt.Errorf("backup %s/%s spec.checksum only works with spec.br", ns, name)
+}
+
+// validateTableFilter checks that the table filter is only set for the backup
+// taken by BR or dumpling, mydumper always backs up all the tables
+func (bm *backupManager) validateTableFilter(backup *v1alpha1.Backup) (string, error) {
+	ns := backup.GetNamespace()
+	name := backup.GetName()
+
+	if len(backup.Spec.TableFilter) == 0 || backup.Spec.BR != nil || backup.Spec.Dumpling != nil {
+		return "", nil
+	}
+	return "TableFilterNotSupported", fmt.Errorf("backup %s/%s spec.tableFilter only works with spec.br or spec.dumpling", ns, name)
+}
+
+// isLogBackupJobOutdated returns whether the backup job was created for another
+// command of the log backup task
+func isLogBackupJobOutdated(backup *v1alpha1.Backup, job *batchv1.Job) bool {
+	annotations := job.GetAnnotations()
+	if annotations[label.AnnLogBackupCommand] != string(backup.GetLogBackupCommand()) {
+		return true
+	}
+	return backup.GetLogBackupCommand() == v1alpha1.LogBackupCommandTruncate &&
+		annotations[label.AnnLogBackupTruncateUntil] != backup.Spec.LogBackup.TruncateUntil
+}
+
+// isLogBackupStatusOutdated returns whether the job of the started log backup
+// task completed LogBackupStatusRefreshInterval ago. The task keeps running in
+// TiKV after the job completes, the job is recreated to report its checkpoint.
+func isLogBackupStatusOutdated(backup *v1alpha1.Backup, job *batchv1.Job) bool {
+	if backup.GetLogBackupCommand() != v1alpha1.LogBackupCommandStart || v1alpha1.IsBackupFailed(backup) {
+		return false
+	}
+	completionTime := job.Status.CompletionTime
+	return completionTime != nil && time.Since(completionTime.Time) >= constants.LogBackupStatusRefreshInterval
+}
+
+// validateBaseBackup checks that the base backup of an incremental BR backup
+// exists and is complete
+func (bm *backupManager) validateBaseBackup(backup *v1alpha1.Backup) (string, error) {
+	ns := backup.GetNamespace()
+	name := backup.GetName()
+
+	if backup.Spec.BR == nil || backup.Spec.Type != v1alpha1.BackupTypeInc {
+		return "", nil
+	}
+
+	lastBackupTS := backup.Spec.BR.LastBackupTS
+	if lastBackupTS == "" {
+		return "LastBackupTSIsEmpty", fmt.Errorf("incremental backup %s/%s spec.br.lastBackupTS is empty", ns, name)
+	}
+
+	backups, err := bm.backupLister.Backups(ns).List(labels.Everything())
+	if err != nil {
+		return "ListBackupFailed", fmt.Errorf("incremental backup %s/%s list backups failed, err: %v", ns, name, err)
+	}
+	if backuputil.GetBaseBackup(backup, backups) != nil {
+		return "", nil
+	}
+	return "BaseBackupNotFound", fmt.Errorf("incremental backup %s/%s can't find a complete base backup with commitTs %s", ns, name, lastBackupTS)
+}
+
+// validateSecondaryStorages checks that the backup data can be replicated to
+// the secondary storages, only the data in the object storages is replicated
+func (bm *backupManager) validateSecondaryStorages(backup *v1alpha1.Backup) (string, error) {
+	ns := backup.GetNamespace()
+	name := backup.GetName()
+
+	if len(backup.Spec.SecondaryStorages) == 0 {
+		return "", nil
+	}
+	if backup.Spec.StorageType == v1alpha1.BackupStorageTypePVC || backup.Spec.LogBackup != nil || backup.Spec.VolumeSnapshot != nil {
+		return "SecondaryStorageNotSupported", fmt.Errorf("backup %s/%s spec.secondaryStorages doesn't work with storage type pvc, spec.logBackup or spec.volumeSnapshot", ns, name)
+	}
+	names := map[string]bool{}
+	for i, storage := range backup.Spec.SecondaryStorages {
+		if storage.Name == "" || names[storage.Name] {
+			return "InvalidSecondaryStorageName", fmt.Errorf("backup %s/%s spec.secondaryStorages[%d].name %q is empty or duplicated", ns, name, i, storage.Name)
+		}
+		names[storage.Name] = true
+		if _, reason, err := backuputil.GenerateSecondaryStorageEnv(backup, i, bm.secretLister); err != nil {
+			return reason, err
+		}
+	}
+	return "", nil
+}
+
+// syncReplicateJob creates the job which replicates the data of a complete
+// backup to the secondary storages, the job records the status of each storage
+func (bm *backupManager) syncReplicateJob(backup *v1alpha1.Backup) error {
+	ns := backup.GetNamespace()
+	name := backup.GetName()
+	replicateJobName := backup.GetReplicateJobName()
+
+	_, err := bm.jobLister.Jobs(ns).Get(replicateJobName)
+	if err == nil {
+		// already have a replicate job running，return directly
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return fmt.Errorf("backup %s/%s get job %s failed, err: %v", ns, name, replicateJobName, err)
+	}
+
+	job, reason, err := bm.makeReplicateJob(backup)
+	if err != nil {
+		bm.statusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupReplicated,
+			Status:  corev1.ConditionFalse,
+			Reason:  reason,
+			Message: err.Error(),
+		})
+		return err
+	}
+
+	if err := bm.jobControl.CreateJob(backup, job); err != nil {
+		errMsg := fmt.Errorf("create backup %s/%s job %s failed, err: %v", ns, name, replicateJobName, err)
+		bm.statusUpdater.Update(backup, &v1alpha1.BackupCondition{
+			Type:    v1alpha1.BackupReplicated,
+			Status:  corev1.ConditionFalse,
+			Reason:  "CreateReplicateJobFailed",
+			Message: errMsg.Error(),
+		})
+		return errMsg
+	}
+
+	return bm.statusUpdater.Update(backup, &v1alpha1.BackupCondition{
+		Type:   v1alpha1.BackupReplicated,
+		Status: corev1.ConditionFalse,
+		Reason: "Replicating",
+	})
+}
+
+func (bm *backupManager) makeReplicateJob(backup *v1alpha1.Backup) (*batchv1.Job, string, error) {
+	ns := backup.GetNamespace()
+	name := backup.GetName()
+
+	storageEnv, reason, err := backuputil.GenerateStorageCertEnv(backup, ns, bm.secretLister)
+	if err != nil {
+		return nil, reason, err
+	}
+	for i := range backup.Spec.SecondaryStorages {
+		secondaryEnv, reason, err := backuputil.GenerateSecondaryStorageEnv(backup, i, bm.secretLister)
+		if err != nil {
+			return nil, reason, err
+		}
+		storageEnv = append(storageEnv, secondaryEnv...)
+	}
+	caVolumes, caVolumeMounts := backuputil.GenerateStorageCAVolume(backup)
+
+	args := []string{
+		"replicate",
+		fmt.Sprintf("--namespace=%s", ns),
+		fmt.Sprintf("--tidbcluster=%s", backup.Spec.Cluster),
+		fmt.Sprintf("--backupName=%s", name),
+	}
+
+	backupLabel := label.NewBackup().Instance(backup.Spec.Cluster).ReplicateJob().Backup(name)
+
+	podSpec := &corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      backupLabel.Labels(),
+			Annotations: backuputil.GenerateStoragePodAnnotations(backup),
+		},
+		Spec: corev1.PodSpec{
+			ServiceAccountName: backuputil.GetServiceAccountName(backup.Spec.ServiceAccount, false),
+			Containers: []corev1.Container{
+				{
+					Name:            label.ReplicateJobLabelVal,
+					Image:           controller.TidbBackupManagerImage,
+					Args:            args,
+					ImagePullPolicy: corev1.PullAlways,
+					Resources: util.ResourceRequirement(v1alpha1.ContainerSpec{
+						Requests: backup.Spec.Requests,
+						Limits:   backup.Spec.Limits,
+					}),
+					Env:          storageEnv,
+					VolumeMounts: caVolumeMounts,
+				},
+			},
+			RestartPolicy: corev1.RestartPolicyNever,
+			Affinity:      backup.Spec.Affinity,
+			NodeSelector:  backup.Spec.NodeSelector,
+			Tolerations:   backup.Spec.Tolerations,
+			Volumes:       caVolumes,
+		},
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backup.GetReplicateJobName(),
+			Namespace: ns,
+			Labels:    backupLabel,
+			OwnerReferences: []metav1.OwnerReference{
+				controller.GetBackupOwnerRef(backup),
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: controller.Int32Ptr(constants.DefaultBackoffLimit),
+			Template:     *podSpec,
+		},
+	}
+	return job, "", nil
+}
+
 func (bm *backupManager) makeBackupJob(backup *v1alpha1.Backup) (*batchv1.Job, string, error) {
 	ns := backup.GetNamespace()
 	name := backup.GetName()
 
-	user, password, reason, err := backuputil.GetTidbUserAndPassword(ns, name, backup.Spec.TidbSecretName, bm.secretLister)
+	user, _, reason, err := backuputil.GetTidbUserAndPassword(ns, name, backup.Spec.TidbSecretName, bm.secretLister)
+	if err != nil {
+		return nil, reason, err
+	}
+
+	storageEnv, reason, err := backuputil.GenerateStorageCertEnv(backup, ns, bm.secretLister)
 	if err != nil {
 		return nil, reason, err
 	}
 
-	storageEnv, reason, err := backuputil.GenerateStorageCertEnv(backup, bm.secretLister)
+	encryptionEnv, reason, err := backuputil.GenerateEncryptionEnv(backup, bm.secretLister)
 	if err != nil {
 		return nil, reason, err
 	}
+	caVolumes, caVolumeMounts := backuputil.GenerateStorageCAVolume(backup)
+
+	serviceAccount := backuputil.GetServiceAccountName(backup.Spec.ServiceAccount, backup.Spec.VolumeSnapshot != nil)
+	if backuputil.IsDefaultServiceAccount(serviceAccount) {
+		reason, err = backuputil.EnsureDefaultServiceAccount(backup, backup, ns, serviceAccount, bm.saLister, bm.saControl, bm.roleLister, bm.roleBindingLister, bm.rbacControl)
+	} else {
+		reason, err = backuputil.CheckServiceAccountAnnotations(backup, ns, serviceAccount, bm.saLister)
+	}
+	if err != nil {
+		return nil, reason, fmt.Errorf("backup %s/%s, %v", ns, name, err)
+	}
 
 	// TODO: make pvc request storage size configurable
 	reason, err = bm.ensureBackupPVCExist(backup)
@@ -157,56 +682,105 @@ func (bm *backupManager) makeBackupJob(backup *v1alpha1.Backup) (*batchv1.Job, s
 		fmt.Sprintf("--backupName=%s", name),
 		fmt.Sprintf("--tidbservice=%s", controller.TiDBMemberName(backup.Spec.Cluster)),
 		fmt.Sprintf("--storageType=%s", backup.Spec.StorageType),
-		fmt.Sprintf("--password=%s", password),
 		fmt.Sprintf("--user=%s", user),
 	}
 
 	backupLabel := label.NewBackup().Instance(backup.Spec.Cluster).BackupJob().Backup(name)
 
-	// TODO: need add ResourceRequirement for backup job
 	podSpec := &corev1.PodTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
-			Labels: backupLabel.Labels(),
+			Labels:      backupLabel.Labels(),
+			Annotations: backuputil.GenerateStoragePodAnnotations(backup),
 		},
 		Spec: corev1.PodSpec{
-			ServiceAccountName: constants.DefaultServiceAccountName,
+			ServiceAccountName: serviceAccount,
 			Containers: []corev1.Container{
 				{
 					Name:            label.BackupJobLabelVal,
 					Image:           controller.TidbBackupManagerImage,
 					Args:            args,
 					ImagePullPolicy: corev1.PullAlways,
-					VolumeMounts: []corev1.VolumeMount{
-						{Name: label.BackupJobLabelVal, MountPath: constants.BackupRootPath},
-					},
-					Env: storageEnv,
+					Resources: util.ResourceRequirement(v1alpha1.ContainerSpec{
+						Requests: backup.Spec.Requests,
+						Limits:   backup.Spec.Limits,
+					}),
+					VolumeMounts: caVolumeMounts,
+					Env:          append(append(storageEnv, encryptionEnv...), backuputil.GenerateTidbPasswordEnv(backup.Spec.TidbSecretName)),
 				},
 			},
 			RestartPolicy: corev1.RestartPolicyNever,
-			Volumes: []corev1.Volume{
-				{
-					Name: label.BackupJobLabelVal,
-					VolumeSource: corev1.VolumeSource{
-						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-							ClaimName: backup.GetBackupPVCName(),
-						},
-					},
+			Affinity:      backup.Spec.Affinity,
+			NodeSelector:  backup.Spec.NodeSelector,
+			Tolerations:   backup.Spec.Tolerations,
+			Volumes:       caVolumes,
+		},
+	}
+
+	// the job of a log backup task only runs br commands, and the pvc of the
+	// backup is shared by the jobs of the cluster with ReadWriteOnce access
+	if backup.Spec.LogBackup == nil {
+		podSpec.Spec.Volumes = append(podSpec.Spec.Volumes, corev1.Volume{
+			Name: label.BackupJobLabelVal,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: backup.GetBackupPVCName(),
 				},
 			},
-		},
+		})
+		podSpec.Spec.Containers[0].VolumeMounts = append(podSpec.Spec.Containers[0].VolumeMounts, corev1.VolumeMount{
+			Name: label.BackupJobLabelVal, MountPath: constants.BackupRootPath,
+		})
+	}
+
+	if backup.Spec.VolumeSnapshot != nil {
+		volumes, reason, err := bm.getSnapshotVolumes(backup)
+		if err != nil {
+			return nil, reason, err
+		}
+		data, err := json.Marshal(volumes)
+		if err != nil {
+			return nil, "MarshalSnapshotVolumesFailed", fmt.Errorf("backup %s/%s marshal snapshot volumes failed, err: %v", ns, name, err)
+		}
+		podSpec.Spec.Containers[0].Env = append(podSpec.Spec.Containers[0].Env, corev1.EnvVar{
+			Name:  "SNAPSHOT_VOLUMES",
+			Value: string(data),
+		})
+		// the job pauses the scheduling of PD by its API, which requires the
+		// client certificate if TLS is enabled in the cluster
+		tlsVolume, tlsVolumeMount := backuputil.GenerateClusterClientTLSVolume()
+		podSpec.Spec.Volumes = append(podSpec.Spec.Volumes, tlsVolume)
+		podSpec.Spec.Containers[0].VolumeMounts = append(podSpec.Spec.Containers[0].VolumeMounts, tlsVolumeMount)
+	}
+
+	var jobAnnotations map[string]string
+	if backup.Spec.LogBackup != nil {
+		jobAnnotations = map[string]string{
+			label.AnnLogBackupCommand: string(backup.GetLogBackupCommand()),
+		}
+		if backup.GetLogBackupCommand() == v1alpha1.LogBackupCommandTruncate {
+			jobAnnotations[label.AnnLogBackupTruncateUntil] = backup.Spec.LogBackup.TruncateUntil
+		}
+	}
+
+	// the volume snapshot job is retried if it is killed, so that the
+	// scheduling of PD paused by it is resumed
+	var backoffLimit int32
+	if backup.Spec.VolumeSnapshot != nil {
+		backoffLimit = constants.DefaultBackoffLimit
 	}
 
 	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      backup.GetBackupJobName(),
-			Namespace: ns,
-			Labels:    backupLabel,
+			Name:        backup.GetBackupJobName(),
+			Namespace:   ns,
+			Labels:      backupLabel,
+			Annotations: jobAnnotations,
 			OwnerReferences: []metav1.OwnerReference{
 				controller.GetBackupOwnerRef(backup),
 			},
 		},
 		Spec: batchv1.JobSpec{
-			BackoffLimit: controller.Int32Ptr(0),
+			BackoffLimit: controller.Int32Ptr(backoffLimit),
 			Template:     *podSpec,
 		},
 	}
@@ -218,6 +792,11 @@ func (bm *backupManager) ensureBackupPVCExist(backup *v1alpha1.Backup) (string,
 	ns := backup.GetNamespace()
 	name := backup.GetName()
 
+	if backup.Spec.LogBackup != nil {
+		// the job of a log backup task doesn't mount the pvc
+		return "", nil
+	}
+
 	storageSize := constants.DefaultStorageSize
 	if backup.Spec.StorageSize != "" {
 		storageSize = backup.Spec.StorageSize
@@ -243,11 +822,15 @@ func (bm *backupManager) ensureBackupPVCExist(backup *v1alpha1.Backup) (string,
 	if backup.Spec.StorageClassName != "" {
 		storageClassName = backup.Spec.StorageClassName
 	}
+	pvcLabel := label.NewBackup().Instance(backup.Spec.Cluster)
+	if backup.Spec.StorageType == v1alpha1.BackupStorageTypePVC {
+		pvcLabel = pvcLabel.Backup(name)
+	}
 	pvc := &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      backupPVCName,
 			Namespace: ns,
-			Labels:    label.NewBackup().Instance(backup.Spec.Cluster),
+			Labels:    pvcLabel,
 		},
 		Spec: corev1.PersistentVolumeClaimSpec{
 			StorageClassName: &storageClassName,
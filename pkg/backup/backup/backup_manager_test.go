@@ -0,0 +1,161 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsLogBackupStatusOutdated(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name           string
+		command        v1alpha1.LogBackupCommand
+		failed         bool
+		completedSince time.Duration
+		expectOutdated bool
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		backup := &v1alpha1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: "log-backup", Namespace: "ns"},
+			Spec: v1alpha1.BackupSpec{
+				LogBackup: &v1alpha1.LogBackupConfig{Command: test.command},
+			},
+		}
+		if test.failed {
+			backup.Status.Conditions = []v1alpha1.BackupCondition{
+				{Type: v1alpha1.BackupFailed, Status: corev1.ConditionTrue},
+			}
+		}
+		job := &batchv1.Job{}
+		if test.completedSince > 0 {
+			completionTime := metav1.NewTime(time.Now().Add(-test.completedSince))
+			job.Status.CompletionTime = &completionTime
+		}
+		g.Expect(isLogBackupStatusOutdated(backup, job)).To(Equal(test.expectOutdated))
+	}
+
+	tests := []testcase{
+		{
+			name:    "job of the started task is running",
+			command: v1alpha1.LogBackupCommandStart,
+		},
+		{
+			name:           "job of the started task completed recently",
+			command:        v1alpha1.LogBackupCommandStart,
+			completedSince: time.Minute,
+		},
+		{
+			name:           "job of the started task completed long ago",
+			command:        v1alpha1.LogBackupCommandStart,
+			completedSince: time.Hour,
+			expectOutdated: true,
+		},
+		{
+			name:           "job of the stopped task",
+			command:        v1alpha1.LogBackupCommandStop,
+			completedSince: time.Hour,
+		},
+		{
+			name:           "the task failed",
+			command:        v1alpha1.LogBackupCommandStart,
+			failed:         true,
+			completedSince: time.Hour,
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func TestValidateStorageType(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name         string
+		storageType  v1alpha1.BackupStorageType
+		update       func(spec *v1alpha1.BackupSpec)
+		expectReason string
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		backup := &v1alpha1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "ns"},
+			Spec:       v1alpha1.BackupSpec{StorageType: test.storageType},
+		}
+		if test.update != nil {
+			test.update(&backup.Spec)
+		}
+		bm := &backupManager{}
+		reason, err := bm.validateStorageType(backup)
+		g.Expect(reason).To(Equal(test.expectReason))
+		if test.expectReason == "" {
+			g.Expect(err).NotTo(HaveOccurred())
+		} else {
+			g.Expect(err).To(HaveOccurred())
+		}
+	}
+
+	tests := []testcase{
+		{
+			name:        "pvc storage with mydumper",
+			storageType: v1alpha1.BackupStorageTypePVC,
+		},
+		{
+			name:         "pvc storage with br",
+			storageType:  v1alpha1.BackupStorageTypePVC,
+			update:       func(spec *v1alpha1.BackupSpec) { spec.BR = &v1alpha1.BRConfig{} },
+			expectReason: "PVCStorageNotSupported",
+		},
+		{
+			name:         "pvc storage with dumpling",
+			storageType:  v1alpha1.BackupStorageTypePVC,
+			update:       func(spec *v1alpha1.BackupSpec) { spec.Dumpling = &v1alpha1.DumplingConfig{} },
+			expectReason: "PVCStorageNotSupported",
+		},
+		{
+			name:         "pvc storage with log backup",
+			storageType:  v1alpha1.BackupStorageTypePVC,
+			update:       func(spec *v1alpha1.BackupSpec) { spec.LogBackup = &v1alpha1.LogBackupConfig{} },
+			expectReason: "PVCStorageNotSupported",
+		},
+		{
+			name:         "pvc storage with volume snapshots",
+			storageType:  v1alpha1.BackupStorageTypePVC,
+			update:       func(spec *v1alpha1.BackupSpec) { spec.VolumeSnapshot = &v1alpha1.VolumeSnapshotConfig{} },
+			expectReason: "PVCStorageNotSupported",
+		},
+		{
+			name:        "s3 storage with br",
+			storageType: v1alpha1.BackupStorageTypeS3,
+			update:      func(spec *v1alpha1.BackupSpec) { spec.BR = &v1alpha1.BRConfig{} },
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
@@ -18,17 +18,19 @@ import (
 	"sort"
 	"time"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/backup"
 	"github.com/pingcap/tidb-operator/pkg/backup/constants"
+	backuputil "github.com/pingcap/tidb-operator/pkg/backup/util"
 	listers "github.com/pingcap/tidb-operator/pkg/client/listers/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	"github.com/robfig/cron"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	batchlisters "k8s.io/client-go/listers/batch/v1"
+	"k8s.io/kubernetes/pkg/util/slice"
 )
 
 type backupScheduleManager struct {
@@ -58,13 +60,21 @@ func (bm *backupScheduleManager) Sync(bs *v1alpha1.BackupSchedule) error {
 		defer bm.backupGC(bs)
 	}
 
+	if bs.Spec.Pause {
+		log.V(4).Infof("backup schedule %s/%s is paused", bs.GetNamespace(), bs.GetName())
+		return advanceLastBackupTime(bs)
+	}
+
 	if err := bm.canPerformNextBackup(bs); err != nil {
 		return err
 	}
 
-	scheduledTime, err := getLastScheduledTime(bs)
+	scheduledTime, err := getLastScheduledTime(bs, bs.Spec.Schedule, bs.Status.LastBackupTime)
 	if scheduledTime == nil {
-		return err
+		if err != nil {
+			return err
+		}
+		return bm.syncIncrementalBackup(bs)
 	}
 
 	// delete the last backup job for release the backup PVC
@@ -72,7 +82,11 @@ func (bm *backupScheduleManager) Sync(bs *v1alpha1.BackupSchedule) error {
 		return nil
 	}
 
-	backup, err := bm.createBackup(bs, *scheduledTime)
+	if err := bm.replaceLastBackup(bs); err != nil {
+		return err
+	}
+
+	backup, err := bm.createBackup(bs, *scheduledTime, "")
 	if err != nil {
 		return err
 	}
@@ -82,6 +96,95 @@ func (bm *backupScheduleManager) Sync(bs *v1alpha1.BackupSchedule) error {
 	return nil
 }
 
+// advanceLastBackupTime moves the last backup time of the paused backup schedule
+// to just before the last missed scheduled time, so that only one backup is
+// taken for the runs missed while it is paused after it is resumed, and the
+// missed runs don't pile up over the limit of getLastScheduledTime.
+func advanceLastBackupTime(bs *v1alpha1.BackupSchedule) error {
+	scheduledTime, err := getLastScheduledTime(bs, bs.Spec.Schedule, bs.Status.LastBackupTime)
+	if scheduledTime == nil {
+		return err
+	}
+	// the scheduled times are whole minutes, and metav1.Time is serialized in seconds
+	bs.Status.LastBackupTime = &metav1.Time{Time: scheduledTime.Add(-time.Second)}
+	return nil
+}
+
+// syncIncrementalBackup creates an incremental BR backup on top of the last
+// complete backup when the incremental schedule is due
+func (bm *backupScheduleManager) syncIncrementalBackup(bs *v1alpha1.BackupSchedule) error {
+	ns := bs.GetNamespace()
+	bsName := bs.GetName()
+
+	if bs.Spec.IncrementalSchedule == "" || bs.Spec.BackupTemplate.BR == nil {
+		return nil
+	}
+	if bs.Status.LastBackupTime == nil {
+		// incremental backups are chained to a full backup, wait for the first one
+		return nil
+	}
+
+	lastBackupTime := bs.Status.LastBackupTime
+	if bs.Status.LastIncrementalBackupTime != nil && bs.Status.LastIncrementalBackupTime.After(lastBackupTime.Time) {
+		lastBackupTime = bs.Status.LastIncrementalBackupTime
+	}
+	scheduledTime, err := getLastScheduledTime(bs, bs.Spec.IncrementalSchedule, lastBackupTime)
+	if scheduledTime == nil {
+		return err
+	}
+
+	base, err := bm.getLastCompleteBackup(bs)
+	if err != nil {
+		return err
+	}
+	if base == nil {
+		log.Infof("backup schedule %s/%s has no complete backup, skip incremental backup", ns, bsName)
+		return nil
+	}
+
+	// delete the last backup job for release the backup PVC
+	if err := bm.deleteLastBackupJob(bs); err != nil {
+		return nil
+	}
+
+	if err := bm.replaceLastBackup(bs); err != nil {
+		return err
+	}
+
+	backup, err := bm.createBackup(bs, *scheduledTime, base.Status.CommitTs)
+	if err != nil {
+		return err
+	}
+
+	bs.Status.LastBackup = backup.GetName()
+	bs.Status.LastIncrementalBackupTime = &metav1.Time{Time: *scheduledTime}
+	return nil
+}
+
+// getLastCompleteBackup returns the newest complete backup created by the backup schedule
+func (bm *backupScheduleManager) getLastCompleteBackup(bs *v1alpha1.BackupSchedule) (*v1alpha1.Backup, error) {
+	ns := bs.GetNamespace()
+	bsName := bs.GetName()
+
+	backupLabels := label.NewBackupSchedule().Instance(bs.Spec.BackupTemplate.Cluster).BackupSchedule(bsName)
+	selector, err := backupLabels.Selector()
+	if err != nil {
+		return nil, fmt.Errorf("generate backup schedule %s/%s label selector failed, err: %v", ns, bsName, err)
+	}
+	backupsList, err := bm.backupLister.Backups(ns).List(selector)
+	if err != nil {
+		return nil, fmt.Errorf("get backup schedule %s/%s backup list failed, selector: %s, err: %v", ns, bsName, selector, err)
+	}
+
+	sort.Sort(byCreateTime(backupsList))
+	for _, backup := range backupsList {
+		if v1alpha1.IsBackupComplete(backup) && backup.Status.CommitTs != "" {
+			return backup, nil
+		}
+	}
+	return nil, nil
+}
+
 func (bm *backupScheduleManager) deleteLastBackupJob(bs *v1alpha1.BackupSchedule) error {
 	ns := bs.GetNamespace()
 	bsName := bs.GetName()
@@ -107,10 +210,39 @@ func (bm *backupScheduleManager) deleteLastBackupJob(bs *v1alpha1.BackupSchedule
 	return bm.jobControl.DeleteJob(backup, job)
 }
 
+// replaceLastBackup deletes the last backup if it is still running and the
+// concurrency policy is Replace, the data of it is cleaned per its clean policy
+func (bm *backupScheduleManager) replaceLastBackup(bs *v1alpha1.BackupSchedule) error {
+	ns := bs.GetNamespace()
+	bsName := bs.GetName()
+
+	if bs.GetConcurrencyPolicy() != v1alpha1.ConcurrencyPolicyReplace {
+		return nil
+	}
+	backup, err := bm.backupLister.Backups(ns).Get(bs.Status.LastBackup)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("backup schedule %s/%s, get backup %s failed, err: %v", ns, bsName, bs.Status.LastBackup, err)
+	}
+	if v1alpha1.IsBackupComplete(backup) || v1alpha1.IsBackupFailed(backup) || backup.DeletionTimestamp != nil {
+		return nil
+	}
+
+	log.Infof("backup schedule %s/%s replace the running backup %s", ns, bsName, backup.GetName())
+	return bm.backupControl.DeleteBackup(backup)
+}
+
 func (bm *backupScheduleManager) canPerformNextBackup(bs *v1alpha1.BackupSchedule) error {
 	ns := bs.GetNamespace()
 	bsName := bs.GetName()
 
+	if bs.GetConcurrencyPolicy() == v1alpha1.ConcurrencyPolicyReplace {
+		// the running last backup is replaced when the next backup is due
+		return nil
+	}
+
 	backup, err := bm.backupLister.Backups(ns).Get(bs.Status.LastBackup)
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -127,18 +259,23 @@ func (bm *backupScheduleManager) canPerformNextBackup(bs *v1alpha1.BackupSchedul
 	return controller.RequeueErrorf("backup schedule %s/%s, the last backup %s is still running", ns, bsName, bs.Status.LastBackup)
 }
 
-func getLastScheduledTime(bs *v1alpha1.BackupSchedule) (*time.Time, error) {
+func getLastScheduledTime(bs *v1alpha1.BackupSchedule, schedule string, lastBackupTime *metav1.Time) (*time.Time, error) {
 	ns := bs.GetNamespace()
 	bsName := bs.GetName()
 
-	sched, err := cron.ParseStandard(bs.Spec.Schedule)
+	loc, err := bs.GetLocation()
+	if err != nil {
+		return nil, fmt.Errorf("backup schedule %s/%s timezone %s is invalid, err: %v", ns, bsName, bs.Spec.Timezone, err)
+	}
+	cronSched, err := cron.ParseStandard(schedule)
 	if err != nil {
-		return nil, fmt.Errorf("parse backup schedule %s/%s cron format %s failed, err: %v", ns, bsName, bs.Spec.Schedule, err)
+		return nil, fmt.Errorf("parse backup schedule %s/%s cron format %s failed, err: %v", ns, bsName, schedule, err)
 	}
+	sched := localSchedule{cronSched, loc}
 
 	var earliestTime time.Time
-	if bs.Status.LastBackupTime != nil {
-		earliestTime = bs.Status.LastBackupTime.Time
+	if lastBackupTime != nil {
+		earliestTime = lastBackupTime.Time
 	} else {
 		// If none found, then this is either a recently created backupSchedule,
 		// or the backupSchedule status info was somehow lost,
@@ -151,7 +288,7 @@ func getLastScheduledTime(bs *v1alpha1.BackupSchedule) (*time.Time, error) {
 	now := time.Now()
 	if earliestTime.After(now) {
 		// timestamp fallback, waiting for the next backup schedule period
-		glog.Errorf("backup schedule %s/%s timestamp fallback, lastBackupTime: %s, now: %s",
+		log.Errorf("backup schedule %s/%s timestamp fallback, lastBackupTime: %s, now: %s",
 			ns, bsName, earliestTime.Format(time.RFC3339), now.Format(time.RFC3339))
 		return nil, nil
 	}
@@ -170,24 +307,84 @@ func getLastScheduledTime(bs *v1alpha1.BackupSchedule) (*time.Time, error) {
 		// but less than "lots".
 		if len(scheduledTimes) > 100 {
 			// We can't get the last backup schedule time
-			glog.Errorf("Too many missed start backup schedule time (> 100). Check the clock.")
+			log.Errorf("Too many missed start backup schedule time (> 100). Check the clock.")
 			return nil, nil
 		}
 	}
 
 	if len(scheduledTimes) == 0 {
-		glog.V(4).Infof("unmet backup schedule %s/%s start time, waiting for the next backup schedule period", ns, bsName)
+		log.V(4).Infof("unmet backup schedule %s/%s start time, waiting for the next backup schedule period", ns, bsName)
 		return nil, nil
 	}
 	scheduledTime := scheduledTimes[len(scheduledTimes)-1]
 	return &scheduledTime, nil
 }
 
-func (bm *backupScheduleManager) createBackup(bs *v1alpha1.BackupSchedule, timestamp time.Time) (*v1alpha1.Backup, error) {
+// localSchedule evaluates a cron schedule by the wall clock of a timezone.
+// The schedule is evaluated in UTC, which has no daylight saving transitions,
+// on the wall clock time, and the result is mapped back to the timezone.
+type localSchedule struct {
+	cron.Schedule
+	loc *time.Location
+}
+
+// Next returns the next scheduled time after t
+func (s localSchedule) Next(t time.Time) time.Time {
+	wall := toWallClock(t, s.loc)
+	for {
+		wall = s.Schedule.Next(wall)
+		if wall.IsZero() {
+			// no time matches the schedule
+			return wall
+		}
+		// the wall clock time may be mapped to a time before t if the wall
+		// clock is turned back, which has been scheduled before
+		if next := fromWallClock(wall, s.loc); next.After(t) {
+			return next
+		}
+	}
+}
+
+// toWallClock returns the wall clock time of t in loc as a time in UTC
+func toWallClock(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// fromWallClock returns the time of the wall clock time in loc, the first one
+// is returned if the wall clock time is repeated by a daylight saving
+// transition, and the time shifted by the transition is returned if it's skipped
+func fromWallClock(wall time.Time, loc *time.Location) time.Time {
+	// the transitions are far more than 12 hours apart
+	_, offsetBefore := wall.Add(-12 * time.Hour).In(loc).Zone()
+	_, offsetAfter := wall.Add(12 * time.Hour).In(loc).Zone()
+	earlier := wall.Add(-time.Duration(offsetAfter) * time.Second)
+	later := wall.Add(-time.Duration(offsetBefore) * time.Second)
+	if later.Before(earlier) {
+		earlier, later = later, earlier
+	}
+	for _, t := range []time.Time{earlier, later} {
+		if toWallClock(t, loc).Equal(wall) {
+			return t.In(loc)
+		}
+	}
+	return later.In(loc)
+}
+
+// createBackup creates a backup from the backup template, the backup is an
+// incremental backup on top of lastBackupTS if lastBackupTS is not empty
+func (bm *backupScheduleManager) createBackup(bs *v1alpha1.BackupSchedule, timestamp time.Time, lastBackupTS string) (*v1alpha1.Backup, error) {
 	ns := bs.GetNamespace()
 	bsName := bs.GetName()
 
-	backupSpec := bs.Spec.BackupTemplate
+	backupSpec := *bs.Spec.BackupTemplate.DeepCopy()
+	if lastBackupTS != "" {
+		backupSpec.Type = v1alpha1.BackupTypeInc
+		backupSpec.BR.LastBackupTS = lastBackupTS
+	} else if backupSpec.BR != nil {
+		backupSpec.Type = v1alpha1.BackupTypeFull
+		backupSpec.BR.LastBackupTS = ""
+	}
 	if backupSpec.StorageClassName == "" {
 		if bs.Spec.StorageClassName != "" {
 			backupSpec.StorageClassName = bs.Spec.StorageClassName
@@ -228,25 +425,87 @@ func (bm *backupScheduleManager) backupGC(bs *v1alpha1.BackupSchedule) {
 	backupLables := label.NewBackupSchedule().Instance(bs.Spec.BackupTemplate.Cluster).BackupSchedule(bsName)
 	selector, err := backupLables.Selector()
 	if err != nil {
-		glog.Errorf("generate backup schedule %s/%s label selector failed, err: %v", ns, bsName, err)
+		log.Errorf("generate backup schedule %s/%s label selector failed, err: %v", ns, bsName, err)
 		return
 	}
 	backupsList, err := bm.backupLister.Backups(ns).List(selector)
 	if err != nil {
-		glog.Errorf("get backup schedule %s/%s backup list failed, selector: %s, err: %v", ns, bsName, selector, err)
+		log.Errorf("get backup schedule %s/%s backup list failed, selector: %s, err: %v", ns, bsName, selector, err)
+		return
 	}
 
+	countReclaimedBytes(bs, backupsList)
+
 	// sort backups by creation time before removing extra backups
 	sort.Sort(byCreateTime(backupsList))
 
+	// the chains of base backups of the kept incremental backups are kept as
+	// well, otherwise the incremental backups can't be restored
+	keptBases := map[string]bool{}
+	for i := 0; i < bs.Spec.MaxBackups && i < len(backupsList); i++ {
+		base := backuputil.GetBaseBackup(backupsList[i], backupsList)
+		for base != nil && !keptBases[base.GetName()] {
+			keptBases[base.GetName()] = true
+			base = backuputil.GetBaseBackup(base, backupsList)
+		}
+	}
+
 	for i, backup := range backupsList {
-		if i >= bs.Spec.MaxBackups {
-			// delete the backup
-			glog.Infof("backup schedule %s/%s gc backup %s", ns, bsName, backup.GetName())
-			if err := bm.backupControl.DeleteBackup(backup); err != nil {
+		if i < bs.Spec.MaxBackups || backup.DeletionTimestamp != nil || keptBases[backup.GetName()] {
+			continue
+		}
+		if backup.GetCleanPolicy() != v1alpha1.CleanPolicyTypeDelete {
+			// the data of the pruned backup must be removed from the backend storage,
+			// so switch its clean policy to Delete and delete it in the next round
+			// after the protection finalizer is added by the backup controller
+			log.Infof("backup schedule %s/%s gc backup %s, set clean policy to %s", ns, bsName, backup.GetName(), v1alpha1.CleanPolicyTypeDelete)
+			newBackup := backup.DeepCopy()
+			newBackup.Spec.CleanPolicy = v1alpha1.CleanPolicyTypeDelete
+			if _, err := bm.backupControl.UpdateBackup(newBackup); err != nil {
 				return
 			}
+			continue
+		}
+		if !slice.ContainsString(backup.Finalizers, label.BackupProtectionFinalizer, nil) {
+			log.V(4).Infof("backup schedule %s/%s gc backup %s, waiting for the protection finalizer", ns, bsName, backup.GetName())
+			continue
+		}
+		// delete the backup, the backup data is removed by the clean job of the backup
+		log.Infof("backup schedule %s/%s gc backup %s", ns, bsName, backup.GetName())
+		if err := bm.backupControl.DeleteBackup(backup); err != nil {
+			return
+		}
+		if backup.Status.BackupPath != "" {
+			// the size is counted after the data is cleaned up by the clean job
+			if bs.Status.ReclaimingBackups == nil {
+				bs.Status.ReclaimingBackups = map[string]int64{}
+			}
+			bs.Status.ReclaimingBackups[backup.GetName()] = backup.Status.BackupSize
+		}
+	}
+}
+
+// countReclaimedBytes adds the size of the pruned backups whose data has been
+// cleaned up to the reclaimed bytes of the backup schedule. The protection
+// finalizer of a backup is removed only after its data is cleaned up, so a
+// pruned backup which is gone has been cleaned up as well.
+func countReclaimedBytes(bs *v1alpha1.BackupSchedule, backups []*v1alpha1.Backup) {
+	if len(bs.Status.ReclaimingBackups) == 0 {
+		return
+	}
+	backupsByName := map[string]*v1alpha1.Backup{}
+	for _, backup := range backups {
+		backupsByName[backup.GetName()] = backup
+	}
+	for name, size := range bs.Status.ReclaimingBackups {
+		if backup, ok := backupsByName[name]; ok && !v1alpha1.IsBackupClean(backup) {
+			continue
 		}
+		bs.Status.ReclaimedBytes += size
+		delete(bs.Status.ReclaimingBackups, name)
+	}
+	if len(bs.Status.ReclaimingBackups) == 0 {
+		bs.Status.ReclaimingBackups = nil
 	}
 }
 
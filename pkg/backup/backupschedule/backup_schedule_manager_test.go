@@ -0,0 +1,434 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backupschedule
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned/fake"
+	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/robfig/cron"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestLocalScheduleNext(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name     string
+		timezone string
+		schedule string
+		from     string
+		expect   []string
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		loc, err := time.LoadLocation(test.timezone)
+		g.Expect(err).NotTo(HaveOccurred())
+		cronSched, err := cron.ParseStandard(test.schedule)
+		g.Expect(err).NotTo(HaveOccurred())
+		sched := localSchedule{cronSched, loc}
+
+		next, err := time.Parse(time.RFC3339, test.from)
+		g.Expect(err).NotTo(HaveOccurred())
+		for _, expect := range test.expect {
+			next = sched.Next(next)
+			g.Expect(next.UTC().Format(time.RFC3339)).To(Equal(expect))
+		}
+	}
+	tests := []testcase{
+		{
+			name:     "UTC",
+			timezone: "UTC",
+			schedule: "0 2 * * *",
+			from:     "2021-03-14T00:00:00Z",
+			expect:   []string{"2021-03-14T02:00:00Z", "2021-03-15T02:00:00Z"},
+		},
+		{
+			name:     "without daylight saving",
+			timezone: "Asia/Shanghai",
+			schedule: "0 2 * * *",
+			from:     "2021-03-14T00:00:00Z",
+			expect:   []string{"2021-03-14T18:00:00Z", "2021-03-15T18:00:00Z"},
+		},
+		{
+			name:     "skipped by daylight saving",
+			timezone: "America/New_York",
+			schedule: "30 2 * * *",
+			from:     "2021-03-13T12:00:00Z",
+			// 02:30 of 03-14 is skipped, it's taken at 03:30 EDT
+			expect: []string{"2021-03-14T07:30:00Z", "2021-03-15T06:30:00Z"},
+		},
+		{
+			name:     "skipped and scheduled by daylight saving",
+			timezone: "America/New_York",
+			schedule: "30 2,3 * * *",
+			from:     "2021-03-13T12:00:00Z",
+			// 02:30 is shifted to 03:30, which is taken only once
+			expect: []string{"2021-03-14T07:30:00Z", "2021-03-15T06:30:00Z", "2021-03-15T07:30:00Z"},
+		},
+		{
+			name:     "repeated by daylight saving",
+			timezone: "America/New_York",
+			schedule: "30 1 * * *",
+			from:     "2021-11-06T12:00:00Z",
+			// 01:30 of 11-07 is repeated, it's taken at the first one in EDT
+			expect: []string{"2021-11-07T05:30:00Z", "2021-11-08T06:30:00Z"},
+		},
+		{
+			name:     "from the repeated hour",
+			timezone: "America/New_York",
+			schedule: "*/20 * * * *",
+			from:     "2021-11-07T06:10:00Z",
+			// 01:10 EST is in the repeated hour, 01:20 and 01:40 have been taken in EDT
+			expect: []string{"2021-11-07T07:00:00Z", "2021-11-07T07:20:00Z"},
+		},
+		{
+			name:     "half hour daylight saving",
+			timezone: "Australia/Lord_Howe",
+			schedule: "15 2 * * *",
+			from:     "2021-10-02T00:00:00Z",
+			// 02:15 of 10-03 is skipped, it's taken at 02:45 +11
+			expect: []string{"2021-10-02T15:45:00Z", "2021-10-03T15:15:00Z"},
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func TestGetLastScheduledTimeWithTimezone(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	bs := &v1alpha1.BackupSchedule{}
+	bs.Namespace = "ns"
+	bs.Name = "bs"
+	bs.CreationTimestamp = metav1.Time{Time: time.Now().Add(-25 * time.Hour)}
+
+	bs.Spec.Timezone = "Asia/Shanghai"
+	scheduledTime, err := getLastScheduledTime(bs, "0 0 * * *", nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(scheduledTime).NotTo(BeNil())
+	local := scheduledTime.In(time.FixedZone("CST", 8*3600))
+	g.Expect(local.Hour()).To(Equal(0))
+	g.Expect(local.Minute()).To(Equal(0))
+
+	for _, timezone := range []string{"Local", "Mars/Olympus_Mons"} {
+		bs.Spec.Timezone = timezone
+		scheduledTime, err = getLastScheduledTime(bs, "0 0 * * *", nil)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(scheduledTime).To(BeNil())
+	}
+}
+
+func TestSyncPausedBackupSchedule(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	bs := newHourlyBackupSchedule()
+	lastSlot := time.Now().Truncate(time.Hour)
+	bm, backupIndexer := newFakeBackupScheduleManager()
+
+	// no backup is taken while the backup schedule is paused, and the
+	// baseline is kept right before the last missed scheduled time
+	bs.Spec.Pause = true
+	for i := 0; i < 2; i++ {
+		g.Expect(bm.Sync(bs)).To(Succeed())
+		g.Expect(backupIndexer.List()).To(BeEmpty())
+		g.Expect(bs.Status.LastBackupTime.Time.Equal(lastSlot.Add(-time.Second))).To(BeTrue())
+	}
+
+	// only one backup is taken for the missed runs after resumed
+	bs.Spec.Pause = false
+	g.Expect(bm.Sync(bs)).To(Succeed())
+	g.Expect(backupIndexer.List()).To(HaveLen(1))
+	g.Expect(bs.Status.LastBackup).To(Equal(bs.GetBackupCRDName(lastSlot)))
+	g.Expect(bs.Status.LastBackupTime.Time.Equal(lastSlot)).To(BeTrue())
+
+	// the backup of the last run is complete, wait for the next run
+	backup, err := bm.backupLister.Backups(bs.Namespace).Get(bs.Status.LastBackup)
+	g.Expect(err).NotTo(HaveOccurred())
+	backup = backup.DeepCopy()
+	backup.Status.Conditions = []v1alpha1.BackupCondition{{Type: v1alpha1.BackupComplete, Status: corev1.ConditionTrue}}
+	g.Expect(backupIndexer.Update(backup)).To(Succeed())
+	g.Expect(bm.Sync(bs)).To(Succeed())
+	g.Expect(backupIndexer.List()).To(HaveLen(1))
+}
+
+func TestReplaceLastBackup(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name              string
+		concurrencyPolicy v1alpha1.ConcurrencyPolicy
+		lastBackupCond    *v1alpha1.BackupCondition
+		noLastBackup      bool
+		expectRequeue     bool
+		expectReplaced    bool
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		bs := newHourlyBackupSchedule()
+		bs.Spec.ConcurrencyPolicy = test.concurrencyPolicy
+		bm, backupIndexer := newFakeBackupScheduleManager()
+		lastBackup := &v1alpha1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: "last-backup", Namespace: bs.Namespace},
+		}
+		if test.lastBackupCond != nil {
+			lastBackup.Status.Conditions = []v1alpha1.BackupCondition{*test.lastBackupCond}
+		}
+		if !test.noLastBackup {
+			g.Expect(backupIndexer.Add(lastBackup)).To(Succeed())
+		}
+		bs.Status.LastBackup = lastBackup.Name
+
+		err := bm.Sync(bs)
+		if test.expectRequeue {
+			g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+			g.Expect(bs.Status.LastBackup).To(Equal(lastBackup.Name))
+			return
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(bs.Status.LastBackup).NotTo(Equal(lastBackup.Name))
+		_, err = bm.backupLister.Backups(bs.Namespace).Get(bs.Status.LastBackup)
+		g.Expect(err).NotTo(HaveOccurred())
+		_, err = bm.backupLister.Backups(bs.Namespace).Get(lastBackup.Name)
+		g.Expect(err == nil).To(Equal(!test.expectReplaced && !test.noLastBackup))
+	}
+
+	tests := []testcase{
+		{
+			name:          "forbid: wait for the running last backup",
+			expectRequeue: true,
+		},
+		{
+			name:           "forbid: the last backup is complete",
+			lastBackupCond: &v1alpha1.BackupCondition{Type: v1alpha1.BackupComplete, Status: corev1.ConditionTrue},
+		},
+		{
+			name:              "replace: the running last backup is replaced",
+			concurrencyPolicy: v1alpha1.ConcurrencyPolicyReplace,
+			expectReplaced:    true,
+		},
+		{
+			name:              "replace: the last backup is complete",
+			concurrencyPolicy: v1alpha1.ConcurrencyPolicyReplace,
+			lastBackupCond:    &v1alpha1.BackupCondition{Type: v1alpha1.BackupComplete, Status: corev1.ConditionTrue},
+		},
+		{
+			name:              "replace: the last backup is failed",
+			concurrencyPolicy: v1alpha1.ConcurrencyPolicyReplace,
+			lastBackupCond:    &v1alpha1.BackupCondition{Type: v1alpha1.BackupFailed, Status: corev1.ConditionTrue},
+		},
+		{
+			name:              "replace: the last backup is not found",
+			concurrencyPolicy: v1alpha1.ConcurrencyPolicyReplace,
+			noLastBackup:      true,
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func TestBackupGCKeepsBaseBackups(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name         string
+		maxBackups   int
+		expectPruned []string
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		bs := newHourlyBackupSchedule()
+		bs.Spec.MaxBackups = test.maxBackups
+		bm, backupIndexer := newFakeBackupScheduleManager()
+
+		// full-1 <- inc-1 <- inc-2, full-2 <- inc-3, from the oldest to the newest
+		chain := []struct {
+			name         string
+			commitTs     string
+			lastBackupTS string
+		}{
+			{"full-1", "100", ""},
+			{"inc-1", "200", "100"},
+			{"inc-2", "300", "200"},
+			{"full-2", "400", ""},
+			{"inc-3", "500", "400"},
+		}
+		now := time.Now()
+		for i, c := range chain {
+			backup := &v1alpha1.Backup{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              c.name,
+					Namespace:         bs.Namespace,
+					Labels:            label.NewBackupSchedule().Instance(bs.Spec.BackupTemplate.Cluster).BackupSchedule(bs.Name).Labels(),
+					CreationTimestamp: metav1.Time{Time: now.Add(time.Duration(i-len(chain)) * time.Hour)},
+					Finalizers:        []string{label.BackupProtectionFinalizer},
+				},
+				Spec: v1alpha1.BackupSpec{
+					Cluster:     bs.Spec.BackupTemplate.Cluster,
+					Type:        v1alpha1.BackupTypeFull,
+					BR:          &v1alpha1.BRConfig{},
+					CleanPolicy: v1alpha1.CleanPolicyTypeDelete,
+				},
+				Status: v1alpha1.BackupStatus{
+					CommitTs:   c.commitTs,
+					Conditions: []v1alpha1.BackupCondition{{Type: v1alpha1.BackupComplete, Status: corev1.ConditionTrue}},
+				},
+			}
+			if c.lastBackupTS != "" {
+				backup.Spec.Type = v1alpha1.BackupTypeInc
+				backup.Spec.BR.LastBackupTS = c.lastBackupTS
+			}
+			g.Expect(backupIndexer.Add(backup)).To(Succeed())
+		}
+
+		bm.backupGC(bs)
+		for _, c := range chain {
+			_, err := bm.backupLister.Backups(bs.Namespace).Get(c.name)
+			pruned := false
+			for _, name := range test.expectPruned {
+				pruned = pruned || name == c.name
+			}
+			g.Expect(err != nil).To(Equal(pruned), c.name)
+		}
+	}
+
+	tests := []testcase{
+		{
+			name:         "keep the full backup of the kept incremental backup",
+			maxBackups:   1,
+			expectPruned: []string{"full-1", "inc-1", "inc-2"},
+		},
+		{
+			name:         "the chain ends with a full backup",
+			maxBackups:   2,
+			expectPruned: []string{"full-1", "inc-1", "inc-2"},
+		},
+		{
+			name:       "keep the whole chain of the kept incremental backup",
+			maxBackups: 3,
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+// newHourlyBackupSchedule returns an hourly backup schedule whose last backup
+// was taken 3 runs ago
+func TestBackupGCCountsReclaimedBytes(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	bs := newHourlyBackupSchedule()
+	bs.Spec.MaxBackups = 1
+	bm, backupIndexer := newFakeBackupScheduleManager()
+
+	now := time.Now()
+	newBackup := func(name string, size int64, age time.Duration) *v1alpha1.Backup {
+		return &v1alpha1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         bs.Namespace,
+				Labels:            label.NewBackupSchedule().Instance(bs.Spec.BackupTemplate.Cluster).BackupSchedule(bs.Name).Labels(),
+				CreationTimestamp: metav1.Time{Time: now.Add(-age)},
+				Finalizers:        []string{label.BackupProtectionFinalizer},
+			},
+			Spec: v1alpha1.BackupSpec{
+				Cluster:     bs.Spec.BackupTemplate.Cluster,
+				CleanPolicy: v1alpha1.CleanPolicyTypeDelete,
+			},
+			Status: v1alpha1.BackupStatus{
+				BackupPath: "s3://bucket/" + name,
+				BackupSize: size,
+				Conditions: []v1alpha1.BackupCondition{{Type: v1alpha1.BackupComplete, Status: corev1.ConditionTrue}},
+			},
+		}
+	}
+	for _, backup := range []*v1alpha1.Backup{
+		newBackup("backup-1", 100, 3*time.Hour),
+		newBackup("backup-2", 200, 2*time.Hour),
+		newBackup("backup-3", 300, time.Hour),
+	} {
+		g.Expect(backupIndexer.Add(backup)).To(Succeed())
+	}
+
+	// the size of the deleted backups is not counted before their data is cleaned up
+	bm.backupGC(bs)
+	g.Expect(bs.Status.ReclaimedBytes).To(BeZero())
+	g.Expect(bs.Status.ReclaimingBackups).To(Equal(map[string]int64{"backup-1": 100, "backup-2": 200}))
+
+	// backup-2 is gone after its data is cleaned up, the clean job of backup-1 is still running
+	cleaning := newBackup("backup-1", 100, 3*time.Hour)
+	cleaning.DeletionTimestamp = &metav1.Time{Time: now}
+	cleaning.Status.Conditions = append(cleaning.Status.Conditions, v1alpha1.BackupCondition{Type: v1alpha1.BackupClean, Status: corev1.ConditionFalse})
+	g.Expect(backupIndexer.Add(cleaning)).To(Succeed())
+	bm.backupGC(bs)
+	g.Expect(bs.Status.ReclaimedBytes).To(Equal(int64(200)))
+	g.Expect(bs.Status.ReclaimingBackups).To(Equal(map[string]int64{"backup-1": 100}))
+
+	// the data of backup-1 is cleaned up
+	cleaned := cleaning.DeepCopy()
+	cleaned.Status.Conditions[1].Status = corev1.ConditionTrue
+	g.Expect(backupIndexer.Update(cleaned)).To(Succeed())
+	bm.backupGC(bs)
+	g.Expect(bs.Status.ReclaimedBytes).To(Equal(int64(300)))
+	g.Expect(bs.Status.ReclaimingBackups).To(BeNil())
+
+	// the reclaimed backups are not counted again
+	g.Expect(backupIndexer.Delete(cleaned)).To(Succeed())
+	bm.backupGC(bs)
+	g.Expect(bs.Status.ReclaimedBytes).To(Equal(int64(300)))
+}
+
+func newHourlyBackupSchedule() *v1alpha1.BackupSchedule {
+	bs := &v1alpha1.BackupSchedule{}
+	bs.Namespace = "ns"
+	bs.Name = "bs"
+	bs.CreationTimestamp = metav1.Time{Time: time.Now().Add(-24 * time.Hour)}
+	bs.Spec.Schedule = "0 * * * *"
+	bs.Spec.BackupTemplate.Cluster = "demo"
+	bs.Status.LastBackupTime = &metav1.Time{Time: time.Now().Truncate(time.Hour).Add(-3 * time.Hour)}
+	return bs
+}
+
+func newFakeBackupScheduleManager() (*backupScheduleManager, cache.Indexer) {
+	informerFactory := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), 0)
+	backupInformer := informerFactory.Pingcap().V1alpha1().Backups()
+	jobInformer := kubeInformerFactory.Batch().V1().Jobs()
+	bm := &backupScheduleManager{
+		backupLister:  backupInformer.Lister(),
+		backupControl: controller.NewFakeBackupControl(backupInformer),
+		jobLister:     jobInformer.Lister(),
+		jobControl:    controller.NewFakeJobControl(jobInformer),
+	}
+	return bm, backupInformer.Informer().GetIndexer()
+}
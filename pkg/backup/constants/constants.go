@@ -13,6 +13,8 @@
 
 package constants
 
+import "time"
+
 const (
 	// TimeFormat is the time format for generate backup CR name
 	TimeFormat = "2006-01-02t15-04-05"
@@ -20,24 +22,92 @@ const (
 	// DefaultServiceAccountName is the default name of the ServiceAccount to use to run backup and restore's job pod.
 	DefaultServiceAccountName = "tidb-backup-manager"
 
+	// DefaultVolumeSnapshotServiceAccountName is the default name of the ServiceAccount of the job pods
+	// of the backups and restores by volume snapshots, which are granted to manage the snapshots and pods.
+	DefaultVolumeSnapshotServiceAccountName = "tidb-backup-manager-volume-snapshot"
+
 	// BackupRootPath is the root path to backup data
 	BackupRootPath = "/backup"
 
+	// BackupDataVolumeName is the name of the volume of the pvc which keeps the backup data
+	BackupDataVolumeName = "backup-data"
+
+	// BackupDataMountPath is the mount path of the pvc which keeps the backup data in the restore job
+	BackupDataMountPath = "/backup-data"
+
 	// DefaultStorageSize is the default pvc request storage size for backup and restore
 	DefaultStorageSize = "100Gi"
 
 	// DefaultBackoffLimit specifies the number of retries before marking this job failed.
 	DefaultBackoffLimit = 6
 
+	// LogBackupStatusRefreshInterval is the interval after which the completed job of a
+	// started log backup task is recreated to refresh the checkpoint of the task
+	LogBackupStatusRefreshInterval = 10 * time.Minute
+
 	// TidbUserKey represents the user name key in tidb secret
 	TidbUserKey = "user"
 
 	// TidbPasswordKey represents the password key in tidb secret
 	TidbPasswordKey = "password"
 
+	// TidbPasswordEnv is the env which passes the tidb password to the backup and restore jobs,
+	// it is the same as defined in cmd/backup-manager/app/constants
+	TidbPasswordEnv = "TIDB_PASSWORD"
+
 	// S3AccessKey represents the S3 compatible access key id in related secret
 	S3AccessKey = "access_key"
 
 	// S3SecretKey represents the S3 compatible secret access key in related secret
 	S3SecretKey = "secret_key"
+
+	// GCSCredentialsKey represents the JSON key of the GCP service account in related secret
+	GCSCredentialsKey = "credentials"
+
+	// CACertKey represents the CA bundle key in the CA secret of the backend storage
+	CACertKey = "ca.crt"
+
+	// StorageCAVolumeName is the name of the volume which stores the CA bundle of the backend storage
+	StorageCAVolumeName = "storage-ca"
+
+	// StorageCAMountPath is the mount path of the CA bundle of the backend storage
+	StorageCAMountPath = "/var/lib/storage-ca"
+
+	// ClusterClientTLSSecretName is the secret of the client certificate of the tidb cluster
+	// whose components are connected by TLS
+	ClusterClientTLSSecretName = "client-tls"
+
+	// ClusterClientTLSVolumeName is the name of the volume which stores the client certificate of the tidb cluster
+	ClusterClientTLSVolumeName = "cluster-client-tls"
+
+	// ClusterClientTLSMountPath is the mount path of the client certificate of the tidb
+	// cluster, it is where pdapi loads the client certificate from
+	ClusterClientTLSMountPath = "/var/lib/tls"
+
+	// SSEKMSKeyIDKey represents the KMS key id of SSE-KMS in the encryption secret
+	SSEKMSKeyIDKey = "sse_kms_key_id"
+
+	// CrypterKey represents the key of BR's client-side encryption in the encryption secret
+	CrypterKey = "crypter_key"
+
+	// Kube2IAMRoleAnnotation is the pod annotation of the IAM role assumed by kube2iam
+	Kube2IAMRoleAnnotation = "iam.amazonaws.com/role"
+
+	// IRSARoleAnnotation is the service account annotation of the IAM role assumed by IRSA
+	IRSARoleAnnotation = "eks.amazonaws.com/role-arn"
+
+	// WorkloadIdentityAnnotation is the service account annotation of the GCP service account bound by Workload Identity
+	WorkloadIdentityAnnotation = "iam.gke.io/gcp-service-account"
+
+	// AWSEBSCSIDriver is the name of the CSI driver of AWS EBS volumes
+	AWSEBSCSIDriver = "ebs.csi.aws.com"
+
+	// SecondaryStorageRemotePrefix is the name prefix of the rclone remotes of
+	// the secondary storages, the remote of the i-th storage is named by the
+	// prefix and i, e.g. secondary0, which is defined by the RCLONE_CONFIG_SECONDARY0_* env
+	SecondaryStorageRemotePrefix = "secondary"
+
+	// LogBackupStorageEnvPrefix is the name prefix of the env of the storage of the
+	// log backup replayed by a point-in-time restore, e.g. LOG_BACKUP_AWS_REGION
+	LogBackupStorageEnvPrefix = "LOG_BACKUP_"
 )
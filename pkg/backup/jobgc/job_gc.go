@@ -0,0 +1,208 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobgc
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	listers "github.com/pingcap/tidb-operator/pkg/client/listers/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/wait"
+	batchlisters "k8s.io/client-go/listers/batch/v1"
+)
+
+// gcInterval is the interval of deleting the finished jobs
+const gcInterval = time.Minute
+
+// JobGC deletes the finished jobs of the Backups or Restores of each namespace
+// beyond the history limit or older than the ttl, the pods of the jobs are
+// deleted with them. The jobs are kept if their owners may still sync them,
+// e.g. the backup is running or being deleted, otherwise the jobs would be
+// created again by the owners.
+type JobGC struct {
+	kind       string
+	selector   labels.Selector
+	jobLister  batchlisters.JobLister
+	jobControl controller.JobControlInterface
+	// getOwner returns the owner of the jobs, and whether the finished jobs of
+	// the owner can be deleted
+	getOwner     func(ns, name string) (runtime.Object, bool, error)
+	historyLimit int
+	ttl          time.Duration
+	now          func() time.Time
+}
+
+// NewBackupJobGC returns a JobGC of the backup and replicate jobs of the
+// Backups, the clean jobs are left to be deleted with the Backups
+func NewBackupJobGC(
+	backupLister listers.BackupLister,
+	jobLister batchlisters.JobLister,
+	jobControl controller.JobControlInterface,
+	historyLimit int,
+	ttl time.Duration,
+) *JobGC {
+	getOwner := func(ns, name string) (runtime.Object, bool, error) {
+		backup, err := backupLister.Backups(ns).Get(name)
+		if err != nil {
+			return nil, false, err
+		}
+		if backup.DeletionTimestamp != nil || backup.Spec.LogBackup != nil || v1alpha1.NeedToReplicate(backup) {
+			return backup, false, nil
+		}
+		return backup, v1alpha1.IsBackupComplete(backup) || v1alpha1.IsBackupFailed(backup), nil
+	}
+	return newJobGC(controller.BackupControllerKind.Kind, []string{label.BackupJobLabelVal, label.ReplicateJobLabelVal},
+		jobLister, jobControl, getOwner, historyLimit, ttl)
+}
+
+// NewRestoreJobGC returns a JobGC of the restore jobs of the Restores
+func NewRestoreJobGC(
+	restoreLister listers.RestoreLister,
+	jobLister batchlisters.JobLister,
+	jobControl controller.JobControlInterface,
+	historyLimit int,
+	ttl time.Duration,
+) *JobGC {
+	getOwner := func(ns, name string) (runtime.Object, bool, error) {
+		restore, err := restoreLister.Restores(ns).Get(name)
+		if err != nil {
+			return nil, false, err
+		}
+		if restore.DeletionTimestamp != nil {
+			return restore, false, nil
+		}
+		return restore, v1alpha1.IsRestoreComplete(restore) || v1alpha1.IsRestoreFailed(restore), nil
+	}
+	return newJobGC(controller.RestoreControllerKind.Kind, []string{label.RestoreJobLabelVal},
+		jobLister, jobControl, getOwner, historyLimit, ttl)
+}
+
+func newJobGC(
+	kind string,
+	components []string,
+	jobLister batchlisters.JobLister,
+	jobControl controller.JobControlInterface,
+	getOwner func(ns, name string) (runtime.Object, bool, error),
+	historyLimit int,
+	ttl time.Duration,
+) *JobGC {
+	// the restore jobs are labeled by the labels of the backup jobs as well
+	selector := labels.SelectorFromSet(labels.Set(label.NewBackup()))
+	requirement, err := labels.NewRequirement(label.ComponentLabelKey, selection.In, components)
+	if err != nil {
+		// the components are constants
+		panic(err)
+	}
+	return &JobGC{
+		kind:         kind,
+		selector:     selector.Add(*requirement),
+		jobLister:    jobLister,
+		jobControl:   jobControl,
+		getOwner:     getOwner,
+		historyLimit: historyLimit,
+		ttl:          ttl,
+		now:          time.Now,
+	}
+}
+
+// Run deletes the finished jobs periodically until stopCh is closed, it
+// returns immediately if neither the history limit nor the ttl is set
+func (gc *JobGC) Run(stopCh <-chan struct{}) {
+	if gc.historyLimit <= 0 && gc.ttl <= 0 {
+		return
+	}
+	log.Infof("start the gc of the %s jobs, history limit: %d, ttl: %s", gc.kind, gc.historyLimit, gc.ttl)
+	wait.Until(gc.gc, gcInterval, stopCh)
+}
+
+type finishedJob struct {
+	job      *batchv1.Job
+	owner    runtime.Object
+	finished time.Time
+}
+
+func (gc *JobGC) gc() {
+	jobs, err := gc.jobLister.List(gc.selector)
+	if err != nil {
+		log.Errorf("list the %s jobs failed, err: %v", gc.kind, err)
+		return
+	}
+
+	jobsByNamespace := map[string][]finishedJob{}
+	for _, job := range jobs {
+		finished, ok := getFinishedTime(job)
+		if !ok || job.DeletionTimestamp != nil {
+			continue
+		}
+		ownerRef := metav1.GetControllerOf(job)
+		if ownerRef == nil || ownerRef.Kind != gc.kind {
+			continue
+		}
+		owner, deletable, err := gc.getOwner(job.Namespace, ownerRef.Name)
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				log.Errorf("get the owner %s %s/%s of job %s failed, err: %v", gc.kind, job.Namespace, ownerRef.Name, job.Name, err)
+			}
+			// the jobs of the deleted owners are deleted by the garbage collector
+			continue
+		}
+		if !deletable {
+			continue
+		}
+		jobsByNamespace[job.Namespace] = append(jobsByNamespace[job.Namespace], finishedJob{job, owner, finished})
+	}
+
+	now := gc.now()
+	for _, jobs := range jobsByNamespace {
+		// the newest jobs are kept
+		sort.Slice(jobs, func(i, j int) bool {
+			return jobs[i].finished.After(jobs[j].finished)
+		})
+		for i, j := range jobs {
+			overLimit := gc.historyLimit > 0 && i >= gc.historyLimit
+			expired := gc.ttl > 0 && now.Sub(j.finished) > gc.ttl
+			if !overLimit && !expired {
+				continue
+			}
+			log.Infof("gc the finished %s job %s/%s, finished at %s", gc.kind, j.job.Namespace, j.job.Name, j.finished.Format(time.RFC3339))
+			if err := gc.jobControl.DeleteJob(j.owner, j.job); err != nil && !errors.IsNotFound(err) {
+				log.Errorf("gc the finished %s job %s/%s failed, err: %v", gc.kind, j.job.Namespace, j.job.Name, err)
+			}
+		}
+	}
+}
+
+// getFinishedTime returns the time at which the job succeeded or failed
+func getFinishedTime(job *batchv1.Job) (time.Time, bool) {
+	for _, c := range job.Status.Conditions {
+		if (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) && c.Status == corev1.ConditionTrue {
+			if job.Status.CompletionTime != nil {
+				return job.Status.CompletionTime.Time, true
+			}
+			return c.LastTransitionTime.Time, true
+		}
+	}
+	return time.Time{}, false
+}
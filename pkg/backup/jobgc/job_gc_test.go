@@ -0,0 +1,133 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobgc
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned/fake"
+	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+type fakeJobControl struct {
+	controller.JobControlInterface
+	deleted []string
+}
+
+func (c *fakeJobControl) DeleteJob(_ runtime.Object, job *batchv1.Job) error {
+	c.deleted = append(c.deleted, job.Name)
+	return nil
+}
+
+func TestBackupJobGC(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	now := time.Date(2021, 1, 10, 0, 0, 0, 0, time.UTC)
+	type testcase struct {
+		name         string
+		historyLimit int
+		ttl          time.Duration
+		expect       []string
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		informerFactory := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+		kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), 0)
+		backupIndexer := informerFactory.Pingcap().V1alpha1().Backups().Informer().GetIndexer()
+		jobInformer := kubeInformerFactory.Batch().V1().Jobs()
+		jobControl := &fakeJobControl{}
+
+		addBackup := func(name string, condition v1alpha1.BackupConditionType, finishedDaysAgo int, component string) {
+			backup := &v1alpha1.Backup{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+			}
+			v1alpha1.UpdateBackupCondition(&backup.Status, &v1alpha1.BackupCondition{
+				Type:   condition,
+				Status: corev1.ConditionTrue,
+			})
+			g.Expect(backupIndexer.Add(backup)).To(Succeed())
+
+			job := &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "backup-" + name,
+					Namespace:       "ns",
+					Labels:          label.NewBackup().Instance("demo").Component(component).Backup(name),
+					OwnerReferences: []metav1.OwnerReference{controller.GetBackupOwnerRef(backup)},
+				},
+			}
+			if finishedDaysAgo >= 0 {
+				completionTime := metav1.NewTime(now.AddDate(0, 0, -finishedDaysAgo))
+				job.Status.CompletionTime = &completionTime
+				job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+			}
+			g.Expect(jobInformer.Informer().GetIndexer().Add(job)).To(Succeed())
+		}
+		addBackup("complete-1", v1alpha1.BackupComplete, 1, label.BackupJobLabelVal)
+		addBackup("complete-2", v1alpha1.BackupComplete, 2, label.BackupJobLabelVal)
+		addBackup("failed-3", v1alpha1.BackupFailed, 3, label.BackupJobLabelVal)
+		addBackup("complete-4", v1alpha1.BackupComplete, 4, label.BackupJobLabelVal)
+		// the job of a running backup and the clean job are kept
+		addBackup("running", v1alpha1.BackupRunning, 5, label.BackupJobLabelVal)
+		addBackup("clean", v1alpha1.BackupComplete, 6, label.CleanJobLabelVal)
+		// the job is not finished
+		addBackup("unfinished", v1alpha1.BackupComplete, -1, label.BackupJobLabelVal)
+
+		gc := NewBackupJobGC(informerFactory.Pingcap().V1alpha1().Backups().Lister(), jobInformer.Lister(), jobControl, test.historyLimit, test.ttl)
+		gc.now = func() time.Time { return now }
+		gc.gc()
+
+		sort.Strings(jobControl.deleted)
+		g.Expect(jobControl.deleted).To(Equal(test.expect))
+	}
+	tests := []testcase{
+		{
+			name:         "history limit",
+			historyLimit: 2,
+			expect:       []string{"backup-complete-4", "backup-failed-3"},
+		},
+		{
+			name:   "ttl",
+			ttl:    50 * time.Hour,
+			expect: []string{"backup-complete-4", "backup-failed-3"},
+		},
+		{
+			name:         "history limit and ttl",
+			historyLimit: 2,
+			ttl:          30 * time.Hour,
+			expect:       []string{"backup-complete-2", "backup-complete-4", "backup-failed-3"},
+		},
+		{
+			name:         "history limit beyond the finished jobs",
+			historyLimit: 10,
+			expect:       nil,
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
@@ -15,6 +15,8 @@ package restore
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/backup"
@@ -23,23 +25,34 @@ import (
 	listers "github.com/pingcap/tidb-operator/pkg/client/listers/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/util"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	batchlisters "k8s.io/client-go/listers/batch/v1"
 	corelisters "k8s.io/client-go/listers/core/v1"
+	rbaclisters "k8s.io/client-go/listers/rbac/v1"
 )
 
 type restoreManager struct {
-	backupLister  listers.BackupLister
-	statusUpdater controller.RestoreConditionUpdaterInterface
-	secretLister  corelisters.SecretLister
-	jobLister     batchlisters.JobLister
-	jobControl    controller.JobControlInterface
-	pvcLister     corelisters.PersistentVolumeClaimLister
-	pvcControl    controller.GeneralPVCControlInterface
+	backupLister      listers.BackupLister
+	statusUpdater     controller.RestoreConditionUpdaterInterface
+	secretLister      corelisters.SecretLister
+	jobLister         batchlisters.JobLister
+	jobControl        controller.JobControlInterface
+	pvcLister         corelisters.PersistentVolumeClaimLister
+	pvcControl        controller.GeneralPVCControlInterface
+	tcLister          listers.TidbClusterLister
+	tcControl         controller.TidbClusterControlInterface
+	saLister          corelisters.ServiceAccountLister
+	saControl         controller.ServiceAccountControlInterface
+	roleLister        rbaclisters.RoleLister
+	roleBindingLister rbaclisters.RoleBindingLister
+	rbacControl       controller.RBACControlInterface
+	snapshotControl   controller.VolumeSnapshotControlInterface
 }
 
 // NewRestoreManager return restoreManager
@@ -51,6 +64,14 @@ func NewRestoreManager(
 	jobControl controller.JobControlInterface,
 	pvcLister corelisters.PersistentVolumeClaimLister,
 	pvcControl controller.GeneralPVCControlInterface,
+	tcLister listers.TidbClusterLister,
+	tcControl controller.TidbClusterControlInterface,
+	saLister corelisters.ServiceAccountLister,
+	saControl controller.ServiceAccountControlInterface,
+	roleLister rbaclisters.RoleLister,
+	roleBindingLister rbaclisters.RoleBindingLister,
+	rbacControl controller.RBACControlInterface,
+	snapshotControl controller.VolumeSnapshotControlInterface,
 ) backup.RestoreManager {
 	return &restoreManager{
 		backupLister,
@@ -60,6 +81,14 @@ func NewRestoreManager(
 		jobControl,
 		pvcLister,
 		pvcControl,
+		tcLister,
+		tcControl,
+		saLister,
+		saControl,
+		roleLister,
+		roleBindingLister,
+		rbacControl,
+		snapshotControl,
 	}
 }
 
@@ -82,6 +111,35 @@ func (rm *restoreManager) syncRestoreJob(restore *v1alpha1.Restore) error {
 		return fmt.Errorf("restore %s/%s get job %s failed, err: %v", ns, name, restoreJobName, err)
 	}
 
+	if restore.Spec.VolumeSnapshot != nil {
+		reason, err := rm.ensureVolumeSnapshotPVCs(restore)
+		if err != nil {
+			rm.statusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+				Type:    v1alpha1.RestoreFailed,
+				Status:  corev1.ConditionTrue,
+				Reason:  reason,
+				Message: err.Error(),
+			})
+			return err
+		}
+	}
+
+	if restore.Spec.CreateCluster != nil {
+		reason, err := rm.ensureTidbClusterReady(restore)
+		if err != nil {
+			if controller.IsRequeueError(err) {
+				return err
+			}
+			rm.statusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+				Type:    v1alpha1.RestoreFailed,
+				Status:  corev1.ConditionTrue,
+				Reason:  reason,
+				Message: err.Error(),
+			})
+			return err
+		}
+	}
+
 	// not found restore job, need to create it
 	backup, reason, err := rm.getBackupFromRestore(restore)
 	if err != nil {
@@ -94,7 +152,73 @@ func (rm *restoreManager) syncRestoreJob(restore *v1alpha1.Restore) error {
 		return err
 	}
 
-	job, reason, err := rm.makeRestoreJob(restore, backup)
+	reason, err = rm.validateLightningRestore(restore, backup)
+	if err != nil {
+		rm.statusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+			Type:    v1alpha1.RestoreFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  reason,
+			Message: err.Error(),
+		})
+		return err
+	}
+
+	reason, err = rm.validateBackupPVC(restore, backup)
+	if err != nil {
+		rm.statusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+			Type:    v1alpha1.RestoreFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  reason,
+			Message: err.Error(),
+		})
+		return err
+	}
+
+	reason, err = rm.validateChecksum(restore, backup)
+	if err != nil {
+		rm.statusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+			Type:    v1alpha1.RestoreFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  reason,
+			Message: err.Error(),
+		})
+		return err
+	}
+
+	reason, err = rm.validateTableFilter(restore, backup)
+	if err != nil {
+		rm.statusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+			Type:    v1alpha1.RestoreFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  reason,
+			Message: err.Error(),
+		})
+		return err
+	}
+
+	reason, err = rm.validateWarmUp(restore)
+	if err != nil {
+		rm.statusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+			Type:    v1alpha1.RestoreFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  reason,
+			Message: err.Error(),
+		})
+		return err
+	}
+
+	logBackup, reason, err := rm.getLogBackupFromRestore(restore, backup)
+	if err != nil {
+		rm.statusUpdater.Update(restore, &v1alpha1.RestoreCondition{
+			Type:    v1alpha1.RestoreFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  reason,
+			Message: err.Error(),
+		})
+		return err
+	}
+
+	job, reason, err := rm.makeRestoreJob(restore, backup, logBackup)
 	if err != nil {
 		rm.statusUpdater.Update(restore, &v1alpha1.RestoreCondition{
 			Type:    v1alpha1.RestoreFailed,
@@ -133,6 +257,46 @@ func (rm *restoreManager) syncRestoreJob(restore *v1alpha1.Restore) error {
 	})
 }
 
+// ensureTidbClusterReady creates the tidb cluster to be restored from restore's
+// createCluster template if it does not exist, and requeues the restore until
+// all the members of the cluster are ready.
+func (rm *restoreManager) ensureTidbClusterReady(restore *v1alpha1.Restore) (string, error) {
+	ns := restore.GetNamespace()
+	name := restore.GetName()
+	tcName := restore.Spec.Cluster
+
+	tc, err := rm.tcLister.TidbClusters(ns).Get(tcName)
+	if errors.IsNotFound(err) {
+		tc = &v1alpha1.TidbCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      tcName,
+				Namespace: ns,
+			},
+			Spec: *restore.Spec.CreateCluster.DeepCopy(),
+		}
+		if _, err := rm.tcControl.CreateTidbCluster(tc); err != nil {
+			return "CreateTidbClusterFailed", fmt.Errorf("restore %s/%s create tidbcluster %s failed, err: %v", ns, name, tcName, err)
+		}
+		return "", controller.RequeueErrorf("restore %s/%s tidbcluster %s is created, waiting for it to be ready", ns, name, tcName)
+	}
+	if err != nil {
+		return "GetTidbClusterFailed", fmt.Errorf("restore %s/%s get tidbcluster %s failed, err: %v", ns, name, tcName, err)
+	}
+
+	if restore.Spec.VolumeSnapshot != nil {
+		// the TiKV stores restored from the snapshots can't join PD until it
+		// is recovered by the restore job, so only PD is waited for
+		if !tc.PDAllMembersReady() {
+			return "", controller.RequeueErrorf("restore %s/%s tidbcluster %s pd is not ready yet", ns, name, tcName)
+		}
+		return "", nil
+	}
+	if !tc.PDAllMembersReady() || !tc.TiKVAllStoresReady() || !tc.TiDBAllMembersReady() {
+		return "", controller.RequeueErrorf("restore %s/%s tidbcluster %s is not ready yet", ns, name, tcName)
+	}
+	return "", nil
+}
+
 func (rm *restoreManager) getBackupFromRestore(restore *v1alpha1.Restore) (*v1alpha1.Backup, string, error) {
 	backupNs := restore.Spec.BackupNamespace
 	ns := restore.GetNamespace()
@@ -143,6 +307,14 @@ func (rm *restoreManager) getBackupFromRestore(restore *v1alpha1.Restore) (*v1al
 		errMsg := fmt.Errorf("restore %s/%s get backup %s/%s failed, err: %v", ns, name, backupNs, restore.Spec.Backup, err)
 		return nil, "BackupNotFound", errMsg
 	}
+	if restore.Spec.VolumeSnapshot != nil {
+		// the backup taken by volume snapshots has no data in the backend storage
+		reason, err := validateVolumeSnapshotRestore(restore, backup)
+		if err != nil {
+			return nil, reason, err
+		}
+		return backup, "", nil
+	}
 	if backup.Status.BackupPath == "" {
 		errMsg := fmt.Errorf("restore %s/%s backup %s/%s backupPath is empty", ns, name, backupNs, restore.Spec.Backup)
 		return nil, "BackupPathIsEmpty", errMsg
@@ -151,19 +323,507 @@ func (rm *restoreManager) getBackupFromRestore(restore *v1alpha1.Restore) (*v1al
 	return backup, "", nil
 }
 
-func (rm *restoreManager) makeRestoreJob(restore *v1alpha1.Restore, backup *v1alpha1.Backup) (*batchv1.Job, string, error) {
+// validateVolumeSnapshotRestore checks that the backup restored from the volume
+// snapshots is a complete volume snapshot backup which matches the cluster to be created
+func validateVolumeSnapshotRestore(restore *v1alpha1.Restore, backup *v1alpha1.Backup) (string, error) {
+	ns := restore.GetNamespace()
+	name := restore.GetName()
+	backupNs := backup.GetNamespace()
+
+	if restore.Spec.CreateCluster == nil {
+		return "CreateClusterIsEmpty", fmt.Errorf("restore %s/%s spec.createCluster is required by spec.volumeSnapshot", ns, name)
+	}
+	if restore.Spec.Lightning != nil || restore.Spec.PitrRestoredTs != "" {
+		return "ConflictRestoreTool", fmt.Errorf("restore %s/%s spec.volumeSnapshot can't be used with spec.lightning or spec.pitrRestoredTs", ns, name)
+	}
+	if backup.Spec.VolumeSnapshot == nil {
+		return "BackupIsNotVolumeSnapshot", fmt.Errorf("restore %s/%s backup %s/%s is not a volume snapshot backup", ns, name, backupNs, backup.GetName())
+	}
+	if !v1alpha1.IsBackupComplete(backup) || len(backup.Status.VolumeSnapshots) == 0 {
+		return "BackupNotComplete", fmt.Errorf("restore %s/%s backup %s/%s is not complete", ns, name, backupNs, backup.GetName())
+	}
+	if backup.GetVolumeSnapshotProvider() == v1alpha1.VolumeSnapshotProviderCSI && backupNs != ns {
+		return "VolumeSnapshotNotInNamespace", fmt.Errorf("restore %s/%s backup %s/%s of provider csi must be in the same namespace", ns, name, backupNs, backup.GetName())
+	}
+
+	members := map[v1alpha1.MemberType]int32{}
+	for _, snapshot := range backup.Status.VolumeSnapshots {
+		memberType, _, err := parseSnapshotPVCName(backup.Spec.Cluster, snapshot.PVCName)
+		if err != nil {
+			return "InvalidSnapshotPVCName", fmt.Errorf("restore %s/%s backup %s/%s, %v", ns, name, backupNs, backup.GetName(), err)
+		}
+		members[memberType]++
+	}
+	if replicas := restore.Spec.CreateCluster.TiKV.Replicas; replicas != members[v1alpha1.TiKVMemberType] {
+		return "TiKVReplicasMismatch", fmt.Errorf("restore %s/%s spec.createCluster.tikv.replicas %d doesn't match the %d tikv snapshots of backup %s/%s",
+			ns, name, replicas, members[v1alpha1.TiKVMemberType], backupNs, backup.GetName())
+	}
+	if restoresPDVolumes(restore, backup) {
+		if replicas := restore.Spec.CreateCluster.PD.Replicas; replicas != members[v1alpha1.PDMemberType] {
+			return "PDReplicasMismatch", fmt.Errorf("restore %s/%s spec.createCluster.pd.replicas %d doesn't match the %d pd snapshots of backup %s/%s",
+				ns, name, replicas, members[v1alpha1.PDMemberType], backupNs, backup.GetName())
+		}
+	} else if backup.Status.ClusterID == "" {
+		return "BackupClusterIDIsEmpty", fmt.Errorf("restore %s/%s backup %s/%s status.clusterID is empty, pd can't be recovered", ns, name, backupNs, backup.GetName())
+	} else if backup.Status.MaxAllocID == 0 {
+		return "BackupMaxAllocIDIsEmpty", fmt.Errorf("restore %s/%s backup %s/%s status.maxAllocID is empty, pd can't be recovered", ns, name, backupNs, backup.GetName())
+	}
+	if backup.Status.CommitTs == "" {
+		return "BackupCommitTsIsEmpty", fmt.Errorf("restore %s/%s backup %s/%s status.commitTs is empty, the data can't be truncated to it", ns, name, backupNs, backup.GetName())
+	}
+	return "", nil
+}
+
+// restoresPDVolumes returns whether the PD volumes are restored from the snapshots,
+// the members recorded in the PD data are only valid for the cluster with the
+// same name and namespace as the backed up one
+func restoresPDVolumes(restore *v1alpha1.Restore, backup *v1alpha1.Backup) bool {
+	if backup.GetNamespace() != restore.GetNamespace() || backup.Spec.Cluster != restore.Spec.Cluster {
+		return false
+	}
+	for _, snapshot := range backup.Status.VolumeSnapshots {
+		if memberType, _, err := parseSnapshotPVCName(backup.Spec.Cluster, snapshot.PVCName); err == nil && memberType == v1alpha1.PDMemberType {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSnapshotPVCName returns the member type and ordinal of the snapshotted
+// pvc created by the statefulset of the cluster, e.g. tikv-demo-tikv-0
+func parseSnapshotPVCName(tcName, pvcName string) (v1alpha1.MemberType, int32, error) {
+	for _, memberType := range []v1alpha1.MemberType{v1alpha1.PDMemberType, v1alpha1.TiKVMemberType} {
+		prefix := fmt.Sprintf("%s-%s-%s-", memberType, tcName, memberType)
+		if !strings.HasPrefix(pvcName, prefix) {
+			continue
+		}
+		ordinal, err := strconv.ParseInt(strings.TrimPrefix(pvcName, prefix), 10, 32)
+		if err != nil {
+			return "", 0, fmt.Errorf("parse ordinal of pvc %s failed, err: %v", pvcName, err)
+		}
+		return memberType, int32(ordinal), nil
+	}
+	return "", 0, fmt.Errorf("pvc %s is not a pd or tikv pvc of cluster %s", pvcName, tcName)
+}
+
+// ensureVolumeSnapshotPVCs provisions the TiKV and PD pvcs of the cluster to be
+// restored from the snapshots of the backup before the cluster is created, the
+// statefulsets of the cluster adopt the pvcs by their names
+func (rm *restoreManager) ensureVolumeSnapshotPVCs(restore *v1alpha1.Restore) (string, error) {
 	ns := restore.GetNamespace()
 	name := restore.GetName()
+	tcName := restore.Spec.Cluster
+
+	_, err := rm.tcLister.TidbClusters(ns).Get(tcName)
+	if err == nil {
+		// the cluster is only created after all the pvcs are provisioned
+		return "", nil
+	}
+	if !errors.IsNotFound(err) {
+		return "GetTidbClusterFailed", fmt.Errorf("restore %s/%s get tidbcluster %s failed, err: %v", ns, name, tcName, err)
+	}
 
-	user, password, reason, err := backuputil.GetTidbUserAndPassword(ns, name, restore.Spec.TidbSecretName, rm.secretLister)
+	backup, reason, err := rm.getBackupFromRestore(restore)
+	if err != nil {
+		return reason, err
+	}
+	restorePD := restoresPDVolumes(restore, backup)
+	for _, snapshot := range backup.Status.VolumeSnapshots {
+		memberType, ordinal, err := parseSnapshotPVCName(backup.Spec.Cluster, snapshot.PVCName)
+		if err != nil {
+			return "InvalidSnapshotPVCName", fmt.Errorf("restore %s/%s, %v", ns, name, err)
+		}
+		if memberType == v1alpha1.PDMemberType && !restorePD {
+			continue
+		}
+		pvcName := fmt.Sprintf("%s-%s-%s-%d", memberType, tcName, memberType, ordinal)
+
+		snapshotName := snapshot.SnapshotName
+		if backup.GetVolumeSnapshotProvider() == v1alpha1.VolumeSnapshotProviderAWSEBS {
+			// the EBS snapshot is imported as a pre-provisioned VolumeSnapshot
+			csiDriver := restore.Spec.VolumeSnapshot.CSIDriver
+			if csiDriver == "" {
+				csiDriver = constants.AWSEBSCSIDriver
+			}
+			snapshotName = fmt.Sprintf("%s-%s", name, pvcName)
+			if err := rm.snapshotControl.CreateVolumeSnapshotFromHandle(restore, ns, snapshotName, csiDriver, snapshot.SnapshotID); err != nil {
+				return "CreateVolumeSnapshotFailed", fmt.Errorf("restore %s/%s create volume snapshot %s of snapshot %s failed, err: %v", ns, name, snapshotName, snapshot.SnapshotID, err)
+			}
+		}
+
+		reason, err := rm.ensurePVCFromSnapshot(restore, memberType, pvcName, snapshotName)
+		if err != nil {
+			return reason, err
+		}
+	}
+	return "", nil
+}
+
+// ensurePVCFromSnapshot creates the pvc of the member provisioned from the
+// VolumeSnapshot, the pvc is not owned by the restore so that it is kept by the cluster
+func (rm *restoreManager) ensurePVCFromSnapshot(restore *v1alpha1.Restore, memberType v1alpha1.MemberType, pvcName, snapshotName string) (string, error) {
+	ns := restore.GetNamespace()
+	name := restore.GetName()
+
+	pvc, err := rm.pvcLister.PersistentVolumeClaims(ns).Get(pvcName)
+	if err == nil {
+		if pvc.Spec.DataSource == nil || pvc.Spec.DataSource.Name != snapshotName {
+			return "PVCAlreadyExists", fmt.Errorf("restore %s/%s pvc %s already exists and is not provisioned from volume snapshot %s", ns, name, pvcName, snapshotName)
+		}
+		return "", nil
+	}
+	if !errors.IsNotFound(err) {
+		return "GetPVCFailed", fmt.Errorf("restore %s/%s get pvc %s failed, err: %v", ns, name, pvcName, err)
+	}
+
+	spec := restore.Spec.CreateCluster
+	l := label.New().Instance(restore.Spec.Cluster)
+	var storageClassName string
+	var requests *v1alpha1.ResourceRequirement
+	if memberType == v1alpha1.PDMemberType {
+		l = l.PD()
+		storageClassName = spec.PD.StorageClassName
+		requests = spec.PD.Requests
+	} else {
+		l = l.TiKV()
+		storageClassName = spec.TiKV.StorageClassName
+		requests = spec.TiKV.Requests
+	}
+	if storageClassName == "" {
+		storageClassName = controller.DefaultStorageClassName
+	}
+	if requests == nil || requests.Storage == "" {
+		return "StorageSizeIsEmpty", fmt.Errorf("restore %s/%s spec.createCluster.%s.requests.storage is required by spec.volumeSnapshot", ns, name, memberType)
+	}
+	rs, err := resource.ParseQuantity(requests.Storage)
+	if err != nil {
+		return "ParseStorageSizeFailed", fmt.Errorf("restore %s/%s parse %s storage size %s failed, err: %v", ns, name, memberType, requests.Storage, err)
+	}
+
+	apiGroup := controller.VolumeSnapshotGVR.Group
+	pvc = &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pvcName,
+			Namespace: ns,
+			Labels:    l.Labels(),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: &storageClassName,
+			AccessModes: []corev1.PersistentVolumeAccessMode{
+				corev1.ReadWriteOnce,
+			},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: rs,
+				},
+			},
+			DataSource: &corev1.TypedLocalObjectReference{
+				APIGroup: &apiGroup,
+				Kind:     "VolumeSnapshot",
+				Name:     snapshotName,
+			},
+		},
+	}
+	if err := rm.pvcControl.CreatePVC(restore, pvc); err != nil {
+		return "CreatePVCFailed", fmt.Errorf("restore %s/%s create pvc %s from volume snapshot %s failed, err: %v", ns, name, pvcName, snapshotName, err)
+	}
+	return "", nil
+}
+
+// validateLightningRestore checks that the backup imported by lightning is a
+// dumpling export and the lightning backend is valid
+func (rm *restoreManager) validateLightningRestore(restore *v1alpha1.Restore, backup *v1alpha1.Backup) (string, error) {
+	ns := restore.GetNamespace()
+	name := restore.GetName()
+
+	lightning := restore.Spec.Lightning
+	if lightning == nil {
+		return "", nil
+	}
+	if backup.Spec.Dumpling == nil {
+		return "BackupIsNotDumplingExport", fmt.Errorf("restore %s/%s backup %s/%s is not a dumpling export", ns, name, backup.GetNamespace(), backup.GetName())
+	}
+	switch lightning.Backend {
+	case "", v1alpha1.LightningBackendLocal, v1alpha1.LightningBackendTiDB:
+	default:
+		return "InvalidLightningBackend", fmt.Errorf("restore %s/%s spec.lightning.backend %s is invalid, must be local or tidb", ns, name, lightning.Backend)
+	}
+	return "", nil
+}
+
+// validateBackupPVC checks that the pvc which keeps the data of a backup with
+// pvc storage can be mounted by the restore job
+func (rm *restoreManager) validateBackupPVC(restore *v1alpha1.Restore, backup *v1alpha1.Backup) (string, error) {
+	ns := restore.GetNamespace()
+	name := restore.GetName()
+
+	if backup.Spec.StorageType != v1alpha1.BackupStorageTypePVC {
+		return "", nil
+	}
+	if backup.Status.BackupPVC == "" {
+		return "BackupPVCIsEmpty", fmt.Errorf("restore %s/%s backup %s/%s status.backupPVC is empty", ns, name, backup.GetNamespace(), backup.GetName())
+	}
+	if backup.GetNamespace() != ns {
+		return "BackupPVCNotInNamespace", fmt.Errorf("restore %s/%s backup %s/%s with pvc storage must be in the same namespace", ns, name, backup.GetNamespace(), backup.GetName())
+	}
+	return "", nil
+}
+
+// validateChecksum checks that the checksum is only enabled explicitly for the
+// restore by lightning or BR, loader doesn't verify the loaded data
+func (rm *restoreManager) validateChecksum(restore *v1alpha1.Restore, backup *v1alpha1.Backup) (string, error) {
+	ns := restore.GetNamespace()
+	name := restore.GetName()
+
+	if restore.Spec.Checksum == nil || !*restore.Spec.Checksum {
+		return "", nil
+	}
+	if restore.Spec.Lightning != nil || isBRRestore(restore, backup) {
+		return "", nil
+	}
+	return "ChecksumNotSupported", fmt.Errorf("restore %s/%s spec.checksum only works with spec.lightning or a backup taken by br", ns, name)
+}
+
+// validateTableFilter checks that the table filter is only set for the restore
+// by lightning or BR, loader always loads all the tables of the backup
+func (rm *restoreManager) validateTableFilter(restore *v1alpha1.Restore, backup *v1alpha1.Backup) (string, error) {
+	ns := restore.GetNamespace()
+	name := restore.GetName()
+
+	if len(restore.Spec.TableFilter) == 0 || restore.Spec.Lightning != nil || isBRRestore(restore, backup) {
+		return "", nil
+	}
+	return "TableFilterNotSupported", fmt.Errorf("restore %s/%s spec.tableFilter only works with spec.lightning or a backup taken by br", ns, name)
+}
+
+// isBRRestore returns whether the data of the restore is restored by BR, i.e. the
+// point-in-time restore or the restore of a backup taken by BR
+func isBRRestore(restore *v1alpha1.Restore, backup *v1alpha1.Backup) bool {
+	return restore.Spec.PitrRestoredTs != "" || (backup.Spec.BR != nil && restore.Spec.VolumeSnapshot == nil)
+}
+
+// validateWarmUp checks the configs of the warm-up of the restored tables
+func (rm *restoreManager) validateWarmUp(restore *v1alpha1.Restore) (string, error) {
+	ns := restore.GetNamespace()
+	name := restore.GetName()
+
+	warmUp := restore.Spec.WarmUp
+	if warmUp == nil {
+		return "", nil
+	}
+	switch strategy := restore.GetWarmUpStrategy(); strategy {
+	case v1alpha1.RestoreWarmUpScan, v1alpha1.RestoreWarmUpAnalyze:
+	default:
+		return "InvalidWarmUpConfig", fmt.Errorf("restore %s/%s spec.warmUp.strategy %s is not supported", ns, name, strategy)
+	}
+	if warmUp.Concurrency < 0 {
+		return "InvalidWarmUpConfig", fmt.Errorf("restore %s/%s spec.warmUp.concurrency %d must not be negative", ns, name, warmUp.Concurrency)
+	}
+	if _, err := restore.GetWarmUpTimeout(); err != nil {
+		return "InvalidWarmUpConfig", fmt.Errorf("restore %s/%s spec.warmUp.timeout, %v", ns, name, err)
+	}
+	return "", nil
+}
+
+// getBackupChainPaths returns the paths of the BR backups restored in order, an
+// incremental backup is restored on top of the chain of its base backups
+func (rm *restoreManager) getBackupChainPaths(restore *v1alpha1.Restore, backup *v1alpha1.Backup) ([]string, string, error) {
+	ns := restore.GetNamespace()
+	name := restore.GetName()
+	backupNs := backup.GetNamespace()
+
+	paths := []string{backup.Status.BackupPath}
+	if backup.Spec.Type != v1alpha1.BackupTypeInc {
+		return paths, "", nil
+	}
+	backups, err := rm.backupLister.Backups(backupNs).List(labels.Everything())
+	if err != nil {
+		return nil, "ListBackupFailed", fmt.Errorf("restore %s/%s list backups in namespace %s failed, err: %v", ns, name, backupNs, err)
+	}
+	for inc := backup; inc.Spec.Type == v1alpha1.BackupTypeInc; {
+		base := backuputil.GetBaseBackup(inc, backups)
+		if base == nil {
+			return nil, "BaseBackupNotFound", fmt.Errorf("restore %s/%s can't find a complete base backup of incremental backup %s/%s with commitTs %s",
+				ns, name, backupNs, inc.GetName(), inc.Spec.BR.LastBackupTS)
+		}
+		if base.Spec.StorageType != backup.Spec.StorageType || base.Status.BackupPath == "" {
+			return nil, "BaseBackupStorageMismatch", fmt.Errorf("restore %s/%s base backup %s/%s is not in the %s storage of backup %s/%s",
+				ns, name, backupNs, base.GetName(), backup.Spec.StorageType, backupNs, backup.GetName())
+		}
+		if len(paths) > len(backups) {
+			return nil, "InvalidBackupChain", fmt.Errorf("restore %s/%s the base backups of backup %s/%s form a loop", ns, name, backupNs, backup.GetName())
+		}
+		paths = append([]string{base.Status.BackupPath}, paths...)
+		inc = base
+	}
+	return paths, "", nil
+}
+
+// getLogBackupFromRestore returns the log backup replayed by the point-in-time
+// restore, and checks that pitrRestoredTs is in the range of the available logs
+func (rm *restoreManager) getLogBackupFromRestore(restore *v1alpha1.Restore, backup *v1alpha1.Backup) (*v1alpha1.Backup, string, error) {
+	ns := restore.GetNamespace()
+	name := restore.GetName()
+	backupNs := restore.Spec.BackupNamespace
+
+	if restore.Spec.PitrRestoredTs == "" {
+		if restore.Spec.LogBackup != "" {
+			return nil, "PitrRestoredTsIsEmpty", fmt.Errorf("restore %s/%s spec.pitrRestoredTs is required by spec.logBackup", ns, name)
+		}
+		return nil, "", nil
+	}
+	if restore.Spec.Lightning != nil {
+		return nil, "ConflictRestoreTool", fmt.Errorf("restore %s/%s spec.pitrRestoredTs can't be used with spec.lightning", ns, name)
+	}
+	if backup.Spec.BR == nil {
+		return nil, "BackupIsNotBR", fmt.Errorf("restore %s/%s backup %s/%s is not taken by br", ns, name, backupNs, backup.GetName())
+	}
+	if backup.Spec.Type == v1alpha1.BackupTypeInc {
+		return nil, "BackupIsIncremental", fmt.Errorf("restore %s/%s backup %s/%s is incremental, the logs are replayed on a full backup", ns, name, backupNs, backup.GetName())
+	}
+	if restore.Spec.LogBackup == "" {
+		return nil, "LogBackupIsEmpty", fmt.Errorf("restore %s/%s spec.logBackup is required by spec.pitrRestoredTs", ns, name)
+	}
+
+	logBackup, err := rm.backupLister.Backups(backupNs).Get(restore.Spec.LogBackup)
+	if err != nil {
+		return nil, "LogBackupNotFound", fmt.Errorf("restore %s/%s get log backup %s/%s failed, err: %v", ns, name, backupNs, restore.Spec.LogBackup, err)
+	}
+	if logBackup.Spec.LogBackup == nil {
+		return nil, "BackupIsNotLogBackup", fmt.Errorf("restore %s/%s backup %s/%s is not a log backup", ns, name, backupNs, logBackup.GetName())
+	}
+	if logBackup.Status.BackupPath == "" || logBackup.Status.LogBackup == nil || logBackup.Status.LogBackup.CheckpointTs == "" {
+		return nil, "LogBackupCheckpointIsEmpty", fmt.Errorf("restore %s/%s log backup %s/%s has no checkpoint yet", ns, name, backupNs, logBackup.GetName())
+	}
+	if logBackup.Spec.StorageType != backup.Spec.StorageType {
+		return nil, "LogBackupStorageMismatch", fmt.Errorf("restore %s/%s log backup %s/%s storage type %s is different from backup storage type %s",
+			ns, name, backupNs, logBackup.GetName(), logBackup.Spec.StorageType, backup.Spec.StorageType)
+	}
+
+	restoredTs, err := strconv.ParseUint(restore.Spec.PitrRestoredTs, 10, 64)
+	if err != nil {
+		return nil, "InvalidPitrRestoredTs", fmt.Errorf("restore %s/%s parse spec.pitrRestoredTs %s failed, err: %v", ns, name, restore.Spec.PitrRestoredTs, err)
+	}
+	commitTs, err := strconv.ParseUint(backup.Status.CommitTs, 10, 64)
+	if err != nil {
+		return nil, "InvalidBackupCommitTs", fmt.Errorf("restore %s/%s parse backup %s/%s commitTs %s failed, err: %v", ns, name, backupNs, backup.GetName(), backup.Status.CommitTs, err)
+	}
+	logStartTs, checkpointTs, err := getLogBackupRange(logBackup.Status.LogBackup)
+	if err != nil {
+		return nil, "InvalidLogBackupStatus", fmt.Errorf("restore %s/%s log backup %s/%s, %v", ns, name, backupNs, logBackup.GetName(), err)
+	}
+	if commitTs < logStartTs {
+		return nil, "LogBackupNotCoverBackup", fmt.Errorf("restore %s/%s the logs of log backup %s/%s start from %d, after backup %s/%s commitTs %d",
+			ns, name, backupNs, logBackup.GetName(), logStartTs, backupNs, backup.GetName(), commitTs)
+	}
+	if restoredTs < commitTs || restoredTs > checkpointTs {
+		return nil, "PitrRestoredTsOutOfRange", fmt.Errorf("restore %s/%s spec.pitrRestoredTs %d is out of the range [%d, %d] of backup %s/%s and log backup %s/%s",
+			ns, name, restoredTs, commitTs, checkpointTs, backupNs, backup.GetName(), backupNs, logBackup.GetName())
+	}
+	return logBackup, "", nil
+}
+
+// getLogBackupRange returns the range of the logs kept in the storage, the
+// logs before truncateUntil have been removed
+func getLogBackupRange(status *v1alpha1.LogBackupStatus) (uint64, uint64, error) {
+	startTs, err := strconv.ParseUint(status.StartTs, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("parse startTs %s failed, err: %v", status.StartTs, err)
+	}
+	checkpointTs, err := strconv.ParseUint(status.CheckpointTs, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("parse checkpointTs %s failed, err: %v", status.CheckpointTs, err)
+	}
+	if status.TruncateUntil != "" {
+		truncateUntil, err := strconv.ParseUint(status.TruncateUntil, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("parse truncateUntil %s failed, err: %v", status.TruncateUntil, err)
+		}
+		if truncateUntil > startTs {
+			startTs = truncateUntil
+		}
+	}
+	return startTs, checkpointTs, nil
+}
+
+func (rm *restoreManager) makeRestoreJob(restore *v1alpha1.Restore, backup *v1alpha1.Backup, logBackup *v1alpha1.Backup) (*batchv1.Job, string, error) {
+	ns := restore.GetNamespace()
+	name := restore.GetName()
+
+	user, _, reason, err := backuputil.GetTidbUserAndPassword(ns, name, restore.Spec.TidbSecretName, rm.secretLister)
 	if err != nil {
 		return nil, reason, err
 	}
 
-	storageEnv, reason, err := backuputil.GenerateStorageCertEnv(backup, rm.secretLister)
+	storageEnv, reason, err := backuputil.GenerateStorageCertEnv(backup, ns, rm.secretLister)
+	if err != nil {
+		return nil, reason, err
+	}
+	decryptionEnv, reason, err := backuputil.GenerateDecryptionEnv(backup, ns, rm.secretLister)
 	if err != nil {
 		return nil, reason, err
 	}
+	storageEnv = append(storageEnv, decryptionEnv...)
+	storageVolumes, storageVolumeMounts := backuputil.GenerateStorageCAVolume(backup)
+	pvcVolumes, pvcVolumeMounts := backuputil.GenerateBackupPVCVolume(backup)
+	storageVolumes = append(storageVolumes, pvcVolumes...)
+	storageVolumeMounts = append(storageVolumeMounts, pvcVolumeMounts...)
+	if logBackup != nil {
+		// the log backup may be kept in another storage than the backup
+		logBackupEnv, reason, err := backuputil.GenerateLogBackupStorageEnv(logBackup, ns, rm.secretLister)
+		if err != nil {
+			return nil, reason, err
+		}
+		storageEnv = append(storageEnv, logBackupEnv...)
+		storageEnv = append(storageEnv, corev1.EnvVar{
+			Name:  "LOG_BACKUP_PATH",
+			Value: logBackup.Status.BackupPath,
+		})
+	}
+	if backup.Spec.BR != nil && restore.Spec.PitrRestoredTs == "" && restore.Spec.VolumeSnapshot == nil {
+		paths, reason, err := rm.getBackupChainPaths(restore, backup)
+		if err != nil {
+			return nil, reason, err
+		}
+		storageEnv = append(storageEnv, corev1.EnvVar{
+			Name:  "BR_BACKUP_PATHS",
+			Value: strings.Join(paths, ","),
+		})
+	}
+	if restore.Spec.VolumeSnapshot != nil {
+		// the data written after the commitTs is discarded from the restored TiKV stores
+		storageEnv = append(storageEnv, corev1.EnvVar{
+			Name:  "BACKUP_COMMIT_TS",
+			Value: backup.Status.CommitTs,
+		})
+		// the job calls PD and TiKV, which requires the client certificate if
+		// TLS is enabled in the cluster
+		tlsVolume, tlsVolumeMount := backuputil.GenerateClusterClientTLSVolume()
+		storageVolumes = append(storageVolumes, tlsVolume)
+		storageVolumeMounts = append(storageVolumeMounts, tlsVolumeMount)
+	}
+	if restore.Spec.VolumeSnapshot != nil && !restoresPDVolumes(restore, backup) {
+		// PD is started with empty volumes, the restore job recovers it with
+		// the cluster id of the backup so that the restored TiKV stores can join,
+		// and the ids allocated by it must be larger than the ones in the snapshots
+		storageEnv = append(storageEnv, corev1.EnvVar{
+			Name:  "PD_RECOVER_CLUSTER_ID",
+			Value: backup.Status.ClusterID,
+		}, corev1.EnvVar{
+			Name:  "PD_RECOVER_MAX_ALLOC_ID",
+			Value: strconv.FormatUint(backup.Status.MaxAllocID, 10),
+		})
+	}
+
+	serviceAccount := backuputil.GetServiceAccountName(restore.Spec.ServiceAccount, restore.Spec.VolumeSnapshot != nil)
+	if backuputil.IsDefaultServiceAccount(serviceAccount) {
+		reason, err = backuputil.EnsureDefaultServiceAccount(restore, backup, ns, serviceAccount, rm.saLister, rm.saControl, rm.roleLister, rm.roleBindingLister, rm.rbacControl)
+	} else {
+		reason, err = backuputil.CheckServiceAccountAnnotations(backup, ns, serviceAccount, rm.saLister)
+	}
+	if err != nil {
+		return nil, reason, fmt.Errorf("restore %s/%s, %v", ns, name, err)
+	}
 
 	args := []string{
 		"restore",
@@ -173,33 +833,39 @@ func (rm *restoreManager) makeRestoreJob(restore *v1alpha1.Restore, backup *v1al
 		fmt.Sprintf("--backupPath=%s", backup.Status.BackupPath),
 		fmt.Sprintf("--backupName=%s", backup.GetName()),
 		fmt.Sprintf("--tidbservice=%s", controller.TiDBMemberName(restore.Spec.Cluster)),
-		fmt.Sprintf("--password=%s", password),
 		fmt.Sprintf("--user=%s", user),
 	}
 
 	restoreLabel := label.NewBackup().Instance(restore.Spec.Cluster).RestoreJob().Restore(name)
 
-	// TODO: need add ResourceRequirement for restore job
 	podSpec := &corev1.PodTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
-			Labels: restoreLabel.Labels(),
+			Labels:      restoreLabel.Labels(),
+			Annotations: backuputil.GenerateStoragePodAnnotations(backup),
 		},
 		Spec: corev1.PodSpec{
-			ServiceAccountName: constants.DefaultServiceAccountName,
+			ServiceAccountName: serviceAccount,
 			Containers: []corev1.Container{
 				{
 					Name:            label.RestoreJobLabelVal,
 					Image:           controller.TidbBackupManagerImage,
 					Args:            args,
 					ImagePullPolicy: corev1.PullAlways,
-					VolumeMounts: []corev1.VolumeMount{
+					Resources: util.ResourceRequirement(v1alpha1.ContainerSpec{
+						Requests: restore.Spec.Requests,
+						Limits:   restore.Spec.Limits,
+					}),
+					VolumeMounts: append([]corev1.VolumeMount{
 						{Name: label.RestoreJobLabelVal, MountPath: constants.BackupRootPath},
-					},
-					Env: storageEnv,
+					}, storageVolumeMounts...),
+					Env: append(storageEnv, backuputil.GenerateTidbPasswordEnv(restore.Spec.TidbSecretName)),
 				},
 			},
 			RestartPolicy: corev1.RestartPolicyNever,
-			Volumes: []corev1.Volume{
+			Affinity:      restore.Spec.Affinity,
+			NodeSelector:  restore.Spec.NodeSelector,
+			Tolerations:   restore.Spec.Tolerations,
+			Volumes: append([]corev1.Volume{
 				{
 					Name: label.RestoreJobLabelVal,
 					VolumeSource: corev1.VolumeSource{
@@ -208,7 +874,7 @@ func (rm *restoreManager) makeRestoreJob(restore *v1alpha1.Restore, backup *v1al
 						},
 					},
 				},
-			},
+			}, storageVolumes...),
 		},
 	}
 
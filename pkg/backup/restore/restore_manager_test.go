@@ -0,0 +1,864 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restore
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned/fake"
+	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidateVolumeSnapshotRestore(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name         string
+		update       func(restore *v1alpha1.Restore, backup *v1alpha1.Backup)
+		expectReason string
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		restore := newVolumeSnapshotRestore()
+		backup := newVolumeSnapshotBackup()
+		if test.update != nil {
+			test.update(restore, backup)
+		}
+		reason, err := validateVolumeSnapshotRestore(restore, backup)
+		g.Expect(reason).To(Equal(test.expectReason))
+		if test.expectReason == "" {
+			g.Expect(err).NotTo(HaveOccurred())
+		} else {
+			g.Expect(err).To(HaveOccurred())
+		}
+	}
+
+	tests := []testcase{
+		{
+			name: "restore pd and tikv volumes",
+		},
+		{
+			name: "recover pd of another cluster",
+			update: func(restore *v1alpha1.Restore, backup *v1alpha1.Backup) {
+				restore.Spec.Cluster = "demo2"
+				restore.Spec.CreateCluster.PD.Replicas = 3
+			},
+		},
+		{
+			name: "createCluster is empty",
+			update: func(restore *v1alpha1.Restore, backup *v1alpha1.Backup) {
+				restore.Spec.CreateCluster = nil
+			},
+			expectReason: "CreateClusterIsEmpty",
+		},
+		{
+			name: "conflict with point-in-time restore",
+			update: func(restore *v1alpha1.Restore, backup *v1alpha1.Backup) {
+				restore.Spec.PitrRestoredTs = "400"
+			},
+			expectReason: "ConflictRestoreTool",
+		},
+		{
+			name: "backup is not a volume snapshot backup",
+			update: func(restore *v1alpha1.Restore, backup *v1alpha1.Backup) {
+				backup.Spec.VolumeSnapshot = nil
+			},
+			expectReason: "BackupIsNotVolumeSnapshot",
+		},
+		{
+			name: "backup is not complete",
+			update: func(restore *v1alpha1.Restore, backup *v1alpha1.Backup) {
+				backup.Status.Conditions = nil
+			},
+			expectReason: "BackupNotComplete",
+		},
+		{
+			name: "csi snapshots in another namespace",
+			update: func(restore *v1alpha1.Restore, backup *v1alpha1.Backup) {
+				backup.Namespace = "ns2"
+				restore.Spec.BackupNamespace = "ns2"
+			},
+			expectReason: "VolumeSnapshotNotInNamespace",
+		},
+		{
+			name: "invalid snapshot pvc name",
+			update: func(restore *v1alpha1.Restore, backup *v1alpha1.Backup) {
+				backup.Status.VolumeSnapshots[0].PVCName = "data-demo-tikv-0"
+			},
+			expectReason: "InvalidSnapshotPVCName",
+		},
+		{
+			name: "tikv replicas mismatch",
+			update: func(restore *v1alpha1.Restore, backup *v1alpha1.Backup) {
+				restore.Spec.CreateCluster.TiKV.Replicas = 4
+			},
+			expectReason: "TiKVReplicasMismatch",
+		},
+		{
+			name: "pd replicas mismatch",
+			update: func(restore *v1alpha1.Restore, backup *v1alpha1.Backup) {
+				restore.Spec.CreateCluster.PD.Replicas = 3
+			},
+			expectReason: "PDReplicasMismatch",
+		},
+		{
+			name: "cluster id is empty",
+			update: func(restore *v1alpha1.Restore, backup *v1alpha1.Backup) {
+				restore.Spec.Cluster = "demo2"
+				backup.Status.ClusterID = ""
+			},
+			expectReason: "BackupClusterIDIsEmpty",
+		},
+		{
+			name: "max allocated id is empty",
+			update: func(restore *v1alpha1.Restore, backup *v1alpha1.Backup) {
+				restore.Spec.Cluster = "demo2"
+				backup.Status.MaxAllocID = 0
+			},
+			expectReason: "BackupMaxAllocIDIsEmpty",
+		},
+		{
+			name: "commitTs is empty",
+			update: func(restore *v1alpha1.Restore, backup *v1alpha1.Backup) {
+				backup.Status.CommitTs = ""
+			},
+			expectReason: "BackupCommitTsIsEmpty",
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func TestEnsureVolumeSnapshotPVCs(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name            string
+		update          func(restore *v1alpha1.Restore, backup *v1alpha1.Backup)
+		tcExists        bool
+		existingPVC     *corev1.PersistentVolumeClaim
+		expectReason    string
+		expectPVCs      map[string]string
+		expectSnapshots map[string]string
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		restore := newVolumeSnapshotRestore()
+		backup := newVolumeSnapshotBackup()
+		if test.update != nil {
+			test.update(restore, backup)
+		}
+
+		informerFactory := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+		kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), 0)
+		backupInformer := informerFactory.Pingcap().V1alpha1().Backups()
+		tcInformer := informerFactory.Pingcap().V1alpha1().TidbClusters()
+		pvcInformer := kubeInformerFactory.Core().V1().PersistentVolumeClaims()
+		g.Expect(backupInformer.Informer().GetIndexer().Add(backup)).To(Succeed())
+		if test.tcExists {
+			tc := &v1alpha1.TidbCluster{ObjectMeta: metav1.ObjectMeta{Name: restore.Spec.Cluster, Namespace: restore.Namespace}}
+			g.Expect(tcInformer.Informer().GetIndexer().Add(tc)).To(Succeed())
+		}
+		if test.existingPVC != nil {
+			g.Expect(pvcInformer.Informer().GetIndexer().Add(test.existingPVC)).To(Succeed())
+		}
+		snapshotControl := controller.NewFakeVolumeSnapshotControl()
+		rm := &restoreManager{
+			backupLister:    backupInformer.Lister(),
+			pvcLister:       pvcInformer.Lister(),
+			pvcControl:      controller.NewFakeGeneralPVCControl(pvcInformer),
+			tcLister:        tcInformer.Lister(),
+			snapshotControl: snapshotControl,
+		}
+
+		reason, err := rm.ensureVolumeSnapshotPVCs(restore)
+		g.Expect(reason).To(Equal(test.expectReason))
+		if test.expectReason != "" {
+			g.Expect(err).To(HaveOccurred())
+			return
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+
+		pvcs, err := pvcInformer.Lister().PersistentVolumeClaims(restore.Namespace).List(labels.Everything())
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(pvcs).To(HaveLen(len(test.expectPVCs)))
+		for _, pvc := range pvcs {
+			g.Expect(test.expectPVCs).To(HaveKey(pvc.Name))
+			g.Expect(pvc.Spec.DataSource).NotTo(BeNil())
+			g.Expect(pvc.Spec.DataSource.Kind).To(Equal("VolumeSnapshot"))
+			g.Expect(pvc.Spec.DataSource.Name).To(Equal(test.expectPVCs[pvc.Name]))
+		}
+		g.Expect(snapshotControl.Snapshots).To(Equal(test.expectSnapshots))
+	}
+
+	tests := []testcase{
+		{
+			name: "provision pd and tikv pvcs from the csi snapshots",
+			expectPVCs: map[string]string{
+				"pd-demo-pd-0":     "backup-pd-demo-pd-0",
+				"tikv-demo-tikv-0": "backup-tikv-demo-tikv-0",
+				"tikv-demo-tikv-1": "backup-tikv-demo-tikv-1",
+				"tikv-demo-tikv-2": "backup-tikv-demo-tikv-2",
+			},
+			expectSnapshots: map[string]string{},
+		},
+		{
+			name: "provision tikv pvcs of another cluster from the ebs snapshots",
+			update: func(restore *v1alpha1.Restore, backup *v1alpha1.Backup) {
+				restore.Spec.Cluster = "demo2"
+				backup.Spec.VolumeSnapshot = &v1alpha1.VolumeSnapshotConfig{Provider: v1alpha1.VolumeSnapshotProviderAWSEBS}
+			},
+			expectPVCs: map[string]string{
+				"tikv-demo2-tikv-0": "restore-tikv-demo2-tikv-0",
+				"tikv-demo2-tikv-1": "restore-tikv-demo2-tikv-1",
+				"tikv-demo2-tikv-2": "restore-tikv-demo2-tikv-2",
+			},
+			expectSnapshots: map[string]string{
+				"ns/restore-tikv-demo2-tikv-0": "snap-tikv-0",
+				"ns/restore-tikv-demo2-tikv-1": "snap-tikv-1",
+				"ns/restore-tikv-demo2-tikv-2": "snap-tikv-2",
+			},
+		},
+		{
+			name:            "the cluster has been created",
+			tcExists:        true,
+			expectPVCs:      map[string]string{},
+			expectSnapshots: map[string]string{},
+		},
+		{
+			name: "pvc exists and is not provisioned from the snapshot",
+			existingPVC: &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "pd-demo-pd-0", Namespace: "ns"},
+			},
+			expectReason: "PVCAlreadyExists",
+		},
+		{
+			name: "storage size is empty",
+			update: func(restore *v1alpha1.Restore, backup *v1alpha1.Backup) {
+				restore.Spec.CreateCluster.TiKV.Requests = nil
+			},
+			expectReason: "StorageSizeIsEmpty",
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func TestGetBackupChainPaths(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name         string
+		target       string
+		update       func(backups map[string]*v1alpha1.Backup)
+		expectReason string
+		expectPaths  []string
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		backups := map[string]*v1alpha1.Backup{
+			"full":  newBRBackup("full", "100", ""),
+			"inc-1": newBRBackup("inc-1", "200", "100"),
+			"inc-2": newBRBackup("inc-2", "300", "200"),
+		}
+		if test.update != nil {
+			test.update(backups)
+		}
+		backupInformer := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0).Pingcap().V1alpha1().Backups()
+		for _, backup := range backups {
+			g.Expect(backupInformer.Informer().GetIndexer().Add(backup)).To(Succeed())
+		}
+		rm := &restoreManager{backupLister: backupInformer.Lister()}
+		restore := &v1alpha1.Restore{ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "ns"}}
+
+		paths, reason, err := rm.getBackupChainPaths(restore, backups[test.target])
+		g.Expect(reason).To(Equal(test.expectReason))
+		if test.expectReason != "" {
+			g.Expect(err).To(HaveOccurred())
+			return
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(paths).To(Equal(test.expectPaths))
+	}
+
+	tests := []testcase{
+		{
+			name:        "full backup",
+			target:      "full",
+			expectPaths: []string{"s3://bucket/full"},
+		},
+		{
+			name:        "incremental backups are restored after their base",
+			target:      "inc-2",
+			expectPaths: []string{"s3://bucket/full", "s3://bucket/inc-1", "s3://bucket/inc-2"},
+		},
+		{
+			name:   "base backup is not complete",
+			target: "inc-2",
+			update: func(backups map[string]*v1alpha1.Backup) {
+				backups["full"].Status.Conditions = nil
+			},
+			expectReason: "BaseBackupNotFound",
+		},
+		{
+			name:   "base backup is in another storage",
+			target: "inc-2",
+			update: func(backups map[string]*v1alpha1.Backup) {
+				backups["inc-1"].Spec.StorageType = v1alpha1.BackupStorageTypeGCS
+			},
+			expectReason: "BaseBackupStorageMismatch",
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func TestGetLogBackupFromRestore(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name         string
+		update       func(restore *v1alpha1.Restore, backup, logBackup *v1alpha1.Backup)
+		expectReason string
+		expectLog    bool
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		backup := newBRBackup("full", "200", "")
+		logBackup := newBRBackup("log", "", "")
+		logBackup.Spec.LogBackup = &v1alpha1.LogBackupConfig{}
+		logBackup.Status.LogBackup = &v1alpha1.LogBackupStatus{StartTs: "100", CheckpointTs: "500"}
+		restore := &v1alpha1.Restore{
+			ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "ns"},
+			Spec: v1alpha1.RestoreSpec{
+				Backup:          "full",
+				BackupNamespace: "ns",
+				LogBackup:       "log",
+				PitrRestoredTs:  "300",
+			},
+		}
+		if test.update != nil {
+			test.update(restore, backup, logBackup)
+		}
+		backupInformer := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0).Pingcap().V1alpha1().Backups()
+		g.Expect(backupInformer.Informer().GetIndexer().Add(logBackup)).To(Succeed())
+		rm := &restoreManager{backupLister: backupInformer.Lister()}
+
+		got, reason, err := rm.getLogBackupFromRestore(restore, backup)
+		g.Expect(reason).To(Equal(test.expectReason))
+		if test.expectReason != "" {
+			g.Expect(err).To(HaveOccurred())
+			g.Expect(got).To(BeNil())
+			return
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+		if test.expectLog {
+			g.Expect(got).NotTo(BeNil())
+			g.Expect(got.GetName()).To(Equal("log"))
+		} else {
+			g.Expect(got).To(BeNil())
+		}
+	}
+
+	tests := []testcase{
+		{
+			name:      "restore to a ts covered by the logs",
+			expectLog: true,
+		},
+		{
+			name: "restore to the checkpointTs",
+			update: func(restore *v1alpha1.Restore, backup, logBackup *v1alpha1.Backup) {
+				restore.Spec.PitrRestoredTs = "500"
+			},
+			expectLog: true,
+		},
+		{
+			name: "not a point-in-time restore",
+			update: func(restore *v1alpha1.Restore, backup, logBackup *v1alpha1.Backup) {
+				restore.Spec.PitrRestoredTs = ""
+				restore.Spec.LogBackup = ""
+			},
+		},
+		{
+			name:         "logBackup without pitrRestoredTs",
+			update:       func(restore *v1alpha1.Restore, backup, logBackup *v1alpha1.Backup) { restore.Spec.PitrRestoredTs = "" },
+			expectReason: "PitrRestoredTsIsEmpty",
+		},
+		{
+			name: "conflict with lightning",
+			update: func(restore *v1alpha1.Restore, backup, logBackup *v1alpha1.Backup) {
+				restore.Spec.Lightning = &v1alpha1.LightningConfig{}
+			},
+			expectReason: "ConflictRestoreTool",
+		},
+		{
+			name:         "backup is not taken by br",
+			update:       func(restore *v1alpha1.Restore, backup, logBackup *v1alpha1.Backup) { backup.Spec.BR = nil },
+			expectReason: "BackupIsNotBR",
+		},
+		{
+			name: "backup is incremental",
+			update: func(restore *v1alpha1.Restore, backup, logBackup *v1alpha1.Backup) {
+				backup.Spec.Type = v1alpha1.BackupTypeInc
+			},
+			expectReason: "BackupIsIncremental",
+		},
+		{
+			name:         "pitrRestoredTs without logBackup",
+			update:       func(restore *v1alpha1.Restore, backup, logBackup *v1alpha1.Backup) { restore.Spec.LogBackup = "" },
+			expectReason: "LogBackupIsEmpty",
+		},
+		{
+			name: "log backup not found",
+			update: func(restore *v1alpha1.Restore, backup, logBackup *v1alpha1.Backup) {
+				restore.Spec.LogBackup = "missing"
+			},
+			expectReason: "LogBackupNotFound",
+		},
+		{
+			name:         "not a log backup",
+			update:       func(restore *v1alpha1.Restore, backup, logBackup *v1alpha1.Backup) { logBackup.Spec.LogBackup = nil },
+			expectReason: "BackupIsNotLogBackup",
+		},
+		{
+			name: "log backup has no checkpoint",
+			update: func(restore *v1alpha1.Restore, backup, logBackup *v1alpha1.Backup) {
+				logBackup.Status.LogBackup.CheckpointTs = ""
+			},
+			expectReason: "LogBackupCheckpointIsEmpty",
+		},
+		{
+			name: "log backup in another storage type",
+			update: func(restore *v1alpha1.Restore, backup, logBackup *v1alpha1.Backup) {
+				logBackup.Spec.StorageType = v1alpha1.BackupStorageTypeGCS
+			},
+			expectReason: "LogBackupStorageMismatch",
+		},
+		{
+			name: "invalid pitrRestoredTs",
+			update: func(restore *v1alpha1.Restore, backup, logBackup *v1alpha1.Backup) {
+				restore.Spec.PitrRestoredTs = "now"
+			},
+			expectReason: "InvalidPitrRestoredTs",
+		},
+		{
+			name:         "invalid backup commitTs",
+			update:       func(restore *v1alpha1.Restore, backup, logBackup *v1alpha1.Backup) { backup.Status.CommitTs = "" },
+			expectReason: "InvalidBackupCommitTs",
+		},
+		{
+			name: "invalid log backup status",
+			update: func(restore *v1alpha1.Restore, backup, logBackup *v1alpha1.Backup) {
+				logBackup.Status.LogBackup.StartTs = ""
+			},
+			expectReason: "InvalidLogBackupStatus",
+		},
+		{
+			name: "logs start after the backup",
+			update: func(restore *v1alpha1.Restore, backup, logBackup *v1alpha1.Backup) {
+				logBackup.Status.LogBackup.StartTs = "250"
+			},
+			expectReason: "LogBackupNotCoverBackup",
+		},
+		{
+			name: "logs truncated after the backup",
+			update: func(restore *v1alpha1.Restore, backup, logBackup *v1alpha1.Backup) {
+				logBackup.Status.LogBackup.TruncateUntil = "250"
+			},
+			expectReason: "LogBackupNotCoverBackup",
+		},
+		{
+			name: "pitrRestoredTs before the backup",
+			update: func(restore *v1alpha1.Restore, backup, logBackup *v1alpha1.Backup) {
+				restore.Spec.PitrRestoredTs = "150"
+			},
+			expectReason: "PitrRestoredTsOutOfRange",
+		},
+		{
+			name: "pitrRestoredTs after the checkpoint",
+			update: func(restore *v1alpha1.Restore, backup, logBackup *v1alpha1.Backup) {
+				restore.Spec.PitrRestoredTs = "600"
+			},
+			expectReason: "PitrRestoredTsOutOfRange",
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func TestGetLogBackupRange(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name               string
+		status             v1alpha1.LogBackupStatus
+		expectErr          bool
+		expectStartTs      uint64
+		expectCheckpointTs uint64
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		startTs, checkpointTs, err := getLogBackupRange(&test.status)
+		if test.expectErr {
+			g.Expect(err).To(HaveOccurred())
+			return
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(startTs).To(Equal(test.expectStartTs))
+		g.Expect(checkpointTs).To(Equal(test.expectCheckpointTs))
+	}
+
+	tests := []testcase{
+		{
+			name:               "not truncated",
+			status:             v1alpha1.LogBackupStatus{StartTs: "100", CheckpointTs: "500"},
+			expectStartTs:      100,
+			expectCheckpointTs: 500,
+		},
+		{
+			name:               "truncated after the startTs",
+			status:             v1alpha1.LogBackupStatus{StartTs: "100", CheckpointTs: "500", TruncateUntil: "300"},
+			expectStartTs:      300,
+			expectCheckpointTs: 500,
+		},
+		{
+			name:               "truncated before the startTs",
+			status:             v1alpha1.LogBackupStatus{StartTs: "100", CheckpointTs: "500", TruncateUntil: "50"},
+			expectStartTs:      100,
+			expectCheckpointTs: 500,
+		},
+		{
+			name:      "invalid startTs",
+			status:    v1alpha1.LogBackupStatus{StartTs: "start", CheckpointTs: "500"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid checkpointTs",
+			status:    v1alpha1.LogBackupStatus{StartTs: "100"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid truncateUntil",
+			status:    v1alpha1.LogBackupStatus{StartTs: "100", CheckpointTs: "500", TruncateUntil: "-1"},
+			expectErr: true,
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func TestValidateBackupPVC(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name         string
+		update       func(backup *v1alpha1.Backup)
+		expectReason string
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		restore := &v1alpha1.Restore{
+			ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "ns"},
+		}
+		backup := &v1alpha1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "ns"},
+			Spec:       v1alpha1.BackupSpec{StorageType: v1alpha1.BackupStorageTypePVC},
+			Status:     v1alpha1.BackupStatus{BackupPVC: "backup-pvc-backup"},
+		}
+		if test.update != nil {
+			test.update(backup)
+		}
+		rm := &restoreManager{}
+		reason, err := rm.validateBackupPVC(restore, backup)
+		g.Expect(reason).To(Equal(test.expectReason))
+		if test.expectReason == "" {
+			g.Expect(err).NotTo(HaveOccurred())
+		} else {
+			g.Expect(err).To(HaveOccurred())
+		}
+	}
+
+	tests := []testcase{
+		{
+			name: "backup pvc in the namespace of the restore",
+		},
+		{
+			name:         "backup pvc is empty",
+			update:       func(backup *v1alpha1.Backup) { backup.Status.BackupPVC = "" },
+			expectReason: "BackupPVCIsEmpty",
+		},
+		{
+			name:         "backup pvc in another namespace",
+			update:       func(backup *v1alpha1.Backup) { backup.Namespace = "other" },
+			expectReason: "BackupPVCNotInNamespace",
+		},
+		{
+			name: "backup with s3 storage",
+			update: func(backup *v1alpha1.Backup) {
+				backup.Namespace = "other"
+				backup.Spec.StorageType = v1alpha1.BackupStorageTypeS3
+				backup.Status.BackupPVC = ""
+			},
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func TestValidateTableFilter(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name         string
+		update       func(restore *v1alpha1.Restore, backup *v1alpha1.Backup)
+		expectReason string
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		restore := &v1alpha1.Restore{
+			ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "ns"},
+			Spec:       v1alpha1.RestoreSpec{TableFilter: []string{"db1.*"}},
+		}
+		backup := newBRBackup("backup", "400", "")
+		if test.update != nil {
+			test.update(restore, backup)
+		}
+		rm := &restoreManager{}
+		reason, err := rm.validateTableFilter(restore, backup)
+		g.Expect(reason).To(Equal(test.expectReason))
+		if test.expectReason == "" {
+			g.Expect(err).NotTo(HaveOccurred())
+		} else {
+			g.Expect(err).To(HaveOccurred())
+		}
+	}
+
+	tests := []testcase{
+		{
+			name: "restore of a br backup",
+		},
+		{
+			name: "restore of an incremental br backup",
+			update: func(restore *v1alpha1.Restore, backup *v1alpha1.Backup) {
+				backup.Spec.Type = v1alpha1.BackupTypeInc
+				backup.Spec.BR.LastBackupTS = "300"
+			},
+		},
+		{
+			name: "point-in-time restore",
+			update: func(restore *v1alpha1.Restore, backup *v1alpha1.Backup) {
+				restore.Spec.PitrRestoredTs = "500"
+			},
+		},
+		{
+			name: "restore by lightning",
+			update: func(restore *v1alpha1.Restore, backup *v1alpha1.Backup) {
+				backup.Spec.BR = nil
+				restore.Spec.Lightning = &v1alpha1.LightningConfig{}
+			},
+		},
+		{
+			name: "restore by loader",
+			update: func(restore *v1alpha1.Restore, backup *v1alpha1.Backup) {
+				backup.Spec.BR = nil
+			},
+			expectReason: "TableFilterNotSupported",
+		},
+		{
+			name: "restore by loader without table filter",
+			update: func(restore *v1alpha1.Restore, backup *v1alpha1.Backup) {
+				backup.Spec.BR = nil
+				restore.Spec.TableFilter = nil
+			},
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func TestValidateChecksum(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name         string
+		update       func(restore *v1alpha1.Restore, backup *v1alpha1.Backup)
+		expectReason string
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		checksum := true
+		restore := &v1alpha1.Restore{
+			ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "ns"},
+			Spec:       v1alpha1.RestoreSpec{Checksum: &checksum},
+		}
+		backup := newBRBackup("backup", "400", "")
+		if test.update != nil {
+			test.update(restore, backup)
+		}
+		rm := &restoreManager{}
+		reason, err := rm.validateChecksum(restore, backup)
+		g.Expect(reason).To(Equal(test.expectReason))
+		if test.expectReason == "" {
+			g.Expect(err).NotTo(HaveOccurred())
+		} else {
+			g.Expect(err).To(HaveOccurred())
+		}
+	}
+
+	tests := []testcase{
+		{
+			name: "restore of a br backup",
+		},
+		{
+			name: "point-in-time restore",
+			update: func(restore *v1alpha1.Restore, backup *v1alpha1.Backup) {
+				restore.Spec.PitrRestoredTs = "500"
+			},
+		},
+		{
+			name: "restore by lightning",
+			update: func(restore *v1alpha1.Restore, backup *v1alpha1.Backup) {
+				backup.Spec.BR = nil
+				restore.Spec.Lightning = &v1alpha1.LightningConfig{}
+			},
+		},
+		{
+			name: "restore by loader",
+			update: func(restore *v1alpha1.Restore, backup *v1alpha1.Backup) {
+				backup.Spec.BR = nil
+			},
+			expectReason: "ChecksumNotSupported",
+		},
+		{
+			name: "checksum is disabled for the restore by loader",
+			update: func(restore *v1alpha1.Restore, backup *v1alpha1.Backup) {
+				backup.Spec.BR = nil
+				checksum := false
+				restore.Spec.Checksum = &checksum
+			},
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+// newBRBackup returns a complete BR backup, which is incremental if lastBackupTS is set
+func newBRBackup(name, commitTs, lastBackupTS string) *v1alpha1.Backup {
+	backup := &v1alpha1.Backup{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+		Spec: v1alpha1.BackupSpec{
+			Cluster:     "demo",
+			Type:        v1alpha1.BackupTypeFull,
+			StorageType: v1alpha1.BackupStorageTypeS3,
+			BR:          &v1alpha1.BRConfig{},
+		},
+		Status: v1alpha1.BackupStatus{
+			BackupPath: "s3://bucket/" + name,
+			CommitTs:   commitTs,
+			Conditions: []v1alpha1.BackupCondition{
+				{Type: v1alpha1.BackupComplete, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+	if lastBackupTS != "" {
+		backup.Spec.Type = v1alpha1.BackupTypeInc
+		backup.Spec.BR.LastBackupTS = lastBackupTS
+	}
+	return backup
+}
+
+func newVolumeSnapshotBackup() *v1alpha1.Backup {
+	return &v1alpha1.Backup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "ns"},
+		Spec: v1alpha1.BackupSpec{
+			Cluster:     "demo",
+			StorageType: v1alpha1.BackupStorageTypeS3,
+			VolumeSnapshot: &v1alpha1.VolumeSnapshotConfig{
+				VolumeSnapshotClassName: "csi-snapclass",
+			},
+		},
+		Status: v1alpha1.BackupStatus{
+			CommitTs:   "400",
+			ClusterID:  "6789",
+			MaxAllocID: 1000,
+			VolumeSnapshots: []v1alpha1.VolumeSnapshotStatus{
+				{PVCName: "pd-demo-pd-0", SnapshotName: "backup-pd-demo-pd-0", SnapshotID: "snap-pd-0"},
+				{PVCName: "tikv-demo-tikv-0", SnapshotName: "backup-tikv-demo-tikv-0", SnapshotID: "snap-tikv-0"},
+				{PVCName: "tikv-demo-tikv-1", SnapshotName: "backup-tikv-demo-tikv-1", SnapshotID: "snap-tikv-1"},
+				{PVCName: "tikv-demo-tikv-2", SnapshotName: "backup-tikv-demo-tikv-2", SnapshotID: "snap-tikv-2"},
+			},
+			Conditions: []v1alpha1.BackupCondition{
+				{Type: v1alpha1.BackupComplete, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+}
+
+func newVolumeSnapshotRestore() *v1alpha1.Restore {
+	return &v1alpha1.Restore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "ns"},
+		Spec: v1alpha1.RestoreSpec{
+			Cluster:         "demo",
+			Backup:          "backup",
+			BackupNamespace: "ns",
+			VolumeSnapshot:  &v1alpha1.VolumeSnapshotRestoreConfig{},
+			CreateCluster: &v1alpha1.TidbClusterSpec{
+				PD: v1alpha1.PDSpec{
+					ContainerSpec: v1alpha1.ContainerSpec{
+						Requests: &v1alpha1.ResourceRequirement{Storage: "10Gi"},
+					},
+					Replicas: 1,
+				},
+				TiKV: v1alpha1.TiKVSpec{
+					ContainerSpec: v1alpha1.ContainerSpec{
+						Requests: &v1alpha1.ResourceRequirement{Storage: "100Gi"},
+					},
+					Replicas: 3,
+				},
+			},
+		},
+	}
+}
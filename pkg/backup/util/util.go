@@ -15,12 +15,22 @@ package util
 
 import (
 	"fmt"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/backup/constants"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	corelisters "k8s.io/client-go/listers/core/v1"
+	rbaclisters "k8s.io/client-go/listers/rbac/v1"
 )
 
 // CheckAllKeysExistInSecret check if all keys are included in the specific secret
@@ -36,6 +46,20 @@ func CheckAllKeysExistInSecret(secret *corev1.Secret, keys ...string) (string, b
 	return strings.Join(notExistKeys, ","), len(notExistKeys) == 0
 }
 
+// generateSecretKeyEnv generate the env which references the key of the secret in the
+// namespace of the job, so that the credential is not exposed in the job
+func generateSecretKeyEnv(name, secretName, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  key,
+			},
+		},
+	}
+}
+
 // GenerateCephCertEnvVar generate the env info in order to access ceph
 func GenerateCephCertEnvVar(secret *corev1.Secret, endpoint string) ([]corev1.EnvVar, error) {
 	var envVars []corev1.EnvVar
@@ -50,32 +74,540 @@ func GenerateCephCertEnvVar(secret *corev1.Secret, endpoint string) ([]corev1.En
 		return envVars, fmt.Errorf("cenph endpoint URI %s must start with http:// scheme", endpoint)
 	}
 	envVars = []corev1.EnvVar{
+		{
+			Name:  "S3_ENDPOINT",
+			Value: endpoint,
+		},
+		generateSecretKeyEnv("AWS_ACCESS_KEY_ID", secret.GetName(), constants.S3AccessKey),
+		generateSecretKeyEnv("AWS_SECRET_ACCESS_KEY", secret.GetName(), constants.S3SecretKey),
+	}
+	return envVars, nil
+}
+
+// GenerateS3CertEnvVar generate the env info in order to access S3 compatible storage,
+// the secret is nil if the IAM role of the job pod is used
+func GenerateS3CertEnvVar(secret *corev1.Secret, s3 *v1alpha1.S3StorageProvider) ([]corev1.EnvVar, error) {
+	var envVars []corev1.EnvVar
+
+	endpoint := s3.Endpoint
+	if endpoint != "" && !strings.Contains(endpoint, "://") {
+		// convert xxx.xxx.xxx.xxx:port to http://xxx.xxx.xxx.xxx:port
+		endpoint = fmt.Sprintf("http://%s", endpoint)
+	}
+	if endpoint != "" && !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		return envVars, fmt.Errorf("s3 endpoint URI %s must start with http:// or https:// scheme", endpoint)
+	}
+
+	v2Auth := false
+	switch s3.SignatureVersion {
+	case "", "v4":
+	case "v2":
+		v2Auth = true
+	default:
+		return envVars, fmt.Errorf("s3 signature version %s is invalid, must be v2 or v4", s3.SignatureVersion)
+	}
+
+	provider := s3.Provider
+	if provider == "" {
+		provider = "AWS"
+	}
+	envVars = []corev1.EnvVar{
+		{
+			Name:  "S3_PROVIDER",
+			Value: provider,
+		},
+		{
+			Name:  "AWS_REGION",
+			Value: s3.Region,
+		},
 		{
 			Name:  "S3_ENDPOINT",
 			Value: endpoint,
 		},
 		{
-			Name:  "AWS_ACCESS_KEY_ID",
-			Value: string(secret.Data[constants.S3AccessKey]),
+			Name:  "S3_FORCE_PATH_STYLE",
+			Value: strconv.FormatBool(s3.ForcePathStyle),
 		},
 		{
-			Name:  "AWS_SECRET_ACCESS_KEY",
-			Value: string(secret.Data[constants.S3SecretKey]),
+			Name:  "S3_V2_AUTH",
+			Value: strconv.FormatBool(v2Auth),
 		},
 	}
+	if secret != nil {
+		envVars = append(envVars,
+			generateSecretKeyEnv("AWS_ACCESS_KEY_ID", secret.GetName(), constants.S3AccessKey),
+			generateSecretKeyEnv("AWS_SECRET_ACCESS_KEY", secret.GetName(), constants.S3SecretKey),
+		)
+	} else {
+		// the credentials of the IAM role are provided by the environment
+		// (kube2iam or IRSA) of the job pod
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  "S3_ENV_AUTH",
+			Value: "true",
+		})
+	}
+	if s3.CASecretName != "" {
+		caFile := filepath.Join(constants.StorageCAMountPath, constants.CACertKey)
+		envVars = append(envVars, []corev1.EnvVar{
+			{
+				// used by br, dumpling and lightning
+				Name:  "AWS_CA_BUNDLE",
+				Value: caFile,
+			},
+			{
+				// used by rclone
+				Name:  "RCLONE_CA_CERT",
+				Value: caFile,
+			},
+		}...)
+	}
 	return envVars, nil
 }
 
+// GenerateGCSCertEnvVar generate the env info in order to access google cloud storage,
+// the secret is nil if the GCP service account bound by Workload Identity is used
+func GenerateGCSCertEnvVar(secret *corev1.Secret, gcs *v1alpha1.GCSStorageProvider) []corev1.EnvVar {
+	envVars := []corev1.EnvVar{
+		{
+			Name:  "GCS_PROJECT_ID",
+			Value: gcs.ProjectID,
+		},
+		{
+			Name:  "GCS_LOCATION",
+			Value: gcs.Location,
+		},
+		{
+			Name:  "GCS_STORAGE_CLASS",
+			Value: gcs.StorageClass,
+		},
+		{
+			Name:  "GCS_OBJECT_ACL",
+			Value: gcs.ObjectACL,
+		},
+	}
+	if secret != nil {
+		envVars = append(envVars, generateSecretKeyEnv("GCS_SERVICE_ACCOUNT_JSON_KEY", secret.GetName(), constants.GCSCredentialsKey))
+	} else {
+		// the credentials of the GCP service account are provided by the
+		// GKE metadata server of the job pod
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  "GCS_ENV_AUTH",
+			Value: "true",
+		})
+	}
+	return envVars
+}
+
+// GenerateStorageCAVolume generate the volume and volume mount of the CA bundle
+// used to verify the certificate of the backend storage
+func GenerateStorageCAVolume(backup *v1alpha1.Backup) ([]corev1.Volume, []corev1.VolumeMount) {
+	if backup.Spec.StorageType != v1alpha1.BackupStorageTypeS3 || backup.Spec.S3 == nil || backup.Spec.S3.CASecretName == "" {
+		return nil, nil
+	}
+	volumes := []corev1.Volume{
+		{
+			Name: constants.StorageCAVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: backup.Spec.S3.CASecretName,
+				},
+			},
+		},
+	}
+	volumeMounts := []corev1.VolumeMount{
+		{Name: constants.StorageCAVolumeName, MountPath: constants.StorageCAMountPath, ReadOnly: true},
+	}
+	return volumes, volumeMounts
+}
+
+// GenerateClusterClientTLSVolume generate the volume and volume mount of the client
+// certificate used to connect to PD if TLS is enabled between the cluster components,
+// the secret is optional as TLS may not be enabled
+func GenerateClusterClientTLSVolume() (corev1.Volume, corev1.VolumeMount) {
+	optional := true
+	volume := corev1.Volume{
+		Name: constants.ClusterClientTLSVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: constants.ClusterClientTLSSecretName,
+				Optional:   &optional,
+			},
+		},
+	}
+	volumeMount := corev1.VolumeMount{
+		Name:      constants.ClusterClientTLSVolumeName,
+		MountPath: constants.ClusterClientTLSMountPath,
+		ReadOnly:  true,
+	}
+	return volume, volumeMount
+}
+
+// GetStorageRoleARN returns the IAM role used by the job pods to access the backend storage
+func GetStorageRoleARN(backup *v1alpha1.Backup) string {
+	if backup.Spec.StorageType != v1alpha1.BackupStorageTypeS3 || backup.Spec.S3 == nil {
+		return ""
+	}
+	return backup.Spec.S3.RoleARN
+}
+
+// GenerateStoragePodAnnotations generate the annotations of the job pods which
+// assume the IAM role of the backend storage by kube2iam
+func GenerateStoragePodAnnotations(backup *v1alpha1.Backup) map[string]string {
+	roleARN := GetStorageRoleARN(backup)
+	if roleARN == "" {
+		return nil
+	}
+	return map[string]string{
+		constants.Kube2IAMRoleAnnotation: roleARN,
+	}
+}
+
+// GetServiceAccountName returns the service account name of the job pods, the jobs
+// of the backups and restores by volume snapshots run with their own default
+// service account which is granted to manage the snapshots and pods
+func GetServiceAccountName(serviceAccount string, volumeSnapshot bool) string {
+	if serviceAccount != "" {
+		return serviceAccount
+	}
+	if volumeSnapshot {
+		return constants.DefaultVolumeSnapshotServiceAccountName
+	}
+	return constants.DefaultServiceAccountName
+}
+
+// IsDefaultServiceAccount returns whether the service account of the job pods is
+// created by the operator, see EnsureDefaultServiceAccount
+func IsDefaultServiceAccount(serviceAccount string) bool {
+	return serviceAccount == constants.DefaultServiceAccountName ||
+		serviceAccount == constants.DefaultVolumeSnapshotServiceAccountName
+}
+
+// GenerateServiceAccountAnnotations generate the annotations of the service account
+// of the job pods which bind the cloud identity of the backend storage, the IAM role
+// by IRSA or the GCP service account by Workload Identity
+func GenerateServiceAccountAnnotations(backup *v1alpha1.Backup) map[string]string {
+	switch backup.Spec.StorageType {
+	case v1alpha1.BackupStorageTypeS3:
+		if roleARN := GetStorageRoleARN(backup); roleARN != "" {
+			return map[string]string{constants.IRSARoleAnnotation: roleARN}
+		}
+	case v1alpha1.BackupStorageTypeGCS:
+		if backup.Spec.GCS != nil && backup.Spec.GCS.GCPServiceAccount != "" {
+			return map[string]string{constants.WorkloadIdentityAnnotation: backup.Spec.GCS.GCPServiceAccount}
+		}
+	}
+	return nil
+}
+
+// CheckServiceAccountAnnotations checks that the service account of the job pods is
+// annotated with the cloud identity of the backend storage. The annotations bind the
+// cloud identity to all the pods of the service account, so they are never written by
+// the operator on behalf of the backup, the administrator must annotate the service account.
+func CheckServiceAccountAnnotations(
+	backup *v1alpha1.Backup,
+	ns, saName string,
+	saLister corelisters.ServiceAccountLister,
+) (string, error) {
+	if len(GenerateServiceAccountAnnotations(backup)) == 0 {
+		return "", nil
+	}
+
+	sa, err := saLister.ServiceAccounts(ns).Get(saName)
+	if err != nil {
+		return "GetServiceAccountFailed", fmt.Errorf("get service account %s/%s failed, err: %v", ns, saName, err)
+	}
+	return checkServiceAccountAnnotations(backup, sa)
+}
+
+func checkServiceAccountAnnotations(backup *v1alpha1.Backup, sa *corev1.ServiceAccount) (string, error) {
+	for k, v := range GenerateServiceAccountAnnotations(backup) {
+		if sa.Annotations[k] != v {
+			return "ServiceAccountNotAnnotated", fmt.Errorf("service account %s/%s must be annotated with %s=%s by the administrator",
+				sa.GetNamespace(), sa.GetName(), k, v)
+		}
+	}
+	return "", nil
+}
+
+// defaultRoleRules are the rules of the role of the default service account, the jobs
+// only record events and write the status of the backups and restores, and the
+// credentials of the storage and TiDB are passed by the secrets referenced in the env,
+// so no secret is readable by the role
+var defaultRoleRules = []rbacv1.PolicyRule{
+	{
+		APIGroups: []string{""},
+		Resources: []string{"events"},
+		Verbs:     []string{"create", "patch"},
+	},
+	{
+		APIGroups: []string{v1alpha1.SchemeGroupVersion.Group},
+		Resources: []string{"backups", "restores"},
+		Verbs:     []string{"get", "list", "watch"},
+	},
+	{
+		APIGroups: []string{v1alpha1.SchemeGroupVersion.Group},
+		Resources: []string{"backups/status", "restores/status"},
+		Verbs:     []string{"patch"},
+	},
+}
+
+// volumeSnapshotRoleRules are the extra rules of the role of the default service
+// account of the volume snapshot jobs, which create and delete the snapshots of the
+// cluster and restart the recovered PD pods
+var volumeSnapshotRoleRules = []rbacv1.PolicyRule{
+	{
+		APIGroups: []string{"snapshot.storage.k8s.io"},
+		Resources: []string{"volumesnapshots"},
+		Verbs:     []string{"get", "create", "delete"},
+	},
+	{
+		APIGroups: []string{v1alpha1.SchemeGroupVersion.Group},
+		Resources: []string{"tidbclusters"},
+		Verbs:     []string{"get"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"pods"},
+		Verbs:     []string{"list", "delete"},
+	},
+}
+
+// EnsureDefaultServiceAccount creates the default service account name of the job
+// pods in ns if it does not exist, see GetServiceAccountName. It is bound to a role
+// of the same name which only grants the permissions the jobs need instead of the
+// operator's credentials, the snapshots and pods are only granted to the service
+// account of the volume snapshot jobs. The rules of the role are reconciled on
+// every sync. The service account is created without the annotations of the cloud
+// identity, see CheckServiceAccountAnnotations.
+func EnsureDefaultServiceAccount(
+	object runtime.Object,
+	backup *v1alpha1.Backup,
+	ns, name string,
+	saLister corelisters.ServiceAccountLister,
+	saControl controller.ServiceAccountControlInterface,
+	roleLister rbaclisters.RoleLister,
+	roleBindingLister rbaclisters.RoleBindingLister,
+	rbacControl controller.RBACControlInterface,
+) (string, error) {
+	labels := label.NewBackup()
+
+	rules := append([]rbacv1.PolicyRule{}, defaultRoleRules...)
+	if name == constants.DefaultVolumeSnapshotServiceAccountName {
+		rules = append(rules, volumeSnapshotRoleRules...)
+	}
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels:    labels,
+		},
+		Rules: rules,
+	}
+	existing, err := roleLister.Roles(ns).Get(name)
+	if errors.IsNotFound(err) {
+		if err := rbacControl.CreateRole(object, role); err != nil && !errors.IsAlreadyExists(err) {
+			return "CreateRoleFailed", fmt.Errorf("create role %s/%s failed, err: %v", ns, name, err)
+		}
+	} else if err != nil {
+		return "GetRoleFailed", fmt.Errorf("get role %s/%s failed, err: %v", ns, name, err)
+	} else if !apiequality.Semantic.DeepEqual(existing.Rules, role.Rules) {
+		// the role created by an older operator is reconciled to the current rules
+		updated := existing.DeepCopy()
+		updated.Rules = role.Rules
+		if err := rbacControl.UpdateRole(object, updated); err != nil {
+			return "UpdateRoleFailed", fmt.Errorf("update role %s/%s failed, err: %v", ns, name, err)
+		}
+	}
+
+	_, err = roleBindingLister.RoleBindings(ns).Get(name)
+	if errors.IsNotFound(err) {
+		rb := &rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+				Labels:    labels,
+			},
+			Subjects: []rbacv1.Subject{
+				{
+					Kind:      rbacv1.ServiceAccountKind,
+					Name:      name,
+					Namespace: ns,
+				},
+			},
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     "Role",
+				Name:     name,
+			},
+		}
+		if err := rbacControl.CreateRoleBinding(object, rb); err != nil && !errors.IsAlreadyExists(err) {
+			return "CreateRoleBindingFailed", fmt.Errorf("create role binding %s/%s failed, err: %v", ns, name, err)
+		}
+	} else if err != nil {
+		return "GetRoleBindingFailed", fmt.Errorf("get role binding %s/%s failed, err: %v", ns, name, err)
+	}
+
+	sa, err := saLister.ServiceAccounts(ns).Get(name)
+	if errors.IsNotFound(err) {
+		sa = &corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+				Labels:    labels,
+			},
+		}
+		if err := saControl.CreateServiceAccount(object, sa); err != nil && !errors.IsAlreadyExists(err) {
+			return "CreateServiceAccountFailed", fmt.Errorf("create service account %s/%s failed, err: %v", ns, name, err)
+		}
+	} else if err != nil {
+		return "GetServiceAccountFailed", fmt.Errorf("get service account %s/%s failed, err: %v", ns, name, err)
+	}
+	return checkServiceAccountAnnotations(backup, sa)
+}
+
+// GenerateBackupPVCVolume generate the volume and volume mount of the pvc which
+// keeps the data of a backup with pvc storage
+func GenerateBackupPVCVolume(backup *v1alpha1.Backup) ([]corev1.Volume, []corev1.VolumeMount) {
+	if backup.Spec.StorageType != v1alpha1.BackupStorageTypePVC || backup.Status.BackupPVC == "" {
+		return nil, nil
+	}
+	volumes := []corev1.Volume{
+		{
+			Name: constants.BackupDataVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: backup.Status.BackupPVC,
+					ReadOnly:  true,
+				},
+			},
+		},
+	}
+	volumeMounts := []corev1.VolumeMount{
+		{Name: constants.BackupDataVolumeName, MountPath: constants.BackupDataMountPath, ReadOnly: true},
+	}
+	return volumes, volumeMounts
+}
+
 // GenerateStorageCertEnv generate the env info in order to access backend backup storage
-func GenerateStorageCertEnv(backup *v1alpha1.Backup, secretLister corelisters.SecretLister) ([]corev1.EnvVar, string, error) {
+// by the job in namespace ns, the credentials reference the storage secret of the backup
+// which must exist in ns
+func GenerateStorageCertEnv(backup *v1alpha1.Backup, ns string, secretLister corelisters.SecretLister) ([]corev1.EnvVar, string, error) {
+	return generateStorageCertEnv(ns, backup.GetName(), backup.Spec.StorageType, &backup.Spec.StorageProvider, secretLister)
+}
+
+// secondaryStorageEnvKeys maps the env of the primary storage to the options
+// of the rclone remote of a secondary storage, the CA bundle is not mapped
+// as rclone only supports a global one
+var secondaryStorageEnvKeys = map[string]string{
+	"S3_PROVIDER":                  "PROVIDER",
+	"AWS_REGION":                   "REGION",
+	"S3_ENDPOINT":                  "ENDPOINT",
+	"S3_FORCE_PATH_STYLE":          "FORCE_PATH_STYLE",
+	"S3_V2_AUTH":                   "V2_AUTH",
+	"S3_ENV_AUTH":                  "ENV_AUTH",
+	"AWS_ACCESS_KEY_ID":            "ACCESS_KEY_ID",
+	"AWS_SECRET_ACCESS_KEY":        "SECRET_ACCESS_KEY",
+	"GCS_PROJECT_ID":               "PROJECT_NUMBER",
+	"GCS_LOCATION":                 "LOCATION",
+	"GCS_STORAGE_CLASS":            "STORAGE_CLASS",
+	"GCS_OBJECT_ACL":               "OBJECT_ACL",
+	"GCS_SERVICE_ACCOUNT_JSON_KEY": "SERVICE_ACCOUNT_CREDENTIALS",
+	"GCS_ENV_AUTH":                 "ENV_AUTH",
+}
+
+// GetSecondaryStorageRemote returns the name of the rclone remote of the
+// index-th secondary storage of a backup
+func GetSecondaryStorageRemote(index int) string {
+	return fmt.Sprintf("%s%d", constants.SecondaryStorageRemotePrefix, index)
+}
+
+// GenerateSecondaryStorageEnv generate the env which defines the rclone remote
+// of the index-th secondary storage of a backup by the RCLONE_CONFIG_<REMOTE>_*
+// env, the remote is named by GetSecondaryStorageRemote
+func GenerateSecondaryStorageEnv(backup *v1alpha1.Backup, index int, secretLister corelisters.SecretLister) ([]corev1.EnvVar, string, error) {
 	ns := backup.GetNamespace()
 	name := backup.GetName()
+	storage := backup.Spec.SecondaryStorages[index]
+
+	var rcloneType, provider string
+	switch storage.StorageType {
+	case v1alpha1.BackupStorageTypeS3:
+		rcloneType = "s3"
+	case v1alpha1.BackupStorageTypeCeph:
+		rcloneType, provider = "s3", "Ceph"
+	case v1alpha1.BackupStorageTypeGCS:
+		rcloneType = "google cloud storage"
+	default:
+		err := fmt.Errorf("backup %s/%s secondary storage %s doesn't support storage type %s", ns, name, storage.Name, storage.StorageType)
+		return nil, "NotSupportStorageType", err
+	}
+	if storage.S3 != nil && storage.S3.CASecretName != "" {
+		err := fmt.Errorf("backup %s/%s secondary storage %s doesn't support caSecretName", ns, name, storage.Name)
+		return nil, "SecondaryStorageCANotSupported", err
+	}
+	certEnv, reason, err := generateStorageCertEnv(ns, name, storage.StorageType, &storage.StorageProvider, secretLister)
+	if err != nil {
+		return nil, reason, fmt.Errorf("secondary storage %s, %v", storage.Name, err)
+	}
 
+	prefix := fmt.Sprintf("RCLONE_CONFIG_%s_", strings.ToUpper(GetSecondaryStorageRemote(index)))
+	envVars := []corev1.EnvVar{
+		{
+			Name:  prefix + "TYPE",
+			Value: rcloneType,
+		},
+	}
+	if provider != "" {
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  prefix + "PROVIDER",
+			Value: provider,
+		})
+	}
+	for _, env := range certEnv {
+		key, ok := secondaryStorageEnvKeys[env.Name]
+		if !ok || (env.Value == "" && env.ValueFrom == nil) {
+			continue
+		}
+		envVars = append(envVars, corev1.EnvVar{
+			Name:      prefix + key,
+			Value:     env.Value,
+			ValueFrom: env.ValueFrom,
+		})
+	}
+	return envVars, "", nil
+}
+
+// GenerateLogBackupStorageEnv generate the env info in order to access the
+// storage of the log backup replayed by a point-in-time restore, the env is
+// the one of GenerateStorageCertEnv prefixed by constants.LogBackupStorageEnvPrefix
+func GenerateLogBackupStorageEnv(logBackup *v1alpha1.Backup, ns string, secretLister corelisters.SecretLister) ([]corev1.EnvVar, string, error) {
+	certEnv, reason, err := GenerateStorageCertEnv(logBackup, ns, secretLister)
+	if err != nil {
+		return nil, reason, fmt.Errorf("log backup, %v", err)
+	}
+	envVars := make([]corev1.EnvVar, 0, len(certEnv))
+	for _, env := range certEnv {
+		env.Name = constants.LogBackupStorageEnvPrefix + env.Name
+		envVars = append(envVars, env)
+	}
+	return envVars, "", nil
+}
+
+func generateStorageCertEnv(
+	ns, name string,
+	storageType v1alpha1.BackupStorageType,
+	storageProvider *v1alpha1.StorageProvider,
+	secretLister corelisters.SecretLister,
+) ([]corev1.EnvVar, string, error) {
 	var certEnv []corev1.EnvVar
 
-	switch backup.Spec.StorageType {
+	switch storageType {
 	case v1alpha1.BackupStorageTypeCeph:
-		cephSecretName := backup.Spec.Ceph.SecretName
+		if storageProvider.Ceph == nil {
+			err := fmt.Errorf("backup %s/%s spec.ceph is empty", ns, name)
+			return certEnv, "CephConfigIsEmpty", err
+		}
+		cephSecretName := storageProvider.Ceph.SecretName
 		secret, err := secretLister.Secrets(ns).Get(cephSecretName)
 		if err != nil {
 			err := fmt.Errorf("backup %s/%s get ceph secret %s failed, err: %v", ns, name, cephSecretName, err)
@@ -88,17 +620,198 @@ func GenerateStorageCertEnv(backup *v1alpha1.Backup, secretLister corelisters.Se
 			return certEnv, "KeyNotExist", err
 		}
 
-		certEnv, err = GenerateCephCertEnvVar(secret, backup.Spec.Ceph.Endpoint)
+		certEnv, err = GenerateCephCertEnvVar(secret, storageProvider.Ceph.Endpoint)
 		if err != nil {
 			return certEnv, "InvalidCephEndpoint", err
 		}
+	case v1alpha1.BackupStorageTypeS3:
+		if storageProvider.S3 == nil {
+			err := fmt.Errorf("backup %s/%s spec.s3 is empty", ns, name)
+			return certEnv, "S3ConfigIsEmpty", err
+		}
+		s3SecretName := storageProvider.S3.SecretName
+		var secret *corev1.Secret
+		if s3SecretName != "" {
+			var err error
+			secret, err = secretLister.Secrets(ns).Get(s3SecretName)
+			if err != nil {
+				err := fmt.Errorf("backup %s/%s get s3 secret %s failed, err: %v", ns, name, s3SecretName, err)
+				return certEnv, "GetS3SecretFailed", err
+			}
+
+			keyStr, exist := CheckAllKeysExistInSecret(secret, constants.S3AccessKey, constants.S3SecretKey)
+			if !exist {
+				err := fmt.Errorf("backup %s/%s, The secret %s missing some keys %s", ns, name, s3SecretName, keyStr)
+				return certEnv, "KeyNotExist", err
+			}
+		} else if storageProvider.S3.RoleARN == "" {
+			err := fmt.Errorf("backup %s/%s spec.s3 requires secretName or roleARN", ns, name)
+			return certEnv, "S3CredentialIsEmpty", err
+		}
+
+		var err error
+		certEnv, err = GenerateS3CertEnvVar(secret, storageProvider.S3)
+		if err != nil {
+			return certEnv, "InvalidS3Config", fmt.Errorf("backup %s/%s, %v", ns, name, err)
+		}
+	case v1alpha1.BackupStorageTypeGCS:
+		if storageProvider.GCS == nil {
+			err := fmt.Errorf("backup %s/%s spec.gcs is empty", ns, name)
+			return certEnv, "GCSConfigIsEmpty", err
+		}
+		gcsSecretName := storageProvider.GCS.SecretName
+		var secret *corev1.Secret
+		if gcsSecretName != "" {
+			var err error
+			secret, err = secretLister.Secrets(ns).Get(gcsSecretName)
+			if err != nil {
+				err := fmt.Errorf("backup %s/%s get gcs secret %s failed, err: %v", ns, name, gcsSecretName, err)
+				return certEnv, "GetGCSSecretFailed", err
+			}
+
+			keyStr, exist := CheckAllKeysExistInSecret(secret, constants.GCSCredentialsKey)
+			if !exist {
+				err := fmt.Errorf("backup %s/%s, The secret %s missing some keys %s", ns, name, gcsSecretName, keyStr)
+				return certEnv, "KeyNotExist", err
+			}
+		} else if storageProvider.GCS.GCPServiceAccount == "" {
+			err := fmt.Errorf("backup %s/%s spec.gcs requires secretName or gcpServiceAccount", ns, name)
+			return certEnv, "GCSCredentialIsEmpty", err
+		}
+
+		certEnv = GenerateGCSCertEnvVar(secret, storageProvider.GCS)
+	case v1alpha1.BackupStorageTypePVC:
+		// the backup data is kept in the pvc of the backup job, no cert is required
 	default:
-		err := fmt.Errorf("backup %s/%s don't support storage type %s", ns, name, backup.Spec.StorageType)
+		err := fmt.Errorf("backup %s/%s don't support storage type %s", ns, name, storageType)
 		return certEnv, "NotSupportStorageType", err
 	}
 	return certEnv, "", nil
 }
 
+// GenerateEncryptionEnv generate the env info in order to encrypt the backup data
+func GenerateEncryptionEnv(backup *v1alpha1.Backup, secretLister corelisters.SecretLister) ([]corev1.EnvVar, string, error) {
+	ns := backup.GetNamespace()
+	name := backup.GetName()
+
+	var envVars []corev1.EnvVar
+	encryption := backup.Spec.Encryption
+	if encryption == nil {
+		return envVars, "", nil
+	}
+
+	switch encryption.SSE {
+	case "", v1alpha1.ServerSideEncryptionAES256, v1alpha1.ServerSideEncryptionKMS:
+	default:
+		err := fmt.Errorf("backup %s/%s server-side encryption %s is invalid, must be %s or %s",
+			ns, name, encryption.SSE, v1alpha1.ServerSideEncryptionAES256, v1alpha1.ServerSideEncryptionKMS)
+		return envVars, "InvalidServerSideEncryption", err
+	}
+	if encryption.CrypterMethod != "" && backup.Spec.BR == nil {
+		err := fmt.Errorf("backup %s/%s client-side encryption only works with br", ns, name)
+		return envVars, "InvalidClientSideEncryption", err
+	}
+
+	var secret *corev1.Secret
+	if encryption.SecretName != "" {
+		var err error
+		secret, err = secretLister.Secrets(ns).Get(encryption.SecretName)
+		if err != nil {
+			err := fmt.Errorf("backup %s/%s get encryption secret %s failed, err: %v", ns, name, encryption.SecretName, err)
+			return envVars, "GetEncryptionSecretFailed", err
+		}
+	}
+
+	if encryption.SSE != "" {
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  "S3_SSE",
+			Value: string(encryption.SSE),
+		})
+		if encryption.SSE == v1alpha1.ServerSideEncryptionKMS && secret != nil {
+			if keyID, exist := secret.Data[constants.SSEKMSKeyIDKey]; exist {
+				envVars = append(envVars, corev1.EnvVar{
+					Name:  "S3_SSE_KMS_KEY_ID",
+					Value: string(keyID),
+				})
+			}
+		}
+	}
+
+	if encryption.CrypterMethod != "" {
+		crypterEnv, reason, err := generateCrypterEnv(ns, name, encryption, secret)
+		if err != nil {
+			return envVars, reason, err
+		}
+		envVars = append(envVars, crypterEnv...)
+	}
+	return envVars, "", nil
+}
+
+// GenerateDecryptionEnv generate the env info in order to restore the backup data encrypted
+// by BR's client-side encryption in namespace ns, the encryption secret of the backup must
+// exist in ns as the key is referenced by the env of the restore job
+func GenerateDecryptionEnv(backup *v1alpha1.Backup, ns string, secretLister corelisters.SecretLister) ([]corev1.EnvVar, string, error) {
+	name := backup.GetName()
+
+	encryption := backup.Spec.Encryption
+	if encryption == nil || encryption.CrypterMethod == "" {
+		return nil, "", nil
+	}
+	var secret *corev1.Secret
+	if encryption.SecretName != "" {
+		var err error
+		secret, err = secretLister.Secrets(ns).Get(encryption.SecretName)
+		if err != nil {
+			err := fmt.Errorf("backup %s/%s get encryption secret %s failed, err: %v", ns, name, encryption.SecretName, err)
+			return nil, "GetEncryptionSecretFailed", err
+		}
+	}
+	return generateCrypterEnv(ns, name, encryption, secret)
+}
+
+// generateCrypterEnv generate the env of BR's client-side encryption, the key references
+// the encryption secret so that it is not exposed in the job
+func generateCrypterEnv(ns, name string, encryption *v1alpha1.BackupEncryption, secret *corev1.Secret) ([]corev1.EnvVar, string, error) {
+	if secret == nil {
+		err := fmt.Errorf("backup %s/%s client-side encryption requires the encryption secret", ns, name)
+		return nil, "EncryptionSecretIsEmpty", err
+	}
+	keyStr, exist := CheckAllKeysExistInSecret(secret, constants.CrypterKey)
+	if !exist {
+		err := fmt.Errorf("backup %s/%s, The secret %s missing some keys %s", ns, name, encryption.SecretName, keyStr)
+		return nil, "KeyNotExist", err
+	}
+	return []corev1.EnvVar{
+		{
+			Name:  "BR_CRYPTER_METHOD",
+			Value: encryption.CrypterMethod,
+		},
+		{
+			Name: "BR_CRYPTER_KEY",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: encryption.SecretName},
+					Key:                  constants.CrypterKey,
+				},
+			},
+		},
+	}, "", nil
+}
+
+// GenerateTidbPasswordEnv generate the env which passes the tidb password to the backup and
+// restore jobs, it references the tidb secret so that the password is not exposed in the job
+func GenerateTidbPasswordEnv(tidbSecretName string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: constants.TidbPasswordEnv,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: tidbSecretName},
+				Key:                  constants.TidbPasswordKey,
+			},
+		},
+	}
+}
+
 // GetTidbUserAndPassword get the tidb user and password from specific secret
 func GetTidbUserAndPassword(ns, name, tidbSecretName string, secretLister corelisters.SecretLister) (user, password, reason string, err error) {
 	secret, err := secretLister.Secrets(ns).Get(tidbSecretName)
@@ -119,3 +832,20 @@ func GetTidbUserAndPassword(ns, name, tidbSecretName string, secretLister coreli
 	password = string(secret.Data[constants.TidbPasswordKey])
 	return
 }
+
+// GetBaseBackup returns the complete backup of the same cluster which the incremental
+// BR backup is taken on top of, it returns nil if the base is not found in backups
+func GetBaseBackup(backup *v1alpha1.Backup, backups []*v1alpha1.Backup) *v1alpha1.Backup {
+	if backup.Spec.BR == nil || backup.Spec.Type != v1alpha1.BackupTypeInc || backup.Spec.BR.LastBackupTS == "" {
+		return nil
+	}
+	for _, base := range backups {
+		if base.GetName() == backup.GetName() || base.Spec.Cluster != backup.Spec.Cluster || base.Spec.BR == nil {
+			continue
+		}
+		if base.Status.CommitTs == backup.Spec.BR.LastBackupTS && v1alpha1.IsBackupComplete(base) {
+			return base
+		}
+	}
+	return nil
+}
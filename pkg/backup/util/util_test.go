@@ -0,0 +1,276 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/backup/constants"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestEnsureDefaultServiceAccount(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name          string
+		saName        string
+		existingRules []rbacv1.PolicyRule
+		updateRoleErr bool
+		expectReason  string
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		backup := &v1alpha1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "ns"},
+		}
+		kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), 0)
+		roleInformer := kubeInformerFactory.Rbac().V1().Roles()
+		rbInformer := kubeInformerFactory.Rbac().V1().RoleBindings()
+		saInformer := kubeInformerFactory.Core().V1().ServiceAccounts()
+		rbacControl := controller.NewFakeRBACControl(roleInformer, rbInformer)
+		saControl := controller.NewFakeServiceAccountControl(saInformer)
+		if test.existingRules != nil {
+			g.Expect(roleInformer.Informer().GetIndexer().Add(&rbacv1.Role{
+				ObjectMeta: metav1.ObjectMeta{Name: test.saName, Namespace: "ns"},
+				Rules:      test.existingRules,
+			})).To(Succeed())
+		}
+		if test.updateRoleErr {
+			rbacControl.SetUpdateRoleError(errors.New("update role failed"), 0)
+		}
+
+		reason, err := EnsureDefaultServiceAccount(backup, backup, "ns", test.saName,
+			saInformer.Lister(), saControl, roleInformer.Lister(), rbInformer.Lister(), rbacControl)
+		g.Expect(reason).To(Equal(test.expectReason))
+		if test.expectReason != "" {
+			g.Expect(err).To(HaveOccurred())
+			return
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+
+		role, err := roleInformer.Lister().Roles("ns").Get(test.saName)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(role.Rules).To(ContainElement(rbacv1.PolicyRule{
+			APIGroups: []string{v1alpha1.SchemeGroupVersion.Group},
+			Resources: []string{"backups/status", "restores/status"},
+			Verbs:     []string{"patch"},
+		}))
+		var resources []string
+		for _, rule := range role.Rules {
+			resources = append(resources, rule.Resources...)
+		}
+		g.Expect(resources).NotTo(ContainElement("secrets"))
+		// the snapshots and pods are only managed by the volume snapshot jobs
+		if test.saName == constants.DefaultVolumeSnapshotServiceAccountName {
+			g.Expect(resources).To(ContainElement("volumesnapshots"))
+			g.Expect(resources).To(ContainElement("pods"))
+		} else {
+			g.Expect(resources).NotTo(ContainElement("volumesnapshots"))
+			g.Expect(resources).NotTo(ContainElement("pods"))
+		}
+		rb, err := rbInformer.Lister().RoleBindings("ns").Get(test.saName)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(rb.RoleRef.Name).To(Equal(test.saName))
+		g.Expect(rb.Subjects).To(ConsistOf(rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: test.saName, Namespace: "ns"}))
+		_, err = saInformer.Lister().ServiceAccounts("ns").Get(test.saName)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		// the reconciled role is not updated again
+		rbacControl.SetUpdateRoleError(errors.New("update role failed"), 0)
+		reason, err = EnsureDefaultServiceAccount(backup, backup, "ns", test.saName,
+			saInformer.Lister(), saControl, roleInformer.Lister(), rbInformer.Lister(), rbacControl)
+		g.Expect(reason).To(BeEmpty())
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+
+	oldRules := []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{""},
+			Resources: []string{"secrets"},
+			Verbs:     []string{"get"},
+		},
+		{
+			APIGroups: []string{v1alpha1.SchemeGroupVersion.Group},
+			Resources: []string{"backups", "restores"},
+			Verbs:     []string{"get", "list", "watch", "update"},
+		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"pods"},
+			Verbs:     []string{"list", "delete"},
+		},
+	}
+	tests := []testcase{
+		{
+			name:   "create the role",
+			saName: constants.DefaultServiceAccountName,
+		},
+		{
+			name:   "create the role of the volume snapshot jobs",
+			saName: constants.DefaultVolumeSnapshotServiceAccountName,
+		},
+		{
+			name:          "reconcile the role of an older operator",
+			saName:        constants.DefaultServiceAccountName,
+			existingRules: oldRules,
+		},
+		{
+			name:          "update role failed",
+			saName:        constants.DefaultServiceAccountName,
+			existingRules: oldRules,
+			updateRoleErr: true,
+			expectReason:  "UpdateRoleFailed",
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func TestGenerateStorageCertEnv(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name          string
+		backup        *v1alpha1.Backup
+		secretNs      string
+		secretData    map[string][]byte
+		expectReason  string
+		expectSecrets map[string]string
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), 0)
+		secretInformer := kubeInformerFactory.Core().V1().Secrets()
+		g.Expect(secretInformer.Informer().GetIndexer().Add(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "storage-secret", Namespace: test.secretNs},
+			Data:       test.secretData,
+		})).To(Succeed())
+
+		envVars, reason, err := GenerateStorageCertEnv(test.backup, "restore-ns", secretInformer.Lister())
+		g.Expect(reason).To(Equal(test.expectReason))
+		if test.expectReason != "" {
+			g.Expect(err).To(HaveOccurred())
+			return
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+
+		secrets := map[string]string{}
+		for _, env := range envVars {
+			for _, data := range test.secretData {
+				g.Expect(env.Value).NotTo(ContainSubstring(string(data)))
+			}
+			if env.ValueFrom != nil {
+				g.Expect(env.ValueFrom.SecretKeyRef.Name).To(Equal("storage-secret"))
+				secrets[env.Name] = env.ValueFrom.SecretKeyRef.Key
+			}
+		}
+		g.Expect(secrets).To(Equal(test.expectSecrets))
+	}
+
+	s3Backup := &v1alpha1.Backup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "ns"},
+		Spec: v1alpha1.BackupSpec{
+			StorageType: v1alpha1.BackupStorageTypeS3,
+			StorageProvider: v1alpha1.StorageProvider{
+				S3: &v1alpha1.S3StorageProvider{SecretName: "storage-secret"},
+			},
+		},
+	}
+	s3SecretData := map[string][]byte{
+		constants.S3AccessKey: []byte("access-key-id"),
+		constants.S3SecretKey: []byte("secret-access-key"),
+	}
+	tests := []testcase{
+		{
+			name:       "s3 credentials reference the secret",
+			backup:     s3Backup,
+			secretNs:   "restore-ns",
+			secretData: s3SecretData,
+			expectSecrets: map[string]string{
+				"AWS_ACCESS_KEY_ID":     constants.S3AccessKey,
+				"AWS_SECRET_ACCESS_KEY": constants.S3SecretKey,
+			},
+		},
+		{
+			name: "ceph credentials reference the secret",
+			backup: &v1alpha1.Backup{
+				ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "ns"},
+				Spec: v1alpha1.BackupSpec{
+					StorageType: v1alpha1.BackupStorageTypeCeph,
+					StorageProvider: v1alpha1.StorageProvider{
+						Ceph: &v1alpha1.CephStorageProvider{SecretName: "storage-secret", Endpoint: "10.0.0.1:7480"},
+					},
+				},
+			},
+			secretNs:   "restore-ns",
+			secretData: s3SecretData,
+			expectSecrets: map[string]string{
+				"AWS_ACCESS_KEY_ID":     constants.S3AccessKey,
+				"AWS_SECRET_ACCESS_KEY": constants.S3SecretKey,
+			},
+		},
+		{
+			name: "gcs credentials reference the secret",
+			backup: &v1alpha1.Backup{
+				ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "ns"},
+				Spec: v1alpha1.BackupSpec{
+					StorageType: v1alpha1.BackupStorageTypeGCS,
+					StorageProvider: v1alpha1.StorageProvider{
+						GCS: &v1alpha1.GCSStorageProvider{SecretName: "storage-secret"},
+					},
+				},
+			},
+			secretNs: "restore-ns",
+			secretData: map[string][]byte{
+				constants.GCSCredentialsKey: []byte(`{"type": "service_account"}`),
+			},
+			expectSecrets: map[string]string{
+				"GCS_SERVICE_ACCOUNT_JSON_KEY": constants.GCSCredentialsKey,
+			},
+		},
+		{
+			name:         "secret is not in the namespace of the job",
+			backup:       s3Backup,
+			secretNs:     "ns",
+			secretData:   s3SecretData,
+			expectReason: "GetS3SecretFailed",
+		},
+		{
+			name:     "secret misses the keys",
+			backup:   s3Backup,
+			secretNs: "restore-ns",
+			secretData: map[string][]byte{
+				constants.S3AccessKey: []byte("access-key-id"),
+			},
+			expectReason: "KeyNotExist",
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
@@ -25,3 +25,5 @@ type BackupScheduleExpansion interface{}
 type RestoreExpansion interface{}
 
 type TidbClusterExpansion interface{}
+
+type TidbNGMonitoringExpansion interface{}
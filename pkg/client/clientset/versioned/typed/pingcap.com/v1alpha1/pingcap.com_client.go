@@ -31,6 +31,7 @@ type PingcapV1alpha1Interface interface {
 	BackupSchedulesGetter
 	RestoresGetter
 	TidbClustersGetter
+	TidbNGMonitoringsGetter
 }
 
 // PingcapV1alpha1Client is used to interact with features provided by the pingcap.com group.
@@ -54,6 +55,10 @@ func (c *PingcapV1alpha1Client) TidbClusters(namespace string) TidbClusterInterf
 	return newTidbClusters(c, namespace)
 }
 
+func (c *PingcapV1alpha1Client) TidbNGMonitorings(namespace string) TidbNGMonitoringInterface {
+	return newTidbNGMonitorings(c, namespace)
+}
+
 // NewForConfig creates a new PingcapV1alpha1Client for the given config.
 func NewForConfig(c *rest.Config) (*PingcapV1alpha1Client, error) {
 	config := *c
@@ -32,6 +32,8 @@ type Interface interface {
 	Restores() RestoreInformer
 	// TidbClusters returns a TidbClusterInformer.
 	TidbClusters() TidbClusterInformer
+	// TidbNGMonitorings returns a TidbNGMonitoringInformer.
+	TidbNGMonitorings() TidbNGMonitoringInformer
 }
 
 type version struct {
@@ -64,3 +66,8 @@ func (v *version) Restores() RestoreInformer {
 func (v *version) TidbClusters() TidbClusterInformer {
 	return &tidbClusterInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// TidbNGMonitorings returns a TidbNGMonitoringInformer.
+func (v *version) TidbNGMonitorings() TidbNGMonitoringInformer {
+	return &tidbNGMonitoringInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
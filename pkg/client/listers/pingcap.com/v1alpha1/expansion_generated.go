@@ -49,3 +49,11 @@ type TidbClusterListerExpansion interface{}
 // TidbClusterNamespaceListerExpansion allows custom methods to be added to
 // TidbClusterNamespaceLister.
 type TidbClusterNamespaceListerExpansion interface{}
+
+// TidbNGMonitoringListerExpansion allows custom methods to be added to
+// TidbNGMonitoringLister.
+type TidbNGMonitoringListerExpansion interface{}
+
+// TidbNGMonitoringNamespaceListerExpansion allows custom methods to be added to
+// TidbNGMonitoringNamespaceLister.
+type TidbNGMonitoringNamespaceListerExpansion interface{}
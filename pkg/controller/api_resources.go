@@ -0,0 +1,63 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+
+	apps "k8s.io/api/apps/v1"
+	"k8s.io/client-go/discovery"
+)
+
+// requiredAPIResources are the resources of the workloads generated by the
+// operator, keyed by their group versions
+var requiredAPIResources = map[string][]string{
+	apps.SchemeGroupVersion.String(): {"statefulsets"},
+}
+
+// CheckAPIResources returns an error if any of the API resources used by the
+// operator is not served, e.g. apps/v1 is only served by Kubernetes 1.9 or
+// later, so the operator fails at the start rather than the informers never
+// getting synced. The StatefulSets created by the older operators through
+// apps/v1beta1 are the same objects in apps/v1, they are adopted as they are.
+func CheckAPIResources(cli discovery.DiscoveryInterface) error {
+	for groupVersion, resources := range requiredAPIResources {
+		list, err := cli.ServerResourcesForGroupVersion(groupVersion)
+		if err != nil {
+			return fmt.Errorf("failed to discover the resources of %s, Kubernetes 1.9 or later is required: %v", groupVersion, err)
+		}
+		served := map[string]bool{}
+		if list != nil {
+			for _, resource := range list.APIResources {
+				served[resource.Name] = true
+			}
+		}
+		for _, resource := range resources {
+			if !served[resource] {
+				return fmt.Errorf("%s is not served in %s, Kubernetes 1.9 or later is required", resource, groupVersion)
+			}
+		}
+	}
+	return nil
+}
+
+// openShiftGroupVersion is only served by OpenShift
+const openShiftGroupVersion = "security.openshift.io/v1"
+
+// IsOpenShift returns whether the Kubernetes cluster is OpenShift, which is
+// detected by the API group of the security context constraints
+func IsOpenShift(cli discovery.DiscoveryInterface) bool {
+	list, err := cli.ServerResourcesForGroupVersion(openShiftGroupVersion)
+	return err == nil && list != nil && len(list.APIResources) > 0
+}
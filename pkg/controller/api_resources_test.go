@@ -0,0 +1,58 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCheckAPIResources(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	kubeCli := kubefake.NewSimpleClientset()
+	discovery := kubeCli.Discovery().(*fakediscovery.FakeDiscovery)
+
+	discovery.Resources = []*metav1.APIResourceList{
+		{GroupVersion: "apps/v1beta1", APIResources: []metav1.APIResource{{Name: "statefulsets"}}},
+	}
+	g.Expect(CheckAPIResources(discovery)).NotTo(Succeed())
+
+	discovery.Resources = []*metav1.APIResourceList{
+		{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{{Name: "deployments"}}},
+	}
+	g.Expect(CheckAPIResources(discovery)).NotTo(Succeed())
+
+	discovery.Resources = []*metav1.APIResourceList{
+		{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{{Name: "deployments"}, {Name: "statefulsets"}}},
+	}
+	g.Expect(CheckAPIResources(discovery)).To(Succeed())
+}
+
+func TestIsOpenShift(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	kubeCli := kubefake.NewSimpleClientset()
+	discovery := kubeCli.Discovery().(*fakediscovery.FakeDiscovery)
+	g.Expect(IsOpenShift(discovery)).To(BeFalse())
+
+	discovery.Resources = []*metav1.APIResourceList{
+		{GroupVersion: "security.openshift.io/v1", APIResources: []metav1.APIResource{{Name: "securitycontextconstraints"}}},
+	}
+	g.Expect(IsOpenShift(discovery)).To(BeTrue())
+}
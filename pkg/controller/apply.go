@@ -0,0 +1,72 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// FieldManager is the field manager of the fields applied by the operator
+	FieldManager = "tidb-operator"
+
+	applyPatchType types.PatchType = "application/apply-patch+yaml"
+)
+
+// applyObjectMeta returns the metadata owned by the operator, the annotations
+// out of the pingcap.com domains are added by the users or the other tools
+// and are left to their own field managers
+func applyObjectMeta(meta metav1.ObjectMeta) metav1.ObjectMeta {
+	applyMeta := metav1.ObjectMeta{
+		Name:            meta.Name,
+		Namespace:       meta.Namespace,
+		Labels:          meta.Labels,
+		OwnerReferences: meta.OwnerReferences,
+	}
+	for k, v := range meta.Annotations {
+		domain := strings.Split(k, "/")[0]
+		if domain != "pingcap.com" && !strings.HasSuffix(domain, ".pingcap.com") {
+			continue
+		}
+		if applyMeta.Annotations == nil {
+			applyMeta.Annotations = map[string]string{}
+		}
+		applyMeta.Annotations[k] = v
+	}
+	return applyMeta
+}
+
+// serverSideApply applies the object with the operator's field manager, the
+// conflicts with the other managers are forced to the operator's values
+func serverSideApply(client rest.Interface, resource string, meta metav1.ObjectMeta, obj interface{}, result runtime.Object) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return client.Patch(applyPatchType).
+		Namespace(meta.Namespace).
+		Resource(resource).
+		Name(meta.Name).
+		Param("fieldManager", FieldManager).
+		Param("force", "true").
+		Body(data).
+		Do().
+		Into(result)
+}
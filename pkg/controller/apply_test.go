@@ -0,0 +1,53 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApplyObjectMeta(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	meta := metav1.ObjectMeta{
+		Name:            "demo-pd",
+		Namespace:       metav1.NamespaceDefault,
+		ResourceVersion: "10",
+		Labels:          map[string]string{"app.kubernetes.io/component": "pd"},
+		Annotations: map[string]string{
+			"pingcap.com/last-applied-configuration": "{}",
+			"tidb.pingcap.com/pod-name":              "demo-pd-0",
+			"prometheus.io/scrape":                   "true",
+			"example.com/pingcap.com":                "user",
+		},
+		OwnerReferences: []metav1.OwnerReference{{Name: "demo"}},
+	}
+
+	applyMeta := applyObjectMeta(meta)
+	g.Expect(applyMeta.Name).To(Equal("demo-pd"))
+	g.Expect(applyMeta.Namespace).To(Equal(metav1.NamespaceDefault))
+	g.Expect(applyMeta.ResourceVersion).To(BeEmpty())
+	g.Expect(applyMeta.Labels).To(Equal(meta.Labels))
+	g.Expect(applyMeta.OwnerReferences).To(Equal(meta.OwnerReferences))
+	g.Expect(applyMeta.Annotations).To(Equal(map[string]string{
+		"pingcap.com/last-applied-configuration": "{}",
+		"tidb.pingcap.com/pod-name":              "demo-pd-0",
+	}))
+
+	meta.Annotations = map[string]string{"prometheus.io/scrape": "true"}
+	g.Expect(applyObjectMeta(meta).Annotations).To(BeNil())
+}
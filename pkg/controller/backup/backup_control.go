@@ -97,7 +97,9 @@ func (bc *defaultBackupControl) removeProtectionFinalizer(backup *v1alpha1.Backu
 }
 
 func needToAddFinalizer(backup *v1alpha1.Backup) bool {
-	return backup.DeletionTimestamp == nil && !slice.ContainsString(backup.Finalizers, label.BackupProtectionFinalizer, nil)
+	return backup.DeletionTimestamp == nil &&
+		backup.GetCleanPolicy() != v1alpha1.CleanPolicyTypeRetain &&
+		!slice.ContainsString(backup.Finalizers, label.BackupProtectionFinalizer, nil)
 }
 
 func isDeletionCandidate(backup *v1alpha1.Backup) bool {
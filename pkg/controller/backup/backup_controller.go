@@ -17,14 +17,15 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/golang/glog"
 	perrors "github.com/pingcap/errors"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/backup/backup"
+	"github.com/pingcap/tidb-operator/pkg/backup/jobgc"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
 	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions"
 	listers "github.com/pingcap/tidb-operator/pkg/client/listers/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -52,6 +53,8 @@ type Controller struct {
 	backupListerSynced cache.InformerSynced
 	// backups that need to be synced.
 	queue workqueue.RateLimitingInterface
+	// jobGC deletes the finished jobs of the backups
+	jobGC *jobgc.JobGC
 }
 
 // NewController creates a backup controller.
@@ -62,7 +65,7 @@ func NewController(
 	kubeInformerFactory kubeinformers.SharedInformerFactory,
 ) *Controller {
 	eventBroadcaster := record.NewBroadcaster()
-	eventBroadcaster.StartLogging(glog.Infof)
+	eventBroadcaster.StartLogging(log.Infof)
 	eventBroadcaster.StartRecordingToSink(&eventv1.EventSinkImpl{
 		Interface: eventv1.New(kubeCli.CoreV1().RESTClient()).Events("")})
 	recorder := eventBroadcaster.NewRecorder(v1alpha1.Scheme, corev1.EventSource{Component: "backup"})
@@ -70,11 +73,17 @@ func NewController(
 	backupInformer := informerFactory.Pingcap().V1alpha1().Backups()
 	jobInformer := kubeInformerFactory.Batch().V1().Jobs()
 	pvcInformer := kubeInformerFactory.Core().V1().PersistentVolumeClaims()
+	pvInformer := kubeInformerFactory.Core().V1().PersistentVolumes()
 	secretInformer := kubeInformerFactory.Core().V1().Secrets()
+	saInformer := kubeInformerFactory.Core().V1().ServiceAccounts()
+	roleInformer := kubeInformerFactory.Rbac().V1().Roles()
+	roleBindingInformer := kubeInformerFactory.Rbac().V1().RoleBindings()
 	statusUpdater := controller.NewRealBackupConditionUpdater(cli, backupInformer.Lister(), recorder)
 	jobControl := controller.NewRealJobControl(kubeCli, recorder)
 	pvcControl := controller.NewRealGeneralPVCControl(kubeCli, recorder)
-	backupCleaner := backup.NewBackupCleaner(statusUpdater, secretInformer.Lister(), jobInformer.Lister(), jobControl)
+	saControl := controller.NewRealServiceAccountControl(kubeCli, recorder)
+	rbacControl := controller.NewRealRBACControl(kubeCli, recorder)
+	backupCleaner := backup.NewBackupCleaner(statusUpdater, secretInformer.Lister(), jobInformer.Lister(), jobControl, pvcInformer.Lister(), pvcControl)
 
 	bkc := &Controller{
 		kubeClient: kubeCli,
@@ -82,6 +91,7 @@ func NewController(
 		control: NewDefaultBackupControl(
 			cli,
 			backup.NewBackupManager(
+				backupInformer.Lister(),
 				backupCleaner,
 				statusUpdater,
 				secretInformer.Lister(),
@@ -89,12 +99,19 @@ func NewController(
 				jobControl,
 				pvcInformer.Lister(),
 				pvcControl,
+				pvInformer.Lister(),
+				saInformer.Lister(),
+				saControl,
+				roleInformer.Lister(),
+				roleBindingInformer.Lister(),
+				rbacControl,
 			),
 		),
 		queue: workqueue.NewNamedRateLimitingQueue(
 			workqueue.DefaultControllerRateLimiter(),
 			"backup",
 		),
+		jobGC: jobgc.NewBackupJobGC(backupInformer.Lister(), jobInformer.Lister(), jobControl, controller.BackupJobHistoryLimit, controller.BackupJobTTL),
 	}
 
 	backupInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -115,12 +132,13 @@ func (bkc *Controller) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
 	defer bkc.queue.ShutDown()
 
-	glog.Info("Starting backup controller")
-	defer glog.Info("Shutting down backup controller")
+	log.Info("Starting backup controller")
+	defer log.Info("Shutting down backup controller")
 
 	for i := 0; i < workers; i++ {
 		go wait.Until(bkc.worker, time.Second, stopCh)
 	}
+	go bkc.jobGC.Run(stopCh)
 
 	<-stopCh
 }
@@ -142,7 +160,7 @@ func (bkc *Controller) processNextWorkItem() bool {
 	defer bkc.queue.Done(key)
 	if err := bkc.sync(key.(string)); err != nil {
 		if perrors.Find(err, controller.IsRequeueError) != nil {
-			glog.Infof("Backup: %v, still need sync: %v, requeuing", key.(string), err)
+			log.Infof("Backup: %v, still need sync: %v, requeuing", key.(string), err)
 		} else {
 			utilruntime.HandleError(fmt.Errorf("Backup: %v, sync failed, err: %v, requeuing", key.(string), err))
 		}
@@ -157,7 +175,7 @@ func (bkc *Controller) processNextWorkItem() bool {
 func (bkc *Controller) sync(key string) error {
 	startTime := time.Now()
 	defer func() {
-		glog.V(4).Infof("Finished syncing Backup %q (%v)", key, time.Since(startTime))
+		log.V(4).Infof("Finished syncing Backup %q (%v)", key, time.Since(startTime))
 	}()
 
 	ns, name, err := cache.SplitMetaNamespaceKey(key)
@@ -166,7 +184,7 @@ func (bkc *Controller) sync(key string) error {
 	}
 	backup, err := bkc.backupLister.Backups(ns).Get(name)
 	if errors.IsNotFound(err) {
-		glog.Infof("Backup has been deleted %v", key)
+		log.Infof("Backup has been deleted %v", key)
 		return nil
 	}
 	if err != nil {
@@ -187,22 +205,30 @@ func (bkc *Controller) updateBackup(cur interface{}) {
 
 	if newBackup.DeletionTimestamp != nil {
 		// the backup is being deleted, we need to do some cleanup work, enqueue backup.
-		glog.Infof("backup %s/%s is being deleted", ns, name)
+		log.Infof("backup %s/%s is being deleted", ns, name)
+		bkc.enqueueBackup(newBackup)
+		return
+	}
+
+	if v1alpha1.NeedToReplicate(newBackup) {
+		log.V(4).Infof("backup %s/%s is Complete, replicate it to the secondary storages", ns, name)
 		bkc.enqueueBackup(newBackup)
 		return
 	}
 
 	if v1alpha1.IsBackupComplete(newBackup) {
-		glog.V(4).Infof("backup %s/%s is Complete, skipping.", ns, name)
+		log.V(4).Infof("backup %s/%s is Complete, skipping.", ns, name)
 		return
 	}
 
-	if v1alpha1.IsBackupScheduled(newBackup) {
-		glog.V(4).Infof("backup %s/%s is already scheduled, skipping", ns, name)
+	if v1alpha1.IsBackupScheduled(newBackup) && newBackup.Spec.LogBackup == nil {
+		// the job of a log backup is replaced when the command is changed,
+		// so it is always synced
+		log.V(4).Infof("backup %s/%s is already scheduled, skipping", ns, name)
 		return
 	}
 
-	glog.V(4).Infof("backup object %s/%s enqueue", ns, name)
+	log.V(4).Infof("backup object %s/%s enqueue", ns, name)
 	bkc.enqueueBackup(newBackup)
 }
 
@@ -17,17 +17,21 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
+	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions/pingcap.com/v1alpha1"
+	listers "github.com/pingcap/tidb-operator/pkg/client/listers/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 )
 
 // BackupControlInterface manages Backups used in BackupSchedule
 type BackupControlInterface interface {
 	CreateBackup(backup *v1alpha1.Backup) (*v1alpha1.Backup, error)
+	UpdateBackup(backup *v1alpha1.Backup) (*v1alpha1.Backup, error)
 	DeleteBackup(backup *v1alpha1.Backup) error
 }
 
@@ -54,14 +58,29 @@ func (rbc *realBackupControl) CreateBackup(backup *v1alpha1.Backup) (*v1alpha1.B
 	bsName := backup.GetLabels()[label.BackupScheduleLabelKey]
 	backup, err := rbc.cli.PingcapV1alpha1().Backups(ns).Create(backup)
 	if err != nil {
-		glog.Errorf("failed to create Backup: [%s/%s] for backupSchedule/%s, err: %v", ns, backupName, bsName, err)
+		log.Errorf("failed to create Backup: [%s/%s] for backupSchedule/%s, err: %v", ns, backupName, bsName, err)
 	} else {
-		glog.V(4).Infof("create Backup: [%s/%s] for backupSchedule/%s successfully", ns, backupName, bsName)
+		log.V(4).Infof("create Backup: [%s/%s] for backupSchedule/%s successfully", ns, backupName, bsName)
 	}
 	rbc.recordBackupEvent("create", backup, err)
 	return backup, err
 }
 
+func (rbc *realBackupControl) UpdateBackup(backup *v1alpha1.Backup) (*v1alpha1.Backup, error) {
+	ns := backup.GetNamespace()
+	backupName := backup.GetName()
+
+	bsName := backup.GetLabels()[label.BackupScheduleLabelKey]
+	updateBackup, err := rbc.cli.PingcapV1alpha1().Backups(ns).Update(backup)
+	if err != nil {
+		log.Errorf("failed to update Backup: [%s/%s] for backupSchedule/%s, err: %v", ns, backupName, bsName, err)
+	} else {
+		log.V(4).Infof("update Backup: [%s/%s] for backupSchedule/%s successfully", ns, backupName, bsName)
+	}
+	rbc.recordBackupEvent("update", backup, err)
+	return updateBackup, err
+}
+
 func (rbc *realBackupControl) DeleteBackup(backup *v1alpha1.Backup) error {
 	ns := backup.GetNamespace()
 	backupName := backup.GetName()
@@ -69,9 +88,9 @@ func (rbc *realBackupControl) DeleteBackup(backup *v1alpha1.Backup) error {
 	bsName := backup.GetLabels()[label.BackupScheduleLabelKey]
 	err := rbc.cli.PingcapV1alpha1().Backups(ns).Delete(backupName, nil)
 	if err != nil {
-		glog.Errorf("failed to delete Backup: [%s/%s] for backupSchedule/%s, err: %v", ns, backupName, bsName, err)
+		log.Errorf("failed to delete Backup: [%s/%s] for backupSchedule/%s, err: %v", ns, backupName, bsName, err)
 	} else {
-		glog.V(4).Infof("delete backup: [%s/%s] successfully, backupSchedule/%s", ns, backupName, bsName)
+		log.V(4).Infof("delete backup: [%s/%s] successfully, backupSchedule/%s", ns, backupName, bsName)
 	}
 	rbc.recordBackupEvent("delete", backup, err)
 	return err
@@ -96,3 +115,76 @@ func (rbc *realBackupControl) recordBackupEvent(verb string, backup *v1alpha1.Ba
 }
 
 var _ BackupControlInterface = &realBackupControl{}
+
+// FakeBackupControl is a fake BackupControlInterface
+type FakeBackupControl struct {
+	BackupLister        listers.BackupLister
+	BackupIndexer       cache.Indexer
+	createBackupTracker requestTracker
+	updateBackupTracker requestTracker
+	deleteBackupTracker requestTracker
+}
+
+// NewFakeBackupControl returns a FakeBackupControl
+func NewFakeBackupControl(backupInformer informers.BackupInformer) *FakeBackupControl {
+	return &FakeBackupControl{
+		backupInformer.Lister(),
+		backupInformer.Informer().GetIndexer(),
+		requestTracker{0, nil, 0},
+		requestTracker{0, nil, 0},
+		requestTracker{0, nil, 0},
+	}
+}
+
+// SetCreateBackupError sets the error attributes of createBackupTracker
+func (fbc *FakeBackupControl) SetCreateBackupError(err error, after int) {
+	fbc.createBackupTracker.err = err
+	fbc.createBackupTracker.after = after
+}
+
+// SetUpdateBackupError sets the error attributes of updateBackupTracker
+func (fbc *FakeBackupControl) SetUpdateBackupError(err error, after int) {
+	fbc.updateBackupTracker.err = err
+	fbc.updateBackupTracker.after = after
+}
+
+// SetDeleteBackupError sets the error attributes of deleteBackupTracker
+func (fbc *FakeBackupControl) SetDeleteBackupError(err error, after int) {
+	fbc.deleteBackupTracker.err = err
+	fbc.deleteBackupTracker.after = after
+}
+
+// CreateBackup adds the backup to BackupIndexer
+func (fbc *FakeBackupControl) CreateBackup(backup *v1alpha1.Backup) (*v1alpha1.Backup, error) {
+	defer fbc.createBackupTracker.inc()
+	if fbc.createBackupTracker.errorReady() {
+		defer fbc.createBackupTracker.reset()
+		return backup, fbc.createBackupTracker.err
+	}
+
+	return backup, fbc.BackupIndexer.Add(backup)
+}
+
+// UpdateBackup updates the backup in BackupIndexer
+func (fbc *FakeBackupControl) UpdateBackup(backup *v1alpha1.Backup) (*v1alpha1.Backup, error) {
+	defer fbc.updateBackupTracker.inc()
+	if fbc.updateBackupTracker.errorReady() {
+		defer fbc.updateBackupTracker.reset()
+		return backup, fbc.updateBackupTracker.err
+	}
+
+	return backup, fbc.BackupIndexer.Update(backup)
+}
+
+// DeleteBackup deletes the backup from BackupIndexer
+func (fbc *FakeBackupControl) DeleteBackup(backup *v1alpha1.Backup) error {
+	defer fbc.deleteBackupTracker.inc()
+	if fbc.deleteBackupTracker.errorReady() {
+		defer fbc.deleteBackupTracker.reset()
+		return fbc.deleteBackupTracker.err
+	}
+
+	return fbc.BackupIndexer.Delete(backup)
+}
+
+var _ BackupControlInterface = &FakeBackupControl{}
@@ -62,6 +62,42 @@ func TestBackupControlCreateBackupFailed(t *testing.T) {
 	g.Expect(events[0]).To(ContainSubstring(corev1.EventTypeWarning))
 }
 
+func TestBackupControlUpdateBackupSuccess(t *testing.T) {
+	g := NewGomegaWithT(t)
+	recorder := record.NewFakeRecorder(10)
+	backup := newBackup()
+	fakeClient := &fake.Clientset{}
+	control := NewRealBackupControl(fakeClient, recorder)
+	fakeClient.AddReactor("update", "backups", func(action core.Action) (bool, runtime.Object, error) {
+		update := action.(core.UpdateAction)
+		return true, update.GetObject(), nil
+	})
+	_, err := control.UpdateBackup(backup)
+	g.Expect(err).To(Succeed())
+
+	events := collectEvents(recorder.Events)
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0]).To(ContainSubstring(corev1.EventTypeNormal))
+}
+
+func TestBackupControlUpdateBackupFailed(t *testing.T) {
+	g := NewGomegaWithT(t)
+	recorder := record.NewFakeRecorder(10)
+	backup := newBackup()
+	fakeClient := &fake.Clientset{}
+	control := NewRealBackupControl(fakeClient, recorder)
+	fakeClient.AddReactor("update", "backups", func(action core.Action) (bool, runtime.Object, error) {
+		update := action.(core.UpdateAction)
+		return true, update.GetObject(), apierrors.NewInternalError(errors.New("API server down"))
+	})
+	_, err := control.UpdateBackup(backup)
+	g.Expect(err).To(HaveOccurred())
+
+	events := collectEvents(recorder.Events)
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0]).To(ContainSubstring(corev1.EventTypeWarning))
+}
+
 func TestBackupControlDeleteBackupSuccess(t *testing.T) {
 	g := NewGomegaWithT(t)
 	recorder := record.NewFakeRecorder(10)
@@ -0,0 +1,137 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
+	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions/pingcap.com/v1alpha1"
+	listers "github.com/pingcap/tidb-operator/pkg/client/listers/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	"k8s.io/client-go/tools/cache"
+)
+
+// BackupProgressUpdaterInterface enables updating the progress of a running Backup.
+type BackupProgressUpdaterInterface interface {
+	Update(backup *v1alpha1.Backup, progress *v1alpha1.BackupProgress) error
+	UpdateLogBackup(backup *v1alpha1.Backup, logBackup *v1alpha1.LogBackupStatus, backupSize int64) error
+	UpdatePausedScheduleLimits(backup *v1alpha1.Backup, limits map[string]uint64) error
+}
+
+type realBackupProgressUpdater struct {
+	cli          versioned.Interface
+	backupLister listers.BackupLister
+}
+
+// NewRealBackupProgressUpdater returns a BackupProgressUpdaterInterface that updates the progress of a Backup
+func NewRealBackupProgressUpdater(
+	cli versioned.Interface,
+	backupLister listers.BackupLister) BackupProgressUpdaterInterface {
+	return &realBackupProgressUpdater{
+		cli,
+		backupLister,
+	}
+}
+
+func (bpu *realBackupProgressUpdater) Update(backup *v1alpha1.Backup, progress *v1alpha1.BackupProgress) error {
+	err := patchBackupStatus(bpu.cli, bpu.backupLister, backup, func(status *v1alpha1.BackupStatus) {
+		status.Progress = progress
+	})
+	if err == nil {
+		log.V(4).Infof("Backup: [%s/%s] progress updated successfully", backup.GetNamespace(), backup.GetName())
+	}
+	return err
+}
+
+// UpdateLogBackup updates the status of the log backup task and the size of the log data
+func (bpu *realBackupProgressUpdater) UpdateLogBackup(backup *v1alpha1.Backup, logBackup *v1alpha1.LogBackupStatus, backupSize int64) error {
+	err := patchBackupStatus(bpu.cli, bpu.backupLister, backup, func(status *v1alpha1.BackupStatus) {
+		status.LogBackup = logBackup
+		status.BackupSize = backupSize
+	})
+	if err == nil {
+		log.V(4).Infof("Backup: [%s/%s] log backup status updated successfully", backup.GetNamespace(), backup.GetName())
+	}
+	return err
+}
+
+// UpdatePausedScheduleLimits records the schedule limits of PD paused by the backup,
+// they are cleared by nil limits after the scheduling is resumed
+func (bpu *realBackupProgressUpdater) UpdatePausedScheduleLimits(backup *v1alpha1.Backup, limits map[string]uint64) error {
+	err := patchBackupStatus(bpu.cli, bpu.backupLister, backup, func(status *v1alpha1.BackupStatus) {
+		status.PausedScheduleLimits = limits
+	})
+	if err == nil {
+		log.V(4).Infof("Backup: [%s/%s] paused schedule limits updated successfully", backup.GetNamespace(), backup.GetName())
+	}
+	return err
+}
+
+var _ BackupProgressUpdaterInterface = &realBackupProgressUpdater{}
+
+// FakeBackupProgressUpdater is a fake BackupProgressUpdaterInterface
+type FakeBackupProgressUpdater struct {
+	BackupLister        listers.BackupLister
+	BackupIndexer       cache.Indexer
+	updateBackupTracker requestTracker
+}
+
+// NewFakeBackupProgressUpdater returns a FakeBackupProgressUpdater
+func NewFakeBackupProgressUpdater(backupInformer informers.BackupInformer) *FakeBackupProgressUpdater {
+	return &FakeBackupProgressUpdater{
+		backupInformer.Lister(),
+		backupInformer.Informer().GetIndexer(),
+		requestTracker{0, nil, 0},
+	}
+}
+
+// SetUpdateBackupError sets the error attributes of updateBackupTracker
+func (fbp *FakeBackupProgressUpdater) SetUpdateBackupError(err error, after int) {
+	fbp.updateBackupTracker.err = err
+	fbp.updateBackupTracker.after = after
+}
+
+func (fbp *FakeBackupProgressUpdater) update(backup *v1alpha1.Backup) error {
+	defer fbp.updateBackupTracker.inc()
+	if fbp.updateBackupTracker.errorReady() {
+		defer fbp.updateBackupTracker.reset()
+		return fbp.updateBackupTracker.err
+	}
+
+	return fbp.BackupIndexer.Update(backup)
+}
+
+// Update updates the progress of the Backup
+func (fbp *FakeBackupProgressUpdater) Update(backup *v1alpha1.Backup, progress *v1alpha1.BackupProgress) error {
+	backup = backup.DeepCopy()
+	backup.Status.Progress = progress
+	return fbp.update(backup)
+}
+
+// UpdateLogBackup updates the log backup status of the Backup
+func (fbp *FakeBackupProgressUpdater) UpdateLogBackup(backup *v1alpha1.Backup, logBackup *v1alpha1.LogBackupStatus, backupSize int64) error {
+	backup = backup.DeepCopy()
+	backup.Status.LogBackup = logBackup
+	backup.Status.BackupSize = backupSize
+	return fbp.update(backup)
+}
+
+// UpdatePausedScheduleLimits updates the paused schedule limits of the Backup
+func (fbp *FakeBackupProgressUpdater) UpdatePausedScheduleLimits(backup *v1alpha1.Backup, limits map[string]uint64) error {
+	backup = backup.DeepCopy()
+	backup.Status.PausedScheduleLimits = limits
+	return fbp.update(backup)
+}
+
+var _ BackupProgressUpdaterInterface = &FakeBackupProgressUpdater{}
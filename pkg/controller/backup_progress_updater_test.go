@@ -0,0 +1,121 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned/fake"
+	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestBackupStatusPatch(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	backup := &v1alpha1.Backup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "ns", ResourceVersion: "1"},
+		Status: v1alpha1.BackupStatus{
+			BackupPath:           "s3://bucket/backup",
+			PausedScheduleLimits: map[string]uint64{"leader-schedule-limit": 4},
+		},
+	}
+	backupInformer := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0).Pingcap().V1alpha1().Backups()
+	g.Expect(backupInformer.Informer().GetIndexer().Add(backup)).To(Succeed())
+
+	fakeClient := &fake.Clientset{}
+	var patches []string
+	fakeClient.AddReactor("patch", "backups", func(action core.Action) (bool, runtime.Object, error) {
+		p := action.(core.PatchAction)
+		g.Expect(p.GetSubresource()).To(Equal("status"))
+		g.Expect(p.GetPatchType()).To(Equal(types.MergePatchType))
+		patches = append(patches, string(p.GetPatch()))
+		return true, backup, nil
+	})
+
+	// only the changed fields are patched and the cleared limits are set to null
+	progressUpdater := NewRealBackupProgressUpdater(fakeClient, backupInformer.Lister())
+	g.Expect(progressUpdater.UpdatePausedScheduleLimits(backup, nil)).To(Succeed())
+	g.Expect(patches).To(Equal([]string{`{"metadata":{"resourceVersion":"1"},"status":{"pausedScheduleLimits":null}}`}))
+
+	// the fields changed by the caller are patched with the condition
+	statusUpdater := NewRealBackupConditionUpdater(fakeClient, backupInformer.Lister(), record.NewFakeRecorder(10))
+	changed := backup.DeepCopy()
+	changed.Status.CommitTs = "400"
+	g.Expect(statusUpdater.Update(changed, &v1alpha1.BackupCondition{
+		Type:   v1alpha1.BackupComplete,
+		Status: corev1.ConditionTrue,
+	})).To(Succeed())
+	g.Expect(patches).To(HaveLen(2))
+	g.Expect(patches[1]).To(ContainSubstring(`"commitTs":"400"`))
+	g.Expect(patches[1]).To(ContainSubstring(`"conditions":[`))
+	g.Expect(patches[1]).NotTo(ContainSubstring("backupPath"))
+
+	// the condition is not patched again
+	g.Expect(statusUpdater.Update(changed, &v1alpha1.BackupCondition{
+		Type:   v1alpha1.BackupComplete,
+		Status: corev1.ConditionTrue,
+	})).To(Succeed())
+	g.Expect(patches).To(HaveLen(2))
+}
+
+func TestBackupStatusPatchConflict(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	backup := &v1alpha1.Backup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "ns", ResourceVersion: "1"},
+	}
+	backupInformer := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0).Pingcap().V1alpha1().Backups()
+	g.Expect(backupInformer.Informer().GetIndexer().Add(backup)).To(Succeed())
+
+	// the job has marked the backup as complete, which is not in the cache yet
+	latest := backup.DeepCopy()
+	latest.ResourceVersion = "2"
+	latest.Status.Conditions = []v1alpha1.BackupCondition{
+		{Type: v1alpha1.BackupComplete, Status: corev1.ConditionTrue},
+	}
+	fakeClient := &fake.Clientset{}
+	fakeClient.AddReactor("get", "backups", func(action core.Action) (bool, runtime.Object, error) {
+		return true, latest, nil
+	})
+	var patches []string
+	fakeClient.AddReactor("patch", "backups", func(action core.Action) (bool, runtime.Object, error) {
+		patch := string(action.(core.PatchAction).GetPatch())
+		patches = append(patches, patch)
+		if !strings.Contains(patch, `"resourceVersion":"2"`) {
+			return true, nil, apierrors.NewConflict(v1alpha1.Resource("backups"), "backup", errors.New("object has been modified"))
+		}
+		return true, latest, nil
+	})
+
+	statusUpdater := NewRealBackupConditionUpdater(fakeClient, backupInformer.Lister(), record.NewFakeRecorder(10))
+	g.Expect(statusUpdater.Update(backup.DeepCopy(), &v1alpha1.BackupCondition{
+		Type:   v1alpha1.BackupClean,
+		Status: corev1.ConditionTrue,
+	})).To(Succeed())
+	g.Expect(patches).To(HaveLen(2))
+	// the retried patch keeps the condition set by the job
+	g.Expect(patches[1]).To(ContainSubstring(`"type":"Complete"`))
+	g.Expect(patches[1]).To(ContainSubstring(`"type":"Clean"`))
+}
@@ -17,7 +17,7 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/golang/glog"
+	"github.com/pingcap/tidb-operator/pkg/log"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
@@ -69,7 +69,7 @@ func (bss *realBackupScheduleStatusUpdater) UpdateBackupScheduleStatus(
 	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		_, updateErr := bss.cli.PingcapV1alpha1().BackupSchedules(ns).Update(bs)
 		if updateErr == nil {
-			glog.Infof("BackupSchedule: [%s/%s] updated successfully", ns, bsName)
+			log.Infof("BackupSchedule: [%s/%s] updated successfully", ns, bsName)
 			return nil
 		}
 		if updated, err := bss.bsLister.BackupSchedules(ns).Get(bsName); err == nil {
@@ -17,13 +17,16 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/golang/glog"
+	"github.com/pingcap/tidb-operator/pkg/log"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
 	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions/pingcap.com/v1alpha1"
 	listers "github.com/pingcap/tidb-operator/pkg/client/listers/pingcap.com/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
@@ -56,33 +59,62 @@ func NewRealBackupConditionUpdater(
 func (bcu *realBackupConditionUpdater) Update(backup *v1alpha1.Backup, condition *v1alpha1.BackupCondition) error {
 	ns := backup.GetNamespace()
 	backupName := backup.GetName()
-	oldStatus := backup.Status.DeepCopy()
-	var isUpdate bool
-	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		isUpdate = v1alpha1.UpdateBackupCondition(&backup.Status, condition)
-		if isUpdate {
-			_, updateErr := bcu.cli.PingcapV1alpha1().Backups(ns).Update(backup)
-			if updateErr == nil {
-				glog.Infof("Backup: [%s/%s] updated successfully", ns, backupName)
-				return nil
-			}
-			if updated, err := bcu.backupLister.Backups(ns).Get(backupName); err == nil {
-				// make a copy so we don't mutate the shared cache
-				backup = updated.DeepCopy()
-				backup.Status = *oldStatus
-			} else {
-				utilruntime.HandleError(fmt.Errorf("error getting updated backup %s/%s from lister: %v", ns, backupName, err))
-			}
-			return updateErr
-		}
+	if !v1alpha1.UpdateBackupCondition(&backup.Status, condition) {
 		return nil
+	}
+	// the caller may have changed the other fields of the status as well, the
+	// conditions are taken from the latest status so those set by others are kept
+	newStatus := backup.Status.DeepCopy()
+	err := patchBackupStatus(bcu.cli, bcu.backupLister, backup, func(status *v1alpha1.BackupStatus) {
+		conditions := status.Conditions
+		*status = *newStatus
+		status.Conditions = conditions
+		v1alpha1.UpdateBackupCondition(status, condition)
 	})
-	if isUpdate {
-		bcu.recordBackupEvent("update", backup, err)
+	if err == nil {
+		log.Infof("Backup: [%s/%s] updated successfully", ns, backupName)
 	}
+	bcu.recordBackupEvent("update", backup, err)
 	return err
 }
 
+// patchBackupStatus writes the changes made by mutate on the status of the cached backup
+// to the status subresource by a JSON merge patch, which only requires the patch permission
+// on backups/status granted to the backup jobs. The patch carries the resourceVersion of
+// the status it is computed from, so it fails with a conflict instead of overwriting the
+// changes made by the other writers in the meantime, e.g. the conditions set by the job.
+// On conflict the backup is read again from the API server, as the cache may still be
+// stale, and mutate is applied to its latest status.
+func patchBackupStatus(cli versioned.Interface, backupLister listers.BackupLister, backup *v1alpha1.Backup, mutate func(status *v1alpha1.BackupStatus)) error {
+	ns := backup.GetNamespace()
+	backupName := backup.GetName()
+	current := backup
+	if cached, err := backupLister.Backups(ns).Get(backupName); err == nil {
+		current = cached
+	} else {
+		utilruntime.HandleError(fmt.Errorf("error getting backup %s/%s from lister: %v", ns, backupName, err))
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		// make a copy so we don't mutate the shared cache
+		newStatus := current.Status.DeepCopy()
+		mutate(newStatus)
+		patch, err := versionedStatusMergePatch(current.GetResourceVersion(), &current.Status, newStatus)
+		if err != nil {
+			return err
+		}
+		_, patchErr := cli.PingcapV1alpha1().Backups(ns).Patch(backupName, types.MergePatchType, patch, "status")
+		if !errors.IsConflict(patchErr) {
+			return patchErr
+		}
+		if updated, err := cli.PingcapV1alpha1().Backups(ns).Get(backupName, metav1.GetOptions{}); err == nil {
+			current = updated
+		} else {
+			utilruntime.HandleError(fmt.Errorf("error getting updated backup %s/%s: %v", ns, backupName, err))
+		}
+		return patchErr
+	})
+}
+
 func (bcu *realBackupConditionUpdater) recordBackupEvent(verb string, backup *v1alpha1.Backup, err error) {
 	backupName := backup.GetName()
 	if err == nil {
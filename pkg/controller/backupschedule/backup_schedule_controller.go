@@ -17,7 +17,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/golang/glog"
 	perrors "github.com/pingcap/errors"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/backup/backupschedule"
@@ -25,6 +24,7 @@ import (
 	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions"
 	listers "github.com/pingcap/tidb-operator/pkg/client/listers/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -62,7 +62,7 @@ func NewController(
 	kubeInformerFactory kubeinformers.SharedInformerFactory,
 ) *Controller {
 	eventBroadcaster := record.NewBroadcaster()
-	eventBroadcaster.StartLogging(glog.Infof)
+	eventBroadcaster.StartLogging(log.Infof)
 	eventBroadcaster.StartRecordingToSink(&eventv1.EventSinkImpl{
 		Interface: eventv1.New(kubeCli.CoreV1().RESTClient()).Events("")})
 	recorder := eventBroadcaster.NewRecorder(v1alpha1.Scheme, corev1.EventSource{Component: "backupSchedule"})
@@ -111,8 +111,8 @@ func (bsc *Controller) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
 	defer bsc.queue.ShutDown()
 
-	glog.Info("Starting backup schedule controller")
-	defer glog.Info("Shutting down backup schedule controller")
+	log.Info("Starting backup schedule controller")
+	defer log.Info("Shutting down backup schedule controller")
 
 	for i := 0; i < workers; i++ {
 		go wait.Until(bsc.worker, time.Second, stopCh)
@@ -138,7 +138,7 @@ func (bsc *Controller) processNextWorkItem() bool {
 	defer bsc.queue.Done(key)
 	if err := bsc.sync(key.(string)); err != nil {
 		if perrors.Find(err, controller.IsRequeueError) != nil {
-			glog.Infof("BackupSchedule: %v, still need sync: %v, requeuing", key.(string), err)
+			log.Infof("BackupSchedule: %v, still need sync: %v, requeuing", key.(string), err)
 		} else {
 			utilruntime.HandleError(fmt.Errorf("BackupSchedule: %v, sync failed, err: %v, requeuing", key.(string), err))
 		}
@@ -153,7 +153,7 @@ func (bsc *Controller) processNextWorkItem() bool {
 func (bsc *Controller) sync(key string) error {
 	startTime := time.Now()
 	defer func() {
-		glog.V(4).Infof("Finished syncing BackupSchedule %q (%v)", key, time.Since(startTime))
+		log.V(4).Infof("Finished syncing BackupSchedule %q (%v)", key, time.Since(startTime))
 	}()
 
 	ns, name, err := cache.SplitMetaNamespaceKey(key)
@@ -162,7 +162,7 @@ func (bsc *Controller) sync(key string) error {
 	}
 	bs, err := bsc.bsLister.BackupSchedules(ns).Get(name)
 	if errors.IsNotFound(err) {
-		glog.Infof("BackupSchedule has been deleted %v", key)
+		log.Infof("BackupSchedule has been deleted %v", key)
 		return nil
 	}
 	if err != nil {
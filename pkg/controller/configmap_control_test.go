@@ -0,0 +1,109 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestConfigMapControlCreatesConfigMap(t *testing.T) {
+	g := NewGomegaWithT(t)
+	recorder := record.NewFakeRecorder(10)
+	tc := newTidbCluster()
+	cm := newConfigMap(tc)
+	fakeClient := &fake.Clientset{}
+	control := NewRealConfigMapControl(fakeClient, nil, recorder)
+	fakeClient.AddReactor("create", "configmaps", func(action core.Action) (bool, runtime.Object, error) {
+		create := action.(core.CreateAction)
+		return true, create.GetObject(), nil
+	})
+	err := control.CreateConfigMap(tc, cm)
+	g.Expect(err).To(Succeed())
+
+	events := collectEvents(recorder.Events)
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0]).To(ContainSubstring(corev1.EventTypeNormal))
+}
+
+func TestConfigMapControlCreatesConfigMapFailed(t *testing.T) {
+	g := NewGomegaWithT(t)
+	recorder := record.NewFakeRecorder(10)
+	tc := newTidbCluster()
+	cm := newConfigMap(tc)
+	fakeClient := &fake.Clientset{}
+	control := NewRealConfigMapControl(fakeClient, nil, recorder)
+	fakeClient.AddReactor("create", "configmaps", func(action core.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewInternalError(errors.New("API server down"))
+	})
+	err := control.CreateConfigMap(tc, cm)
+	g.Expect(err).To(HaveOccurred())
+
+	events := collectEvents(recorder.Events)
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0]).To(ContainSubstring(corev1.EventTypeWarning))
+}
+
+func TestConfigMapControlUpdateConfigMapConflictSuccess(t *testing.T) {
+	g := NewGomegaWithT(t)
+	recorder := record.NewFakeRecorder(10)
+	tc := newTidbCluster()
+	cm := newConfigMap(tc)
+	cm.Data["config-file"] = "new"
+	fakeClient := &fake.Clientset{}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	oldCm := newConfigMap(tc)
+	err := indexer.Add(oldCm)
+	g.Expect(err).To(Succeed())
+	cmLister := corelisters.NewConfigMapLister(indexer)
+	control := NewRealConfigMapControl(fakeClient, cmLister, recorder)
+	conflict := false
+	fakeClient.AddReactor("update", "configmaps", func(action core.Action) (bool, runtime.Object, error) {
+		update := action.(core.UpdateAction)
+		if !conflict {
+			conflict = true
+			return true, oldCm, apierrors.NewConflict(action.GetResource().GroupResource(), cm.Name, errors.New("conflict"))
+		}
+		return true, update.GetObject(), nil
+	})
+	updateCm, err := control.UpdateConfigMap(tc, cm)
+	g.Expect(err).To(Succeed())
+	g.Expect(updateCm.Data["config-file"]).To(Equal("new"))
+
+	events := collectEvents(recorder.Events)
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0]).To(ContainSubstring(corev1.EventTypeNormal))
+}
+
+func newConfigMap(tc *v1alpha1.TidbCluster) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      TiProxyMemberName(tc.Name),
+			Namespace: metav1.NamespaceDefault,
+		},
+		Data: map[string]string{"config-file": "old"},
+	}
+}
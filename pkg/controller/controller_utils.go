@@ -18,11 +18,12 @@ import (
 	"time"
 
 	"github.com/dustin/go-humanize"
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	errorutils "k8s.io/apimachinery/pkg/util/errors"
 )
 
 var (
@@ -38,6 +39,9 @@ var (
 	// backupScheduleControllerKind contains the schema.GroupVersionKind for backupschedule controller type.
 	backupScheduleControllerKind = v1alpha1.SchemeGroupVersion.WithKind("BackupSchedule")
 
+	// TidbNGMonitoringControllerKind contains the schema.GroupVersionKind for tidbngmonitoring controller type.
+	TidbNGMonitoringControllerKind = v1alpha1.SchemeGroupVersion.WithKind("TidbNGMonitoring")
+
 	// DefaultStorageClassName is the default storageClassName
 	DefaultStorageClassName string
 
@@ -47,6 +51,13 @@ var (
 	// TidbBackupManagerImage is the image of tidb backup manager tool
 	TidbBackupManagerImage string
 
+	// BackupJobHistoryLimit is the number of the finished jobs of the Backups and Restores
+	// kept in each namespace, the older ones are deleted with their pods, 0 keeps all of them
+	BackupJobHistoryLimit int
+	// BackupJobTTL is how long the finished jobs of the Backups and Restores are kept
+	// before they are deleted with their pods, 0 keeps them forever
+	BackupJobTTL time.Duration
+
 	// ClusterScoped controls whether operator should manage kubernetes cluster wide TiDB clusters
 	ClusterScoped bool
 
@@ -54,6 +65,12 @@ var (
 	TestMode bool
 	// ResyncDuration is the resync time of informer
 	ResyncDuration time.Duration
+	// ServerSideApply controls whether the StatefulSets, Services and ConfigMaps generated by the
+	// operator are updated by the server-side apply, it requires Kubernetes 1.16 or later
+	ServerSideApply bool
+	// OpenShift controls whether the generated pods are adjusted for the security context
+	// constraints of OpenShift, the user and group ids are left to be assigned by the constraints
+	OpenShift bool
 )
 
 const (
@@ -63,7 +80,8 @@ const (
 
 // RequeueError is used to requeue the item, this error type should't be considered as a real error
 type RequeueError struct {
-	s string
+	s     string
+	after time.Duration
 }
 
 func (re *RequeueError) Error() string {
@@ -72,7 +90,13 @@ func (re *RequeueError) Error() string {
 
 // RequeueErrorf returns a RequeueError
 func RequeueErrorf(format string, a ...interface{}) error {
-	return &RequeueError{fmt.Sprintf(format, a...)}
+	return &RequeueError{s: fmt.Sprintf(format, a...)}
+}
+
+// RequeueAfterErrorf returns a RequeueError, the item is requeued after the duration
+// instead of the backoff if it's positive
+func RequeueAfterErrorf(after time.Duration, format string, a ...interface{}) error {
+	return &RequeueError{s: fmt.Sprintf(format, a...), after: after}
 }
 
 // IsRequeueError returns whether err is a RequeueError
@@ -81,6 +105,22 @@ func IsRequeueError(err error) bool {
 	return ok
 }
 
+// GetRequeueAfter returns the duration after which the item is requeued, it's 0 if err
+// is not a RequeueError or the item is requeued with the backoff
+func GetRequeueAfter(err error) time.Duration {
+	switch e := err.(type) {
+	case *RequeueError:
+		return e.after
+	case errorutils.Aggregate:
+		for _, err := range e.Errors() {
+			if after := GetRequeueAfter(err); after > 0 {
+				return after
+			}
+		}
+	}
+	return 0
+}
+
 // GetOwnerRef returns TidbCluster's OwnerReference
 func GetOwnerRef(tc *v1alpha1.TidbCluster) metav1.OwnerReference {
 	controller := true
@@ -137,6 +177,20 @@ func GetBackupScheduleOwnerRef(bs *v1alpha1.BackupSchedule) metav1.OwnerReferenc
 	}
 }
 
+// GetTidbNGMonitoringOwnerRef returns TidbNGMonitoring's OwnerReference
+func GetTidbNGMonitoringOwnerRef(tngm *v1alpha1.TidbNGMonitoring) metav1.OwnerReference {
+	controller := true
+	blockOwnerDeletion := true
+	return metav1.OwnerReference{
+		APIVersion:         TidbNGMonitoringControllerKind.GroupVersion().String(),
+		Kind:               TidbNGMonitoringControllerKind.Kind,
+		Name:               tngm.GetName(),
+		UID:                tngm.GetUID(),
+		Controller:         &controller,
+		BlockOwnerDeletion: &blockOwnerDeletion,
+	}
+}
+
 // GetServiceType returns member's service type
 func GetServiceType(services []v1alpha1.Service, serviceName string) corev1.ServiceType {
 	for _, svc := range services {
@@ -167,12 +221,12 @@ func TiKVCapacity(limits *v1alpha1.ResourceRequirement) string {
 	}
 	q, err := resource.ParseQuantity(limits.Storage)
 	if err != nil {
-		glog.Errorf("failed to parse quantity %s: %v", limits.Storage, err)
+		log.Errorf("failed to parse quantity %s: %v", limits.Storage, err)
 		return defaultArgs
 	}
 	i, b := q.AsInt64()
 	if !b {
-		glog.Errorf("quantity %s can't be converted to int64", q.String())
+		log.Errorf("quantity %s can't be converted to int64", q.String())
 		return defaultArgs
 	}
 	if i%humanize.GiByte == 0 {
@@ -218,6 +272,36 @@ func TiDBPeerMemberName(clusterName string) string {
 	return fmt.Sprintf("%s-tidb-peer", clusterName)
 }
 
+// TiProxyMemberName returns tiproxy member name
+func TiProxyMemberName(clusterName string) string {
+	return fmt.Sprintf("%s-tiproxy", clusterName)
+}
+
+// TiProxyPeerMemberName returns tiproxy peer service name
+func TiProxyPeerMemberName(clusterName string) string {
+	return fmt.Sprintf("%s-tiproxy-peer", clusterName)
+}
+
+// PDMSMemberName returns the member name of a pd microservice, e.g. tso or scheduling
+func PDMSMemberName(clusterName string, memberType v1alpha1.MemberType) string {
+	return fmt.Sprintf("%s-%s", clusterName, memberType)
+}
+
+// PDMSPeerMemberName returns the peer service name of a pd microservice
+func PDMSPeerMemberName(clusterName string, memberType v1alpha1.MemberType) string {
+	return fmt.Sprintf("%s-%s-peer", clusterName, memberType)
+}
+
+// DashboardMemberName returns the standalone dashboard member name
+func DashboardMemberName(clusterName string) string {
+	return fmt.Sprintf("%s-dashboard", clusterName)
+}
+
+// NGMonitoringMemberName returns ng-monitoring member name
+func NGMonitoringMemberName(name string) string {
+	return fmt.Sprintf("%s-ng-monitoring", name)
+}
+
 // AnnProm adds annotations for prometheus scraping metrics
 func AnnProm(port int32) map[string]string {
 	return map[string]string{
@@ -16,16 +16,18 @@ package controller
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/pingcap/tidb-operator/pkg/label"
 
 	. "github.com/onsi/gomega"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
-	apps "k8s.io/api/apps/v1beta1"
+	apps "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	errorutils "k8s.io/apimachinery/pkg/util/errors"
 )
 
 func TestRequeueError(t *testing.T) {
@@ -39,6 +41,18 @@ func TestRequeueError(t *testing.T) {
 	g.Expect(IsRequeueError(fmt.Errorf("i am not a requeue error"))).To(BeFalse())
 }
 
+func TestGetRequeueAfter(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	err := RequeueAfterErrorf(time.Minute, "i am a requeue %s", "error")
+	g.Expect(IsRequeueError(err)).To(BeTrue())
+	g.Expect(err.Error()).To(Equal("i am a requeue error"))
+	g.Expect(GetRequeueAfter(err)).To(Equal(time.Minute))
+	g.Expect(GetRequeueAfter(errorutils.NewAggregate([]error{fmt.Errorf("i am not a requeue error"), err}))).To(Equal(time.Minute))
+	g.Expect(GetRequeueAfter(RequeueErrorf("i am a requeue error"))).To(BeZero())
+	g.Expect(GetRequeueAfter(fmt.Errorf("i am not a requeue error"))).To(BeZero())
+}
+
 func TestGetOwnerRef(t *testing.T) {
 	g := NewGomegaWithT(t)
 
@@ -198,6 +212,32 @@ func TestTiDBPeerMemberName(t *testing.T) {
 	g.Expect(TiDBPeerMemberName("demo")).To(Equal("demo-tidb-peer"))
 }
 
+func TestTiProxyMemberName(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(TiProxyMemberName("demo")).To(Equal("demo-tiproxy"))
+}
+
+func TestTiProxyPeerMemberName(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(TiProxyPeerMemberName("demo")).To(Equal("demo-tiproxy-peer"))
+}
+
+func TestPDMSMemberName(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(PDMSMemberName("demo", v1alpha1.PDTSOMemberType)).To(Equal("demo-tso"))
+	g.Expect(PDMSPeerMemberName("demo", v1alpha1.PDSchedulingMemberType)).To(Equal("demo-scheduling-peer"))
+}
+
+func TestDashboardMemberName(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(DashboardMemberName("demo")).To(Equal("demo-dashboard"))
+}
+
+func TestNGMonitoringMemberName(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(NGMonitoringMemberName("demo")).To(Equal("demo-ng-monitoring"))
+}
+
 func TestAnnProm(t *testing.T) {
 	g := NewGomegaWithT(t)
 
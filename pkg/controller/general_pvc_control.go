@@ -17,8 +17,8 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	coreinformers "k8s.io/client-go/informers/core/v1"
@@ -31,6 +31,7 @@ import (
 // GeneralPVCControlInterface manages PVCs used in backup and restore's pvc
 type GeneralPVCControlInterface interface {
 	CreatePVC(object runtime.Object, pvc *corev1.PersistentVolumeClaim) error
+	DeletePVC(object runtime.Object, pvc *corev1.PersistentVolumeClaim) error
 }
 
 type realGeneralPVCControl struct {
@@ -57,14 +58,30 @@ func (gpc *realGeneralPVCControl) CreatePVC(object runtime.Object, pvc *corev1.P
 
 	_, err := gpc.kubeCli.CoreV1().PersistentVolumeClaims(ns).Create(pvc)
 	if err != nil {
-		glog.Errorf("failed to create pvc: [%s/%s], %s: %s, %v", ns, pvcName, kind, instanceName, err)
+		log.Errorf("failed to create pvc: [%s/%s], %s: %s, %v", ns, pvcName, kind, instanceName, err)
 	} else {
-		glog.V(4).Infof("create pvc: [%s/%s] successfully, %s: %s", ns, pvcName, kind, instanceName)
+		log.V(4).Infof("create pvc: [%s/%s] successfully, %s: %s", ns, pvcName, kind, instanceName)
 	}
 	gpc.recordPVCEvent("create", object, pvc, err)
 	return err
 }
 
+func (gpc *realGeneralPVCControl) DeletePVC(object runtime.Object, pvc *corev1.PersistentVolumeClaim) error {
+	ns := pvc.GetNamespace()
+	pvcName := pvc.GetName()
+	instanceName := pvc.GetLabels()[label.InstanceLabelKey]
+	kind := object.GetObjectKind().GroupVersionKind().Kind
+
+	err := gpc.kubeCli.CoreV1().PersistentVolumeClaims(ns).Delete(pvcName, nil)
+	if err != nil {
+		log.Errorf("failed to delete pvc: [%s/%s], %s: %s, %v", ns, pvcName, kind, instanceName, err)
+	} else {
+		log.V(4).Infof("delete pvc: [%s/%s] successfully, %s: %s", ns, pvcName, kind, instanceName)
+	}
+	gpc.recordPVCEvent("delete", object, pvc, err)
+	return err
+}
+
 func (gpc *realGeneralPVCControl) recordPVCEvent(verb string, obj runtime.Object, pvc *corev1.PersistentVolumeClaim, err error) {
 	pvcName := pvc.GetName()
 	ns := pvc.GetNamespace()
@@ -90,6 +107,7 @@ type FakeGeneralPVCControl struct {
 	PVCLister        corelisters.PersistentVolumeClaimLister
 	PVCIndexer       cache.Indexer
 	createPVCTracker requestTracker
+	deletePVCTracker requestTracker
 }
 
 // NewFakeGeneralPVCControl returns a FakeGeneralPVCControl
@@ -98,6 +116,7 @@ func NewFakeGeneralPVCControl(pvcInformer coreinformers.PersistentVolumeClaimInf
 		pvcInformer.Lister(),
 		pvcInformer.Informer().GetIndexer(),
 		requestTracker{0, nil, 0},
+		requestTracker{0, nil, 0},
 	}
 }
 
@@ -107,6 +126,12 @@ func (fjc *FakeGeneralPVCControl) SetCreatePVCError(err error, after int) {
 	fjc.createPVCTracker.after = after
 }
 
+// SetDeletePVCError sets the error attributes of deletePVCTracker
+func (fjc *FakeGeneralPVCControl) SetDeletePVCError(err error, after int) {
+	fjc.deletePVCTracker.err = err
+	fjc.deletePVCTracker.after = after
+}
+
 // CreatePVC adds the pvc to PVCIndexer
 func (fjc *FakeGeneralPVCControl) CreatePVC(_ runtime.Object, pvc *corev1.PersistentVolumeClaim) error {
 	defer fjc.createPVCTracker.inc()
@@ -118,4 +143,15 @@ func (fjc *FakeGeneralPVCControl) CreatePVC(_ runtime.Object, pvc *corev1.Persis
 	return fjc.PVCIndexer.Add(pvc)
 }
 
+// DeletePVC deletes the pvc from PVCIndexer
+func (fjc *FakeGeneralPVCControl) DeletePVC(_ runtime.Object, pvc *corev1.PersistentVolumeClaim) error {
+	defer fjc.deletePVCTracker.inc()
+	if fjc.deletePVCTracker.errorReady() {
+		defer fjc.deletePVCTracker.reset()
+		return fjc.deletePVCTracker.err
+	}
+
+	return fjc.PVCIndexer.Delete(pvc)
+}
+
 var _ GeneralPVCControlInterface = &FakeGeneralPVCControl{}
@@ -62,3 +62,39 @@ func TestGeneralPVCControlCreatesPVCFailed(t *testing.T) {
 	g.Expect(events).To(HaveLen(1))
 	g.Expect(events[0]).To(ContainSubstring(corev1.EventTypeWarning))
 }
+
+func TestGeneralPVCControlDeletePVCSuccess(t *testing.T) {
+	g := NewGomegaWithT(t)
+	recorder := record.NewFakeRecorder(10)
+	backup := newBackup()
+	pvc := newPVCFromBackup(backup)
+	fakeClient := &fake.Clientset{}
+	control := NewRealGeneralPVCControl(fakeClient, recorder)
+	fakeClient.AddReactor("delete", "persistentvolumeclaims", func(action core.Action) (bool, runtime.Object, error) {
+		return true, nil, nil
+	})
+	err := control.DeletePVC(backup, pvc)
+	g.Expect(err).To(Succeed())
+
+	events := collectEvents(recorder.Events)
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0]).To(ContainSubstring(corev1.EventTypeNormal))
+}
+
+func TestGeneralPVCControlDeletePVCFailed(t *testing.T) {
+	g := NewGomegaWithT(t)
+	recorder := record.NewFakeRecorder(10)
+	backup := newBackup()
+	pvc := newPVCFromBackup(backup)
+	fakeClient := &fake.Clientset{}
+	control := NewRealGeneralPVCControl(fakeClient, recorder)
+	fakeClient.AddReactor("delete", "persistentvolumeclaims", func(action core.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewInternalError(errors.New("API server down"))
+	})
+	err := control.DeletePVC(backup, pvc)
+	g.Expect(err).To(HaveOccurred())
+
+	events := collectEvents(recorder.Events)
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0]).To(ContainSubstring(corev1.EventTypeWarning))
+}
@@ -0,0 +1,193 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	"github.com/pingcap/tidb-operator/pkg/tracing"
+	corev1 "k8s.io/api/core/v1"
+	extv1beta1 "k8s.io/api/extensions/v1beta1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	extinformers "k8s.io/client-go/informers/extensions/v1beta1"
+	"k8s.io/client-go/kubernetes"
+	extlisters "k8s.io/client-go/listers/extensions/v1beta1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+)
+
+// IngressControlInterface manages Ingresses generated by the operator for TidbCluster
+type IngressControlInterface interface {
+	CreateIngress(*v1alpha1.TidbCluster, *extv1beta1.Ingress) error
+	UpdateIngress(*v1alpha1.TidbCluster, *extv1beta1.Ingress) (*extv1beta1.Ingress, error)
+	DeleteIngress(*v1alpha1.TidbCluster, *extv1beta1.Ingress) error
+}
+
+type realIngressControl struct {
+	kubeCli       kubernetes.Interface
+	ingressLister extlisters.IngressLister
+	recorder      record.EventRecorder
+}
+
+// NewRealIngressControl creates a new IngressControlInterface
+func NewRealIngressControl(kubeCli kubernetes.Interface, ingressLister extlisters.IngressLister, recorder record.EventRecorder) IngressControlInterface {
+	return &realIngressControl{
+		kubeCli,
+		ingressLister,
+		recorder,
+	}
+}
+
+func (ic *realIngressControl) CreateIngress(tc *v1alpha1.TidbCluster, ingress *extv1beta1.Ingress) error {
+	span := tracing.StartCall(tc.GetNamespace(), tc.GetName(), "CreateIngress")
+	_, err := ic.kubeCli.ExtensionsV1beta1().Ingresses(tc.Namespace).Create(ingress)
+	tracing.FinishCall(span, err)
+	ic.recordIngressEvent("create", tc, ingress, err)
+	return err
+}
+
+func (ic *realIngressControl) UpdateIngress(tc *v1alpha1.TidbCluster, ingress *extv1beta1.Ingress) (*extv1beta1.Ingress, error) {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	ingressName := ingress.GetName()
+	annotations := ingress.Annotations
+	ingressSpec := ingress.Spec
+
+	var updateIngress *extv1beta1.Ingress
+	span := tracing.StartCall(tc.GetNamespace(), tc.GetName(), "UpdateIngress")
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		var updateErr error
+		updateIngress, updateErr = ic.kubeCli.ExtensionsV1beta1().Ingresses(ns).Update(ingress)
+		if updateErr == nil {
+			log.Infof("update Ingress: [%s/%s] successfully, TidbCluster: %s", ns, ingressName, tcName)
+			return nil
+		}
+
+		if updated, err := ic.ingressLister.Ingresses(ns).Get(ingressName); err != nil {
+			utilruntime.HandleError(fmt.Errorf("error getting updated Ingress %s/%s from lister: %v", ns, ingressName, err))
+		} else {
+			ingress = updated.DeepCopy()
+			ingress.Annotations = annotations
+			ingress.Spec = ingressSpec
+		}
+
+		return updateErr
+	})
+	tracing.FinishCall(span, err)
+	ic.recordIngressEvent("update", tc, ingress, err)
+	return updateIngress, err
+}
+
+func (ic *realIngressControl) DeleteIngress(tc *v1alpha1.TidbCluster, ingress *extv1beta1.Ingress) error {
+	span := tracing.StartCall(tc.GetNamespace(), tc.GetName(), "DeleteIngress")
+	err := ic.kubeCli.ExtensionsV1beta1().Ingresses(tc.Namespace).Delete(ingress.Name, nil)
+	tracing.FinishCall(span, err)
+	ic.recordIngressEvent("delete", tc, ingress, err)
+	return err
+}
+
+func (ic *realIngressControl) recordIngressEvent(verb string, tc *v1alpha1.TidbCluster, ingress *extv1beta1.Ingress, err error) {
+	tcName := tc.GetName()
+	ingressName := ingress.GetName()
+	if err == nil {
+		reason := fmt.Sprintf("Successful%s", strings.Title(verb))
+		msg := fmt.Sprintf("%s Ingress %s in TidbCluster %s successful",
+			strings.ToLower(verb), ingressName, tcName)
+		ic.recorder.Event(tc, corev1.EventTypeNormal, reason, msg)
+	} else {
+		reason := fmt.Sprintf("Failed%s", strings.Title(verb))
+		msg := fmt.Sprintf("%s Ingress %s in TidbCluster %s failed error: %s",
+			strings.ToLower(verb), ingressName, tcName, err)
+		ic.recorder.Event(tc, corev1.EventTypeWarning, reason, msg)
+	}
+}
+
+var _ IngressControlInterface = &realIngressControl{}
+
+// FakeIngressControl is a fake IngressControlInterface
+type FakeIngressControl struct {
+	IngressLister        extlisters.IngressLister
+	IngressIndexer       cache.Indexer
+	createIngressTracker requestTracker
+	updateIngressTracker requestTracker
+	deleteIngressTracker requestTracker
+}
+
+// NewFakeIngressControl returns a FakeIngressControl
+func NewFakeIngressControl(ingressInformer extinformers.IngressInformer) *FakeIngressControl {
+	return &FakeIngressControl{
+		ingressInformer.Lister(),
+		ingressInformer.Informer().GetIndexer(),
+		requestTracker{0, nil, 0},
+		requestTracker{0, nil, 0},
+		requestTracker{0, nil, 0},
+	}
+}
+
+// SetCreateIngressError sets the error attributes of createIngressTracker
+func (fic *FakeIngressControl) SetCreateIngressError(err error, after int) {
+	fic.createIngressTracker.err = err
+	fic.createIngressTracker.after = after
+}
+
+// SetUpdateIngressError sets the error attributes of updateIngressTracker
+func (fic *FakeIngressControl) SetUpdateIngressError(err error, after int) {
+	fic.updateIngressTracker.err = err
+	fic.updateIngressTracker.after = after
+}
+
+// SetDeleteIngressError sets the error attributes of deleteIngressTracker
+func (fic *FakeIngressControl) SetDeleteIngressError(err error, after int) {
+	fic.deleteIngressTracker.err = err
+	fic.deleteIngressTracker.after = after
+}
+
+// CreateIngress adds the ingress to IngressIndexer
+func (fic *FakeIngressControl) CreateIngress(_ *v1alpha1.TidbCluster, ingress *extv1beta1.Ingress) error {
+	defer fic.createIngressTracker.inc()
+	if fic.createIngressTracker.errorReady() {
+		defer fic.createIngressTracker.reset()
+		return fic.createIngressTracker.err
+	}
+
+	return fic.IngressIndexer.Add(ingress)
+}
+
+// UpdateIngress updates the ingress of IngressIndexer
+func (fic *FakeIngressControl) UpdateIngress(_ *v1alpha1.TidbCluster, ingress *extv1beta1.Ingress) (*extv1beta1.Ingress, error) {
+	defer fic.updateIngressTracker.inc()
+	if fic.updateIngressTracker.errorReady() {
+		defer fic.updateIngressTracker.reset()
+		return nil, fic.updateIngressTracker.err
+	}
+
+	return ingress, fic.IngressIndexer.Update(ingress)
+}
+
+// DeleteIngress deletes the ingress from IngressIndexer
+func (fic *FakeIngressControl) DeleteIngress(_ *v1alpha1.TidbCluster, ingress *extv1beta1.Ingress) error {
+	defer fic.deleteIngressTracker.inc()
+	if fic.deleteIngressTracker.errorReady() {
+		defer fic.deleteIngressTracker.reset()
+		return fic.deleteIngressTracker.err
+	}
+
+	return fic.IngressIndexer.Delete(ingress)
+}
+
+var _ IngressControlInterface = &FakeIngressControl{}
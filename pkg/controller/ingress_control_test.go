@@ -0,0 +1,111 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	extv1beta1 "k8s.io/api/extensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	extlisters "k8s.io/client-go/listers/extensions/v1beta1"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestIngressControlCreatesIngress(t *testing.T) {
+	g := NewGomegaWithT(t)
+	recorder := record.NewFakeRecorder(10)
+	tc := newTidbCluster()
+	ingress := newIngress(tc)
+	fakeClient := &fake.Clientset{}
+	control := NewRealIngressControl(fakeClient, nil, recorder)
+	fakeClient.AddReactor("create", "ingresses", func(action core.Action) (bool, runtime.Object, error) {
+		create := action.(core.CreateAction)
+		return true, create.GetObject(), nil
+	})
+	err := control.CreateIngress(tc, ingress)
+	g.Expect(err).To(Succeed())
+
+	events := collectEvents(recorder.Events)
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0]).To(ContainSubstring(corev1.EventTypeNormal))
+}
+
+func TestIngressControlUpdateIngressConflictSuccess(t *testing.T) {
+	g := NewGomegaWithT(t)
+	recorder := record.NewFakeRecorder(10)
+	tc := newTidbCluster()
+	ingress := newIngress(tc)
+	ingress.Spec.Rules[0].Host = "new.example.com"
+	fakeClient := &fake.Clientset{}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	oldIngress := newIngress(tc)
+	err := indexer.Add(oldIngress)
+	g.Expect(err).To(Succeed())
+	control := NewRealIngressControl(fakeClient, extlisters.NewIngressLister(indexer), recorder)
+	conflict := false
+	fakeClient.AddReactor("update", "ingresses", func(action core.Action) (bool, runtime.Object, error) {
+		update := action.(core.UpdateAction)
+		if !conflict {
+			conflict = true
+			return true, oldIngress, apierrors.NewConflict(action.GetResource().GroupResource(), ingress.Name, errors.New("conflict"))
+		}
+		return true, update.GetObject(), nil
+	})
+	updateIngress, err := control.UpdateIngress(tc, ingress)
+	g.Expect(err).To(Succeed())
+	g.Expect(updateIngress.Spec.Rules[0].Host).To(Equal("new.example.com"))
+
+	events := collectEvents(recorder.Events)
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0]).To(ContainSubstring(corev1.EventTypeNormal))
+}
+
+func TestIngressControlDeleteIngressFailed(t *testing.T) {
+	g := NewGomegaWithT(t)
+	recorder := record.NewFakeRecorder(10)
+	tc := newTidbCluster()
+	ingress := newIngress(tc)
+	fakeClient := &fake.Clientset{}
+	control := NewRealIngressControl(fakeClient, nil, recorder)
+	fakeClient.AddReactor("delete", "ingresses", func(action core.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewInternalError(errors.New("API server down"))
+	})
+	err := control.DeleteIngress(tc, ingress)
+	g.Expect(err).To(HaveOccurred())
+
+	events := collectEvents(recorder.Events)
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0]).To(ContainSubstring(corev1.EventTypeWarning))
+}
+
+func newIngress(tc *v1alpha1.TidbCluster) *extv1beta1.Ingress {
+	return &extv1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      DashboardMemberName(tc.Name),
+			Namespace: metav1.NamespaceDefault,
+		},
+		Spec: extv1beta1.IngressSpec{
+			Rules: []extv1beta1.IngressRule{{Host: "old.example.com"}},
+		},
+	}
+}
@@ -17,8 +17,8 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -60,9 +60,9 @@ func (rjc *realJobControl) CreateJob(object runtime.Object, job *batchv1.Job) er
 
 	_, err := rjc.kubeCli.BatchV1().Jobs(ns).Create(job)
 	if err != nil {
-		glog.Errorf("failed to create %s job: [%s/%s], cluster: %s, err: %v", strings.ToLower(kind), ns, jobName, instanceName, err)
+		log.Errorf("failed to create %s job: [%s/%s], cluster: %s, err: %v", strings.ToLower(kind), ns, jobName, instanceName, err)
 	} else {
-		glog.V(4).Infof("create %s job: [%s/%s] successfully, cluster: %s", strings.ToLower(kind), ns, jobName, instanceName)
+		log.V(4).Infof("create %s job: [%s/%s] successfully, cluster: %s", strings.ToLower(kind), ns, jobName, instanceName)
 	}
 	rjc.recordJobEvent("create", object, job, err)
 	return err
@@ -80,9 +80,9 @@ func (rjc *realJobControl) DeleteJob(object runtime.Object, job *batchv1.Job) er
 	}
 	err := rjc.kubeCli.BatchV1().Jobs(ns).Delete(jobName, opts)
 	if err != nil {
-		glog.Errorf("failed to delete %s job: [%s/%s], cluster: %s, err: %v", strings.ToLower(kind), ns, jobName, instanceName, err)
+		log.Errorf("failed to delete %s job: [%s/%s], cluster: %s, err: %v", strings.ToLower(kind), ns, jobName, instanceName, err)
 	} else {
-		glog.V(4).Infof("delete %s job: [%s/%s] successfully, cluster: %s", strings.ToLower(kind), ns, jobName, instanceName)
+		log.V(4).Infof("delete %s job: [%s/%s] successfully, cluster: %s", strings.ToLower(kind), ns, jobName, instanceName)
 	}
 	rjc.recordJobEvent("delete", object, job, err)
 	return err
@@ -18,10 +18,11 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	"github.com/pingcap/tidb-operator/pkg/tracing"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -73,14 +74,15 @@ func (rpc *realPodControl) UpdatePod(tc *v1alpha1.TidbCluster, pod *corev1.Pod)
 
 	var updatePod *corev1.Pod
 	// don't wait due to limited number of clients, but backoff after the default number of steps
+	span := tracing.StartCall(tc.GetNamespace(), tc.GetName(), "UpdatePod")
 	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		var updateErr error
 		updatePod, updateErr = rpc.kubeCli.CoreV1().Pods(ns).Update(pod)
 		if updateErr == nil {
-			glog.Infof("Pod: [%s/%s] updated successfully, TidbCluster: [%s/%s]", ns, podName, ns, tcName)
+			log.Infof("Pod: [%s/%s] updated successfully, TidbCluster: [%s/%s]", ns, podName, ns, tcName)
 			return nil
 		}
-		glog.Errorf("failed to update Pod: [%s/%s], error: %v", ns, podName, updateErr)
+		log.Errorf("failed to update Pod: [%s/%s], error: %v", ns, podName, updateErr)
 
 		if updated, err := rpc.podLister.Pods(ns).Get(podName); err == nil {
 			// make a copy so we don't mutate the shared cache
@@ -93,6 +95,7 @@ func (rpc *realPodControl) UpdatePod(tc *v1alpha1.TidbCluster, pod *corev1.Pod)
 
 		return updateErr
 	})
+	tracing.FinishCall(span, err)
 	rpc.recordPodEvent("update", tc, podName, err)
 	return updatePod, err
 }
@@ -157,7 +160,7 @@ func (rpc *realPodControl) UpdateMetaInfo(tc *v1alpha1.TidbCluster, pod *corev1.
 	if labels[label.ClusterIDLabelKey] == clusterID &&
 		labels[label.MemberIDLabelKey] == memberID &&
 		labels[label.StoreIDLabelKey] == storeID {
-		glog.V(4).Infof("pod %s/%s already has cluster labels set, skipping. TidbCluster: %s", ns, podName, tcName)
+		log.V(4).Infof("pod %s/%s already has cluster labels set, skipping. TidbCluster: %s", ns, podName, tcName)
 		return pod, nil
 	}
 	// labels is a pointer, modify labels will modify pod.Labels
@@ -166,14 +169,15 @@ func (rpc *realPodControl) UpdateMetaInfo(tc *v1alpha1.TidbCluster, pod *corev1.
 	setIfNotEmpty(labels, label.StoreIDLabelKey, storeID)
 
 	var updatePod *corev1.Pod
+	span := tracing.StartCall(tc.GetNamespace(), tc.GetName(), "UpdatePodMetaInfo")
 	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 		var updateErr error
 		updatePod, updateErr = rpc.kubeCli.CoreV1().Pods(ns).Update(pod)
 		if updateErr == nil {
-			glog.V(4).Infof("update pod %s/%s with cluster labels %v successfully, TidbCluster: %s", ns, podName, labels, tcName)
+			log.V(4).Infof("update pod %s/%s with cluster labels %v successfully, TidbCluster: %s", ns, podName, labels, tcName)
 			return nil
 		}
-		glog.Errorf("failed to update pod %s/%s with cluster labels %v, TidbCluster: %s, err: %v", ns, podName, labels, tcName, updateErr)
+		log.Errorf("failed to update pod %s/%s with cluster labels %v, TidbCluster: %s, err: %v", ns, podName, labels, tcName, updateErr)
 
 		if updated, err := rpc.podLister.Pods(ns).Get(podName); err == nil {
 			// make a copy so we don't mutate the shared cache
@@ -184,6 +188,7 @@ func (rpc *realPodControl) UpdateMetaInfo(tc *v1alpha1.TidbCluster, pod *corev1.
 		}
 		return updateErr
 	})
+	tracing.FinishCall(span, err)
 
 	rpc.recordPodEvent("update", tc, podName, err)
 	return updatePod, err
@@ -195,11 +200,13 @@ func (rpc *realPodControl) DeletePod(tc *v1alpha1.TidbCluster, pod *corev1.Pod)
 	podName := pod.GetName()
 	preconditions := metav1.Preconditions{UID: &pod.UID}
 	deleteOptions := metav1.DeleteOptions{Preconditions: &preconditions}
+	span := tracing.StartCall(tc.GetNamespace(), tc.GetName(), "DeletePod")
 	err := rpc.kubeCli.CoreV1().Pods(ns).Delete(podName, &deleteOptions)
+	tracing.FinishCall(span, err)
 	if err != nil {
-		glog.Errorf("failed to delete Pod: [%s/%s], TidbCluster: %s, %v", ns, podName, tcName, err)
+		log.Errorf("failed to delete Pod: [%s/%s], TidbCluster: %s, %v", ns, podName, tcName, err)
 	} else {
-		glog.V(4).Infof("delete Pod: [%s/%s] successfully, TidbCluster: %s", ns, podName, tcName)
+		log.V(4).Infof("delete Pod: [%s/%s] successfully, TidbCluster: %s", ns, podName, tcName)
 	}
 	rpc.recordPodEvent("delete", tc, podName, err)
 	return err
@@ -17,9 +17,10 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	"github.com/pingcap/tidb-operator/pkg/tracing"
 	corev1 "k8s.io/api/core/v1"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
@@ -64,10 +65,12 @@ func (rpc *realPVControl) PatchPVReclaimPolicy(tc *v1alpha1.TidbCluster, pv *cor
 	pvName := pv.GetName()
 	patchBytes := []byte(fmt.Sprintf(`{"spec":{"persistentVolumeReclaimPolicy":"%s"}}`, reclaimPolicy))
 
+	span := tracing.StartCall(tc.GetNamespace(), tc.GetName(), "PatchPVReclaimPolicy")
 	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 		_, err := rpc.kubeCli.CoreV1().PersistentVolumes().Patch(pvName, types.StrategicMergePatchType, patchBytes)
 		return err
 	})
+	tracing.FinishCall(span, err)
 	rpc.recordPVEvent("patch", tc, pvName, err)
 	return err
 }
@@ -85,7 +88,7 @@ func (rpc *realPVControl) UpdateMetaInfo(tc *v1alpha1.TidbCluster, pv *corev1.Pe
 	pvName := pv.GetName()
 	pvcRef := pv.Spec.ClaimRef
 	if pvcRef == nil {
-		glog.Warningf("PV: [%s] doesn't have a ClaimRef, skipping, TidbCluster: %s/%s", pvName, ns, tcName)
+		log.Warningf("PV: [%s] doesn't have a ClaimRef, skipping, TidbCluster: %s/%s", pvName, ns, tcName)
 		return pv, nil
 	}
 
@@ -96,7 +99,7 @@ func (rpc *realPVControl) UpdateMetaInfo(tc *v1alpha1.TidbCluster, pv *corev1.Pe
 			return pv, err
 		}
 
-		glog.Warningf("PV: [%s]'s PVC: [%s/%s] doesn't exist, skipping. TidbCluster: %s", pvName, ns, pvcName, tcName)
+		log.Warningf("PV: [%s]'s PVC: [%s/%s] doesn't exist, skipping. TidbCluster: %s", pvName, ns, pvcName, tcName)
 		return pv, nil
 	}
 
@@ -115,7 +118,7 @@ func (rpc *realPVControl) UpdateMetaInfo(tc *v1alpha1.TidbCluster, pv *corev1.Pe
 		pv.Labels[label.MemberIDLabelKey] == memberID &&
 		pv.Labels[label.StoreIDLabelKey] == storeID &&
 		pv.Annotations[label.AnnPodNameKey] == podName {
-		glog.V(4).Infof("pv %s already has labels and annotations synced, skipping. TidbCluster: %s/%s", pvName, ns, tcName)
+		log.V(4).Infof("pv %s already has labels and annotations synced, skipping. TidbCluster: %s/%s", pvName, ns, tcName)
 		return pv, nil
 	}
 
@@ -133,14 +136,15 @@ func (rpc *realPVControl) UpdateMetaInfo(tc *v1alpha1.TidbCluster, pv *corev1.Pe
 	labels := pv.GetLabels()
 	ann := pv.GetAnnotations()
 	var updatePV *corev1.PersistentVolume
+	span := tracing.StartCall(tc.GetNamespace(), tc.GetName(), "UpdatePVMetaInfo")
 	err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 		var updateErr error
 		updatePV, updateErr = rpc.kubeCli.CoreV1().PersistentVolumes().Update(pv)
 		if updateErr == nil {
-			glog.Infof("PV: [%s] updated successfully, TidbCluster: %s/%s", pvName, ns, tcName)
+			log.Infof("PV: [%s] updated successfully, TidbCluster: %s/%s", pvName, ns, tcName)
 			return nil
 		}
-		glog.Errorf("failed to update PV: [%s], TidbCluster %s/%s, error: %v", pvName, ns, tcName, err)
+		log.Errorf("failed to update PV: [%s], TidbCluster %s/%s, error: %v", pvName, ns, tcName, err)
 
 		if updated, err := rpc.pvLister.Get(pvName); err == nil {
 			// make a copy so we don't mutate the shared cache
@@ -152,6 +156,7 @@ func (rpc *realPVControl) UpdateMetaInfo(tc *v1alpha1.TidbCluster, pv *corev1.Pe
 		}
 		return updateErr
 	})
+	tracing.FinishCall(span, err)
 
 	rpc.recordPVEvent("update", tc, pvName, err)
 	return updatePV, err
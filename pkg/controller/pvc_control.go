@@ -17,9 +17,10 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	"github.com/pingcap/tidb-operator/pkg/tracing"
 	corev1 "k8s.io/api/core/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	coreinformers "k8s.io/client-go/informers/core/v1"
@@ -61,11 +62,13 @@ func (rpc *realPVCControl) DeletePVC(tc *v1alpha1.TidbCluster, pvc *corev1.Persi
 	ns := tc.GetNamespace()
 	tcName := tc.GetName()
 	pvcName := pvc.GetName()
+	span := tracing.StartCall(tc.GetNamespace(), tc.GetName(), "DeletePVC")
 	err := rpc.kubeCli.CoreV1().PersistentVolumeClaims(tc.GetNamespace()).Delete(pvcName, nil)
+	tracing.FinishCall(span, err)
 	if err != nil {
-		glog.Errorf("failed to delete PVC: [%s/%s], TidbCluster: %s, %v", ns, pvcName, tcName, err)
+		log.Errorf("failed to delete PVC: [%s/%s], TidbCluster: %s, %v", ns, pvcName, tcName, err)
 	}
-	glog.V(4).Infof("delete PVC: [%s/%s] successfully, TidbCluster: %s", ns, pvcName, tcName)
+	log.V(4).Infof("delete PVC: [%s/%s] successfully, TidbCluster: %s", ns, pvcName, tcName)
 	rpc.recordPVCEvent("delete", tc, pvcName, err)
 	return err
 }
@@ -78,14 +81,15 @@ func (rpc *realPVCControl) UpdatePVC(tc *v1alpha1.TidbCluster, pvc *corev1.Persi
 	labels := pvc.GetLabels()
 	ann := pvc.GetAnnotations()
 	var updatePVC *corev1.PersistentVolumeClaim
+	span := tracing.StartCall(tc.GetNamespace(), tc.GetName(), "UpdatePVC")
 	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 		var updateErr error
 		updatePVC, updateErr = rpc.kubeCli.CoreV1().PersistentVolumeClaims(ns).Update(pvc)
 		if updateErr == nil {
-			glog.Infof("update PVC: [%s/%s] successfully, TidbCluster: %s", ns, pvcName, tcName)
+			log.Infof("update PVC: [%s/%s] successfully, TidbCluster: %s", ns, pvcName, tcName)
 			return nil
 		}
-		glog.Errorf("failed to update PVC: [%s/%s], TidbCluster: %s, error: %v", ns, pvcName, tcName, updateErr)
+		log.Errorf("failed to update PVC: [%s/%s], TidbCluster: %s, error: %v", ns, pvcName, tcName, updateErr)
 
 		if updated, err := rpc.pvcLister.PersistentVolumeClaims(ns).Get(pvcName); err == nil {
 			// make a copy so we don't mutate the shared cache
@@ -98,6 +102,7 @@ func (rpc *realPVCControl) UpdatePVC(tc *v1alpha1.TidbCluster, pvc *corev1.Persi
 
 		return updateErr
 	})
+	tracing.FinishCall(span, err)
 	rpc.recordPVCEvent("update", tc, pvcName, err)
 	return updatePVC, err
 }
@@ -124,7 +129,7 @@ func (rpc *realPVCControl) UpdateMetaInfo(tc *v1alpha1.TidbCluster, pvc *corev1.
 		pvc.Labels[label.MemberIDLabelKey] == memberID &&
 		pvc.Labels[label.StoreIDLabelKey] == storeID &&
 		pvc.Annotations[label.AnnPodNameKey] == podName {
-		glog.V(4).Infof("pvc %s/%s already has labels and annotations synced, skipping, TidbCluster: %s", ns, pvcName, tcName)
+		log.V(4).Infof("pvc %s/%s already has labels and annotations synced, skipping, TidbCluster: %s", ns, pvcName, tcName)
 		return pvc, nil
 	}
 
@@ -136,14 +141,15 @@ func (rpc *realPVCControl) UpdateMetaInfo(tc *v1alpha1.TidbCluster, pvc *corev1.
 	labels := pvc.GetLabels()
 	ann := pvc.GetAnnotations()
 	var updatePVC *corev1.PersistentVolumeClaim
+	span := tracing.StartCall(tc.GetNamespace(), tc.GetName(), "UpdatePVCMetaInfo")
 	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 		var updateErr error
 		updatePVC, updateErr = rpc.kubeCli.CoreV1().PersistentVolumeClaims(ns).Update(pvc)
 		if updateErr == nil {
-			glog.V(4).Infof("update PVC: [%s/%s] successfully, TidbCluster: %s", ns, pvcName, tcName)
+			log.V(4).Infof("update PVC: [%s/%s] successfully, TidbCluster: %s", ns, pvcName, tcName)
 			return nil
 		}
-		glog.Errorf("failed to update PVC: [%s/%s], TidbCluster: %s, error: %v", ns, pvcName, tcName, updateErr)
+		log.Errorf("failed to update PVC: [%s/%s], TidbCluster: %s, error: %v", ns, pvcName, tcName, updateErr)
 
 		if updated, err := rpc.pvcLister.PersistentVolumeClaims(ns).Get(pvcName); err == nil {
 			// make a copy so we don't mutate the shared cache
@@ -156,6 +162,7 @@ func (rpc *realPVCControl) UpdateMetaInfo(tc *v1alpha1.TidbCluster, pvc *corev1.
 
 		return updateErr
 	})
+	tracing.FinishCall(span, err)
 	rpc.recordPVCEvent("update", tc, pvcName, err)
 	return updatePVC, err
 }
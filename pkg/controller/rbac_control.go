@@ -0,0 +1,191 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tidb-operator/pkg/log"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	rbacinformers "k8s.io/client-go/informers/rbac/v1"
+	"k8s.io/client-go/kubernetes"
+	rbaclisters "k8s.io/client-go/listers/rbac/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+// RBACControlInterface manages Roles and RoleBindings of the ServiceAccounts used in backup and restore
+type RBACControlInterface interface {
+	CreateRole(object runtime.Object, role *rbacv1.Role) error
+	UpdateRole(object runtime.Object, role *rbacv1.Role) error
+	CreateRoleBinding(object runtime.Object, rb *rbacv1.RoleBinding) error
+}
+
+type realRBACControl struct {
+	kubeCli  kubernetes.Interface
+	recorder record.EventRecorder
+}
+
+// NewRealRBACControl creates a new RBACControlInterface
+func NewRealRBACControl(
+	kubeCli kubernetes.Interface,
+	recorder record.EventRecorder,
+) RBACControlInterface {
+	return &realRBACControl{
+		kubeCli:  kubeCli,
+		recorder: recorder,
+	}
+}
+
+func (rrc *realRBACControl) CreateRole(object runtime.Object, role *rbacv1.Role) error {
+	ns := role.GetNamespace()
+	roleName := role.GetName()
+	kind := object.GetObjectKind().GroupVersionKind().Kind
+
+	_, err := rrc.kubeCli.RbacV1().Roles(ns).Create(role)
+	if err != nil {
+		log.Errorf("failed to create %s role: [%s/%s], err: %v", strings.ToLower(kind), ns, roleName, err)
+	} else {
+		log.V(4).Infof("create %s role: [%s/%s] successfully", strings.ToLower(kind), ns, roleName)
+	}
+	rrc.recordRBACEvent("create", "role", object, ns, roleName, err)
+	return err
+}
+
+func (rrc *realRBACControl) UpdateRole(object runtime.Object, role *rbacv1.Role) error {
+	ns := role.GetNamespace()
+	roleName := role.GetName()
+	kind := object.GetObjectKind().GroupVersionKind().Kind
+
+	_, err := rrc.kubeCli.RbacV1().Roles(ns).Update(role)
+	if err != nil {
+		log.Errorf("failed to update %s role: [%s/%s], err: %v", strings.ToLower(kind), ns, roleName, err)
+	} else {
+		log.V(4).Infof("update %s role: [%s/%s] successfully", strings.ToLower(kind), ns, roleName)
+	}
+	rrc.recordRBACEvent("update", "role", object, ns, roleName, err)
+	return err
+}
+
+func (rrc *realRBACControl) CreateRoleBinding(object runtime.Object, rb *rbacv1.RoleBinding) error {
+	ns := rb.GetNamespace()
+	rbName := rb.GetName()
+	kind := object.GetObjectKind().GroupVersionKind().Kind
+
+	_, err := rrc.kubeCli.RbacV1().RoleBindings(ns).Create(rb)
+	if err != nil {
+		log.Errorf("failed to create %s role binding: [%s/%s], err: %v", strings.ToLower(kind), ns, rbName, err)
+	} else {
+		log.V(4).Infof("create %s role binding: [%s/%s] successfully", strings.ToLower(kind), ns, rbName)
+	}
+	rrc.recordRBACEvent("create", "role binding", object, ns, rbName, err)
+	return err
+}
+
+func (rrc *realRBACControl) recordRBACEvent(verb, resource string, obj runtime.Object, ns, name string, err error) {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if err == nil {
+		reason := fmt.Sprintf("Successful%s", strings.Title(verb))
+		msg := fmt.Sprintf("%s %s %s/%s for %s successful",
+			strings.ToLower(verb), resource, ns, name, strings.ToLower(kind))
+		rrc.recorder.Event(obj, corev1.EventTypeNormal, reason, msg)
+	} else {
+		reason := fmt.Sprintf("Failed%s", strings.Title(verb))
+		msg := fmt.Sprintf("%s %s %s/%s for %s failed error: %s",
+			strings.ToLower(verb), resource, ns, name, strings.ToLower(kind), err)
+		rrc.recorder.Event(obj, corev1.EventTypeWarning, reason, msg)
+	}
+}
+
+var _ RBACControlInterface = &realRBACControl{}
+
+// FakeRBACControl is a fake RBACControlInterface
+type FakeRBACControl struct {
+	RoleLister               rbaclisters.RoleLister
+	RoleIndexer              cache.Indexer
+	RoleBindingLister        rbaclisters.RoleBindingLister
+	RoleBindingIndexer       cache.Indexer
+	createRoleTracker        requestTracker
+	updateRoleTracker        requestTracker
+	createRoleBindingTracker requestTracker
+}
+
+// NewFakeRBACControl returns a FakeRBACControl
+func NewFakeRBACControl(roleInformer rbacinformers.RoleInformer, rbInformer rbacinformers.RoleBindingInformer) *FakeRBACControl {
+	return &FakeRBACControl{
+		roleInformer.Lister(),
+		roleInformer.Informer().GetIndexer(),
+		rbInformer.Lister(),
+		rbInformer.Informer().GetIndexer(),
+		requestTracker{0, nil, 0},
+		requestTracker{0, nil, 0},
+		requestTracker{0, nil, 0},
+	}
+}
+
+// SetCreateRoleError sets the error attributes of createRoleTracker
+func (frc *FakeRBACControl) SetCreateRoleError(err error, after int) {
+	frc.createRoleTracker.err = err
+	frc.createRoleTracker.after = after
+}
+
+// SetUpdateRoleError sets the error attributes of updateRoleTracker
+func (frc *FakeRBACControl) SetUpdateRoleError(err error, after int) {
+	frc.updateRoleTracker.err = err
+	frc.updateRoleTracker.after = after
+}
+
+// SetCreateRoleBindingError sets the error attributes of createRoleBindingTracker
+func (frc *FakeRBACControl) SetCreateRoleBindingError(err error, after int) {
+	frc.createRoleBindingTracker.err = err
+	frc.createRoleBindingTracker.after = after
+}
+
+// CreateRole adds the role to RoleIndexer
+func (frc *FakeRBACControl) CreateRole(_ runtime.Object, role *rbacv1.Role) error {
+	defer frc.createRoleTracker.inc()
+	if frc.createRoleTracker.errorReady() {
+		defer frc.createRoleTracker.reset()
+		return frc.createRoleTracker.err
+	}
+
+	return frc.RoleIndexer.Add(role)
+}
+
+// UpdateRole updates the role in RoleIndexer
+func (frc *FakeRBACControl) UpdateRole(_ runtime.Object, role *rbacv1.Role) error {
+	defer frc.updateRoleTracker.inc()
+	if frc.updateRoleTracker.errorReady() {
+		defer frc.updateRoleTracker.reset()
+		return frc.updateRoleTracker.err
+	}
+
+	return frc.RoleIndexer.Update(role)
+}
+
+// CreateRoleBinding adds the role binding to RoleBindingIndexer
+func (frc *FakeRBACControl) CreateRoleBinding(_ runtime.Object, rb *rbacv1.RoleBinding) error {
+	defer frc.createRoleBindingTracker.inc()
+	if frc.createRoleBindingTracker.errorReady() {
+		defer frc.createRoleBindingTracker.reset()
+		return frc.createRoleBindingTracker.err
+	}
+
+	return frc.RoleBindingIndexer.Add(rb)
+}
+
+var _ RBACControlInterface = &FakeRBACControl{}
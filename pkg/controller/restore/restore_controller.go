@@ -17,18 +17,20 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/golang/glog"
 	perrors "github.com/pingcap/errors"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/backup/jobgc"
 	"github.com/pingcap/tidb-operator/pkg/backup/restore"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
 	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions"
 	listers "github.com/pingcap/tidb-operator/pkg/client/listers/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	eventv1 "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -52,17 +54,20 @@ type Controller struct {
 	restoreListerSynced cache.InformerSynced
 	// restores that need to be synced.
 	queue workqueue.RateLimitingInterface
+	// jobGC deletes the finished jobs of the restores
+	jobGC *jobgc.JobGC
 }
 
 // NewController creates a restore controller.
 func NewController(
 	kubeCli kubernetes.Interface,
 	cli versioned.Interface,
+	dynamicCli dynamic.Interface,
 	informerFactory informers.SharedInformerFactory,
 	kubeInformerFactory kubeinformers.SharedInformerFactory,
 ) *Controller {
 	eventBroadcaster := record.NewBroadcaster()
-	eventBroadcaster.StartLogging(glog.Infof)
+	eventBroadcaster.StartLogging(log.Infof)
 	eventBroadcaster.StartRecordingToSink(&eventv1.EventSinkImpl{
 		Interface: eventv1.New(kubeCli.CoreV1().RESTClient()).Events("")})
 	recorder := eventBroadcaster.NewRecorder(v1alpha1.Scheme, corev1.EventSource{Component: "restore"})
@@ -72,9 +77,17 @@ func NewController(
 	jobInformer := kubeInformerFactory.Batch().V1().Jobs()
 	pvcInformer := kubeInformerFactory.Core().V1().PersistentVolumeClaims()
 	secretInformer := kubeInformerFactory.Core().V1().Secrets()
+	saInformer := kubeInformerFactory.Core().V1().ServiceAccounts()
+	roleInformer := kubeInformerFactory.Rbac().V1().Roles()
+	roleBindingInformer := kubeInformerFactory.Rbac().V1().RoleBindings()
+	tcInformer := informerFactory.Pingcap().V1alpha1().TidbClusters()
 	statusUpdater := controller.NewRealRestoreConditionUpdater(cli, restoreInformer.Lister(), recorder)
 	jobControl := controller.NewRealJobControl(kubeCli, recorder)
 	pvcControl := controller.NewRealGeneralPVCControl(kubeCli, recorder)
+	saControl := controller.NewRealServiceAccountControl(kubeCli, recorder)
+	rbacControl := controller.NewRealRBACControl(kubeCli, recorder)
+	tcControl := controller.NewRealTidbClusterControl(cli, tcInformer.Lister(), recorder)
+	snapshotControl := controller.NewRealVolumeSnapshotControl(dynamicCli, recorder)
 
 	rsc := &Controller{
 		kubeClient: kubeCli,
@@ -88,12 +101,21 @@ func NewController(
 				jobControl,
 				pvcInformer.Lister(),
 				pvcControl,
+				tcInformer.Lister(),
+				tcControl,
+				saInformer.Lister(),
+				saControl,
+				roleInformer.Lister(),
+				roleBindingInformer.Lister(),
+				rbacControl,
+				snapshotControl,
 			),
 		),
 		queue: workqueue.NewNamedRateLimitingQueue(
 			workqueue.DefaultControllerRateLimiter(),
 			"restore",
 		),
+		jobGC: jobgc.NewRestoreJobGC(restoreInformer.Lister(), jobInformer.Lister(), jobControl, controller.BackupJobHistoryLimit, controller.BackupJobTTL),
 	}
 
 	restoreInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -114,12 +136,13 @@ func (rsc *Controller) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
 	defer rsc.queue.ShutDown()
 
-	glog.Info("Starting restore controller")
-	defer glog.Info("Shutting down restore controller")
+	log.Info("Starting restore controller")
+	defer log.Info("Shutting down restore controller")
 
 	for i := 0; i < workers; i++ {
 		go wait.Until(rsc.worker, time.Second, stopCh)
 	}
+	go rsc.jobGC.Run(stopCh)
 
 	<-stopCh
 }
@@ -141,7 +164,7 @@ func (rsc *Controller) processNextWorkItem() bool {
 	defer rsc.queue.Done(key)
 	if err := rsc.sync(key.(string)); err != nil {
 		if perrors.Find(err, controller.IsRequeueError) != nil {
-			glog.Infof("Restore: %v, still need sync: %v, requeuing", key.(string), err)
+			log.Infof("Restore: %v, still need sync: %v, requeuing", key.(string), err)
 		} else {
 			utilruntime.HandleError(fmt.Errorf("Restore: %v, sync failed, err: %v, requeuing", key.(string), err))
 		}
@@ -156,7 +179,7 @@ func (rsc *Controller) processNextWorkItem() bool {
 func (rsc *Controller) sync(key string) error {
 	startTime := time.Now()
 	defer func() {
-		glog.V(4).Infof("Finished syncing Restore %q (%v)", key, time.Since(startTime))
+		log.V(4).Infof("Finished syncing Restore %q (%v)", key, time.Since(startTime))
 	}()
 
 	ns, name, err := cache.SplitMetaNamespaceKey(key)
@@ -165,7 +188,7 @@ func (rsc *Controller) sync(key string) error {
 	}
 	restore, err := rsc.restoreLister.Restores(ns).Get(name)
 	if errors.IsNotFound(err) {
-		glog.Infof("Restore has been deleted %v", key)
+		log.Infof("Restore has been deleted %v", key)
 		return nil
 	}
 	if err != nil {
@@ -185,16 +208,16 @@ func (rsc *Controller) updateRestore(cur interface{}) {
 	name := newRestore.GetName()
 
 	if v1alpha1.IsRestoreComplete(newRestore) {
-		glog.V(4).Infof("restore %s/%s is Complete, skipping.", ns, name)
+		log.V(4).Infof("restore %s/%s is Complete, skipping.", ns, name)
 		return
 	}
 
 	if v1alpha1.IsRestoreScheduled(newRestore) {
-		glog.V(4).Infof("restore %s/%s is already scheduled, skipping", ns, name)
+		log.V(4).Infof("restore %s/%s is already scheduled, skipping", ns, name)
 		return
 	}
 
-	glog.V(4).Infof("restore object %s/%s enqueue", ns, name)
+	log.V(4).Infof("restore object %s/%s enqueue", ns, name)
 	rsc.enqueueRestore(newRestore)
 }
 
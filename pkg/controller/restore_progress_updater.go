@@ -0,0 +1,54 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
+	listers "github.com/pingcap/tidb-operator/pkg/client/listers/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/log"
+)
+
+// RestoreProgressUpdaterInterface enables updating the progress of a running Restore.
+type RestoreProgressUpdaterInterface interface {
+	UpdateWarmUp(restore *v1alpha1.Restore, warmUp *v1alpha1.RestoreWarmUpStatus) error
+}
+
+type realRestoreProgressUpdater struct {
+	cli           versioned.Interface
+	restoreLister listers.RestoreLister
+}
+
+// NewRealRestoreProgressUpdater returns a RestoreProgressUpdaterInterface that updates the progress of a Restore
+func NewRealRestoreProgressUpdater(
+	cli versioned.Interface,
+	restoreLister listers.RestoreLister) RestoreProgressUpdaterInterface {
+	return &realRestoreProgressUpdater{
+		cli,
+		restoreLister,
+	}
+}
+
+// UpdateWarmUp updates the progress of the warm-up of the restored tables
+func (rpu *realRestoreProgressUpdater) UpdateWarmUp(restore *v1alpha1.Restore, warmUp *v1alpha1.RestoreWarmUpStatus) error {
+	err := patchRestoreStatus(rpu.cli, rpu.restoreLister, restore, func(status *v1alpha1.RestoreStatus) {
+		status.WarmUp = warmUp
+	})
+	if err == nil {
+		log.V(4).Infof("Restore: [%s/%s] warm-up progress updated successfully", restore.GetNamespace(), restore.GetName())
+	}
+	return err
+}
+
+var _ RestoreProgressUpdaterInterface = &realRestoreProgressUpdater{}
@@ -17,13 +17,16 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/golang/glog"
+	"github.com/pingcap/tidb-operator/pkg/log"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
 	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions/pingcap.com/v1alpha1"
 	listers "github.com/pingcap/tidb-operator/pkg/client/listers/pingcap.com/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
@@ -56,33 +59,57 @@ func NewRealRestoreConditionUpdater(
 func (rcu *realRestoreConditionUpdater) Update(restore *v1alpha1.Restore, condition *v1alpha1.RestoreCondition) error {
 	ns := restore.GetNamespace()
 	restoreName := restore.GetName()
-	oldStatus := restore.Status.DeepCopy()
-	var isUpdate bool
-	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		isUpdate = v1alpha1.UpdateRestoreCondition(&restore.Status, condition)
-		if isUpdate {
-			_, updateErr := rcu.cli.PingcapV1alpha1().Restores(ns).Update(restore)
-			if updateErr == nil {
-				glog.Infof("Restore: [%s/%s] updated successfully", ns, restoreName)
-				return nil
-			}
-			if updated, err := rcu.restoreLister.Restores(ns).Get(restoreName); err == nil {
-				// make a copy so we don't mutate the shared cache
-				restore = updated.DeepCopy()
-				restore.Status = *oldStatus
-			} else {
-				utilruntime.HandleError(fmt.Errorf("error getting updated restore %s/%s from lister: %v", ns, restoreName, err))
-			}
-			return updateErr
-		}
+	if !v1alpha1.UpdateRestoreCondition(&restore.Status, condition) {
 		return nil
+	}
+	// the caller may have changed the other fields of the status as well, the
+	// conditions are taken from the latest status so those set by others are kept
+	newStatus := restore.Status.DeepCopy()
+	err := patchRestoreStatus(rcu.cli, rcu.restoreLister, restore, func(status *v1alpha1.RestoreStatus) {
+		conditions := status.Conditions
+		*status = *newStatus
+		status.Conditions = conditions
+		v1alpha1.UpdateRestoreCondition(status, condition)
 	})
-	if isUpdate {
-		rcu.recordRestoreEvent("update", restore, err)
+	if err == nil {
+		log.Infof("Restore: [%s/%s] updated successfully", ns, restoreName)
 	}
+	rcu.recordRestoreEvent("update", restore, err)
 	return err
 }
 
+// patchRestoreStatus writes the changes made by mutate on the status of the cached restore
+// to the status subresource by a JSON merge patch, see patchBackupStatus.
+func patchRestoreStatus(cli versioned.Interface, restoreLister listers.RestoreLister, restore *v1alpha1.Restore, mutate func(status *v1alpha1.RestoreStatus)) error {
+	ns := restore.GetNamespace()
+	restoreName := restore.GetName()
+	current := restore
+	if cached, err := restoreLister.Restores(ns).Get(restoreName); err == nil {
+		current = cached
+	} else {
+		utilruntime.HandleError(fmt.Errorf("error getting restore %s/%s from lister: %v", ns, restoreName, err))
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		// make a copy so we don't mutate the shared cache
+		newStatus := current.Status.DeepCopy()
+		mutate(newStatus)
+		patch, err := versionedStatusMergePatch(current.GetResourceVersion(), &current.Status, newStatus)
+		if err != nil {
+			return err
+		}
+		_, patchErr := cli.PingcapV1alpha1().Restores(ns).Patch(restoreName, types.MergePatchType, patch, "status")
+		if !errors.IsConflict(patchErr) {
+			return patchErr
+		}
+		if updated, err := cli.PingcapV1alpha1().Restores(ns).Get(restoreName, metav1.GetOptions{}); err == nil {
+			current = updated
+		} else {
+			utilruntime.HandleError(fmt.Errorf("error getting updated restore %s/%s: %v", ns, restoreName, err))
+		}
+		return patchErr
+	})
+}
+
 func (rcu *realRestoreConditionUpdater) recordRestoreEvent(verb string, restore *v1alpha1.Restore, err error) {
 	restoreName := restore.GetName()
 	if err == nil {
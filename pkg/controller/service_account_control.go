@@ -0,0 +1,118 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tidb-operator/pkg/log"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+// ServiceAccountControlInterface manages ServiceAccounts used in backup、restore and clean
+type ServiceAccountControlInterface interface {
+	CreateServiceAccount(object runtime.Object, sa *corev1.ServiceAccount) error
+}
+
+type realServiceAccountControl struct {
+	kubeCli  kubernetes.Interface
+	recorder record.EventRecorder
+}
+
+// NewRealServiceAccountControl creates a new ServiceAccountControlInterface
+func NewRealServiceAccountControl(
+	kubeCli kubernetes.Interface,
+	recorder record.EventRecorder,
+) ServiceAccountControlInterface {
+	return &realServiceAccountControl{
+		kubeCli:  kubeCli,
+		recorder: recorder,
+	}
+}
+
+func (rsc *realServiceAccountControl) CreateServiceAccount(object runtime.Object, sa *corev1.ServiceAccount) error {
+	ns := sa.GetNamespace()
+	saName := sa.GetName()
+	kind := object.GetObjectKind().GroupVersionKind().Kind
+
+	_, err := rsc.kubeCli.CoreV1().ServiceAccounts(ns).Create(sa)
+	if err != nil {
+		log.Errorf("failed to create %s service account: [%s/%s], err: %v", strings.ToLower(kind), ns, saName, err)
+	} else {
+		log.V(4).Infof("create %s service account: [%s/%s] successfully", strings.ToLower(kind), ns, saName)
+	}
+	rsc.recordServiceAccountEvent("create", object, sa, err)
+	return err
+}
+
+func (rsc *realServiceAccountControl) recordServiceAccountEvent(verb string, obj runtime.Object, sa *corev1.ServiceAccount, err error) {
+	saName := sa.GetName()
+	ns := sa.GetNamespace()
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if err == nil {
+		reason := fmt.Sprintf("Successful%s", strings.Title(verb))
+		msg := fmt.Sprintf("%s service account %s/%s for %s successful",
+			strings.ToLower(verb), ns, saName, strings.ToLower(kind))
+		rsc.recorder.Event(obj, corev1.EventTypeNormal, reason, msg)
+	} else {
+		reason := fmt.Sprintf("Failed%s", strings.Title(verb))
+		msg := fmt.Sprintf("%s service account %s/%s for %s failed error: %s",
+			strings.ToLower(verb), ns, saName, strings.ToLower(kind), err)
+		rsc.recorder.Event(obj, corev1.EventTypeWarning, reason, msg)
+	}
+}
+
+var _ ServiceAccountControlInterface = &realServiceAccountControl{}
+
+// FakeServiceAccountControl is a fake ServiceAccountControlInterface
+type FakeServiceAccountControl struct {
+	SaLister                    corelisters.ServiceAccountLister
+	SaIndexer                   cache.Indexer
+	createServiceAccountTracker requestTracker
+}
+
+// NewFakeServiceAccountControl returns a FakeServiceAccountControl
+func NewFakeServiceAccountControl(saInformer coreinformers.ServiceAccountInformer) *FakeServiceAccountControl {
+	return &FakeServiceAccountControl{
+		saInformer.Lister(),
+		saInformer.Informer().GetIndexer(),
+		requestTracker{0, nil, 0},
+	}
+}
+
+// SetCreateServiceAccountError sets the error attributes of createServiceAccountTracker
+func (fsc *FakeServiceAccountControl) SetCreateServiceAccountError(err error, after int) {
+	fsc.createServiceAccountTracker.err = err
+	fsc.createServiceAccountTracker.after = after
+}
+
+// CreateServiceAccount adds the service account to SaIndexer
+func (fsc *FakeServiceAccountControl) CreateServiceAccount(_ runtime.Object, sa *corev1.ServiceAccount) error {
+	defer fsc.createServiceAccountTracker.inc()
+	if fsc.createServiceAccountTracker.errorReady() {
+		defer fsc.createServiceAccountTracker.reset()
+		return fsc.createServiceAccountTracker.err
+	}
+
+	return fsc.SaIndexer.Add(sa)
+}
+
+var _ ServiceAccountControlInterface = &FakeServiceAccountControl{}
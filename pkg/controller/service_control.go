@@ -14,15 +14,18 @@
 package controller
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	tcinformers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions/pingcap.com/v1alpha1"
 	v1listers "github.com/pingcap/tidb-operator/pkg/client/listers/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	"github.com/pingcap/tidb-operator/pkg/tracing"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
@@ -39,6 +42,7 @@ var ExternalTrafficPolicy string
 type ServiceControlInterface interface {
 	CreateService(*v1alpha1.TidbCluster, *corev1.Service) error
 	UpdateService(*v1alpha1.TidbCluster, *corev1.Service) (*corev1.Service, error)
+	PatchServiceAnnotations(*v1alpha1.TidbCluster, *corev1.Service, map[string]string) error
 	DeleteService(*v1alpha1.TidbCluster, *corev1.Service) error
 }
 
@@ -58,7 +62,9 @@ func NewRealServiceControl(kubeCli kubernetes.Interface, svcLister corelisters.S
 }
 
 func (sc *realServiceControl) CreateService(tc *v1alpha1.TidbCluster, svc *corev1.Service) error {
+	span := tracing.StartCall(tc.GetNamespace(), tc.GetName(), "CreateService")
 	_, err := sc.kubeCli.CoreV1().Services(tc.Namespace).Create(svc)
+	tracing.FinishCall(span, err)
 	sc.recordServiceEvent("create", tc, svc, err)
 	return err
 }
@@ -69,12 +75,17 @@ func (sc *realServiceControl) UpdateService(tc *v1alpha1.TidbCluster, svc *corev
 	svcName := svc.GetName()
 	svcSpec := svc.Spec.DeepCopy()
 
+	if ServerSideApply {
+		return sc.applyService(tc, svc)
+	}
+
 	var updateSvc *corev1.Service
+	span := tracing.StartCall(tc.GetNamespace(), tc.GetName(), "UpdateService")
 	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 		var updateErr error
 		updateSvc, updateErr = sc.kubeCli.CoreV1().Services(ns).Update(svc)
 		if updateErr == nil {
-			glog.Infof("update Service: [%s/%s] successfully, TidbCluster: %s", ns, svcName, tcName)
+			log.Infof("update Service: [%s/%s] successfully, TidbCluster: %s", ns, svcName, tcName)
 			return nil
 		}
 
@@ -87,12 +98,63 @@ func (sc *realServiceControl) UpdateService(tc *v1alpha1.TidbCluster, svc *corev
 
 		return updateErr
 	})
+	tracing.FinishCall(span, err)
 	sc.recordServiceEvent("update", tc, svc, err)
 	return updateSvc, err
 }
 
+// applyService applies the fields of the Service generated by the operator, the fields
+// managed by the others, e.g. the annotations of the cloud provider, are kept as they are
+func (sc *realServiceControl) applyService(tc *v1alpha1.TidbCluster, svc *corev1.Service) (*corev1.Service, error) {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	svcName := svc.GetName()
+
+	updateSvc := &corev1.Service{}
+	span := tracing.StartCall(tc.GetNamespace(), tc.GetName(), "ApplyService")
+	err := serverSideApply(sc.kubeCli.CoreV1().RESTClient(), "services", svc.ObjectMeta, &corev1.Service{
+		TypeMeta:   metav1.TypeMeta{APIVersion: corev1.SchemeGroupVersion.String(), Kind: "Service"},
+		ObjectMeta: applyObjectMeta(svc.ObjectMeta),
+		Spec:       svc.Spec,
+	}, updateSvc)
+	tracing.FinishCall(span, err)
+	if err == nil {
+		log.Infof("apply Service: [%s/%s] successfully, TidbCluster: %s", ns, svcName, tcName)
+	}
+	sc.recordServiceEvent("update", tc, svc, err)
+	return updateSvc, err
+}
+
+// PatchServiceAnnotations sets the annotations of the Service by a patch, the
+// rest of the Service is left as it is, so it can be used on the Services
+// created by the others, e.g. the TiDB Service created by the helm chart
+func (sc *realServiceControl) PatchServiceAnnotations(tc *v1alpha1.TidbCluster, svc *corev1.Service, annotations map[string]string) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	svcName := svc.GetName()
+
+	patchBytes, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotations,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	span := tracing.StartCall(tc.GetNamespace(), tc.GetName(), "PatchServiceAnnotations")
+	_, err = sc.kubeCli.CoreV1().Services(ns).Patch(svcName, types.StrategicMergePatchType, patchBytes)
+	tracing.FinishCall(span, err)
+	if err == nil {
+		log.Infof("patch Service: [%s/%s] annotations %v successfully, TidbCluster: %s", ns, svcName, annotations, tcName)
+	}
+	sc.recordServiceEvent("patch", tc, svc, err)
+	return err
+}
+
 func (sc *realServiceControl) DeleteService(tc *v1alpha1.TidbCluster, svc *corev1.Service) error {
+	span := tracing.StartCall(tc.GetNamespace(), tc.GetName(), "DeleteService")
 	err := sc.kubeCli.CoreV1().Services(tc.Namespace).Delete(svc.Name, nil)
+	tracing.FinishCall(span, err)
 	sc.recordServiceEvent("delete", tc, svc, err)
 	return err
 }
@@ -124,6 +186,7 @@ type FakeServiceControl struct {
 	TcIndexer                cache.Indexer
 	createServiceTracker     requestTracker
 	updateServiceTracker     requestTracker
+	patchServiceTracker      requestTracker
 	deleteStatefulSetTracker requestTracker
 }
 
@@ -138,6 +201,7 @@ func NewFakeServiceControl(svcInformer coreinformers.ServiceInformer, epsInforme
 		requestTracker{0, nil, 0},
 		requestTracker{0, nil, 0},
 		requestTracker{0, nil, 0},
+		requestTracker{0, nil, 0},
 	}
 }
 
@@ -153,6 +217,12 @@ func (ssc *FakeServiceControl) SetUpdateServiceError(err error, after int) {
 	ssc.updateServiceTracker.after = after
 }
 
+// SetPatchServiceError sets the error attributes of patchServiceTracker
+func (ssc *FakeServiceControl) SetPatchServiceError(err error, after int) {
+	ssc.patchServiceTracker.err = err
+	ssc.patchServiceTracker.after = after
+}
+
 // SetDeleteServiceError sets the error attributes of deleteServiceTracker
 func (ssc *FakeServiceControl) SetDeleteServiceError(err error, after int) {
 	ssc.deleteStatefulSetTracker.err = err
@@ -207,6 +277,24 @@ func (ssc *FakeServiceControl) UpdateService(_ *v1alpha1.TidbCluster, svc *corev
 	return svc, ssc.SvcIndexer.Update(svc)
 }
 
+// PatchServiceAnnotations sets the annotations of the service of SvcIndexer
+func (ssc *FakeServiceControl) PatchServiceAnnotations(_ *v1alpha1.TidbCluster, svc *corev1.Service, annotations map[string]string) error {
+	defer ssc.patchServiceTracker.inc()
+	if ssc.patchServiceTracker.errorReady() {
+		defer ssc.patchServiceTracker.reset()
+		return ssc.patchServiceTracker.err
+	}
+
+	svc = svc.DeepCopy()
+	if svc.Annotations == nil {
+		svc.Annotations = map[string]string{}
+	}
+	for k, v := range annotations {
+		svc.Annotations[k] = v
+	}
+	return ssc.SvcIndexer.Update(svc)
+}
+
 // DeleteService deletes the service of SvcIndexer
 func (ssc *FakeServiceControl) DeleteService(_ *v1alpha1.TidbCluster, _ *corev1.Service) error {
 	return nil
@@ -17,17 +17,19 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	tcinformers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions/pingcap.com/v1alpha1"
 	v1listers "github.com/pingcap/tidb-operator/pkg/client/listers/pingcap.com/v1alpha1"
-	apps "k8s.io/api/apps/v1beta1"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	"github.com/pingcap/tidb-operator/pkg/tracing"
+	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
-	appsinformers "k8s.io/client-go/informers/apps/v1beta1"
+	appsinformers "k8s.io/client-go/informers/apps/v1"
 	"k8s.io/client-go/kubernetes"
-	appslisters "k8s.io/client-go/listers/apps/v1beta1"
+	appslisters "k8s.io/client-go/listers/apps/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
@@ -56,7 +58,9 @@ func NewRealStatefuSetControl(kubeCli kubernetes.Interface, setLister appslister
 
 // CreateStatefulSet create a StatefulSet in a TidbCluster.
 func (sc *realStatefulSetControl) CreateStatefulSet(tc *v1alpha1.TidbCluster, set *apps.StatefulSet) error {
-	_, err := sc.kubeCli.AppsV1beta1().StatefulSets(tc.Namespace).Create(set)
+	span := tracing.StartCall(tc.GetNamespace(), tc.GetName(), "CreateStatefulSet")
+	_, err := sc.kubeCli.AppsV1().StatefulSets(tc.Namespace).Create(set)
+	tracing.FinishCall(span, err)
 	// sink already exists errors
 	if apierrors.IsAlreadyExists(err) {
 		return err
@@ -73,15 +77,20 @@ func (sc *realStatefulSetControl) UpdateStatefulSet(tc *v1alpha1.TidbCluster, se
 	setSpec := set.Spec.DeepCopy()
 	var updatedSS *apps.StatefulSet
 
+	if ServerSideApply {
+		return sc.applyStatefulSet(tc, set)
+	}
+
+	span := tracing.StartCall(tc.GetNamespace(), tc.GetName(), "UpdateStatefulSet")
 	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 		// TODO: verify if StatefulSet identity(name, namespace, labels) matches TidbCluster
 		var updateErr error
-		updatedSS, updateErr = sc.kubeCli.AppsV1beta1().StatefulSets(ns).Update(set)
+		updatedSS, updateErr = sc.kubeCli.AppsV1().StatefulSets(ns).Update(set)
 		if updateErr == nil {
-			glog.Infof("TidbCluster: [%s/%s]'s StatefulSet: [%s/%s] updated successfully", ns, tcName, ns, setName)
+			log.Infof("TidbCluster: [%s/%s]'s StatefulSet: [%s/%s] updated successfully", ns, tcName, ns, setName)
 			return nil
 		}
-		glog.Errorf("failed to update TidbCluster: [%s/%s]'s StatefulSet: [%s/%s], error: %v", ns, tcName, ns, setName, updateErr)
+		log.Errorf("failed to update TidbCluster: [%s/%s]'s StatefulSet: [%s/%s], error: %v", ns, tcName, ns, setName, updateErr)
 
 		if updated, err := sc.setLister.StatefulSets(ns).Get(setName); err == nil {
 			// make a copy so we don't mutate the shared cache
@@ -92,6 +101,32 @@ func (sc *realStatefulSetControl) UpdateStatefulSet(tc *v1alpha1.TidbCluster, se
 		}
 		return updateErr
 	})
+	tracing.FinishCall(span, err)
+
+	sc.recordStatefulSetEvent("update", tc, set, err)
+	return updatedSS, err
+}
+
+// applyStatefulSet applies the fields of the StatefulSet generated by the operator, the fields
+// managed by the others, e.g. the extra annotations, are kept as they are
+func (sc *realStatefulSetControl) applyStatefulSet(tc *v1alpha1.TidbCluster, set *apps.StatefulSet) (*apps.StatefulSet, error) {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	setName := set.GetName()
+
+	updatedSS := &apps.StatefulSet{}
+	span := tracing.StartCall(tc.GetNamespace(), tc.GetName(), "ApplyStatefulSet")
+	err := serverSideApply(sc.kubeCli.AppsV1().RESTClient(), "statefulsets", set.ObjectMeta, &apps.StatefulSet{
+		TypeMeta:   metav1.TypeMeta{APIVersion: apps.SchemeGroupVersion.String(), Kind: "StatefulSet"},
+		ObjectMeta: applyObjectMeta(set.ObjectMeta),
+		Spec:       set.Spec,
+	}, updatedSS)
+	tracing.FinishCall(span, err)
+	if err == nil {
+		log.Infof("TidbCluster: [%s/%s]'s StatefulSet: [%s/%s] applied successfully", ns, tcName, ns, setName)
+	} else {
+		log.Errorf("failed to apply TidbCluster: [%s/%s]'s StatefulSet: [%s/%s], error: %v", ns, tcName, ns, setName, err)
+	}
 
 	sc.recordStatefulSetEvent("update", tc, set, err)
 	return updatedSS, err
@@ -99,7 +134,9 @@ func (sc *realStatefulSetControl) UpdateStatefulSet(tc *v1alpha1.TidbCluster, se
 
 // DeleteStatefulSet delete a StatefulSet in a TidbCluster.
 func (sc *realStatefulSetControl) DeleteStatefulSet(tc *v1alpha1.TidbCluster, set *apps.StatefulSet) error {
-	err := sc.kubeCli.AppsV1beta1().StatefulSets(tc.Namespace).Delete(set.Name, nil)
+	span := tracing.StartCall(tc.GetNamespace(), tc.GetName(), "DeleteStatefulSet")
+	err := sc.kubeCli.AppsV1().StatefulSets(tc.Namespace).Delete(set.Name, nil)
+	tracing.FinishCall(span, err)
 	sc.recordStatefulSetEvent("delete", tc, set, err)
 	return err
 }
@@ -52,11 +52,16 @@ type TiDBControlInterface interface {
 // defaultTiDBControl is default implementation of TiDBControlInterface.
 type defaultTiDBControl struct {
 	httpClient *http.Client
+	breaker    *httputil.CircuitBreaker
 }
 
 // NewDefaultTiDBControl returns a defaultTiDBControl instance
 func NewDefaultTiDBControl() TiDBControlInterface {
-	return &defaultTiDBControl{httpClient: &http.Client{Timeout: timeout}}
+	breaker := httputil.NewCircuitBreaker()
+	return &defaultTiDBControl{
+		httpClient: &http.Client{Timeout: timeout, Transport: breaker.Transport(nil)},
+		breaker:    breaker,
+	}
 }
 
 func (tdc *defaultTiDBControl) useTLSHTTPClient(enableTLS bool) error {
@@ -68,7 +73,7 @@ func (tdc *defaultTiDBControl) useTLSHTTPClient(enableTLS bool) error {
 		config := &tls.Config{
 			RootCAs: rootCAs,
 		}
-		tdc.httpClient.Transport = &http.Transport{TLSClientConfig: config}
+		tdc.httpClient.Transport = tdc.breaker.Transport(&http.Transport{TLSClientConfig: config})
 	}
 	return nil
 }
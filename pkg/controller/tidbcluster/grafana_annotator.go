@@ -0,0 +1,193 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tidbcluster
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/grafana"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	apps "k8s.io/api/apps/v1"
+)
+
+// StatusAnnotator annotates the operator actions, e.g. the upgrades, failovers
+// and scaling, observed from the status changes of a sync
+type StatusAnnotator interface {
+	Annotate(tc *v1alpha1.TidbCluster, oldStatus *v1alpha1.TidbClusterStatus)
+}
+
+type grafanaAnnotator struct {
+	client grafana.Client
+	mutex  sync.Mutex
+	// the scaling states of the components keyed by namespace/name/component,
+	// they are kept in memory, so the scaling in progress when the operator
+	// restarts is not annotated
+	scaling map[string]*scalingState
+	now     func() time.Time
+}
+
+type scalingState struct {
+	replicas   int32
+	inProgress bool
+}
+
+// componentStatus is the status of a component used by the annotator
+type componentStatus struct {
+	name         string
+	replicas     int32
+	realReplicas int32
+	phase        v1alpha1.MemberPhase
+	oldPhase     v1alpha1.MemberPhase
+	statefulSet  *apps.StatefulSetStatus
+	failures     []string
+	oldFailures  []string
+}
+
+// NewGrafanaAnnotator returns a StatusAnnotator posting the annotations to Grafana
+func NewGrafanaAnnotator(client grafana.Client) StatusAnnotator {
+	return &grafanaAnnotator{
+		client:  client,
+		scaling: map[string]*scalingState{},
+		now:     time.Now,
+	}
+}
+
+func (ga *grafanaAnnotator) Annotate(tc *v1alpha1.TidbCluster, oldStatus *v1alpha1.TidbClusterStatus) {
+	components := []componentStatus{
+		{
+			name:         v1alpha1.PDMemberType.String(),
+			replicas:     tc.Spec.PD.Replicas,
+			realReplicas: tc.PDRealReplicas(),
+			phase:        tc.Status.PD.Phase,
+			oldPhase:     oldStatus.PD.Phase,
+			statefulSet:  tc.Status.PD.StatefulSet,
+			failures:     sortedKeys(tc.Status.PD.FailureMembers),
+			oldFailures:  sortedKeys(oldStatus.PD.FailureMembers),
+		},
+		{
+			name:         v1alpha1.TiKVMemberType.String(),
+			replicas:     tc.Spec.TiKV.Replicas,
+			realReplicas: tc.TiKVRealReplicas(),
+			phase:        tc.Status.TiKV.Phase,
+			oldPhase:     oldStatus.TiKV.Phase,
+			statefulSet:  tc.Status.TiKV.StatefulSet,
+			failures:     sortedKeys(tc.Status.TiKV.FailureStores),
+			oldFailures:  sortedKeys(oldStatus.TiKV.FailureStores),
+		},
+		{
+			name:         v1alpha1.TiDBMemberType.String(),
+			replicas:     tc.Spec.TiDB.Replicas,
+			realReplicas: tc.TiDBRealReplicas(),
+			phase:        tc.Status.TiDB.Phase,
+			oldPhase:     oldStatus.TiDB.Phase,
+			statefulSet:  tc.Status.TiDB.StatefulSet,
+			failures:     sortedKeys(tc.Status.TiDB.FailureMembers),
+			oldFailures:  sortedKeys(oldStatus.TiDB.FailureMembers),
+		},
+	}
+
+	for i := range components {
+		c := &components[i]
+		if c.oldPhase != v1alpha1.UpgradePhase && c.phase == v1alpha1.UpgradePhase {
+			ga.annotate(tc, c.name, "upgrade", "%s upgrade started", c.name)
+		} else if c.oldPhase == v1alpha1.UpgradePhase && c.phase != v1alpha1.UpgradePhase {
+			ga.annotate(tc, c.name, "upgrade", "%s upgrade finished", c.name)
+		}
+
+		for _, name := range difference(c.failures, c.oldFailures) {
+			ga.annotate(tc, c.name, "failover", "%s failover of %s started", c.name, name)
+		}
+		for _, name := range difference(c.oldFailures, c.failures) {
+			ga.annotate(tc, c.name, "failover", "%s failover of %s finished", c.name, name)
+		}
+
+		ga.annotateScaling(tc, c)
+	}
+}
+
+func (ga *grafanaAnnotator) annotateScaling(tc *v1alpha1.TidbCluster, c *componentStatus) {
+	ga.mutex.Lock()
+	defer ga.mutex.Unlock()
+
+	key := fmt.Sprintf("%s/%s/%s", tc.GetNamespace(), tc.GetName(), c.name)
+	state, ok := ga.scaling[key]
+	if !ok {
+		ga.scaling[key] = &scalingState{replicas: c.replicas}
+		return
+	}
+	if state.replicas != c.replicas {
+		ga.annotate(tc, c.name, "scale", "%s scaling from %d to %d started", c.name, state.replicas, c.replicas)
+		state.replicas = c.replicas
+		state.inProgress = true
+		return
+	}
+	if state.inProgress && c.statefulSet != nil &&
+		c.statefulSet.Replicas == c.realReplicas && c.statefulSet.ReadyReplicas == c.realReplicas {
+		ga.annotate(tc, c.name, "scale", "%s scaling to %d finished", c.name, c.replicas)
+		state.inProgress = false
+	}
+}
+
+func (ga *grafanaAnnotator) annotate(tc *v1alpha1.TidbCluster, component string, action string, format string, args ...interface{}) {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	annotation := &grafana.Annotation{
+		Time: ga.now().UnixNano() / int64(time.Millisecond),
+		Tags: []string{"tidb-operator", ns + "/" + tcName, component, action},
+		Text: fmt.Sprintf("TidbCluster %s/%s: %s", ns, tcName, fmt.Sprintf(format, args...)),
+	}
+	// the annotations are best effort, they never fail the sync
+	if err := ga.client.Annotate(annotation); err != nil {
+		log.ForCluster(ns, tcName).Warningf("failed to annotate %q to grafana, error: %v", annotation.Text, err)
+	}
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string]v1alpha1.PDFailureMember:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]v1alpha1.TiKVFailureStore:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]v1alpha1.TiDBFailureMember:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// difference returns the items in a but not in b
+func difference(a, b []string) []string {
+	set := map[string]bool{}
+	for _, item := range b {
+		set[item] = true
+	}
+	var diff []string
+	for _, item := range a {
+		if !set[item] {
+			diff = append(diff, item)
+		}
+	}
+	return diff
+}
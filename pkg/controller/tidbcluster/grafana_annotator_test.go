@@ -0,0 +1,77 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tidbcluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/grafana"
+	apps "k8s.io/api/apps/v1"
+)
+
+func TestGrafanaAnnotatorAnnotate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	client := &grafana.FakeClient{}
+	annotator := NewGrafanaAnnotator(client)
+	texts := func() []string {
+		var texts []string
+		for _, annotation := range client.Annotations {
+			texts = append(texts, annotation.Text)
+		}
+		client.Annotations = nil
+		return texts
+	}
+
+	tc := newTidbCluster()
+	tc.Spec.PD.Replicas = 3
+	tc.Spec.TiKV.Replicas = 3
+	tc.Spec.TiDB.Replicas = 2
+	tc.Status.PD.StatefulSet = &apps.StatefulSetStatus{Replicas: 3, ReadyReplicas: 3}
+	tc.Status.TiKV.StatefulSet = &apps.StatefulSetStatus{Replicas: 3, ReadyReplicas: 3}
+	tc.Status.TiDB.StatefulSet = &apps.StatefulSetStatus{Replicas: 2, ReadyReplicas: 2}
+
+	// the replicas are only recorded when the cluster is observed for the first time
+	annotator.Annotate(tc, tc.Status.DeepCopy())
+	g.Expect(texts()).To(BeEmpty())
+
+	oldStatus := tc.Status.DeepCopy()
+	tc.Status.PD.Phase = v1alpha1.UpgradePhase
+	tc.Status.TiKV.FailureStores = map[string]v1alpha1.TiKVFailureStore{"1": {StoreID: "1"}}
+	tc.Spec.TiDB.Replicas = 3
+	annotator.Annotate(tc, oldStatus)
+	g.Expect(texts()).To(Equal([]string{
+		"TidbCluster default/test-pd: pd upgrade started",
+		"TidbCluster default/test-pd: tikv failover of 1 started",
+		"TidbCluster default/test-pd: tidb scaling from 2 to 3 started",
+	}))
+
+	// the scaling is not finished until all the pods are ready
+	tc.Status.TiDB.StatefulSet = &apps.StatefulSetStatus{Replicas: 3, ReadyReplicas: 2}
+	annotator.Annotate(tc, tc.Status.DeepCopy())
+	g.Expect(texts()).To(BeEmpty())
+
+	oldStatus = tc.Status.DeepCopy()
+	tc.Status.PD.Phase = v1alpha1.NormalPhase
+	tc.Status.TiKV.FailureStores = nil
+	tc.Status.TiDB.StatefulSet = &apps.StatefulSetStatus{Replicas: 3, ReadyReplicas: 3}
+	annotator.Annotate(tc, oldStatus)
+	g.Expect(texts()).To(Equal([]string{
+		"TidbCluster default/test-pd: pd upgrade finished",
+		"TidbCluster default/test-pd: tikv failover of 1 finished",
+		"TidbCluster default/test-pd: tidb scaling to 3 finished",
+	}))
+}
@@ -14,13 +14,19 @@
 package tidbcluster
 
 import (
+	"fmt"
+
+	"github.com/opentracing/opentracing-go"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
 	"github.com/pingcap/tidb-operator/pkg/manager"
 	"github.com/pingcap/tidb-operator/pkg/manager/member"
+	"github.com/pingcap/tidb-operator/pkg/tracing"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	errorutils "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/kubernetes/pkg/util/slice"
 )
 
 // ControlInterface implements the control logic for updating TidbClusters and their children StatefulSets.
@@ -36,64 +42,128 @@ type ControlInterface interface {
 func NewDefaultTidbClusterControl(
 	tcControl controller.TidbClusterControlInterface,
 	pdMemberManager manager.Manager,
+	pdMSMemberManager manager.Manager,
 	tikvMemberManager manager.Manager,
+	recoveryManager manager.Manager,
 	tidbMemberManager manager.Manager,
+	tiproxyMemberManager manager.Manager,
+	dashboardMemberManager manager.Manager,
 	reclaimPolicyManager manager.Manager,
+	adoptionManager manager.Manager,
 	metaManager manager.Manager,
 	orphanPodsCleaner member.OrphanPodsCleaner,
+	failedNodePodsCleaner member.FailedNodePodsCleaner,
 	pvcCleaner member.PVCCleanerInterface,
+	orphanPVCsCleaner member.OrphanPVCsCleaner,
+	tidbClusterCleaner member.TidbClusterCleanerInterface,
+	annotator StatusAnnotator,
 	recorder record.EventRecorder) ControlInterface {
 	return &defaultTidbClusterControl{
 		tcControl,
 		pdMemberManager,
+		pdMSMemberManager,
 		tikvMemberManager,
+		recoveryManager,
 		tidbMemberManager,
+		tiproxyMemberManager,
+		dashboardMemberManager,
 		reclaimPolicyManager,
+		adoptionManager,
 		metaManager,
 		orphanPodsCleaner,
+		failedNodePodsCleaner,
 		pvcCleaner,
+		orphanPVCsCleaner,
+		tidbClusterCleaner,
+		annotator,
 		recorder,
 	}
 }
 
 type defaultTidbClusterControl struct {
-	tcControl            controller.TidbClusterControlInterface
-	pdMemberManager      manager.Manager
-	tikvMemberManager    manager.Manager
-	tidbMemberManager    manager.Manager
-	reclaimPolicyManager manager.Manager
-	metaManager          manager.Manager
-	orphanPodsCleaner    member.OrphanPodsCleaner
-	pvcCleaner           member.PVCCleanerInterface
-	recorder             record.EventRecorder
+	tcControl              controller.TidbClusterControlInterface
+	pdMemberManager        manager.Manager
+	pdMSMemberManager      manager.Manager
+	tikvMemberManager      manager.Manager
+	recoveryManager        manager.Manager
+	tidbMemberManager      manager.Manager
+	tiproxyMemberManager   manager.Manager
+	dashboardMemberManager manager.Manager
+	reclaimPolicyManager   manager.Manager
+	adoptionManager        manager.Manager
+	metaManager            manager.Manager
+	orphanPodsCleaner      member.OrphanPodsCleaner
+	failedNodePodsCleaner  member.FailedNodePodsCleaner
+	pvcCleaner             member.PVCCleanerInterface
+	orphanPVCsCleaner      member.OrphanPVCsCleaner
+	tidbClusterCleaner     member.TidbClusterCleanerInterface
+	annotator              StatusAnnotator
+	recorder               record.EventRecorder
 }
 
 // UpdateStatefulSet executes the core logic loop for a tidbcluster.
 func (tcc *defaultTidbClusterControl) UpdateTidbCluster(tc *v1alpha1.TidbCluster) error {
 	var errs []error
 	oldStatus := tc.Status.DeepCopy()
+	span := tracing.StartSync("SyncTidbCluster", tc.GetNamespace(), tc.GetName())
+	defer span.Finish()
+
+	if tc.DeletionTimestamp != nil {
+		return tracing.Phase(span, "Cleanup", func() error { return tcc.cleanUpTidbCluster(tc) })
+	}
+	if err := tcc.addCleanupFinalizer(tc); err != nil {
+		return err
+	}
 
-	if err := tcc.updateTidbCluster(tc); err != nil {
+	if err := tcc.updateTidbCluster(tc, span); err != nil {
 		errs = append(errs, err)
 	}
+	// annotator is nil if the operator actions are not annotated
+	if tcc.annotator != nil {
+		tcc.annotator.Annotate(tc, oldStatus)
+	}
 	if apiequality.Semantic.DeepEqual(&tc.Status, oldStatus) {
 		return errorutils.NewAggregate(errs)
 	}
-	if _, err := tcc.tcControl.UpdateTidbCluster(tc.DeepCopy(), &tc.Status, oldStatus); err != nil {
+	err := tracing.Phase(span, "UpdateStatus", func() error {
+		_, err := tcc.tcControl.UpdateTidbCluster(tc.DeepCopy(), &tc.Status, oldStatus)
+		return err
+	})
+	if err != nil {
 		errs = append(errs, err)
 	}
 
 	return errorutils.NewAggregate(errs)
 }
 
-func (tcc *defaultTidbClusterControl) updateTidbCluster(tc *v1alpha1.TidbCluster) error {
+func (tcc *defaultTidbClusterControl) updateTidbCluster(tc *v1alpha1.TidbCluster, span opentracing.Span) error {
+	// taking the ownership of the existing statefulsets and services if the tc is annotated to adopt them,
+	// before they are synced by the member managers
+	if err := tracing.Phase(span, "Adoption", func() error { return tcc.adoptionManager.Sync(tc) }); err != nil {
+		return err
+	}
+
 	// syncing all PVs managed by operator's reclaim policy to Retain
-	if err := tcc.reclaimPolicyManager.Sync(tc); err != nil {
+	if err := tracing.Phase(span, "ReclaimPolicy", func() error { return tcc.reclaimPolicyManager.Sync(tc) }); err != nil {
 		return err
 	}
 
 	// cleaning all orphan pods(pd or tikv which don't have a related PVC) managed by operator
-	if _, err := tcc.orphanPodsCleaner.Clean(tc); err != nil {
+	err := tracing.Phase(span, "OrphanPodsCleaner", func() error {
+		_, err := tcc.orphanPodsCleaner.Clean(tc)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// force deleting the pd and tikv pods stuck in Terminating on the confirmed failed nodes,
+	// so that the statefulset controller can create the replacements
+	err = tracing.Phase(span, "FailedNodePodsCleaner", func() error {
+		_, err := tcc.failedNodePodsCleaner.Clean(tc)
+		return err
+	})
+	if err != nil {
 		return err
 	}
 
@@ -108,7 +178,19 @@ func (tcc *defaultTidbClusterControl) updateTidbCluster(tc *v1alpha1.TidbCluster
 	//   - upgrade the pd cluster
 	//   - scale out/in the pd cluster
 	//   - failover the pd cluster
-	if err := tcc.pdMemberManager.Sync(tc); err != nil {
+	if err := tracing.Phase(span, "PD", func() error { return tcc.pdMemberManager.Sync(tc) }); err != nil {
+		return err
+	}
+
+	// works that should do to making the pd microservices current state match the desired state,
+	// only in the pd microservice mode:
+	//   - waiting for the pd cluster available
+	//   - create or update the tso and scheduling headless services
+	//   - create the tso and scheduling statefulsets
+	//   - sync the microservices status from the pods to TidbCluster object
+	//   - upgrade the tso and scheduling members after pd
+	//   - scale out/in the tso and scheduling members
+	if err := tracing.Phase(span, "PDMicroservices", func() error { return tcc.pdMSMemberManager.Sync(tc) }); err != nil {
 		return err
 	}
 
@@ -121,7 +203,14 @@ func (tcc *defaultTidbClusterControl) updateTidbCluster(tc *v1alpha1.TidbCluster
 	//   - upgrade the tikv cluster
 	//   - scale out/in the tikv cluster
 	//   - failover the tikv cluster
-	if err := tcc.tikvMemberManager.Sync(tc); err != nil {
+	if err := tracing.Phase(span, "TiKV", func() error { return tcc.tikvMemberManager.Sync(tc) }); err != nil {
+		return err
+	}
+
+	// works that should do to recovering the cluster in the recovery mode:
+	//   - submit the unsafe recovery of the failed stores to pd
+	//   - sync the progress of the unsafe recovery to TidbCluster object
+	if err := tracing.Phase(span, "Recovery", func() error { return tcc.recoveryManager.Sync(tc) }); err != nil {
 		return err
 	}
 
@@ -133,7 +222,27 @@ func (tcc *defaultTidbClusterControl) updateTidbCluster(tc *v1alpha1.TidbCluster
 	//   - upgrade the tidb cluster
 	//   - scale out/in the tidb cluster
 	//   - failover the tidb cluster
-	if err := tcc.tidbMemberManager.Sync(tc); err != nil {
+	if err := tracing.Phase(span, "TiDB", func() error { return tcc.tidbMemberManager.Sync(tc) }); err != nil {
+		return err
+	}
+
+	// works that should do to making the tiproxy cluster current state match the desired state:
+	//   - waiting for the pd cluster available
+	//   - create or update the tiproxy configmap
+	//   - create or update tiproxy service and headless service
+	//   - create the tiproxy statefulset
+	//   - sync tiproxy cluster status from the pods to TidbCluster object
+	//   - upgrade the tiproxy cluster when the other components are not upgrading
+	if err := tracing.Phase(span, "TiProxy", func() error { return tcc.tiproxyMemberManager.Sync(tc) }); err != nil {
+		return err
+	}
+
+	// works that should do to making the dashboard current state match the desired state:
+	//   - waiting for the pd cluster available
+	//   - create or update the dashboard service
+	//   - create, update or delete the dashboard ingress
+	//   - create or update the dashboard statefulset
+	if err := tracing.Phase(span, "Dashboard", func() error { return tcc.dashboardMemberManager.Sync(tc) }); err != nil {
 		return err
 	}
 
@@ -141,13 +250,57 @@ func (tcc *defaultTidbClusterControl) updateTidbCluster(tc *v1alpha1.TidbCluster
 	//   - label.StoreIDLabelKey
 	//   - label.MemberIDLabelKey
 	//   - label.NamespaceLabelKey
-	if err := tcc.metaManager.Sync(tc); err != nil {
+	if err := tracing.Phase(span, "Meta", func() error { return tcc.metaManager.Sync(tc) }); err != nil {
 		return err
 	}
 
 	// cleaning the pod scheduling annotation for pd and tikv
-	_, err := tcc.pvcCleaner.Clean(tc)
-	return err
+	err = tracing.Phase(span, "PVCCleaner", func() error {
+		_, err := tcc.pvcCleaner.Clean(tc)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// cleaning the pvcs of pd and tikv which are not used by any pod for the grace period
+	return tracing.Phase(span, "OrphanPVCsCleaner", func() error {
+		_, err := tcc.orphanPVCsCleaner.Clean(tc)
+		return err
+	})
+}
+
+// addCleanupFinalizer adds the cleanup finalizer, so that the TidbCluster is not removed
+// before the resources left behind by the garbage collection are cleaned up
+func (tcc *defaultTidbClusterControl) addCleanupFinalizer(tc *v1alpha1.TidbCluster) error {
+	if slice.ContainsString(tc.Finalizers, label.TidbClusterCleanupFinalizer, nil) {
+		return nil
+	}
+	finalizers := append(append([]string{}, tc.Finalizers...), label.TidbClusterCleanupFinalizer)
+	updateTC, err := tcc.tcControl.UpdateFinalizers(tc, finalizers)
+	if err != nil {
+		return fmt.Errorf("add tidbcluster %s/%s cleanup finalizer failed, err: %v", tc.GetNamespace(), tc.GetName(), err)
+	}
+	tc.ObjectMeta = updateTC.ObjectMeta
+	return nil
+}
+
+// cleanUpTidbCluster cleans up the TidbCluster which is being deleted and removes the cleanup
+// finalizer, the cleanup is retried until it succeeds
+func (tcc *defaultTidbClusterControl) cleanUpTidbCluster(tc *v1alpha1.TidbCluster) error {
+	if !slice.ContainsString(tc.Finalizers, label.TidbClusterCleanupFinalizer, nil) {
+		return nil
+	}
+	if err := tcc.tidbClusterCleaner.Clean(tc); err != nil {
+		return err
+	}
+	finalizers := slice.RemoveString(tc.Finalizers, label.TidbClusterCleanupFinalizer, nil)
+	updateTC, err := tcc.tcControl.UpdateFinalizers(tc, finalizers)
+	if err != nil {
+		return fmt.Errorf("remove tidbcluster %s/%s cleanup finalizer failed, err: %v", tc.GetNamespace(), tc.GetName(), err)
+	}
+	tc.ObjectMeta = updateTC.ObjectMeta
+	return nil
 }
 
 var _ ControlInterface = &defaultTidbClusterControl{}
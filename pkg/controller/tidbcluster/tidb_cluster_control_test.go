@@ -23,9 +23,10 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned/fake"
 	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
 	mm "github.com/pingcap/tidb-operator/pkg/manager/member"
 	"github.com/pingcap/tidb-operator/pkg/manager/meta"
-	apps "k8s.io/api/apps/v1beta1"
+	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -207,6 +208,25 @@ func TestTidbClusterControlUpdateTidbCluster(t *testing.T) {
 	}
 }
 
+func TestTidbClusterControlCleanupFinalizer(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForTidbClusterControl()
+	tc.Finalizers = []string{"foo"}
+	control, _, pdMemberManager, _, _, _ := newFakeTidbClusterControl()
+
+	g.Expect(control.UpdateTidbCluster(tc)).To(Succeed())
+	g.Expect(tc.Finalizers).To(Equal([]string{"foo", label.TidbClusterCleanupFinalizer}))
+
+	// the members are not synced once the tidbcluster is being deleted
+	pdMemberManager.SetSyncError(fmt.Errorf("pd member manager sync error"))
+	now := metav1.Now()
+	tc.DeletionTimestamp = &now
+	g.Expect(control.UpdateTidbCluster(tc)).To(Succeed())
+	g.Expect(tc.Finalizers).To(Equal([]string{"foo"}))
+	g.Expect(control.UpdateTidbCluster(tc)).To(Succeed())
+}
+
 func TestTidbClusterStatusEquality(t *testing.T) {
 	g := NewGomegaWithT(t)
 	tcStatus := v1alpha1.TidbClusterStatus{}
@@ -227,13 +247,20 @@ func newFakeTidbClusterControl() (ControlInterface, *meta.FakeReclaimPolicyManag
 
 	tcControl := controller.NewFakeTidbClusterControl(tcInformer)
 	pdMemberManager := mm.NewFakePDMemberManager()
+	pdMSMemberManager := mm.NewFakePDMSMemberManager()
 	tikvMemberManager := mm.NewFakeTiKVMemberManager()
+	recoveryManager := mm.NewFakeRecoveryManager()
 	tidbMemberManager := mm.NewFakeTiDBMemberManager()
+	tiproxyMemberManager := mm.NewFakeTiProxyMemberManager()
+	dashboardMemberManager := mm.NewFakeDashboardMemberManager()
 	reclaimPolicyManager := meta.NewFakeReclaimPolicyManager()
 	metaManager := meta.NewFakeMetaManager()
 	opc := mm.NewFakeOrphanPodsCleaner()
+	fnpc := mm.NewFakeFailedNodePodsCleaner()
 	pcc := mm.NewFakePVCCleaner()
-	control := NewDefaultTidbClusterControl(tcControl, pdMemberManager, tikvMemberManager, tidbMemberManager, reclaimPolicyManager, metaManager, opc, pcc, recorder)
+	opvcc := mm.NewFakeOrphanPVCsCleaner()
+	tcc := mm.NewFakeTidbClusterCleaner()
+	control := NewDefaultTidbClusterControl(tcControl, pdMemberManager, pdMSMemberManager, tikvMemberManager, recoveryManager, tidbMemberManager, tiproxyMemberManager, dashboardMemberManager, reclaimPolicyManager, meta.NewFakeAdoptionManager(), metaManager, opc, fnpc, pcc, opvcc, tcc, nil, recorder)
 
 	return control, reclaimPolicyManager, pdMemberManager, tikvMemberManager, tidbMemberManager, metaManager
 }
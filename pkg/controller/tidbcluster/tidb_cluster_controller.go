@@ -17,17 +17,17 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/golang/glog"
 	perrors "github.com/pingcap/errors"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
 	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions"
 	listers "github.com/pingcap/tidb-operator/pkg/client/listers/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	mm "github.com/pingcap/tidb-operator/pkg/manager/member"
 	"github.com/pingcap/tidb-operator/pkg/manager/meta"
 	"github.com/pingcap/tidb-operator/pkg/pdapi"
-	apps "k8s.io/api/apps/v1beta1"
+	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -36,7 +36,7 @@ import (
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	eventv1 "k8s.io/client-go/kubernetes/typed/core/v1"
-	appslisters "k8s.io/client-go/listers/apps/v1beta1"
+	appslisters "k8s.io/client-go/listers/apps/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
@@ -72,31 +72,40 @@ func NewController(
 	cli versioned.Interface,
 	informerFactory informers.SharedInformerFactory,
 	kubeInformerFactory kubeinformers.SharedInformerFactory,
+	labelFilterKubeInformerFactory kubeinformers.SharedInformerFactory,
 	autoFailover bool,
 	pdFailoverPeriod time.Duration,
 	tikvFailoverPeriod time.Duration,
 	tidbFailoverPeriod time.Duration,
+	podForceDeletionOnNodeFailure bool,
+	nodeFailureThreshold time.Duration,
+	orphanPVCGracePeriod time.Duration,
+	annotator StatusAnnotator,
 ) *Controller {
 	eventBroadcaster := record.NewBroadcaster()
-	eventBroadcaster.StartLogging(glog.Infof)
+	eventBroadcaster.StartLogging(log.Infof)
 	eventBroadcaster.StartRecordingToSink(&eventv1.EventSinkImpl{
 		Interface: eventv1.New(kubeCli.CoreV1().RESTClient()).Events("")})
 	recorder := eventBroadcaster.NewRecorder(v1alpha1.Scheme, corev1.EventSource{Component: "tidbcluster"})
 
 	tcInformer := informerFactory.Pingcap().V1alpha1().TidbClusters()
-	setInformer := kubeInformerFactory.Apps().V1beta1().StatefulSets()
+	setInformer := labelFilterKubeInformerFactory.Apps().V1().StatefulSets()
 	svcInformer := kubeInformerFactory.Core().V1().Services()
+	cmInformer := kubeInformerFactory.Core().V1().ConfigMaps()
 	epsInformer := kubeInformerFactory.Core().V1().Endpoints()
-	pvcInformer := kubeInformerFactory.Core().V1().PersistentVolumeClaims()
+	pvcInformer := labelFilterKubeInformerFactory.Core().V1().PersistentVolumeClaims()
 	pvInformer := kubeInformerFactory.Core().V1().PersistentVolumes()
-	podInformer := kubeInformerFactory.Core().V1().Pods()
+	podInformer := labelFilterKubeInformerFactory.Core().V1().Pods()
 	nodeInformer := kubeInformerFactory.Core().V1().Nodes()
+	ingInformer := kubeInformerFactory.Extensions().V1beta1().Ingresses()
 
 	tcControl := controller.NewRealTidbClusterControl(cli, tcInformer.Lister(), recorder)
 	pdControl := pdapi.NewDefaultPDControl()
 	tidbControl := controller.NewDefaultTiDBControl()
 	setControl := controller.NewRealStatefuSetControl(kubeCli, setInformer.Lister(), recorder)
 	svcControl := controller.NewRealServiceControl(kubeCli, svcInformer.Lister(), recorder)
+	cmControl := controller.NewRealConfigMapControl(kubeCli, cmInformer.Lister(), recorder)
+	ingControl := controller.NewRealIngressControl(kubeCli, ingInformer.Lister(), recorder)
 	pvControl := controller.NewRealPVControl(kubeCli, pvcInformer.Lister(), pvInformer.Lister(), recorder)
 	pvcControl := controller.NewRealPVCControl(kubeCli, recorder, pvcInformer.Lister())
 	podControl := controller.NewRealPodControl(kubeCli, pdControl, podInformer.Lister(), recorder)
@@ -105,9 +114,12 @@ func NewController(
 	pdFailover := mm.NewPDFailover(cli, pdControl, pdFailoverPeriod, podInformer.Lister(), podControl, pvcInformer.Lister(), pvcControl, pvInformer.Lister())
 	tikvFailover := mm.NewTiKVFailover(tikvFailoverPeriod)
 	tidbFailover := mm.NewTiDBFailover(tidbFailoverPeriod)
-	pdUpgrader := mm.NewPDUpgrader(pdControl, podControl, podInformer.Lister())
-	tikvUpgrader := mm.NewTiKVUpgrader(pdControl, podControl, podInformer.Lister())
-	tidbUpgrader := mm.NewTiDBUpgrader(tidbControl, podInformer.Lister())
+	upgradeChecker := mm.NewUpgradeChecker(pdControl, recorder)
+	pdUpgrader := mm.NewPDUpgrader(pdControl, podControl, podInformer.Lister(), upgradeChecker)
+	tikvUpgrader := mm.NewTiKVUpgrader(pdControl, podControl, podInformer.Lister(), upgradeChecker)
+	tidbUpgrader := mm.NewTiDBUpgrader(tidbControl, podInformer.Lister(), upgradeChecker)
+	tiproxyUpgrader := mm.NewTiProxyUpgrader(podInformer.Lister())
+	pdMSUpgrader := mm.NewPDMSUpgrader(podInformer.Lister())
 
 	tcc := &Controller{
 		kubeClient: kubeCli,
@@ -129,6 +141,14 @@ func NewController(
 				autoFailover,
 				pdFailover,
 			),
+			mm.NewPDMSMemberManager(
+				setControl,
+				svcControl,
+				setInformer.Lister(),
+				svcInformer.Lister(),
+				podInformer.Lister(),
+				pdMSUpgrader,
+			),
 			mm.NewTiKVMemberManager(
 				pdControl,
 				setControl,
@@ -142,6 +162,7 @@ func NewController(
 				tikvScaler,
 				tikvUpgrader,
 			),
+			mm.NewRecoveryManager(pdControl),
 			mm.NewTiDBMemberManager(
 				setControl,
 				svcControl,
@@ -153,11 +174,30 @@ func NewController(
 				autoFailover,
 				tidbFailover,
 			),
+			mm.NewTiProxyMemberManager(
+				setControl,
+				svcControl,
+				cmControl,
+				setInformer.Lister(),
+				svcInformer.Lister(),
+				cmInformer.Lister(),
+				podInformer.Lister(),
+				tiproxyUpgrader,
+			),
+			mm.NewDashboardMemberManager(
+				setControl,
+				svcControl,
+				ingControl,
+				setInformer.Lister(),
+				svcInformer.Lister(),
+				ingInformer.Lister(),
+			),
 			meta.NewReclaimPolicyManager(
 				pvcInformer.Lister(),
 				pvInformer.Lister(),
 				pvControl,
 			),
+			meta.NewAdoptionManager(kubeCli),
 			meta.NewMetaManager(
 				pvcInformer.Lister(),
 				pvcControl,
@@ -172,11 +212,33 @@ func NewController(
 				pvcInformer.Lister(),
 				kubeCli,
 			),
+			mm.NewFailedNodePodsCleaner(
+				podInformer.Lister(),
+				nodeInformer.Lister(),
+				pvcInformer.Lister(),
+				kubeCli,
+				podForceDeletionOnNodeFailure,
+				nodeFailureThreshold,
+			),
 			mm.NewRealPVCCleaner(
 				podInformer.Lister(),
 				pvcControl,
 				pvcInformer.Lister(),
 			),
+			mm.NewOrphanPVCsCleaner(
+				podInformer.Lister(),
+				pvcInformer.Lister(),
+				pvcControl,
+				setInformer.Lister(),
+				orphanPVCGracePeriod,
+			),
+			mm.NewRealTidbClusterCleaner(
+				pdControl,
+				pvcInformer.Lister(),
+				pvcControl,
+				kubeCli,
+			),
+			annotator,
 			recorder,
 		),
 		queue: workqueue.NewNamedRateLimitingQueue(
@@ -213,8 +275,8 @@ func (tcc *Controller) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
 	defer tcc.queue.ShutDown()
 
-	glog.Info("Starting tidbcluster controller")
-	defer glog.Info("Shutting down tidbcluster controller")
+	log.Info("Starting tidbcluster controller")
+	defer log.Info("Shutting down tidbcluster controller")
 
 	for i := 0; i < workers; i++ {
 		go wait.Until(tcc.worker, time.Second, stopCh)
@@ -240,11 +302,15 @@ func (tcc *Controller) processNextWorkItem() bool {
 	defer tcc.queue.Done(key)
 	if err := tcc.sync(key.(string)); err != nil {
 		if perrors.Find(err, controller.IsRequeueError) != nil {
-			glog.Infof("TidbCluster: %v, still need sync: %v, requeuing", key.(string), err)
+			log.Infof("TidbCluster: %v, still need sync: %v, requeuing", key.(string), err)
 		} else {
 			utilruntime.HandleError(fmt.Errorf("TidbCluster: %v, sync failed %v, requeuing", key.(string), err))
 		}
-		tcc.queue.AddRateLimited(key)
+		if after := controller.GetRequeueAfter(err); after > 0 {
+			tcc.queue.AddAfter(key, after)
+		} else {
+			tcc.queue.AddRateLimited(key)
+		}
 	} else {
 		tcc.queue.Forget(key)
 	}
@@ -254,17 +320,18 @@ func (tcc *Controller) processNextWorkItem() bool {
 // sync syncs the given tidbcluster.
 func (tcc *Controller) sync(key string) error {
 	startTime := time.Now()
-	defer func() {
-		glog.V(4).Infof("Finished syncing TidbCluster %q (%v)", key, time.Since(startTime))
-	}()
-
 	ns, name, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
 		return err
 	}
+	logger := log.ForCluster(ns, name)
+	defer func() {
+		logger.V(4).Infof("Finished syncing TidbCluster %q (%v)", key, time.Since(startTime))
+	}()
+
 	tc, err := tcc.tcLister.TidbClusters(ns).Get(name)
 	if errors.IsNotFound(err) {
-		glog.Infof("TidbCluster has been deleted %v", key)
+		logger.Infof("TidbCluster has been deleted %v", key)
 		return nil
 	}
 	if err != nil {
@@ -306,7 +373,7 @@ func (tcc *Controller) addStatefulSet(obj interface{}) {
 	if tc == nil {
 		return
 	}
-	glog.V(4).Infof("StatefuSet %s/%s created, TidbCluster: %s/%s", ns, setName, ns, tc.Name)
+	log.V(4).Infof("StatefuSet %s/%s created, TidbCluster: %s/%s", ns, setName, ns, tc.Name)
 	tcc.enqueueTidbCluster(tc)
 }
 
@@ -327,7 +394,7 @@ func (tcc *Controller) updateStatefuSet(old, cur interface{}) {
 	if tc == nil {
 		return
 	}
-	glog.V(4).Infof("StatefulSet %s/%s updated, %+v -> %+v.", ns, setName, oldSet.Spec, curSet.Spec)
+	log.V(4).Infof("StatefulSet %s/%s updated, %+v -> %+v.", ns, setName, oldSet.Spec, curSet.Spec)
 	tcc.enqueueTidbCluster(tc)
 }
 
@@ -358,7 +425,7 @@ func (tcc *Controller) deleteStatefulSet(obj interface{}) {
 	if tc == nil {
 		return
 	}
-	glog.V(4).Infof("StatefulSet %s/%s deleted through %v.", ns, setName, utilruntime.GetCaller())
+	log.V(4).Infof("StatefulSet %s/%s deleted through %v.", ns, setName, utilruntime.GetCaller())
 	tcc.enqueueTidbCluster(tc)
 }
 
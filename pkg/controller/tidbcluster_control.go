@@ -14,26 +14,30 @@
 package controller
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
-	"github.com/golang/glog"
+	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
 	tcinformers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions/pingcap.com/v1alpha1"
 	listers "github.com/pingcap/tidb-operator/pkg/client/listers/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	"github.com/pingcap/tidb-operator/pkg/tracing"
 	corev1 "k8s.io/api/core/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
-	"k8s.io/client-go/util/retry"
 )
 
 // TidbClusterControlInterface manages TidbClusters
 type TidbClusterControlInterface interface {
+	CreateTidbCluster(*v1alpha1.TidbCluster) (*v1alpha1.TidbCluster, error)
 	UpdateTidbCluster(*v1alpha1.TidbCluster, *v1alpha1.TidbClusterStatus, *v1alpha1.TidbClusterStatus) (*v1alpha1.TidbCluster, error)
+	UpdateFinalizers(*v1alpha1.TidbCluster, []string) (*v1alpha1.TidbCluster, error)
 }
 
 type realTidbClusterControl struct {
@@ -53,39 +57,96 @@ func NewRealTidbClusterControl(cli versioned.Interface,
 	}
 }
 
+func (rtc *realTidbClusterControl) CreateTidbCluster(tc *v1alpha1.TidbCluster) (*v1alpha1.TidbCluster, error) {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	span := tracing.StartCall(tc.GetNamespace(), tc.GetName(), "CreateTidbCluster")
+	createTC, err := rtc.cli.PingcapV1alpha1().TidbClusters(ns).Create(tc)
+	tracing.FinishCall(span, err)
+	if err != nil {
+		log.Errorf("failed to create TidbCluster: [%s/%s], error: %v", ns, tcName, err)
+	} else {
+		log.V(4).Infof("create TidbCluster: [%s/%s] successfully", ns, tcName)
+	}
+	rtc.recordTidbClusterEvent("create", tc, err)
+	return createTC, err
+}
+
+// UpdateTidbCluster applies the status changes accumulated during a sync at once by a JSON merge
+// patch, the patch doesn't carry the resourceVersion, so it never conflicts with the other writers
+// and the fields which are not changed in the sync are left as they are
 func (rtc *realTidbClusterControl) UpdateTidbCluster(tc *v1alpha1.TidbCluster, newStatus *v1alpha1.TidbClusterStatus, oldStatus *v1alpha1.TidbClusterStatus) (*v1alpha1.TidbCluster, error) {
 	ns := tc.GetNamespace()
 	tcName := tc.GetName()
 
-	status := tc.Status.DeepCopy()
-	var updateTC *v1alpha1.TidbCluster
-
-	// don't wait due to limited number of clients, but backoff after the default number of steps
-	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		var updateErr error
-		updateTC, updateErr = rtc.cli.PingcapV1alpha1().TidbClusters(ns).Update(tc)
-		if updateErr == nil {
-			glog.Infof("TidbCluster: [%s/%s] updated successfully", ns, tcName)
-			return nil
-		}
-		glog.Errorf("failed to update TidbCluster: [%s/%s], error: %v", ns, tcName, updateErr)
-
-		if updated, err := rtc.tcLister.TidbClusters(ns).Get(tcName); err == nil {
-			// make a copy so we don't mutate the shared cache
-			tc = updated.DeepCopy()
-			tc.Status = *status
-		} else {
-			utilruntime.HandleError(fmt.Errorf("error getting updated TidbCluster %s/%s from lister: %v", ns, tcName, err))
-		}
-
-		return updateErr
-	})
+	patch, err := statusMergePatch(oldStatus, newStatus)
+	if err != nil {
+		return nil, err
+	}
+	span := tracing.StartCall(tc.GetNamespace(), tc.GetName(), "UpdateTidbCluster")
+	updateTC, err := rtc.cli.PingcapV1alpha1().TidbClusters(ns).Patch(tcName, types.MergePatchType, patch)
+	tracing.FinishCall(span, err)
+	if err != nil {
+		log.Errorf("failed to update TidbCluster: [%s/%s], error: %v", ns, tcName, err)
+	} else {
+		log.Infof("TidbCluster: [%s/%s] updated successfully", ns, tcName)
+	}
 	if !deepEqualExceptHeartbeatTime(newStatus.DeepCopy(), oldStatus.DeepCopy()) {
 		rtc.recordTidbClusterEvent("update", tc, err)
 	}
 	return updateTC, err
 }
 
+// UpdateFinalizers replaces the finalizers of the TidbCluster, the update carries the resourceVersion
+// of the TidbCluster and fails on a conflict, so that the finalizers added by the others are not lost
+func (rtc *realTidbClusterControl) UpdateFinalizers(tc *v1alpha1.TidbCluster, finalizers []string) (*v1alpha1.TidbCluster, error) {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	tcCopy := tc.DeepCopy()
+	tcCopy.Finalizers = finalizers
+	span := tracing.StartCall(tc.GetNamespace(), tc.GetName(), "UpdateTidbClusterFinalizers")
+	updateTC, err := rtc.cli.PingcapV1alpha1().TidbClusters(ns).Update(tcCopy)
+	tracing.FinishCall(span, err)
+	if err != nil {
+		log.Errorf("failed to update finalizers of TidbCluster: [%s/%s], error: %v", ns, tcName, err)
+	} else {
+		log.Infof("TidbCluster: [%s/%s] finalizers updated to %v successfully", ns, tcName, finalizers)
+	}
+	return updateTC, err
+}
+
+// statusMergePatch returns the JSON merge patch of the status changes, the removed fields and map
+// entries are set to null in the patch
+func statusMergePatch(oldStatus, newStatus interface{}) ([]byte, error) {
+	oldData, err := json.Marshal(map[string]interface{}{"status": oldStatus})
+	if err != nil {
+		return nil, err
+	}
+	newData, err := json.Marshal(map[string]interface{}{"status": newStatus})
+	if err != nil {
+		return nil, err
+	}
+	return jsonpatch.CreateMergePatch(oldData, newData)
+}
+
+// versionedStatusMergePatch returns the merge patch of the status which carries the
+// resourceVersion, the patch is rejected with a conflict if the object is changed
+// after the version
+func versionedStatusMergePatch(resourceVersion string, oldStatus, newStatus interface{}) ([]byte, error) {
+	patch, err := statusMergePatch(oldStatus, newStatus)
+	if err != nil || resourceVersion == "" {
+		return patch, err
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(patch, &fields); err != nil {
+		return nil, err
+	}
+	fields["metadata"] = map[string]string{"resourceVersion": resourceVersion}
+	return json.Marshal(fields)
+}
+
 func (rtc *realTidbClusterControl) recordTidbClusterEvent(verb string, tc *v1alpha1.TidbCluster, err error) {
 	tcName := tc.GetName()
 	if err == nil {
@@ -121,6 +182,7 @@ func sweepHeartbeatTime(stores map[string]v1alpha1.TiKVStore) {
 type FakeTidbClusterControl struct {
 	TcLister                 listers.TidbClusterLister
 	TcIndexer                cache.Indexer
+	createTidbClusterTracker requestTracker
 	updateTidbClusterTracker requestTracker
 }
 
@@ -130,15 +192,33 @@ func NewFakeTidbClusterControl(tcInformer tcinformers.TidbClusterInformer) *Fake
 		tcInformer.Lister(),
 		tcInformer.Informer().GetIndexer(),
 		requestTracker{0, nil, 0},
+		requestTracker{0, nil, 0},
 	}
 }
 
+// SetCreateTidbClusterError sets the error attributes of createTidbClusterTracker
+func (ssc *FakeTidbClusterControl) SetCreateTidbClusterError(err error, after int) {
+	ssc.createTidbClusterTracker.err = err
+	ssc.createTidbClusterTracker.after = after
+}
+
 // SetUpdateTidbClusterError sets the error attributes of updateTidbClusterTracker
 func (ssc *FakeTidbClusterControl) SetUpdateTidbClusterError(err error, after int) {
 	ssc.updateTidbClusterTracker.err = err
 	ssc.updateTidbClusterTracker.after = after
 }
 
+// CreateTidbCluster creates the TidbCluster
+func (ssc *FakeTidbClusterControl) CreateTidbCluster(tc *v1alpha1.TidbCluster) (*v1alpha1.TidbCluster, error) {
+	defer ssc.createTidbClusterTracker.inc()
+	if ssc.createTidbClusterTracker.errorReady() {
+		defer ssc.createTidbClusterTracker.reset()
+		return tc, ssc.createTidbClusterTracker.err
+	}
+
+	return tc, ssc.TcIndexer.Add(tc)
+}
+
 // UpdateTidbCluster updates the TidbCluster
 func (ssc *FakeTidbClusterControl) UpdateTidbCluster(tc *v1alpha1.TidbCluster, _ *v1alpha1.TidbClusterStatus, _ *v1alpha1.TidbClusterStatus) (*v1alpha1.TidbCluster, error) {
 	defer ssc.updateTidbClusterTracker.inc()
@@ -149,3 +229,10 @@ func (ssc *FakeTidbClusterControl) UpdateTidbCluster(tc *v1alpha1.TidbCluster, _
 
 	return tc, ssc.TcIndexer.Update(tc)
 }
+
+// UpdateFinalizers updates the finalizers of the TidbCluster
+func (ssc *FakeTidbClusterControl) UpdateFinalizers(tc *v1alpha1.TidbCluster, finalizers []string) (*v1alpha1.TidbCluster, error) {
+	tcCopy := tc.DeepCopy()
+	tcCopy.Finalizers = finalizers
+	return tcCopy, ssc.TcIndexer.Update(tcCopy)
+}
@@ -22,15 +22,51 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned/fake"
-	listers "github.com/pingcap/tidb-operator/pkg/client/listers/pingcap.com/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	core "k8s.io/client-go/testing"
-	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 )
 
+func TestTidbClusterControlCreateTidbClusterSuccess(t *testing.T) {
+	g := NewGomegaWithT(t)
+	recorder := record.NewFakeRecorder(10)
+	tc := newTidbCluster()
+	fakeClient := &fake.Clientset{}
+	control := NewRealTidbClusterControl(fakeClient, nil, recorder)
+	fakeClient.AddReactor("create", "tidbclusters", func(action core.Action) (bool, runtime.Object, error) {
+		create := action.(core.CreateAction)
+		return true, create.GetObject(), nil
+	})
+	_, err := control.CreateTidbCluster(tc)
+	g.Expect(err).To(Succeed())
+
+	events := collectEvents(recorder.Events)
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0]).To(ContainSubstring(corev1.EventTypeNormal))
+}
+
+func TestTidbClusterControlCreateTidbClusterFailed(t *testing.T) {
+	g := NewGomegaWithT(t)
+	recorder := record.NewFakeRecorder(10)
+	tc := newTidbCluster()
+	fakeClient := &fake.Clientset{}
+	control := NewRealTidbClusterControl(fakeClient, nil, recorder)
+	fakeClient.AddReactor("create", "tidbclusters", func(action core.Action) (bool, runtime.Object, error) {
+		create := action.(core.CreateAction)
+		return true, create.GetObject(), apierrors.NewInternalError(errors.New("API server down"))
+	})
+	_, err := control.CreateTidbCluster(tc)
+	g.Expect(err).To(HaveOccurred())
+
+	events := collectEvents(recorder.Events)
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0]).To(ContainSubstring(corev1.EventTypeWarning))
+}
+
 func TestTidbClusterControlUpdateTidbCluster(t *testing.T) {
 	g := NewGomegaWithT(t)
 	recorder := record.NewFakeRecorder(10)
@@ -38,9 +74,8 @@ func TestTidbClusterControlUpdateTidbCluster(t *testing.T) {
 	tc.Spec.PD.Replicas = int32(5)
 	fakeClient := &fake.Clientset{}
 	control := NewRealTidbClusterControl(fakeClient, nil, recorder)
-	fakeClient.AddReactor("update", "tidbclusters", func(action core.Action) (bool, runtime.Object, error) {
-		update := action.(core.UpdateAction)
-		return true, update.GetObject(), nil
+	fakeClient.AddReactor("patch", "tidbclusters", func(action core.Action) (bool, runtime.Object, error) {
+		return true, tc, nil
 	})
 	updateTC, err := control.UpdateTidbCluster(tc, &v1alpha1.TidbClusterStatus{}, &v1alpha1.TidbClusterStatus{})
 	g.Expect(err).To(Succeed())
@@ -50,28 +85,40 @@ func TestTidbClusterControlUpdateTidbCluster(t *testing.T) {
 	g.Expect(events).To(HaveLen(0))
 }
 
-func TestTidbClusterControlUpdateTidbClusterConflictSuccess(t *testing.T) {
+func TestTidbClusterControlUpdateTidbClusterPatch(t *testing.T) {
 	g := NewGomegaWithT(t)
 	recorder := record.NewFakeRecorder(10)
 	tc := newTidbCluster()
 	fakeClient := &fake.Clientset{}
-	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
-	tcLister := listers.NewTidbClusterLister(indexer)
-	control := NewRealTidbClusterControl(fakeClient, tcLister, recorder)
-	conflict := false
-	fakeClient.AddReactor("update", "tidbclusters", func(action core.Action) (bool, runtime.Object, error) {
-		update := action.(core.UpdateAction)
-		if !conflict {
-			conflict = true
-			return true, update.GetObject(), apierrors.NewConflict(action.GetResource().GroupResource(), tc.Name, errors.New("conflict"))
-		}
-		return true, update.GetObject(), nil
+	control := NewRealTidbClusterControl(fakeClient, nil, recorder)
+	var patch string
+	fakeClient.AddReactor("patch", "tidbclusters", func(action core.Action) (bool, runtime.Object, error) {
+		p := action.(core.PatchAction)
+		g.Expect(p.GetPatchType()).To(Equal(types.MergePatchType))
+		patch = string(p.GetPatch())
+		return true, tc, nil
 	})
-	_, err := control.UpdateTidbCluster(tc, &v1alpha1.TidbClusterStatus{}, &v1alpha1.TidbClusterStatus{})
+
+	oldStatus := &v1alpha1.TidbClusterStatus{
+		TiKV: v1alpha1.TiKVStatus{
+			Stores: map[string]v1alpha1.TiKVStore{
+				"1": {ID: "1", State: v1alpha1.TiKVStateUp},
+				"2": {ID: "2", State: v1alpha1.TiKVStateUp},
+			},
+		},
+	}
+	newStatus := oldStatus.DeepCopy()
+	newStatus.TiKV.Synced = true
+	delete(newStatus.TiKV.Stores, "2")
+
+	// only the changed fields are patched and the removed stores are set to null
+	_, err := control.UpdateTidbCluster(tc, newStatus, oldStatus)
 	g.Expect(err).To(Succeed())
+	g.Expect(patch).To(Equal(`{"status":{"tikv":{"stores":{"2":null},"synced":true}}}`))
 
 	events := collectEvents(recorder.Events)
-	g.Expect(events).To(HaveLen(0))
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0]).To(ContainSubstring(corev1.EventTypeNormal))
 }
 
 func TestDeepEqualExceptHeartbeatTime(t *testing.T) {
@@ -0,0 +1,65 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tidbngmonitoring
+
+import (
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/manager"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	errorutils "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// ControlInterface implements the control logic for updating TidbNGMonitoring
+// It is implemented as an interface to allow for extensions that provide different semantics.
+// Currently, there is only one implementation.
+type ControlInterface interface {
+	// UpdateTidbNGMonitoring implements the control logic for ng-monitoring creation and update
+	UpdateTidbNGMonitoring(tngm *v1alpha1.TidbNGMonitoring) error
+}
+
+// NewDefaultTidbNGMonitoringControl returns a new instance of the default implementation ControlInterface that
+// implements the documented semantics for TidbNGMonitoring.
+func NewDefaultTidbNGMonitoringControl(
+	tngmControl controller.TidbNGMonitoringControlInterface,
+	ngMonitoringManager manager.NGMonitoringManager) ControlInterface {
+	return &defaultTidbNGMonitoringControl{
+		tngmControl,
+		ngMonitoringManager,
+	}
+}
+
+type defaultTidbNGMonitoringControl struct {
+	tngmControl         controller.TidbNGMonitoringControlInterface
+	ngMonitoringManager manager.NGMonitoringManager
+}
+
+// UpdateTidbNGMonitoring executes the core logic loop for a TidbNGMonitoring.
+func (tnc *defaultTidbNGMonitoringControl) UpdateTidbNGMonitoring(tngm *v1alpha1.TidbNGMonitoring) error {
+	var errs []error
+	tngm.SetGroupVersionKind(controller.TidbNGMonitoringControllerKind)
+	oldStatus := tngm.Status.DeepCopy()
+
+	if err := tnc.ngMonitoringManager.Sync(tngm); err != nil {
+		errs = append(errs, err)
+	}
+	if apiequality.Semantic.DeepEqual(&tngm.Status, oldStatus) {
+		return errorutils.NewAggregate(errs)
+	}
+	if _, err := tnc.tngmControl.UpdateTidbNGMonitoring(tngm.DeepCopy()); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errorutils.NewAggregate(errs)
+}
@@ -0,0 +1,193 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tidbngmonitoring
+
+import (
+	"fmt"
+	"time"
+
+	perrors "github.com/pingcap/errors"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
+	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions"
+	listers "github.com/pingcap/tidb-operator/pkg/client/listers/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	mm "github.com/pingcap/tidb-operator/pkg/manager/member"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	eventv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// Controller controls tidbngmonitoring.
+type Controller struct {
+	// kubernetes client interface
+	kubeClient kubernetes.Interface
+	// operator client interface
+	cli versioned.Interface
+	// control returns an interface capable of syncing a tidbngmonitoring.
+	// Abstracted out for testing.
+	control ControlInterface
+	// tngmLister is able to list/get tidbngmonitoring from a shared informer's store
+	tngmLister listers.TidbNGMonitoringLister
+	// tngmListerSynced returns true if the tidbngmonitoring shared informer has synced at least once
+	tngmListerSynced cache.InformerSynced
+	// tidbngmonitorings that need to be synced.
+	queue workqueue.RateLimitingInterface
+}
+
+// NewController creates a tidbngmonitoring controller.
+func NewController(
+	kubeCli kubernetes.Interface,
+	cli versioned.Interface,
+	informerFactory informers.SharedInformerFactory,
+	kubeInformerFactory kubeinformers.SharedInformerFactory,
+	labelFilterKubeInformerFactory kubeinformers.SharedInformerFactory,
+) *Controller {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(log.Infof)
+	eventBroadcaster.StartRecordingToSink(&eventv1.EventSinkImpl{
+		Interface: eventv1.New(kubeCli.CoreV1().RESTClient()).Events("")})
+	recorder := eventBroadcaster.NewRecorder(v1alpha1.Scheme, corev1.EventSource{Component: "tidbngmonitoring"})
+
+	tngmInformer := informerFactory.Pingcap().V1alpha1().TidbNGMonitorings()
+	tcInformer := informerFactory.Pingcap().V1alpha1().TidbClusters()
+	setInformer := labelFilterKubeInformerFactory.Apps().V1().StatefulSets()
+	svcInformer := kubeInformerFactory.Core().V1().Services()
+	cmInformer := kubeInformerFactory.Core().V1().ConfigMaps()
+	tngmControl := controller.NewRealTidbNGMonitoringControl(cli, tngmInformer.Lister(), recorder)
+	setControl := controller.NewRealStatefuSetControl(kubeCli, setInformer.Lister(), recorder)
+	svcControl := controller.NewRealServiceControl(kubeCli, svcInformer.Lister(), recorder)
+	cmControl := controller.NewRealConfigMapControl(kubeCli, cmInformer.Lister(), recorder)
+
+	tnc := &Controller{
+		kubeClient: kubeCli,
+		cli:        cli,
+		control: NewDefaultTidbNGMonitoringControl(
+			tngmControl,
+			mm.NewNGMonitoringManager(
+				setControl,
+				svcControl,
+				cmControl,
+				tcInformer.Lister(),
+				setInformer.Lister(),
+				svcInformer.Lister(),
+				cmInformer.Lister(),
+			),
+		),
+		queue: workqueue.NewNamedRateLimitingQueue(
+			workqueue.DefaultControllerRateLimiter(),
+			"tidbngmonitoring",
+		),
+	}
+
+	tngmInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: tnc.enqueueTidbNGMonitoring,
+		UpdateFunc: func(old, cur interface{}) {
+			tnc.enqueueTidbNGMonitoring(cur)
+		},
+		DeleteFunc: tnc.enqueueTidbNGMonitoring,
+	})
+	tnc.tngmLister = tngmInformer.Lister()
+	tnc.tngmListerSynced = tngmInformer.Informer().HasSynced
+
+	return tnc
+}
+
+// Run runs the tidbngmonitoring controller.
+func (tnc *Controller) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer tnc.queue.ShutDown()
+
+	log.Info("Starting tidbngmonitoring controller")
+	defer log.Info("Shutting down tidbngmonitoring controller")
+
+	for i := 0; i < workers; i++ {
+		go wait.Until(tnc.worker, time.Second, stopCh)
+	}
+
+	<-stopCh
+}
+
+// worker runs a worker goroutine that invokes processNextWorkItem until the the controller's queue is closed
+func (tnc *Controller) worker() {
+	for tnc.processNextWorkItem() {
+		// revive:disable:empty-block
+	}
+}
+
+// processNextWorkItem dequeues items, processes them, and marks them done. It enforces that the syncHandler is never
+// invoked concurrently with the same key.
+func (tnc *Controller) processNextWorkItem() bool {
+	key, quit := tnc.queue.Get()
+	if quit {
+		return false
+	}
+	defer tnc.queue.Done(key)
+	if err := tnc.sync(key.(string)); err != nil {
+		if perrors.Find(err, controller.IsRequeueError) != nil {
+			log.Infof("TidbNGMonitoring: %v, still need sync: %v, requeuing", key.(string), err)
+		} else {
+			utilruntime.HandleError(fmt.Errorf("TidbNGMonitoring: %v, sync failed, err: %v, requeuing", key.(string), err))
+		}
+		tnc.queue.AddRateLimited(key)
+	} else {
+		tnc.queue.Forget(key)
+	}
+	return true
+}
+
+// sync syncs the given tidbngmonitoring.
+func (tnc *Controller) sync(key string) error {
+	startTime := time.Now()
+	defer func() {
+		log.V(4).Infof("Finished syncing TidbNGMonitoring %q (%v)", key, time.Since(startTime))
+	}()
+
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	tngm, err := tnc.tngmLister.TidbNGMonitorings(ns).Get(name)
+	if errors.IsNotFound(err) {
+		log.Infof("TidbNGMonitoring has been deleted %v", key)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return tnc.syncTidbNGMonitoring(tngm.DeepCopy())
+}
+
+func (tnc *Controller) syncTidbNGMonitoring(tngm *v1alpha1.TidbNGMonitoring) error {
+	return tnc.control.UpdateTidbNGMonitoring(tngm)
+}
+
+// enqueueTidbNGMonitoring enqueues the given tidbngmonitoring in the work queue.
+func (tnc *Controller) enqueueTidbNGMonitoring(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("Cound't get key for object %+v: %v", obj, err))
+		return
+	}
+	tnc.queue.Add(key)
+}
@@ -0,0 +1,121 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
+	tcinformers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions/pingcap.com/v1alpha1"
+	listers "github.com/pingcap/tidb-operator/pkg/client/listers/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	corev1 "k8s.io/api/core/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+)
+
+// TidbNGMonitoringControlInterface manages TidbNGMonitorings
+type TidbNGMonitoringControlInterface interface {
+	UpdateTidbNGMonitoring(*v1alpha1.TidbNGMonitoring) (*v1alpha1.TidbNGMonitoring, error)
+}
+
+type realTidbNGMonitoringControl struct {
+	cli        versioned.Interface
+	tngmLister listers.TidbNGMonitoringLister
+	recorder   record.EventRecorder
+}
+
+// NewRealTidbNGMonitoringControl creates a new TidbNGMonitoringControlInterface
+func NewRealTidbNGMonitoringControl(cli versioned.Interface,
+	tngmLister listers.TidbNGMonitoringLister,
+	recorder record.EventRecorder) TidbNGMonitoringControlInterface {
+	return &realTidbNGMonitoringControl{
+		cli,
+		tngmLister,
+		recorder,
+	}
+}
+
+func (rtc *realTidbNGMonitoringControl) UpdateTidbNGMonitoring(tngm *v1alpha1.TidbNGMonitoring) (*v1alpha1.TidbNGMonitoring, error) {
+	ns := tngm.GetNamespace()
+	name := tngm.GetName()
+
+	status := tngm.Status.DeepCopy()
+	var updateTNGM *v1alpha1.TidbNGMonitoring
+
+	// don't wait due to limited number of clients, but backoff after the default number of steps
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var updateErr error
+		updateTNGM, updateErr = rtc.cli.PingcapV1alpha1().TidbNGMonitorings(ns).Update(tngm)
+		if updateErr == nil {
+			log.Infof("TidbNGMonitoring: [%s/%s] updated successfully", ns, name)
+			return nil
+		}
+		log.Errorf("failed to update TidbNGMonitoring: [%s/%s], error: %v", ns, name, updateErr)
+
+		if updated, err := rtc.tngmLister.TidbNGMonitorings(ns).Get(name); err == nil {
+			// make a copy so we don't mutate the shared cache
+			tngm = updated.DeepCopy()
+			tngm.Status = *status
+		} else {
+			utilruntime.HandleError(fmt.Errorf("error getting updated TidbNGMonitoring %s/%s from lister: %v", ns, name, err))
+		}
+
+		return updateErr
+	})
+	if err != nil {
+		rtc.recorder.Event(tngm, corev1.EventTypeWarning, "FailedUpdate",
+			fmt.Sprintf("update TidbNGMonitoring %s failed error: %s", name, err))
+	}
+	return updateTNGM, err
+}
+
+// FakeTidbNGMonitoringControl is a fake TidbNGMonitoringControlInterface
+type FakeTidbNGMonitoringControl struct {
+	TngmLister                    listers.TidbNGMonitoringLister
+	TngmIndexer                   cache.Indexer
+	updateTidbNGMonitoringTracker requestTracker
+}
+
+// NewFakeTidbNGMonitoringControl returns a FakeTidbNGMonitoringControl
+func NewFakeTidbNGMonitoringControl(tngmInformer tcinformers.TidbNGMonitoringInformer) *FakeTidbNGMonitoringControl {
+	return &FakeTidbNGMonitoringControl{
+		tngmInformer.Lister(),
+		tngmInformer.Informer().GetIndexer(),
+		requestTracker{0, nil, 0},
+	}
+}
+
+// SetUpdateTidbNGMonitoringError sets the error attributes of updateTidbNGMonitoringTracker
+func (ftc *FakeTidbNGMonitoringControl) SetUpdateTidbNGMonitoringError(err error, after int) {
+	ftc.updateTidbNGMonitoringTracker.err = err
+	ftc.updateTidbNGMonitoringTracker.after = after
+}
+
+// UpdateTidbNGMonitoring updates the TidbNGMonitoring
+func (ftc *FakeTidbNGMonitoringControl) UpdateTidbNGMonitoring(tngm *v1alpha1.TidbNGMonitoring) (*v1alpha1.TidbNGMonitoring, error) {
+	defer ftc.updateTidbNGMonitoringTracker.inc()
+	if ftc.updateTidbNGMonitoringTracker.errorReady() {
+		defer ftc.updateTidbNGMonitoringTracker.reset()
+		return tngm, ftc.updateTidbNGMonitoringTracker.err
+	}
+
+	return tngm, ftc.TngmIndexer.Update(tngm)
+}
+
+var _ TidbNGMonitoringControlInterface = &realTidbNGMonitoringControl{}
+var _ TidbNGMonitoringControlInterface = &FakeTidbNGMonitoringControl{}
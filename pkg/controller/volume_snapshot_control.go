@@ -0,0 +1,178 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tidb-operator/pkg/log"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/record"
+)
+
+var (
+	// VolumeSnapshotGVR is the resource of the VolumeSnapshots which the pvcs are provisioned from
+	VolumeSnapshotGVR = schema.GroupVersionResource{
+		Group:    "snapshot.storage.k8s.io",
+		Version:  "v1beta1",
+		Resource: "volumesnapshots",
+	}
+	// VolumeSnapshotContentGVR is the resource of the VolumeSnapshotContents bound to the VolumeSnapshots
+	VolumeSnapshotContentGVR = schema.GroupVersionResource{
+		Group:    "snapshot.storage.k8s.io",
+		Version:  "v1beta1",
+		Resource: "volumesnapshotcontents",
+	}
+)
+
+// VolumeSnapshotControlInterface manages the VolumeSnapshots used in restore
+type VolumeSnapshotControlInterface interface {
+	// CreateVolumeSnapshotFromHandle creates a VolumeSnapshot bound to a pre-provisioned
+	// VolumeSnapshotContent of the snapshot which is taken out of Kubernetes, e.g. an EBS snapshot
+	CreateVolumeSnapshotFromHandle(object runtime.Object, ns, name, driver, snapshotHandle string) error
+}
+
+type realVolumeSnapshotControl struct {
+	dynamicCli dynamic.Interface
+	recorder   record.EventRecorder
+}
+
+// NewRealVolumeSnapshotControl creates a new VolumeSnapshotControlInterface
+func NewRealVolumeSnapshotControl(
+	dynamicCli dynamic.Interface,
+	recorder record.EventRecorder,
+) VolumeSnapshotControlInterface {
+	return &realVolumeSnapshotControl{
+		dynamicCli: dynamicCli,
+		recorder:   recorder,
+	}
+}
+
+func (rvc *realVolumeSnapshotControl) CreateVolumeSnapshotFromHandle(object runtime.Object, ns, name, driver, snapshotHandle string) error {
+	kind := object.GetObjectKind().GroupVersionKind().Kind
+	err := rvc.createVolumeSnapshotFromHandle(ns, name, driver, snapshotHandle)
+	if err != nil {
+		log.Errorf("failed to create %s volume snapshot: [%s/%s] of %s, err: %v", strings.ToLower(kind), ns, name, snapshotHandle, err)
+	} else {
+		log.V(4).Infof("create %s volume snapshot: [%s/%s] of %s successfully", strings.ToLower(kind), ns, name, snapshotHandle)
+	}
+	rvc.recordVolumeSnapshotEvent("create", object, ns, name, err)
+	return err
+}
+
+func (rvc *realVolumeSnapshotControl) createVolumeSnapshotFromHandle(ns, name, driver, snapshotHandle string) error {
+	// the content is cluster scoped, so it is prefixed with the namespace of the VolumeSnapshot
+	contentName := fmt.Sprintf("%s-%s", ns, name)
+	content := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				// the snapshot is managed by the backup rather than the restore
+				"deletionPolicy": "Retain",
+				"driver":         driver,
+				"source": map[string]interface{}{
+					"snapshotHandle": snapshotHandle,
+				},
+				"volumeSnapshotRef": map[string]interface{}{
+					"namespace": ns,
+					"name":      name,
+				},
+			},
+		},
+	}
+	content.SetAPIVersion(VolumeSnapshotContentGVR.GroupVersion().String())
+	content.SetKind("VolumeSnapshotContent")
+	content.SetName(contentName)
+	_, err := rvc.dynamicCli.Resource(VolumeSnapshotContentGVR).Create(content, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	snapshot := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"source": map[string]interface{}{
+					"volumeSnapshotContentName": contentName,
+				},
+			},
+		},
+	}
+	snapshot.SetAPIVersion(VolumeSnapshotGVR.GroupVersion().String())
+	snapshot.SetKind("VolumeSnapshot")
+	snapshot.SetName(name)
+	snapshot.SetNamespace(ns)
+	_, err = rvc.dynamicCli.Resource(VolumeSnapshotGVR).Namespace(ns).Create(snapshot, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+func (rvc *realVolumeSnapshotControl) recordVolumeSnapshotEvent(verb string, obj runtime.Object, ns, name string, err error) {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if err == nil {
+		reason := fmt.Sprintf("Successful%s", strings.Title(verb))
+		msg := fmt.Sprintf("%s volume snapshot %s/%s for %s successful",
+			strings.ToLower(verb), ns, name, strings.ToLower(kind))
+		rvc.recorder.Event(obj, corev1.EventTypeNormal, reason, msg)
+	} else {
+		reason := fmt.Sprintf("Failed%s", strings.Title(verb))
+		msg := fmt.Sprintf("%s volume snapshot %s/%s for %s failed error: %s",
+			strings.ToLower(verb), ns, name, strings.ToLower(kind), err)
+		rvc.recorder.Event(obj, corev1.EventTypeWarning, reason, msg)
+	}
+}
+
+var _ VolumeSnapshotControlInterface = &realVolumeSnapshotControl{}
+
+// FakeVolumeSnapshotControl is a fake VolumeSnapshotControlInterface
+type FakeVolumeSnapshotControl struct {
+	// Snapshots are the snapshot handles of the created VolumeSnapshots keyed by namespace/name
+	Snapshots                   map[string]string
+	createVolumeSnapshotTracker requestTracker
+}
+
+// NewFakeVolumeSnapshotControl returns a FakeVolumeSnapshotControl
+func NewFakeVolumeSnapshotControl() *FakeVolumeSnapshotControl {
+	return &FakeVolumeSnapshotControl{
+		map[string]string{},
+		requestTracker{0, nil, 0},
+	}
+}
+
+// SetCreateVolumeSnapshotError sets the error attributes of createVolumeSnapshotTracker
+func (fvc *FakeVolumeSnapshotControl) SetCreateVolumeSnapshotError(err error, after int) {
+	fvc.createVolumeSnapshotTracker.err = err
+	fvc.createVolumeSnapshotTracker.after = after
+}
+
+// CreateVolumeSnapshotFromHandle records the snapshot handle of the VolumeSnapshot
+func (fvc *FakeVolumeSnapshotControl) CreateVolumeSnapshotFromHandle(_ runtime.Object, ns, name, _, snapshotHandle string) error {
+	defer fvc.createVolumeSnapshotTracker.inc()
+	if fvc.createVolumeSnapshotTracker.errorReady() {
+		defer fvc.createVolumeSnapshotTracker.reset()
+		return fvc.createVolumeSnapshotTracker.err
+	}
+
+	fvc.Snapshots[fmt.Sprintf("%s/%s", ns, name)] = snapshotHandle
+	return nil
+}
+
+var _ VolumeSnapshotControlInterface = &FakeVolumeSnapshotControl{}
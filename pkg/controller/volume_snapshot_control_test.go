@@ -0,0 +1,70 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestVolumeSnapshotControlCreateVolumeSnapshotFromHandleSuccess(t *testing.T) {
+	g := NewGomegaWithT(t)
+	recorder := record.NewFakeRecorder(10)
+	backup := newBackup()
+	ns := backup.GetNamespace()
+	fakeClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	control := NewRealVolumeSnapshotControl(fakeClient, recorder)
+	err := control.CreateVolumeSnapshotFromHandle(backup, ns, "demo-snapshot", "ebs.csi.aws.com", "snap-0123")
+	g.Expect(err).To(Succeed())
+
+	content, err := fakeClient.Resource(VolumeSnapshotContentGVR).Get(ns+"-demo-snapshot", metav1.GetOptions{})
+	g.Expect(err).To(Succeed())
+	handle, _, _ := unstructured.NestedString(content.Object, "spec", "source", "snapshotHandle")
+	g.Expect(handle).To(Equal("snap-0123"))
+	snapshot, err := fakeClient.Resource(VolumeSnapshotGVR).Namespace(ns).Get("demo-snapshot", metav1.GetOptions{})
+	g.Expect(err).To(Succeed())
+	contentName, _, _ := unstructured.NestedString(snapshot.Object, "spec", "source", "volumeSnapshotContentName")
+	g.Expect(contentName).To(Equal(content.GetName()))
+
+	events := collectEvents(recorder.Events)
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0]).To(ContainSubstring(corev1.EventTypeNormal))
+}
+
+func TestVolumeSnapshotControlCreateVolumeSnapshotFromHandleFailed(t *testing.T) {
+	g := NewGomegaWithT(t)
+	recorder := record.NewFakeRecorder(10)
+	backup := newBackup()
+	fakeClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	control := NewRealVolumeSnapshotControl(fakeClient, recorder)
+	fakeClient.PrependReactor("create", "volumesnapshotcontents", func(action core.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewInternalError(errors.New("API server down"))
+	})
+	err := control.CreateVolumeSnapshotFromHandle(backup, backup.GetNamespace(), "demo-snapshot", "ebs.csi.aws.com", "snap-0123")
+	g.Expect(err).To(HaveOccurred())
+
+	events := collectEvents(recorder.Events)
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0]).To(ContainSubstring(corev1.EventTypeWarning))
+}
@@ -18,10 +18,12 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	"github.com/pingcap/tidb-operator/pkg/metrics"
 	"github.com/pingcap/tidb-operator/pkg/pdapi"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -29,6 +31,7 @@ import (
 // TiDBDiscovery helps new PD member to discover all other members in cluster bootstrap phase.
 type TiDBDiscovery interface {
 	Discover(string) (string, error)
+	GetPDAddresses(string) (string, error)
 }
 
 type tidbDiscovery struct {
@@ -37,6 +40,9 @@ type tidbDiscovery struct {
 	clusters  map[string]*clusterInfo
 	tcGetFn   func(ns, tcName string) (*v1alpha1.TidbCluster, error)
 	pdControl pdapi.PDControlInterface
+	// stateless makes the decisions from the TidbCluster and PD only, so that
+	// the replicas of the discovery service return the same args for a member
+	stateless bool
 }
 
 type clusterInfo struct {
@@ -44,12 +50,16 @@ type clusterInfo struct {
 	peers           map[string]struct{}
 }
 
-// NewTiDBDiscovery returns a TiDBDiscovery
-func NewTiDBDiscovery(cli versioned.Interface) TiDBDiscovery {
+// NewTiDBDiscovery returns a TiDBDiscovery. By default, the cluster is bootstrapped by the
+// last member which asks for its args once all the members of the cluster have asked, which
+// relies on the peers kept in memory. If stateless is true, the first member bootstraps the
+// cluster and the others join it, so the discovery service can run with multiple replicas.
+func NewTiDBDiscovery(cli versioned.Interface, stateless bool) TiDBDiscovery {
 	td := &tidbDiscovery{
 		cli:       cli,
 		pdControl: pdapi.NewDefaultPDControl(),
 		clusters:  map[string]*clusterInfo{},
+		stateless: stateless,
 	}
 	td.tcGetFn = td.realTCGetFn
 	return td
@@ -62,9 +72,11 @@ func (td *tidbDiscovery) Discover(advertisePeerUrl string) (string, error) {
 	if advertisePeerUrl == "" {
 		return "", fmt.Errorf("advertisePeerUrl is empty")
 	}
-	glog.Infof("advertisePeerUrl is: %s", advertisePeerUrl)
-	strArr := strings.Split(advertisePeerUrl, ".")
-	if len(strArr) != 4 {
+	log.Infof("advertisePeerUrl is: %s", advertisePeerUrl)
+	// the advertisePeerUrl is <pod>.<peer-service>.<namespace>.svc[.<cluster-domain>]:2380,
+	// the cluster domain is present if the members are deployed across Kubernetes clusters
+	strArr := strings.Split(strings.Split(advertisePeerUrl, ":")[0], ".")
+	if len(strArr) < 4 || strArr[3] != "svc" {
 		return "", fmt.Errorf("advertisePeerUrl format is wrong: %s", advertisePeerUrl)
 	}
 
@@ -74,10 +86,19 @@ func (td *tidbDiscovery) Discover(advertisePeerUrl string) (string, error) {
 	if ns != podNamespace {
 		return "", fmt.Errorf("the peer's namespace: %s is not equal to discovery namespace: %s", ns, podNamespace)
 	}
+	args, err := td.discover(ns, tcName, podName, advertisePeerUrl)
+	metrics.ObserveDiscoveryResult(ns, tcName, args, err)
+	return args, err
+}
+
+func (td *tidbDiscovery) discover(ns, tcName, podName, advertisePeerUrl string) (string, error) {
 	tc, err := td.tcGetFn(ns, tcName)
 	if err != nil {
 		return "", err
 	}
+	if td.stateless {
+		return td.discoverStateless(tc, podName, advertisePeerUrl)
+	}
 	keyName := fmt.Sprintf("%s/%s", ns, tcName)
 	// TODO: the replicas should be the total replicas of pd sets.
 	replicas := tc.Spec.PD.Replicas
@@ -92,24 +113,98 @@ func (td *tidbDiscovery) Discover(advertisePeerUrl string) (string, error) {
 	currentCluster = td.clusters[keyName]
 	currentCluster.peers[podName] = struct{}{}
 
-	if len(currentCluster.peers) == int(replicas) {
+	// the members always join the referenced cluster, which may be in another Kubernetes cluster
+	if tc.Spec.Cluster == nil && len(currentCluster.peers) == int(replicas) {
 		delete(currentCluster.peers, podName)
 		return fmt.Sprintf("--initial-cluster=%s=%s://%s", podName, tc.Scheme(), advertisePeerUrl), nil
 	}
 
-	pdClient := td.pdControl.GetPDClient(pdapi.Namespace(tc.GetNamespace()), tc.GetName(), tc.Spec.EnableTLSCluster)
-	membersInfo, err := pdClient.GetMembers()
+	membersInfo, err := td.getMembers(tc)
 	if err != nil {
 		return "", err
 	}
 
+	delete(currentCluster.peers, podName)
+	return joinArgs(membersInfo), nil
+}
+
+// discoverStateless returns the same args for a member whichever replica of the discovery
+// service is asked: the first member bootstraps the cluster if the cluster is not bootstrapped
+// yet, i.e. the TidbCluster has no cluster ID and there are no pd members, the others join it,
+// they are retried by the start script until the first member is started
+func (td *tidbDiscovery) discoverStateless(tc *v1alpha1.TidbCluster, podName, advertisePeerUrl string) (string, error) {
+	membersInfo, err := td.getMembers(tc)
+	if err == nil && len(membersInfo.Members) > 0 {
+		return joinArgs(membersInfo), nil
+	}
+	if tc.Spec.Cluster == nil && tc.Status.ClusterID == "" && podName == fmt.Sprintf("%s-pd-0", tc.GetName()) {
+		return fmt.Sprintf("--initial-cluster=%s=%s://%s", podName, tc.Scheme(), advertisePeerUrl), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("there are no pd members of TidbCluster: %s/%s to join", tc.GetNamespace(), tc.GetName())
+}
+
+func joinArgs(membersInfo *pdapi.MembersInfo) string {
 	membersArr := make([]string, 0)
 	for _, member := range membersInfo.Members {
 		memberURL := strings.ReplaceAll(member.PeerUrls[0], ":2380", ":2379")
 		membersArr = append(membersArr, memberURL)
 	}
-	delete(currentCluster.peers, podName)
-	return fmt.Sprintf("--join=%s", strings.Join(membersArr, ",")), nil
+	return fmt.Sprintf("--join=%s", strings.Join(membersArr, ","))
+}
+
+// GetPDAddresses returns the client addresses of the PD members, which are
+// used by the TiKV and TiDB members deployed across Kubernetes clusters
+func (td *tidbDiscovery) GetPDAddresses(tcName string) (string, error) {
+	if tcName == "" {
+		return "", fmt.Errorf("tcName is empty")
+	}
+	ns := os.Getenv("MY_POD_NAMESPACE")
+	tc, err := td.tcGetFn(ns, tcName)
+	if err != nil {
+		return "", err
+	}
+
+	membersInfo, err := td.getMembers(tc)
+	if err != nil {
+		return "", err
+	}
+	addrs := make([]string, 0)
+	for _, member := range membersInfo.Members {
+		if len(member.ClientUrls) == 0 {
+			continue
+		}
+		addr := strings.TrimPrefix(member.ClientUrls[0], "http://")
+		addr = strings.TrimPrefix(addr, "https://")
+		addrs = append(addrs, addr)
+	}
+	if len(addrs) == 0 {
+		return "", fmt.Errorf("there are no pd members of TidbCluster: %s/%s", ns, tcName)
+	}
+	return strings.Join(addrs, ","), nil
+}
+
+// getMembers returns the pd members of the cluster, the latency is observed
+func (td *tidbDiscovery) getMembers(tc *v1alpha1.TidbCluster) (*pdapi.MembersInfo, error) {
+	start := time.Now()
+	membersInfo, err := td.getPDClient(tc).GetMembers()
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	metrics.DiscoveryPDRequestDuration.WithLabelValues(tc.GetNamespace(), tc.GetName(), result).Observe(time.Since(start).Seconds())
+	return membersInfo, err
+}
+
+// getPDClient returns the client of the referenced cluster if the TidbCluster joins
+// another one and has no pd members of its own, or the client of its own pd members
+func (td *tidbDiscovery) getPDClient(tc *v1alpha1.TidbCluster) pdapi.PDClient {
+	if ref := tc.Spec.Cluster; ref != nil && (tc.Spec.PD.Replicas == 0 || len(tc.Status.PD.Members) == 0) {
+		return td.pdControl.GetRemotePDClient(pdapi.Namespace(ref.Namespace), ref.Name, tc.Spec.EnableTLSCluster, ref.ClusterDomain)
+	}
+	return td.pdControl.GetPDClient(pdapi.Namespace(tc.GetNamespace()), tc.GetName(), tc.Spec.EnableTLSCluster)
 }
 
 func (td *tidbDiscovery) realTCGetFn(ns, tcName string) (*v1alpha1.TidbCluster, error) {
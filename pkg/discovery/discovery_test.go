@@ -400,6 +400,175 @@ func TestDiscoveryDiscovery(t *testing.T) {
 	}
 }
 
+func TestDiscoveryDiscoverAcrossK8s(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc, _ := newTC()
+	tc.Spec.ClusterDomain = "cluster2.local"
+	tc.Spec.Cluster = &v1alpha1.TidbClusterRef{Namespace: "default", Name: "demo-1", ClusterDomain: "cluster1.local"}
+
+	fakePDControl := pdapi.NewFakePDControl()
+	remotePDClient := pdapi.NewFakePDClient()
+	fakePDControl.SetRemotePDClient("default", "demo-1", "cluster1.local", remotePDClient)
+	remotePDClient.AddReaction(pdapi.GetMembersActionType, func(action *pdapi.Action) (interface{}, error) {
+		return &pdapi.MembersInfo{
+			Members: []*pdpb.Member{
+				{
+					PeerUrls: []string{"demo-1-pd-0.demo-1-pd-peer.default.svc.cluster1.local:2380"},
+				},
+			},
+		}, nil
+	})
+	td := &tidbDiscovery{
+		pdControl: fakePDControl,
+		tcGetFn: func(ns, tcName string) (*v1alpha1.TidbCluster, error) {
+			return tc, nil
+		},
+		clusters: map[string]*clusterInfo{
+			"default/demo": {
+				resourceVersion: "1",
+				peers: map[string]struct{}{
+					"demo-pd-0": {},
+					"demo-pd-1": {},
+				},
+			},
+		},
+	}
+	os.Setenv("MY_POD_NAMESPACE", "default")
+
+	// the members join the referenced cluster instead of bootstrapping a new one
+	s, err := td.Discover("demo-pd-2.demo-pd-peer.default.svc.cluster2.local:2380")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(s).To(Equal("--join=demo-1-pd-0.demo-1-pd-peer.default.svc.cluster1.local:2379"))
+	g.Expect(len(td.clusters["default/demo"].peers)).To(Equal(2))
+
+	_, err = td.Discover("demo-pd-2.demo-pd-peer.default.cluster2.local:2380")
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(strings.Contains(err.Error(), "advertisePeerUrl format is wrong: ")).To(BeTrue())
+
+	// the bootstrapping cluster accepts the urls with the cluster domain
+	tc.Spec.Cluster = nil
+	s, err = td.Discover("demo-pd-2.demo-pd-peer.default.svc.cluster2.local:2380")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(s).To(Equal("--initial-cluster=demo-pd-2=http://demo-pd-2.demo-pd-peer.default.svc.cluster2.local:2380"))
+}
+
+func TestDiscoveryDiscoverStateless(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc, _ := newTC()
+	fakePDControl := pdapi.NewFakePDControl()
+	pdClient := pdapi.NewFakePDClient()
+	fakePDControl.SetPDClient("default", "demo", pdClient)
+	var members *pdapi.MembersInfo
+	pdClient.AddReaction(pdapi.GetMembersActionType, func(action *pdapi.Action) (interface{}, error) {
+		if members == nil {
+			return nil, fmt.Errorf("there are no pd members")
+		}
+		return members, nil
+	})
+	newDiscovery := func() *tidbDiscovery {
+		return &tidbDiscovery{
+			pdControl: fakePDControl,
+			tcGetFn: func(ns, tcName string) (*v1alpha1.TidbCluster, error) {
+				return tc, nil
+			},
+			clusters:  map[string]*clusterInfo{},
+			stateless: true,
+		}
+	}
+	os.Setenv("MY_POD_NAMESPACE", "default")
+
+	// the first member bootstraps the cluster whichever replica is asked, and the others wait for it
+	for i := 0; i < 2; i++ {
+		s, err := newDiscovery().Discover("demo-pd-0.demo-pd-peer.default.svc:2380")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(s).To(Equal("--initial-cluster=demo-pd-0=http://demo-pd-0.demo-pd-peer.default.svc:2380"))
+		_, err = newDiscovery().Discover("demo-pd-1.demo-pd-peer.default.svc:2380")
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(strings.Contains(err.Error(), "there are no pd members")).To(BeTrue())
+	}
+
+	// the cluster is bootstrapped, the first member rejoins it after its data is lost
+	tc.Status.ClusterID = "6748238458456158986"
+	_, err := newDiscovery().Discover("demo-pd-0.demo-pd-peer.default.svc:2380")
+	g.Expect(err).To(HaveOccurred())
+
+	members = &pdapi.MembersInfo{
+		Members: []*pdpb.Member{
+			{PeerUrls: []string{"demo-pd-1.demo-pd-peer.default.svc:2380"}},
+			{PeerUrls: []string{"demo-pd-2.demo-pd-peer.default.svc:2380"}},
+		},
+	}
+	for _, url := range []string{"demo-pd-0.demo-pd-peer.default.svc:2380", "demo-pd-1.demo-pd-peer.default.svc:2380"} {
+		s, err := newDiscovery().Discover(url)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(s).To(Equal("--join=demo-pd-1.demo-pd-peer.default.svc:2379,demo-pd-2.demo-pd-peer.default.svc:2379"))
+	}
+
+	// the members are started before the cluster id is synced to the TidbCluster
+	tc.Status.ClusterID = ""
+	s, err := newDiscovery().Discover("demo-pd-0.demo-pd-peer.default.svc:2380")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(s).To(Equal("--join=demo-pd-1.demo-pd-peer.default.svc:2379,demo-pd-2.demo-pd-peer.default.svc:2379"))
+}
+
+func TestDiscoveryGetPDAddresses(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc, _ := newTC()
+	tc.Spec.AcrossK8s = true
+	tc.Spec.Cluster = &v1alpha1.TidbClusterRef{Namespace: "default", Name: "demo-1", ClusterDomain: "cluster1.local"}
+
+	fakePDControl := pdapi.NewFakePDControl()
+	pdClient := pdapi.NewFakePDClient()
+	fakePDControl.SetPDClient("default", "demo", pdClient)
+	remotePDClient := pdapi.NewFakePDClient()
+	fakePDControl.SetRemotePDClient("default", "demo-1", "cluster1.local", remotePDClient)
+	members := &pdapi.MembersInfo{
+		Members: []*pdpb.Member{
+			{ClientUrls: []string{"http://demo-1-pd-0.demo-1-pd-peer.default.svc.cluster1.local:2379"}},
+			{ClientUrls: []string{"http://demo-pd-0.demo-pd-peer.default.svc.cluster2.local:2379"}},
+		},
+	}
+	var remote bool
+	pdClient.AddReaction(pdapi.GetMembersActionType, func(action *pdapi.Action) (interface{}, error) {
+		remote = false
+		return members, nil
+	})
+	remotePDClient.AddReaction(pdapi.GetMembersActionType, func(action *pdapi.Action) (interface{}, error) {
+		remote = true
+		return members, nil
+	})
+	td := &tidbDiscovery{
+		pdControl: fakePDControl,
+		tcGetFn: func(ns, tcName string) (*v1alpha1.TidbCluster, error) {
+			return tc, nil
+		},
+		clusters: map[string]*clusterInfo{},
+	}
+	os.Setenv("MY_POD_NAMESPACE", "default")
+
+	_, err := td.GetPDAddresses("")
+	g.Expect(err).To(HaveOccurred())
+
+	// the local pd members are not started yet
+	s, err := td.GetPDAddresses("demo")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(remote).To(BeTrue())
+	g.Expect(s).To(Equal("demo-1-pd-0.demo-1-pd-peer.default.svc.cluster1.local:2379,demo-pd-0.demo-pd-peer.default.svc.cluster2.local:2379"))
+
+	tc.Status.PD.Members = map[string]v1alpha1.PDMember{"demo-pd-0": {Name: "demo-pd-0"}}
+	_, err = td.GetPDAddresses("demo")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(remote).To(BeFalse())
+
+	members.Members = nil
+	_, err = td.GetPDAddresses("demo")
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(strings.Contains(err.Error(), "there are no pd members")).To(BeTrue())
+}
+
 func newTC() (*v1alpha1.TidbCluster, error) {
 	return &v1alpha1.TidbCluster{
 		TypeMeta: metav1.TypeMeta{Kind: "TidbCluster", APIVersion: "v1alpha1"},
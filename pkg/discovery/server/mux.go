@@ -18,11 +18,13 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 
 	restful "github.com/emicklei/go-restful"
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
 	"github.com/pingcap/tidb-operator/pkg/discovery"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	"github.com/pingcap/tidb-operator/pkg/metrics"
 )
 
 type server struct {
@@ -30,24 +32,26 @@ type server struct {
 }
 
 // StartServer starts a TiDB Discovery server
-func StartServer(cli versioned.Interface, port int) {
-	svr := &server{discovery.NewTiDBDiscovery(cli)}
+func StartServer(cli versioned.Interface, port int, stateless bool) {
+	svr := &server{discovery.NewTiDBDiscovery(cli, stateless)}
 
 	ws := new(restful.WebService)
-	ws.Route(ws.GET("/new/{advertise-peer-url}").To(svr.newHandler))
+	ws.Route(ws.GET("/new/{advertise-peer-url}").To(instrument("new", svr.newHandler)))
+	ws.Route(ws.GET("/pdaddr/{tc-name}").To(instrument("pdaddr", svr.pdAddrHandler)))
+	ws.Route(ws.GET("/healthz").To(svr.healthzHandler))
 	restful.Add(ws)
 
-	glog.Infof("starting TiDB Discovery server, listening on 0.0.0.0:%d", port)
-	glog.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), nil))
+	log.Infof("starting TiDB Discovery server, listening on 0.0.0.0:%d", port)
+	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), nil))
 }
 
 func (svr *server) newHandler(req *restful.Request, resp *restful.Response) {
 	encodedAdvertisePeerURL := req.PathParameter("advertise-peer-url")
 	data, err := base64.StdEncoding.DecodeString(encodedAdvertisePeerURL)
 	if err != nil {
-		glog.Errorf("failed to decode advertise-peer-url: %s", encodedAdvertisePeerURL)
+		log.Errorf("failed to decode advertise-peer-url: %s", encodedAdvertisePeerURL)
 		if err := resp.WriteError(http.StatusInternalServerError, err); err != nil {
-			glog.Errorf("failed to writeError: %v", err)
+			log.Errorf("failed to writeError: %v", err)
 		}
 		return
 	}
@@ -55,15 +59,49 @@ func (svr *server) newHandler(req *restful.Request, resp *restful.Response) {
 
 	result, err := svr.discovery.Discover(advertisePeerURL)
 	if err != nil {
-		glog.Errorf("failed to discover: %s, %v", advertisePeerURL, err)
+		log.Errorf("failed to discover: %s, %v", advertisePeerURL, err)
 		if err := resp.WriteError(http.StatusInternalServerError, err); err != nil {
-			glog.Errorf("failed to writeError: %v", err)
+			log.Errorf("failed to writeError: %v", err)
 		}
 		return
 	}
 
-	glog.Infof("generated args for %s: %s", advertisePeerURL, result)
+	log.Infof("generated args for %s: %s", advertisePeerURL, result)
 	if _, err := io.WriteString(resp, result); err != nil {
-		glog.Errorf("failed to writeString: %s, %v", result, err)
+		log.Errorf("failed to writeString: %s, %v", result, err)
+	}
+}
+
+func (svr *server) pdAddrHandler(req *restful.Request, resp *restful.Response) {
+	tcName := req.PathParameter("tc-name")
+
+	result, err := svr.discovery.GetPDAddresses(tcName)
+	if err != nil {
+		log.Errorf("failed to get pd addresses of %s, %v", tcName, err)
+		if err := resp.WriteError(http.StatusInternalServerError, err); err != nil {
+			log.Errorf("failed to writeError: %v", err)
+		}
+		return
+	}
+
+	log.Infof("pd addresses of %s: %s", tcName, result)
+	if _, err := io.WriteString(resp, result); err != nil {
+		log.Errorf("failed to writeString: %s, %v", result, err)
+	}
+}
+
+// healthzHandler returns ok as long as the server is serving, the discovery doesn't
+// depend on the pd members, which are not started in the bootstrap phase
+func (svr *server) healthzHandler(req *restful.Request, resp *restful.Response) {
+	if _, err := io.WriteString(resp, "ok"); err != nil {
+		log.Errorf("failed to writeString: ok, %v", err)
+	}
+}
+
+// instrument counts the requests served by the handler by the status code
+func instrument(handler string, fn restful.RouteFunction) restful.RouteFunction {
+	return func(req *restful.Request, resp *restful.Response) {
+		fn(req, resp)
+		metrics.DiscoveryRequestCounter.WithLabelValues(handler, strconv.Itoa(resp.StatusCode())).Inc()
 	}
 }
@@ -0,0 +1,99 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grafana
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/httputil"
+)
+
+const (
+	annotationsPrefix = "api/annotations"
+	timeout           = 5 * time.Second
+)
+
+// Annotation is an annotation of the Grafana dashboards
+// https://grafana.com/docs/http_api/annotations/
+type Annotation struct {
+	// Time is the epoch in milliseconds
+	Time int64    `json:"time"`
+	Tags []string `json:"tags,omitempty"`
+	Text string   `json:"text"`
+}
+
+// Client posts the annotations to Grafana
+type Client interface {
+	Annotate(annotation *Annotation) error
+}
+
+type client struct {
+	url        string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewClient returns a Client of the Grafana at the url, the apiKey is sent as
+// the bearer token if it's not empty
+func NewClient(url string, apiKey string) Client {
+	return &client{
+		url:    strings.TrimSuffix(url, "/"),
+		apiKey: apiKey,
+		// the annotations are not worth blocking the syncs when Grafana is down
+		httpClient: &http.Client{Timeout: timeout, Transport: httputil.NewCircuitBreaker().Transport(nil)},
+	}
+}
+
+func (c *client) Annotate(annotation *Annotation) error {
+	data, err := json.Marshal(annotation)
+	if err != nil {
+		return err
+	}
+	apiURL := fmt.Sprintf("%s/%s", c.url, annotationsPrefix)
+	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer httputil.DeferClose(res.Body)
+	if res.StatusCode >= 400 {
+		return fmt.Errorf("failed to post annotation to %s, status code %d, %v", apiURL, res.StatusCode, httputil.ReadErrorBody(res.Body))
+	}
+	return nil
+}
+
+// FakeClient records the annotations posted
+type FakeClient struct {
+	Annotations []*Annotation
+}
+
+// Annotate records the annotation
+func (fc *FakeClient) Annotate(annotation *Annotation) error {
+	fc.Annotations = append(fc.Annotations, annotation)
+	return nil
+}
+
+var _ Client = &FakeClient{}
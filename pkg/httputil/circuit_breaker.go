@@ -0,0 +1,145 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httputil
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/log"
+)
+
+const (
+	// defaultFailureThreshold is the number of the consecutive failures of an endpoint to open the circuit
+	defaultFailureThreshold = 3
+	// defaultInitialBackoff is how long the circuit is open after it's opened for the first time
+	defaultInitialBackoff = 5 * time.Second
+	// defaultMaxBackoff is the maximum duration the circuit is open
+	defaultMaxBackoff = 2 * time.Minute
+)
+
+// CircuitBreaker tracks the consecutive failures of the endpoints and fails
+// the requests to an endpoint fast while it's considered to be down
+//
+// After the threshold of consecutive failures is reached, the circuit of the
+// endpoint is open and the requests fail immediately. When the backoff is over,
+// a single request is let through to probe the endpoint: the circuit is closed
+// if it succeeds, otherwise it's open again with the backoff doubled. So a dead
+// endpoint only costs a request timeout once per backoff rather than on every
+// request.
+type CircuitBreaker struct {
+	mutex          sync.Mutex
+	endpoints      map[string]*endpointState
+	threshold      int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	now            func() time.Time
+}
+
+type endpointState struct {
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// NewCircuitBreaker returns a CircuitBreaker with the default threshold and backoff
+func NewCircuitBreaker() *CircuitBreaker {
+	return &CircuitBreaker{
+		endpoints:      map[string]*endpointState{},
+		threshold:      defaultFailureThreshold,
+		initialBackoff: defaultInitialBackoff,
+		maxBackoff:     defaultMaxBackoff,
+		now:            time.Now,
+	}
+}
+
+// Transport wraps the transport with the circuit breaker, http.DefaultTransport is used if it's nil
+func (cb *CircuitBreaker) Transport(transport http.RoundTripper) http.RoundTripper {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &circuitBreakerTransport{cb, transport}
+}
+
+// allow returns an error if the circuit of the endpoint is open
+func (cb *CircuitBreaker) allow(endpoint string) error {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	state, ok := cb.endpoints[endpoint]
+	if !ok || state.failures < cb.threshold {
+		return nil
+	}
+	if state.probing || cb.now().Before(state.openUntil) {
+		return fmt.Errorf("circuit breaker is open for %s after %d consecutive failures, retry after %s",
+			endpoint, state.failures, state.openUntil.Format(time.RFC3339))
+	}
+	state.probing = true
+	return nil
+}
+
+// done records the result of a request to the endpoint
+func (cb *CircuitBreaker) done(endpoint string, err error) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	if err == nil {
+		if state, ok := cb.endpoints[endpoint]; ok && state.failures >= cb.threshold {
+			log.Infof("circuit breaker is closed for %s", endpoint)
+		}
+		delete(cb.endpoints, endpoint)
+		return
+	}
+
+	state, ok := cb.endpoints[endpoint]
+	if !ok {
+		state = &endpointState{}
+		cb.endpoints[endpoint] = state
+	}
+	state.failures++
+	state.probing = false
+	if state.failures < cb.threshold {
+		return
+	}
+	backoff := cb.initialBackoff
+	for i := cb.threshold; i < state.failures && backoff < cb.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > cb.maxBackoff {
+		backoff = cb.maxBackoff
+	}
+	state.openUntil = cb.now().Add(backoff)
+	log.Warningf("circuit breaker is open for %s for %s after %d consecutive failures, last error: %v",
+		endpoint, backoff, state.failures, err)
+}
+
+type circuitBreakerTransport struct {
+	breaker   *CircuitBreaker
+	transport http.RoundTripper
+}
+
+// RoundTrip fails fast if the circuit of the request's host is open, only the
+// transport errors, e.g. connection refused or timeout, are counted as the
+// failures, the error responses mean the endpoint is alive
+func (t *circuitBreakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := req.URL.Host
+	if err := t.breaker.allow(endpoint); err != nil {
+		return nil, err
+	}
+	res, err := t.transport.RoundTrip(req)
+	t.breaker.done(endpoint, err)
+	return res, err
+}
@@ -0,0 +1,84 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httputil
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+type fakeRoundTripper struct {
+	requests int
+	err      error
+}
+
+func (f *fakeRoundTripper) RoundTrip(_ *http.Request) (*http.Response, error) {
+	f.requests++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+func TestCircuitBreakerTransport(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	now := time.Now()
+	cb := NewCircuitBreaker()
+	cb.now = func() time.Time { return now }
+	rt := &fakeRoundTripper{err: fmt.Errorf("connection refused")}
+	transport := cb.Transport(rt)
+	pdReq, _ := http.NewRequest("GET", "http://demo-pd.default:2379/pd/health", nil)
+	otherReq, _ := http.NewRequest("GET", "http://other-pd.default:2379/pd/health", nil)
+
+	// the circuit is open after the consecutive failures
+	for i := 0; i < defaultFailureThreshold; i++ {
+		_, err := transport.RoundTrip(pdReq)
+		g.Expect(err).To(HaveOccurred())
+	}
+	g.Expect(rt.requests).To(Equal(defaultFailureThreshold))
+	_, err := transport.RoundTrip(pdReq)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(rt.requests).To(Equal(defaultFailureThreshold))
+
+	// the other endpoints are not affected
+	rt.err = nil
+	_, err = transport.RoundTrip(otherReq)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(rt.requests).To(Equal(defaultFailureThreshold + 1))
+
+	// a failed probe after the backoff opens the circuit with the backoff doubled
+	rt.err = fmt.Errorf("timeout")
+	now = now.Add(defaultInitialBackoff)
+	_, err = transport.RoundTrip(pdReq)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(rt.requests).To(Equal(defaultFailureThreshold + 2))
+	now = now.Add(defaultInitialBackoff)
+	_, err = transport.RoundTrip(pdReq)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(rt.requests).To(Equal(defaultFailureThreshold + 2))
+
+	// a successful probe closes the circuit
+	rt.err = nil
+	now = now.Add(defaultInitialBackoff)
+	_, err = transport.RoundTrip(pdReq)
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = transport.RoundTrip(pdReq)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(rt.requests).To(Equal(defaultFailureThreshold + 4))
+}
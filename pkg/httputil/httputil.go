@@ -8,7 +8,7 @@ import (
 	"io/ioutil"
 	"net/http"
 
-	"github.com/golang/glog"
+	"github.com/pingcap/tidb-operator/pkg/log"
 )
 
 const (
@@ -21,7 +21,7 @@ const (
 // This is designed to be used in a defer statement.
 func DeferClose(c io.Closer) {
 	if err := c.Close(); err != nil {
-		glog.Error(err)
+		log.Error(err)
 	}
 }
 
@@ -69,7 +69,7 @@ func ReadCACerts() (*x509.CertPool, error) {
 		return nil, fmt.Errorf("fail to read CA file %s, error: %v", k8sCAFile, err)
 	}
 	if ok := rootCAs.AppendCertsFromPEM(caCert); !ok {
-		glog.Warningf("fail to append CA file to pool, using system CAs only")
+		log.Warningf("fail to append CA file to pool, using system CAs only")
 	}
 	return rootCAs, nil
 }
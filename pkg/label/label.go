@@ -57,6 +57,16 @@ const (
 
 	// BackupProtectionFinalizer is the name of finalizer on backups
 	BackupProtectionFinalizer string = "tidb.pingcap.com/backup-protection"
+	// TidbClusterCleanupFinalizer is the name of finalizer on tidbclusters, it's removed
+	// after the resources which are not garbage collected are cleaned up
+	TidbClusterCleanupFinalizer string = "tidb.pingcap.com/cluster-cleanup"
+
+	// AnnLogBackupCommand is job annotation key of the log backup command executed by the backup job
+	AnnLogBackupCommand = "tidb.pingcap.com/log-backup-command"
+	// AnnLogBackupTruncateUntil is job annotation key of the ts before which the log data is truncated by the backup job
+	AnnLogBackupTruncateUntil = "tidb.pingcap.com/log-backup-truncate-until"
+	// AnnBackupCommitTs is VolumeSnapshot annotation key of the commitTs of the backup which creates it
+	AnnBackupCommitTs = "tidb.pingcap.com/backup-commit-ts"
 
 	// AnnFailTiDBScheduler is for injecting a failure into the TiDB custom scheduler
 	// A pod with this annotation will produce an error when scheduled.
@@ -76,6 +86,33 @@ const (
 
 	// AnnForceUpgradeVal is tc annotation value to indicate whether force upgrade should be done
 	AnnForceUpgradeVal = "true"
+	// AnnAdoptKey is tc annotation key to indicate whether the existing statefulsets and services
+	// of the cluster, which are not created by tidb-operator, should be adopted
+	AnnAdoptKey = "tidb.pingcap.com/adopt"
+	// AnnAdoptVal is tc annotation value to indicate whether the existing resources should be adopted
+	AnnAdoptVal = "true"
+	// AnnPDMaxPodsPerNode is tc annotation key to override the max number of pd pods on a node
+	// allowed by the HA predicate of tidb-scheduler
+	AnnPDMaxPodsPerNode = "tidb.pingcap.com/pd-max-pods-per-node"
+	// AnnTiKVMaxPodsPerNode is tc annotation key to override the max number of tikv pods on a node
+	// allowed by the HA predicate of tidb-scheduler
+	AnnTiKVMaxPodsPerNode = "tidb.pingcap.com/tikv-max-pods-per-node"
+	// AnnPDMaxPodsPerZone is tc annotation key of the max number of pd pods in a zone allowed by
+	// the HA predicate of tidb-scheduler, the zone of a node is its failure-domain zone label
+	AnnPDMaxPodsPerZone = "tidb.pingcap.com/pd-max-pods-per-zone"
+	// AnnTiKVMaxPodsPerZone is tc annotation key of the max number of tikv pods in a zone allowed by
+	// the HA predicate of tidb-scheduler, the zone of a node is its failure-domain zone label
+	AnnTiKVMaxPodsPerZone = "tidb.pingcap.com/tikv-max-pods-per-zone"
+	// AnnHAModeKey is tc annotation key of the mode of the HA predicate of tidb-scheduler
+	AnnHAModeKey = "tidb.pingcap.com/ha-mode"
+	// AnnHAModePreferred is tc annotation value to make the HA constraints advisory, the pods are
+	// scheduled to the least loaded nodes with a warning event if no node satisfies the constraints
+	AnnHAModePreferred = "preferred"
+	// AnnNodeFailureConfirmed is node annotation key set by the fault-trigger or the cluster admin
+	// to confirm that a NotReady node is gone and will not come back
+	AnnNodeFailureConfirmed = "tidb.pingcap.com/node-failure-confirmed"
+	// AnnExternalDNSHostname is service annotation key of the hostname published by external-dns
+	AnnExternalDNSHostname = "external-dns.alpha.kubernetes.io/hostname"
 
 	// PDLabelVal is PD label value
 	PDLabelVal string = "pd"
@@ -83,6 +120,16 @@ const (
 	TiDBLabelVal string = "tidb"
 	// TiKVLabelVal is TiKV label value
 	TiKVLabelVal string = "tikv"
+	// TiProxyLabelVal is TiProxy label value
+	TiProxyLabelVal string = "tiproxy"
+	// PDTSOLabelVal is PD TSO microservice label value
+	PDTSOLabelVal string = "tso"
+	// PDSchedulingLabelVal is PD scheduling microservice label value
+	PDSchedulingLabelVal string = "scheduling"
+	// DashboardLabelVal is the standalone dashboard label value
+	DashboardLabelVal string = "dashboard"
+	// NGMonitoringLabelVal is ng-monitoring label value
+	NGMonitoringLabelVal string = "ng-monitoring"
 
 	// CleanJobLabelVal is clean job label value
 	CleanJobLabelVal string = "clean"
@@ -90,6 +137,8 @@ const (
 	RestoreJobLabelVal string = "restore"
 	// BackupJobLabelVal is backup job label value
 	BackupJobLabelVal string = "backup"
+	// ReplicateJobLabelVal is replicate job label value
+	ReplicateJobLabelVal string = "replicate"
 )
 
 // Label is the label field in metadata
@@ -168,6 +217,12 @@ func (l Label) RestoreJob() Label {
 	return l
 }
 
+// ReplicateJob assigns replicate to component key in label
+func (l Label) ReplicateJob() Label {
+	l.Component(ReplicateJobLabelVal)
+	return l
+}
+
 // Backup assigns specific value to backup key in label
 func (l Label) Backup(val string) Label {
 	l[BackupLabelKey] = val
@@ -209,6 +264,36 @@ func (l Label) TiKV() Label {
 	return l
 }
 
+// TiProxy assigns tiproxy to component key in label
+func (l Label) TiProxy() Label {
+	l.Component(TiProxyLabelVal)
+	return l
+}
+
+// PDTSO assigns tso to component key in label
+func (l Label) PDTSO() Label {
+	l.Component(PDTSOLabelVal)
+	return l
+}
+
+// PDScheduling assigns scheduling to component key in label
+func (l Label) PDScheduling() Label {
+	l.Component(PDSchedulingLabelVal)
+	return l
+}
+
+// Dashboard assigns dashboard to component key in label
+func (l Label) Dashboard() Label {
+	l.Component(DashboardLabelVal)
+	return l
+}
+
+// NGMonitoring assigns ng-monitoring to component key in label
+func (l Label) NGMonitoring() Label {
+	l.Component(NGMonitoringLabelVal)
+	return l
+}
+
 // IsTiKV returns whether label is a TiKV
 func (l Label) IsTiKV() bool {
 	return l[ComponentLabelKey] == TiKVLabelVal
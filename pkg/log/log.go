@@ -0,0 +1,197 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package log is the structured logger of tidb-operator, it keeps the glog
+// style functions, e.g. Infof and V(4).Infof, and adds the key/value context,
+// so that the logs of a TidbCluster can be filtered by the log pipelines,
+// especially with the json format.
+package log
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// FormatText is the human readable log format
+	FormatText = "text"
+	// FormatJSON is the log format of one json object per line
+	FormatJSON = "json"
+)
+
+var (
+	format    = FormatText
+	verbosity int32
+	std       = newLogger(FormatText, os.Stderr)
+)
+
+// AddFlags adds the flags of the logger to the flag set, the -v flag is shared
+// with glog if it's already registered
+func AddFlags(fs *flag.FlagSet) {
+	fs.StringVar(&format, "log-format", FormatText, "The log format, text or json")
+	if fs.Lookup("v") == nil {
+		fs.Var(verbosityValue{}, "v", "The log level for V logs")
+	}
+}
+
+// Init sets up the logger by the flags, it should be called after the flags are parsed
+func Init(fs *flag.FlagSet) error {
+	if format != FormatText && format != FormatJSON {
+		return fmt.Errorf("unknown log format %q, must be %s or %s", format, FormatText, FormatJSON)
+	}
+	if f := fs.Lookup("v"); f != nil {
+		if err := (verbosityValue{}).Set(f.Value.String()); err != nil {
+			return err
+		}
+	}
+	std = newLogger(format, os.Stderr)
+	return nil
+}
+
+// Flush flushes the buffered logs
+func Flush() {
+	// the error of syncing stderr is ignored, e.g. it's not supported on some terminals
+	_ = std.s.Sync()
+}
+
+func newLogger(format string, w zapcore.WriteSyncer) *Logger {
+	config := zap.NewProductionEncoderConfig()
+	config.EncodeTime = zapcore.ISO8601TimeEncoder
+	var encoder zapcore.Encoder
+	if format == FormatJSON {
+		encoder = zapcore.NewJSONEncoder(config)
+	} else {
+		config.EncodeLevel = zapcore.CapitalLevelEncoder
+		encoder = zapcore.NewConsoleEncoder(config)
+	}
+	core := zapcore.NewCore(encoder, zapcore.Lock(w), zapcore.DebugLevel)
+	return &Logger{zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1)).Sugar()}
+}
+
+type verbosityValue struct{}
+
+func (verbosityValue) String() string {
+	return strconv.Itoa(int(verbosity))
+}
+
+func (verbosityValue) Set(s string) error {
+	v, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid log level %q: %v", s, err)
+	}
+	verbosity = int32(v)
+	return nil
+}
+
+// Logger writes the logs with its key/value context
+type Logger struct {
+	s *zap.SugaredLogger
+}
+
+// WithValues returns a Logger with the key/value pairs added to the context
+func WithValues(keysAndValues ...interface{}) *Logger {
+	return std.WithValues(keysAndValues...)
+}
+
+// ForCluster returns a Logger with the namespace and name of the TidbCluster in the context
+func ForCluster(ns, tcName string) *Logger {
+	return std.WithValues("namespace", ns, "cluster", tcName)
+}
+
+// WithValues returns a Logger with the key/value pairs added to the context
+func (l *Logger) WithValues(keysAndValues ...interface{}) *Logger {
+	return &Logger{l.s.With(keysAndValues...)}
+}
+
+// WithComponent returns a Logger with the component, e.g. pd or tikv, in the context
+func (l *Logger) WithComponent(component string) *Logger {
+	return l.WithValues("component", component)
+}
+
+// V returns a Verbose which only writes the logs if the level is enabled by -v
+func (l *Logger) V(level int32) Verbose {
+	return Verbose{level <= verbosity, l.s}
+}
+
+// Info logs at the info level
+func (l *Logger) Info(args ...interface{}) { l.s.Info(args...) }
+
+// Infof logs at the info level
+func (l *Logger) Infof(format string, args ...interface{}) { l.s.Infof(format, args...) }
+
+// Warning logs at the warning level
+func (l *Logger) Warning(args ...interface{}) { l.s.Warn(args...) }
+
+// Warningf logs at the warning level
+func (l *Logger) Warningf(format string, args ...interface{}) { l.s.Warnf(format, args...) }
+
+// Error logs at the error level
+func (l *Logger) Error(args ...interface{}) { l.s.Error(args...) }
+
+// Errorf logs at the error level
+func (l *Logger) Errorf(format string, args ...interface{}) { l.s.Errorf(format, args...) }
+
+// Verbose writes the info logs only if it's enabled
+type Verbose struct {
+	enabled bool
+	s       *zap.SugaredLogger
+}
+
+// Enabled returns whether the level of the Verbose is enabled
+func (v Verbose) Enabled() bool { return v.enabled }
+
+// Info logs at the info level if it's enabled
+func (v Verbose) Info(args ...interface{}) {
+	if v.enabled {
+		v.s.Info(args...)
+	}
+}
+
+// Infof logs at the info level if it's enabled
+func (v Verbose) Infof(format string, args ...interface{}) {
+	if v.enabled {
+		v.s.Infof(format, args...)
+	}
+}
+
+// V returns a Verbose which only writes the logs if the level is enabled by -v
+func V(level int32) Verbose { return Verbose{level <= verbosity, std.s} }
+
+// Info logs at the info level
+func Info(args ...interface{}) { std.s.Info(args...) }
+
+// Infof logs at the info level
+func Infof(format string, args ...interface{}) { std.s.Infof(format, args...) }
+
+// Warning logs at the warning level
+func Warning(args ...interface{}) { std.s.Warn(args...) }
+
+// Warningf logs at the warning level
+func Warningf(format string, args ...interface{}) { std.s.Warnf(format, args...) }
+
+// Error logs at the error level
+func Error(args ...interface{}) { std.s.Error(args...) }
+
+// Errorf logs at the error level
+func Errorf(format string, args ...interface{}) { std.s.Errorf(format, args...) }
+
+// Fatal logs at the fatal level and exits
+func Fatal(args ...interface{}) { std.s.Fatal(args...) }
+
+// Fatalf logs at the fatal level and exits
+func Fatalf(format string, args ...interface{}) { std.s.Fatalf(format, args...) }
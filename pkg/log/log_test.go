@@ -0,0 +1,65 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLoggerJSONFormat(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	buf := &bytes.Buffer{}
+	logger := newLogger(FormatJSON, zapcore.AddSync(buf)).WithValues("namespace", "default", "cluster", "demo").WithComponent("pd")
+	logger.Infof("PD replication config %v is updated", 3)
+
+	entry := map[string]interface{}{}
+	g.Expect(json.Unmarshal(buf.Bytes(), &entry)).To(Succeed())
+	g.Expect(entry["level"]).To(Equal("info"))
+	g.Expect(entry["msg"]).To(Equal("PD replication config 3 is updated"))
+	g.Expect(entry["namespace"]).To(Equal("default"))
+	g.Expect(entry["cluster"]).To(Equal("demo"))
+	g.Expect(entry["component"]).To(Equal("pd"))
+}
+
+func TestLoggerVerbosity(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	AddFlags(fs)
+	g.Expect(fs.Parse([]string{"-v=2", "-log-format=json"})).To(Succeed())
+	g.Expect(Init(fs)).To(Succeed())
+	defer func() {
+		verbosity, format = 0, FormatText
+		std = newLogger(FormatText, os.Stderr)
+	}()
+
+	buf := &bytes.Buffer{}
+	logger := newLogger(FormatText, zapcore.AddSync(buf))
+	g.Expect(logger.V(2).Enabled()).To(BeTrue())
+	logger.V(4).Infof("not logged")
+	g.Expect(buf.Len()).To(Equal(0))
+	logger.V(2).Infof("logged")
+	g.Expect(buf.String()).To(ContainSubstring("logged"))
+
+	g.Expect(fs.Parse([]string{"-log-format=xml"})).To(Succeed())
+	g.Expect(Init(fs)).NotTo(Succeed())
+}
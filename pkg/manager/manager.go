@@ -20,3 +20,9 @@ type Manager interface {
 	// Sync	implements the logic for syncing tidbcluster.
 	Sync(*v1alpha1.TidbCluster) error
 }
+
+// NGMonitoringManager implements the logic for syncing tidbngmonitoring.
+type NGMonitoringManager interface {
+	// Sync	implements the logic for syncing tidbngmonitoring.
+	Sync(*v1alpha1.TidbNGMonitoring) error
+}
@@ -0,0 +1,380 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/manager"
+	"github.com/pingcap/tidb-operator/pkg/util"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	extv1beta1 "k8s.io/api/extensions/v1beta1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	extlisters "k8s.io/client-go/listers/extensions/v1beta1"
+)
+
+const (
+	dashboardPort              = 12333
+	dashboardDataDir           = "/var/lib/dashboard"
+	dashboardClusterTLSDir     = "/var/lib/dashboard-tls"
+	defaultDashboardPathPrefix = "/dashboard"
+)
+
+type dashboardMemberManager struct {
+	setControl     controller.StatefulSetControlInterface
+	svcControl     controller.ServiceControlInterface
+	ingressControl controller.IngressControlInterface
+	setLister      appslisters.StatefulSetLister
+	svcLister      corelisters.ServiceLister
+	ingressLister  extlisters.IngressLister
+}
+
+// NewDashboardMemberManager returns a *dashboardMemberManager
+func NewDashboardMemberManager(setControl controller.StatefulSetControlInterface,
+	svcControl controller.ServiceControlInterface,
+	ingressControl controller.IngressControlInterface,
+	setLister appslisters.StatefulSetLister,
+	svcLister corelisters.ServiceLister,
+	ingressLister extlisters.IngressLister) manager.Manager {
+	return &dashboardMemberManager{
+		setControl:     setControl,
+		svcControl:     svcControl,
+		ingressControl: ingressControl,
+		setLister:      setLister,
+		svcLister:      svcLister,
+		ingressLister:  ingressLister,
+	}
+}
+
+func (dmm *dashboardMemberManager) Sync(tc *v1alpha1.TidbCluster) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	if !tc.DashboardEnabled() {
+		return nil
+	}
+
+	if !tc.PDIsAvailable() {
+		return controller.RequeueErrorf("TidbCluster: [%s/%s], waiting for PD cluster running", ns, tcName)
+	}
+
+	if err := dmm.syncDashboardService(tc); err != nil {
+		return err
+	}
+	if err := dmm.syncDashboardIngress(tc); err != nil {
+		return err
+	}
+	return dmm.syncDashboardStatefulSet(tc)
+}
+
+func (dmm *dashboardMemberManager) syncDashboardService(tc *v1alpha1.TidbCluster) error {
+	newSvc := getNewDashboardService(tc)
+	oldSvcTmp, err := dmm.svcLister.Services(newSvc.Namespace).Get(newSvc.Name)
+	if errors.IsNotFound(err) {
+		err = SetServiceLastAppliedConfigAnnotation(newSvc)
+		if err != nil {
+			return err
+		}
+		return dmm.svcControl.CreateService(tc, newSvc)
+	}
+	if err != nil {
+		return err
+	}
+
+	oldSvc := oldSvcTmp.DeepCopy()
+	return updateService(dmm.svcControl, tc, newSvc, oldSvc)
+}
+
+func (dmm *dashboardMemberManager) syncDashboardIngress(tc *v1alpha1.TidbCluster) error {
+	ns := tc.GetNamespace()
+	name := controller.DashboardMemberName(tc.GetName())
+
+	oldIngress, err := dmm.ingressLister.Ingresses(ns).Get(name)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	exist := err == nil
+
+	if tc.Spec.Dashboard.Ingress == nil {
+		if exist {
+			// the ingress is removed from the spec
+			return dmm.ingressControl.DeleteIngress(tc, oldIngress)
+		}
+		return nil
+	}
+
+	newIngress := getNewDashboardIngress(tc)
+	if !exist {
+		return dmm.ingressControl.CreateIngress(tc, newIngress)
+	}
+	if apiequality.Semantic.DeepEqual(newIngress.Spec, oldIngress.Spec) &&
+		apiequality.Semantic.DeepEqual(newIngress.Annotations, oldIngress.Annotations) {
+		return nil
+	}
+	ingress := oldIngress.DeepCopy()
+	ingress.Annotations = newIngress.Annotations
+	ingress.Spec = newIngress.Spec
+	_, err = dmm.ingressControl.UpdateIngress(tc, ingress)
+	return err
+}
+
+func (dmm *dashboardMemberManager) syncDashboardStatefulSet(tc *v1alpha1.TidbCluster) error {
+	ns := tc.GetNamespace()
+
+	newSet := getNewDashboardStatefulSet(tc)
+	oldSetTmp, err := dmm.setLister.StatefulSets(ns).Get(newSet.Name)
+	if errors.IsNotFound(err) {
+		err = SetLastAppliedConfigAnnotation(newSet)
+		if err != nil {
+			return err
+		}
+		err = dmm.setControl.CreateStatefulSet(tc, newSet)
+		if err != nil {
+			return err
+		}
+		tc.Status.Dashboard.StatefulSet = &apps.StatefulSetStatus{}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	oldSet := oldSetTmp.DeepCopy()
+	tc.Status.Dashboard.StatefulSet = &oldSet.Status
+
+	return updateStatefulSet(dmm.setControl, tc, newSet, oldSet)
+}
+
+func dashboardLabel(tc *v1alpha1.TidbCluster) label.Label {
+	instanceName := tc.GetLabels()[label.InstanceLabelKey]
+	return label.New().Instance(instanceName).Dashboard()
+}
+
+func dashboardPathPrefix(tc *v1alpha1.TidbCluster) string {
+	if len(tc.Spec.Dashboard.PathPrefix) > 0 {
+		return tc.Spec.Dashboard.PathPrefix
+	}
+	return defaultDashboardPathPrefix
+}
+
+func getNewDashboardService(tc *v1alpha1.TidbCluster) *corev1.Service {
+	dbLabel := dashboardLabel(tc).Labels()
+	svcType := tc.Spec.Dashboard.ServiceType
+	if svcType == "" {
+		svcType = corev1.ServiceTypeClusterIP
+	}
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            controller.DashboardMemberName(tc.Name),
+			Namespace:       tc.Namespace,
+			Labels:          dbLabel,
+			OwnerReferences: []metav1.OwnerReference{controller.GetOwnerRef(tc)},
+		},
+		Spec: corev1.ServiceSpec{
+			Type: svcType,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "dashboard",
+					Port:       dashboardPort,
+					TargetPort: intstr.FromInt(dashboardPort),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+			Selector: dbLabel,
+		},
+	}
+}
+
+func getNewDashboardIngress(tc *v1alpha1.TidbCluster) *extv1beta1.Ingress {
+	spec := tc.Spec.Dashboard.Ingress
+	backend := extv1beta1.IngressBackend{
+		ServiceName: controller.DashboardMemberName(tc.Name),
+		ServicePort: intstr.FromInt(dashboardPort),
+	}
+
+	var rules []extv1beta1.IngressRule
+	for _, host := range spec.Hosts {
+		rules = append(rules, extv1beta1.IngressRule{
+			Host: host,
+			IngressRuleValue: extv1beta1.IngressRuleValue{
+				HTTP: &extv1beta1.HTTPIngressRuleValue{
+					Paths: []extv1beta1.HTTPIngressPath{
+						{Path: dashboardPathPrefix(tc), Backend: backend},
+					},
+				},
+			},
+		})
+	}
+
+	return &extv1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            controller.DashboardMemberName(tc.Name),
+			Namespace:       tc.Namespace,
+			Labels:          dashboardLabel(tc).Labels(),
+			Annotations:     spec.Annotations,
+			OwnerReferences: []metav1.OwnerReference{controller.GetOwnerRef(tc)},
+		},
+		Spec: extv1beta1.IngressSpec{
+			TLS:   spec.TLS,
+			Rules: rules,
+		},
+	}
+}
+
+func getNewDashboardStatefulSet(tc *v1alpha1.TidbCluster) *apps.StatefulSet {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	name := controller.DashboardMemberName(tcName)
+	spec := tc.Spec.Dashboard
+
+	scheme := "http"
+	if tc.Spec.EnableTLSCluster {
+		scheme = "https"
+	}
+	args := []string{
+		"--host=0.0.0.0",
+		fmt.Sprintf("--port=%d", dashboardPort),
+		fmt.Sprintf("--pd=%s://%s:2379", scheme, controller.PDMemberName(tcName)),
+		fmt.Sprintf("--data-dir=%s", dashboardDataDir),
+		fmt.Sprintf("--path-prefix=%s", dashboardPathPrefix(tc)),
+	}
+
+	volMounts := []corev1.VolumeMount{
+		{Name: "data", MountPath: dashboardDataDir},
+	}
+	vols := []corev1.Volume{
+		// the dashboard keeps no state that has to survive restarts
+		{Name: "data", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+	}
+	caPath := "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+	if tc.Spec.EnableTLSCluster {
+		args = append(args,
+			fmt.Sprintf("--cluster-ca=%s", caPath),
+			fmt.Sprintf("--cluster-cert=%s/dashboard.crt", dashboardClusterTLSDir),
+			fmt.Sprintf("--cluster-key=%s/dashboard.key", dashboardClusterTLSDir),
+		)
+		volMounts = append(volMounts, corev1.VolumeMount{
+			Name: "dashboard-tls", ReadOnly: true, MountPath: dashboardClusterTLSDir,
+		})
+		vols = append(vols, corev1.Volume{
+			Name: "dashboard-tls", VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: name},
+			},
+		})
+	}
+	if tc.Spec.TiDB.EnableTLSClient {
+		// the dashboard only needs to verify tidb when querying it
+		args = append(args, fmt.Sprintf("--tidb-ca=%s", caPath))
+	}
+
+	dnsPolicy := corev1.DNSClusterFirst // same as k8s defaults
+	if spec.HostNetwork {
+		dnsPolicy = corev1.DNSClusterFirstWithHostNet
+	}
+
+	dbLabel := dashboardLabel(tc)
+	return &apps.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       ns,
+			Labels:          dbLabel.Labels(),
+			OwnerReferences: []metav1.OwnerReference{controller.GetOwnerRef(tc)},
+		},
+		Spec: apps.StatefulSetSpec{
+			// the sessions of the dashboard are not shared between replicas
+			Replicas: controller.Int32Ptr(1),
+			Selector: dbLabel.LabelSelector(),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      dbLabel.Labels(),
+					Annotations: spec.Annotations,
+				},
+				Spec: corev1.PodSpec{
+					SchedulerName: tc.Spec.SchedulerName,
+					Affinity:      spec.Affinity,
+					NodeSelector:  spec.NodeSelector,
+					HostNetwork:   spec.HostNetwork,
+					DNSPolicy:     dnsPolicy,
+					Containers: []corev1.Container{
+						{
+							Name:            label.DashboardLabelVal,
+							Image:           spec.Image,
+							ImagePullPolicy: spec.ImagePullPolicy,
+							Command:         []string{"/tidb-dashboard"},
+							Args:            args,
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "dashboard",
+									ContainerPort: int32(dashboardPort),
+									Protocol:      corev1.ProtocolTCP,
+								},
+							},
+							VolumeMounts: volMounts,
+							Resources:    util.ResourceRequirement(spec.ContainerSpec),
+							Env: []corev1.EnvVar{
+								{
+									Name:  "TZ",
+									Value: tc.Spec.Timezone,
+								},
+							},
+							ReadinessProbe: &corev1.Probe{
+								Handler: corev1.Handler{
+									TCPSocket: &corev1.TCPSocketAction{
+										Port: intstr.FromInt(dashboardPort),
+									},
+								},
+								InitialDelaySeconds: int32(10),
+							},
+						},
+					},
+					RestartPolicy:     corev1.RestartPolicyAlways,
+					Tolerations:       spec.Tolerations,
+					Volumes:           vols,
+					SecurityContext:   podSecurityContext(spec.PodSecurityContext),
+					PriorityClassName: spec.PriorityClassName,
+				},
+			},
+			ServiceName:         name,
+			PodManagementPolicy: apps.ParallelPodManagement,
+			UpdateStrategy: apps.StatefulSetUpdateStrategy{
+				Type: apps.RollingUpdateStatefulSetStrategyType,
+			},
+		},
+	}
+}
+
+type FakeDashboardMemberManager struct {
+	err error
+}
+
+func NewFakeDashboardMemberManager() *FakeDashboardMemberManager {
+	return &FakeDashboardMemberManager{}
+}
+
+func (fdmm *FakeDashboardMemberManager) SetSyncError(err error) {
+	fdmm.err = err
+}
+
+func (fdmm *FakeDashboardMemberManager) Sync(_ *v1alpha1.TidbCluster) error {
+	return fdmm.err
+}
@@ -0,0 +1,136 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned/fake"
+	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	apps "k8s.io/api/apps/v1"
+	extv1beta1 "k8s.io/api/extensions/v1beta1"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDashboardMemberManagerSync(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForDashboard()
+	dmm := newFakeDashboardMemberManager()
+	name := controller.DashboardMemberName(tc.Name)
+
+	// dashboard is not synced if it's not enabled
+	disabled := tc.DeepCopy()
+	disabled.Spec.Dashboard = nil
+	g.Expect(dmm.Sync(disabled)).To(Succeed())
+	_, err := dmm.setLister.StatefulSets(tc.Namespace).Get(name)
+	g.Expect(err).To(HaveOccurred())
+
+	// waiting for pd
+	unavailable := tc.DeepCopy()
+	unavailable.Status.PD.Members = nil
+	err = dmm.Sync(unavailable)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+
+	g.Expect(dmm.Sync(tc)).To(Succeed())
+	_, err = dmm.svcLister.Services(tc.Namespace).Get(name)
+	g.Expect(err).NotTo(HaveOccurred())
+	set, err := dmm.setLister.StatefulSets(tc.Namespace).Get(name)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(*set.Spec.Replicas).To(Equal(int32(1)))
+	g.Expect(tc.Status.Dashboard.StatefulSet).NotTo(BeNil())
+	ing, err := dmm.ingressLister.Ingresses(tc.Namespace).Get(name)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ing.Spec.Rules).To(HaveLen(1))
+	g.Expect(ing.Spec.Rules[0].Host).To(Equal("dashboard.example.com"))
+	g.Expect(ing.Spec.Rules[0].HTTP.Paths[0].Path).To(Equal(defaultDashboardPathPrefix))
+
+	// the ingress is updated when the spec changes
+	tc.Spec.Dashboard.Ingress.Hosts = []string{"a.example.com", "b.example.com"}
+	g.Expect(dmm.Sync(tc)).To(Succeed())
+	ing, err = dmm.ingressLister.Ingresses(tc.Namespace).Get(name)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ing.Spec.Rules).To(HaveLen(2))
+
+	// and deleted when it's removed from the spec
+	tc.Spec.Dashboard.Ingress = nil
+	g.Expect(dmm.Sync(tc)).To(Succeed())
+	_, err = dmm.ingressLister.Ingresses(tc.Namespace).Get(name)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestGetNewDashboardStatefulSet(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForDashboard()
+	tc.Spec.Dashboard.PathPrefix = "/db"
+	set := getNewDashboardStatefulSet(tc)
+	args := set.Spec.Template.Spec.Containers[0].Args
+	g.Expect(args).To(ContainElement("--pd=http://test-pd:2379"))
+	g.Expect(args).To(ContainElement("--path-prefix=/db"))
+	g.Expect(set.Spec.Template.Spec.Volumes).To(HaveLen(1))
+
+	tc.Spec.EnableTLSCluster = true
+	tc.Spec.TiDB.EnableTLSClient = true
+	set = getNewDashboardStatefulSet(tc)
+	args = set.Spec.Template.Spec.Containers[0].Args
+	g.Expect(args).To(ContainElement("--pd=https://test-pd:2379"))
+	g.Expect(args).To(ContainElement("--cluster-cert=/var/lib/dashboard-tls/dashboard.crt"))
+	g.Expect(args).To(ContainElement("--tidb-ca=/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"))
+	g.Expect(set.Spec.Template.Spec.Volumes).To(HaveLen(2))
+	g.Expect(set.Spec.Template.Spec.Volumes[1].Secret.SecretName).To(Equal("test-dashboard"))
+}
+
+func newFakeDashboardMemberManager() *dashboardMemberManager {
+	cli := fake.NewSimpleClientset()
+	kubeCli := kubefake.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeCli, 0)
+	setInformer := kubeInformerFactory.Apps().V1().StatefulSets()
+	tcInformer := informers.NewSharedInformerFactory(cli, 0).Pingcap().V1alpha1().TidbClusters()
+	svcInformer := kubeInformerFactory.Core().V1().Services()
+	epsInformer := kubeInformerFactory.Core().V1().Endpoints()
+	ingInformer := kubeInformerFactory.Extensions().V1beta1().Ingresses()
+
+	return &dashboardMemberManager{
+		setControl:     controller.NewFakeStatefulSetControl(setInformer, tcInformer),
+		svcControl:     controller.NewFakeServiceControl(svcInformer, epsInformer, tcInformer),
+		ingressControl: controller.NewFakeIngressControl(ingInformer),
+		setLister:      setInformer.Lister(),
+		svcLister:      svcInformer.Lister(),
+		ingressLister:  ingInformer.Lister(),
+	}
+}
+
+func newTidbClusterForDashboard() *v1alpha1.TidbCluster {
+	tc := newTidbClusterForTiDB()
+	tc.Spec.PD.Replicas = 1
+	tc.Status.PD.Members = map[string]v1alpha1.PDMember{
+		"test-pd-0": {Name: "test-pd-0", Health: true},
+	}
+	tc.Status.PD.StatefulSet = &apps.StatefulSetStatus{ReadyReplicas: 1}
+	tc.Spec.Dashboard = &v1alpha1.DashboardSpec{
+		ContainerSpec: v1alpha1.ContainerSpec{
+			Image: "pingcap/tidb-dashboard:latest",
+		},
+		Ingress: &v1alpha1.DashboardIngressSpec{
+			Hosts: []string{"dashboard.example.com"},
+			TLS:   []extv1beta1.IngressTLS{{Hosts: []string{"dashboard.example.com"}, SecretName: "dashboard-ingress"}},
+		},
+	}
+	return tc
+}
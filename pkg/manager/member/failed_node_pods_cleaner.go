@@ -0,0 +1,241 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	"github.com/pingcap/tidb-operator/pkg/metrics"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+)
+
+const (
+	skipReasonFailedNodePodsCleanerIsNotPDOrTiKV     = "failed node pods cleaner: member type is not pd or tikv"
+	skipReasonFailedNodePodsCleanerPodIsNotDeleting  = "failed node pods cleaner: pod is not terminating"
+	skipReasonFailedNodePodsCleanerNodeIsReady       = "failed node pods cleaner: node is ready"
+	skipReasonFailedNodePodsCleanerNodeNotConfirmed  = "failed node pods cleaner: node failure is not confirmed"
+	skipReasonFailedNodePodsCleanerNodeInGracePeriod = "failed node pods cleaner: node is not ready within the threshold"
+	skipReasonFailedNodePodsCleanerPodIsNotFound     = "failed node pods cleaner: pod does not exist anymore"
+	skipReasonFailedNodePodsCleanerPodChanged        = "failed node pods cleaner: pod changed before deletion"
+)
+
+// FailedNodePodsCleaner implements the logic for force deleting the pd and
+// tikv pods stuck in Terminating on the failed nodes
+//
+// The pods of a NotReady node can't be deleted gracefully because the kubelet
+// can't confirm the deletion, so the statefulset controller won't create the
+// replacement pods and the failover is blocked until the node comes back or is
+// removed from the cluster. When a node has been NotReady longer than the
+// threshold and its failure is confirmed, either by the cloud provider deleting
+// the node object or by the label.AnnNodeFailureConfirmed annotation set by
+// the fault-trigger or the admin, these pods are force deleted and their
+// volumes are detached from the node.
+type FailedNodePodsCleaner interface {
+	Clean(*v1alpha1.TidbCluster) (map[string]string, error)
+}
+
+type failedNodePodsCleaner struct {
+	podLister            corelisters.PodLister
+	nodeLister           corelisters.NodeLister
+	pvcLister            corelisters.PersistentVolumeClaimLister
+	kubeCli              kubernetes.Interface
+	enabled              bool
+	nodeFailureThreshold time.Duration
+}
+
+// NewFailedNodePodsCleaner returns a FailedNodePodsCleaner
+func NewFailedNodePodsCleaner(podLister corelisters.PodLister,
+	nodeLister corelisters.NodeLister,
+	pvcLister corelisters.PersistentVolumeClaimLister,
+	kubeCli kubernetes.Interface,
+	enabled bool,
+	nodeFailureThreshold time.Duration) FailedNodePodsCleaner {
+	return &failedNodePodsCleaner{podLister, nodeLister, pvcLister, kubeCli, enabled, nodeFailureThreshold}
+}
+
+func (fnpc *failedNodePodsCleaner) Clean(tc *v1alpha1.TidbCluster) (map[string]string, error) {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	// for unit test and metrics
+	skipReason := map[string]string{}
+
+	if !fnpc.enabled {
+		return skipReason, nil
+	}
+	defer func() {
+		metrics.ObserveCleanerSkipReasons(ns, tcName, metrics.FailedNodePodsCleaner, skipReason)
+	}()
+
+	selector, err := label.New().Instance(tc.GetLabels()[label.InstanceLabelKey]).Selector()
+	if err != nil {
+		return skipReason, err
+	}
+	pods, err := fnpc.podLister.Pods(ns).List(selector)
+	if err != nil {
+		return skipReason, err
+	}
+
+	for _, pod := range pods {
+		podName := pod.GetName()
+		l := label.Label(pod.Labels)
+		if !(l.IsPD() || l.IsTiKV()) {
+			skipReason[podName] = skipReasonFailedNodePodsCleanerIsNotPDOrTiKV
+			continue
+		}
+
+		if pod.DeletionTimestamp == nil || pod.Spec.NodeName == "" {
+			skipReason[podName] = skipReasonFailedNodePodsCleanerPodIsNotDeleting
+			continue
+		}
+
+		reason, err := fnpc.checkNodeFailed(pod.Spec.NodeName)
+		if err != nil {
+			return skipReason, err
+		}
+		if reason != "" {
+			skipReason[podName] = reason
+			continue
+		}
+
+		// re-check from apiserver directly to make sure the pod is still stuck on the failed node
+		apiPod, err := fnpc.kubeCli.CoreV1().Pods(ns).Get(podName, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			skipReason[podName] = skipReasonFailedNodePodsCleanerPodIsNotFound
+			continue
+		}
+		if err != nil {
+			return skipReason, err
+		}
+		if apiPod.UID != pod.UID || apiPod.Spec.NodeName != pod.Spec.NodeName {
+			skipReason[podName] = skipReasonFailedNodePodsCleanerPodChanged
+			continue
+		}
+
+		var gracePeriod int64
+		uid := pod.UID
+		err = fnpc.kubeCli.CoreV1().Pods(ns).Delete(podName, &metav1.DeleteOptions{
+			GracePeriodSeconds: &gracePeriod,
+			Preconditions:      &metav1.Preconditions{UID: &uid},
+		})
+		if err != nil && !errors.IsNotFound(err) {
+			log.Errorf("failed node pods cleaner: failed to force delete pod: %s/%s on node %s, %v", ns, podName, pod.Spec.NodeName, err)
+			return skipReason, err
+		}
+		metrics.ObserveCleanerAction(ns, tcName, metrics.FailedNodePodsCleaner, metrics.ActionForceDeletePod)
+		log.Infof("failed node pods cleaner: force delete pod: %s/%s on failed node %s successfully", ns, podName, pod.Spec.NodeName)
+
+		if err := fnpc.detachVolumes(tc, pod); err != nil {
+			return skipReason, err
+		}
+	}
+
+	return skipReason, nil
+}
+
+// checkNodeFailed returns an empty skip reason if the node is confirmed to be failed
+func (fnpc *failedNodePodsCleaner) checkNodeFailed(nodeName string) (string, error) {
+	node, err := fnpc.nodeLister.Get(nodeName)
+	if errors.IsNotFound(err) {
+		// if the node is not found in cache, re-check from apiserver directly,
+		// a node removed by the cloud provider is confirmed to be gone
+		node, err = fnpc.kubeCli.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return "", nil
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var readyCondition *v1.NodeCondition
+	for i := range node.Status.Conditions {
+		if node.Status.Conditions[i].Type == v1.NodeReady {
+			readyCondition = &node.Status.Conditions[i]
+			break
+		}
+	}
+	if readyCondition == nil || readyCondition.Status == v1.ConditionTrue {
+		return skipReasonFailedNodePodsCleanerNodeIsReady, nil
+	}
+	if readyCondition.LastTransitionTime.Add(fnpc.nodeFailureThreshold).After(time.Now()) {
+		return skipReasonFailedNodePodsCleanerNodeInGracePeriod, nil
+	}
+	if node.Annotations[label.AnnNodeFailureConfirmed] != "true" {
+		return skipReasonFailedNodePodsCleanerNodeNotConfirmed, nil
+	}
+	return "", nil
+}
+
+// detachVolumes deletes the volume attachments of the pod's volumes on its
+// failed node, so that the volumes can be attached to the new node without
+// waiting for the attach/detach controller's timeout
+func (fnpc *failedNodePodsCleaner) detachVolumes(tc *v1alpha1.TidbCluster, pod *v1.Pod) error {
+	ns := pod.GetNamespace()
+	pvNames := map[string]bool{}
+	for _, vol := range pod.Spec.Volumes {
+		if vol.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvc, err := fnpc.pvcLister.PersistentVolumeClaims(ns).Get(vol.PersistentVolumeClaim.ClaimName)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if pvc.Spec.VolumeName != "" {
+			pvNames[pvc.Spec.VolumeName] = true
+		}
+	}
+	if len(pvNames) == 0 {
+		return nil
+	}
+
+	vas, err := fnpc.kubeCli.StorageV1beta1().VolumeAttachments().List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, va := range vas.Items {
+		pvName := va.Spec.Source.PersistentVolumeName
+		if va.Spec.NodeName != pod.Spec.NodeName || pvName == nil || !pvNames[*pvName] {
+			continue
+		}
+		err := fnpc.kubeCli.StorageV1beta1().VolumeAttachments().Delete(va.GetName(), &metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			log.Errorf("failed node pods cleaner: failed to delete volume attachment %s of pod %s/%s, %v", va.GetName(), ns, pod.GetName(), err)
+			return err
+		}
+		metrics.ObserveCleanerAction(ns, tc.GetName(), metrics.FailedNodePodsCleaner, metrics.ActionDeleteVolumeAttachment)
+		log.Infof("failed node pods cleaner: delete volume attachment %s of pod %s/%s successfully", va.GetName(), ns, pod.GetName())
+	}
+	return nil
+}
+
+type fakeFailedNodePodsCleaner struct{}
+
+// NewFakeFailedNodePodsCleaner returns a fake failed node pods cleaner
+func NewFakeFailedNodePodsCleaner() FailedNodePodsCleaner {
+	return &fakeFailedNodePodsCleaner{}
+}
+
+func (ffnpc *fakeFailedNodePodsCleaner) Clean(_ *v1alpha1.TidbCluster) (map[string]string, error) {
+	return nil, nil
+}
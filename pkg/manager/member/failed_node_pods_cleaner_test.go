@@ -0,0 +1,226 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	corev1 "k8s.io/api/core/v1"
+	storagev1beta1 "k8s.io/api/storage/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestFailedNodePodsCleanerClean(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPD()
+	now := metav1.Now()
+	failedAt := metav1.NewTime(time.Now().Add(-time.Hour))
+
+	type testcase struct {
+		name     string
+		disabled bool
+		pods     []*corev1.Pod
+		nodes    []*corev1.Node
+		expectFn func(*GomegaWithT, map[string]string, kubernetes.Interface, error)
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		fnpc, podIndexer, nodeIndexer, pvcIndexer, client := newFakeFailedNodePodsCleaner(!test.disabled)
+		for _, pod := range test.pods {
+			client.CoreV1().Pods(pod.Namespace).Create(pod)
+			podIndexer.Add(pod)
+		}
+		for _, node := range test.nodes {
+			client.CoreV1().Nodes().Create(node)
+			nodeIndexer.Add(node)
+		}
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "pvc-1", Namespace: metav1.NamespaceDefault},
+			Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "pv-1"},
+		}
+		pvcIndexer.Add(pvc)
+		pvName := "pv-1"
+		client.StorageV1beta1().VolumeAttachments().Create(&storagev1beta1.VolumeAttachment{
+			ObjectMeta: metav1.ObjectMeta{Name: "va-1"},
+			Spec: storagev1beta1.VolumeAttachmentSpec{
+				NodeName: "node-1",
+				Source:   storagev1beta1.VolumeAttachmentSource{PersistentVolumeName: &pvName},
+			},
+		})
+
+		skipReason, err := fnpc.Clean(tc)
+		test.expectFn(g, skipReason, client, err)
+	}
+
+	newPod := func(name string, l label.Label, deleting bool) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: metav1.NamespaceDefault,
+				Labels:    l.Instance(tc.GetLabels()[label.InstanceLabelKey]).Labels(),
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "node-1",
+				Volumes: []corev1.Volume{
+					{
+						Name: "pd",
+						VolumeSource: corev1.VolumeSource{
+							PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "pvc-1"},
+						},
+					},
+				},
+			},
+		}
+		if deleting {
+			pod.DeletionTimestamp = &now
+		}
+		return pod
+	}
+	newNode := func(ready corev1.ConditionStatus, since metav1.Time, confirmed bool) *corev1.Node {
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{
+					{Type: corev1.NodeReady, Status: ready, LastTransitionTime: since},
+				},
+			},
+		}
+		if confirmed {
+			node.Annotations = map[string]string{label.AnnNodeFailureConfirmed: "true"}
+		}
+		return node
+	}
+	expectPodDeleted := func(g *GomegaWithT, client kubernetes.Interface, deleted bool) {
+		_, err := client.CoreV1().Pods(metav1.NamespaceDefault).Get("pod-1", metav1.GetOptions{})
+		g.Expect(errors.IsNotFound(err)).To(Equal(deleted))
+		_, err = client.StorageV1beta1().VolumeAttachments().Get("va-1", metav1.GetOptions{})
+		g.Expect(errors.IsNotFound(err)).To(Equal(deleted))
+	}
+
+	tests := []testcase{
+		{
+			name:     "disabled",
+			disabled: true,
+			pods:     []*corev1.Pod{newPod("pod-1", label.New().PD(), true)},
+			expectFn: func(g *GomegaWithT, skipReason map[string]string, client kubernetes.Interface, err error) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(len(skipReason)).To(Equal(0))
+				expectPodDeleted(g, client, false)
+			},
+		},
+		{
+			name: "not pd or tikv pods",
+			pods: []*corev1.Pod{newPod("pod-1", label.New().TiDB(), true)},
+			expectFn: func(g *GomegaWithT, skipReason map[string]string, client kubernetes.Interface, err error) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(skipReason["pod-1"]).To(Equal(skipReasonFailedNodePodsCleanerIsNotPDOrTiKV))
+				expectPodDeleted(g, client, false)
+			},
+		},
+		{
+			name:  "pod is not terminating",
+			pods:  []*corev1.Pod{newPod("pod-1", label.New().PD(), false)},
+			nodes: []*corev1.Node{newNode(corev1.ConditionFalse, failedAt, true)},
+			expectFn: func(g *GomegaWithT, skipReason map[string]string, client kubernetes.Interface, err error) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(skipReason["pod-1"]).To(Equal(skipReasonFailedNodePodsCleanerPodIsNotDeleting))
+				expectPodDeleted(g, client, false)
+			},
+		},
+		{
+			name:  "node is ready",
+			pods:  []*corev1.Pod{newPod("pod-1", label.New().TiKV(), true)},
+			nodes: []*corev1.Node{newNode(corev1.ConditionTrue, failedAt, true)},
+			expectFn: func(g *GomegaWithT, skipReason map[string]string, client kubernetes.Interface, err error) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(skipReason["pod-1"]).To(Equal(skipReasonFailedNodePodsCleanerNodeIsReady))
+				expectPodDeleted(g, client, false)
+			},
+		},
+		{
+			name:  "node is not ready within the threshold",
+			pods:  []*corev1.Pod{newPod("pod-1", label.New().TiKV(), true)},
+			nodes: []*corev1.Node{newNode(corev1.ConditionUnknown, now, true)},
+			expectFn: func(g *GomegaWithT, skipReason map[string]string, client kubernetes.Interface, err error) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(skipReason["pod-1"]).To(Equal(skipReasonFailedNodePodsCleanerNodeInGracePeriod))
+				expectPodDeleted(g, client, false)
+			},
+		},
+		{
+			name:  "node failure is not confirmed",
+			pods:  []*corev1.Pod{newPod("pod-1", label.New().TiKV(), true)},
+			nodes: []*corev1.Node{newNode(corev1.ConditionUnknown, failedAt, false)},
+			expectFn: func(g *GomegaWithT, skipReason map[string]string, client kubernetes.Interface, err error) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(skipReason["pod-1"]).To(Equal(skipReasonFailedNodePodsCleanerNodeNotConfirmed))
+				expectPodDeleted(g, client, false)
+			},
+		},
+		{
+			name:  "node failure is confirmed",
+			pods:  []*corev1.Pod{newPod("pod-1", label.New().TiKV(), true)},
+			nodes: []*corev1.Node{newNode(corev1.ConditionUnknown, failedAt, true)},
+			expectFn: func(g *GomegaWithT, skipReason map[string]string, client kubernetes.Interface, err error) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(len(skipReason)).To(Equal(0))
+				expectPodDeleted(g, client, true)
+			},
+		},
+		{
+			name: "node is removed",
+			pods: []*corev1.Pod{newPod("pod-1", label.New().PD(), true)},
+			expectFn: func(g *GomegaWithT, skipReason map[string]string, client kubernetes.Interface, err error) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(len(skipReason)).To(Equal(0))
+				expectPodDeleted(g, client, true)
+			},
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func newFakeFailedNodePodsCleaner(enabled bool) (*failedNodePodsCleaner, cache.Indexer, cache.Indexer, cache.Indexer, kubernetes.Interface) {
+	kubeCli := kubefake.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeCli, 0)
+	podInformer := kubeInformerFactory.Core().V1().Pods()
+	nodeInformer := kubeInformerFactory.Core().V1().Nodes()
+	pvcInformer := kubeInformerFactory.Core().V1().PersistentVolumeClaims()
+
+	return &failedNodePodsCleaner{
+			podInformer.Lister(),
+			nodeInformer.Lister(),
+			pvcInformer.Lister(),
+			kubeCli,
+			enabled,
+			10 * time.Minute,
+		},
+		podInformer.Informer().GetIndexer(),
+		nodeInformer.Informer().GetIndexer(),
+		pvcInformer.Informer().GetIndexer(),
+		kubeCli
+}
@@ -0,0 +1,394 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	listers "github.com/pingcap/tidb-operator/pkg/client/listers/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/manager"
+	"github.com/pingcap/tidb-operator/pkg/util"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+)
+
+const (
+	ngMonitoringPort       = 12020
+	ngMonitoringConfigDir  = "/etc/ng-monitoring"
+	ngMonitoringDataDir    = "/var/lib/ng-monitoring"
+	ngMonitoringTLSDir     = "/var/lib/ng-monitoring-tls"
+	ngMonitoringConfigKey  = "config-file"
+	ngMonitoringDataVolume = "ng-monitoring"
+	// defaultNGMonitoringStorageSize is the size of the data PV if it's not specified
+	defaultNGMonitoringStorageSize = "10Gi"
+	// ngMonitoringConfigDigestAnnotation is the digest of the generated config,
+	// the pod is restarted if the config changes
+	ngMonitoringConfigDigestAnnotation = "pingcap.com/ng-monitoring-config-digest"
+)
+
+type ngMonitoringManager struct {
+	setControl controller.StatefulSetControlInterface
+	svcControl controller.ServiceControlInterface
+	cmControl  controller.ConfigMapControlInterface
+	tcLister   listers.TidbClusterLister
+	setLister  appslisters.StatefulSetLister
+	svcLister  corelisters.ServiceLister
+	cmLister   corelisters.ConfigMapLister
+}
+
+// NewNGMonitoringManager returns a *ngMonitoringManager
+func NewNGMonitoringManager(setControl controller.StatefulSetControlInterface,
+	svcControl controller.ServiceControlInterface,
+	cmControl controller.ConfigMapControlInterface,
+	tcLister listers.TidbClusterLister,
+	setLister appslisters.StatefulSetLister,
+	svcLister corelisters.ServiceLister,
+	cmLister corelisters.ConfigMapLister) manager.NGMonitoringManager {
+	return &ngMonitoringManager{
+		setControl: setControl,
+		svcControl: svcControl,
+		cmControl:  cmControl,
+		tcLister:   tcLister,
+		setLister:  setLister,
+		svcLister:  svcLister,
+		cmLister:   cmLister,
+	}
+}
+
+func (nmm *ngMonitoringManager) Sync(tngm *v1alpha1.TidbNGMonitoring) error {
+	ns := tngm.GetNamespace()
+	name := tngm.GetName()
+
+	tc, err := nmm.tcLister.TidbClusters(ns).Get(tngm.Spec.Cluster)
+	if errors.IsNotFound(err) {
+		return controller.RequeueErrorf("TidbNGMonitoring: [%s/%s], waiting for TidbCluster %s created", ns, name, tngm.Spec.Cluster)
+	}
+	if err != nil {
+		return err
+	}
+	if !tc.PDIsAvailable() {
+		return controller.RequeueErrorf("TidbNGMonitoring: [%s/%s], waiting for PD cluster running", ns, name)
+	}
+
+	if err := nmm.syncConfigMap(tc, tngm); err != nil {
+		return err
+	}
+	if err := nmm.syncHeadlessService(tc, tngm); err != nil {
+		return err
+	}
+	return nmm.syncStatefulSet(tc, tngm)
+}
+
+func (nmm *ngMonitoringManager) syncConfigMap(tc *v1alpha1.TidbCluster, tngm *v1alpha1.TidbNGMonitoring) error {
+	newCm := getNewNGMonitoringConfigMap(tc, tngm)
+	oldCmTmp, err := nmm.cmLister.ConfigMaps(newCm.Namespace).Get(newCm.Name)
+	if errors.IsNotFound(err) {
+		return nmm.cmControl.CreateConfigMap(tc, newCm)
+	}
+	if err != nil {
+		return err
+	}
+
+	if oldCmTmp.Data[ngMonitoringConfigKey] == newCm.Data[ngMonitoringConfigKey] {
+		return nil
+	}
+	cm := oldCmTmp.DeepCopy()
+	cm.Data = newCm.Data
+	_, err = nmm.cmControl.UpdateConfigMap(tc, cm)
+	return err
+}
+
+func (nmm *ngMonitoringManager) syncHeadlessService(tc *v1alpha1.TidbCluster, tngm *v1alpha1.TidbNGMonitoring) error {
+	newSvc := getNewNGMonitoringHeadlessService(tngm)
+	oldSvcTmp, err := nmm.svcLister.Services(newSvc.Namespace).Get(newSvc.Name)
+	if errors.IsNotFound(err) {
+		err = SetServiceLastAppliedConfigAnnotation(newSvc)
+		if err != nil {
+			return err
+		}
+		return nmm.svcControl.CreateService(tc, newSvc)
+	}
+	if err != nil {
+		return err
+	}
+
+	oldSvc := oldSvcTmp.DeepCopy()
+	return updateService(nmm.svcControl, tc, newSvc, oldSvc)
+}
+
+func (nmm *ngMonitoringManager) syncStatefulSet(tc *v1alpha1.TidbCluster, tngm *v1alpha1.TidbNGMonitoring) error {
+	ns := tngm.GetNamespace()
+
+	newSet, err := getNewNGMonitoringStatefulSet(tc, tngm)
+	if err != nil {
+		return err
+	}
+	oldSetTmp, err := nmm.setLister.StatefulSets(ns).Get(newSet.Name)
+	if errors.IsNotFound(err) {
+		err = SetLastAppliedConfigAnnotation(newSet)
+		if err != nil {
+			return err
+		}
+		err = nmm.setControl.CreateStatefulSet(tc, newSet)
+		if err != nil {
+			return err
+		}
+		tngm.Status.StatefulSet = &apps.StatefulSetStatus{}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	oldSet := oldSetTmp.DeepCopy()
+	tngm.Status.StatefulSet = &oldSet.Status
+
+	return updateStatefulSet(nmm.setControl, tc, newSet, oldSet)
+}
+
+// getNGMonitoringConfig generates the config of ng-monitoring, the user
+// config is appended to it
+func getNGMonitoringConfig(tc *v1alpha1.TidbCluster, tngm *v1alpha1.TidbNGMonitoring) string {
+	scheme := "http"
+	if tc.Spec.EnableTLSCluster {
+		scheme = "https"
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "address = \"0.0.0.0:%d\"\n", ngMonitoringPort)
+	fmt.Fprintf(&buf, "\n[pd]\nendpoints = [\"%s://%s:2379\"]\n", scheme, controller.PDMemberName(tc.Name))
+	fmt.Fprintf(&buf, "\n[storage]\npath = %q\n", ngMonitoringDataDir)
+	if len(tngm.Spec.Retention) > 0 {
+		fmt.Fprintf(&buf, "\n[tsdb]\nretention-period = %q\n", tngm.Spec.Retention)
+	}
+	if tc.Spec.EnableTLSCluster {
+		fmt.Fprintf(&buf, "\n[security]\nca-path = %q\ncert-path = %q\nkey-path = %q\n",
+			"/var/run/secrets/kubernetes.io/serviceaccount/ca.crt",
+			fmt.Sprintf("%s/ng-monitoring.crt", ngMonitoringTLSDir),
+			fmt.Sprintf("%s/ng-monitoring.key", ngMonitoringTLSDir))
+	}
+	if len(tngm.Spec.Config) > 0 {
+		fmt.Fprintf(&buf, "\n%s\n", tngm.Spec.Config)
+	}
+	return buf.String()
+}
+
+func ngMonitoringLabel(tngm *v1alpha1.TidbNGMonitoring) label.Label {
+	return label.New().Instance(tngm.Spec.Cluster).NGMonitoring()
+}
+
+func getNewNGMonitoringConfigMap(tc *v1alpha1.TidbCluster, tngm *v1alpha1.TidbNGMonitoring) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            controller.NGMonitoringMemberName(tngm.Name),
+			Namespace:       tngm.Namespace,
+			Labels:          ngMonitoringLabel(tngm).Labels(),
+			OwnerReferences: []metav1.OwnerReference{controller.GetTidbNGMonitoringOwnerRef(tngm)},
+		},
+		Data: map[string]string{
+			ngMonitoringConfigKey: getNGMonitoringConfig(tc, tngm),
+		},
+	}
+}
+
+func getNewNGMonitoringHeadlessService(tngm *v1alpha1.TidbNGMonitoring) *corev1.Service {
+	ngmLabel := ngMonitoringLabel(tngm).Labels()
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            controller.NGMonitoringMemberName(tngm.Name),
+			Namespace:       tngm.Namespace,
+			Labels:          ngmLabel,
+			OwnerReferences: []metav1.OwnerReference{controller.GetTidbNGMonitoringOwnerRef(tngm)},
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: "None",
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "ng-monitoring",
+					Port:       ngMonitoringPort,
+					TargetPort: intstr.FromInt(ngMonitoringPort),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+			Selector:                 ngmLabel,
+			PublishNotReadyAddresses: true,
+		},
+	}
+}
+
+func getNewNGMonitoringStatefulSet(tc *v1alpha1.TidbCluster, tngm *v1alpha1.TidbNGMonitoring) (*apps.StatefulSet, error) {
+	ns := tngm.GetNamespace()
+	name := controller.NGMonitoringMemberName(tngm.Name)
+	spec := tngm.Spec
+
+	size := defaultNGMonitoringStorageSize
+	if spec.Requests != nil && len(spec.Requests.Storage) > 0 {
+		size = spec.Requests.Storage
+	}
+	q, err := resource.ParseQuantity(size)
+	if err != nil {
+		return nil, fmt.Errorf("cant' get storage size: %s for TidbNGMonitoring: %s/%s, %v", size, ns, tngm.Name, err)
+	}
+	storageClassName := spec.StorageClassName
+	if storageClassName == "" {
+		storageClassName = controller.DefaultStorageClassName
+	}
+
+	volMounts := []corev1.VolumeMount{
+		{Name: "config", ReadOnly: true, MountPath: ngMonitoringConfigDir},
+		{Name: ngMonitoringDataVolume, MountPath: ngMonitoringDataDir},
+	}
+	vols := []corev1.Volume{
+		{Name: "config", VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: name},
+				Items:                []corev1.KeyToPath{{Key: ngMonitoringConfigKey, Path: "config.toml"}},
+			}},
+		},
+	}
+	if tc.Spec.EnableTLSCluster {
+		volMounts = append(volMounts, corev1.VolumeMount{
+			Name: "ng-monitoring-tls", ReadOnly: true, MountPath: ngMonitoringTLSDir,
+		})
+		vols = append(vols, corev1.Volume{
+			Name: "ng-monitoring-tls", VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: name,
+				},
+			},
+		})
+	}
+
+	dnsPolicy := corev1.DNSClusterFirst // same as k8s defaults
+	if spec.HostNetwork {
+		dnsPolicy = corev1.DNSClusterFirstWithHostNet
+	}
+
+	ngmLabel := ngMonitoringLabel(tngm)
+	podAnnotations := CombineAnnotations(map[string]string{
+		ngMonitoringConfigDigestAnnotation: fmt.Sprintf("%x", sha256.Sum256([]byte(getNGMonitoringConfig(tc, tngm))))[:8],
+	}, spec.Annotations)
+
+	return &apps.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       ns,
+			Labels:          ngmLabel.Labels(),
+			OwnerReferences: []metav1.OwnerReference{controller.GetTidbNGMonitoringOwnerRef(tngm)},
+		},
+		Spec: apps.StatefulSetSpec{
+			// ng-monitoring doesn't support multiple replicas
+			Replicas: controller.Int32Ptr(1),
+			Selector: ngmLabel.LabelSelector(),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      ngmLabel.Labels(),
+					Annotations: podAnnotations,
+				},
+				Spec: corev1.PodSpec{
+					SchedulerName: tc.Spec.SchedulerName,
+					Affinity:      spec.Affinity,
+					NodeSelector:  spec.NodeSelector,
+					HostNetwork:   spec.HostNetwork,
+					DNSPolicy:     dnsPolicy,
+					Containers: []corev1.Container{
+						{
+							Name:            label.NGMonitoringLabelVal,
+							Image:           spec.Image,
+							ImagePullPolicy: spec.ImagePullPolicy,
+							Command:         []string{"/ng-monitoring-server"},
+							Args: []string{
+								fmt.Sprintf("--config=%s/config.toml", ngMonitoringConfigDir),
+								// ng-monitoring registers the address in pd, where TiDB Dashboard finds it
+								fmt.Sprintf("--advertise-address=$(POD_NAME).%s.%s.svc:%d", name, ns, ngMonitoringPort),
+							},
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "ng-monitoring",
+									ContainerPort: int32(ngMonitoringPort),
+									Protocol:      corev1.ProtocolTCP,
+								},
+							},
+							VolumeMounts: volMounts,
+							Resources:    util.ResourceRequirement(spec.ContainerSpec),
+							Env: []corev1.EnvVar{
+								{
+									Name: "POD_NAME",
+									ValueFrom: &corev1.EnvVarSource{
+										FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+									},
+								},
+								{
+									Name:  "TZ",
+									Value: tc.Spec.Timezone,
+								},
+							},
+						},
+					},
+					RestartPolicy:     corev1.RestartPolicyAlways,
+					Tolerations:       spec.Tolerations,
+					Volumes:           vols,
+					SecurityContext:   podSecurityContext(spec.PodSecurityContext),
+					PriorityClassName: spec.PriorityClassName,
+				},
+			},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: ngMonitoringDataVolume},
+					Spec: corev1.PersistentVolumeClaimSpec{
+						AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+						StorageClassName: &storageClassName,
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceStorage: q,
+							},
+						},
+					},
+				},
+			},
+			ServiceName:         name,
+			PodManagementPolicy: apps.ParallelPodManagement,
+			UpdateStrategy: apps.StatefulSetUpdateStrategy{
+				Type: apps.RollingUpdateStatefulSetStrategyType,
+			},
+		},
+	}, nil
+}
+
+type FakeNGMonitoringManager struct {
+	err error
+}
+
+func NewFakeNGMonitoringManager() *FakeNGMonitoringManager {
+	return &FakeNGMonitoringManager{}
+}
+
+func (fnmm *FakeNGMonitoringManager) SetSyncError(err error) {
+	fnmm.err = err
+}
+
+func (fnmm *FakeNGMonitoringManager) Sync(_ *v1alpha1.TidbNGMonitoring) error {
+	return fnmm.err
+}
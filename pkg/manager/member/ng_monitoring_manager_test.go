@@ -0,0 +1,126 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned/fake"
+	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestNGMonitoringManagerSync(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForTiProxy()
+	tngm := newTidbNGMonitoring()
+	nmm, setControl, tcIndexer := newFakeNGMonitoringManager()
+
+	// waiting for the tidb cluster
+	err := nmm.Sync(tngm)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+
+	g.Expect(tcIndexer.Add(tc)).To(Succeed())
+	g.Expect(nmm.Sync(tngm)).To(Succeed())
+	g.Expect(tngm.Status.StatefulSet).NotTo(BeNil())
+
+	name := controller.NGMonitoringMemberName(tngm.Name)
+	cm, err := nmm.cmLister.ConfigMaps(tngm.Namespace).Get(name)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cm.OwnerReferences[0].Kind).To(Equal("TidbNGMonitoring"))
+	_, err = nmm.svcLister.Services(tngm.Namespace).Get(name)
+	g.Expect(err).NotTo(HaveOccurred())
+	set, err := setControl.SetLister.StatefulSets(tngm.Namespace).Get(name)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(set.Spec.VolumeClaimTemplates).To(HaveLen(1))
+	q := set.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests[corev1.ResourceStorage]
+	g.Expect(q.String()).To(Equal("20Gi"))
+
+	// the config change restarts the pod
+	tngm.Spec.Retention = "7d"
+	g.Expect(nmm.Sync(tngm)).To(Succeed())
+	newSet, err := setControl.SetLister.StatefulSets(tngm.Namespace).Get(name)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(newSet.Spec.Template.Annotations[ngMonitoringConfigDigestAnnotation]).NotTo(Equal(set.Spec.Template.Annotations[ngMonitoringConfigDigestAnnotation]))
+}
+
+func TestGetNGMonitoringConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForTiProxy()
+	tngm := newTidbNGMonitoring()
+	config := getNGMonitoringConfig(tc, tngm)
+	g.Expect(config).To(ContainSubstring("endpoints = [\"http://test-pd:2379\"]"))
+	g.Expect(config).To(ContainSubstring("[tsdb]\nretention-period = \"1\""))
+	g.Expect(config).NotTo(ContainSubstring("[security]"))
+
+	tc.Spec.EnableTLSCluster = true
+	tngm.Spec.Retention = ""
+	tngm.Spec.Config = "[log]\nlevel = \"warn\""
+	config = getNGMonitoringConfig(tc, tngm)
+	g.Expect(config).To(ContainSubstring("endpoints = [\"https://test-pd:2379\"]"))
+	g.Expect(config).To(ContainSubstring("[security]"))
+	g.Expect(config).NotTo(ContainSubstring("[tsdb]"))
+	g.Expect(config).To(HaveSuffix("[log]\nlevel = \"warn\"\n"))
+}
+
+func newFakeNGMonitoringManager() (*ngMonitoringManager, *controller.FakeStatefulSetControl, cache.Indexer) {
+	cli := fake.NewSimpleClientset()
+	kubeCli := kubefake.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeCli, 0)
+	setInformer := kubeInformerFactory.Apps().V1().StatefulSets()
+	tcInformer := informers.NewSharedInformerFactory(cli, 0).Pingcap().V1alpha1().TidbClusters()
+	svcInformer := kubeInformerFactory.Core().V1().Services()
+	epsInformer := kubeInformerFactory.Core().V1().Endpoints()
+	cmInformer := kubeInformerFactory.Core().V1().ConfigMaps()
+	setControl := controller.NewFakeStatefulSetControl(setInformer, tcInformer)
+
+	nmm := &ngMonitoringManager{
+		setControl: setControl,
+		svcControl: controller.NewFakeServiceControl(svcInformer, epsInformer, tcInformer),
+		cmControl:  controller.NewFakeConfigMapControl(cmInformer),
+		tcLister:   tcInformer.Lister(),
+		setLister:  setInformer.Lister(),
+		svcLister:  svcInformer.Lister(),
+		cmLister:   cmInformer.Lister(),
+	}
+	return nmm, setControl, tcInformer.Informer().GetIndexer()
+}
+
+func newTidbNGMonitoring() *v1alpha1.TidbNGMonitoring {
+	return &v1alpha1.TidbNGMonitoring{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "demo",
+			Namespace: corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.TidbNGMonitoringSpec{
+			Cluster: "test",
+			ContainerSpec: v1alpha1.ContainerSpec{
+				Image: "ng-monitoring",
+				Requests: &v1alpha1.ResourceRequirement{
+					Storage: "20Gi",
+				},
+			},
+			Retention: "1",
+		},
+	}
+}
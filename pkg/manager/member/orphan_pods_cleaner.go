@@ -14,10 +14,11 @@
 package member
 
 import (
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	"github.com/pingcap/tidb-operator/pkg/metrics"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -68,8 +69,12 @@ func NewOrphanPodsCleaner(podLister corelisters.PodLister,
 
 func (opc *orphanPodsCleaner) Clean(tc *v1alpha1.TidbCluster) (map[string]string, error) {
 	ns := tc.GetNamespace()
-	// for unit test
+	tcName := tc.GetName()
+	// for unit test and metrics
 	skipReason := map[string]string{}
+	defer func() {
+		metrics.ObserveCleanerSkipReasons(ns, tcName, metrics.OrphanPodsCleaner, skipReason)
+	}()
 
 	selector, err := label.New().Instance(tc.GetLabels()[label.InstanceLabelKey]).Selector()
 	if err != nil {
@@ -146,10 +151,11 @@ func (opc *orphanPodsCleaner) Clean(tc *v1alpha1.TidbCluster) (map[string]string
 		}
 		err = opc.podControl.DeletePod(tc, pod)
 		if err != nil {
-			glog.Errorf("orphan pods cleaner: failed to clean orphan pod: %s/%s, %v", ns, podName, err)
+			log.Errorf("orphan pods cleaner: failed to clean orphan pod: %s/%s, %v", ns, podName, err)
 			return skipReason, err
 		}
-		glog.Infof("orphan pods cleaner: clean orphan pod: %s/%s successfully", ns, podName)
+		metrics.ObserveCleanerAction(ns, tcName, metrics.OrphanPodsCleaner, metrics.ActionDeletePod)
+		log.Infof("orphan pods cleaner: clean orphan pod: %s/%s successfully", ns, podName)
 	}
 
 	return skipReason, nil
@@ -0,0 +1,190 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	"github.com/pingcap/tidb-operator/pkg/metrics"
+	"k8s.io/apimachinery/pkg/api/errors"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+)
+
+const (
+	skipReasonOrphanPVCsCleanerIsNotPDOrTiKV       = "orphan pvcs cleaner: member type is not pd or tikv"
+	skipReasonOrphanPVCsCleanerNotStatefulSetPVC   = "orphan pvcs cleaner: pvc is not created by the statefulset"
+	skipReasonOrphanPVCsCleanerStatefulSetNotFound = "orphan pvcs cleaner: statefulset is not found"
+	skipReasonOrphanPVCsCleanerOrdinalIsExpected   = "orphan pvcs cleaner: ordinal is in the statefulset replicas"
+	skipReasonOrphanPVCsCleanerPodIsFound          = "orphan pvcs cleaner: pod is found"
+	skipReasonOrphanPVCsCleanerPVCIsDeleting       = "orphan pvcs cleaner: pvc is being deleted"
+	skipReasonOrphanPVCsCleanerInGracePeriod       = "orphan pvcs cleaner: pvc is in the grace period"
+)
+
+// OrphanPVCsCleaner implements the logic for cleaning the orphan PVCs of pd and tikv
+//
+// The PVCs of the ordinals beyond the replicas of the statefulsets are left behind
+// after the scaling in, they are kept for the scaling out to the same ordinals in
+// case the scaling in is a mistake, and they are deleted just before the scaling out.
+// The orphan PVCs which are not used by any pod are marked with the defer deleting
+// annotation, they are deleted once they are orphans for the grace period, so that
+// they don't consume the storage quota forever.
+type OrphanPVCsCleaner interface {
+	Clean(*v1alpha1.TidbCluster) (map[string]string, error)
+}
+
+type orphanPVCsCleaner struct {
+	podLister   corelisters.PodLister
+	pvcLister   corelisters.PersistentVolumeClaimLister
+	pvcControl  controller.PVCControlInterface
+	setLister   appslisters.StatefulSetLister
+	gracePeriod time.Duration
+}
+
+// NewOrphanPVCsCleaner returns a OrphanPVCsCleaner, the orphan PVCs are not deleted if
+// the gracePeriod is not positive
+func NewOrphanPVCsCleaner(podLister corelisters.PodLister,
+	pvcLister corelisters.PersistentVolumeClaimLister,
+	pvcControl controller.PVCControlInterface,
+	setLister appslisters.StatefulSetLister,
+	gracePeriod time.Duration) OrphanPVCsCleaner {
+	return &orphanPVCsCleaner{podLister, pvcLister, pvcControl, setLister, gracePeriod}
+}
+
+func (opc *orphanPVCsCleaner) Clean(tc *v1alpha1.TidbCluster) (map[string]string, error) {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	// for unit test and metrics
+	skipReason := map[string]string{}
+	if opc.gracePeriod <= 0 {
+		return skipReason, nil
+	}
+	defer func() {
+		metrics.ObserveCleanerSkipReasons(ns, tcName, metrics.OrphanPVCsCleaner, skipReason)
+	}()
+
+	selector, err := label.New().Instance(tc.GetLabels()[label.InstanceLabelKey]).Selector()
+	if err != nil {
+		return skipReason, fmt.Errorf("cluster %s/%s assemble label selector failed, err: %v", ns, tcName, err)
+	}
+	pvcs, err := opc.pvcLister.PersistentVolumeClaims(ns).List(selector)
+	if err != nil {
+		return skipReason, fmt.Errorf("cluster %s/%s list pvc failed, selector: %s, err: %v", ns, tcName, selector, err)
+	}
+
+	for _, pvc := range pvcs {
+		pvcName := pvc.GetName()
+		l := label.Label(pvc.Labels)
+		var memberType v1alpha1.MemberType
+		var setName string
+		switch {
+		case l.IsPD():
+			memberType, setName = v1alpha1.PDMemberType, controller.PDMemberName(tcName)
+		case l.IsTiKV():
+			memberType, setName = v1alpha1.TiKVMemberType, controller.TiKVMemberName(tcName)
+		default:
+			skipReason[pvcName] = skipReasonOrphanPVCsCleanerIsNotPDOrTiKV
+			continue
+		}
+
+		prefix := fmt.Sprintf("%s-%s-", memberType, setName)
+		if !strings.HasPrefix(pvcName, prefix) {
+			skipReason[pvcName] = skipReasonOrphanPVCsCleanerNotStatefulSetPVC
+			continue
+		}
+		ordinal, err := strconv.ParseInt(strings.TrimPrefix(pvcName, prefix), 10, 32)
+		if err != nil {
+			skipReason[pvcName] = skipReasonOrphanPVCsCleanerNotStatefulSetPVC
+			continue
+		}
+
+		set, err := opc.setLister.StatefulSets(ns).Get(setName)
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				return skipReason, fmt.Errorf("cluster %s/%s get statefulset %s failed, err: %v", ns, tcName, setName, err)
+			}
+			skipReason[pvcName] = skipReasonOrphanPVCsCleanerStatefulSetNotFound
+			continue
+		}
+		if set.Spec.Replicas == nil || int32(ordinal) < *set.Spec.Replicas {
+			skipReason[pvcName] = skipReasonOrphanPVCsCleanerOrdinalIsExpected
+			continue
+		}
+
+		podName := ordinalPodName(memberType, tcName, int32(ordinal))
+		_, err = opc.podLister.Pods(ns).Get(podName)
+		if err == nil {
+			skipReason[pvcName] = skipReasonOrphanPVCsCleanerPodIsFound
+			continue
+		}
+		if !errors.IsNotFound(err) {
+			return skipReason, fmt.Errorf("cluster %s/%s get pvc %s pod %s failed, err: %v", ns, tcName, pvcName, podName, err)
+		}
+
+		if pvc.DeletionTimestamp != nil {
+			skipReason[pvcName] = skipReasonOrphanPVCsCleanerPVCIsDeleting
+			continue
+		}
+
+		orphanedAt, err := time.Parse(time.RFC3339, pvc.Annotations[label.AnnPVCDeferDeleting])
+		if err != nil {
+			// the orphan pvc is not marked by the scaling in, e.g. the statefulset is scaled in manually
+			pvc = pvc.DeepCopy()
+			if pvc.Annotations == nil {
+				pvc.Annotations = map[string]string{}
+			}
+			now := time.Now().Format(time.RFC3339)
+			pvc.Annotations[label.AnnPVCDeferDeleting] = now
+			if _, err := opc.pvcControl.UpdatePVC(tc, pvc); err != nil {
+				return skipReason, fmt.Errorf("cluster %s/%s set pvc %s annotation %s to %s failed, err: %v", ns, tcName, pvcName, label.AnnPVCDeferDeleting, now, err)
+			}
+			log.Infof("orphan pvcs cleaner: set orphan pvc %s/%s annotation %s to %s", ns, pvcName, label.AnnPVCDeferDeleting, now)
+			skipReason[pvcName] = skipReasonOrphanPVCsCleanerInGracePeriod
+			continue
+		}
+		if time.Since(orphanedAt) < opc.gracePeriod {
+			skipReason[pvcName] = skipReasonOrphanPVCsCleanerInGracePeriod
+			continue
+		}
+
+		if err := opc.pvcControl.DeletePVC(tc, pvc); err != nil {
+			log.Errorf("orphan pvcs cleaner: failed to clean orphan pvc: %s/%s, %v", ns, pvcName, err)
+			return skipReason, err
+		}
+		metrics.ObserveCleanerAction(ns, tcName, metrics.OrphanPVCsCleaner, metrics.ActionDeletePVC)
+		log.Infof("orphan pvcs cleaner: clean orphan pvc: %s/%s orphaned at %s successfully", ns, pvcName, orphanedAt.Format(time.RFC3339))
+	}
+
+	return skipReason, nil
+}
+
+var _ OrphanPVCsCleaner = &orphanPVCsCleaner{}
+
+type fakeOrphanPVCsCleaner struct{}
+
+// NewFakeOrphanPVCsCleaner returns a fake orphan pvcs cleaner
+func NewFakeOrphanPVCsCleaner() OrphanPVCsCleaner {
+	return &fakeOrphanPVCsCleaner{}
+}
+
+func (fopc *fakeOrphanPVCsCleaner) Clean(_ *v1alpha1.TidbCluster) (map[string]string, error) {
+	return nil, nil
+}
@@ -0,0 +1,185 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestOrphanPVCsCleanerClean(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPD()
+	tcName := tc.GetName()
+	pdSetName := controller.PDMemberName(tcName)
+	newPVC := func(name string, l label.Label, orphanedAgo time.Duration) *corev1.PersistentVolumeClaim {
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: metav1.NamespaceDefault,
+				Labels:    l.Instance(tc.GetLabels()[label.InstanceLabelKey]).Labels(),
+			},
+		}
+		if orphanedAgo > 0 {
+			pvc.Annotations = map[string]string{label.AnnPVCDeferDeleting: time.Now().Add(-orphanedAgo).Format(time.RFC3339)}
+		}
+		return pvc
+	}
+
+	type testcase struct {
+		name          string
+		gracePeriod   time.Duration
+		pvcs          []*corev1.PersistentVolumeClaim
+		pods          []string
+		noStatefulSet bool
+		expectFn      func(*GomegaWithT, map[string]string, cache.Indexer)
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		opc, pvcIndexer, podIndexer, setIndexer := newFakeOrphanPVCsCleaner(test.gracePeriod)
+		for _, pvc := range test.pvcs {
+			g.Expect(pvcIndexer.Add(pvc)).To(Succeed())
+		}
+		for _, podName := range test.pods {
+			g.Expect(podIndexer.Add(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: podName, Namespace: metav1.NamespaceDefault}})).To(Succeed())
+		}
+		if !test.noStatefulSet {
+			replicas := int32(3)
+			set := &apps.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: pdSetName, Namespace: metav1.NamespaceDefault},
+				Spec:       apps.StatefulSetSpec{Replicas: &replicas},
+			}
+			g.Expect(setIndexer.Add(set)).To(Succeed())
+		}
+
+		skipReason, err := opc.Clean(tc)
+		g.Expect(err).NotTo(HaveOccurred())
+		test.expectFn(g, skipReason, pvcIndexer)
+	}
+	tests := []testcase{
+		{
+			name:        "disabled",
+			gracePeriod: 0,
+			pvcs:        []*corev1.PersistentVolumeClaim{newPVC("pd-"+pdSetName+"-3", label.New().PD(), time.Hour)},
+			expectFn: func(g *GomegaWithT, skipReason map[string]string, pvcIndexer cache.Indexer) {
+				g.Expect(skipReason).To(BeEmpty())
+				g.Expect(pvcIndexer.List()).To(HaveLen(1))
+			},
+		},
+		{
+			name:        "not pd or tikv",
+			gracePeriod: time.Minute,
+			pvcs:        []*corev1.PersistentVolumeClaim{newPVC("tidb-"+tcName+"-tidb-3", label.New().TiDB(), time.Hour)},
+			expectFn: func(g *GomegaWithT, skipReason map[string]string, pvcIndexer cache.Indexer) {
+				g.Expect(skipReason).To(Equal(map[string]string{"tidb-" + tcName + "-tidb-3": skipReasonOrphanPVCsCleanerIsNotPDOrTiKV}))
+			},
+		},
+		{
+			name:        "not created by the statefulset",
+			gracePeriod: time.Minute,
+			pvcs:        []*corev1.PersistentVolumeClaim{newPVC("backup-"+pdSetName, label.New().PD(), time.Hour)},
+			expectFn: func(g *GomegaWithT, skipReason map[string]string, pvcIndexer cache.Indexer) {
+				g.Expect(skipReason).To(Equal(map[string]string{"backup-" + pdSetName: skipReasonOrphanPVCsCleanerNotStatefulSetPVC}))
+			},
+		},
+		{
+			name:          "statefulset is not found",
+			gracePeriod:   time.Minute,
+			pvcs:          []*corev1.PersistentVolumeClaim{newPVC("pd-"+pdSetName+"-3", label.New().PD(), time.Hour)},
+			noStatefulSet: true,
+			expectFn: func(g *GomegaWithT, skipReason map[string]string, pvcIndexer cache.Indexer) {
+				g.Expect(skipReason).To(Equal(map[string]string{"pd-" + pdSetName + "-3": skipReasonOrphanPVCsCleanerStatefulSetNotFound}))
+			},
+		},
+		{
+			name:        "ordinal is expected",
+			gracePeriod: time.Minute,
+			pvcs:        []*corev1.PersistentVolumeClaim{newPVC("pd-"+pdSetName+"-2", label.New().PD(), time.Hour)},
+			expectFn: func(g *GomegaWithT, skipReason map[string]string, pvcIndexer cache.Indexer) {
+				g.Expect(skipReason).To(Equal(map[string]string{"pd-" + pdSetName + "-2": skipReasonOrphanPVCsCleanerOrdinalIsExpected}))
+			},
+		},
+		{
+			name:        "pod is found",
+			gracePeriod: time.Minute,
+			pvcs:        []*corev1.PersistentVolumeClaim{newPVC("pd-"+pdSetName+"-3", label.New().PD(), time.Hour)},
+			pods:        []string{ordinalPodName(v1alpha1.PDMemberType, tcName, 3)},
+			expectFn: func(g *GomegaWithT, skipReason map[string]string, pvcIndexer cache.Indexer) {
+				g.Expect(skipReason).To(Equal(map[string]string{"pd-" + pdSetName + "-3": skipReasonOrphanPVCsCleanerPodIsFound}))
+			},
+		},
+		{
+			name:        "orphan pvc is marked",
+			gracePeriod: time.Minute,
+			pvcs:        []*corev1.PersistentVolumeClaim{newPVC("pd-"+pdSetName+"-3", label.New().PD(), 0)},
+			expectFn: func(g *GomegaWithT, skipReason map[string]string, pvcIndexer cache.Indexer) {
+				g.Expect(skipReason).To(Equal(map[string]string{"pd-" + pdSetName + "-3": skipReasonOrphanPVCsCleanerInGracePeriod}))
+				obj, exist, err := pvcIndexer.GetByKey(metav1.NamespaceDefault + "/pd-" + pdSetName + "-3")
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(exist).To(BeTrue())
+				g.Expect(obj.(*corev1.PersistentVolumeClaim).Annotations).To(HaveKey(label.AnnPVCDeferDeleting))
+			},
+		},
+		{
+			name:        "orphan pvc in the grace period",
+			gracePeriod: 2 * time.Hour,
+			pvcs:        []*corev1.PersistentVolumeClaim{newPVC("pd-"+pdSetName+"-3", label.New().PD(), time.Hour)},
+			expectFn: func(g *GomegaWithT, skipReason map[string]string, pvcIndexer cache.Indexer) {
+				g.Expect(skipReason).To(Equal(map[string]string{"pd-" + pdSetName + "-3": skipReasonOrphanPVCsCleanerInGracePeriod}))
+				g.Expect(pvcIndexer.List()).To(HaveLen(1))
+			},
+		},
+		{
+			name:        "orphan pvc beyond the grace period",
+			gracePeriod: time.Minute,
+			pvcs: []*corev1.PersistentVolumeClaim{
+				newPVC("pd-"+pdSetName+"-3", label.New().PD(), time.Hour),
+				newPVC("pd-"+pdSetName+"-4", label.New().PD(), time.Hour),
+			},
+			expectFn: func(g *GomegaWithT, skipReason map[string]string, pvcIndexer cache.Indexer) {
+				g.Expect(skipReason).To(BeEmpty())
+				g.Expect(pvcIndexer.List()).To(BeEmpty())
+			},
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func newFakeOrphanPVCsCleaner(gracePeriod time.Duration) (*orphanPVCsCleaner, cache.Indexer, cache.Indexer, cache.Indexer) {
+	kubeCli := kubefake.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeCli, 0)
+	podInformer := kubeInformerFactory.Core().V1().Pods()
+	pvcInformer := kubeInformerFactory.Core().V1().PersistentVolumeClaims()
+	setInformer := kubeInformerFactory.Apps().V1().StatefulSets()
+	pvcControl := controller.NewFakePVCControl(pvcInformer)
+
+	return &orphanPVCsCleaner{podInformer.Lister(), pvcInformer.Lister(), pvcControl, setInformer.Lister(), gracePeriod},
+		pvcInformer.Informer().GetIndexer(), podInformer.Informer().GetIndexer(), setInformer.Informer().GetIndexer()
+}
@@ -18,10 +18,10 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	"github.com/pingcap/tidb-operator/pkg/pdapi"
 	"github.com/pingcap/tidb-operator/pkg/util"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -101,7 +101,7 @@ func (pf *pdFailover) Failover(tc *v1alpha1.TidbCluster) error {
 
 func (pf *pdFailover) Recover(tc *v1alpha1.TidbCluster) {
 	tc.Status.PD.FailureMembers = nil
-	glog.Infof("pd failover: clearing pd failoverMembers, %s/%s", tc.GetNamespace(), tc.GetName())
+	log.Infof("pd failover: clearing pd failoverMembers, %s/%s", tc.GetNamespace(), tc.GetName())
 }
 
 func (pf *pdFailover) tryToMarkAPeerAsFailure(tc *v1alpha1.TidbCluster) error {
@@ -169,10 +169,10 @@ func (pf *pdFailover) tryToDeleteAFailureMember(tc *v1alpha1.TidbCluster) error
 	// invoke deleteMember api to delete a member from the pd cluster
 	err = controller.GetPDClient(pf.pdControl, tc).DeleteMemberByID(memberID)
 	if err != nil {
-		glog.Errorf("pd failover: failed to delete member: %d, %v", memberID, err)
+		log.Errorf("pd failover: failed to delete member: %d, %v", memberID, err)
 		return err
 	}
-	glog.Infof("pd failover: delete member: %d successfully", memberID)
+	log.Infof("pd failover: delete member: %d successfully", memberID)
 
 	// The order of old PVC deleting and the new Pod creating is not guaranteed by Kubernetes.
 	// If new Pod is created before old PVC deleted, new Pod will reuse old PVC.
@@ -202,10 +202,10 @@ func (pf *pdFailover) tryToDeleteAFailureMember(tc *v1alpha1.TidbCluster) error
 	if pvc != nil && pvc.DeletionTimestamp == nil && pvc.GetUID() == failureMember.PVCUID {
 		err = pf.pvcControl.DeletePVC(tc, pvc)
 		if err != nil {
-			glog.Errorf("pd failover: failed to delete pvc: %s/%s, %v", ns, pvcName, err)
+			log.Errorf("pd failover: failed to delete pvc: %s/%s, %v", ns, pvcName, err)
 			return err
 		}
-		glog.Infof("pd failover: pvc: %s/%s successfully", ns, pvcName)
+		log.Infof("pd failover: pvc: %s/%s successfully", ns, pvcName)
 	}
 
 	setMemberDeleted(tc, failurePodName)
@@ -216,7 +216,7 @@ func setMemberDeleted(tc *v1alpha1.TidbCluster, podName string) {
 	failureMember := tc.Status.PD.FailureMembers[podName]
 	failureMember.MemberDeleted = true
 	tc.Status.PD.FailureMembers[podName] = failureMember
-	glog.Infof("pd failover: set pd member: %s/%s deleted", tc.GetName(), podName)
+	log.Infof("pd failover: set pd member: %s/%s deleted", tc.GetName(), podName)
 }
 
 type fakePDFailover struct{}
@@ -15,22 +15,24 @@ package member
 
 import (
 	"fmt"
+	"reflect"
 	"strconv"
+	"strings"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	"github.com/pingcap/tidb-operator/pkg/manager"
 	"github.com/pingcap/tidb-operator/pkg/pdapi"
 	"github.com/pingcap/tidb-operator/pkg/util"
-	apps "k8s.io/api/apps/v1beta1"
+	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
-	"k8s.io/client-go/listers/apps/v1beta1"
+	appslisters "k8s.io/client-go/listers/apps/v1"
 	corelisters "k8s.io/client-go/listers/core/v1"
 )
 
@@ -38,7 +40,7 @@ type pdMemberManager struct {
 	pdControl    pdapi.PDControlInterface
 	setControl   controller.StatefulSetControlInterface
 	svcControl   controller.ServiceControlInterface
-	setLister    v1beta1.StatefulSetLister
+	setLister    appslisters.StatefulSetLister
 	svcLister    corelisters.ServiceLister
 	podLister    corelisters.PodLister
 	epsLister    corelisters.EndpointsLister
@@ -54,7 +56,7 @@ type pdMemberManager struct {
 func NewPDMemberManager(pdControl pdapi.PDControlInterface,
 	setControl controller.StatefulSetControlInterface,
 	svcControl controller.ServiceControlInterface,
-	setLister v1beta1.StatefulSetLister,
+	setLister appslisters.StatefulSetLister,
 	svcLister corelisters.ServiceLister,
 	podLister corelisters.PodLister,
 	epsLister corelisters.EndpointsLister,
@@ -92,7 +94,56 @@ func (pmm *pdMemberManager) Sync(tc *v1alpha1.TidbCluster) error {
 	}
 
 	// Sync PD StatefulSet
-	return pmm.syncPDStatefulSetForTidbCluster(tc)
+	if err := pmm.syncPDStatefulSetForTidbCluster(tc); err != nil {
+		return err
+	}
+
+	// Sync PD replication config
+	if err := pmm.syncReplicationConfig(tc); err != nil {
+		return err
+	}
+
+	// Sync PD placement rules
+	return pmm.syncPlacementRules(tc)
+}
+
+// syncReplicationConfig reconciles the location-labels of PD to the topology
+// spread constraints and the max-replicas to the spec, so that the labels of
+// the TiKV stores match the PD config
+func (pmm *pdMemberManager) syncReplicationConfig(tc *v1alpha1.TidbCluster) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	if len(tc.Spec.TopologySpreadConstraints) == 0 && tc.Spec.PD.MaxReplicas == nil {
+		return nil
+	}
+	if !tc.Status.PD.Synced {
+		return nil
+	}
+
+	pdClient := controller.GetPDClient(pmm.pdControl, tc)
+	config, err := pdClient.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	replication := map[string]interface{}{}
+	locationLabels := tc.PDLocationLabels()
+	if len(locationLabels) > 0 && !reflect.DeepEqual([]string(config.Replication.LocationLabels), locationLabels) {
+		replication["location-labels"] = strings.Join(locationLabels, ",")
+	}
+	if maxReplicas := tc.Spec.PD.MaxReplicas; maxReplicas != nil && config.Replication.MaxReplicas != uint64(*maxReplicas) {
+		replication["max-replicas"] = *maxReplicas
+	}
+	if len(replication) == 0 {
+		return nil
+	}
+
+	if err := pdClient.UpdateReplicationConfig(replication); err != nil {
+		return err
+	}
+	log.ForCluster(ns, tcName).WithComponent("pd").Infof("PD replication config %v is updated", replication)
+	return nil
 }
 
 func (pmm *pdMemberManager) syncPDServiceForTidbCluster(tc *v1alpha1.TidbCluster) error {
@@ -114,24 +165,7 @@ func (pmm *pdMemberManager) syncPDServiceForTidbCluster(tc *v1alpha1.TidbCluster
 
 	oldSvc := oldSvcTmp.DeepCopy()
 
-	equal, err := serviceEqual(newSvc, oldSvc)
-	if err != nil {
-		return err
-	}
-	if !equal {
-		svc := *oldSvc
-		svc.Spec = newSvc.Spec
-		// TODO add unit test
-		svc.Spec.ClusterIP = oldSvc.Spec.ClusterIP
-		err = SetServiceLastAppliedConfigAnnotation(&svc)
-		if err != nil {
-			return err
-		}
-		_, err = pmm.svcControl.UpdateService(tc, &svc)
-		return err
-	}
-
-	return nil
+	return updateService(pmm.svcControl, tc, newSvc, oldSvc)
 }
 
 func (pmm *pdMemberManager) syncPDHeadlessServiceForTidbCluster(tc *v1alpha1.TidbCluster) error {
@@ -151,22 +185,7 @@ func (pmm *pdMemberManager) syncPDHeadlessServiceForTidbCluster(tc *v1alpha1.Tid
 		return err
 	}
 
-	equal, err := serviceEqual(newSvc, oldSvc)
-	if err != nil {
-		return err
-	}
-	if !equal {
-		svc := *oldSvc
-		svc.Spec = newSvc.Spec
-		err = SetServiceLastAppliedConfigAnnotation(newSvc)
-		if err != nil {
-			return err
-		}
-		_, err = pmm.svcControl.UpdateService(tc, &svc)
-		return err
-	}
-
-	return nil
+	return updateService(pmm.svcControl, tc, newSvc, oldSvc)
 }
 
 func (pmm *pdMemberManager) syncPDStatefulSetForTidbCluster(tc *v1alpha1.TidbCluster) error {
@@ -197,7 +216,7 @@ func (pmm *pdMemberManager) syncPDStatefulSetForTidbCluster(tc *v1alpha1.TidbClu
 	oldPDSet := oldPDSetTmp.DeepCopy()
 
 	if err := pmm.syncTidbClusterStatus(tc, oldPDSet); err != nil {
-		glog.Errorf("failed to sync TidbCluster: [%s/%s]'s status, error: %v", ns, tcName, err)
+		log.ForCluster(ns, tcName).WithComponent("pd").Errorf("failed to sync TidbCluster status, error: %v", err)
 	}
 
 	if !tc.Status.PD.Synced {
@@ -227,7 +246,8 @@ func (pmm *pdMemberManager) syncPDStatefulSetForTidbCluster(tc *v1alpha1.TidbClu
 		}
 	}
 
-	if pmm.autoFailover {
+	// the failover is paused in the recovery mode, the failed members are recovered manually
+	if pmm.autoFailover && !tc.RecoveryModeEnabled() {
 		if tc.PDAllPodsStarted() && tc.PDAllMembersReady() && tc.Status.PD.FailureMembers != nil {
 			pmm.pdFailover.Recover(tc)
 		} else if tc.PDAllPodsStarted() && !tc.PDAllMembersReady() || tc.PDAutoFailovering() {
@@ -240,20 +260,7 @@ func (pmm *pdMemberManager) syncPDStatefulSetForTidbCluster(tc *v1alpha1.TidbClu
 	return pmm.updateStatefulSet(tc, newPDSet, oldPDSet)
 }
 func (pmm *pdMemberManager) updateStatefulSet(tc *v1alpha1.TidbCluster, newPDSet, oldPDSet *apps.StatefulSet) error {
-	if !statefulSetEqual(*newPDSet, *oldPDSet) {
-		set := *oldPDSet
-		set.Spec.Template = newPDSet.Spec.Template
-		*set.Spec.Replicas = *newPDSet.Spec.Replicas
-		set.Spec.UpdateStrategy = newPDSet.Spec.UpdateStrategy
-		err := SetLastAppliedConfigAnnotation(&set)
-		if err != nil {
-			return err
-		}
-		_, err = pmm.setControl.UpdateStatefulSet(tc, &set)
-		return err
-	}
-
-	return nil
+	return updateStatefulSet(pmm.setControl, tc, newPDSet, oldPDSet)
 }
 
 func (pmm *pdMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, set *apps.StatefulSet) error {
@@ -270,6 +277,7 @@ func (pmm *pdMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, set
 		tc.Status.PD.Phase = v1alpha1.UpgradePhase
 	} else {
 		tc.Status.PD.Phase = v1alpha1.NormalPhase
+		tc.Status.PD.Upgrade = nil
 	}
 
 	pdClient := controller.GetPDClient(pmm.pdControl, tc)
@@ -310,7 +318,7 @@ func (pmm *pdMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, set
 		}
 		name := memberHealth.Name
 		if len(name) == 0 {
-			glog.Warningf("PD member: [%d] doesn't have a name, and can't get it from clientUrls: [%s], memberHealth Info: [%v] in [%s/%s]",
+			log.Warningf("PD member: [%d] doesn't have a name, and can't get it from clientUrls: [%s], memberHealth Info: [%v] in [%s/%s]",
 				id, memberHealth.ClientUrls, memberHealth, ns, tcName)
 			continue
 		}
@@ -387,12 +395,15 @@ func (pmm *pdMemberManager) getNewPDHeadlessServiceForTidbCluster(tc *v1alpha1.T
 			Ports: []corev1.ServicePort{
 				{
 					Name:       "peer",
-					Port:       2380,
-					TargetPort: intstr.FromInt(2380),
+					Port:       pdPeerPort,
+					TargetPort: intstr.FromInt(pdPeerPort),
 					Protocol:   corev1.ProtocolTCP,
 				},
 			},
 			Selector: pdLabel,
+			// the PD members wait for their own domain before they start, while the
+			// pods are not ready until the service mesh sidecars are ready
+			PublishNotReadyAddresses: tc.ServiceMeshEnabled(),
 		},
 	}
 }
@@ -487,7 +498,7 @@ func (pmm *pdMemberManager) getNewPDSetForTidbCluster(tc *v1alpha1.TidbCluster)
 	}
 	pdLabel := label.New().Instance(instanceName).PD()
 	setName := controller.PDMemberName(tcName)
-	podAnnotations := CombineAnnotations(controller.AnnProm(2379), tc.Spec.PD.Annotations)
+	podAnnotations := CombineAnnotations(CombineAnnotations(controller.AnnProm(2379), serviceMeshAnnotations(tc, pdPeerPort)), tc.Spec.PD.Annotations)
 	storageClassName := tc.Spec.PD.StorageClassName
 	if storageClassName == "" {
 		storageClassName = controller.DefaultStorageClassName
@@ -521,7 +532,7 @@ func (pmm *pdMemberManager) getNewPDSetForTidbCluster(tc *v1alpha1.TidbCluster)
 				},
 				Spec: corev1.PodSpec{
 					SchedulerName: tc.Spec.SchedulerName,
-					Affinity:      tc.Spec.PD.Affinity,
+					Affinity:      topologySpreadAffinity(tc.Spec.PD.Affinity, tc.Spec.TopologySpreadConstraints, pdLabel),
 					NodeSelector:  tc.Spec.PD.NodeSelector,
 					HostNetwork:   tc.Spec.PD.HostNetwork,
 					DNSPolicy:     dnsPolicy,
@@ -584,7 +595,7 @@ func (pmm *pdMemberManager) getNewPDSetForTidbCluster(tc *v1alpha1.TidbCluster)
 					RestartPolicy:     corev1.RestartPolicyAlways,
 					Tolerations:       tc.Spec.PD.Tolerations,
 					Volumes:           vols,
-					SecurityContext:   tc.Spec.PD.PodSecurityContext,
+					SecurityContext:   podSecurityContext(tc.Spec.PD.PodSecurityContext),
 					PriorityClassName: tc.Spec.PD.PriorityClassName,
 				},
 			},
@@ -616,6 +627,34 @@ func (pmm *pdMemberManager) getNewPDSetForTidbCluster(tc *v1alpha1.TidbCluster)
 		},
 	}
 
+	if tc.PDMSEnabled() {
+		// the pd members only serve the api in the microservice mode, it's
+		// only set in this mode so that the existing pods are not restarted
+		container := &pdSet.Spec.Template.Spec.Containers[0]
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name:  "PD_MODE",
+			Value: string(tc.Spec.PD.Mode),
+		})
+	}
+
+	if tc.Spec.ClusterDomain != "" {
+		// the pd members advertise the FQDN under the cluster domain
+		container := &pdSet.Spec.Template.Spec.Containers[0]
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name:  "CLUSTER_DOMAIN",
+			Value: tc.Spec.ClusterDomain,
+		})
+	}
+
+	if tc.RecoveryModeEnabled() && tc.Spec.RecoveryMode.PDForceNewCluster {
+		// the first pd member is started with --force-new-cluster by the startup script
+		container := &pdSet.Spec.Template.Spec.Containers[0]
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name:  "PD_FORCE_NEW_CLUSTER",
+			Value: "true",
+		})
+	}
+
 	return pdSet, nil
 }
 
@@ -20,13 +20,15 @@ import (
 
 	. "github.com/onsi/gomega"
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/pkg/typeutil"
+	"github.com/pingcap/pd/server"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned/fake"
 	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/label"
 	"github.com/pingcap/tidb-operator/pkg/pdapi"
-	apps "k8s.io/api/apps/v1beta1"
+	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -766,10 +768,95 @@ func TestPDMemberManagerSyncPDSts(t *testing.T) {
 	}
 }
 
+func TestPDMemberManagerSyncReplicationConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPD()
+	tc.Status.PD.Synced = true
+	pmm, _, _, pdControl, _, _, _ := newFakePDMemberManager()
+	pdClient := controller.NewFakePDClient(pdControl, tc)
+
+	config := &server.Config{
+		Replication: server.ReplicationConfig{
+			MaxReplicas:    3,
+			LocationLabels: typeutil.StringSlice{"region", "zone", "rack", "host"},
+		},
+	}
+	var updated map[string]interface{}
+	pdClient.AddReaction(pdapi.GetConfigActionType, func(action *pdapi.Action) (interface{}, error) {
+		return config, nil
+	})
+	pdClient.AddReaction(pdapi.UpdateReplicationConfigActionType, func(action *pdapi.Action) (interface{}, error) {
+		updated = action.Config
+		return nil, nil
+	})
+
+	// nothing is reconciled without the topology spread constraints and max replicas
+	g.Expect(pmm.syncReplicationConfig(tc)).To(Succeed())
+	g.Expect(updated).To(BeNil())
+
+	tc.Spec.TopologySpreadConstraints = []v1alpha1.TopologySpreadConstraint{
+		{TopologyKey: "zone"},
+		{TopologyKey: corev1.LabelHostname},
+	}
+	maxReplicas := int32(5)
+	tc.Spec.PD.MaxReplicas = &maxReplicas
+	g.Expect(pmm.syncReplicationConfig(tc)).To(Succeed())
+	g.Expect(updated).To(Equal(map[string]interface{}{
+		"location-labels": "zone,host",
+		"max-replicas":    int32(5),
+	}))
+
+	// the config is not updated if it matches the spec
+	updated = nil
+	config.Replication.MaxReplicas = 5
+	config.Replication.LocationLabels = typeutil.StringSlice{"zone", "host"}
+	g.Expect(pmm.syncReplicationConfig(tc)).To(Succeed())
+	g.Expect(updated).To(BeNil())
+}
+
+func TestGetNewPDSetWithTopologySpreadConstraints(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPD()
+	tc.Spec.TopologySpreadConstraints = []v1alpha1.TopologySpreadConstraint{
+		{TopologyKey: "zone"},
+		{TopologyKey: corev1.LabelHostname},
+	}
+	pmm, _, _, _, _, _, _ := newFakePDMemberManager()
+	set, err := pmm.getNewPDSetForTidbCluster(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	terms := set.Spec.Template.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	g.Expect(terms).To(HaveLen(2))
+	g.Expect(terms[0].PodAffinityTerm.TopologyKey).To(Equal("zone"))
+	g.Expect(terms[1].PodAffinityTerm.TopologyKey).To(Equal(corev1.LabelHostname))
+	g.Expect(terms[0].PodAffinityTerm.LabelSelector.MatchLabels).To(Equal(set.Spec.Template.Labels))
+	// the affinity in the spec is not changed
+	g.Expect(tc.Spec.PD.Affinity).To(BeNil())
+}
+
+func TestGetNewPDSetWithClusterDomain(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPD()
+	pmm, _, _, _, _, _, _ := newFakePDMemberManager()
+	set, err := pmm.getNewPDSetForTidbCluster(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	for _, env := range set.Spec.Template.Spec.Containers[0].Env {
+		g.Expect(env.Name).NotTo(Equal("CLUSTER_DOMAIN"))
+	}
+
+	tc.Spec.ClusterDomain = "cluster.local"
+	set, err = pmm.getNewPDSetForTidbCluster(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(set.Spec.Template.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "CLUSTER_DOMAIN", Value: "cluster.local"}))
+}
+
 func newFakePDMemberManager() (*pdMemberManager, *controller.FakeStatefulSetControl, *controller.FakeServiceControl, *pdapi.FakePDControl, cache.Indexer, cache.Indexer, *controller.FakePodControl) {
 	cli := fake.NewSimpleClientset()
 	kubeCli := kubefake.NewSimpleClientset()
-	setInformer := kubeinformers.NewSharedInformerFactory(kubeCli, 0).Apps().V1beta1().StatefulSets()
+	setInformer := kubeinformers.NewSharedInformerFactory(kubeCli, 0).Apps().V1().StatefulSets()
 	svcInformer := kubeinformers.NewSharedInformerFactory(kubeCli, 0).Core().V1().Services()
 	podInformer := kubeinformers.NewSharedInformerFactory(kubeCli, 0).Core().V1().Pods()
 	epsInformer := kubeinformers.NewSharedInformerFactory(kubeCli, 0).Core().V1().Endpoints()
@@ -0,0 +1,414 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/manager"
+	"github.com/pingcap/tidb-operator/pkg/util"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+)
+
+const (
+	pdMSPort = 2379
+)
+
+// pdMSMemberTypes are the pd microservices in the order they are synced
+var pdMSMemberTypes = []v1alpha1.MemberType{
+	v1alpha1.PDTSOMemberType,
+	v1alpha1.PDSchedulingMemberType,
+}
+
+type pdMSMemberManager struct {
+	setControl   controller.StatefulSetControlInterface
+	svcControl   controller.ServiceControlInterface
+	setLister    appslisters.StatefulSetLister
+	svcLister    corelisters.ServiceLister
+	podLister    corelisters.PodLister
+	pdMSUpgrader Upgrader
+}
+
+// NewPDMSMemberManager returns a *pdMSMemberManager which manages the tso and
+// scheduling microservices of pd in the microservice mode
+func NewPDMSMemberManager(setControl controller.StatefulSetControlInterface,
+	svcControl controller.ServiceControlInterface,
+	setLister appslisters.StatefulSetLister,
+	svcLister corelisters.ServiceLister,
+	podLister corelisters.PodLister,
+	pdMSUpgrader Upgrader) manager.Manager {
+	return &pdMSMemberManager{
+		setControl:   setControl,
+		svcControl:   svcControl,
+		setLister:    setLister,
+		svcLister:    svcLister,
+		podLister:    podLister,
+		pdMSUpgrader: pdMSUpgrader,
+	}
+}
+
+func (pmm *pdMSMemberManager) Sync(tc *v1alpha1.TidbCluster) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	if !tc.PDMSEnabled() {
+		return nil
+	}
+
+	for _, memberType := range pdMSMemberTypes {
+		if tc.PDMSSpecFor(memberType) == nil {
+			return fmt.Errorf("TidbCluster: [%s/%s], spec.pd.%s is required in the pd microservice mode", ns, tcName, memberType)
+		}
+	}
+
+	// the microservices register themselves to the pd members serving the api
+	if !tc.PDIsAvailable() {
+		return controller.RequeueErrorf("TidbCluster: [%s/%s], waiting for PD cluster running", ns, tcName)
+	}
+
+	for _, memberType := range pdMSMemberTypes {
+		if err := pmm.syncHeadlessService(tc, memberType); err != nil {
+			return err
+		}
+		if err := pmm.syncStatefulSet(tc, memberType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (pmm *pdMSMemberManager) syncHeadlessService(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType) error {
+	newSvc := getNewPDMSHeadlessService(tc, memberType)
+	oldSvcTmp, err := pmm.svcLister.Services(newSvc.Namespace).Get(newSvc.Name)
+	if errors.IsNotFound(err) {
+		err = SetServiceLastAppliedConfigAnnotation(newSvc)
+		if err != nil {
+			return err
+		}
+		return pmm.svcControl.CreateService(tc, newSvc)
+	}
+	if err != nil {
+		return err
+	}
+
+	oldSvc := oldSvcTmp.DeepCopy()
+	return updateService(pmm.svcControl, tc, newSvc, oldSvc)
+}
+
+func (pmm *pdMSMemberManager) syncStatefulSet(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	status := tc.PDMSStatusFor(memberType)
+
+	newSet := getNewPDMSStatefulSet(tc, memberType)
+	oldSetTmp, err := pmm.setLister.StatefulSets(ns).Get(controller.PDMSMemberName(tcName, memberType))
+	if errors.IsNotFound(err) {
+		err = SetLastAppliedConfigAnnotation(newSet)
+		if err != nil {
+			return err
+		}
+		err = pmm.setControl.CreateStatefulSet(tc, newSet)
+		if err != nil {
+			return err
+		}
+		status.StatefulSet = &apps.StatefulSetStatus{}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	oldSet := oldSetTmp.DeepCopy()
+
+	if err = pmm.syncTidbClusterStatus(tc, memberType, oldSet); err != nil {
+		return err
+	}
+
+	if err = pmm.scale(tc, memberType, oldSet, newSet); err != nil {
+		return err
+	}
+
+	if !templateEqual(newSet.Spec.Template, oldSet.Spec.Template) || status.Phase == v1alpha1.UpgradePhase {
+		if err := pmm.pdMSUpgrader.Upgrade(tc, oldSet, newSet); err != nil {
+			return err
+		}
+	}
+
+	return updateStatefulSet(pmm.setControl, tc, newSet, oldSet)
+}
+
+// scale sets the replicas of the new statefulset. The members are scaled out at
+// once, but scaled in one by one and only when all the members are healthy, so
+// that the primary of the service can always be elected from the remaining members.
+func (pmm *pdMSMemberManager) scale(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, oldSet, newSet *apps.StatefulSet) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	current := *oldSet.Spec.Replicas
+	desired := *newSet.Spec.Replicas
+	if desired >= current {
+		return nil
+	}
+
+	status := tc.PDMSStatusFor(memberType)
+	if status.Phase == v1alpha1.UpgradePhase {
+		return controller.RequeueErrorf("TidbCluster: [%s/%s]'s pd %s is upgrading, can not scale in until the upgrade completed",
+			ns, tcName, memberType)
+	}
+	for i := int32(0); i < current; i++ {
+		podName := pdMSPodName(tcName, i, memberType)
+		if member, exist := status.Members[podName]; !exist || !member.Health {
+			return controller.RequeueErrorf("TidbCluster: [%s/%s]'s pd %s member %s is not healthy, can not scale in",
+				ns, tcName, memberType, podName)
+		}
+	}
+
+	replicas := current - 1
+	*newSet.Spec.Replicas = replicas
+	setUpgradePartition(newSet, replicas)
+	return nil
+}
+
+func (pmm *pdMSMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, set *apps.StatefulSet) error {
+	status := tc.PDMSStatusFor(memberType)
+	status.StatefulSet = &set.Status
+
+	selector, err := pdMSLabel(tc, memberType).Selector()
+	if err != nil {
+		return err
+	}
+	pods, err := pmm.podLister.Pods(tc.GetNamespace()).List(selector)
+	if err != nil {
+		return err
+	}
+
+	upgrading := statefulSetIsUpgrading(set)
+	members := map[string]v1alpha1.PDMSMember{}
+	for _, pod := range pods {
+		if revision, exist := pod.Labels[apps.ControllerRevisionHashLabelKey]; exist && revision != set.Status.UpdateRevision {
+			upgrading = true
+		}
+
+		// the microservices keep no membership of their own, a member is
+		// healthy if its pod is ready
+		newMember := v1alpha1.PDMSMember{
+			Name:   pod.Name,
+			Health: podReady(tc, pod, memberType.String()),
+		}
+		newMember.LastTransitionTime = metav1.Now()
+		if oldMember, exist := status.Members[pod.Name]; exist && oldMember.Health == newMember.Health {
+			newMember.LastTransitionTime = oldMember.LastTransitionTime
+		}
+		members[pod.Name] = newMember
+	}
+	status.Members = members
+
+	if upgrading && !tc.PDUpgrading() {
+		status.Phase = v1alpha1.UpgradePhase
+	} else {
+		status.Phase = v1alpha1.NormalPhase
+	}
+	return nil
+}
+
+func pdMSLabel(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType) label.Label {
+	l := label.New().Instance(tc.GetLabels()[label.InstanceLabelKey])
+	if memberType == v1alpha1.PDTSOMemberType {
+		return l.PDTSO()
+	}
+	return l.PDScheduling()
+}
+
+func getNewPDMSHeadlessService(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType) *corev1.Service {
+	msLabel := pdMSLabel(tc, memberType).Labels()
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            controller.PDMSPeerMemberName(tc.Name, memberType),
+			Namespace:       tc.Namespace,
+			Labels:          msLabel,
+			OwnerReferences: []metav1.OwnerReference{controller.GetOwnerRef(tc)},
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: "None",
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "client",
+					Port:       pdMSPort,
+					TargetPort: intstr.FromInt(pdMSPort),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+			Selector:                 msLabel,
+			PublishNotReadyAddresses: true,
+		},
+	}
+}
+
+func getNewPDMSStatefulSet(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType) *apps.StatefulSet {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	spec := tc.PDMSSpecFor(memberType)
+	setName := controller.PDMSMemberName(tcName, memberType)
+	peerName := controller.PDMSPeerMemberName(tcName, memberType)
+
+	image := spec.Image
+	if image == "" {
+		image = tc.Spec.PD.Image
+	}
+	imagePullPolicy := spec.ImagePullPolicy
+	if imagePullPolicy == "" {
+		imagePullPolicy = tc.Spec.PD.ImagePullPolicy
+	}
+
+	scheme := tc.Scheme()
+	args := []string{
+		"services",
+		memberType.String(),
+		"--name=$(POD_NAME)",
+		fmt.Sprintf("--listen-addr=%s://0.0.0.0:%d", scheme, pdMSPort),
+		fmt.Sprintf("--advertise-listen-addr=%s://$(POD_NAME).%s.$(NAMESPACE).svc:%d", scheme, peerName, pdMSPort),
+		fmt.Sprintf("--backend-endpoints=%s://%s:2379", scheme, controller.PDMemberName(tcName)),
+	}
+
+	var volMounts []corev1.VolumeMount
+	var vols []corev1.Volume
+	if tc.Spec.EnableTLSCluster {
+		tlsDir := fmt.Sprintf("/var/lib/%s-tls", memberType)
+		args = append(args,
+			"--cacert=/var/run/secrets/kubernetes.io/serviceaccount/ca.crt",
+			fmt.Sprintf("--cert=%s/%s.crt", tlsDir, memberType),
+			fmt.Sprintf("--key=%s/%s.key", tlsDir, memberType),
+		)
+		volMounts = append(volMounts, corev1.VolumeMount{
+			Name: fmt.Sprintf("%s-tls", memberType), ReadOnly: true, MountPath: tlsDir,
+		})
+		vols = append(vols, corev1.Volume{
+			Name: fmt.Sprintf("%s-tls", memberType), VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: setName,
+				},
+			},
+		})
+	}
+
+	dnsPolicy := corev1.DNSClusterFirst // same as k8s defaults
+	if spec.HostNetwork {
+		dnsPolicy = corev1.DNSClusterFirstWithHostNet
+	}
+
+	msLabel := pdMSLabel(tc, memberType)
+	return &apps.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            setName,
+			Namespace:       ns,
+			Labels:          msLabel.Labels(),
+			OwnerReferences: []metav1.OwnerReference{controller.GetOwnerRef(tc)},
+		},
+		Spec: apps.StatefulSetSpec{
+			Replicas: controller.Int32Ptr(spec.Replicas),
+			Selector: msLabel.LabelSelector(),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      msLabel.Labels(),
+					Annotations: CombineAnnotations(controller.AnnProm(pdMSPort), spec.Annotations),
+				},
+				Spec: corev1.PodSpec{
+					SchedulerName: tc.Spec.SchedulerName,
+					Affinity:      spec.Affinity,
+					NodeSelector:  spec.NodeSelector,
+					HostNetwork:   spec.HostNetwork,
+					DNSPolicy:     dnsPolicy,
+					Containers: []corev1.Container{
+						{
+							Name:            memberType.String(),
+							Image:           image,
+							ImagePullPolicy: imagePullPolicy,
+							Command:         []string{"/pd-server"},
+							Args:            args,
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "client",
+									ContainerPort: int32(pdMSPort),
+									Protocol:      corev1.ProtocolTCP,
+								},
+							},
+							VolumeMounts: volMounts,
+							Resources:    util.ResourceRequirement(spec.ContainerSpec),
+							Env: []corev1.EnvVar{
+								{
+									Name: "NAMESPACE",
+									ValueFrom: &corev1.EnvVarSource{
+										FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"},
+									},
+								},
+								{
+									Name: "POD_NAME",
+									ValueFrom: &corev1.EnvVarSource{
+										FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+									},
+								},
+								{
+									Name:  "TZ",
+									Value: tc.Spec.Timezone,
+								},
+							},
+							ReadinessProbe: &corev1.Probe{
+								Handler: corev1.Handler{
+									TCPSocket: &corev1.TCPSocketAction{
+										Port: intstr.FromInt(pdMSPort),
+									},
+								},
+								InitialDelaySeconds: int32(10),
+							},
+						},
+					},
+					RestartPolicy:     corev1.RestartPolicyAlways,
+					Tolerations:       spec.Tolerations,
+					Volumes:           vols,
+					SecurityContext:   podSecurityContext(spec.PodSecurityContext),
+					PriorityClassName: spec.PriorityClassName,
+				},
+			},
+			ServiceName:         peerName,
+			PodManagementPolicy: apps.ParallelPodManagement,
+			UpdateStrategy: apps.StatefulSetUpdateStrategy{Type: apps.RollingUpdateStatefulSetStrategyType,
+				RollingUpdate: &apps.RollingUpdateStatefulSetStrategy{Partition: controller.Int32Ptr(spec.Replicas)},
+			},
+		},
+	}
+}
+
+type FakePDMSMemberManager struct {
+	err error
+}
+
+func NewFakePDMSMemberManager() *FakePDMSMemberManager {
+	return &FakePDMSMemberManager{}
+}
+
+func (fpmm *FakePDMSMemberManager) SetSyncError(err error) {
+	fpmm.err = err
+}
+
+func (fpmm *FakePDMSMemberManager) Sync(_ *v1alpha1.TidbCluster) error {
+	return fpmm.err
+}
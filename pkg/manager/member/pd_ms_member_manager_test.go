@@ -0,0 +1,181 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned/fake"
+	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	apps "k8s.io/api/apps/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPDMSMemberManagerSync(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPDMS()
+	pmm, setControl := newFakePDMSMemberManager()
+
+	// nothing is synced in the normal mode
+	normal := tc.DeepCopy()
+	normal.Spec.PD.Mode = v1alpha1.PDModeNormal
+	g.Expect(pmm.Sync(normal)).To(Succeed())
+	_, err := setControl.SetLister.StatefulSets(tc.Namespace).Get(controller.PDMSMemberName(tc.Name, v1alpha1.PDTSOMemberType))
+	g.Expect(err).To(HaveOccurred())
+
+	// the spec of the microservices is required
+	missing := tc.DeepCopy()
+	missing.Spec.PD.Scheduling = nil
+	g.Expect(pmm.Sync(missing)).NotTo(Succeed())
+
+	// waiting for pd
+	unavailable := tc.DeepCopy()
+	unavailable.Status.PD.Members = nil
+	err = pmm.Sync(unavailable)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+
+	g.Expect(pmm.Sync(tc)).To(Succeed())
+	for _, memberType := range pdMSMemberTypes {
+		_, err = pmm.svcLister.Services(tc.Namespace).Get(controller.PDMSPeerMemberName(tc.Name, memberType))
+		g.Expect(err).NotTo(HaveOccurred())
+		set, err := setControl.SetLister.StatefulSets(tc.Namespace).Get(controller.PDMSMemberName(tc.Name, memberType))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(*set.Spec.Replicas).To(Equal(int32(2)))
+		g.Expect(tc.PDMSStatusFor(memberType).StatefulSet).NotTo(BeNil())
+	}
+}
+
+func TestPDMSMemberManagerScaleIn(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPDMS()
+	pmm, _ := newFakePDMSMemberManager()
+	memberType := v1alpha1.PDTSOMemberType
+
+	oldSet := getNewPDMSStatefulSet(tc, memberType)
+	*oldSet.Spec.Replicas = 3
+	newSet := getNewPDMSStatefulSet(tc, memberType)
+	*newSet.Spec.Replicas = 1
+
+	// the members are not healthy
+	err := pmm.scale(tc, memberType, oldSet, newSet)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+
+	tc.Status.PD.TSO.Members = map[string]v1alpha1.PDMSMember{}
+	for i := int32(0); i < 3; i++ {
+		name := pdMSPodName(tc.Name, i, memberType)
+		tc.Status.PD.TSO.Members[name] = v1alpha1.PDMSMember{Name: name, Health: true}
+	}
+	g.Expect(pmm.scale(tc, memberType, oldSet, newSet)).To(Succeed())
+	// scaled in one by one
+	g.Expect(*newSet.Spec.Replicas).To(Equal(int32(2)))
+
+	// scaled out at once
+	*oldSet.Spec.Replicas = 1
+	newSet = getNewPDMSStatefulSet(tc, memberType)
+	*newSet.Spec.Replicas = 3
+	g.Expect(pmm.scale(tc, memberType, oldSet, newSet)).To(Succeed())
+	g.Expect(*newSet.Spec.Replicas).To(Equal(int32(3)))
+}
+
+func TestGetNewPDMSStatefulSet(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPDMS()
+	set := getNewPDMSStatefulSet(tc, v1alpha1.PDSchedulingMemberType)
+	container := set.Spec.Template.Spec.Containers[0]
+	// the image defaults to the pd image
+	g.Expect(container.Image).To(Equal(tc.Spec.PD.Image))
+	g.Expect(container.Args[:2]).To(Equal([]string{"services", "scheduling"}))
+	g.Expect(container.Args).To(ContainElement("--backend-endpoints=http://test-pd:2379"))
+	g.Expect(container.Args).To(ContainElement("--advertise-listen-addr=http://$(POD_NAME).test-scheduling-peer.$(NAMESPACE).svc:2379"))
+	g.Expect(set.Spec.Template.Spec.Volumes).To(BeEmpty())
+
+	tc.Spec.EnableTLSCluster = true
+	tc.Spec.PD.Scheduling.Image = "pd:ms"
+	set = getNewPDMSStatefulSet(tc, v1alpha1.PDSchedulingMemberType)
+	container = set.Spec.Template.Spec.Containers[0]
+	g.Expect(container.Image).To(Equal("pd:ms"))
+	g.Expect(container.Args).To(ContainElement("--cert=/var/lib/scheduling-tls/scheduling.crt"))
+	g.Expect(set.Spec.Template.Spec.Volumes[0].Secret.SecretName).To(Equal("test-scheduling"))
+}
+
+func TestPDMSUpgrader(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPDMS()
+	tc.Status.PD.Scheduling.StatefulSet = &apps.StatefulSetStatus{
+		Replicas:        2,
+		CurrentRevision: "1",
+		UpdateRevision:  "2",
+	}
+	oldSet := getNewPDMSStatefulSet(tc, v1alpha1.PDSchedulingMemberType)
+	g.Expect(SetLastAppliedConfigAnnotation(oldSet)).To(Succeed())
+	newSet := oldSet.DeepCopy()
+	newSet.Spec.Template.Spec.Containers[0].Image = "pd:v2"
+
+	upgrader := NewPDMSUpgrader(nil)
+
+	// scheduling is not upgraded while tso is upgrading
+	tc.Status.PD.TSO.Phase = v1alpha1.UpgradePhase
+	g.Expect(upgrader.Upgrade(tc, oldSet, newSet)).To(Succeed())
+	g.Expect(newSet.Spec.Template.Spec.Containers[0].Image).To(Equal(tc.Spec.PD.Image))
+	g.Expect(tc.Status.PD.Scheduling.Phase).NotTo(Equal(v1alpha1.UpgradePhase))
+
+	tc.Status.PD.TSO.Phase = v1alpha1.NormalPhase
+	newSet.Spec.Template.Spec.Containers[0].Image = "pd:v2"
+	g.Expect(upgrader.Upgrade(tc, oldSet, newSet)).To(Succeed())
+	g.Expect(tc.Status.PD.Scheduling.Phase).To(Equal(v1alpha1.UpgradePhase))
+}
+
+func newFakePDMSMemberManager() (*pdMSMemberManager, *controller.FakeStatefulSetControl) {
+	cli := fake.NewSimpleClientset()
+	kubeCli := kubefake.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeCli, 0)
+	setInformer := kubeInformerFactory.Apps().V1().StatefulSets()
+	tcInformer := informers.NewSharedInformerFactory(cli, 0).Pingcap().V1alpha1().TidbClusters()
+	svcInformer := kubeInformerFactory.Core().V1().Services()
+	epsInformer := kubeInformerFactory.Core().V1().Endpoints()
+	podInformer := kubeInformerFactory.Core().V1().Pods()
+	setControl := controller.NewFakeStatefulSetControl(setInformer, tcInformer)
+
+	pmm := &pdMSMemberManager{
+		setControl:   setControl,
+		svcControl:   controller.NewFakeServiceControl(svcInformer, epsInformer, tcInformer),
+		setLister:    setInformer.Lister(),
+		svcLister:    svcInformer.Lister(),
+		podLister:    podInformer.Lister(),
+		pdMSUpgrader: NewFakePDMSUpgrader(),
+	}
+	return pmm, setControl
+}
+
+func newTidbClusterForPDMS() *v1alpha1.TidbCluster {
+	tc := newTidbClusterForTiDB()
+	tc.Spec.PD.Replicas = 1
+	tc.Spec.PD.Image = v1alpha1.PDMemberType.String()
+	tc.Status.PD.Members = map[string]v1alpha1.PDMember{
+		"test-pd-0": {Name: "test-pd-0", Health: true},
+	}
+	tc.Status.PD.StatefulSet = &apps.StatefulSetStatus{ReadyReplicas: 1}
+	tc.Spec.PD.Mode = v1alpha1.PDModeMicroservice
+	tc.Spec.PD.TSO = &v1alpha1.PDMSSpec{Replicas: 2}
+	tc.Spec.PD.Scheduling = &v1alpha1.PDMSSpec{Replicas: 2}
+	return tc
+}
@@ -0,0 +1,105 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	apps "k8s.io/api/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+)
+
+type pdMSUpgrader struct {
+	podLister corelisters.PodLister
+}
+
+// NewPDMSUpgrader returns a pd microservice Upgrader, the microservice is
+// taken from the component label of the statefulset
+func NewPDMSUpgrader(podLister corelisters.PodLister) Upgrader {
+	return &pdMSUpgrader{
+		podLister: podLister,
+	}
+}
+
+func (pmu *pdMSUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	memberType := v1alpha1.MemberType(newSet.Labels[label.ComponentLabelKey])
+	status := tc.PDMSStatusFor(memberType)
+
+	// pd is upgraded first, then tso and scheduling in turn
+	if tc.PDUpgrading() ||
+		(memberType == v1alpha1.PDSchedulingMemberType && tc.Status.PD.TSO.Phase == v1alpha1.UpgradePhase) {
+		_, podSpec, err := GetLastAppliedConfig(oldSet)
+		if err != nil {
+			return err
+		}
+		newSet.Spec.Template.Spec = *podSpec
+		return nil
+	}
+
+	status.Phase = v1alpha1.UpgradePhase
+	if !templateEqual(newSet.Spec.Template, oldSet.Spec.Template) {
+		return nil
+	}
+
+	if status.StatefulSet.UpdateRevision == status.StatefulSet.CurrentRevision {
+		return nil
+	}
+
+	if oldSet.Spec.UpdateStrategy.Type == apps.OnDeleteStatefulSetStrategyType || oldSet.Spec.UpdateStrategy.RollingUpdate == nil {
+		newSet.Spec.UpdateStrategy = oldSet.Spec.UpdateStrategy
+		log.ForCluster(ns, tcName).WithComponent("pd-ms").Warningf("pd %s statefulset %s UpdateStrategy has been modified manually", memberType, oldSet.GetName())
+		return nil
+	}
+
+	setUpgradePartition(newSet, *oldSet.Spec.UpdateStrategy.RollingUpdate.Partition)
+	for i := status.StatefulSet.Replicas - 1; i >= 0; i-- {
+		podName := pdMSPodName(tcName, i, memberType)
+		pod, err := pmu.podLister.Pods(ns).Get(podName)
+		if err != nil {
+			return err
+		}
+		revision, exist := pod.Labels[apps.ControllerRevisionHashLabelKey]
+		if !exist {
+			return controller.RequeueErrorf("tidbcluster: [%s/%s]'s pd %s pod: [%s] has no label: %s", ns, tcName, memberType, podName, apps.ControllerRevisionHashLabelKey)
+		}
+
+		if revision == status.StatefulSet.UpdateRevision {
+			if member, exist := status.Members[podName]; !exist || !member.Health {
+				return controller.RequeueErrorf("tidbcluster: [%s/%s]'s pd %s upgraded pod: [%s] is not ready", ns, tcName, memberType, podName)
+			}
+			continue
+		}
+		setUpgradePartition(newSet, i)
+		return nil
+	}
+
+	return nil
+}
+
+type fakePDMSUpgrader struct{}
+
+// NewFakePDMSUpgrader returns a fake pd microservice upgrader
+func NewFakePDMSUpgrader() Upgrader {
+	return &fakePDMSUpgrader{}
+}
+
+func (fpmu *fakePDMSUpgrader) Upgrade(tc *v1alpha1.TidbCluster, _ *apps.StatefulSet, newSet *apps.StatefulSet) error {
+	memberType := v1alpha1.MemberType(newSet.Labels[label.ComponentLabelKey])
+	tc.PDMSStatusFor(memberType).Phase = v1alpha1.UpgradePhase
+	return nil
+}
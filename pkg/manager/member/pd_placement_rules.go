@@ -0,0 +1,149 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+)
+
+// defaultPlacementRuleGroup is the group of the default placement rule of PD
+const defaultPlacementRuleGroup = "pd"
+
+// syncPlacementRules reconciles the placement rule groups in the spec to PD,
+// and removes the groups synced before but removed from the spec
+func (pmm *pdMemberManager) syncPlacementRules(tc *v1alpha1.TidbCluster) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	groups := tc.Spec.PD.PlacementRules
+
+	if len(groups) == 0 && len(tc.Status.PD.PlacementRuleGroups) == 0 {
+		return nil
+	}
+	if !tc.Status.PD.Synced {
+		return nil
+	}
+
+	pdClient := controller.GetPDClient(pmm.pdControl, tc)
+	bundles, err := pdClient.GetPlacementRuleBundles()
+	if err != nil {
+		if len(groups) == 0 {
+			return err
+		}
+		// the placement rules can only be accessed after they are enabled in PD
+		if err := pdClient.UpdateReplicationConfig(map[string]interface{}{"enable-placement-rules": "true"}); err != nil {
+			return err
+		}
+		return controller.RequeueErrorf("TidbCluster: [%s/%s], enabling the placement rules of PD, %v", ns, tcName, err)
+	}
+	existing := map[string]*pdapi.PlacementRuleBundle{}
+	for _, bundle := range bundles {
+		existing[bundle.ID] = bundle
+	}
+
+	var synced []string
+	syncedSet := map[string]bool{}
+	for i := range groups {
+		bundle := newPlacementRuleBundle(&groups[i])
+		equal, err := placementRuleBundleEqual(bundle, existing[bundle.ID])
+		if err != nil {
+			return err
+		}
+		if !equal {
+			if err := pdClient.SetPlacementRuleBundle(bundle); err != nil {
+				return err
+			}
+			log.ForCluster(ns, tcName).WithComponent("pd").Infof("placement rule group %s is synced to PD", bundle.ID)
+		}
+		synced = append(synced, bundle.ID)
+		syncedSet[bundle.ID] = true
+	}
+
+	for _, groupID := range tc.Status.PD.PlacementRuleGroups {
+		if syncedSet[groupID] {
+			continue
+		}
+		// PD can't place any replica without the default group, so it's left as it is
+		if groupID == defaultPlacementRuleGroup {
+			log.ForCluster(ns, tcName).WithComponent("pd").Warningf("placement rule group %s is removed from the spec but kept in PD", groupID)
+			continue
+		}
+		if err := pdClient.DeletePlacementRuleBundle(groupID); err != nil {
+			return err
+		}
+		log.ForCluster(ns, tcName).WithComponent("pd").Infof("placement rule group %s is deleted from PD", groupID)
+	}
+
+	tc.Status.PD.PlacementRuleGroups = synced
+	return nil
+}
+
+func newPlacementRuleBundle(group *v1alpha1.PlacementRuleGroup) *pdapi.PlacementRuleBundle {
+	bundle := &pdapi.PlacementRuleBundle{
+		ID:       group.ID,
+		Index:    group.Index,
+		Override: group.Override,
+	}
+	for _, rule := range group.Rules {
+		r := &pdapi.PlacementRule{
+			GroupID:        group.ID,
+			ID:             rule.ID,
+			Index:          rule.Index,
+			Override:       rule.Override,
+			StartKeyHex:    strings.ToLower(rule.StartKey),
+			EndKeyHex:      strings.ToLower(rule.EndKey),
+			Role:           string(rule.Role),
+			Count:          rule.Count,
+			LocationLabels: rule.LocationLabels,
+			IsolationLevel: rule.IsolationLevel,
+		}
+		for _, constraint := range rule.LabelConstraints {
+			r.LabelConstraints = append(r.LabelConstraints, pdapi.PlacementLabelConstraint{
+				Key:    constraint.Key,
+				Op:     constraint.Op,
+				Values: constraint.Values,
+			})
+		}
+		bundle.Rules = append(bundle.Rules, r)
+	}
+	return bundle
+}
+
+// placementRuleBundleEqual compares the bundles regardless of the order of the rules
+func placementRuleBundleEqual(bundle, existing *pdapi.PlacementRuleBundle) (bool, error) {
+	if existing == nil {
+		return false, nil
+	}
+	data := make([][]byte, 0, 2)
+	for _, b := range []*pdapi.PlacementRuleBundle{bundle, existing} {
+		rules := append([]*pdapi.PlacementRule(nil), b.Rules...)
+		sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+		sorted := *b
+		sorted.Rules = rules
+		d, err := json.Marshal(&sorted)
+		if err != nil {
+			return false, fmt.Errorf("failed to marshal placement rule group %s: %v", b.ID, err)
+		}
+		data = append(data, d)
+	}
+	return bytes.Equal(data[0], data[1]), nil
+}
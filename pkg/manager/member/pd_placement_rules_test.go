@@ -0,0 +1,129 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+)
+
+func TestPDMemberManagerSyncPlacementRules(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPD()
+	tc.Status.PD.Synced = true
+	tc.Spec.PD.PlacementRules = []v1alpha1.PlacementRuleGroup{
+		{
+			ID:    "learners",
+			Index: 10,
+			Rules: []v1alpha1.PlacementRule{
+				{
+					ID:    "learner",
+					Role:  v1alpha1.PlacementRoleLearner,
+					Count: 1,
+					LabelConstraints: []v1alpha1.PlacementLabelConstraint{
+						{Key: "zone", Op: "in", Values: []string{"z3"}},
+					},
+				},
+			},
+		},
+	}
+	pmm, _, _, pdControl, _, _, _ := newFakePDMemberManager()
+	pdClient := controller.NewFakePDClient(pdControl, tc)
+
+	var bundles []*pdapi.PlacementRuleBundle
+	var set, deleted []string
+	var enabled bool
+	pdClient.AddReaction(pdapi.GetPlacementRuleBundlesActionType, func(action *pdapi.Action) (interface{}, error) {
+		if !enabled {
+			return nil, fmt.Errorf("placement rules feature is disabled")
+		}
+		return bundles, nil
+	})
+	pdClient.AddReaction(pdapi.UpdateReplicationConfigActionType, func(action *pdapi.Action) (interface{}, error) {
+		enabled = action.Config["enable-placement-rules"] == "true"
+		return nil, nil
+	})
+	pdClient.AddReaction(pdapi.SetPlacementRuleBundleActionType, func(action *pdapi.Action) (interface{}, error) {
+		set = append(set, action.Name)
+		bundles = append(bundles, action.PlacementRuleBundle)
+		return nil, nil
+	})
+	pdClient.AddReaction(pdapi.DeletePlacementRuleBundleActionType, func(action *pdapi.Action) (interface{}, error) {
+		deleted = append(deleted, action.Name)
+		return nil, nil
+	})
+
+	// the placement rules are enabled first
+	err := pmm.syncPlacementRules(tc)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(enabled).To(BeTrue())
+
+	g.Expect(pmm.syncPlacementRules(tc)).To(Succeed())
+	g.Expect(set).To(Equal([]string{"learners"}))
+	g.Expect(bundles[0].Rules[0].GroupID).To(Equal("learners"))
+	g.Expect(tc.Status.PD.PlacementRuleGroups).To(Equal([]string{"learners"}))
+
+	// the group is not set again if it's not changed
+	set = nil
+	g.Expect(pmm.syncPlacementRules(tc)).To(Succeed())
+	g.Expect(set).To(BeNil())
+
+	tc.Spec.PD.PlacementRules[0].Rules[0].Count = 2
+	g.Expect(pmm.syncPlacementRules(tc)).To(Succeed())
+	g.Expect(set).To(Equal([]string{"learners"}))
+
+	// the groups removed from the spec are deleted from PD
+	tc.Spec.PD.PlacementRules = nil
+	g.Expect(pmm.syncPlacementRules(tc)).To(Succeed())
+	g.Expect(deleted).To(Equal([]string{"learners"}))
+	g.Expect(tc.Status.PD.PlacementRuleGroups).To(BeEmpty())
+}
+
+func TestPlacementRuleBundleEqual(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	group := &v1alpha1.PlacementRuleGroup{
+		ID: "pd",
+		Rules: []v1alpha1.PlacementRule{
+			{ID: "voters", Role: v1alpha1.PlacementRoleVoter, Count: 3, StartKey: "7480000000000000FF"},
+			{ID: "learners", Role: v1alpha1.PlacementRoleLearner, Count: 1},
+		},
+	}
+	existing := &pdapi.PlacementRuleBundle{
+		ID: "pd",
+		Rules: []*pdapi.PlacementRule{
+			{GroupID: "pd", ID: "learners", Role: "learner", Count: 1},
+			{GroupID: "pd", ID: "voters", Role: "voter", Count: 3, StartKeyHex: "7480000000000000ff"},
+		},
+	}
+
+	equal, err := placementRuleBundleEqual(newPlacementRuleBundle(group), existing)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(equal).To(BeTrue())
+
+	equal, err = placementRuleBundleEqual(newPlacementRuleBundle(group), nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(equal).To(BeFalse())
+
+	group.Rules[1].Count = 2
+	equal, err = placementRuleBundleEqual(newPlacementRuleBundle(group), existing)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(equal).To(BeFalse())
+}
@@ -17,12 +17,12 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	"github.com/pingcap/tidb-operator/pkg/pdapi"
-	apps "k8s.io/api/apps/v1beta1"
+	apps "k8s.io/api/apps/v1"
 	corelisters "k8s.io/client-go/listers/core/v1"
 )
 
@@ -103,11 +103,11 @@ func (psd *pdScaler) ScaleIn(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulSet,
 
 	err := controller.GetPDClient(psd.pdControl, tc).DeleteMember(memberName)
 	if err != nil {
-		glog.Errorf("pd scale in: failed to delete member %s, %v", memberName, err)
+		log.Errorf("pd scale in: failed to delete member %s, %v", memberName, err)
 		resetReplicas(newSet, oldSet)
 		return err
 	}
-	glog.Infof("pd scale in: delete member %s successfully", memberName)
+	log.Infof("pd scale in: delete member %s successfully", memberName)
 
 	pvcName := ordinalPVCName(v1alpha1.PDMemberType, setName, ordinal)
 	pvc, err := psd.pvcLister.PersistentVolumeClaims(ns).Get(pvcName)
@@ -124,12 +124,12 @@ func (psd *pdScaler) ScaleIn(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulSet,
 
 	_, err = psd.pvcControl.UpdatePVC(tc, pvc)
 	if err != nil {
-		glog.Errorf("pd scale in: failed to set pvc %s/%s annotation: %s to %s",
+		log.Errorf("pd scale in: failed to set pvc %s/%s annotation: %s to %s",
 			ns, pvcName, label.AnnPVCDeferDeleting, now)
 		resetReplicas(newSet, oldSet)
 		return err
 	}
-	glog.Infof("pd scale in: set pvc %s/%s annotation: %s to %s",
+	log.Infof("pd scale in: set pvc %s/%s annotation: %s to %s",
 		ns, pvcName, label.AnnPVCDeferDeleting, now)
 
 	decreaseReplicas(newSet, oldSet)
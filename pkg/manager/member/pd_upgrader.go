@@ -15,29 +15,33 @@ package member
 
 import (
 	"fmt"
+	"time"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	"github.com/pingcap/tidb-operator/pkg/pdapi"
-	apps "k8s.io/api/apps/v1beta1"
+	apps "k8s.io/api/apps/v1"
 	corelisters "k8s.io/client-go/listers/core/v1"
 )
 
 type pdUpgrader struct {
-	pdControl  pdapi.PDControlInterface
-	podControl controller.PodControlInterface
-	podLister  corelisters.PodLister
+	pdControl      pdapi.PDControlInterface
+	podControl     controller.PodControlInterface
+	podLister      corelisters.PodLister
+	upgradeChecker UpgradeChecker
 }
 
 // NewPDUpgrader returns a pdUpgrader
 func NewPDUpgrader(pdControl pdapi.PDControlInterface,
 	podControl controller.PodControlInterface,
-	podLister corelisters.PodLister) Upgrader {
+	podLister corelisters.PodLister,
+	upgradeChecker UpgradeChecker) Upgrader {
 	return &pdUpgrader{
-		pdControl:  pdControl,
-		podControl: podControl,
-		podLister:  podLister,
+		pdControl:      pdControl,
+		podControl:     podControl,
+		podLister:      podLister,
+		upgradeChecker: upgradeChecker,
 	}
 }
 
@@ -52,6 +56,12 @@ func (pu *pdUpgrader) gracefulUpgrade(tc *v1alpha1.TidbCluster, oldSet *apps.Sta
 		return fmt.Errorf("tidbcluster: [%s/%s]'s pd status sync failed,can not to be upgraded", ns, tcName)
 	}
 
+	if tc.Status.PD.Phase != v1alpha1.UpgradePhase {
+		// the rolling upgrade is about to start
+		if err := pu.upgradeChecker.Check(tc, v1alpha1.PDMemberType); err != nil {
+			return err
+		}
+	}
 	tc.Status.PD.Phase = v1alpha1.UpgradePhase
 	if !templateEqual(newSet.Spec.Template, oldSet.Spec.Template) {
 		return nil
@@ -67,11 +77,12 @@ func (pu *pdUpgrader) gracefulUpgrade(tc *v1alpha1.TidbCluster, oldSet *apps.Sta
 		// If we encounter this situation, we will let the native statefulset controller do the upgrade completely, which may be unsafe for upgrading pd.
 		// Therefore, in the production environment, we should try to avoid modifying the pd statefulset update strategy directly.
 		newSet.Spec.UpdateStrategy = oldSet.Spec.UpdateStrategy
-		glog.Warningf("tidbcluster: [%s/%s] pd statefulset %s UpdateStrategy has been modified manually", ns, tcName, oldSet.GetName())
+		log.ForCluster(ns, tcName).WithComponent("pd").Warningf("pd statefulset %s UpdateStrategy has been modified manually", oldSet.GetName())
 		return nil
 	}
 
 	setUpgradePartition(newSet, *oldSet.Spec.UpdateStrategy.RollingUpdate.Partition)
+	tc.Status.PD.Upgrade = newUpgradeProgress(tc.Spec.PD.Image, tc.Status.PD.StatefulSet)
 	for i := tc.Status.PD.StatefulSet.Replicas - 1; i >= 0; i-- {
 		podName := pdPodName(tcName, i)
 		pod, err := pu.podLister.Pods(ns).Get(podName)
@@ -86,8 +97,13 @@ func (pu *pdUpgrader) gracefulUpgrade(tc *v1alpha1.TidbCluster, oldSet *apps.Sta
 
 		if revision == tc.Status.PD.StatefulSet.UpdateRevision {
 			if member, exist := tc.Status.PD.Members[podName]; !exist || !member.Health {
+				setUpgradingPod(tc.Status.PD.Upgrade, podName, i, v1alpha1.UpgradePodRejoining)
 				return controller.RequeueErrorf("tidbcluster: [%s/%s]'s pd upgraded pod: [%s] is not ready", ns, tcName, podName)
 			}
+			if remaining := minReadyRemaining(pod, tc.Spec.PD.MinReadySeconds, time.Now()); remaining > 0 {
+				setUpgradingPod(tc.Status.PD.Upgrade, podName, i, v1alpha1.UpgradePodRejoining)
+				return controller.RequeueAfterErrorf(remaining, "tidbcluster: [%s/%s]'s pd upgraded pod: [%s] is not ready for %ds", ns, tcName, podName, tc.Spec.PD.MinReadySeconds)
+			}
 			continue
 		}
 
@@ -109,15 +125,17 @@ func (pu *pdUpgrader) upgradePDPod(tc *v1alpha1.TidbCluster, ordinal int32, newS
 		} else {
 			targetName = pdPodName(tcName, lastOrdinal)
 		}
+		setUpgradingPod(tc.Status.PD.Upgrade, upgradePodName, ordinal, v1alpha1.UpgradePodEvictingLeaders)
 		err := pu.transferPDLeaderTo(tc, targetName)
 		if err != nil {
-			glog.Errorf("pd upgrader: failed to transfer pd leader to: %s, %v", targetName, err)
+			log.Errorf("pd upgrader: failed to transfer pd leader to: %s, %v", targetName, err)
 			return err
 		}
-		glog.Infof("pd upgrader: transfer pd leader to: %s successfully", targetName)
+		log.Infof("pd upgrader: transfer pd leader to: %s successfully", targetName)
 		return controller.RequeueErrorf("tidbcluster: [%s/%s]'s pd member: [%s] is transferring leader to pd member: [%s]", ns, tcName, upgradePodName, targetName)
 	}
 
+	setUpgradingPod(tc.Status.PD.Upgrade, upgradePodName, ordinal, v1alpha1.UpgradePodRestarting)
 	setUpgradePartition(newSet, ordinal)
 	return nil
 }
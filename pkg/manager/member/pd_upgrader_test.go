@@ -22,7 +22,7 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/label"
 	"github.com/pingcap/tidb-operator/pkg/pdapi"
-	apps "k8s.io/api/apps/v1beta1"
+	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -101,6 +101,14 @@ func TestPDUpgraderUpgrade(t *testing.T) {
 			expectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster, newSet *apps.StatefulSet) {
 				g.Expect(tc.Status.PD.Phase).To(Equal(v1alpha1.UpgradePhase))
 				g.Expect(newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(controller.Int32Ptr(1)))
+				g.Expect(tc.Status.PD.Upgrade).To(Equal(&v1alpha1.UpgradeProgress{
+					Image:    "pd-test-image",
+					PodName:  pdPodName(upgradeTcName, 1),
+					Ordinal:  1,
+					Phase:    v1alpha1.UpgradePodRestarting,
+					Upgraded: 1,
+					Pending:  2,
+				}))
 			},
 		},
 		{
@@ -159,6 +167,7 @@ func TestPDUpgraderUpgrade(t *testing.T) {
 			expectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster, newSet *apps.StatefulSet) {
 				g.Expect(tc.Status.PD.Phase).To(Equal(v1alpha1.UpgradePhase))
 				g.Expect(newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(controller.Int32Ptr(3)))
+				g.Expect(tc.Status.PD.Upgrade).To(BeNil())
 			},
 		},
 		{
@@ -191,6 +200,8 @@ func TestPDUpgraderUpgrade(t *testing.T) {
 			expectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster, newSet *apps.StatefulSet) {
 				g.Expect(tc.Status.PD.Phase).To(Equal(v1alpha1.UpgradePhase))
 				g.Expect(newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(controller.Int32Ptr(2)))
+				g.Expect(tc.Status.PD.Upgrade.PodName).To(Equal(pdPodName(upgradeTcName, 2)))
+				g.Expect(tc.Status.PD.Upgrade.Phase).To(Equal(v1alpha1.UpgradePodRejoining))
 			},
 		},
 		{
@@ -207,6 +218,8 @@ func TestPDUpgraderUpgrade(t *testing.T) {
 			expectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster, newSet *apps.StatefulSet) {
 				g.Expect(tc.Status.PD.Phase).To(Equal(v1alpha1.UpgradePhase))
 				g.Expect(newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(controller.Int32Ptr(2)))
+				g.Expect(tc.Status.PD.Upgrade.PodName).To(Equal(pdPodName(upgradeTcName, 1)))
+				g.Expect(tc.Status.PD.Upgrade.Phase).To(Equal(v1alpha1.UpgradePodEvictingLeaders))
 			},
 		},
 		{
@@ -254,9 +267,10 @@ func newPDUpgrader() (Upgrader, *pdapi.FakePDControl, *controller.FakePodControl
 	pdControl := pdapi.NewFakePDControl()
 	podControl := controller.NewFakePodControl(podInformer)
 	return &pdUpgrader{
-			pdControl:  pdControl,
-			podControl: podControl,
-			podLister:  podInformer.Lister()},
+			pdControl:      pdControl,
+			podControl:     podControl,
+			podLister:      podInformer.Lister(),
+			upgradeChecker: NewFakeUpgradeChecker()},
 		pdControl, podControl, podInformer
 }
 
@@ -16,10 +16,11 @@ package member
 import (
 	"fmt"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	"github.com/pingcap/tidb-operator/pkg/metrics"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	corelisters "k8s.io/client-go/listers/core/v1"
@@ -60,8 +61,11 @@ func NewRealPVCCleaner(
 func (rpc *realPVCCleaner) Clean(tc *v1alpha1.TidbCluster) (map[string]string, error) {
 	ns := tc.GetNamespace()
 	tcName := tc.GetName()
-	// for unit test
+	// for unit test and metrics
 	skipReason := map[string]string{}
+	defer func() {
+		metrics.ObserveCleanerSkipReasons(ns, tcName, metrics.PVCCleaner, skipReason)
+	}()
 
 	selector, err := label.New().Instance(tc.GetLabels()[label.InstanceLabelKey]).Selector()
 	if err != nil {
@@ -84,7 +88,7 @@ func (rpc *realPVCCleaner) Clean(tc *v1alpha1.TidbCluster) (map[string]string, e
 		if pvc.Annotations[label.AnnPVCDeferDeleting] != "" {
 			if _, exist := pvc.Annotations[label.AnnPVCPodScheduling]; !exist {
 				// The defer deleting PVC without pod scheduling annotation, do nothing
-				glog.V(4).Infof("cluster %s/%s defer delete pvc %s has not pod scheduling annotation, skip clean", ns, tcName, pvcName)
+				log.ForCluster(ns, tcName).V(4).Infof("defer delete pvc %s has not pod scheduling annotation, skip clean", pvcName)
 				skipReason[pvcName] = skipReasonPVCCleanerDeferDeletePVCNotHasLock
 				continue
 			}
@@ -93,6 +97,7 @@ func (rpc *realPVCCleaner) Clean(tc *v1alpha1.TidbCluster) (map[string]string, e
 			if _, err := rpc.pvcControl.UpdatePVC(tc, pvc); err != nil {
 				return skipReason, fmt.Errorf("cluster %s/%s remove pvc %s pod scheduling annotation faild, err: %v", ns, tcName, pvcName, err)
 			}
+			metrics.ObserveCleanerAction(ns, tcName, metrics.PVCCleaner, metrics.ActionRemovePodSchedulingAnnotation)
 			continue
 		}
 
@@ -114,14 +119,14 @@ func (rpc *realPVCCleaner) Clean(tc *v1alpha1.TidbCluster) (map[string]string, e
 
 		if _, exist := pvc.Annotations[label.AnnPVCPodScheduling]; !exist {
 			// The PVC without pod scheduling annotation, do nothing
-			glog.V(4).Infof("cluster %s/%s pvc %s has not pod scheduling annotation, skip clean", ns, tcName, pvcName)
+			log.ForCluster(ns, tcName).V(4).Infof("pvc %s has not pod scheduling annotation, skip clean", pvcName)
 			skipReason[pvcName] = skipReasonPVCCleanerPVCNotHasLock
 			continue
 		}
 
 		if pvc.Status.Phase != corev1.ClaimBound || pod.Spec.NodeName == "" {
 			// This pod has not been scheduled yet, no need to clean up the pvc pod schedule annotation
-			glog.V(4).Infof("cluster %s/%s pod %s has not been scheduled yet, skip clean pvc %s pod schedule annotation", ns, tcName, podName, pvcName)
+			log.ForCluster(ns, tcName).V(4).Infof("pod %s has not been scheduled yet, skip clean pvc %s pod schedule annotation", podName, pvcName)
 			skipReason[pvcName] = skipReasonPVCCleanerPodWaitingForScheduling
 			continue
 		}
@@ -130,7 +135,8 @@ func (rpc *realPVCCleaner) Clean(tc *v1alpha1.TidbCluster) (map[string]string, e
 		if _, err := rpc.pvcControl.UpdatePVC(tc, pvc); err != nil {
 			return skipReason, fmt.Errorf("cluster %s/%s remove pvc %s pod scheduling annotation faild, err: %v", ns, tcName, pvcName, err)
 		}
-		glog.Infof("cluster %s/%s, clean pvc %s pod scheduling annotation successfully", ns, tcName, pvcName)
+		metrics.ObserveCleanerAction(ns, tcName, metrics.PVCCleaner, metrics.ActionRemovePodSchedulingAnnotation)
+		log.ForCluster(ns, tcName).Infof("clean pvc %s pod scheduling annotation successfully", pvcName)
 	}
 
 	return skipReason, nil
@@ -0,0 +1,112 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	"github.com/pingcap/tidb-operator/pkg/manager"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+)
+
+type recoveryManager struct {
+	pdControl pdapi.PDControlInterface
+}
+
+// NewRecoveryManager returns a manager which drives the unsafe recovery of the
+// failed stores in the recovery mode
+func NewRecoveryManager(pdControl pdapi.PDControlInterface) manager.Manager {
+	return &recoveryManager{
+		pdControl: pdControl,
+	}
+}
+
+func (rm *recoveryManager) Sync(tc *v1alpha1.TidbCluster) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	if !tc.RecoveryModeEnabled() || len(tc.Spec.RecoveryMode.FailedStores) == 0 {
+		tc.Status.Recovery = v1alpha1.RecoveryStatus{}
+		return nil
+	}
+
+	if !tc.PDIsAvailable() {
+		return controller.RequeueErrorf("TidbCluster: [%s/%s], waiting for PD cluster running", ns, tcName)
+	}
+
+	pdClient := rm.pdControl.GetPDClient(pdapi.Namespace(ns), tcName, tc.Spec.EnableTLSCluster)
+	failedStores := tc.Spec.RecoveryMode.FailedStores
+	if !reflect.DeepEqual(failedStores, tc.Status.Recovery.FailedStores) {
+		storeIDs := make([]uint64, 0, len(failedStores))
+		for _, id := range failedStores {
+			storeID, err := strconv.ParseUint(id, 10, 64)
+			if err != nil {
+				return fmt.Errorf("TidbCluster: [%s/%s], invalid failed store id %q: %v", ns, tcName, id, err)
+			}
+			storeIDs = append(storeIDs, storeID)
+		}
+		if err := pdClient.UnsafeRemoveFailedStores(storeIDs); err != nil {
+			return err
+		}
+		log.ForCluster(ns, tcName).Infof("unsafe recovery of the failed stores %v is submitted", failedStores)
+		tc.Status.Recovery = v1alpha1.RecoveryStatus{
+			FailedStores: append([]string(nil), failedStores...),
+			Phase:        v1alpha1.RecoveryRunning,
+		}
+		return nil
+	}
+
+	if tc.Status.Recovery.Phase != v1alpha1.RecoveryRunning {
+		return nil
+	}
+	stages, err := pdClient.GetUnsafeRecoverProgress()
+	if err != nil {
+		return err
+	}
+	if len(stages) == 0 {
+		return nil
+	}
+	// the last stage of pd's unsafe recovery reports whether it's finished or failed
+	info := stages[len(stages)-1].Info
+	tc.Status.Recovery.Message = info
+	switch {
+	case strings.Contains(info, "finished"):
+		tc.Status.Recovery.Phase = v1alpha1.RecoveryFinished
+	case strings.Contains(info, "failed"):
+		tc.Status.Recovery.Phase = v1alpha1.RecoveryFailed
+	}
+	return nil
+}
+
+type FakeRecoveryManager struct {
+	err error
+}
+
+func NewFakeRecoveryManager() *FakeRecoveryManager {
+	return &FakeRecoveryManager{}
+}
+
+func (frm *FakeRecoveryManager) SetSyncError(err error) {
+	frm.err = err
+}
+
+func (frm *FakeRecoveryManager) Sync(_ *v1alpha1.TidbCluster) error {
+	return frm.err
+}
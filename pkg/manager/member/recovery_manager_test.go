@@ -0,0 +1,99 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	apps "k8s.io/api/apps/v1"
+)
+
+func TestRecoveryManagerSync(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForRecovery()
+	pdControl := pdapi.NewFakePDControl()
+	pdClient := controller.NewFakePDClient(pdControl, tc)
+	rm := NewRecoveryManager(pdControl)
+
+	var submitted []uint64
+	pdClient.AddReaction(pdapi.UnsafeRemoveFailedStoresActionType, func(action *pdapi.Action) (interface{}, error) {
+		submitted = action.IDs
+		return nil, nil
+	})
+	stages := []pdapi.UnsafeRecoverStage{{Info: "Unsafe recovery enters collect report stage"}}
+	pdClient.AddReaction(pdapi.GetUnsafeRecoverProgressActionType, func(action *pdapi.Action) (interface{}, error) {
+		return stages, nil
+	})
+
+	// only one of the three pd members is healthy
+	g.Expect(tc.PDIsAvailable()).To(BeTrue())
+
+	g.Expect(rm.Sync(tc)).To(Succeed())
+	g.Expect(submitted).To(Equal([]uint64{1, 4}))
+	g.Expect(tc.Status.Recovery.FailedStores).To(Equal([]string{"1", "4"}))
+	g.Expect(tc.Status.Recovery.Phase).To(Equal(v1alpha1.RecoveryRunning))
+
+	// the recovery is not submitted again
+	submitted = nil
+	g.Expect(rm.Sync(tc)).To(Succeed())
+	g.Expect(submitted).To(BeNil())
+	g.Expect(tc.Status.Recovery.Phase).To(Equal(v1alpha1.RecoveryRunning))
+	g.Expect(tc.Status.Recovery.Message).To(Equal(stages[0].Info))
+
+	stages = append(stages, pdapi.UnsafeRecoverStage{Info: "Unsafe recovery finished"})
+	g.Expect(rm.Sync(tc)).To(Succeed())
+	g.Expect(tc.Status.Recovery.Phase).To(Equal(v1alpha1.RecoveryFinished))
+
+	// the status is reset when the recovery mode is removed
+	tc.Spec.RecoveryMode = nil
+	g.Expect(rm.Sync(tc)).To(Succeed())
+	g.Expect(tc.Status.Recovery).To(Equal(v1alpha1.RecoveryStatus{}))
+}
+
+func TestRecoveryManagerSyncFailed(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForRecovery()
+	pdControl := pdapi.NewFakePDControl()
+	pdClient := controller.NewFakePDClient(pdControl, tc)
+	rm := NewRecoveryManager(pdControl)
+
+	pdClient.AddReaction(pdapi.UnsafeRemoveFailedStoresActionType, func(action *pdapi.Action) (interface{}, error) {
+		return nil, fmt.Errorf("unsafe recovery is running")
+	})
+	g.Expect(rm.Sync(tc)).NotTo(Succeed())
+	g.Expect(tc.Status.Recovery.Phase).To(BeEmpty())
+
+	tc.Spec.RecoveryMode.FailedStores = []string{"a"}
+	g.Expect(rm.Sync(tc)).NotTo(Succeed())
+}
+
+func newTidbClusterForRecovery() *v1alpha1.TidbCluster {
+	tc := newTidbClusterForTiDB()
+	tc.Spec.PD.Replicas = 3
+	tc.Status.PD.Members = map[string]v1alpha1.PDMember{
+		"test-pd-0": {Name: "test-pd-0", Health: true},
+	}
+	tc.Status.PD.StatefulSet = &apps.StatefulSetStatus{ReadyReplicas: 1}
+	tc.Spec.RecoveryMode = &v1alpha1.RecoverySpec{
+		FailedStores: []string{"1", "4"},
+	}
+	return tc
+}
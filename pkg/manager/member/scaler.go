@@ -16,12 +16,12 @@ package member
 import (
 	"fmt"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	"github.com/pingcap/tidb-operator/pkg/pdapi"
-	apps "k8s.io/api/apps/v1beta1"
+	apps "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	corelisters "k8s.io/client-go/listers/core/v1"
 )
@@ -73,10 +73,10 @@ func (gs *generalScaler) deleteDeferDeletingPVC(tc *v1alpha1.TidbCluster,
 
 	err = gs.pvcControl.DeletePVC(tc, pvc)
 	if err != nil {
-		glog.Errorf("scale out: failed to delete pvc %s/%s, %v", ns, pvcName, err)
+		log.Errorf("scale out: failed to delete pvc %s/%s, %v", ns, pvcName, err)
 		return skipReason, err
 	}
-	glog.Infof("scale out: delete pvc %s/%s successfully", ns, pvcName)
+	log.Infof("scale out: delete pvc %s/%s successfully", ns, pvcName)
 
 	return skipReason, nil
 }
@@ -86,12 +86,12 @@ func resetReplicas(newSet *apps.StatefulSet, oldSet *apps.StatefulSet) {
 }
 func increaseReplicas(newSet *apps.StatefulSet, oldSet *apps.StatefulSet) {
 	*newSet.Spec.Replicas = *oldSet.Spec.Replicas + 1
-	glog.Infof("pd scale out: increase pd statefulset: %s/%s replicas to %d",
+	log.Infof("pd scale out: increase pd statefulset: %s/%s replicas to %d",
 		newSet.GetNamespace(), newSet.GetName(), newSet.Spec.Replicas)
 }
 func decreaseReplicas(newSet *apps.StatefulSet, oldSet *apps.StatefulSet) {
 	*newSet.Spec.Replicas = *oldSet.Spec.Replicas - 1
-	glog.Infof("pd scale in: decrease pd statefulset: %s/%s replicas to %d",
+	log.Infof("pd scale in: decrease pd statefulset: %s/%s replicas to %d",
 		newSet.GetNamespace(), newSet.GetName(), newSet.Spec.Replicas)
 }
 
@@ -0,0 +1,226 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	"github.com/pingcap/tidb-operator/pkg/metrics"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+)
+
+// TidbClusterCleanerInterface implements the logic for cleaning up the resources of a deleted
+// TidbCluster which are left behind by the garbage collection:
+//   - the stores and members registered in the pd cluster of another TidbCluster, which the
+//     members of the deleted TidbCluster joined
+//   - the PVCs of pd and tikv, which are deleted only if the dataRetentionPolicy is Delete
+//   - the Services, ConfigMaps and Secrets with the labels of the TidbCluster, including the
+//     ones without an owner reference
+//
+// The members are deregistered before anything else is deleted, the TidbCluster is requeued
+// until the stores are tombstone, i.e. the regions on them have been migrated.
+type TidbClusterCleanerInterface interface {
+	Clean(*v1alpha1.TidbCluster) error
+}
+
+type realTidbClusterCleaner struct {
+	pdControl  pdapi.PDControlInterface
+	pvcLister  corelisters.PersistentVolumeClaimLister
+	pvcControl controller.PVCControlInterface
+	kubeCli    kubernetes.Interface
+}
+
+// NewRealTidbClusterCleaner returns a realTidbClusterCleaner
+func NewRealTidbClusterCleaner(
+	pdControl pdapi.PDControlInterface,
+	pvcLister corelisters.PersistentVolumeClaimLister,
+	pvcControl controller.PVCControlInterface,
+	kubeCli kubernetes.Interface) TidbClusterCleanerInterface {
+	return &realTidbClusterCleaner{
+		pdControl,
+		pvcLister,
+		pvcControl,
+		kubeCli,
+	}
+}
+
+func (rtcc *realTidbClusterCleaner) Clean(tc *v1alpha1.TidbCluster) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	if err := rtcc.deregisterMembers(tc); err != nil {
+		return err
+	}
+
+	selector, err := label.New().Instance(tc.GetLabels()[label.InstanceLabelKey]).Selector()
+	if err != nil {
+		return fmt.Errorf("cluster %s/%s assemble label selector failed, err: %v", ns, tcName, err)
+	}
+	if err := rtcc.cleanPVCs(tc, selector); err != nil {
+		return err
+	}
+	return rtcc.cleanObjects(tc, selector)
+}
+
+// deregisterMembers deletes the stores and members of the TidbCluster from the pd cluster that
+// they joined, the pd cluster of the TidbCluster itself is deleted along with it
+func (rtcc *realTidbClusterCleaner) deregisterMembers(tc *v1alpha1.TidbCluster) error {
+	ref := tc.Spec.Cluster
+	if ref == nil {
+		return nil
+	}
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	pdClient := rtcc.pdControl.GetRemotePDClient(pdapi.Namespace(ref.Namespace), ref.Name, tc.Spec.EnableTLSCluster, ref.ClusterDomain)
+
+	storeIDs := map[uint64]bool{}
+	for id := range tc.Status.TiKV.Stores {
+		storeID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return fmt.Errorf("cluster %s/%s parse store id %s failed, err: %v", ns, tcName, id, err)
+		}
+		storeIDs[storeID] = true
+	}
+	if len(storeIDs) > 0 {
+		// the tombstone stores are not listed
+		stores, err := pdClient.GetStores()
+		if err != nil {
+			return fmt.Errorf("cluster %s/%s get stores of cluster %s/%s failed, err: %v", ns, tcName, ref.Namespace, ref.Name, err)
+		}
+		var offlineStores []uint64
+		for _, store := range stores.Stores {
+			storeID := store.Store.GetId()
+			if !storeIDs[storeID] {
+				continue
+			}
+			if err := pdClient.DeleteStore(storeID); err != nil {
+				return fmt.Errorf("cluster %s/%s delete store %d failed, err: %v", ns, tcName, storeID, err)
+			}
+			metrics.ObserveCleanerAction(ns, tcName, metrics.TidbClusterCleaner, metrics.ActionDeleteStore)
+			offlineStores = append(offlineStores, storeID)
+		}
+		if len(offlineStores) > 0 {
+			return controller.RequeueErrorf("cluster %s/%s waiting for stores %v to become tombstone", ns, tcName, offlineStores)
+		}
+	}
+
+	for name := range tc.Status.PD.Members {
+		if err := pdClient.DeleteMember(name); err != nil {
+			return fmt.Errorf("cluster %s/%s delete member %s failed, err: %v", ns, tcName, name, err)
+		}
+		metrics.ObserveCleanerAction(ns, tcName, metrics.TidbClusterCleaner, metrics.ActionDeleteMember)
+		log.Infof("tidb cluster cleaner: member %s of cluster %s/%s is deleted from cluster %s/%s", name, ns, tcName, ref.Namespace, ref.Name)
+	}
+	return nil
+}
+
+// cleanPVCs deletes the PVCs of pd and tikv if the dataRetentionPolicy is Delete, the PVCs created
+// by the statefulsets are not garbage collected
+func (rtcc *realTidbClusterCleaner) cleanPVCs(tc *v1alpha1.TidbCluster, selector labels.Selector) error {
+	if tc.GetDataRetentionPolicy() != v1alpha1.DataRetentionPolicyDelete {
+		return nil
+	}
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	pvcs, err := rtcc.pvcLister.PersistentVolumeClaims(ns).List(selector)
+	if err != nil {
+		return fmt.Errorf("cluster %s/%s list pvc failed, selector: %s, err: %v", ns, tcName, selector, err)
+	}
+	for _, pvc := range pvcs {
+		component := pvc.Labels[label.ComponentLabelKey]
+		if component != label.PDLabelVal && component != label.TiKVLabelVal {
+			continue
+		}
+		if pvc.DeletionTimestamp != nil {
+			continue
+		}
+		if err := rtcc.pvcControl.DeletePVC(tc, pvc); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		metrics.ObserveCleanerAction(ns, tcName, metrics.TidbClusterCleaner, metrics.ActionDeletePVC)
+		log.Infof("tidb cluster cleaner: pvc %s/%s of cluster %s is deleted", ns, pvc.GetName(), tcName)
+	}
+	return nil
+}
+
+// cleanObjects deletes the Services, ConfigMaps and Secrets with the labels of the TidbCluster
+func (rtcc *realTidbClusterCleaner) cleanObjects(tc *v1alpha1.TidbCluster, selector labels.Selector) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	opts := metav1.ListOptions{LabelSelector: selector.String()}
+
+	deleteObject := func(kind, name, action string, fn func(string, *metav1.DeleteOptions) error) error {
+		if err := fn(name, nil); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("cluster %s/%s delete %s %s failed, err: %v", ns, tcName, kind, name, err)
+		}
+		metrics.ObserveCleanerAction(ns, tcName, metrics.TidbClusterCleaner, action)
+		log.Infof("tidb cluster cleaner: %s %s/%s of cluster %s is deleted", kind, ns, name, tcName)
+		return nil
+	}
+
+	svcs, err := rtcc.kubeCli.CoreV1().Services(ns).List(opts)
+	if err != nil {
+		return fmt.Errorf("cluster %s/%s list services failed, selector: %s, err: %v", ns, tcName, selector, err)
+	}
+	for _, svc := range svcs.Items {
+		if err := deleteObject("service", svc.Name, metrics.ActionDeleteService, rtcc.kubeCli.CoreV1().Services(ns).Delete); err != nil {
+			return err
+		}
+	}
+
+	cms, err := rtcc.kubeCli.CoreV1().ConfigMaps(ns).List(opts)
+	if err != nil {
+		return fmt.Errorf("cluster %s/%s list configmaps failed, selector: %s, err: %v", ns, tcName, selector, err)
+	}
+	for _, cm := range cms.Items {
+		if err := deleteObject("configmap", cm.Name, metrics.ActionDeleteConfigMap, rtcc.kubeCli.CoreV1().ConfigMaps(ns).Delete); err != nil {
+			return err
+		}
+	}
+
+	secrets, err := rtcc.kubeCli.CoreV1().Secrets(ns).List(opts)
+	if err != nil {
+		return fmt.Errorf("cluster %s/%s list secrets failed, selector: %s, err: %v", ns, tcName, selector, err)
+	}
+	for _, secret := range secrets.Items {
+		if err := deleteObject("secret", secret.Name, metrics.ActionDeleteSecret, rtcc.kubeCli.CoreV1().Secrets(ns).Delete); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var _ TidbClusterCleanerInterface = &realTidbClusterCleaner{}
+
+type fakeTidbClusterCleaner struct{}
+
+// NewFakeTidbClusterCleaner returns a fake tidb cluster cleaner
+func NewFakeTidbClusterCleaner() TidbClusterCleanerInterface {
+	return &fakeTidbClusterCleaner{}
+}
+
+func (ftcc *fakeTidbClusterCleaner) Clean(_ *v1alpha1.TidbCluster) error {
+	return nil
+}
@@ -0,0 +1,190 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"sort"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestTidbClusterCleanerDeregisterMembers(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForCleaner()
+	tc.Spec.Cluster = &v1alpha1.TidbClusterRef{Namespace: "ns1", Name: "cluster1"}
+	tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{"1": {ID: "1"}, "2": {ID: "2"}}
+	tc.Status.PD.Members = map[string]v1alpha1.PDMember{"pd-0": {Name: "pd-0"}}
+
+	cleaner, _, _, pdControl := newFakeTidbClusterCleaner()
+	pdClient := pdapi.NewFakePDClient()
+	pdControl.SetPDClient("ns1", "cluster1", pdClient)
+
+	// store 3 belongs to the joined cluster
+	storeIDs := []uint64{1, 3}
+	pdClient.AddReaction(pdapi.GetStoresActionType, func(action *pdapi.Action) (interface{}, error) {
+		stores := &pdapi.StoresInfo{}
+		for _, id := range storeIDs {
+			stores.Stores = append(stores.Stores, &pdapi.StoreInfo{Store: &pdapi.MetaStore{Store: &metapb.Store{Id: id}}})
+		}
+		return stores, nil
+	})
+	var deletedStores []uint64
+	pdClient.AddReaction(pdapi.DeleteStoreActionType, func(action *pdapi.Action) (interface{}, error) {
+		deletedStores = append(deletedStores, action.ID)
+		return nil, nil
+	})
+	var deletedMembers []string
+	pdClient.AddReaction(pdapi.DeleteMemberActionType, func(action *pdapi.Action) (interface{}, error) {
+		deletedMembers = append(deletedMembers, action.Name)
+		return nil, nil
+	})
+
+	err := cleaner.Clean(tc)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(deletedStores).To(Equal([]uint64{1}))
+	g.Expect(deletedMembers).To(BeEmpty())
+
+	// store 1 becomes tombstone
+	storeIDs = []uint64{3}
+	g.Expect(cleaner.Clean(tc)).To(Succeed())
+	g.Expect(deletedStores).To(Equal([]uint64{1}))
+	g.Expect(deletedMembers).To(Equal([]string{"pd-0"}))
+}
+
+func TestTidbClusterCleanerCleanObjects(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name            string
+		retentionPolicy v1alpha1.DataRetentionPolicyType
+		expectPVCs      []string
+		expectOtherObjs []string
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		tc := newTidbClusterForCleaner()
+		tc.Spec.DataRetentionPolicy = test.retentionPolicy
+		cleaner, kubeCli, pvcIndexer, _ := newFakeTidbClusterCleaner()
+
+		clusterLabels := label.New().Instance(tc.GetName()).Labels()
+		otherLabels := label.New().Instance("other").Labels()
+		for _, pvc := range []*corev1.PersistentVolumeClaim{
+			{ObjectMeta: metav1.ObjectMeta{Name: "pd-test-pd-0", Namespace: tc.Namespace, Labels: label.New().Instance(tc.GetName()).PD().Labels()}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "tikv-test-tikv-0", Namespace: tc.Namespace, Labels: label.New().Instance(tc.GetName()).TiKV().Labels()}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "data-test-dashboard-0", Namespace: tc.Namespace, Labels: label.New().Instance(tc.GetName()).Component(label.DashboardLabelVal).Labels()}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "pd-other-pd-0", Namespace: tc.Namespace, Labels: label.New().Instance("other").PD().Labels()}},
+		} {
+			g.Expect(pvcIndexer.Add(pvc)).To(Succeed())
+		}
+		for _, obj := range []struct {
+			name   string
+			labels map[string]string
+		}{
+			{"test-pd", clusterLabels},
+			{"other-pd", otherLabels},
+		} {
+			meta := metav1.ObjectMeta{Name: obj.name, Namespace: tc.Namespace, Labels: obj.labels}
+			_, err := kubeCli.CoreV1().Services(tc.Namespace).Create(&corev1.Service{ObjectMeta: meta})
+			g.Expect(err).NotTo(HaveOccurred())
+			_, err = kubeCli.CoreV1().ConfigMaps(tc.Namespace).Create(&corev1.ConfigMap{ObjectMeta: meta})
+			g.Expect(err).NotTo(HaveOccurred())
+			_, err = kubeCli.CoreV1().Secrets(tc.Namespace).Create(&corev1.Secret{ObjectMeta: meta})
+			g.Expect(err).NotTo(HaveOccurred())
+		}
+
+		g.Expect(cleaner.Clean(tc)).To(Succeed())
+
+		var pvcs []string
+		for _, obj := range pvcIndexer.List() {
+			pvcs = append(pvcs, obj.(*corev1.PersistentVolumeClaim).Name)
+		}
+		sort.Strings(pvcs)
+		g.Expect(pvcs).To(Equal(test.expectPVCs))
+
+		svcs, err := kubeCli.CoreV1().Services(tc.Namespace).List(metav1.ListOptions{})
+		g.Expect(err).NotTo(HaveOccurred())
+		cms, err := kubeCli.CoreV1().ConfigMaps(tc.Namespace).List(metav1.ListOptions{})
+		g.Expect(err).NotTo(HaveOccurred())
+		secrets, err := kubeCli.CoreV1().Secrets(tc.Namespace).List(metav1.ListOptions{})
+		g.Expect(err).NotTo(HaveOccurred())
+		var objs []string
+		for _, svc := range svcs.Items {
+			objs = append(objs, "service/"+svc.Name)
+		}
+		for _, cm := range cms.Items {
+			objs = append(objs, "configmap/"+cm.Name)
+		}
+		for _, secret := range secrets.Items {
+			objs = append(objs, "secret/"+secret.Name)
+		}
+		g.Expect(objs).To(Equal(test.expectOtherObjs))
+	}
+	tests := []testcase{
+		{
+			name:            "retain pvcs by default",
+			expectPVCs:      []string{"data-test-dashboard-0", "pd-other-pd-0", "pd-test-pd-0", "tikv-test-tikv-0"},
+			expectOtherObjs: []string{"service/other-pd", "configmap/other-pd", "secret/other-pd"},
+		},
+		{
+			name:            "retain pvcs",
+			retentionPolicy: v1alpha1.DataRetentionPolicyRetain,
+			expectPVCs:      []string{"data-test-dashboard-0", "pd-other-pd-0", "pd-test-pd-0", "tikv-test-tikv-0"},
+			expectOtherObjs: []string{"service/other-pd", "configmap/other-pd", "secret/other-pd"},
+		},
+		{
+			name:            "delete pvcs of pd and tikv",
+			retentionPolicy: v1alpha1.DataRetentionPolicyDelete,
+			expectPVCs:      []string{"data-test-dashboard-0", "pd-other-pd-0"},
+			expectOtherObjs: []string{"service/other-pd", "configmap/other-pd", "secret/other-pd"},
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func newFakeTidbClusterCleaner() (*realTidbClusterCleaner, *kubefake.Clientset, cache.Indexer, *pdapi.FakePDControl) {
+	kubeCli := kubefake.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeCli, 0)
+	pvcInformer := kubeInformerFactory.Core().V1().PersistentVolumeClaims()
+	pvcControl := controller.NewFakePVCControl(pvcInformer)
+	pdControl := pdapi.NewFakePDControl()
+
+	return &realTidbClusterCleaner{pdControl, pvcInformer.Lister(), pvcControl, kubeCli},
+		kubeCli, pvcInformer.Informer().GetIndexer(), pdControl
+}
+
+func newTidbClusterForCleaner() *v1alpha1.TidbCluster {
+	return &v1alpha1.TidbCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: corev1.NamespaceDefault,
+			Labels:    label.New().Instance("test").Labels(),
+		},
+	}
+}
@@ -20,15 +20,16 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	"github.com/pingcap/tidb-operator/pkg/manager"
 	"github.com/pingcap/tidb-operator/pkg/util"
-	apps "k8s.io/api/apps/v1beta1"
+	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
-	"k8s.io/client-go/listers/apps/v1beta1"
+	appslisters "k8s.io/client-go/listers/apps/v1"
 	corelisters "k8s.io/client-go/listers/core/v1"
 )
 
@@ -42,7 +43,7 @@ type tidbMemberManager struct {
 	setControl                   controller.StatefulSetControlInterface
 	svcControl                   controller.ServiceControlInterface
 	tidbControl                  controller.TiDBControlInterface
-	setLister                    v1beta1.StatefulSetLister
+	setLister                    appslisters.StatefulSetLister
 	svcLister                    corelisters.ServiceLister
 	podLister                    corelisters.PodLister
 	tidbUpgrader                 Upgrader
@@ -55,7 +56,7 @@ type tidbMemberManager struct {
 func NewTiDBMemberManager(setControl controller.StatefulSetControlInterface,
 	svcControl controller.ServiceControlInterface,
 	tidbControl controller.TiDBControlInterface,
-	setLister v1beta1.StatefulSetLister,
+	setLister appslisters.StatefulSetLister,
 	svcLister corelisters.ServiceLister,
 	podLister corelisters.PodLister,
 	tidbUpgrader Upgrader,
@@ -79,6 +80,11 @@ func (tmm *tidbMemberManager) Sync(tc *v1alpha1.TidbCluster) error {
 	ns := tc.GetNamespace()
 	tcName := tc.GetName()
 
+	// nothing should be written to the cluster before it's recovered
+	if tc.RecoveryModeEnabled() {
+		return nil
+	}
+
 	if !tc.TiKVIsAvailable() {
 		return controller.RequeueErrorf("TidbCluster: [%s/%s], waiting for TiKV cluster running", ns, tcName)
 	}
@@ -88,10 +94,43 @@ func (tmm *tidbMemberManager) Sync(tc *v1alpha1.TidbCluster) error {
 		return err
 	}
 
+	// Sync the external-dns hostname of TiDB Service
+	if err := tmm.syncTiDBServiceDNSNameForTidbCluster(tc); err != nil {
+		return err
+	}
+
 	// Sync Tidb StatefulSet
 	return tmm.syncTiDBStatefulSetForTidbCluster(tc)
 }
 
+// syncTiDBServiceDNSNameForTidbCluster keeps the external-dns hostname annotation
+// on the TiDB Service. The Service is created by the helm chart rather than the
+// operator, so only the annotation is patched, and it's set again when the
+// Service is recreated.
+func (tmm *tidbMemberManager) syncTiDBServiceDNSNameForTidbCluster(tc *v1alpha1.TidbCluster) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	if tc.Spec.TiDB.Service == nil || tc.Spec.TiDB.Service.DNSName == "" {
+		return nil
+	}
+	dnsName := tc.Spec.TiDB.Service.DNSName
+
+	svc, err := tmm.svcLister.Services(ns).Get(controller.TiDBMemberName(tcName))
+	if errors.IsNotFound(err) {
+		log.ForCluster(ns, tcName).WithComponent("tidb").V(4).Infof("TiDB Service %s is not found, skip setting the dns name", controller.TiDBMemberName(tcName))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if svc.Annotations[label.AnnExternalDNSHostname] == dnsName {
+		return nil
+	}
+
+	return tmm.svcControl.PatchServiceAnnotations(tc, svc, map[string]string{label.AnnExternalDNSHostname: dnsName})
+}
+
 func (tmm *tidbMemberManager) syncTiDBHeadlessServiceForTidbCluster(tc *v1alpha1.TidbCluster) error {
 	ns := tc.GetNamespace()
 	tcName := tc.GetName()
@@ -111,22 +150,7 @@ func (tmm *tidbMemberManager) syncTiDBHeadlessServiceForTidbCluster(tc *v1alpha1
 
 	oldSvc := oldSvcTmp.DeepCopy()
 
-	equal, err := serviceEqual(newSvc, oldSvc)
-	if err != nil {
-		return err
-	}
-	if !equal {
-		svc := *oldSvc
-		svc.Spec = newSvc.Spec
-		err = SetServiceLastAppliedConfigAnnotation(newSvc)
-		if err != nil {
-			return err
-		}
-		_, err = tmm.svcControl.UpdateService(tc, &svc)
-		return err
-	}
-
-	return nil
+	return updateService(tmm.svcControl, tc, newSvc, oldSvc)
 }
 
 func (tmm *tidbMemberManager) syncTiDBStatefulSetForTidbCluster(tc *v1alpha1.TidbCluster) error {
@@ -172,20 +196,7 @@ func (tmm *tidbMemberManager) syncTiDBStatefulSetForTidbCluster(tc *v1alpha1.Tid
 		}
 	}
 
-	if !statefulSetEqual(*newTiDBSet, *oldTiDBSet) {
-		set := *oldTiDBSet
-		set.Spec.Template = newTiDBSet.Spec.Template
-		*set.Spec.Replicas = *newTiDBSet.Spec.Replicas
-		set.Spec.UpdateStrategy = newTiDBSet.Spec.UpdateStrategy
-		err := SetLastAppliedConfigAnnotation(&set)
-		if err != nil {
-			return err
-		}
-		_, err = tmm.setControl.UpdateStatefulSet(tc, &set)
-		return err
-	}
-
-	return nil
+	return updateStatefulSet(tmm.setControl, tc, newTiDBSet, oldTiDBSet)
 }
 
 func (tmm *tidbMemberManager) getNewTiDBHeadlessServiceForTidbCluster(tc *v1alpha1.TidbCluster) *corev1.Service {
@@ -312,6 +323,27 @@ func (tmm *tidbMemberManager) getNewTiDBSetForTidbCluster(tc *v1alpha1.TidbClust
 			Value: slowLogFileEnvVal,
 		},
 	}
+	if tc.Spec.ClusterDomain != "" {
+		// the tidb members advertise the FQDN under the cluster domain
+		envs = append(envs, corev1.EnvVar{
+			Name: "NAMESPACE",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{
+					FieldPath: "metadata.namespace",
+				},
+			},
+		}, corev1.EnvVar{
+			Name:  "CLUSTER_DOMAIN",
+			Value: tc.Spec.ClusterDomain,
+		})
+	}
+	if tc.Spec.AcrossK8s {
+		// the pd addresses are got from the discovery service
+		envs = append(envs, corev1.EnvVar{
+			Name:  "ACROSS_K8S",
+			Value: "true",
+		})
+	}
 
 	scheme := corev1.URISchemeHTTP
 	if tc.Spec.EnableTLSCluster {
@@ -355,7 +387,7 @@ func (tmm *tidbMemberManager) getNewTiDBSetForTidbCluster(tc *v1alpha1.TidbClust
 	}
 
 	tidbLabel := label.New().Instance(instanceName).TiDB()
-	podAnnotations := CombineAnnotations(controller.AnnProm(10080), tc.Spec.TiDB.Annotations)
+	podAnnotations := CombineAnnotations(CombineAnnotations(controller.AnnProm(10080), serviceMeshAnnotations(tc)), tc.Spec.TiDB.Annotations)
 	tidbSet := &apps.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            controller.TiDBMemberName(tcName),
@@ -381,8 +413,10 @@ func (tmm *tidbMemberManager) getNewTiDBSetForTidbCluster(tc *v1alpha1.TidbClust
 					RestartPolicy:     corev1.RestartPolicyAlways,
 					Tolerations:       tc.Spec.TiDB.Tolerations,
 					Volumes:           vols,
-					SecurityContext:   tc.Spec.TiDB.PodSecurityContext,
+					SecurityContext:   podSecurityContext(tc.Spec.TiDB.PodSecurityContext),
 					PriorityClassName: tc.Spec.TiDB.PriorityClassName,
+					// tiproxy migrates the connections during the graceful wait
+					TerminationGracePeriodSeconds: tidbTerminationGracePeriodSeconds(tc),
 				},
 			},
 			ServiceName:         controller.TiDBPeerMemberName(tcName),
@@ -398,6 +432,13 @@ func (tmm *tidbMemberManager) getNewTiDBSetForTidbCluster(tc *v1alpha1.TidbClust
 func (tmm *tidbMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, set *apps.StatefulSet) error {
 	tc.Status.TiDB.StatefulSet = &set.Status
 
+	// the selector of the tidb pods is exposed through the scale subresource for the HPA
+	selector, err := label.New().Instance(tc.GetLabels()[label.InstanceLabelKey]).TiDB().Selector()
+	if err != nil {
+		return err
+	}
+	tc.Status.TiDB.Selector = selector.String()
+
 	upgrading, err := tmm.tidbStatefulSetIsUpgradingFn(tmm.podLister, set, tc)
 	if err != nil {
 		return err
@@ -406,6 +447,7 @@ func (tmm *tidbMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, se
 		tc.Status.TiDB.Phase = v1alpha1.UpgradePhase
 	} else {
 		tc.Status.TiDB.Phase = v1alpha1.NormalPhase
+		tc.Status.TiDB.Upgrade = nil
 	}
 
 	tidbStatus := map[string]v1alpha1.TiDBMember{}
@@ -25,7 +25,7 @@ import (
 	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/label"
-	apps "k8s.io/api/apps/v1beta1"
+	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -396,6 +396,7 @@ func TestTiDBMemberManagerSyncTidbClusterStatus(t *testing.T) {
 			tcExpectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster) {
 				g.Expect(tc.Status.TiDB.StatefulSet.Replicas).To(Equal(int32(3)))
 				g.Expect(tc.Status.TiDB.Phase).To(Equal(v1alpha1.UpgradePhase))
+				g.Expect(tc.Status.TiDB.Selector).To(ContainSubstring("app.kubernetes.io/component=tidb"))
 			},
 		},
 		{
@@ -521,10 +522,71 @@ func TestTiDBMemberManagerSyncTidbClusterStatus(t *testing.T) {
 	}
 }
 
+func TestGetNewTiDBSetAcrossK8s(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForTiDB()
+	tc.Spec.ClusterDomain = "cluster.local"
+	tc.Spec.AcrossK8s = true
+	tmm, _, _, _ := newFakeTiDBMemberManager()
+	set := tmm.getNewTiDBSetForTidbCluster(tc)
+
+	envs := map[string]corev1.EnvVar{}
+	for _, env := range set.Spec.Template.Spec.Containers[len(set.Spec.Template.Spec.Containers)-1].Env {
+		envs[env.Name] = env
+	}
+	g.Expect(envs["CLUSTER_DOMAIN"].Value).To(Equal("cluster.local"))
+	g.Expect(envs["ACROSS_K8S"].Value).To(Equal("true"))
+	g.Expect(envs["NAMESPACE"].ValueFrom.FieldRef.FieldPath).To(Equal("metadata.namespace"))
+}
+
+func TestTiDBMemberManagerSyncServiceDNSName(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForTiDB()
+	ns := tc.GetNamespace()
+	svcName := controller.TiDBMemberName(tc.GetName())
+	tmm, _, _, _ := newFakeTiDBMemberManager()
+	svcControl := tmm.svcControl.(*controller.FakeServiceControl)
+
+	// nothing is done if the dns name is not set or the service doesn't exist
+	g.Expect(tmm.syncTiDBServiceDNSNameForTidbCluster(tc)).To(Succeed())
+	tc.Spec.TiDB.Service = &v1alpha1.TiDBServiceSpec{DNSName: "tidb.example.com"}
+	g.Expect(tmm.syncTiDBServiceDNSNameForTidbCluster(tc)).To(Succeed())
+
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        svcName,
+			Namespace:   ns,
+			Annotations: map[string]string{"cloud.google.com/load-balancer-type": "Internal"},
+		},
+	}
+	g.Expect(svcControl.SvcIndexer.Add(svc)).To(Succeed())
+	svcControl.SetPatchServiceError(errors.NewInternalError(fmt.Errorf("API server failed")), 0)
+	g.Expect(tmm.syncTiDBServiceDNSNameForTidbCluster(tc)).NotTo(Succeed())
+
+	g.Expect(tmm.syncTiDBServiceDNSNameForTidbCluster(tc)).To(Succeed())
+	svc, err := tmm.svcLister.Services(ns).Get(svcName)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(svc.Annotations).To(Equal(map[string]string{
+		"cloud.google.com/load-balancer-type": "Internal",
+		label.AnnExternalDNSHostname:          "tidb.example.com",
+	}))
+
+	// the service recreated by the chart gets the annotation again
+	g.Expect(svcControl.SvcIndexer.Update(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: svcName, Namespace: ns},
+	})).To(Succeed())
+	g.Expect(tmm.syncTiDBServiceDNSNameForTidbCluster(tc)).To(Succeed())
+	svc, err = tmm.svcLister.Services(ns).Get(svcName)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(svc.Annotations).To(HaveKeyWithValue(label.AnnExternalDNSHostname, "tidb.example.com"))
+}
+
 func newFakeTiDBMemberManager() (*tidbMemberManager, *controller.FakeStatefulSetControl, cache.Indexer, *controller.FakeTiDBControl) {
 	cli := fake.NewSimpleClientset()
 	kubeCli := kubefake.NewSimpleClientset()
-	setInformer := kubeinformers.NewSharedInformerFactory(kubeCli, 0).Apps().V1beta1().StatefulSets()
+	setInformer := kubeinformers.NewSharedInformerFactory(kubeCli, 0).Apps().V1().StatefulSets()
 	tcInformer := informers.NewSharedInformerFactory(cli, 0).Pingcap().V1alpha1().TidbClusters()
 	svcInformer := kubeinformers.NewSharedInformerFactory(kubeCli, 0).Core().V1().Services()
 	epsInformer := kubeinformers.NewSharedInformerFactory(kubeCli, 0).Core().V1().Endpoints()
@@ -14,10 +14,12 @@
 package member
 
 import (
-	"github.com/golang/glog"
+	"time"
+
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
-	apps "k8s.io/api/apps/v1beta1"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	apps "k8s.io/api/apps/v1"
 	corelisters "k8s.io/client-go/listers/core/v1"
 )
 
@@ -27,15 +29,17 @@ const (
 )
 
 type tidbUpgrader struct {
-	podLister   corelisters.PodLister
-	tidbControl controller.TiDBControlInterface
+	podLister      corelisters.PodLister
+	tidbControl    controller.TiDBControlInterface
+	upgradeChecker UpgradeChecker
 }
 
 // NewTiDBUpgrader returns a tidb Upgrader
-func NewTiDBUpgrader(tidbControl controller.TiDBControlInterface, podLister corelisters.PodLister) Upgrader {
+func NewTiDBUpgrader(tidbControl controller.TiDBControlInterface, podLister corelisters.PodLister, upgradeChecker UpgradeChecker) Upgrader {
 	return &tidbUpgrader{
-		tidbControl: tidbControl,
-		podLister:   podLister,
+		tidbControl:    tidbControl,
+		podLister:      podLister,
+		upgradeChecker: upgradeChecker,
 	}
 }
 
@@ -52,6 +56,12 @@ func (tdu *tidbUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.Stateful
 		return nil
 	}
 
+	if tc.Status.TiDB.Phase != v1alpha1.UpgradePhase {
+		// the rolling upgrade is about to start
+		if err := tdu.upgradeChecker.Check(tc, v1alpha1.TiDBMemberType); err != nil {
+			return err
+		}
+	}
 	tc.Status.TiDB.Phase = v1alpha1.UpgradePhase
 	if !templateEqual(newSet.Spec.Template, oldSet.Spec.Template) {
 		return nil
@@ -67,11 +77,12 @@ func (tdu *tidbUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.Stateful
 		// If we encounter this situation, we will let the native statefulset controller do the upgrade completely, which may be unsafe for upgrading tidb.
 		// Therefore, in the production environment, we should try to avoid modifying the tidb statefulset update strategy directly.
 		newSet.Spec.UpdateStrategy = oldSet.Spec.UpdateStrategy
-		glog.Warningf("tidbcluster: [%s/%s] tidb statefulset %s UpdateStrategy has been modified manually", ns, tcName, oldSet.GetName())
+		log.ForCluster(ns, tcName).WithComponent("tidb").Warningf("tidb statefulset %s UpdateStrategy has been modified manually", oldSet.GetName())
 		return nil
 	}
 
 	setUpgradePartition(newSet, *oldSet.Spec.UpdateStrategy.RollingUpdate.Partition)
+	tc.Status.TiDB.Upgrade = newUpgradeProgress(tc.Spec.TiDB.Image, tc.Status.TiDB.StatefulSet)
 	for i := tc.Status.TiDB.StatefulSet.Replicas - 1; i >= 0; i-- {
 		podName := tidbPodName(tcName, i)
 		pod, err := tdu.podLister.Pods(ns).Get(podName)
@@ -85,10 +96,19 @@ func (tdu *tidbUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.Stateful
 
 		if revision == tc.Status.TiDB.StatefulSet.UpdateRevision {
 			if member, exist := tc.Status.TiDB.Members[podName]; !exist || !member.Health {
+				setUpgradingPod(tc.Status.TiDB.Upgrade, podName, i, v1alpha1.UpgradePodRejoining)
 				return controller.RequeueErrorf("tidbcluster: [%s/%s]'s tidb upgraded pod: [%s] is not ready", ns, tcName, podName)
 			}
+			if remaining := minReadyRemaining(pod, tc.Spec.TiDB.MinReadySeconds, time.Now()); remaining > 0 {
+				setUpgradingPod(tc.Status.TiDB.Upgrade, podName, i, v1alpha1.UpgradePodRejoining)
+				return controller.RequeueAfterErrorf(remaining, "tidbcluster: [%s/%s]'s tidb upgraded pod: [%s] is not ready for %ds", ns, tcName, podName, tc.Spec.TiDB.MinReadySeconds)
+			}
 			continue
 		}
+		if tc.TiProxyEnabled() && !tc.TiProxyAllMembersReady() {
+			// the connections of the tidb member can't be migrated without tiproxy
+			return controller.RequeueErrorf("tidbcluster: [%s/%s]'s tiproxy members are not ready, waiting before upgrading tidb pod: [%s]", ns, tcName, podName)
+		}
 		return tdu.upgradeTiDBPod(tc, i, newSet)
 	}
 
@@ -97,19 +117,22 @@ func (tdu *tidbUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.Stateful
 
 func (tdu *tidbUpgrader) upgradeTiDBPod(tc *v1alpha1.TidbCluster, ordinal int32, newSet *apps.StatefulSet) error {
 	tcName := tc.GetName()
+	upgradePodName := tidbPodName(tcName, ordinal)
 	if tc.Spec.TiDB.Replicas > 1 {
-		if member, exist := tc.Status.TiDB.Members[tidbPodName(tcName, ordinal)]; exist && member.Health {
+		if member, exist := tc.Status.TiDB.Members[upgradePodName]; exist && member.Health {
+			setUpgradingPod(tc.Status.TiDB.Upgrade, upgradePodName, ordinal, v1alpha1.UpgradePodEvictingLeaders)
 			hasResign, err := tdu.tidbControl.ResignDDLOwner(tc, ordinal)
 			if (!hasResign || err != nil) && tc.Status.TiDB.ResignDDLOwnerRetryCount < MaxResignDDLOwnerCount {
-				glog.Errorf("tidb upgrader: failed to resign ddl owner to %s, %v", member.Name, err)
+				log.Errorf("tidb upgrader: failed to resign ddl owner to %s, %v", member.Name, err)
 				tc.Status.TiDB.ResignDDLOwnerRetryCount++
 				return err
 			}
-			glog.Infof("tidb upgrader: resign ddl owner to %s successfully", member.Name)
+			log.Infof("tidb upgrader: resign ddl owner to %s successfully", member.Name)
 		}
 	}
 
 	tc.Status.TiDB.ResignDDLOwnerRetryCount = 0
+	setUpgradingPod(tc.Status.TiDB.Upgrade, upgradePodName, ordinal, v1alpha1.UpgradePodRestarting)
 	setUpgradePartition(newSet, ordinal)
 	return nil
 }
@@ -21,7 +21,7 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/label"
-	apps "k8s.io/api/apps/v1beta1"
+	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -192,6 +192,21 @@ func TestTiDBUpgrader_Upgrade(t *testing.T) {
 				g.Expect(newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(controller.Int32Ptr(1)))
 			},
 		},
+		{
+			name: "upgraded pods are not ready for minReadySeconds",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.PD.Phase = v1alpha1.NormalPhase
+				tc.Status.TiKV.Phase = v1alpha1.NormalPhase
+				tc.Spec.TiDB.MinReadySeconds = 30
+			},
+			getLastAppliedConfigErr: false,
+			errorExpect:             true,
+			expectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster, newSet *apps.StatefulSet) {
+				g.Expect(tc.Status.TiDB.Phase).To(Equal(v1alpha1.UpgradePhase))
+				g.Expect(newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(controller.Int32Ptr(1)))
+				g.Expect(tc.Status.TiDB.Upgrade.Phase).To(Equal(v1alpha1.UpgradePodRejoining))
+			},
+		},
 		{
 			name: "resign DDL owner error",
 			changeFn: func(tc *v1alpha1.TidbCluster) {
@@ -235,7 +250,7 @@ func newTiDBUpgrader() (Upgrader, *controller.FakeTiDBControl, podinformers.PodI
 	kubeCli := kubefake.NewSimpleClientset()
 	tidbControl := controller.NewFakeTiDBControl()
 	podInformer := kubeinformers.NewSharedInformerFactory(kubeCli, 0).Core().V1().Pods()
-	return &tidbUpgrader{tidbControl: tidbControl, podLister: podInformer.Lister()}, tidbControl, podInformer
+	return &tidbUpgrader{tidbControl: tidbControl, podLister: podInformer.Lister(), upgradeChecker: NewFakeUpgradeChecker()}, tidbControl, podInformer
 }
 
 func newStatefulSetForTiDBUpgrader() *apps.StatefulSet {
@@ -16,8 +16,8 @@ package member
 import (
 	"time"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -52,7 +52,7 @@ func (tf *tikvFailover) Failover(tc *v1alpha1.TidbCluster) error {
 				tc.Status.TiKV.FailureStores = map[string]v1alpha1.TiKVFailureStore{}
 			}
 			if len(tc.Status.TiKV.FailureStores) >= int(tc.Spec.TiKV.MaxFailoverCount) {
-				glog.Warningf("%s/%s failure stores count reached the limit: %d", ns, tcName, tc.Spec.TiKV.MaxFailoverCount)
+				log.ForCluster(ns, tcName).WithComponent("tikv").Warningf("failure stores count reached the limit: %d", tc.Spec.TiKV.MaxFailoverCount)
 				return nil
 			}
 
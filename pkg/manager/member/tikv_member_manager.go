@@ -18,21 +18,21 @@ import (
 	"reflect"
 	"strings"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	"github.com/pingcap/tidb-operator/pkg/manager"
 	"github.com/pingcap/tidb-operator/pkg/pdapi"
 	"github.com/pingcap/tidb-operator/pkg/util"
-	apps "k8s.io/api/apps/v1beta1"
+	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
-	"k8s.io/client-go/listers/apps/v1beta1"
+	appslisters "k8s.io/client-go/listers/apps/v1"
 	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/kubernetes/pkg/kubelet/apis"
 )
@@ -42,7 +42,7 @@ type tikvMemberManager struct {
 	setControl                   controller.StatefulSetControlInterface
 	svcControl                   controller.ServiceControlInterface
 	pdControl                    pdapi.PDControlInterface
-	setLister                    v1beta1.StatefulSetLister
+	setLister                    appslisters.StatefulSetLister
 	svcLister                    corelisters.ServiceLister
 	podLister                    corelisters.PodLister
 	nodeLister                   corelisters.NodeLister
@@ -57,7 +57,7 @@ type tikvMemberManager struct {
 func NewTiKVMemberManager(pdControl pdapi.PDControlInterface,
 	setControl controller.StatefulSetControlInterface,
 	svcControl controller.ServiceControlInterface,
-	setLister v1beta1.StatefulSetLister,
+	setLister appslisters.StatefulSetLister,
 	svcLister corelisters.ServiceLister,
 	podLister corelisters.PodLister,
 	nodeLister corelisters.NodeLister,
@@ -136,24 +136,7 @@ func (tkmm *tikvMemberManager) syncServiceForTidbCluster(tc *v1alpha1.TidbCluste
 
 	oldSvc := oldSvcTmp.DeepCopy()
 
-	equal, err := serviceEqual(newSvc, oldSvc)
-	if err != nil {
-		return err
-	}
-	if !equal {
-		svc := *oldSvc
-		svc.Spec = newSvc.Spec
-		// TODO add unit test
-		svc.Spec.ClusterIP = oldSvc.Spec.ClusterIP
-		err = SetServiceLastAppliedConfigAnnotation(newSvc)
-		if err != nil {
-			return err
-		}
-		_, err = tkmm.svcControl.UpdateService(tc, &svc)
-		return err
-	}
-
-	return nil
+	return updateService(tkmm.svcControl, tc, newSvc, oldSvc)
 }
 
 func (tkmm *tikvMemberManager) syncStatefulSetForTidbCluster(tc *v1alpha1.TidbCluster) error {
@@ -210,7 +193,8 @@ func (tkmm *tikvMemberManager) syncStatefulSetForTidbCluster(tc *v1alpha1.TidbCl
 		}
 	}
 
-	if tkmm.autoFailover {
+	// the failover is paused in the recovery mode, the failed stores are removed by unsafe recovery
+	if tkmm.autoFailover && !tc.RecoveryModeEnabled() {
 		if tc.TiKVAllPodsStarted() && !tc.TiKVAllStoresReady() {
 			if err := tkmm.tikvFailover.Failover(tc); err != nil {
 				return err
@@ -218,20 +202,7 @@ func (tkmm *tikvMemberManager) syncStatefulSetForTidbCluster(tc *v1alpha1.TidbCl
 		}
 	}
 
-	if !statefulSetEqual(*newSet, *oldSet) {
-		set := *oldSet
-		set.Spec.Template = newSet.Spec.Template
-		*set.Spec.Replicas = *newSet.Spec.Replicas
-		set.Spec.UpdateStrategy = newSet.Spec.UpdateStrategy
-		err := SetLastAppliedConfigAnnotation(&set)
-		if err != nil {
-			return err
-		}
-		_, err = tkmm.setControl.UpdateStatefulSet(tc, &set)
-		return err
-	}
-
-	return nil
+	return updateStatefulSet(tkmm.setControl, tc, newSet, oldSet)
 }
 
 func (tkmm *tikvMemberManager) getNewServiceForTidbCluster(tc *v1alpha1.TidbCluster, svcConfig SvcConfig) *corev1.Service {
@@ -327,7 +298,7 @@ func (tkmm *tikvMemberManager) getNewSetForTidbCluster(tc *v1alpha1.TidbCluster)
 
 	tikvLabel := tkmm.labelTiKV(tc)
 	setName := controller.TiKVMemberName(tcName)
-	podAnnotations := CombineAnnotations(controller.AnnProm(20180), tc.Spec.TiKV.Annotations)
+	podAnnotations := CombineAnnotations(CombineAnnotations(controller.AnnProm(20180), serviceMeshAnnotations(tc, tikvPort)), tc.Spec.TiKV.Annotations)
 	capacity := controller.TiKVCapacity(tc.Spec.TiKV.Limits)
 	headlessSvcName := controller.TiKVPeerMemberName(tcName)
 	storageClassName := tc.Spec.TiKV.StorageClassName
@@ -357,7 +328,7 @@ func (tkmm *tikvMemberManager) getNewSetForTidbCluster(tc *v1alpha1.TidbCluster)
 				},
 				Spec: corev1.PodSpec{
 					SchedulerName: tc.Spec.SchedulerName,
-					Affinity:      tc.Spec.TiKV.Affinity,
+					Affinity:      topologySpreadAffinity(tc.Spec.TiKV.Affinity, tc.Spec.TopologySpreadConstraints, tikvLabel),
 					NodeSelector:  tc.Spec.TiKV.NodeSelector,
 					HostNetwork:   tc.Spec.TiKV.HostNetwork,
 					DNSPolicy:     dnsPolicy,
@@ -377,8 +348,9 @@ func (tkmm *tikvMemberManager) getNewSetForTidbCluster(tc *v1alpha1.TidbCluster)
 									Protocol:      corev1.ProtocolTCP,
 								},
 							},
-							VolumeMounts: volMounts,
-							Resources:    util.ResourceRequirement(tc.Spec.TiKV.ContainerSpec),
+							VolumeMounts:  volMounts,
+							Resources:     util.ResourceRequirement(tc.Spec.TiKV.ContainerSpec),
+							LivenessProbe: getTiKVLivenessProbe(tc),
 							Env: []corev1.EnvVar{
 								{
 									Name: "NAMESPACE",
@@ -418,7 +390,7 @@ func (tkmm *tikvMemberManager) getNewSetForTidbCluster(tc *v1alpha1.TidbCluster)
 					RestartPolicy:     corev1.RestartPolicyAlways,
 					Tolerations:       tc.Spec.TiKV.Tolerations,
 					Volumes:           vols,
-					SecurityContext:   tc.Spec.TiKV.PodSecurityContext,
+					SecurityContext:   podSecurityContext(tc.Spec.TiKV.PodSecurityContext),
 					PriorityClassName: tc.Spec.TiKV.PriorityClassName,
 				},
 			},
@@ -435,9 +407,54 @@ func (tkmm *tikvMemberManager) getNewSetForTidbCluster(tc *v1alpha1.TidbCluster)
 			},
 		},
 	}
+	container := &tikvset.Spec.Template.Spec.Containers[0]
+	if tc.Spec.ClusterDomain != "" {
+		// the tikv members advertise the FQDN under the cluster domain
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name:  "CLUSTER_DOMAIN",
+			Value: tc.Spec.ClusterDomain,
+		})
+	}
+	if tc.Spec.AcrossK8s {
+		// the pd addresses are got from the discovery service
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name:  "ACROSS_K8S",
+			Value: "true",
+		})
+	}
+
 	return tikvset, nil
 }
 
+// getTiKVLivenessProbe returns the liveness probe of the tikv container, which is
+// only set if spec.tikv.livenessProbe is set
+func getTiKVLivenessProbe(tc *v1alpha1.TidbCluster) *corev1.Probe {
+	spec := tc.Spec.TiKV.LivenessProbe
+	if spec == nil {
+		return nil
+	}
+	probe := &corev1.Probe{
+		Handler: corev1.Handler{
+			TCPSocket: &corev1.TCPSocketAction{
+				Port: intstr.FromInt(20160),
+			},
+		},
+		InitialDelaySeconds: int32(600),
+		PeriodSeconds:       int32(10),
+		FailureThreshold:    int32(3),
+	}
+	if spec.InitialDelaySeconds > 0 {
+		probe.InitialDelaySeconds = spec.InitialDelaySeconds
+	}
+	if spec.PeriodSeconds > 0 {
+		probe.PeriodSeconds = spec.PeriodSeconds
+	}
+	if spec.FailureThreshold > 0 {
+		probe.FailureThreshold = spec.FailureThreshold
+	}
+	return probe
+}
+
 func (tkmm *tikvMemberManager) volumeClaimTemplate(q resource.Quantity, metaName string, storageClassName *string) corev1.PersistentVolumeClaim {
 	return corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{Name: metaName},
@@ -470,6 +487,7 @@ func (tkmm *tikvMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, s
 		tc.Status.TiKV.Phase = v1alpha1.UpgradePhase
 	} else {
 		tc.Status.TiKV.Phase = v1alpha1.NormalPhase
+		tc.Status.TiKV.Upgrade = nil
 	}
 
 	previousStores := tc.Status.TiKV.Stores
@@ -492,7 +510,7 @@ func (tkmm *tikvMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, s
 		// avoid LastHeartbeatTime be overwrite by zero time when pd lost LastHeartbeatTime
 		if status.LastHeartbeatTime.IsZero() {
 			if oldStatus, ok := previousStores[status.ID]; ok {
-				glog.V(4).Infof("the pod:%s's store LastHeartbeatTime is zero,so will keep in %v", status.PodName, oldStatus.LastHeartbeatTime)
+				log.V(4).Infof("the pod:%s's store LastHeartbeatTime is zero,so will keep in %v", status.PodName, oldStatus.LastHeartbeatTime)
 				status.LastHeartbeatTime = oldStatus.LastHeartbeatTime
 			}
 		}
@@ -581,19 +599,19 @@ func (tkmm *tikvMemberManager) setStoreLabelsForTiKV(tc *v1alpha1.TidbCluster) (
 		nodeName := pod.Spec.NodeName
 		ls, err := tkmm.getNodeLabels(nodeName, locationLabels)
 		if err != nil || len(ls) == 0 {
-			glog.Warningf("node: [%s] has no node labels, skipping set store labels for Pod: [%s/%s]", nodeName, ns, podName)
+			log.Warningf("node: [%s] has no node labels, skipping set store labels for Pod: [%s/%s]", nodeName, ns, podName)
 			continue
 		}
 
 		if !tkmm.storeLabelsEqualNodeLabels(store.Store.Labels, ls) {
 			set, err := pdCli.SetStoreLabels(store.Store.Id, ls)
 			if err != nil {
-				glog.Warningf("failed to set pod: [%s/%s]'s store labels: %v", ns, podName, ls)
+				log.Warningf("failed to set pod: [%s/%s]'s store labels: %v", ns, podName, ls)
 				continue
 			}
 			if set {
 				setCount++
-				glog.Infof("pod: [%s/%s] set labels: %v successfully", ns, podName, ls)
+				log.Infof("pod: [%s/%s] set labels: %v successfully", ns, podName, ls)
 			}
 		}
 	}
@@ -29,7 +29,7 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/label"
 	"github.com/pingcap/tidb-operator/pkg/pdapi"
-	apps "k8s.io/api/apps/v1beta1"
+	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -1361,6 +1361,35 @@ func TestTiKVMemberManagerSyncTidbClusterStatus(t *testing.T) {
 	}
 }
 
+func TestGetNewTiKVSetWithLivenessProbe(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPD()
+	tkmm, _, _, _, _, _ := newFakeTiKVMemberManager(tc)
+	set, err := tkmm.getNewSetForTidbCluster(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(set.Spec.Template.Spec.Containers[0].LivenessProbe).To(BeNil())
+
+	// the thresholds which are not set are defaulted
+	tc.Spec.TiKV.LivenessProbe = &v1alpha1.TiKVLivenessProbe{InitialDelaySeconds: 1800}
+	set, err = tkmm.getNewSetForTidbCluster(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	probe := set.Spec.Template.Spec.Containers[0].LivenessProbe
+	g.Expect(probe).NotTo(BeNil())
+	g.Expect(probe.TCPSocket.Port.IntValue()).To(Equal(20160))
+	g.Expect(probe.InitialDelaySeconds).To(Equal(int32(1800)))
+	g.Expect(probe.PeriodSeconds).To(Equal(int32(10)))
+	g.Expect(probe.FailureThreshold).To(Equal(int32(3)))
+
+	tc.Spec.TiKV.LivenessProbe = &v1alpha1.TiKVLivenessProbe{PeriodSeconds: 30, FailureThreshold: 20}
+	set, err = tkmm.getNewSetForTidbCluster(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	probe = set.Spec.Template.Spec.Containers[0].LivenessProbe
+	g.Expect(probe.InitialDelaySeconds).To(Equal(int32(600)))
+	g.Expect(probe.PeriodSeconds).To(Equal(int32(30)))
+	g.Expect(probe.FailureThreshold).To(Equal(int32(20)))
+}
+
 func newFakeTiKVMemberManager(tc *v1alpha1.TidbCluster) (
 	*tikvMemberManager, *controller.FakeStatefulSetControl,
 	*controller.FakeServiceControl, *pdapi.FakePDClient, cache.Indexer, cache.Indexer) {
@@ -1368,7 +1397,7 @@ func newFakeTiKVMemberManager(tc *v1alpha1.TidbCluster) (
 	kubeCli := kubefake.NewSimpleClientset()
 	pdControl := pdapi.NewFakePDControl()
 	pdClient := controller.NewFakePDClient(pdControl, tc)
-	setInformer := kubeinformers.NewSharedInformerFactory(kubeCli, 0).Apps().V1beta1().StatefulSets()
+	setInformer := kubeinformers.NewSharedInformerFactory(kubeCli, 0).Apps().V1().StatefulSets()
 	svcInformer := kubeinformers.NewSharedInformerFactory(kubeCli, 0).Core().V1().Services()
 	epsInformer := kubeinformers.NewSharedInformerFactory(kubeCli, 0).Core().V1().Endpoints()
 	tcInformer := informers.NewSharedInformerFactory(cli, 0).Pingcap().V1alpha1().TidbClusters()
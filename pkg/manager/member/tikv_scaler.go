@@ -18,14 +18,13 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	"github.com/pingcap/tidb-operator/pkg/pdapi"
-	apps "k8s.io/api/apps/v1beta1"
+	apps "k8s.io/api/apps/v1"
 	corelisters "k8s.io/client-go/listers/core/v1"
-	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
 )
 
 type tikvScaler struct {
@@ -67,7 +66,7 @@ func (tsd *tikvScaler) ScaleIn(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulSe
 	// tikv can not scale in when it is upgrading
 	if tc.TiKVUpgrading() {
 		resetReplicas(newSet, oldSet)
-		glog.Infof("the TidbCluster: [%s/%s]'s tikv is upgrading,can not scale in until upgrade have completed",
+		log.Infof("the TidbCluster: [%s/%s]'s tikv is upgrading,can not scale in until upgrade have completed",
 			ns, tcName)
 		return nil
 	}
@@ -90,11 +89,11 @@ func (tsd *tikvScaler) ScaleIn(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulSe
 			}
 			if state != v1alpha1.TiKVStateOffline {
 				if err := controller.GetPDClient(tsd.pdControl, tc).DeleteStore(id); err != nil {
-					glog.Errorf("tikv scale in: failed to delete store %d, %v", id, err)
+					log.Errorf("tikv scale in: failed to delete store %d, %v", id, err)
 					resetReplicas(newSet, oldSet)
 					return err
 				}
-				glog.Infof("tikv scale in: delete store %d successfully", id)
+				log.Infof("tikv scale in: delete store %d successfully", id)
 			}
 			resetReplicas(newSet, oldSet)
 			return controller.RequeueErrorf("TiKV %s/%s store %d  still in cluster, state: %s", ns, podName, id, state)
@@ -109,7 +108,7 @@ func (tsd *tikvScaler) ScaleIn(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulSe
 			}
 
 			// TODO: double check if store is really not in Up/Offline/Down state
-			glog.Infof("TiKV %s/%s store %d becomes tombstone", ns, podName, id)
+			log.Infof("TiKV %s/%s store %d becomes tombstone", ns, podName, id)
 
 			pvcName := ordinalPVCName(v1alpha1.TiKVMemberType, setName, ordinal)
 			pvc, err := tsd.pvcLister.PersistentVolumeClaims(ns).Get(pvcName)
@@ -124,12 +123,12 @@ func (tsd *tikvScaler) ScaleIn(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulSe
 			pvc.Annotations[label.AnnPVCDeferDeleting] = now
 			_, err = tsd.pvcControl.UpdatePVC(tc, pvc)
 			if err != nil {
-				glog.Errorf("tikv scale in: failed to set pvc %s/%s annotation: %s to %s",
+				log.Errorf("tikv scale in: failed to set pvc %s/%s annotation: %s to %s",
 					ns, pvcName, label.AnnPVCDeferDeleting, now)
 				resetReplicas(newSet, oldSet)
 				return err
 			}
-			glog.Infof("tikv scale in: set pvc %s/%s annotation: %s to %s",
+			log.Infof("tikv scale in: set pvc %s/%s annotation: %s to %s",
 				ns, pvcName, label.AnnPVCDeferDeleting, now)
 
 			decreaseReplicas(newSet, oldSet)
@@ -143,7 +142,7 @@ func (tsd *tikvScaler) ScaleIn(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulSe
 	//
 	// 2. This can happen when TiKV pod has not been successfully registered in the cluster, such as always pending.
 	//    In this situation we should delete this TiKV pod immediately to avoid blocking the subsequent operations.
-	if !podutil.IsPodReady(pod) {
+	if !podReady(tc, pod, v1alpha1.TiKVMemberType.String()) {
 		pvcName := ordinalPVCName(v1alpha1.TiKVMemberType, setName, ordinal)
 		pvc, err := tsd.pvcLister.PersistentVolumeClaims(ns).Get(pvcName)
 		if err != nil {
@@ -169,12 +168,12 @@ func (tsd *tikvScaler) ScaleIn(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulSe
 		pvc.Annotations[label.AnnPVCDeferDeleting] = now
 		_, err = tsd.pvcControl.UpdatePVC(tc, pvc)
 		if err != nil {
-			glog.Errorf("pod %s not ready, tikv scale in: failed to set pvc %s/%s annotation: %s to %s",
+			log.Errorf("pod %s not ready, tikv scale in: failed to set pvc %s/%s annotation: %s to %s",
 				podName, ns, pvcName, label.AnnPVCDeferDeleting, now)
 			resetReplicas(newSet, oldSet)
 			return err
 		}
-		glog.Infof("pod %s not ready, tikv scale in: set pvc %s/%s annotation: %s to %s",
+		log.Infof("pod %s not ready, tikv scale in: set pvc %s/%s annotation: %s to %s",
 			podName, ns, pvcName, label.AnnPVCDeferDeleting, now)
 		decreaseReplicas(newSet, oldSet)
 		return nil
@@ -18,11 +18,11 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	"github.com/pingcap/tidb-operator/pkg/pdapi"
-	apps "k8s.io/api/apps/v1beta1"
+	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	corelisters "k8s.io/client-go/listers/core/v1"
 )
@@ -30,31 +30,34 @@ import (
 const (
 	// EvictLeaderBeginTime is the key of evict Leader begin time
 	EvictLeaderBeginTime = "evictLeaderBeginTime"
-	// EvictLeaderTimeout is the timeout limit of evict leader
+	// EvictLeaderTimeout is the default timeout limit of evict leader
 	EvictLeaderTimeout = 3 * time.Minute
 )
 
 type tikvUpgrader struct {
-	pdControl  pdapi.PDControlInterface
-	podControl controller.PodControlInterface
-	podLister  corelisters.PodLister
+	pdControl      pdapi.PDControlInterface
+	podControl     controller.PodControlInterface
+	podLister      corelisters.PodLister
+	upgradeChecker UpgradeChecker
 }
 
 // NewTiKVUpgrader returns a tikv Upgrader
 func NewTiKVUpgrader(pdControl pdapi.PDControlInterface,
 	podControl controller.PodControlInterface,
-	podLister corelisters.PodLister) Upgrader {
+	podLister corelisters.PodLister,
+	upgradeChecker UpgradeChecker) Upgrader {
 	return &tikvUpgrader{
-		pdControl:  pdControl,
-		podControl: podControl,
-		podLister:  podLister,
+		pdControl:      pdControl,
+		podControl:     podControl,
+		podLister:      podLister,
+		upgradeChecker: upgradeChecker,
 	}
 }
 
 func (tku *tikvUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
 	ns := tc.GetNamespace()
 	tcName := tc.GetName()
-	if tc.Status.PD.Phase == v1alpha1.UpgradePhase {
+	if tc.Status.PD.Phase == v1alpha1.UpgradePhase || tc.PDMSUpgrading() {
 		_, podSpec, err := GetLastAppliedConfig(oldSet)
 		if err != nil {
 			return err
@@ -67,6 +70,12 @@ func (tku *tikvUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.Stateful
 		return fmt.Errorf("Tidbcluster: [%s/%s]'s tikv status sync failed, can not to be upgraded", ns, tcName)
 	}
 
+	if tc.Status.TiKV.Phase != v1alpha1.UpgradePhase {
+		// the rolling upgrade is about to start
+		if err := tku.upgradeChecker.Check(tc, v1alpha1.TiKVMemberType); err != nil {
+			return err
+		}
+	}
 	tc.Status.TiKV.Phase = v1alpha1.UpgradePhase
 	if !templateEqual(newSet.Spec.Template, oldSet.Spec.Template) {
 		return nil
@@ -82,11 +91,12 @@ func (tku *tikvUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.Stateful
 		// If we encounter this situation, we will let the native statefulset controller do the upgrade completely, which may be unsafe for upgrading tikv.
 		// Therefore, in the production environment, we should try to avoid modifying the tikv statefulset update strategy directly.
 		newSet.Spec.UpdateStrategy = oldSet.Spec.UpdateStrategy
-		glog.Warningf("tidbcluster: [%s/%s] tikv statefulset %s UpdateStrategy has been modified manually", ns, tcName, oldSet.GetName())
+		log.ForCluster(ns, tcName).WithComponent("tikv").Warningf("tikv statefulset %s UpdateStrategy has been modified manually", oldSet.GetName())
 		return nil
 	}
 
 	setUpgradePartition(newSet, *oldSet.Spec.UpdateStrategy.RollingUpdate.Partition)
+	tc.Status.TiKV.Upgrade = newUpgradeProgress(tc.Spec.TiKV.Image, tc.Status.TiKV.StatefulSet)
 	for i := tc.Status.TiKV.StatefulSet.Replicas - 1; i >= 0; i-- {
 		store := tku.getStoreByOrdinal(tc, i)
 		if store == nil {
@@ -105,11 +115,17 @@ func (tku *tikvUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.Stateful
 		if revision == tc.Status.TiKV.StatefulSet.UpdateRevision {
 
 			if pod.Status.Phase != corev1.PodRunning {
+				setUpgradingPod(tc.Status.TiKV.Upgrade, podName, i, v1alpha1.UpgradePodRejoining)
 				return controller.RequeueErrorf("tidbcluster: [%s/%s]'s upgraded tikv pod: [%s] is not running", ns, tcName, podName)
 			}
 			if store.State != v1alpha1.TiKVStateUp {
+				setUpgradingPod(tc.Status.TiKV.Upgrade, podName, i, v1alpha1.UpgradePodRejoining)
 				return controller.RequeueErrorf("tidbcluster: [%s/%s]'s upgraded tikv pod: [%s] is not all ready", ns, tcName, podName)
 			}
+			if remaining := minReadyRemaining(pod, tc.Spec.TiKV.MinReadySeconds, time.Now()); remaining > 0 {
+				setUpgradingPod(tc.Status.TiKV.Upgrade, podName, i, v1alpha1.UpgradePodRejoining)
+				return controller.RequeueAfterErrorf(remaining, "tidbcluster: [%s/%s]'s upgraded tikv pod: [%s] is not ready for %ds", ns, tcName, podName, tc.Spec.TiKV.MinReadySeconds)
+			}
 
 			continue
 		}
@@ -135,38 +151,49 @@ func (tku *tikvUpgrader) upgradeTiKVPod(tc *v1alpha1.TidbCluster, ordinal int32,
 			if err != nil {
 				return err
 			}
+			spec := getEvictLeaderSpec(tc)
 			_, evicting := upgradePod.Annotations[EvictLeaderBeginTime]
 			if !evicting {
+				if store.LeaderCount < spec.SkipLeaderCount {
+					log.Infof("tikv upgrader: skip evicting the %d leaders of store: %d, %s/%s", store.LeaderCount, storeID, ns, upgradePodName)
+					setUpgradingPod(tc.Status.TiKV.Upgrade, upgradePodName, ordinal, v1alpha1.UpgradePodRestarting)
+					setUpgradePartition(newSet, ordinal)
+					return nil
+				}
+				setUpgradingPod(tc.Status.TiKV.Upgrade, upgradePodName, ordinal, v1alpha1.UpgradePodEvictingLeaders)
 				return tku.beginEvictLeader(tc, storeID, upgradePod)
 			}
 
-			if tku.readyToUpgrade(upgradePod, store) {
+			if tku.readyToUpgrade(upgradePod, store, spec) {
 				err := tku.endEvictLeader(tc, ordinal)
 				if err != nil {
 					return err
 				}
+				setUpgradingPod(tc.Status.TiKV.Upgrade, upgradePodName, ordinal, v1alpha1.UpgradePodRestarting)
 				setUpgradePartition(newSet, ordinal)
 				return nil
 			}
 
-			return controller.RequeueErrorf("tidbcluster: [%s/%s]'s tikv pod: [%s] is evicting leader", ns, tcName, upgradePodName)
+			setUpgradingPod(tc.Status.TiKV.Upgrade, upgradePodName, ordinal, v1alpha1.UpgradePodEvictingLeaders)
+			return controller.RequeueAfterErrorf(time.Duration(spec.PollIntervalSeconds)*time.Second,
+				"tidbcluster: [%s/%s]'s tikv pod: [%s] is evicting leader", ns, tcName, upgradePodName)
 		}
 	}
 
 	return controller.RequeueErrorf("tidbcluster: [%s/%s] no store status found for tikv pod: [%s]", ns, tcName, upgradePodName)
 }
 
-func (tku *tikvUpgrader) readyToUpgrade(upgradePod *corev1.Pod, store v1alpha1.TiKVStore) bool {
-	if store.LeaderCount == 0 {
+func (tku *tikvUpgrader) readyToUpgrade(upgradePod *corev1.Pod, store v1alpha1.TiKVStore, spec v1alpha1.TiKVEvictLeaderSpec) bool {
+	if store.LeaderCount <= spec.MaxLeaderCount {
 		return true
 	}
 	if evictLeaderBeginTimeStr, evicting := upgradePod.Annotations[EvictLeaderBeginTime]; evicting {
 		evictLeaderBeginTime, err := time.Parse(time.RFC3339, evictLeaderBeginTimeStr)
 		if err != nil {
-			glog.Errorf("parse annotation:[%s] to time failed.", EvictLeaderBeginTime)
+			log.Errorf("parse annotation:[%s] to time failed.", EvictLeaderBeginTime)
 			return false
 		}
-		if time.Now().After(evictLeaderBeginTime.Add(EvictLeaderTimeout)) {
+		if time.Now().After(evictLeaderBeginTime.Add(time.Duration(spec.TimeoutSeconds) * time.Second)) {
 			return true
 		}
 	}
@@ -178,11 +205,11 @@ func (tku *tikvUpgrader) beginEvictLeader(tc *v1alpha1.TidbCluster, storeID uint
 	podName := pod.GetName()
 	err := controller.GetPDClient(tku.pdControl, tc).BeginEvictLeader(storeID)
 	if err != nil {
-		glog.Errorf("tikv upgrader: failed to begin evict leader: %d, %s/%s, %v",
+		log.Errorf("tikv upgrader: failed to begin evict leader: %d, %s/%s, %v",
 			storeID, ns, podName, err)
 		return err
 	}
-	glog.Infof("tikv upgrader: begin evict leader: %d, %s/%s successfully", storeID, ns, podName)
+	log.Infof("tikv upgrader: begin evict leader: %d, %s/%s successfully", storeID, ns, podName)
 	if pod.Annotations == nil {
 		pod.Annotations = map[string]string{}
 	}
@@ -190,11 +217,11 @@ func (tku *tikvUpgrader) beginEvictLeader(tc *v1alpha1.TidbCluster, storeID uint
 	pod.Annotations[EvictLeaderBeginTime] = now
 	_, err = tku.podControl.UpdatePod(tc, pod)
 	if err != nil {
-		glog.Errorf("tikv upgrader: failed to set pod %s/%s annotation %s to %s, %v",
+		log.Errorf("tikv upgrader: failed to set pod %s/%s annotation %s to %s, %v",
 			ns, podName, EvictLeaderBeginTime, now, err)
 		return err
 	}
-	glog.Infof("tikv upgrader: set pod %s/%s annotation %s to %s successfully",
+	log.Infof("tikv upgrader: set pod %s/%s annotation %s to %s successfully",
 		ns, podName, EvictLeaderBeginTime, now)
 	return nil
 }
@@ -212,13 +239,25 @@ func (tku *tikvUpgrader) endEvictLeader(tc *v1alpha1.TidbCluster, ordinal int32)
 
 	err = tku.pdControl.GetPDClient(pdapi.Namespace(tc.GetNamespace()), tc.GetName(), tc.Spec.EnableTLSCluster).EndEvictLeader(storeID)
 	if err != nil {
-		glog.Errorf("tikv upgrader: failed to end evict leader storeID: %d ordinal: %d, %v", storeID, ordinal, err)
+		log.Errorf("tikv upgrader: failed to end evict leader storeID: %d ordinal: %d, %v", storeID, ordinal, err)
 		return err
 	}
-	glog.Infof("tikv upgrader: end evict leader storeID: %d ordinal: %d successfully", storeID, ordinal)
+	log.Infof("tikv upgrader: end evict leader storeID: %d ordinal: %d successfully", storeID, ordinal)
 	return nil
 }
 
+// getEvictLeaderSpec returns the leader eviction spec of the tikv with the defaults filled
+func getEvictLeaderSpec(tc *v1alpha1.TidbCluster) v1alpha1.TiKVEvictLeaderSpec {
+	spec := v1alpha1.TiKVEvictLeaderSpec{}
+	if tc.Spec.TiKV.EvictLeader != nil {
+		spec = *tc.Spec.TiKV.EvictLeader
+	}
+	if spec.TimeoutSeconds <= 0 {
+		spec.TimeoutSeconds = int64(EvictLeaderTimeout / time.Second)
+	}
+	return spec
+}
+
 func (tku *tikvUpgrader) getStoreByOrdinal(tc *v1alpha1.TidbCluster, ordinal int32) *v1alpha1.TiKVStore {
 	podName := tikvPodName(tc.GetName(), ordinal)
 	for _, store := range tc.Status.TiKV.Stores {
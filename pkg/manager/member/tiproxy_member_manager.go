@@ -0,0 +1,518 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/manager"
+	"github.com/pingcap/tidb-operator/pkg/util"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+)
+
+const (
+	tiproxyServerPort = 6000
+	tiproxyAPIPort    = 3080
+	tiproxyConfigDir  = "/etc/tiproxy"
+	tiproxyTLSDir     = "/var/lib/tiproxy-tls"
+	// tiproxyConfigFileKey is the key of the config file in the configmap
+	tiproxyConfigFileKey = "config-file"
+	// tiproxyConfigDigestAnnotation is the digest of the generated config, the
+	// pods are rolling updated if the config changes
+	tiproxyConfigDigestAnnotation = "pingcap.com/tiproxy-config-digest"
+	// defaultTiDBGracefulWaitSeconds is the default time tiproxy is given to
+	// migrate the connections of a tidb member before it is killed
+	defaultTiDBGracefulWaitSeconds = int64(30)
+)
+
+type tiproxyMemberManager struct {
+	setControl                      controller.StatefulSetControlInterface
+	svcControl                      controller.ServiceControlInterface
+	cmControl                       controller.ConfigMapControlInterface
+	setLister                       appslisters.StatefulSetLister
+	svcLister                       corelisters.ServiceLister
+	cmLister                        corelisters.ConfigMapLister
+	podLister                       corelisters.PodLister
+	tiproxyUpgrader                 Upgrader
+	tiproxyStatefulSetIsUpgradingFn func(corelisters.PodLister, *apps.StatefulSet, *v1alpha1.TidbCluster) (bool, error)
+}
+
+// NewTiProxyMemberManager returns a *tiproxyMemberManager
+func NewTiProxyMemberManager(setControl controller.StatefulSetControlInterface,
+	svcControl controller.ServiceControlInterface,
+	cmControl controller.ConfigMapControlInterface,
+	setLister appslisters.StatefulSetLister,
+	svcLister corelisters.ServiceLister,
+	cmLister corelisters.ConfigMapLister,
+	podLister corelisters.PodLister,
+	tiproxyUpgrader Upgrader) manager.Manager {
+	return &tiproxyMemberManager{
+		setControl:                      setControl,
+		svcControl:                      svcControl,
+		cmControl:                       cmControl,
+		setLister:                       setLister,
+		svcLister:                       svcLister,
+		cmLister:                        cmLister,
+		podLister:                       podLister,
+		tiproxyUpgrader:                 tiproxyUpgrader,
+		tiproxyStatefulSetIsUpgradingFn: tiproxyStatefulSetIsUpgrading,
+	}
+}
+
+func (tpm *tiproxyMemberManager) Sync(tc *v1alpha1.TidbCluster) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	if !tc.TiProxyEnabled() {
+		return nil
+	}
+
+	if !tc.PDIsAvailable() {
+		return controller.RequeueErrorf("TidbCluster: [%s/%s], waiting for PD cluster running", ns, tcName)
+	}
+
+	// Sync TiProxy ConfigMap
+	if err := tpm.syncTiProxyConfigMapForTidbCluster(tc); err != nil {
+		return err
+	}
+
+	// Sync TiProxy Headless Service
+	if err := tpm.syncService(tc, tpm.getNewTiProxyHeadlessServiceForTidbCluster(tc)); err != nil {
+		return err
+	}
+
+	// Sync TiProxy Service
+	if err := tpm.syncService(tc, tpm.getNewTiProxyServiceForTidbCluster(tc)); err != nil {
+		return err
+	}
+
+	// Sync TiProxy StatefulSet
+	return tpm.syncTiProxyStatefulSetForTidbCluster(tc)
+}
+
+func (tpm *tiproxyMemberManager) syncTiProxyConfigMapForTidbCluster(tc *v1alpha1.TidbCluster) error {
+	newCm := tpm.getNewTiProxyConfigMapForTidbCluster(tc)
+	oldCmTmp, err := tpm.cmLister.ConfigMaps(newCm.Namespace).Get(newCm.Name)
+	if errors.IsNotFound(err) {
+		return tpm.cmControl.CreateConfigMap(tc, newCm)
+	}
+	if err != nil {
+		return err
+	}
+
+	if oldCmTmp.Data[tiproxyConfigFileKey] == newCm.Data[tiproxyConfigFileKey] {
+		return nil
+	}
+	cm := oldCmTmp.DeepCopy()
+	cm.Data = newCm.Data
+	_, err = tpm.cmControl.UpdateConfigMap(tc, cm)
+	return err
+}
+
+func (tpm *tiproxyMemberManager) syncService(tc *v1alpha1.TidbCluster, newSvc *corev1.Service) error {
+	oldSvcTmp, err := tpm.svcLister.Services(newSvc.Namespace).Get(newSvc.Name)
+	if errors.IsNotFound(err) {
+		err = SetServiceLastAppliedConfigAnnotation(newSvc)
+		if err != nil {
+			return err
+		}
+		return tpm.svcControl.CreateService(tc, newSvc)
+	}
+	if err != nil {
+		return err
+	}
+
+	oldSvc := oldSvcTmp.DeepCopy()
+
+	return updateService(tpm.svcControl, tc, newSvc, oldSvc)
+}
+
+func (tpm *tiproxyMemberManager) syncTiProxyStatefulSetForTidbCluster(tc *v1alpha1.TidbCluster) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	newTiProxySet := tpm.getNewTiProxySetForTidbCluster(tc)
+	oldTiProxySetTemp, err := tpm.setLister.StatefulSets(ns).Get(controller.TiProxyMemberName(tcName))
+	if errors.IsNotFound(err) {
+		err = SetLastAppliedConfigAnnotation(newTiProxySet)
+		if err != nil {
+			return err
+		}
+		err = tpm.setControl.CreateStatefulSet(tc, newTiProxySet)
+		if err != nil {
+			return err
+		}
+		tc.Status.TiProxy.StatefulSet = &apps.StatefulSetStatus{}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	oldTiProxySet := oldTiProxySetTemp.DeepCopy()
+
+	if err = tpm.syncTidbClusterStatus(tc, oldTiProxySet); err != nil {
+		return err
+	}
+
+	if !templateEqual(newTiProxySet.Spec.Template, oldTiProxySet.Spec.Template) || tc.Status.TiProxy.Phase == v1alpha1.UpgradePhase {
+		if err := tpm.tiproxyUpgrader.Upgrade(tc, oldTiProxySet, newTiProxySet); err != nil {
+			return err
+		}
+	}
+
+	return updateStatefulSet(tpm.setControl, tc, newTiProxySet, oldTiProxySet)
+}
+
+func (tpm *tiproxyMemberManager) getNewTiProxyConfigMapForTidbCluster(tc *v1alpha1.TidbCluster) *corev1.ConfigMap {
+	instanceName := tc.GetLabels()[label.InstanceLabelKey]
+	tiproxyLabel := label.New().Instance(instanceName).TiProxy().Labels()
+
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            controller.TiProxyMemberName(tc.Name),
+			Namespace:       tc.Namespace,
+			Labels:          tiproxyLabel,
+			OwnerReferences: []metav1.OwnerReference{controller.GetOwnerRef(tc)},
+		},
+		Data: map[string]string{
+			tiproxyConfigFileKey: getTiProxyConfig(tc),
+		},
+	}
+}
+
+// getTiProxyConfig generates the config of tiproxy, tiproxy discovers the
+// tidb members from pd, the user config is appended to it
+func getTiProxyConfig(tc *v1alpha1.TidbCluster) string {
+	caPath := "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+	certPath := fmt.Sprintf("%s/tiproxy.crt", tiproxyTLSDir)
+	keyPath := fmt.Sprintf("%s/tiproxy.key", tiproxyTLSDir)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "[proxy]\naddr = \"0.0.0.0:%d\"\npd-addrs = \"%s:2379\"\n", tiproxyServerPort, controller.PDMemberName(tc.Name))
+	fmt.Fprintf(&buf, "\n[api]\naddr = \"0.0.0.0:%d\"\n", tiproxyAPIPort)
+	if tc.Spec.EnableTLSCluster {
+		// used to connect to pd and the status port of tidb
+		fmt.Fprintf(&buf, "\n[security.cluster-tls]\nca = %q\ncert = %q\nkey = %q\n", caPath, certPath, keyPath)
+	}
+	if tc.Spec.TiDB.EnableTLSClient {
+		// the clients connect to tiproxy by TLS, and so does tiproxy to tidb
+		fmt.Fprintf(&buf, "\n[security.server-tls]\ncert = %q\nkey = %q\n", certPath, keyPath)
+		fmt.Fprintf(&buf, "\n[security.sql-tls]\nca = %q\n", caPath)
+	}
+	if len(tc.Spec.TiProxy.Config) > 0 {
+		fmt.Fprintf(&buf, "\n%s\n", tc.Spec.TiProxy.Config)
+	}
+	return buf.String()
+}
+
+func (tpm *tiproxyMemberManager) getNewTiProxyHeadlessServiceForTidbCluster(tc *v1alpha1.TidbCluster) *corev1.Service {
+	instanceName := tc.GetLabels()[label.InstanceLabelKey]
+	tiproxyLabel := label.New().Instance(instanceName).TiProxy().Labels()
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            controller.TiProxyPeerMemberName(tc.Name),
+			Namespace:       tc.Namespace,
+			Labels:          tiproxyLabel,
+			OwnerReferences: []metav1.OwnerReference{controller.GetOwnerRef(tc)},
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: "None",
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "api",
+					Port:       tiproxyAPIPort,
+					TargetPort: intstr.FromInt(tiproxyAPIPort),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+			Selector:                 tiproxyLabel,
+			PublishNotReadyAddresses: true,
+		},
+	}
+}
+
+func (tpm *tiproxyMemberManager) getNewTiProxyServiceForTidbCluster(tc *v1alpha1.TidbCluster) *corev1.Service {
+	instanceName := tc.GetLabels()[label.InstanceLabelKey]
+	tiproxyLabel := label.New().Instance(instanceName).TiProxy().Labels()
+
+	svcType := tc.Spec.TiProxy.ServiceType
+	if svcType == "" {
+		svcType = corev1.ServiceTypeClusterIP
+	}
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            controller.TiProxyMemberName(tc.Name),
+			Namespace:       tc.Namespace,
+			Labels:          tiproxyLabel,
+			OwnerReferences: []metav1.OwnerReference{controller.GetOwnerRef(tc)},
+		},
+		Spec: corev1.ServiceSpec{
+			Type: svcType,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "mysql-client",
+					Port:       tiproxyServerPort,
+					TargetPort: intstr.FromInt(tiproxyServerPort),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+			Selector: tiproxyLabel,
+		},
+	}
+}
+
+func (tpm *tiproxyMemberManager) getNewTiProxySetForTidbCluster(tc *v1alpha1.TidbCluster) *apps.StatefulSet {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	instanceName := tc.GetLabels()[label.InstanceLabelKey]
+	spec := tc.Spec.TiProxy
+
+	volMounts := []corev1.VolumeMount{
+		{Name: "config", ReadOnly: true, MountPath: tiproxyConfigDir},
+	}
+	vols := []corev1.Volume{
+		{Name: "config", VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: controller.TiProxyMemberName(tcName),
+				},
+				Items: []corev1.KeyToPath{{Key: tiproxyConfigFileKey, Path: "tiproxy.toml"}},
+			}},
+		},
+	}
+	if tc.Spec.EnableTLSCluster || tc.Spec.TiDB.EnableTLSClient {
+		volMounts = append(volMounts, corev1.VolumeMount{
+			Name: "tiproxy-tls", ReadOnly: true, MountPath: tiproxyTLSDir,
+		})
+		vols = append(vols, corev1.Volume{
+			Name: "tiproxy-tls", VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: controller.TiProxyMemberName(tcName),
+				},
+			},
+		})
+	}
+
+	envs := []corev1.EnvVar{
+		{
+			Name: "POD_NAME",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+			},
+		},
+		{
+			Name:  "TZ",
+			Value: tc.Spec.Timezone,
+		},
+	}
+
+	dnsPolicy := corev1.DNSClusterFirst // same as k8s defaults
+	if spec.HostNetwork {
+		dnsPolicy = corev1.DNSClusterFirstWithHostNet
+	}
+
+	tiproxyLabel := label.New().Instance(instanceName).TiProxy()
+	podAnnotations := CombineAnnotations(map[string]string{
+		"prometheus.io/scrape":        "true",
+		"prometheus.io/path":          "/api/metrics",
+		"prometheus.io/port":          fmt.Sprintf("%d", tiproxyAPIPort),
+		tiproxyConfigDigestAnnotation: fmt.Sprintf("%x", sha256.Sum256([]byte(getTiProxyConfig(tc))))[:8],
+	}, spec.Annotations)
+
+	return &apps.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            controller.TiProxyMemberName(tcName),
+			Namespace:       ns,
+			Labels:          tiproxyLabel.Labels(),
+			OwnerReferences: []metav1.OwnerReference{controller.GetOwnerRef(tc)},
+		},
+		Spec: apps.StatefulSetSpec{
+			Replicas: controller.Int32Ptr(spec.Replicas),
+			Selector: tiproxyLabel.LabelSelector(),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      tiproxyLabel.Labels(),
+					Annotations: podAnnotations,
+				},
+				Spec: corev1.PodSpec{
+					SchedulerName: tc.Spec.SchedulerName,
+					Affinity:      spec.Affinity,
+					NodeSelector:  spec.NodeSelector,
+					HostNetwork:   spec.HostNetwork,
+					DNSPolicy:     dnsPolicy,
+					Containers: []corev1.Container{
+						{
+							Name:            v1alpha1.TiProxyMemberType.String(),
+							Image:           spec.Image,
+							ImagePullPolicy: spec.ImagePullPolicy,
+							Command:         []string{"/bin/tiproxy"},
+							Args: []string{
+								fmt.Sprintf("--config=%s/tiproxy.toml", tiproxyConfigDir),
+								// tiproxy registers the address in pd
+								fmt.Sprintf("--advertise-addr=$(POD_NAME).%s.%s.svc", controller.TiProxyPeerMemberName(tcName), ns),
+							},
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "server",
+									ContainerPort: int32(tiproxyServerPort),
+									Protocol:      corev1.ProtocolTCP,
+								},
+								{
+									Name:          "api", // status, metrics
+									ContainerPort: int32(tiproxyAPIPort),
+									Protocol:      corev1.ProtocolTCP,
+								},
+							},
+							VolumeMounts: volMounts,
+							Resources:    util.ResourceRequirement(spec.ContainerSpec),
+							Env:          envs,
+							ReadinessProbe: &corev1.Probe{
+								Handler: corev1.Handler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/api/debug/health",
+										Port: intstr.FromInt(tiproxyAPIPort),
+									},
+								},
+								InitialDelaySeconds: int32(10),
+							},
+						},
+					},
+					RestartPolicy:     corev1.RestartPolicyAlways,
+					Tolerations:       spec.Tolerations,
+					Volumes:           vols,
+					SecurityContext:   podSecurityContext(spec.PodSecurityContext),
+					PriorityClassName: spec.PriorityClassName,
+				},
+			},
+			ServiceName:         controller.TiProxyPeerMemberName(tcName),
+			PodManagementPolicy: apps.ParallelPodManagement,
+			UpdateStrategy: apps.StatefulSetUpdateStrategy{Type: apps.RollingUpdateStatefulSetStrategyType,
+				RollingUpdate: &apps.RollingUpdateStatefulSetStrategy{Partition: controller.Int32Ptr(spec.Replicas)},
+			},
+		},
+	}
+}
+
+func (tpm *tiproxyMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, set *apps.StatefulSet) error {
+	tc.Status.TiProxy.StatefulSet = &set.Status
+
+	upgrading, err := tpm.tiproxyStatefulSetIsUpgradingFn(tpm.podLister, set, tc)
+	if err != nil {
+		return err
+	}
+	if upgrading && !tc.TiDBUpgrading() {
+		tc.Status.TiProxy.Phase = v1alpha1.UpgradePhase
+	} else {
+		tc.Status.TiProxy.Phase = v1alpha1.NormalPhase
+	}
+
+	selector, err := label.New().Instance(tc.GetLabels()[label.InstanceLabelKey]).TiProxy().Selector()
+	if err != nil {
+		return err
+	}
+	pods, err := tpm.podLister.Pods(tc.GetNamespace()).List(selector)
+	if err != nil {
+		return err
+	}
+
+	// tiproxy has no cluster membership, a member is healthy if its pod is ready
+	tiproxyStatus := map[string]v1alpha1.TiProxyMember{}
+	for _, pod := range pods {
+		newMember := v1alpha1.TiProxyMember{
+			Name:   pod.Name,
+			Health: podReady(tc, pod, v1alpha1.TiProxyMemberType.String()),
+		}
+		newMember.LastTransitionTime = metav1.Now()
+		if oldMember, exist := tc.Status.TiProxy.Members[pod.Name]; exist && oldMember.Health == newMember.Health {
+			newMember.LastTransitionTime = oldMember.LastTransitionTime
+		}
+		tiproxyStatus[pod.Name] = newMember
+	}
+	tc.Status.TiProxy.Members = tiproxyStatus
+
+	return nil
+}
+
+func tiproxyStatefulSetIsUpgrading(podLister corelisters.PodLister, set *apps.StatefulSet, tc *v1alpha1.TidbCluster) (bool, error) {
+	if statefulSetIsUpgrading(set) {
+		return true, nil
+	}
+	selector, err := label.New().
+		Instance(tc.GetLabels()[label.InstanceLabelKey]).
+		TiProxy().
+		Selector()
+	if err != nil {
+		return false, err
+	}
+	tiproxyPods, err := podLister.Pods(tc.GetNamespace()).List(selector)
+	if err != nil {
+		return false, err
+	}
+	for _, pod := range tiproxyPods {
+		revisionHash, exist := pod.Labels[apps.ControllerRevisionHashLabelKey]
+		if !exist {
+			return false, nil
+		}
+		if revisionHash != tc.Status.TiProxy.StatefulSet.UpdateRevision {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// tidbTerminationGracePeriodSeconds returns the termination grace period of
+// the tidb pods, it's extended to give tiproxy time to migrate the connections
+// if tiproxy is enabled
+func tidbTerminationGracePeriodSeconds(tc *v1alpha1.TidbCluster) *int64 {
+	if !tc.TiProxyEnabled() {
+		return nil
+	}
+	wait := defaultTiDBGracefulWaitSeconds
+	if tc.Spec.TiProxy.TiDBGracefulWaitSeconds != nil {
+		wait = *tc.Spec.TiProxy.TiDBGracefulWaitSeconds
+	}
+	period := corev1.DefaultTerminationGracePeriodSeconds + wait
+	return &period
+}
+
+type FakeTiProxyMemberManager struct {
+	err error
+}
+
+func NewFakeTiProxyMemberManager() *FakeTiProxyMemberManager {
+	return &FakeTiProxyMemberManager{}
+}
+
+func (ftpm *FakeTiProxyMemberManager) SetSyncError(err error) {
+	ftpm.err = err
+}
+
+func (ftpm *FakeTiProxyMemberManager) Sync(_ *v1alpha1.TidbCluster) error {
+	if ftpm.err != nil {
+		return ftpm.err
+	}
+	return nil
+}
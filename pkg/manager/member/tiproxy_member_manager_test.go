@@ -0,0 +1,198 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned/fake"
+	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	corelisters "k8s.io/client-go/listers/core/v1"
+)
+
+func TestTiProxyMemberManagerSyncCreate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForTiProxy()
+	tpm, setControl, svcLister, cmLister := newFakeTiProxyMemberManager()
+
+	// tiproxy is not synced if it's not enabled
+	disabled := tc.DeepCopy()
+	disabled.Spec.TiProxy = nil
+	g.Expect(tpm.Sync(disabled)).To(Succeed())
+	_, err := tpm.setLister.StatefulSets(tc.Namespace).Get(controller.TiProxyMemberName(tc.Name))
+	g.Expect(err).To(HaveOccurred())
+
+	// waiting for pd
+	unavailable := tc.DeepCopy()
+	unavailable.Status.PD.Members = nil
+	err = tpm.Sync(unavailable)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+
+	g.Expect(tpm.Sync(tc)).To(Succeed())
+	cm, err := cmLister.ConfigMaps(tc.Namespace).Get(controller.TiProxyMemberName(tc.Name))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cm.Data[tiproxyConfigFileKey]).To(Equal(getTiProxyConfig(tc)))
+	_, err = svcLister.Services(tc.Namespace).Get(controller.TiProxyMemberName(tc.Name))
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = svcLister.Services(tc.Namespace).Get(controller.TiProxyPeerMemberName(tc.Name))
+	g.Expect(err).NotTo(HaveOccurred())
+	set, err := setControl.SetLister.StatefulSets(tc.Namespace).Get(controller.TiProxyMemberName(tc.Name))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(*set.Spec.Replicas).To(Equal(int32(2)))
+	g.Expect(set.Spec.Template.Annotations).To(HaveKey(tiproxyConfigDigestAnnotation))
+	g.Expect(tc.Status.TiProxy.StatefulSet).NotTo(BeNil())
+}
+
+func TestGetTiProxyConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForTiProxy()
+	tc.Spec.TiProxy.Config = "[log]\nlevel = \"warn\""
+	config := getTiProxyConfig(tc)
+	g.Expect(config).To(ContainSubstring("pd-addrs = \"test-pd:2379\""))
+	g.Expect(config).To(ContainSubstring("addr = \"0.0.0.0:6000\""))
+	g.Expect(config).To(ContainSubstring("addr = \"0.0.0.0:3080\""))
+	g.Expect(config).NotTo(ContainSubstring("[security"))
+	g.Expect(config).To(HaveSuffix("[log]\nlevel = \"warn\"\n"))
+
+	tc.Spec.EnableTLSCluster = true
+	config = getTiProxyConfig(tc)
+	g.Expect(config).To(ContainSubstring("[security.cluster-tls]"))
+	g.Expect(config).NotTo(ContainSubstring("[security.server-tls]"))
+
+	tc.Spec.TiDB.EnableTLSClient = true
+	config = getTiProxyConfig(tc)
+	g.Expect(config).To(ContainSubstring("[security.server-tls]"))
+	g.Expect(config).To(ContainSubstring("[security.sql-tls]"))
+
+	// the config change rolls the pods
+	tpm, _, _, _ := newFakeTiProxyMemberManager()
+	set1 := tpm.getNewTiProxySetForTidbCluster(tc)
+	tc.Spec.TiProxy.Config = "[log]\nlevel = \"info\""
+	set2 := tpm.getNewTiProxySetForTidbCluster(tc)
+	g.Expect(templateEqual(set1.Spec.Template, set2.Spec.Template)).To(BeFalse())
+	g.Expect(set2.Spec.Template.Spec.Volumes).To(HaveLen(2))
+}
+
+func TestTiDBTerminationGracePeriodSeconds(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForTiProxy()
+	g.Expect(*tidbTerminationGracePeriodSeconds(tc)).To(Equal(int64(60)))
+
+	wait := int64(120)
+	tc.Spec.TiProxy.TiDBGracefulWaitSeconds = &wait
+	g.Expect(*tidbTerminationGracePeriodSeconds(tc)).To(Equal(int64(150)))
+
+	tc.Spec.TiProxy = nil
+	g.Expect(tidbTerminationGracePeriodSeconds(tc)).To(BeNil())
+}
+
+func TestTiProxyUpgrader(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForTiProxy()
+	tc.Status.TiProxy.StatefulSet = &apps.StatefulSetStatus{
+		Replicas:        2,
+		CurrentRevision: "1",
+		UpdateRevision:  "2",
+	}
+	kubeCli := kubefake.NewSimpleClientset()
+	podInformer := kubeinformers.NewSharedInformerFactory(kubeCli, 0).Core().V1().Pods()
+	for i := int32(0); i < 2; i++ {
+		podInformer.Informer().GetIndexer().Add(&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      tiproxyPodName(tc.Name, i),
+				Namespace: tc.Namespace,
+				Labels:    map[string]string{apps.ControllerRevisionHashLabelKey: "1"},
+			},
+		})
+	}
+	upgrader := NewTiProxyUpgrader(podInformer.Lister())
+	newSet := func() (*apps.StatefulSet, *apps.StatefulSet) {
+		tpm, _, _, _ := newFakeTiProxyMemberManager()
+		oldSet := tpm.getNewTiProxySetForTidbCluster(tc)
+		g.Expect(SetLastAppliedConfigAnnotation(oldSet)).To(Succeed())
+		set := oldSet.DeepCopy()
+		set.Spec.Template.Spec.Containers[0].Image = "tiproxy:v2"
+		return oldSet, set
+	}
+
+	// tiproxy is not upgraded while tidb is upgrading
+	tc.Status.TiDB.Phase = v1alpha1.UpgradePhase
+	oldSet, set := newSet()
+	g.Expect(upgrader.Upgrade(tc, oldSet, set)).To(Succeed())
+	g.Expect(set.Spec.Template.Spec.Containers[0].Image).To(Equal(v1alpha1.TiProxyMemberType.String()))
+	g.Expect(tc.Status.TiProxy.Phase).NotTo(Equal(v1alpha1.UpgradePhase))
+
+	tc.Status.TiDB.Phase = v1alpha1.NormalPhase
+	oldSet, set = newSet()
+	set.Spec.Template = oldSet.Spec.Template
+	g.Expect(upgrader.Upgrade(tc, oldSet, set)).To(Succeed())
+	g.Expect(tc.Status.TiProxy.Phase).To(Equal(v1alpha1.UpgradePhase))
+	g.Expect(*set.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(1)))
+}
+
+func newFakeTiProxyMemberManager() (*tiproxyMemberManager, *controller.FakeStatefulSetControl, corelisters.ServiceLister, corelisters.ConfigMapLister) {
+	cli := fake.NewSimpleClientset()
+	kubeCli := kubefake.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeCli, 0)
+	setInformer := kubeInformerFactory.Apps().V1().StatefulSets()
+	tcInformer := informers.NewSharedInformerFactory(cli, 0).Pingcap().V1alpha1().TidbClusters()
+	svcInformer := kubeInformerFactory.Core().V1().Services()
+	epsInformer := kubeInformerFactory.Core().V1().Endpoints()
+	cmInformer := kubeInformerFactory.Core().V1().ConfigMaps()
+	podInformer := kubeInformerFactory.Core().V1().Pods()
+	setControl := controller.NewFakeStatefulSetControl(setInformer, tcInformer)
+	svcControl := controller.NewFakeServiceControl(svcInformer, epsInformer, tcInformer)
+	cmControl := controller.NewFakeConfigMapControl(cmInformer)
+
+	tpm := &tiproxyMemberManager{
+		setControl:                      setControl,
+		svcControl:                      svcControl,
+		cmControl:                       cmControl,
+		setLister:                       setInformer.Lister(),
+		svcLister:                       svcInformer.Lister(),
+		cmLister:                        cmInformer.Lister(),
+		podLister:                       podInformer.Lister(),
+		tiproxyUpgrader:                 NewFakeTiProxyUpgrader(),
+		tiproxyStatefulSetIsUpgradingFn: tiproxyStatefulSetIsUpgrading,
+	}
+	return tpm, setControl, svcInformer.Lister(), cmInformer.Lister()
+}
+
+func newTidbClusterForTiProxy() *v1alpha1.TidbCluster {
+	tc := newTidbClusterForTiDB()
+	tc.Spec.PD.Replicas = 1
+	tc.Status.PD.Members = map[string]v1alpha1.PDMember{
+		"test-pd-0": {Name: "test-pd-0", Health: true},
+	}
+	tc.Status.PD.StatefulSet = &apps.StatefulSetStatus{ReadyReplicas: 1}
+	tc.Spec.TiProxy = &v1alpha1.TiProxySpec{
+		ContainerSpec: v1alpha1.ContainerSpec{
+			Image: v1alpha1.TiProxyMemberType.String(),
+		},
+		Replicas: 2,
+	}
+	return tc
+}
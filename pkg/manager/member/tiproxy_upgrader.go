@@ -0,0 +1,102 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	apps "k8s.io/api/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+)
+
+type tiproxyUpgrader struct {
+	podLister corelisters.PodLister
+}
+
+// NewTiProxyUpgrader returns a tiproxy Upgrader
+func NewTiProxyUpgrader(podLister corelisters.PodLister) Upgrader {
+	return &tiproxyUpgrader{
+		podLister: podLister,
+	}
+}
+
+func (tpu *tiproxyUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	// tiproxy migrates the connections of the restarting tidb members, so it's
+	// not upgraded at the same time as the other components
+	if tc.Status.PD.Phase == v1alpha1.UpgradePhase ||
+		tc.Status.TiKV.Phase == v1alpha1.UpgradePhase ||
+		tc.Status.TiDB.Phase == v1alpha1.UpgradePhase {
+		_, podSpec, err := GetLastAppliedConfig(oldSet)
+		if err != nil {
+			return err
+		}
+		newSet.Spec.Template.Spec = *podSpec
+		return nil
+	}
+
+	tc.Status.TiProxy.Phase = v1alpha1.UpgradePhase
+	if !templateEqual(newSet.Spec.Template, oldSet.Spec.Template) {
+		return nil
+	}
+
+	if tc.Status.TiProxy.StatefulSet.UpdateRevision == tc.Status.TiProxy.StatefulSet.CurrentRevision {
+		return nil
+	}
+
+	if oldSet.Spec.UpdateStrategy.Type == apps.OnDeleteStatefulSetStrategyType || oldSet.Spec.UpdateStrategy.RollingUpdate == nil {
+		newSet.Spec.UpdateStrategy = oldSet.Spec.UpdateStrategy
+		log.ForCluster(ns, tcName).WithComponent("tiproxy").Warningf("tiproxy statefulset %s UpdateStrategy has been modified manually", oldSet.GetName())
+		return nil
+	}
+
+	setUpgradePartition(newSet, *oldSet.Spec.UpdateStrategy.RollingUpdate.Partition)
+	for i := tc.Status.TiProxy.StatefulSet.Replicas - 1; i >= 0; i-- {
+		podName := tiproxyPodName(tcName, i)
+		pod, err := tpu.podLister.Pods(ns).Get(podName)
+		if err != nil {
+			return err
+		}
+		revision, exist := pod.Labels[apps.ControllerRevisionHashLabelKey]
+		if !exist {
+			return controller.RequeueErrorf("tidbcluster: [%s/%s]'s tiproxy pod: [%s] has no label: %s", ns, tcName, podName, apps.ControllerRevisionHashLabelKey)
+		}
+
+		if revision == tc.Status.TiProxy.StatefulSet.UpdateRevision {
+			if member, exist := tc.Status.TiProxy.Members[podName]; !exist || !member.Health {
+				return controller.RequeueErrorf("tidbcluster: [%s/%s]'s tiproxy upgraded pod: [%s] is not ready", ns, tcName, podName)
+			}
+			continue
+		}
+		setUpgradePartition(newSet, i)
+		return nil
+	}
+
+	return nil
+}
+
+type fakeTiProxyUpgrader struct{}
+
+// NewFakeTiProxyUpgrader returns a fake tiproxy upgrader
+func NewFakeTiProxyUpgrader() Upgrader {
+	return &fakeTiProxyUpgrader{}
+}
+
+func (ftpu *fakeTiProxyUpgrader) Upgrade(tc *v1alpha1.TidbCluster, _ *apps.StatefulSet, _ *apps.StatefulSet) error {
+	tc.Status.TiProxy.Phase = v1alpha1.UpgradePhase
+	return nil
+}
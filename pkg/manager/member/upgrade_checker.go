@@ -0,0 +1,186 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	upgradeCheckReasonHealthy             = "Healthy"
+	upgradeCheckReasonForced              = "ForceUpgrade"
+	upgradeCheckReasonPDQuorumNotFull     = "PDQuorumNotFull"
+	upgradeCheckReasonTiKVStoreNotUp      = "TiKVStoreNotUp"
+	upgradeCheckReasonRegionsUnhealthy    = "RegionsUnhealthy"
+	upgradeCheckReasonOperationInFlight   = "OperationInFlight"
+	upgradeCheckReasonCheckFailed         = "CheckFailed"
+	upgradeCheckEventReasonUpgradeRefused = "UpgradeRefused"
+)
+
+// UpgradeChecker checks whether the cluster is healthy enough before a rolling upgrade
+// of a component is started, the upgrade is refused until the cluster is healthy or the
+// TidbCluster is annotated with tidb.pingcap.com/force-upgrade=true:
+//   - all the pd members are healthy, i.e. the pd cluster has the full quorum
+//   - no tikv store is Down or Offline
+//   - no region lacks a healthy majority of the peers
+//   - no failover, leader eviction or pod restart is in flight
+//
+// The result is recorded in the UpgradeHealthy condition of the TidbCluster status, and
+// an event is recorded if the upgrade is refused.
+type UpgradeChecker interface {
+	Check(*v1alpha1.TidbCluster, v1alpha1.MemberType) error
+}
+
+type upgradeChecker struct {
+	pdControl pdapi.PDControlInterface
+	recorder  record.EventRecorder
+}
+
+// NewUpgradeChecker returns a UpgradeChecker
+func NewUpgradeChecker(pdControl pdapi.PDControlInterface, recorder record.EventRecorder) UpgradeChecker {
+	return &upgradeChecker{pdControl, recorder}
+}
+
+func (uc *upgradeChecker) Check(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	if needForceUpgrade(tc) {
+		uc.setCondition(tc, corev1.ConditionTrue, upgradeCheckReasonForced, fmt.Sprintf("the health checks are skipped before upgrading %s", memberType))
+		return nil
+	}
+
+	reason, message := uc.check(tc)
+	if reason == "" {
+		uc.setCondition(tc, corev1.ConditionTrue, upgradeCheckReasonHealthy, fmt.Sprintf("the cluster is healthy to upgrade %s", memberType))
+		return nil
+	}
+
+	message = fmt.Sprintf("refuse to upgrade %s: %s", memberType, message)
+	if uc.setCondition(tc, corev1.ConditionFalse, reason, message) {
+		uc.recorder.Event(tc, corev1.EventTypeWarning, upgradeCheckEventReasonUpgradeRefused, message)
+	}
+	log.Infof("upgrade checker: tidbcluster: [%s/%s] %s", ns, tcName, message)
+	return controller.RequeueErrorf("tidbcluster: [%s/%s] %s", ns, tcName, message)
+}
+
+// check returns the reason and the message of the failed check, or an empty reason if the cluster is healthy
+func (uc *upgradeChecker) check(tc *v1alpha1.TidbCluster) (string, string) {
+	var unhealthy []string
+	for name, member := range tc.Status.PD.Members {
+		if !member.Health {
+			unhealthy = append(unhealthy, name)
+		}
+	}
+	if len(unhealthy) > 0 {
+		sort.Strings(unhealthy)
+		return upgradeCheckReasonPDQuorumNotFull, fmt.Sprintf("pd members %v are unhealthy", unhealthy)
+	}
+	if int32(len(tc.Status.PD.Members)) < tc.Spec.PD.Replicas {
+		return upgradeCheckReasonPDQuorumNotFull, fmt.Sprintf("%d of %d pd members are found", len(tc.Status.PD.Members), tc.Spec.PD.Replicas)
+	}
+
+	for id, store := range tc.Status.TiKV.Stores {
+		if store.State == v1alpha1.TiKVStateDown || store.State == v1alpha1.TiKVStateOffline {
+			unhealthy = append(unhealthy, fmt.Sprintf("%s(%s)", id, store.State))
+		}
+	}
+	if len(unhealthy) > 0 {
+		sort.Strings(unhealthy)
+		return upgradeCheckReasonTiKVStoreNotUp, fmt.Sprintf("tikv stores %v are not up", unhealthy)
+	}
+
+	if len(tc.Status.PD.FailureMembers) > 0 || len(tc.Status.TiKV.FailureStores) > 0 || len(tc.Status.TiDB.FailureMembers) > 0 {
+		return upgradeCheckReasonOperationInFlight, "the failover is in progress"
+	}
+	if msg := statefulSetNotReady(tc); msg != "" {
+		return upgradeCheckReasonOperationInFlight, msg
+	}
+
+	pdClient := controller.GetPDClient(uc.pdControl, tc)
+	schedulers, err := pdClient.GetEvictLeaderSchedulers()
+	if err != nil {
+		return upgradeCheckReasonCheckFailed, fmt.Sprintf("failed to get the evict leader schedulers, %v", err)
+	}
+	if len(schedulers) > 0 {
+		return upgradeCheckReasonOperationInFlight, fmt.Sprintf("the leaders are being evicted by %v", schedulers)
+	}
+
+	regions, err := pdClient.GetRegionsByCheck(pdapi.RegionCheckDownPeer)
+	if err != nil {
+		return upgradeCheckReasonCheckFailed, fmt.Sprintf("failed to get the regions with down peers, %v", err)
+	}
+	count := 0
+	for _, region := range regions.Regions {
+		if !region.HasHealthyMajority() {
+			count++
+		}
+	}
+	if count > 0 {
+		return upgradeCheckReasonRegionsUnhealthy, fmt.Sprintf("%d regions lack a healthy majority of the peers", count)
+	}
+	return "", ""
+}
+
+// setCondition returns true if the condition is changed
+func (uc *upgradeChecker) setCondition(tc *v1alpha1.TidbCluster, status corev1.ConditionStatus, reason, message string) bool {
+	return v1alpha1.UpdateTidbClusterCondition(&tc.Status, &v1alpha1.TidbClusterCondition{
+		Type:    v1alpha1.TidbClusterUpgradeHealthy,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+// statefulSetNotReady returns the message if any pod of pd, tikv or tidb is not ready, e.g. being restarted
+func statefulSetNotReady(tc *v1alpha1.TidbCluster) string {
+	for _, component := range []struct {
+		memberType v1alpha1.MemberType
+		status     *apps.StatefulSetStatus
+	}{
+		{v1alpha1.PDMemberType, tc.Status.PD.StatefulSet},
+		{v1alpha1.TiKVMemberType, tc.Status.TiKV.StatefulSet},
+		{v1alpha1.TiDBMemberType, tc.Status.TiDB.StatefulSet},
+	} {
+		if component.status == nil {
+			continue
+		}
+		if component.status.ReadyReplicas < component.status.Replicas {
+			return fmt.Sprintf("%d of %d %s pods are ready", component.status.ReadyReplicas, component.status.Replicas, component.memberType)
+		}
+	}
+	return ""
+}
+
+var _ UpgradeChecker = &upgradeChecker{}
+
+type fakeUpgradeChecker struct{}
+
+// NewFakeUpgradeChecker returns a fake upgrade checker
+func NewFakeUpgradeChecker() UpgradeChecker {
+	return &fakeUpgradeChecker{}
+}
+
+func (fuc *fakeUpgradeChecker) Check(_ *v1alpha1.TidbCluster, _ v1alpha1.MemberType) error {
+	return nil
+}
@@ -0,0 +1,170 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestUpgradeCheckerCheck(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name         string
+		changeFn     func(*v1alpha1.TidbCluster)
+		schedulers   []string
+		regions      []*pdapi.RegionInfo
+		expectReason string
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		tc := newTidbClusterForUpgradeChecker()
+		if test.changeFn != nil {
+			test.changeFn(tc)
+		}
+		pdControl := pdapi.NewFakePDControl()
+		pdClient := controller.NewFakePDClient(pdControl, tc)
+		pdClient.AddReaction(pdapi.GetEvictLeaderSchedulersActionType, func(action *pdapi.Action) (interface{}, error) {
+			return test.schedulers, nil
+		})
+		pdClient.AddReaction(pdapi.GetRegionsByCheckActionType, func(action *pdapi.Action) (interface{}, error) {
+			g.Expect(action.Name).To(Equal(string(pdapi.RegionCheckDownPeer)))
+			return &pdapi.RegionsInfo{Count: len(test.regions), Regions: test.regions}, nil
+		})
+		recorder := record.NewFakeRecorder(10)
+
+		err := NewUpgradeChecker(pdControl, recorder).Check(tc, v1alpha1.TiKVMemberType)
+		_, condition := v1alpha1.GetTidbClusterCondition(&tc.Status, v1alpha1.TidbClusterUpgradeHealthy)
+		g.Expect(condition).NotTo(BeNil())
+		g.Expect(condition.Reason).To(Equal(test.expectReason))
+		if test.expectReason == upgradeCheckReasonHealthy || test.expectReason == upgradeCheckReasonForced {
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(condition.Status).To(Equal(corev1.ConditionTrue))
+			g.Expect(recorder.Events).To(BeEmpty())
+			return
+		}
+		g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+		g.Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+		g.Expect(recorder.Events).To(HaveLen(1))
+
+		// the event is recorded once until the condition changes
+		g.Expect(controller.IsRequeueError(NewUpgradeChecker(pdControl, recorder).Check(tc, v1alpha1.TiKVMemberType))).To(BeTrue())
+		g.Expect(recorder.Events).To(HaveLen(1))
+	}
+
+	downPeer := pdapi.RegionPeerStat{Peer: pdapi.RegionPeer{ID: 1, StoreID: 1}}
+	peers := []pdapi.RegionPeer{{ID: 1, StoreID: 1}, {ID: 2, StoreID: 2}, {ID: 3, StoreID: 3}}
+	tests := []testcase{
+		{
+			name:         "healthy",
+			expectReason: upgradeCheckReasonHealthy,
+		},
+		{
+			name: "pd member is unhealthy",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.PD.Members["pd-1"] = v1alpha1.PDMember{Name: "pd-1", Health: false}
+			},
+			expectReason: upgradeCheckReasonPDQuorumNotFull,
+		},
+		{
+			name: "pd member is missing",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				delete(tc.Status.PD.Members, "pd-1")
+			},
+			expectReason: upgradeCheckReasonPDQuorumNotFull,
+		},
+		{
+			name: "force upgrade",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				delete(tc.Status.PD.Members, "pd-1")
+				tc.Annotations = map[string]string{label.AnnForceUpgradeKey: label.AnnForceUpgradeVal}
+			},
+			expectReason: upgradeCheckReasonForced,
+		},
+		{
+			name: "tikv store is down",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.TiKV.Stores["2"] = v1alpha1.TiKVStore{ID: "2", State: v1alpha1.TiKVStateDown}
+			},
+			expectReason: upgradeCheckReasonTiKVStoreNotUp,
+		},
+		{
+			name: "tikv store is offline",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.TiKV.Stores["2"] = v1alpha1.TiKVStore{ID: "2", State: v1alpha1.TiKVStateOffline}
+			},
+			expectReason: upgradeCheckReasonTiKVStoreNotUp,
+		},
+		{
+			name: "failover is in progress",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.TiKV.FailureStores = map[string]v1alpha1.TiKVFailureStore{"2": {StoreID: "2"}}
+			},
+			expectReason: upgradeCheckReasonOperationInFlight,
+		},
+		{
+			name: "pod is not ready",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.TiDB.StatefulSet.ReadyReplicas = 1
+			},
+			expectReason: upgradeCheckReasonOperationInFlight,
+		},
+		{
+			name:         "leaders are being evicted",
+			schedulers:   []string{"evict-leader-scheduler-1"},
+			expectReason: upgradeCheckReasonOperationInFlight,
+		},
+		{
+			name:         "region with a healthy majority",
+			regions:      []*pdapi.RegionInfo{{ID: 1, Peers: peers, DownPeers: []pdapi.RegionPeerStat{downPeer}}},
+			expectReason: upgradeCheckReasonHealthy,
+		},
+		{
+			name:         "region lacks a healthy majority",
+			regions:      []*pdapi.RegionInfo{{ID: 1, Peers: peers, DownPeers: []pdapi.RegionPeerStat{downPeer, downPeer}}},
+			expectReason: upgradeCheckReasonRegionsUnhealthy,
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func newTidbClusterForUpgradeChecker() *v1alpha1.TidbCluster {
+	tc := newTidbClusterForPD()
+	tc.Spec.PD.Replicas = 2
+	tc.Status.PD.Members = map[string]v1alpha1.PDMember{
+		"pd-0": {Name: "pd-0", Health: true},
+		"pd-1": {Name: "pd-1", Health: true},
+	}
+	tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{
+		"1": {ID: "1", State: v1alpha1.TiKVStateUp},
+		"2": {ID: "2", State: v1alpha1.TiKVStateUp},
+	}
+	tc.Status.PD.StatefulSet = &apps.StatefulSetStatus{Replicas: 2, ReadyReplicas: 2}
+	tc.Status.TiKV.StatefulSet = &apps.StatefulSetStatus{Replicas: 2, ReadyReplicas: 2}
+	tc.Status.TiDB.StatefulSet = &apps.StatefulSetStatus{Replicas: 2, ReadyReplicas: 2}
+	return tc
+}
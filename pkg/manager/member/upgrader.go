@@ -14,8 +14,11 @@
 package member
 
 import (
+	"time"
+
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
-	apps "k8s.io/api/apps/v1beta1"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 )
 
 // Upgrader implements the logic for upgrading the tidb cluster.
@@ -23,3 +26,46 @@ type Upgrader interface {
 	// Upgrade upgrade the cluster
 	Upgrade(*v1alpha1.TidbCluster, *apps.StatefulSet, *apps.StatefulSet) error
 }
+
+// newUpgradeProgress returns the progress of the rolling upgrade to the image, the pods with the
+// update revision of the statefulset are counted as upgraded
+func newUpgradeProgress(image string, status *apps.StatefulSetStatus) *v1alpha1.UpgradeProgress {
+	return &v1alpha1.UpgradeProgress{
+		Image:    image,
+		Upgraded: status.UpdatedReplicas,
+		Pending:  status.Replicas - status.UpdatedReplicas,
+	}
+}
+
+// setUpgradingPod records the pod being upgraded and its phase in the progress
+func setUpgradingPod(progress *v1alpha1.UpgradeProgress, podName string, ordinal int32, phase v1alpha1.UpgradePodPhase) {
+	if progress == nil {
+		return
+	}
+	progress.PodName = podName
+	progress.Ordinal = ordinal
+	progress.Phase = phase
+}
+
+// minReadyRemaining returns how long the upgraded pod must stay ready until minReadySeconds
+// are passed, the next pod is not upgraded before it, which catches the pods crashing soon
+// after they are ready. The pod which is not ready has to wait for the whole minReadySeconds.
+func minReadyRemaining(pod *corev1.Pod, minReadySeconds int32, now time.Time) time.Duration {
+	if minReadySeconds <= 0 {
+		return 0
+	}
+	minReady := time.Duration(minReadySeconds) * time.Second
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type != corev1.PodReady {
+			continue
+		}
+		if condition.Status != corev1.ConditionTrue {
+			return minReady
+		}
+		if remaining := condition.LastTransitionTime.Add(minReady).Sub(now); remaining > 0 {
+			return remaining
+		}
+		return 0
+	}
+	return minReady
+}
@@ -0,0 +1,83 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMinReadyRemaining(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	now := time.Now()
+	newPod := func(status corev1.ConditionStatus, readyAgo time.Duration) *corev1.Pod {
+		return &corev1.Pod{
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{
+					{Type: corev1.PodScheduled, Status: corev1.ConditionTrue},
+					{Type: corev1.PodReady, Status: status, LastTransitionTime: metav1.NewTime(now.Add(-readyAgo))},
+				},
+			},
+		}
+	}
+
+	type testcase struct {
+		name            string
+		pod             *corev1.Pod
+		minReadySeconds int32
+		expect          time.Duration
+	}
+	tests := []testcase{
+		{
+			name:            "minReadySeconds is not set",
+			pod:             newPod(corev1.ConditionFalse, 0),
+			minReadySeconds: 0,
+			expect:          0,
+		},
+		{
+			name:            "no ready condition",
+			pod:             &corev1.Pod{},
+			minReadySeconds: 30,
+			expect:          30 * time.Second,
+		},
+		{
+			name:            "pod is not ready",
+			pod:             newPod(corev1.ConditionFalse, time.Minute),
+			minReadySeconds: 30,
+			expect:          30 * time.Second,
+		},
+		{
+			name:            "pod is ready within minReadySeconds",
+			pod:             newPod(corev1.ConditionTrue, 10*time.Second),
+			minReadySeconds: 30,
+			expect:          20 * time.Second,
+		},
+		{
+			name:            "pod is ready for minReadySeconds",
+			pod:             newPod(corev1.ConditionTrue, time.Minute),
+			minReadySeconds: 30,
+			expect:          0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.name)
+		g.Expect(minReadyRemaining(test.pod, test.minReadySeconds, now)).To(BeNumerically("~", test.expect, time.Second))
+	}
+}
@@ -16,14 +16,17 @@ package member
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/label"
-	apps "k8s.io/api/apps/v1beta1"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
 )
 
 const (
@@ -128,7 +131,7 @@ func statefulSetEqual(new apps.StatefulSet, old apps.StatefulSet) bool {
 	if lastAppliedConfig, ok := old.Annotations[LastAppliedConfigAnnotation]; ok {
 		err := json.Unmarshal([]byte(lastAppliedConfig), &oldConfig)
 		if err != nil {
-			glog.Errorf("unmarshal Statefulset: [%s/%s]'s applied config failed,error: %v", old.GetNamespace(), old.GetName(), err)
+			log.Errorf("unmarshal Statefulset: [%s/%s]'s applied config failed,error: %v", old.GetNamespace(), old.GetName(), err)
 			return false
 		}
 		return apiequality.Semantic.DeepEqual(oldConfig.Replicas, new.Spec.Replicas) &&
@@ -144,7 +147,7 @@ func templateEqual(new corev1.PodTemplateSpec, old corev1.PodTemplateSpec) bool
 	if lastAppliedConfig, ok := old.Annotations[LastAppliedConfigAnnotation]; ok {
 		err := json.Unmarshal([]byte(lastAppliedConfig), &oldConfig)
 		if err != nil {
-			glog.Errorf("unmarshal PodTemplate: [%s/%s]'s applied config failed,error: %v", old.GetNamespace(), old.GetName(), err)
+			log.Errorf("unmarshal PodTemplate: [%s/%s]'s applied config failed,error: %v", old.GetNamespace(), old.GetName(), err)
 			return false
 		}
 		return apiequality.Semantic.DeepEqual(oldConfig, new.Spec)
@@ -171,7 +174,7 @@ func serviceEqual(new, old *corev1.Service) (bool, error) {
 	if lastAppliedConfig, ok := old.Annotations[LastAppliedConfigAnnotation]; ok {
 		err := json.Unmarshal([]byte(lastAppliedConfig), &oldSpec)
 		if err != nil {
-			glog.Errorf("unmarshal ServiceSpec: [%s/%s]'s applied config failed,error: %v", old.GetNamespace(), old.GetName(), err)
+			log.Errorf("unmarshal ServiceSpec: [%s/%s]'s applied config failed,error: %v", old.GetNamespace(), old.GetName(), err)
 			return false, err
 		}
 		return apiequality.Semantic.DeepEqual(oldSpec, new.Spec), nil
@@ -179,10 +182,50 @@ func serviceEqual(new, old *corev1.Service) (bool, error) {
 	return false, nil
 }
 
+// updateStatefulSet updates the StatefulSet only if the generated spec differs from its last
+// applied config, the fields defaulted by the apiserver are not compared, so the StatefulSet
+// isn't rewritten on every sync
+func updateStatefulSet(setControl controller.StatefulSetControlInterface, tc *v1alpha1.TidbCluster, newSet, oldSet *apps.StatefulSet) error {
+	if statefulSetEqual(*newSet, *oldSet) {
+		return nil
+	}
+	set := *oldSet
+	set.Spec.Template = newSet.Spec.Template
+	*set.Spec.Replicas = *newSet.Spec.Replicas
+	set.Spec.UpdateStrategy = newSet.Spec.UpdateStrategy
+	if err := SetLastAppliedConfigAnnotation(&set); err != nil {
+		return err
+	}
+	_, err := setControl.UpdateStatefulSet(tc, &set)
+	return err
+}
+
+// updateService updates the Service only if the generated spec differs from its last applied
+// config, the last applied config is the generated spec rather than the updated one, which
+// has the cluster ip allocated by the apiserver, so the Service isn't rewritten on every sync
+func updateService(svcControl controller.ServiceControlInterface, tc *v1alpha1.TidbCluster, newSvc, oldSvc *corev1.Service) error {
+	equal, err := serviceEqual(newSvc, oldSvc)
+	if err != nil {
+		return err
+	}
+	if equal {
+		return nil
+	}
+	svc := *oldSvc
+	svc.Spec = newSvc.Spec
+	if err := SetServiceLastAppliedConfigAnnotation(&svc); err != nil {
+		return err
+	}
+	// the cluster ip of a service is immutable
+	svc.Spec.ClusterIP = oldSvc.Spec.ClusterIP
+	_, err = svcControl.UpdateService(tc, &svc)
+	return err
+}
+
 // setUpgradePartition set statefulSet's rolling update partition
 func setUpgradePartition(set *apps.StatefulSet, upgradeOrdinal int32) {
 	set.Spec.UpdateStrategy.RollingUpdate = &apps.RollingUpdateStatefulSetStrategy{Partition: &upgradeOrdinal}
-	glog.Infof("set %s/%s partition to %d", set.GetNamespace(), set.GetName(), upgradeOrdinal)
+	log.Infof("set %s/%s partition to %d", set.GetNamespace(), set.GetName(), upgradeOrdinal)
 }
 
 func imagePullFailed(pod *corev1.Pod) bool {
@@ -207,6 +250,78 @@ func tidbPodName(tcName string, ordinal int32) string {
 	return fmt.Sprintf("%s-%d", controller.TiDBMemberName(tcName), ordinal)
 }
 
+func tiproxyPodName(tcName string, ordinal int32) string {
+	return fmt.Sprintf("%s-%d", controller.TiProxyMemberName(tcName), ordinal)
+}
+
+func pdMSPodName(tcName string, ordinal int32, memberType v1alpha1.MemberType) string {
+	return fmt.Sprintf("%s-%d", controller.PDMSMemberName(tcName, memberType), ordinal)
+}
+
+const (
+	// pdPeerPort is the port of the PD peers
+	pdPeerPort = 2380
+	// tikvPort is the port of TiKV, it's used by the TiKV peers, PD and TiDB
+	tikvPort = 20160
+)
+
+// serviceMeshAnnotations returns the annotations of the Istio sidecar injector
+// for the pods of the component, the inbound ports are the peer ports of the
+// component which bypass the proxy
+func serviceMeshAnnotations(tc *v1alpha1.TidbCluster, inboundPorts ...int32) map[string]string {
+	annotations := map[string]string{}
+	if tc.HoldApplicationUntilProxyStarts() {
+		annotations["proxy.istio.io/config"] = `{"holdApplicationUntilProxyStarts": true}`
+	}
+	if tc.ServiceMeshExcludePeerPorts() {
+		if len(inboundPorts) > 0 {
+			annotations["traffic.sidecar.istio.io/excludeInboundPorts"] = joinPorts(inboundPorts)
+		}
+		// all the components connect to the peer ports of PD and TiKV
+		annotations["traffic.sidecar.istio.io/excludeOutboundPorts"] = joinPorts([]int32{pdPeerPort, tikvPort})
+	}
+	return annotations
+}
+
+func joinPorts(ports []int32) string {
+	s := make([]string, 0, len(ports))
+	for _, port := range ports {
+		s = append(s, strconv.Itoa(int(port)))
+	}
+	return strings.Join(s, ",")
+}
+
+// podReady returns whether the pod is ready, the readiness of the injected
+// service mesh sidecars is ignored, only the container of the component counts
+func podReady(tc *v1alpha1.TidbCluster, pod *corev1.Pod, containerName string) bool {
+	if !tc.ServiceMeshEnabled() {
+		return podutil.IsPodReady(pod)
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == containerName {
+			return status.Ready
+		}
+	}
+	return false
+}
+
+// podSecurityContext returns the security context of the pods generated from
+// the one of the spec. On OpenShift, the user and group ids are assigned by the
+// security context constraints, e.g. from the range of the namespace with the
+// restricted one, so the ids of the spec are dropped, otherwise the pods are
+// rejected by the admission.
+func podSecurityContext(psc *corev1.PodSecurityContext) *corev1.PodSecurityContext {
+	if !controller.OpenShift || psc == nil {
+		return psc
+	}
+	psc = psc.DeepCopy()
+	psc.RunAsUser = nil
+	psc.RunAsGroup = nil
+	psc.FSGroup = nil
+	psc.SupplementalGroups = nil
+	return psc
+}
+
 // CombineAnnotations merges two annotations maps
 func CombineAnnotations(a, b map[string]string) map[string]string {
 	if a == nil {
@@ -218,6 +333,34 @@ func CombineAnnotations(a, b map[string]string) map[string]string {
 	return a
 }
 
+// topologySpreadAffinity adds a preferred pod anti-affinity term for each of the
+// topology spread constraints to the affinity of the pods with the given labels
+func topologySpreadAffinity(affinity *corev1.Affinity, constraints []v1alpha1.TopologySpreadConstraint, podLabel label.Label) *corev1.Affinity {
+	if len(constraints) == 0 {
+		return affinity
+	}
+	if affinity == nil {
+		affinity = &corev1.Affinity{}
+	} else {
+		affinity = affinity.DeepCopy()
+	}
+	if affinity.PodAntiAffinity == nil {
+		affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+	}
+	for _, constraint := range constraints {
+		affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(
+			affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+			corev1.WeightedPodAffinityTerm{
+				Weight: 100,
+				PodAffinityTerm: corev1.PodAffinityTerm{
+					LabelSelector: podLabel.LabelSelector(),
+					TopologyKey:   constraint.TopologyKey,
+				},
+			})
+	}
+	return affinity
+}
+
 // needForceUpgrade check if force upgrade is necessary
 func needForceUpgrade(tc *v1alpha1.TidbCluster) bool {
 	// Check if annotation 'pingcap.com/force-upgrade: "true"' is set
@@ -14,11 +14,19 @@
 package member
 
 import (
+	"fmt"
 	"testing"
 
 	. "github.com/onsi/gomega"
-	apps "k8s.io/api/apps/v1beta1"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned/fake"
+	informers "github.com/pingcap/tidb-operator/pkg/client/informers/externalversions"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
 )
 
 func TestStatefulSetIsUpgrading(t *testing.T) {
@@ -94,3 +102,109 @@ func TestStatefulSetIsUpgrading(t *testing.T) {
 		testFn(test, t)
 	}
 }
+
+func TestUpdateService(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	kubeCli := kubefake.NewSimpleClientset()
+	svcInformer := kubeinformers.NewSharedInformerFactory(kubeCli, 0).Core().V1().Services()
+	epsInformer := kubeinformers.NewSharedInformerFactory(kubeCli, 0).Core().V1().Endpoints()
+	tcInformer := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0).Pingcap().V1alpha1().TidbClusters()
+	svcControl := controller.NewFakeServiceControl(svcInformer, epsInformer, tcInformer)
+	tc := newTidbClusterForPD()
+
+	newSvc := func() *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: metav1.NamespaceDefault},
+			Spec: corev1.ServiceSpec{
+				Type:  corev1.ServiceTypeClusterIP,
+				Ports: []corev1.ServicePort{{Name: "client", Port: 2379}},
+			},
+		}
+	}
+	// the cluster ip is allocated by the apiserver
+	oldSvc := newSvc()
+	g.Expect(SetServiceLastAppliedConfigAnnotation(oldSvc)).To(Succeed())
+	oldSvc.Spec.ClusterIP = "10.0.0.1"
+	g.Expect(svcInformer.Informer().GetIndexer().Add(oldSvc)).To(Succeed())
+
+	// the service is not updated if the generated spec is not changed
+	svcControl.SetUpdateServiceError(fmt.Errorf("service is updated"), 0)
+	g.Expect(updateService(svcControl, tc, newSvc(), oldSvc.DeepCopy())).To(Succeed())
+
+	svcControl.SetUpdateServiceError(nil, 0)
+	changed := newSvc()
+	changed.Spec.Ports[0].Port = 2380
+	g.Expect(updateService(svcControl, tc, changed, oldSvc.DeepCopy())).To(Succeed())
+
+	updated, err := svcInformer.Lister().Services(metav1.NamespaceDefault).Get("test")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(updated.Spec.ClusterIP).To(Equal("10.0.0.1"))
+	equal, err := serviceEqual(changed, updated)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(equal).To(BeTrue())
+}
+
+func TestServiceMeshAnnotations(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForTiDB()
+	g.Expect(serviceMeshAnnotations(tc, pdPeerPort)).To(BeEmpty())
+
+	tc.Spec.ServiceMesh = &v1alpha1.ServiceMeshSpec{}
+	g.Expect(serviceMeshAnnotations(tc, pdPeerPort)).To(Equal(map[string]string{
+		"proxy.istio.io/config":                         `{"holdApplicationUntilProxyStarts": true}`,
+		"traffic.sidecar.istio.io/excludeInboundPorts":  "2380",
+		"traffic.sidecar.istio.io/excludeOutboundPorts": "2380,20160",
+	}))
+	g.Expect(serviceMeshAnnotations(tc)).NotTo(HaveKey("traffic.sidecar.istio.io/excludeInboundPorts"))
+
+	disabled := false
+	tc.Spec.ServiceMesh = &v1alpha1.ServiceMeshSpec{
+		HoldApplicationUntilProxyStarts: &disabled,
+		ExcludePeerPorts:                &disabled,
+	}
+	g.Expect(serviceMeshAnnotations(tc, tikvPort)).To(BeEmpty())
+}
+
+func TestPodReady(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForTiDB()
+	pod := &corev1.Pod{
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: corev1.ConditionFalse},
+			},
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "tikv", Ready: true},
+				{Name: "istio-proxy", Ready: false},
+			},
+		},
+	}
+	g.Expect(podReady(tc, pod, "tikv")).To(BeFalse())
+
+	// the sidecar is ignored in a meshed cluster
+	tc.Spec.ServiceMesh = &v1alpha1.ServiceMeshSpec{}
+	g.Expect(podReady(tc, pod, "tikv")).To(BeTrue())
+	g.Expect(podReady(tc, pod, "pd")).To(BeFalse())
+}
+
+func TestPodSecurityContext(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	uid := int64(1000)
+	psc := &corev1.PodSecurityContext{
+		RunAsUser:    &uid,
+		FSGroup:      &uid,
+		RunAsNonRoot: func(b bool) *bool { return &b }(true),
+	}
+	g.Expect(podSecurityContext(psc)).To(Equal(psc))
+
+	controller.OpenShift = true
+	defer func() { controller.OpenShift = false }()
+	g.Expect(podSecurityContext(nil)).To(BeNil())
+	g.Expect(podSecurityContext(psc)).To(Equal(&corev1.PodSecurityContext{RunAsNonRoot: psc.RunAsNonRoot}))
+	// the spec is not modified
+	g.Expect(psc.RunAsUser).To(Equal(&uid))
+}
@@ -0,0 +1,255 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package meta
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	"github.com/pingcap/tidb-operator/pkg/manager"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// adoptionComponent is the statefulset and services of a component to adopt
+type adoptionComponent struct {
+	label    label.Label
+	setName  string
+	claim    string
+	services []string
+}
+
+type adoptionManager struct {
+	kubeCli kubernetes.Interface
+}
+
+// NewAdoptionManager returns a *adoptionManager, which takes the ownership of the existing
+// statefulsets and services of the TidbCluster annotated with tidb.pingcap.com/adopt=true,
+// e.g. the ones deployed by tidb-ansible or the raw manifests. The resources are adopted by
+// their names, i.e. they must be named as the ones generated by tidb-operator.
+//
+// The services are labeled and owned by the TidbCluster, their specs are updated by the
+// member managers afterwards. The selectors of the statefulsets are immutable, so the pods
+// and the PVCs of a statefulset are labeled and the statefulset is deleted with its pods
+// orphaned, the member managers create the statefulset again which picks up the orphan pods.
+func NewAdoptionManager(kubeCli kubernetes.Interface) manager.Manager {
+	return &adoptionManager{kubeCli}
+}
+
+func (am *adoptionManager) Sync(tc *v1alpha1.TidbCluster) error {
+	if tc.Annotations[label.AnnAdoptKey] != label.AnnAdoptVal {
+		return nil
+	}
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	instanceName := tc.GetLabels()[label.InstanceLabelKey]
+
+	components := []adoptionComponent{
+		{
+			label:    label.New().Instance(instanceName).PD(),
+			setName:  controller.PDMemberName(tcName),
+			claim:    v1alpha1.PDMemberType.String(),
+			services: []string{controller.PDMemberName(tcName), controller.PDPeerMemberName(tcName)},
+		},
+		{
+			label:    label.New().Instance(instanceName).TiKV(),
+			setName:  controller.TiKVMemberName(tcName),
+			claim:    v1alpha1.TiKVMemberType.String(),
+			services: []string{controller.TiKVPeerMemberName(tcName)},
+		},
+		{
+			label:    label.New().Instance(instanceName).TiDB(),
+			setName:  controller.TiDBMemberName(tcName),
+			services: []string{controller.TiDBMemberName(tcName), controller.TiDBPeerMemberName(tcName)},
+		},
+	}
+
+	var releasing []string
+	for _, component := range components {
+		adopted, err := am.adoptStatefulSet(tc, component)
+		if err != nil {
+			return err
+		}
+		if !adopted {
+			releasing = append(releasing, component.setName)
+		}
+		for _, svcName := range component.services {
+			if err := am.adoptService(tc, svcName, component.label); err != nil {
+				return err
+			}
+		}
+	}
+	if len(releasing) > 0 {
+		return controller.RequeueErrorf("TidbCluster: [%s/%s], waiting for the statefulsets %v to release their pods", ns, tcName, releasing)
+	}
+	return nil
+}
+
+func (am *adoptionManager) adoptService(tc *v1alpha1.TidbCluster, svcName string, l label.Label) error {
+	ns := tc.GetNamespace()
+	svc, err := am.kubeCli.CoreV1().Services(ns).Get(svcName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if owned, err := checkControllerOf(tc, "service", &svc.ObjectMeta); owned || err != nil {
+		return err
+	}
+
+	svc.Labels = mergeLabels(svc.Labels, l)
+	svc.OwnerReferences = append(svc.OwnerReferences, controller.GetOwnerRef(tc))
+	if _, err := am.kubeCli.CoreV1().Services(ns).Update(svc); err != nil {
+		return fmt.Errorf("TidbCluster: [%s/%s], adopt service %s failed, err: %v", ns, tc.GetName(), svcName, err)
+	}
+	log.Infof("TidbCluster: [%s/%s], service %s is adopted", ns, tc.GetName(), svcName)
+	return nil
+}
+
+// adoptStatefulSet labels the pods and PVCs of the statefulset and deletes it with the pods
+// orphaned, it returns true if the statefulset is owned by the TidbCluster or doesn't exist
+func (am *adoptionManager) adoptStatefulSet(tc *v1alpha1.TidbCluster, component adoptionComponent) (bool, error) {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	setName := component.setName
+
+	set, err := am.kubeCli.AppsV1().StatefulSets(ns).Get(setName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if owned, err := checkControllerOf(tc, "statefulset", &set.ObjectMeta); owned || err != nil {
+		return owned, err
+	}
+	if set.DeletionTimestamp != nil {
+		return false, nil
+	}
+
+	if component.claim != "" {
+		found := false
+		for _, claim := range set.Spec.VolumeClaimTemplates {
+			if claim.Name == component.claim {
+				found = true
+				break
+			}
+		}
+		if !found {
+			// the statefulset created by tidb-operator would not reuse the existing PVCs
+			return false, fmt.Errorf("TidbCluster: [%s/%s], statefulset %s can't be adopted, it has no volume claim template %s", ns, tcName, setName, component.claim)
+		}
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(set.Spec.Selector)
+	if err != nil {
+		return false, err
+	}
+	pods, err := am.kubeCli.CoreV1().Pods(ns).List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return false, err
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if hasLabels(pod.Labels, component.label) {
+			continue
+		}
+		pod.Labels = mergeLabels(pod.Labels, component.label)
+		if _, err := am.kubeCli.CoreV1().Pods(ns).Update(pod); err != nil {
+			return false, fmt.Errorf("TidbCluster: [%s/%s], label pod %s failed, err: %v", ns, tcName, pod.Name, err)
+		}
+	}
+
+	if component.claim != "" {
+		pvcs, err := am.kubeCli.CoreV1().PersistentVolumeClaims(ns).List(metav1.ListOptions{})
+		if err != nil {
+			return false, err
+		}
+		prefix := fmt.Sprintf("%s-%s-", component.claim, setName)
+		for i := range pvcs.Items {
+			pvc := &pvcs.Items[i]
+			if !strings.HasPrefix(pvc.Name, prefix) || hasLabels(pvc.Labels, component.label) {
+				continue
+			}
+			pvc.Labels = mergeLabels(pvc.Labels, component.label)
+			if _, err := am.kubeCli.CoreV1().PersistentVolumeClaims(ns).Update(pvc); err != nil {
+				return false, fmt.Errorf("TidbCluster: [%s/%s], label pvc %s failed, err: %v", ns, tcName, pvc.Name, err)
+			}
+		}
+	}
+
+	orphan := metav1.DeletePropagationOrphan
+	err = am.kubeCli.AppsV1().StatefulSets(ns).Delete(setName, &metav1.DeleteOptions{PropagationPolicy: &orphan})
+	if err != nil && !errors.IsNotFound(err) {
+		return false, fmt.Errorf("TidbCluster: [%s/%s], delete statefulset %s with its pods orphaned failed, err: %v", ns, tcName, setName, err)
+	}
+	log.Infof("TidbCluster: [%s/%s], statefulset %s is deleted with its pods orphaned, it will be created by tidb-operator", ns, tcName, setName)
+	return false, nil
+}
+
+// checkControllerOf returns true if the object is controlled by the TidbCluster, or an error
+// if it's controlled by another controller
+func checkControllerOf(tc *v1alpha1.TidbCluster, kind string, meta *metav1.ObjectMeta) (bool, error) {
+	ref := metav1.GetControllerOf(meta)
+	if ref == nil {
+		return false, nil
+	}
+	if ref.UID == tc.GetUID() {
+		return true, nil
+	}
+	return false, fmt.Errorf("TidbCluster: [%s/%s], %s %s can't be adopted, it's controlled by %s %s", tc.GetNamespace(), tc.GetName(), kind, meta.Name, ref.Kind, ref.Name)
+}
+
+func hasLabels(labels map[string]string, l label.Label) bool {
+	for k, v := range l {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func mergeLabels(labels map[string]string, l label.Label) map[string]string {
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for k, v := range l {
+		labels[k] = v
+	}
+	return labels
+}
+
+var _ manager.Manager = &adoptionManager{}
+
+type FakeAdoptionManager struct {
+	err error
+}
+
+func NewFakeAdoptionManager() *FakeAdoptionManager {
+	return &FakeAdoptionManager{}
+}
+
+func (fam *FakeAdoptionManager) SetSyncError(err error) {
+	fam.err = err
+}
+
+func (fam *FakeAdoptionManager) Sync(_ *v1alpha1.TidbCluster) error {
+	return fam.err
+}
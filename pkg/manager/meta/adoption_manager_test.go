@@ -0,0 +1,145 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package meta
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestAdoptionManagerSync(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name        string
+		annotated   bool
+		claim       string
+		setOwner    *metav1.OwnerReference
+		expectFn    func(*GomegaWithT, error)
+		expectAdopt bool
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+
+		tc := newTidbClusterForMeta()
+		if test.annotated {
+			tc.Annotations = map[string]string{label.AnnAdoptKey: label.AnnAdoptVal}
+		}
+		ns := tc.GetNamespace()
+		setName := controller.PDMemberName(tc.GetName())
+		legacyLabels := map[string]string{"app": "pd"}
+
+		kubeCli := kubefake.NewSimpleClientset(
+			&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: controller.PDMemberName(tc.GetName()), Namespace: ns, Labels: legacyLabels}},
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: setName + "-0", Namespace: ns, Labels: legacyLabels}},
+			&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pd-" + setName + "-0", Namespace: ns, Labels: legacyLabels}},
+		)
+		set := &apps.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: setName, Namespace: ns, Labels: legacyLabels},
+			Spec: apps.StatefulSetSpec{
+				Selector:             &metav1.LabelSelector{MatchLabels: legacyLabels},
+				VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: test.claim}}},
+			},
+		}
+		if test.setOwner != nil {
+			set.OwnerReferences = []metav1.OwnerReference{*test.setOwner}
+		}
+		_, err := kubeCli.AppsV1().StatefulSets(ns).Create(set)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		am := NewAdoptionManager(kubeCli)
+		err = am.Sync(tc)
+		test.expectFn(g, err)
+
+		pdLabels := label.New().Instance(tc.GetLabels()[label.InstanceLabelKey]).PD()
+		svc, err := kubeCli.CoreV1().Services(ns).Get(controller.PDMemberName(tc.GetName()), metav1.GetOptions{})
+		g.Expect(err).NotTo(HaveOccurred())
+		pod, err := kubeCli.CoreV1().Pods(ns).Get(setName+"-0", metav1.GetOptions{})
+		g.Expect(err).NotTo(HaveOccurred())
+		pvc, err := kubeCli.CoreV1().PersistentVolumeClaims(ns).Get("pd-"+setName+"-0", metav1.GetOptions{})
+		g.Expect(err).NotTo(HaveOccurred())
+		_, setErr := kubeCli.AppsV1().StatefulSets(ns).Get(setName, metav1.GetOptions{})
+
+		if !test.expectAdopt {
+			g.Expect(svc.OwnerReferences).To(BeEmpty())
+			g.Expect(hasLabels(pod.Labels, pdLabels)).To(BeFalse())
+			g.Expect(hasLabels(pvc.Labels, pdLabels)).To(BeFalse())
+			g.Expect(setErr).NotTo(HaveOccurred())
+			return
+		}
+		g.Expect(metav1.GetControllerOf(svc).UID).To(Equal(tc.GetUID()))
+		g.Expect(hasLabels(svc.Labels, pdLabels)).To(BeTrue())
+		g.Expect(hasLabels(pod.Labels, pdLabels)).To(BeTrue())
+		g.Expect(pod.Labels).To(HaveKeyWithValue("app", "pd"))
+		g.Expect(hasLabels(pvc.Labels, pdLabels)).To(BeTrue())
+		g.Expect(errors.IsNotFound(setErr)).To(BeTrue())
+
+		// the statefulset is released, and the services are owned by the TidbCluster
+		g.Expect(am.Sync(tc)).To(Succeed())
+	}
+
+	tests := []testcase{
+		{
+			name:      "not annotated",
+			annotated: false,
+			claim:     "pd",
+			expectFn: func(g *GomegaWithT, err error) {
+				g.Expect(err).NotTo(HaveOccurred())
+			},
+			expectAdopt: false,
+		},
+		{
+			name:      "adopt the existing resources",
+			annotated: true,
+			claim:     "pd",
+			expectFn: func(g *GomegaWithT, err error) {
+				g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+			},
+			expectAdopt: true,
+		},
+		{
+			name:      "statefulset without the expected volume claim template",
+			annotated: true,
+			claim:     "data",
+			expectFn: func(g *GomegaWithT, err error) {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(controller.IsRequeueError(err)).To(BeFalse())
+			},
+			expectAdopt: false,
+		},
+		{
+			name:      "statefulset controlled by another controller",
+			annotated: true,
+			claim:     "pd",
+			setOwner:  &metav1.OwnerReference{Kind: "TidbCluster", Name: "other", UID: "other", Controller: func() *bool { b := true; return &b }()},
+			expectFn: func(g *GomegaWithT, err error) {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(controller.IsRequeueError(err)).To(BeFalse())
+			},
+			expectAdopt: false,
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
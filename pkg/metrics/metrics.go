@@ -0,0 +1,145 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// OrphanPodsCleaner is the cleaner label value of the orphan pods cleaner
+	OrphanPodsCleaner = "orphan_pods"
+	// FailedNodePodsCleaner is the cleaner label value of the failed node pods cleaner
+	FailedNodePodsCleaner = "failed_node_pods"
+	// PVCCleaner is the cleaner label value of the pvc cleaner
+	PVCCleaner = "pvc"
+	// OrphanPVCsCleaner is the cleaner label value of the orphan pvcs cleaner
+	OrphanPVCsCleaner = "orphan_pvcs"
+	// TidbClusterCleaner is the cleaner label value of the cleaner of the deleted tidb clusters
+	TidbClusterCleaner = "tidb_cluster"
+
+	// ActionDeletePod is the action of deleting a pod
+	ActionDeletePod = "delete_pod"
+	// ActionForceDeletePod is the action of force deleting a pod
+	ActionForceDeletePod = "force_delete_pod"
+	// ActionDeleteVolumeAttachment is the action of deleting a volume attachment
+	ActionDeleteVolumeAttachment = "delete_volume_attachment"
+	// ActionRemovePodSchedulingAnnotation is the action of removing the pod scheduling annotation of a pvc
+	ActionRemovePodSchedulingAnnotation = "remove_pod_scheduling_annotation"
+	// ActionDeleteStore is the action of deleting a store from pd
+	ActionDeleteStore = "delete_store"
+	// ActionDeleteMember is the action of deleting a member from pd
+	ActionDeleteMember = "delete_member"
+	// ActionDeletePVC is the action of deleting a pvc
+	ActionDeletePVC = "delete_pvc"
+	// ActionDeleteService is the action of deleting a service
+	ActionDeleteService = "delete_service"
+	// ActionDeleteConfigMap is the action of deleting a configmap
+	ActionDeleteConfigMap = "delete_configmap"
+	// ActionDeleteSecret is the action of deleting a secret
+	ActionDeleteSecret = "delete_secret"
+
+	// DiscoveryResultInitialCluster is the discovery result of bootstrapping the pd cluster
+	DiscoveryResultInitialCluster = "initial_cluster"
+	// DiscoveryResultJoin is the discovery result of joining the pd cluster
+	DiscoveryResultJoin = "join"
+	// DiscoveryResultError is the discovery result of failing to return the args
+	DiscoveryResultError = "error"
+)
+
+var (
+	// CleanerSkipCounter counts the objects skipped by the cleaners per reason
+	CleanerSkipCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "tidb_operator",
+			Subsystem: "cleaner",
+			Name:      "skips_total",
+			Help:      "Total number of the objects skipped by the cleaners of the tidb clusters, by reason",
+		}, []string{"namespace", "cluster", "cleaner", "reason"})
+
+	// CleanerActionCounter counts the actions taken by the cleaners
+	CleanerActionCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "tidb_operator",
+			Subsystem: "cleaner",
+			Name:      "actions_total",
+			Help:      "Total number of the actions, e.g. the deletions, taken by the cleaners of the tidb clusters",
+		}, []string{"namespace", "cluster", "cleaner", "action"})
+
+	// DiscoveryRequestCounter counts the http requests served by the discovery service
+	DiscoveryRequestCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "tidb_operator",
+			Subsystem: "discovery",
+			Name:      "requests_total",
+			Help:      "Total number of the http requests served by the discovery service, by handler and status code",
+		}, []string{"handler", "code"})
+
+	// DiscoveryPDRequestDuration observes the latency of the pd queries made by the discovery service
+	DiscoveryPDRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "tidb_operator",
+			Subsystem: "discovery",
+			Name:      "pd_request_duration_seconds",
+			Help:      "Bucketed histogram of the latency of the pd queries made by the discovery service",
+			Buckets:   prometheus.ExponentialBuckets(0.005, 2, 12),
+		}, []string{"namespace", "cluster", "result"})
+
+	// DiscoveryResultCounter counts the args returned to the pd members by the discovery service
+	DiscoveryResultCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "tidb_operator",
+			Subsystem: "discovery",
+			Name:      "results_total",
+			Help:      "Total number of the discovery results of the pd members, i.e. initial_cluster, join or error",
+		}, []string{"namespace", "cluster", "result"})
+)
+
+func init() {
+	prometheus.MustRegister(CleanerSkipCounter)
+	prometheus.MustRegister(CleanerActionCounter)
+	prometheus.MustRegister(DiscoveryRequestCounter)
+	prometheus.MustRegister(DiscoveryPDRequestDuration)
+	prometheus.MustRegister(DiscoveryResultCounter)
+}
+
+// ObserveCleanerSkipReasons counts the skip reasons returned by a cleaner, the
+// "<cleaner name>: " prefix of the reasons is trimmed
+func ObserveCleanerSkipReasons(ns, tcName, cleaner string, skipReasons map[string]string) {
+	for _, reason := range skipReasons {
+		if i := strings.Index(reason, ": "); i >= 0 {
+			reason = reason[i+2:]
+		}
+		CleanerSkipCounter.WithLabelValues(ns, tcName, cleaner, reason).Inc()
+	}
+}
+
+// ObserveCleanerAction counts an action taken by a cleaner
+func ObserveCleanerAction(ns, tcName, cleaner, action string) {
+	CleanerActionCounter.WithLabelValues(ns, tcName, cleaner, action).Inc()
+}
+
+// ObserveDiscoveryResult counts the discovery result of a pd member, the result is
+// told by the returned args
+func ObserveDiscoveryResult(ns, tcName, args string, err error) {
+	result := DiscoveryResultJoin
+	if err != nil {
+		result = DiscoveryResultError
+	} else if strings.HasPrefix(args, "--initial-cluster") {
+		result = DiscoveryResultInitialCluster
+	}
+	DiscoveryResultCounter.WithLabelValues(ns, tcName, result).Inc()
+}
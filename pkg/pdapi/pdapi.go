@@ -24,13 +24,13 @@ import (
 	"sync"
 	"time"
 
-	"github.com/golang/glog"
-
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/pd/pkg/typeutil"
 	"github.com/pingcap/pd/server"
 	"github.com/pingcap/tidb-operator/pkg/httputil"
+	"github.com/pingcap/tidb-operator/pkg/log"
+	"github.com/pingcap/tidb-operator/pkg/tracing"
 )
 
 const (
@@ -44,6 +44,8 @@ type Namespace string
 type PDControlInterface interface {
 	// GetPDClient provides PDClient of the tidb cluster.
 	GetPDClient(Namespace, string, bool) PDClient
+	// GetRemotePDClient provides PDClient of the tidb cluster in the Kubernetes cluster of the domain.
+	GetRemotePDClient(Namespace, string, bool, string) PDClient
 }
 
 // defaultPDControl is the default implementation of PDControlInterface.
@@ -68,7 +70,28 @@ func (pdc *defaultPDControl) GetPDClient(namespace Namespace, tcName string, tls
 	}
 	key := pdClientKey(scheme, namespace, tcName)
 	if _, ok := pdc.pdClients[key]; !ok {
-		pdc.pdClients[key] = NewPDClient(PdClientURL(namespace, tcName, scheme), timeout, tlsEnabled)
+		pdc.pdClients[key] = newTracedPDClient(PdClientURL(namespace, tcName, scheme), namespace, tcName, tlsEnabled)
+	}
+	return pdc.pdClients[key]
+}
+
+// GetRemotePDClient provides a PDClient of the pd cluster which is reached through the FQDN of its
+// service, so that the pd cluster in another Kubernetes cluster is accessible
+func (pdc *defaultPDControl) GetRemotePDClient(namespace Namespace, tcName string, tlsEnabled bool, clusterDomain string) PDClient {
+	if clusterDomain == "" {
+		return pdc.GetPDClient(namespace, tcName, tlsEnabled)
+	}
+
+	pdc.mutex.Lock()
+	defer pdc.mutex.Unlock()
+
+	scheme := "http"
+	if tlsEnabled {
+		scheme = "https"
+	}
+	key := remotePDClientKey(scheme, namespace, tcName, clusterDomain)
+	if _, ok := pdc.pdClients[key]; !ok {
+		pdc.pdClients[key] = newTracedPDClient(RemotePDClientURL(namespace, tcName, scheme, clusterDomain), namespace, tcName, tlsEnabled)
 	}
 	return pdc.pdClients[key]
 }
@@ -83,12 +106,24 @@ func PdClientURL(namespace Namespace, clusterName string, scheme string) string
 	return fmt.Sprintf("%s://%s-pd.%s:2379", scheme, clusterName, string(namespace))
 }
 
+// remotePDClientKey returns the key of the pd client in the Kubernetes cluster of the domain
+func remotePDClientKey(scheme string, namespace Namespace, clusterName string, clusterDomain string) string {
+	return fmt.Sprintf("%s.%s.%s.%s", scheme, clusterName, string(namespace), clusterDomain)
+}
+
+// RemotePDClientURL builds the url of pd client in the Kubernetes cluster of the domain
+func RemotePDClientURL(namespace Namespace, clusterName string, scheme string, clusterDomain string) string {
+	return fmt.Sprintf("%s://%s-pd.%s.svc.%s:2379", scheme, clusterName, string(namespace), clusterDomain)
+}
+
 // PDClient provides pd server's api
 type PDClient interface {
 	// GetHealth returns the PD's health info
 	GetHealth() (*HealthInfo, error)
 	// GetConfig returns PD's config
 	GetConfig() (*server.Config, error)
+	// UpdateScheduleConfig updates the given items of PD's schedule config
+	UpdateScheduleConfig(config map[string]interface{}) error
 	// GetCluster returns used when syncing pod labels.
 	GetCluster() (*metapb.Cluster, error)
 	// GetMembers returns all PD members from cluster
@@ -119,6 +154,23 @@ type PDClient interface {
 	GetPDLeader() (*pdpb.Member, error)
 	// TransferPDLeader transfers pd leader to specified member
 	TransferPDLeader(name string) error
+	// UnsafeRemoveFailedStores asks PD to recover the regions which lost their
+	// majority because of the failed stores, from the remaining replicas
+	UnsafeRemoveFailedStores(storeIDs []uint64) error
+	// GetUnsafeRecoverProgress returns the stages of the latest unsafe recovery
+	GetUnsafeRecoverProgress() ([]UnsafeRecoverStage, error)
+	// UpdateReplicationConfig updates the given items of PD's replication config
+	UpdateReplicationConfig(config map[string]interface{}) error
+	// GetPlacementRuleBundles returns all the placement rule groups with their rules
+	GetPlacementRuleBundles() ([]*PlacementRuleBundle, error)
+	// SetPlacementRuleBundle replaces the rules of a placement rule group
+	SetPlacementRuleBundle(bundle *PlacementRuleBundle) error
+	// DeletePlacementRuleBundle deletes a placement rule group with its rules
+	DeletePlacementRuleBundle(groupID string) error
+	// GetRegionsByCheck returns the regions in the abnormal state of the check, e.g. the regions with pending peers
+	GetRegionsByCheck(check RegionCheck) (*RegionsInfo, error)
+	// GetRegions returns all the regions of the cluster
+	GetRegions() (*RegionsInfo, error)
 }
 
 var (
@@ -131,6 +183,11 @@ var (
 	schedulersPrefix       = "pd/api/v1/schedulers"
 	pdLeaderPrefix         = "pd/api/v1/leader"
 	pdLeaderTransferPrefix = "pd/api/v1/leader/transfer"
+	unsafeRecoverPrefix    = "pd/api/v1/admin/unsafe/remove-failed-stores"
+	replicationPrefix      = "pd/api/v1/config/replicate"
+	placementRulePrefix    = "pd/api/v1/config/placement-rule"
+	regionsCheckPrefix     = "pd/api/v1/regions/check"
+	regionsPrefix          = "pd/api/v1/regions"
 )
 
 // pdClient is default implementation of PDClient
@@ -141,28 +198,39 @@ type pdClient struct {
 
 // NewPDClient returns a new PDClient
 func NewPDClient(url string, timeout time.Duration, tlsEnabled bool) PDClient {
-	httpClient := &http.Client{Timeout: timeout}
+	var transport http.RoundTripper
 	if tlsEnabled {
 		rootCAs, cert, err := httputil.ReadCerts()
 		if err != nil {
-			glog.Errorf("fail to load certs, fallback to plain connection, err: %s", err)
+			log.Errorf("fail to load certs, fallback to plain connection, err: %s", err)
 		} else {
 			config := &tls.Config{
 				RootCAs:      rootCAs,
 				Certificates: []tls.Certificate{cert},
 			}
-			httpClient = &http.Client{
-				Timeout:   timeout,
-				Transport: &http.Transport{TLSClientConfig: config},
-			}
+			transport = &http.Transport{TLSClientConfig: config}
 		}
 	}
+	// fail fast when the PD is down, so that the workers are not tied up in
+	// the timeouts and the other clusters can still be synced
+	httpClient := &http.Client{
+		Timeout:   timeout,
+		Transport: httputil.NewCircuitBreaker().Transport(transport),
+	}
 	return &pdClient{
 		url:        url,
 		httpClient: httpClient,
 	}
 }
 
+// newTracedPDClient returns a PDClient whose calls are traced as the children of
+// the running sync of the tidb cluster
+func newTracedPDClient(url string, namespace Namespace, tcName string, tlsEnabled bool) PDClient {
+	pc := NewPDClient(url, timeout, tlsEnabled).(*pdClient)
+	pc.httpClient.Transport = tracing.NewRoundTripper(string(namespace), tcName, pc.httpClient.Transport)
+	return pc
+}
+
 // following struct definitions are copied from github.com/pingcap/pd/server/api/store
 // these are not exported by that package
 
@@ -222,6 +290,90 @@ type MembersInfo struct {
 	EtcdLeader *pdpb.Member         `json:"etcd_leader,omitempty"`
 }
 
+// UnsafeRecoverStage is a stage of the unsafe recovery returned from PD RESTful interface
+type UnsafeRecoverStage struct {
+	Info string `json:"info"`
+	Time string `json:"time"`
+}
+
+// RegionCheck is a check of the regions by PD
+type RegionCheck string
+
+const (
+	// RegionCheckPendingPeer checks the regions with the peers whose raft logs fall behind
+	RegionCheckPendingPeer RegionCheck = "pending-peer"
+	// RegionCheckDownPeer checks the regions with the peers which don't send heartbeats
+	RegionCheckDownPeer RegionCheck = "down-peer"
+	// RegionCheckMissPeer checks the regions with less peers than the replicas
+	RegionCheckMissPeer RegionCheck = "miss-peer"
+)
+
+// RegionsInfo is regions info returned from PD RESTful interface
+type RegionsInfo struct {
+	Count   int           `json:"count"`
+	Regions []*RegionInfo `json:"regions"`
+}
+
+// RegionInfo is a single region info returned from PD RESTful interface
+type RegionInfo struct {
+	ID        uint64           `json:"id"`
+	StartKey  string           `json:"start_key"`
+	EndKey    string           `json:"end_key"`
+	Peers     []RegionPeer     `json:"peers,omitempty"`
+	DownPeers []RegionPeerStat `json:"down_peers,omitempty"`
+}
+
+// RegionPeer is a peer of a region on a store
+type RegionPeer struct {
+	ID      uint64 `json:"id"`
+	StoreID uint64 `json:"store_id"`
+}
+
+// RegionPeerStat is a peer which doesn't send heartbeats
+type RegionPeerStat struct {
+	Peer        RegionPeer `json:"peer"`
+	DownSeconds uint64     `json:"down_seconds,omitempty"`
+}
+
+// HasHealthyMajority returns true if the majority of the peers are not down
+func (r *RegionInfo) HasHealthyMajority() bool {
+	return (len(r.Peers)-len(r.DownPeers))*2 > len(r.Peers)
+}
+
+// PlacementRuleBundle is a placement rule group with its rules returned from PD RESTful interface
+type PlacementRuleBundle struct {
+	ID       string           `json:"group_id"`
+	Index    int              `json:"group_index"`
+	Override bool             `json:"group_override"`
+	Rules    []*PlacementRule `json:"rules"`
+}
+
+// PlacementRule is a placement rule returned from PD RESTful interface
+type PlacementRule struct {
+	GroupID          string                     `json:"group_id"`
+	ID               string                     `json:"id"`
+	Index            int                        `json:"index,omitempty"`
+	Override         bool                       `json:"override,omitempty"`
+	StartKeyHex      string                     `json:"start_key"`
+	EndKeyHex        string                     `json:"end_key"`
+	Role             string                     `json:"role"`
+	Count            int                        `json:"count"`
+	LabelConstraints []PlacementLabelConstraint `json:"label_constraints,omitempty"`
+	LocationLabels   []string                   `json:"location_labels,omitempty"`
+	IsolationLevel   string                     `json:"isolation_level,omitempty"`
+}
+
+// PlacementLabelConstraint is the label constraint of a placement rule returned from PD RESTful interface
+type PlacementLabelConstraint struct {
+	Key    string   `json:"key"`
+	Op     string   `json:"op"`
+	Values []string `json:"values,omitempty"`
+}
+
+type unsafeRecoverRequest struct {
+	Stores []uint64 `json:"stores"`
+}
+
 type schedulerInfo struct {
 	Name    string `json:"name"`
 	StoreID uint64 `json:"store_id"`
@@ -257,6 +409,24 @@ func (pc *pdClient) GetConfig() (*server.Config, error) {
 	return config, nil
 }
 
+func (pc *pdClient) UpdateScheduleConfig(config map[string]interface{}) error {
+	apiURL := fmt.Sprintf("%s/%s", pc.url, configPrefix)
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	res, err := pc.httpClient.Post(apiURL, "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	defer httputil.DeferClose(res.Body)
+	if res.StatusCode == http.StatusOK {
+		return nil
+	}
+	err2 := httputil.ReadErrorBody(res.Body)
+	return fmt.Errorf("failed %v to update schedule config: %v", res.StatusCode, err2)
+}
+
 func (pc *pdClient) GetCluster() (*metapb.Cluster, error) {
 	apiURL := fmt.Sprintf("%s/%s", pc.url, clusterIDPrefix)
 	body, err := httputil.GetBodyOK(pc.httpClient, apiURL)
@@ -500,10 +670,10 @@ func (pc *pdClient) EndEvictLeader(storeID uint64) error {
 		return nil
 	}
 	if res.StatusCode == http.StatusOK {
-		glog.Infof("call DELETE method: %s success", apiURL)
+		log.Infof("call DELETE method: %s success", apiURL)
 	} else {
 		err2 := httputil.ReadErrorBody(res.Body)
-		glog.Errorf("call DELETE method: %s failed,statusCode: %v,error: %v", apiURL, res.StatusCode, err2)
+		log.Errorf("call DELETE method: %s failed,statusCode: %v,error: %v", apiURL, res.StatusCode, err2)
 	}
 
 	// pd will return an error with the body contains "scheduler not found" if the scheduler is not found
@@ -578,6 +748,134 @@ func (pc *pdClient) TransferPDLeader(memberName string) error {
 	return fmt.Errorf("failed %v to transfer pd leader to %s,error: %v", res.StatusCode, memberName, err2)
 }
 
+func (pc *pdClient) UnsafeRemoveFailedStores(storeIDs []uint64) error {
+	apiURL := fmt.Sprintf("%s/%s", pc.url, unsafeRecoverPrefix)
+	data, err := json.Marshal(&unsafeRecoverRequest{Stores: storeIDs})
+	if err != nil {
+		return err
+	}
+	res, err := pc.httpClient.Post(apiURL, "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	defer httputil.DeferClose(res.Body)
+	if res.StatusCode == http.StatusOK {
+		return nil
+	}
+	err2 := httputil.ReadErrorBody(res.Body)
+	return fmt.Errorf("failed %v to remove failed stores %v: %v", res.StatusCode, storeIDs, err2)
+}
+
+func (pc *pdClient) GetUnsafeRecoverProgress() ([]UnsafeRecoverStage, error) {
+	apiURL := fmt.Sprintf("%s/%s/show", pc.url, unsafeRecoverPrefix)
+	body, err := pc.getBodyOK(apiURL)
+	if err != nil {
+		return nil, err
+	}
+	var stages []UnsafeRecoverStage
+	err = json.Unmarshal(body, &stages)
+	if err != nil {
+		return nil, err
+	}
+	return stages, nil
+}
+
+func (pc *pdClient) UpdateReplicationConfig(config map[string]interface{}) error {
+	apiURL := fmt.Sprintf("%s/%s", pc.url, replicationPrefix)
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	res, err := pc.httpClient.Post(apiURL, "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	defer httputil.DeferClose(res.Body)
+	if res.StatusCode == http.StatusOK {
+		return nil
+	}
+	err2 := httputil.ReadErrorBody(res.Body)
+	return fmt.Errorf("failed %v to update replication config: %v", res.StatusCode, err2)
+}
+
+func (pc *pdClient) GetPlacementRuleBundles() ([]*PlacementRuleBundle, error) {
+	apiURL := fmt.Sprintf("%s/%s", pc.url, placementRulePrefix)
+	body, err := pc.getBodyOK(apiURL)
+	if err != nil {
+		return nil, err
+	}
+	var bundles []*PlacementRuleBundle
+	err = json.Unmarshal(body, &bundles)
+	if err != nil {
+		return nil, err
+	}
+	return bundles, nil
+}
+
+func (pc *pdClient) SetPlacementRuleBundle(bundle *PlacementRuleBundle) error {
+	apiURL := fmt.Sprintf("%s/%s/%s", pc.url, placementRulePrefix, bundle.ID)
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		return err
+	}
+	res, err := pc.httpClient.Post(apiURL, "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	defer httputil.DeferClose(res.Body)
+	if res.StatusCode == http.StatusOK {
+		return nil
+	}
+	err2 := httputil.ReadErrorBody(res.Body)
+	return fmt.Errorf("failed %v to set placement rule group %s: %v", res.StatusCode, bundle.ID, err2)
+}
+
+func (pc *pdClient) DeletePlacementRuleBundle(groupID string) error {
+	apiURL := fmt.Sprintf("%s/%s/%s", pc.url, placementRulePrefix, groupID)
+	req, err := http.NewRequest("DELETE", apiURL, nil)
+	if err != nil {
+		return err
+	}
+	res, err := pc.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer httputil.DeferClose(res.Body)
+	if res.StatusCode == http.StatusOK || res.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	err2 := httputil.ReadErrorBody(res.Body)
+	return fmt.Errorf("failed %v to delete placement rule group %s: %v", res.StatusCode, groupID, err2)
+}
+
+func (pc *pdClient) GetRegionsByCheck(check RegionCheck) (*RegionsInfo, error) {
+	apiURL := fmt.Sprintf("%s/%s/%s", pc.url, regionsCheckPrefix, check)
+	body, err := pc.getBodyOK(apiURL)
+	if err != nil {
+		return nil, err
+	}
+	regionsInfo := &RegionsInfo{}
+	err = json.Unmarshal(body, regionsInfo)
+	if err != nil {
+		return nil, err
+	}
+	return regionsInfo, nil
+}
+
+func (pc *pdClient) GetRegions() (*RegionsInfo, error) {
+	apiURL := fmt.Sprintf("%s/%s", pc.url, regionsPrefix)
+	body, err := pc.getBodyOK(apiURL)
+	if err != nil {
+		return nil, err
+	}
+	regionsInfo := &RegionsInfo{}
+	err = json.Unmarshal(body, regionsInfo)
+	if err != nil {
+		return nil, err
+	}
+	return regionsInfo, nil
+}
+
 func (pc *pdClient) getBodyOK(apiURL string) ([]byte, error) {
 	res, err := pc.httpClient.Get(apiURL)
 	if err != nil {
@@ -617,25 +915,38 @@ func (fpc *FakePDControl) SetPDClient(namespace Namespace, tcName string, pdclie
 	fpc.defaultPDControl.pdClients[pdClientKey("http", namespace, tcName)] = pdclient
 }
 
+func (fpc *FakePDControl) SetRemotePDClient(namespace Namespace, tcName string, clusterDomain string, pdclient PDClient) {
+	fpc.defaultPDControl.pdClients[remotePDClientKey("http", namespace, tcName, clusterDomain)] = pdclient
+}
+
 type ActionType string
 
 const (
-	GetHealthActionType                ActionType = "GetHealth"
-	GetConfigActionType                ActionType = "GetConfig"
-	GetClusterActionType               ActionType = "GetCluster"
-	GetMembersActionType               ActionType = "GetMembers"
-	GetStoresActionType                ActionType = "GetStores"
-	GetTombStoneStoresActionType       ActionType = "GetTombStoneStores"
-	GetStoreActionType                 ActionType = "GetStore"
-	DeleteStoreActionType              ActionType = "DeleteStore"
-	DeleteMemberByIDActionType         ActionType = "DeleteMemberByID"
-	DeleteMemberActionType             ActionType = "DeleteMember "
-	SetStoreLabelsActionType           ActionType = "SetStoreLabels"
-	BeginEvictLeaderActionType         ActionType = "BeginEvictLeader"
-	EndEvictLeaderActionType           ActionType = "EndEvictLeader"
-	GetEvictLeaderSchedulersActionType ActionType = "GetEvictLeaderSchedulers"
-	GetPDLeaderActionType              ActionType = "GetPDLeader"
-	TransferPDLeaderActionType         ActionType = "TransferPDLeader"
+	GetHealthActionType                 ActionType = "GetHealth"
+	GetConfigActionType                 ActionType = "GetConfig"
+	UpdateScheduleConfigActionType      ActionType = "UpdateScheduleConfig"
+	GetClusterActionType                ActionType = "GetCluster"
+	GetMembersActionType                ActionType = "GetMembers"
+	GetStoresActionType                 ActionType = "GetStores"
+	GetTombStoneStoresActionType        ActionType = "GetTombStoneStores"
+	GetStoreActionType                  ActionType = "GetStore"
+	DeleteStoreActionType               ActionType = "DeleteStore"
+	DeleteMemberByIDActionType          ActionType = "DeleteMemberByID"
+	DeleteMemberActionType              ActionType = "DeleteMember "
+	SetStoreLabelsActionType            ActionType = "SetStoreLabels"
+	BeginEvictLeaderActionType          ActionType = "BeginEvictLeader"
+	EndEvictLeaderActionType            ActionType = "EndEvictLeader"
+	GetEvictLeaderSchedulersActionType  ActionType = "GetEvictLeaderSchedulers"
+	GetPDLeaderActionType               ActionType = "GetPDLeader"
+	TransferPDLeaderActionType          ActionType = "TransferPDLeader"
+	UnsafeRemoveFailedStoresActionType  ActionType = "UnsafeRemoveFailedStores"
+	GetUnsafeRecoverProgressActionType  ActionType = "GetUnsafeRecoverProgress"
+	UpdateReplicationConfigActionType   ActionType = "UpdateReplicationConfig"
+	GetPlacementRuleBundlesActionType   ActionType = "GetPlacementRuleBundles"
+	SetPlacementRuleBundleActionType    ActionType = "SetPlacementRuleBundle"
+	DeletePlacementRuleBundleActionType ActionType = "DeletePlacementRuleBundle"
+	GetRegionsByCheckActionType         ActionType = "GetRegionsByCheck"
+	GetRegionsActionType                ActionType = "GetRegions"
 )
 
 type NotFoundReaction struct {
@@ -647,9 +958,12 @@ func (nfr *NotFoundReaction) Error() string {
 }
 
 type Action struct {
-	ID     uint64
-	Name   string
-	Labels map[string]string
+	ID                  uint64
+	IDs                 []uint64
+	Name                string
+	Labels              map[string]string
+	Config              map[string]interface{}
+	PlacementRuleBundle *PlacementRuleBundle
 }
 
 type Reaction func(action *Action) (interface{}, error)
@@ -696,6 +1010,15 @@ func (pc *FakePDClient) GetConfig() (*server.Config, error) {
 	return result.(*server.Config), nil
 }
 
+func (pc *FakePDClient) UpdateScheduleConfig(config map[string]interface{}) error {
+	if reaction, ok := pc.reactions[UpdateScheduleConfigActionType]; ok {
+		action := &Action{Config: config}
+		_, err := reaction(action)
+		return err
+	}
+	return nil
+}
+
 func (pc *FakePDClient) GetCluster() (*metapb.Cluster, error) {
 	action := &Action{}
 	result, err := pc.fakeAPI(GetClusterActionType, action)
@@ -824,3 +1147,75 @@ func (pc *FakePDClient) TransferPDLeader(memberName string) error {
 	}
 	return nil
 }
+
+func (pc *FakePDClient) UnsafeRemoveFailedStores(storeIDs []uint64) error {
+	if reaction, ok := pc.reactions[UnsafeRemoveFailedStoresActionType]; ok {
+		action := &Action{IDs: storeIDs}
+		_, err := reaction(action)
+		return err
+	}
+	return nil
+}
+
+func (pc *FakePDClient) GetUnsafeRecoverProgress() ([]UnsafeRecoverStage, error) {
+	action := &Action{}
+	result, err := pc.fakeAPI(GetUnsafeRecoverProgressActionType, action)
+	if err != nil {
+		return nil, err
+	}
+	return result.([]UnsafeRecoverStage), nil
+}
+
+func (pc *FakePDClient) UpdateReplicationConfig(config map[string]interface{}) error {
+	if reaction, ok := pc.reactions[UpdateReplicationConfigActionType]; ok {
+		action := &Action{Config: config}
+		_, err := reaction(action)
+		return err
+	}
+	return nil
+}
+
+func (pc *FakePDClient) GetPlacementRuleBundles() ([]*PlacementRuleBundle, error) {
+	action := &Action{}
+	result, err := pc.fakeAPI(GetPlacementRuleBundlesActionType, action)
+	if err != nil {
+		return nil, err
+	}
+	return result.([]*PlacementRuleBundle), nil
+}
+
+func (pc *FakePDClient) SetPlacementRuleBundle(bundle *PlacementRuleBundle) error {
+	if reaction, ok := pc.reactions[SetPlacementRuleBundleActionType]; ok {
+		action := &Action{Name: bundle.ID, PlacementRuleBundle: bundle}
+		_, err := reaction(action)
+		return err
+	}
+	return nil
+}
+
+func (pc *FakePDClient) DeletePlacementRuleBundle(groupID string) error {
+	if reaction, ok := pc.reactions[DeletePlacementRuleBundleActionType]; ok {
+		action := &Action{Name: groupID}
+		_, err := reaction(action)
+		return err
+	}
+	return nil
+}
+
+func (pc *FakePDClient) GetRegionsByCheck(check RegionCheck) (*RegionsInfo, error) {
+	action := &Action{Name: string(check)}
+	result, err := pc.fakeAPI(GetRegionsByCheckActionType, action)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*RegionsInfo), nil
+}
+
+func (pc *FakePDClient) GetRegions() (*RegionsInfo, error) {
+	action := &Action{}
+	result, err := pc.fakeAPI(GetRegionsActionType, action)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*RegionsInfo), nil
+}
@@ -356,6 +356,223 @@ func TestSetStoreLabels(t *testing.T) {
 	}
 }
 
+func TestUpdateScheduleConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+	config := map[string]interface{}{"leader-schedule-limit": float64(0)}
+	tcs := []struct {
+		caseName string
+		path     string
+		method   string
+		want     bool
+	}{{
+		caseName: "success_UpdateScheduleConfig",
+		path:     fmt.Sprintf("/%s", configPrefix),
+		method:   "POST",
+		want:     true,
+	}, {
+		caseName: "failed_UpdateScheduleConfig",
+		path:     fmt.Sprintf("/%s", configPrefix),
+		method:   "POST",
+		want:     false,
+	},
+	}
+
+	for _, tc := range tcs {
+		svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+			g.Expect(request.Method).To(Equal(tc.method), "check method")
+			g.Expect(request.URL.Path).To(Equal(tc.path), "check url")
+
+			data := map[string]interface{}{}
+			err := readJSON(request.Body, &data)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(data).To(Equal(config), "check config")
+
+			w.Header().Set("Content-Type", ContentTypeJSON)
+			if tc.want {
+				w.WriteHeader(http.StatusOK)
+			} else {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		})
+		defer svc.Close()
+
+		pdClient := NewPDClient(svc.URL, timeout, false)
+		err := pdClient.UpdateScheduleConfig(config)
+		if tc.want {
+			g.Expect(err).NotTo(HaveOccurred(), tc.caseName)
+		} else {
+			g.Expect(err).To(HaveOccurred(), tc.caseName)
+		}
+	}
+}
+
+func TestUnsafeRemoveFailedStores(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tcs := []struct {
+		caseName string
+		want     bool
+	}{{
+		caseName: "success_UnsafeRemoveFailedStores",
+		want:     true,
+	}, {
+		caseName: "failed_UnsafeRemoveFailedStores",
+		want:     false,
+	},
+	}
+
+	for _, tc := range tcs {
+		svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+			g.Expect(request.Method).To(Equal("POST"), "check method")
+			g.Expect(request.URL.Path).To(Equal(fmt.Sprintf("/%s", unsafeRecoverPrefix)), "check url")
+
+			data := &unsafeRecoverRequest{}
+			err := readJSON(request.Body, data)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(data.Stores).To(Equal([]uint64{1, 4}), "check stores")
+
+			w.Header().Set("Content-Type", ContentTypeJSON)
+			if tc.want {
+				w.WriteHeader(http.StatusOK)
+			} else {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		})
+		defer svc.Close()
+
+		pdClient := NewPDClient(svc.URL, timeout, false)
+		err := pdClient.UnsafeRemoveFailedStores([]uint64{1, 4})
+		if tc.want {
+			g.Expect(err).NotTo(HaveOccurred(), tc.caseName)
+		} else {
+			g.Expect(err).To(HaveOccurred(), tc.caseName)
+		}
+	}
+}
+
+func TestGetUnsafeRecoverProgress(t *testing.T) {
+	g := NewGomegaWithT(t)
+	stages := []UnsafeRecoverStage{
+		{Info: "Unsafe recovery enters collect report stage", Time: "2019-10-01 10:00:00.000"},
+		{Info: "Unsafe recovery finished", Time: "2019-10-01 10:01:00.000"},
+	}
+	stagesBytes, err := json.Marshal(stages)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+		g.Expect(request.Method).To(Equal("GET"), "check method")
+		g.Expect(request.URL.Path).To(Equal(fmt.Sprintf("/%s/show", unsafeRecoverPrefix)), "check url")
+
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		w.Write(stagesBytes)
+	})
+	defer svc.Close()
+
+	pdClient := NewPDClient(svc.URL, timeout, false)
+	result, err := pdClient.GetUnsafeRecoverProgress()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(stages))
+}
+
+func TestUpdateReplicationConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+	svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+		g.Expect(request.Method).To(Equal("POST"), "check method")
+		g.Expect(request.URL.Path).To(Equal(fmt.Sprintf("/%s", replicationPrefix)), "check url")
+
+		data := map[string]interface{}{}
+		err := readJSON(request.Body, &data)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(data).To(Equal(map[string]interface{}{"enable-placement-rules": "true"}), "check config")
+
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		w.WriteHeader(http.StatusOK)
+	})
+	defer svc.Close()
+
+	pdClient := NewPDClient(svc.URL, timeout, false)
+	err := pdClient.UpdateReplicationConfig(map[string]interface{}{"enable-placement-rules": "true"})
+	g.Expect(err).NotTo(HaveOccurred())
+}
+
+func TestPlacementRuleBundles(t *testing.T) {
+	g := NewGomegaWithT(t)
+	bundles := []*PlacementRuleBundle{
+		{
+			ID: "pd",
+			Rules: []*PlacementRule{
+				{GroupID: "pd", ID: "default", Role: "voter", Count: 3},
+			},
+		},
+		{
+			ID:    "tiflash",
+			Index: 120,
+			Rules: []*PlacementRule{
+				{
+					GroupID: "tiflash",
+					ID:      "learner",
+					Role:    "learner",
+					Count:   1,
+					LabelConstraints: []PlacementLabelConstraint{
+						{Key: "engine", Op: "in", Values: []string{"tiflash"}},
+					},
+				},
+			},
+		},
+	}
+	bundlesBytes, err := json.Marshal(bundles)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		switch request.Method {
+		case "GET":
+			g.Expect(request.URL.Path).To(Equal(fmt.Sprintf("/%s", placementRulePrefix)), "check url")
+			w.Write(bundlesBytes)
+		case "POST":
+			g.Expect(request.URL.Path).To(Equal(fmt.Sprintf("/%s/tiflash", placementRulePrefix)), "check url")
+			data := &PlacementRuleBundle{}
+			err := readJSON(request.Body, data)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(data).To(Equal(bundles[1]), "check bundle")
+			w.WriteHeader(http.StatusOK)
+		case "DELETE":
+			g.Expect(request.URL.Path).To(Equal(fmt.Sprintf("/%s/tiflash", placementRulePrefix)), "check url")
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	defer svc.Close()
+
+	pdClient := NewPDClient(svc.URL, timeout, false)
+	result, err := pdClient.GetPlacementRuleBundles()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(bundles))
+	g.Expect(pdClient.SetPlacementRuleBundle(bundles[1])).To(Succeed())
+	// the deleted group is ignored
+	g.Expect(pdClient.DeletePlacementRuleBundle("tiflash")).To(Succeed())
+}
+
+func TestGetRegionsByCheck(t *testing.T) {
+	g := NewGomegaWithT(t)
+	regions := &RegionsInfo{
+		Count:   1,
+		Regions: []*RegionInfo{{ID: 2, StartKey: "7480000000000000FF1500", EndKey: ""}},
+	}
+	regionsBytes, err := json.Marshal(regions)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		g.Expect(request.URL.Path).To(Equal(fmt.Sprintf("/%s/pending-peer", regionsCheckPrefix)), "check url")
+		w.Write(regionsBytes)
+	})
+	defer svc.Close()
+
+	pdClient := NewPDClient(svc.URL, timeout, false)
+	result, err := pdClient.GetRegionsByCheck(RegionCheckPendingPeer)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(regions))
+}
+
 func TestDeleteMember(t *testing.T) {
 	g := NewGomegaWithT(t)
 	name := "testMember"
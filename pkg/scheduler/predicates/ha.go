@@ -18,14 +18,15 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
 	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -42,6 +43,7 @@ type ha struct {
 	cli           versioned.Interface
 	podListFn     func(ns, instanceName, component string) (*apiv1.PodList, error)
 	podGetFn      func(ns, podName string) (*apiv1.Pod, error)
+	nodeGetFn     func(nodeName string) (*apiv1.Node, error)
 	pvcGetFn      func(ns, pvcName string) (*apiv1.PersistentVolumeClaim, error)
 	tcGetFn       func(ns, tcName string) (*v1alpha1.TidbCluster, error)
 	pvcListFn     func(ns, instanceName, component string) (*apiv1.PersistentVolumeClaimList, error)
@@ -59,6 +61,7 @@ func NewHA(kubeCli kubernetes.Interface, cli versioned.Interface, recorder recor
 	}
 	h.podListFn = h.realPodListFn
 	h.podGetFn = h.realPodGetFn
+	h.nodeGetFn = h.realNodeGetFn
 	h.pvcGetFn = h.realPVCGetFn
 	h.tcGetFn = h.realTCGetFn
 	h.pvcListFn = h.realPVCListFn
@@ -79,6 +82,10 @@ func (h *ha) Name() string {
 //     when replicas is less than 3, no HA is forced because HA is impossible
 //     when replicas is equal or greater than 3, we require TiKV pods are running on more than 3 nodes and no more than ceil(replicas / 3) per node
 //  for PD/TiKV, we both try to balance the number of pods acorss the nodes
+//  c) the max pods per node can be overridden by the TidbCluster annotations, e.g. to allow 2 TiKV pods per node
+//     in a dev cluster, and the pods per zone can be limited by the annotations as well
+//     d) if the TidbCluster is annotated with tidb.pingcap.com/ha-mode=preferred, the constraints are advisory, the
+//     nodes with the least pods are returned if no node satisfies the constraints, e.g. there are fewer nodes than replicas
 // 3. let kube-scheduler to make the final decision
 func (h *ha) Filter(instanceName string, pod *apiv1.Pod, nodes []apiv1.Node) ([]apiv1.Node, error) {
 	h.lock.Lock()
@@ -90,7 +97,7 @@ func (h *ha) Filter(instanceName string, pod *apiv1.Pod, nodes []apiv1.Node) ([]
 	tcName := getTCNameFromPod(pod, component)
 
 	if component != label.PDLabelVal && component != label.TiKVLabelVal {
-		glog.V(4).Infof("component %s is ignored in HA predicate", component)
+		log.V(4).Infof("component %s is ignored in HA predicate", component)
 		return nodes, nil
 	}
 
@@ -121,7 +128,9 @@ func (h *ha) Filter(instanceName string, pod *apiv1.Pod, nodes []apiv1.Node) ([]
 		return nil, err
 	}
 	replicas := getReplicasFrom(tc, component)
-	glog.Infof("ha: tidbcluster %s/%s component %s replicas %d", ns, tcName, component, replicas)
+	log.Infof("ha: tidbcluster %s/%s component %s replicas %d", ns, tcName, component, replicas)
+	maxPodsPerNodeOverride := getMaxPodsFrom(tc, component, maxPodsPerNodeAnnotations)
+	maxPodsPerZone := getMaxPodsFrom(tc, component, maxPodsPerZoneAnnotations)
 
 	allNodes := make(sets.String)
 	nodeMap := make(map[string][]string)
@@ -140,7 +149,16 @@ func (h *ha) Filter(instanceName string, pod *apiv1.Pod, nodes []apiv1.Node) ([]
 
 		nodeMap[nodeName] = append(nodeMap[nodeName], pName)
 	}
-	glog.V(4).Infof("nodeMap: %+v", nodeMap)
+	log.V(4).Infof("nodeMap: %+v", nodeMap)
+
+	zonePods := make(map[string]int)
+	if maxPodsPerZone > 0 {
+		zonePods, err = h.countPodsPerZone(podList, nodes)
+		if err != nil {
+			return nil, err
+		}
+		log.V(4).Infof("zonePods: %+v", zonePods)
+	}
 
 	min := -1
 	minNodeNames := make([]string, 0)
@@ -148,7 +166,9 @@ func (h *ha) Filter(instanceName string, pod *apiv1.Pod, nodes []apiv1.Node) ([]
 		podsCount := len(podNames)
 		maxPodsPerNode := 0
 
-		if component == label.PDLabelVal {
+		if maxPodsPerNodeOverride > 0 {
+			maxPodsPerNode = maxPodsPerNodeOverride
+		} else if component == label.PDLabelVal {
 			/**
 			 * replicas     maxPodsPerNode
 			 * ---------------------------
@@ -167,7 +187,7 @@ func (h *ha) Filter(instanceName string, pod *apiv1.Pod, nodes []apiv1.Node) ([]
 			// replicas less than 3 cannot achieve high availability
 			if replicas < 3 {
 				minNodeNames = append(minNodeNames, nodeName)
-				glog.Infof("replicas is %d, add node %s to minNodeNames", replicas, nodeName)
+				log.Infof("replicas is %d, add node %s to minNodeNames", replicas, nodeName)
 				continue
 			}
 
@@ -195,17 +215,22 @@ func (h *ha) Filter(instanceName string, pod *apiv1.Pod, nodes []apiv1.Node) ([]
 
 		if podsCount+1 > maxPodsPerNode {
 			// pods on this node exceeds the limit, skip
-			glog.Infof("node %s has %d instances of component %s, max allowed is %d, skipping",
+			log.Infof("node %s has %d instances of component %s, max allowed is %d, skipping",
 				nodeName, podsCount, component, maxPodsPerNode)
 			continue
 		}
+		if zone := getNodeZone(nodes, nodeName); maxPodsPerZone > 0 && zone != "" && zonePods[zone]+1 > maxPodsPerZone {
+			log.Infof("zone %s of node %s has %d instances of component %s, max allowed is %d, skipping",
+				zone, nodeName, zonePods[zone], component, maxPodsPerZone)
+			continue
+		}
 
 		// Choose nodes which has minimum count of the component
 		if min == -1 {
 			min = podsCount
 		}
 		if podsCount > min {
-			glog.Infof("node %s podsCount %d > min %d, skipping", nodeName, podsCount, min)
+			log.Infof("node %s podsCount %d > min %d, skipping", nodeName, podsCount, min)
 			continue
 		}
 		if podsCount < min {
@@ -215,9 +240,17 @@ func (h *ha) Filter(instanceName string, pod *apiv1.Pod, nodes []apiv1.Node) ([]
 		minNodeNames = append(minNodeNames, nodeName)
 	}
 
+	if len(minNodeNames) == 0 && tc.Annotations[label.AnnHAModeKey] == label.AnnHAModePreferred {
+		leastNodeNames := getLeastLoadedNodeNames(nodeMap)
+		msg := fmt.Sprintf("no node of %v satisfies the HA constraints, because these pods had been scheduled to nodes: %v, schedule to the least loaded nodes: %v",
+			GetNodeNames(nodes), nodeMap, leastNodeNames)
+		log.Info(msg)
+		h.recorder.Event(pod, apiv1.EventTypeWarning, "HAConstraintsUnsatisfied", msg)
+		return getNodeFromNames(nodes, leastNodeNames), nil
+	}
 	if len(minNodeNames) == 0 {
 		msg := fmt.Sprintf("can't schedule to nodes: %v, because these pods had been scheduled to nodes: %v", GetNodeNames(nodes), nodeMap)
-		glog.Info(msg)
+		log.Info(msg)
 		h.recorder.Event(pod, apiv1.EventTypeWarning, "FailedScheduling", msg)
 		return nil, errors.New(msg)
 	}
@@ -276,11 +309,11 @@ func (h *ha) realAcquireLock(pod *apiv1.Pod) (*apiv1.PersistentVolumeClaim, *api
 	delete(schedulingPVC.Annotations, label.AnnPVCPodScheduling)
 	err = h.updatePVCFn(schedulingPVC)
 	if err != nil {
-		glog.Errorf("ha: failed to delete pvc %s/%s annotation %s, %v",
+		log.Errorf("ha: failed to delete pvc %s/%s annotation %s, %v",
 			ns, schedulingPVC.GetName(), label.AnnPVCPodScheduling, err)
 		return schedulingPVC, currentPVC, err
 	}
-	glog.Infof("ha: delete pvc %s/%s annotation %s successfully",
+	log.Infof("ha: delete pvc %s/%s annotation %s successfully",
 		ns, schedulingPVC.GetName(), label.AnnPVCPodScheduling)
 	return schedulingPVC, currentPVC, h.setCurrentPodScheduling(currentPVC)
 }
@@ -296,6 +329,39 @@ func (h *ha) realPodGetFn(ns, podName string) (*apiv1.Pod, error) {
 	return h.kubeCli.CoreV1().Pods(ns).Get(podName, metav1.GetOptions{})
 }
 
+func (h *ha) realNodeGetFn(nodeName string) (*apiv1.Node, error) {
+	return h.kubeCli.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+}
+
+// countPodsPerZone returns the number of the pods in each zone, the pods on the nodes without the zone label are not counted
+func (h *ha) countPodsPerZone(podList *apiv1.PodList, nodes []apiv1.Node) (map[string]int, error) {
+	zones := make(map[string]string)
+	for _, node := range nodes {
+		zones[node.GetName()] = node.Labels[apiv1.LabelZoneFailureDomain]
+	}
+	zonePods := make(map[string]int)
+	for _, pod := range podList.Items {
+		nodeName := pod.Spec.NodeName
+		if nodeName == "" {
+			continue
+		}
+		zone, ok := zones[nodeName]
+		if !ok {
+			// the pod is on a node which is not feasible for the current pod
+			node, err := h.nodeGetFn(nodeName)
+			if err != nil {
+				return nil, err
+			}
+			zone = node.Labels[apiv1.LabelZoneFailureDomain]
+			zones[nodeName] = zone
+		}
+		if zone != "" {
+			zonePods[zone]++
+		}
+	}
+	return zonePods, nil
+}
+
 func (h *ha) realPVCListFn(ns, instanceName, component string) (*apiv1.PersistentVolumeClaimList, error) {
 	selector := label.New().Instance(instanceName).Component(component).Labels()
 	return h.kubeCli.CoreV1().PersistentVolumeClaims(ns).List(metav1.ListOptions{
@@ -313,10 +379,10 @@ func (h *ha) realUpdatePVCFn(pvc *apiv1.PersistentVolumeClaim) error {
 	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 		_, updateErr := h.kubeCli.CoreV1().PersistentVolumeClaims(ns).Update(pvc)
 		if updateErr == nil {
-			glog.Infof("update PVC: [%s/%s] successfully, TidbCluster: %s", ns, pvcName, tcName)
+			log.Infof("update PVC: [%s/%s] successfully, TidbCluster: %s", ns, pvcName, tcName)
 			return nil
 		}
-		glog.Errorf("failed to update PVC: [%s/%s], TidbCluster: %s, error: %v", ns, pvcName, tcName, updateErr)
+		log.Errorf("failed to update PVC: [%s/%s], TidbCluster: %s, error: %v", ns, pvcName, tcName, updateErr)
 
 		if updated, err := h.pvcGetFn(ns, pvcName); err == nil {
 			// make a copy so we don't mutate the shared cache
@@ -349,11 +415,11 @@ func (h *ha) setCurrentPodScheduling(pvc *apiv1.PersistentVolumeClaim) error {
 	pvc.Annotations[label.AnnPVCPodScheduling] = now
 	err := h.updatePVCFn(pvc)
 	if err != nil {
-		glog.Errorf("ha: failed to set pvc %s/%s annotation %s to %s, %v",
+		log.Errorf("ha: failed to set pvc %s/%s annotation %s to %s, %v",
 			ns, pvcName, label.AnnPVCPodScheduling, now, err)
 		return err
 	}
-	glog.Infof("ha: set pvc %s/%s annotation %s to %s successfully",
+	log.Infof("ha: set pvc %s/%s annotation %s to %s successfully",
 		ns, pvcName, label.AnnPVCPodScheduling, now)
 	return nil
 }
@@ -370,6 +436,61 @@ func getReplicasFrom(tc *v1alpha1.TidbCluster, component string) int32 {
 	return tc.Spec.TiKV.Replicas
 }
 
+var (
+	maxPodsPerNodeAnnotations = map[string]string{
+		label.PDLabelVal:   label.AnnPDMaxPodsPerNode,
+		label.TiKVLabelVal: label.AnnTiKVMaxPodsPerNode,
+	}
+	maxPodsPerZoneAnnotations = map[string]string{
+		label.PDLabelVal:   label.AnnPDMaxPodsPerZone,
+		label.TiKVLabelVal: label.AnnTiKVMaxPodsPerZone,
+	}
+)
+
+// getMaxPodsFrom returns the limit of the component set by the TidbCluster annotation, or 0 if it's not set,
+// the invalid value is ignored
+func getMaxPodsFrom(tc *v1alpha1.TidbCluster, component string, annotations map[string]string) int {
+	key := annotations[component]
+	val, ok := tc.Annotations[key]
+	if !ok {
+		return 0
+	}
+	limit, err := strconv.Atoi(val)
+	if err != nil || limit <= 0 {
+		log.Errorf("ha: tidbcluster %s/%s annotation %s: %q is ignored, it should be a positive integer",
+			tc.GetNamespace(), tc.GetName(), key, val)
+		return 0
+	}
+	return limit
+}
+
+// getLeastLoadedNodeNames returns the names of the nodes with the least pods
+func getLeastLoadedNodeNames(nodeMap map[string][]string) []string {
+	min := -1
+	nodeNames := make([]string, 0)
+	for nodeName, podNames := range nodeMap {
+		if min != -1 && len(podNames) > min {
+			continue
+		}
+		if min == -1 || len(podNames) < min {
+			min = len(podNames)
+			nodeNames = make([]string, 0)
+		}
+		nodeNames = append(nodeNames, nodeName)
+	}
+	sort.Strings(nodeNames)
+	return nodeNames
+}
+
+func getNodeZone(nodes []apiv1.Node, nodeName string) string {
+	for _, node := range nodes {
+		if node.GetName() == nodeName {
+			return node.Labels[apiv1.LabelZoneFailureDomain]
+		}
+	}
+	return ""
+}
+
 func pvcName(component, podName string) string {
 	return fmt.Sprintf("%s-%s", component, podName)
 }
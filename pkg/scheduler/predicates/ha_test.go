@@ -433,6 +433,7 @@ func TestHAFilter(t *testing.T) {
 		nodesFn       func() []apiv1.Node
 		podListFn     func(string, string, string) (*apiv1.PodList, error)
 		podGetFn      func(string, string) (*apiv1.Pod, error)
+		nodeGetFn     func(string) (*apiv1.Node, error)
 		pvcGetFn      func(string, string) (*apiv1.PersistentVolumeClaim, error)
 		tcGetFn       func(string, string) (*v1alpha1.TidbCluster, error)
 		acquireLockFn func(*apiv1.Pod) (*apiv1.PersistentVolumeClaim, *apiv1.PersistentVolumeClaim, error)
@@ -450,6 +451,7 @@ func TestHAFilter(t *testing.T) {
 
 		ha := ha{
 			podListFn:     test.podListFn,
+			nodeGetFn:     test.nodeGetFn,
 			pvcGetFn:      test.pvcGetFn,
 			tcGetFn:       test.tcGetFn,
 			acquireLockFn: test.acquireLockFn,
@@ -907,6 +909,119 @@ func TestHAFilter(t *testing.T) {
 				g.Expect(getSortedNodeNames(nodes)).To(Equal([]string{"kube-node-2"}))
 			},
 		},
+		{
+			name:      "two nodes, 2,2 pods scheduled on these two nodes, replicas is 5, max pods per node is 3, return these two nodes",
+			podFn:     newHATiKVPod,
+			nodesFn:   fakeTwoNodes,
+			podListFn: podListFn(map[string][]int32{"kube-node-1": {0, 1}, "kube-node-2": {2, 3}}),
+			tcGetFn: func(ns string, tcName string) (*v1alpha1.TidbCluster, error) {
+				tc, _ := tcGetFn(ns, tcName)
+				tc.Spec.TiKV.Replicas = 5
+				tc.Annotations = map[string]string{label.AnnTiKVMaxPodsPerNode: "3"}
+				return tc, nil
+			},
+			acquireLockFn: acquireSuccess,
+			expectFn: func(nodes []apiv1.Node, err error, _ record.FakeRecorder) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(getSortedNodeNames(nodes)).To(Equal([]string{"kube-node-1", "kube-node-2"}))
+			},
+		},
+		{
+			name:      "two nodes, 2,2 pods scheduled on these two nodes, replicas is 5, invalid max pods per node is ignored, can't schedule",
+			podFn:     newHATiKVPod,
+			nodesFn:   fakeTwoNodes,
+			podListFn: podListFn(map[string][]int32{"kube-node-1": {0, 1}, "kube-node-2": {2, 3}}),
+			tcGetFn: func(ns string, tcName string) (*v1alpha1.TidbCluster, error) {
+				tc, _ := tcGetFn(ns, tcName)
+				tc.Spec.TiKV.Replicas = 5
+				tc.Annotations = map[string]string{label.AnnTiKVMaxPodsPerNode: "-1"}
+				return tc, nil
+			},
+			acquireLockFn: acquireSuccess,
+			expectFn: func(nodes []apiv1.Node, err error, _ record.FakeRecorder) {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(len(nodes)).To(Equal(0))
+			},
+		},
+		{
+			name:      "two nodes, 2,1 pods scheduled on these two nodes, replicas is 5, preferred HA mode, return the least loaded node",
+			podFn:     newHATiKVPod,
+			nodesFn:   fakeTwoNodes,
+			podListFn: podListFn(map[string][]int32{"kube-node-1": {0, 1}, "kube-node-2": {2}}),
+			tcGetFn: func(ns string, tcName string) (*v1alpha1.TidbCluster, error) {
+				tc, _ := tcGetFn(ns, tcName)
+				tc.Spec.TiKV.Replicas = 5
+				tc.Annotations = map[string]string{label.AnnHAModeKey: label.AnnHAModePreferred}
+				return tc, nil
+			},
+			acquireLockFn: acquireSuccess,
+			expectFn: func(nodes []apiv1.Node, err error, recorder record.FakeRecorder) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(getSortedNodeNames(nodes)).To(Equal([]string{"kube-node-2"}))
+				events := collectEvents(recorder.Events)
+				g.Expect(events).To(HaveLen(1))
+				g.Expect(events[0]).To(ContainSubstring("HAConstraintsUnsatisfied"))
+			},
+		},
+		{
+			name:      "three nodes, three pods scheduled on these three nodes, preferred HA mode, return all the three nodes",
+			podFn:     newHAPDPod,
+			nodesFn:   fakeThreeNodes,
+			podListFn: podListFn(map[string][]int32{"kube-node-1": {0}, "kube-node-2": {1}, "kube-node-3": {2}}),
+			tcGetFn: func(ns string, tcName string) (*v1alpha1.TidbCluster, error) {
+				tc, _ := tcGetFn(ns, tcName)
+				tc.Annotations = map[string]string{label.AnnHAModeKey: label.AnnHAModePreferred}
+				return tc, nil
+			},
+			acquireLockFn: acquireSuccess,
+			expectFn: func(nodes []apiv1.Node, err error, _ record.FakeRecorder) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(getSortedNodeNames(nodes)).To(Equal([]string{"kube-node-1", "kube-node-2", "kube-node-3"}))
+			},
+		},
+		{
+			name:    "three nodes in two zones, two pods scheduled in zone-a, max pods per zone is 2, return the node in zone-b",
+			podFn:   newHAPDPod,
+			nodesFn: fakeThreeNodesInTwoZones,
+			// kube-node-4 in zone-a is not a feasible node
+			podListFn: podListFn(map[string][]int32{"kube-node-1": {0}, "kube-node-4": {1}}),
+			nodeGetFn: func(nodeName string) (*apiv1.Node, error) {
+				g.Expect(nodeName).To(Equal("kube-node-4"))
+				return &apiv1.Node{
+					ObjectMeta: metav1.ObjectMeta{Name: nodeName, Labels: map[string]string{apiv1.LabelZoneFailureDomain: "zone-a"}},
+				}, nil
+			},
+			tcGetFn: func(ns string, tcName string) (*v1alpha1.TidbCluster, error) {
+				tc, _ := tcGetFn(ns, tcName)
+				tc.Spec.PD.Replicas = 5
+				tc.Annotations = map[string]string{label.AnnPDMaxPodsPerZone: "2"}
+				return tc, nil
+			},
+			acquireLockFn: acquireSuccess,
+			expectFn: func(nodes []apiv1.Node, err error, _ record.FakeRecorder) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(getSortedNodeNames(nodes)).To(Equal([]string{"kube-node-3"}))
+			},
+		},
+		{
+			name:      "three nodes in two zones, get the node of a scheduled pod failed",
+			podFn:     newHAPDPod,
+			nodesFn:   fakeThreeNodesInTwoZones,
+			podListFn: podListFn(map[string][]int32{"kube-node-4": {0}}),
+			nodeGetFn: func(nodeName string) (*apiv1.Node, error) {
+				return nil, errors.New("get node failed")
+			},
+			tcGetFn: func(ns string, tcName string) (*v1alpha1.TidbCluster, error) {
+				tc, _ := tcGetFn(ns, tcName)
+				tc.Annotations = map[string]string{label.AnnPDMaxPodsPerZone: "1"}
+				return tc, nil
+			},
+			acquireLockFn: acquireSuccess,
+			expectFn: func(nodes []apiv1.Node, err error, _ record.FakeRecorder) {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(strings.Contains(err.Error(), "get node failed")).To(BeTrue())
+			},
+		},
 	}
 
 	for i := range tests {
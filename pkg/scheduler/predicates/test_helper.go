@@ -35,6 +35,14 @@ func fakeThreeNodes() []apiv1.Node {
 	}
 }
 
+func fakeThreeNodesInTwoZones() []apiv1.Node {
+	nodes := fakeThreeNodes()
+	nodes[0].Labels = map[string]string{apiv1.LabelZoneFailureDomain: "zone-a"}
+	nodes[1].Labels = map[string]string{apiv1.LabelZoneFailureDomain: "zone-a"}
+	nodes[2].Labels = map[string]string{apiv1.LabelZoneFailureDomain: "zone-b"}
+	return nodes
+}
+
 func fakeFourNodes() []apiv1.Node {
 	return []apiv1.Node{
 		{
@@ -19,8 +19,8 @@ import (
 	"sync"
 
 	restful "github.com/emicklei/go-restful"
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	"github.com/pingcap/tidb-operator/pkg/scheduler"
 	"k8s.io/client-go/kubernetes"
 	schedulerapiv1 "k8s.io/kubernetes/pkg/scheduler/api/v1"
@@ -58,8 +58,8 @@ func StartServer(kubeCli kubernetes.Interface, cli versioned.Interface, port int
 		Writes(schedulerapiv1.HostPriorityList{}))
 	restful.Add(ws)
 
-	glog.Infof("start scheduler extender server, listening on 0.0.0.0:%d", port)
-	glog.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), nil))
+	log.Infof("start scheduler extender server, listening on 0.0.0.0:%d", port)
+	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), nil))
 }
 
 func (svr *server) filterNode(req *restful.Request, resp *restful.Response) {
@@ -104,8 +104,8 @@ func (svr *server) prioritizeNode(req *restful.Request, resp *restful.Response)
 }
 
 func errorResponse(resp *restful.Response, svcErr restful.ServiceError) {
-	glog.Error(svcErr.Message)
+	log.Error(svcErr.Message)
 	if writeErr := resp.WriteServiceError(svcErr.Code, svcErr); writeErr != nil {
-		glog.Errorf("unable to write error: %v", writeErr)
+		log.Errorf("unable to write error: %v", writeErr)
 	}
 }
@@ -15,6 +15,7 @@ package alias
 
 import (
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -43,3 +44,35 @@ func (t TikvList) DeepCopyObject() runtime.Object {
 	}
 	return out
 }
+
+// ClusterMembers combines the status of the tidb cluster, the pods and the
+// pvcs of the members and the stores reported by PD, so the members are
+// printed in one table
+type ClusterMembers struct {
+	TidbCluster *v1alpha1.TidbCluster
+	PodList     *v1.PodList
+	PVCList     *v1.PersistentVolumeClaimList
+	// Stores is nil if PD is unreachable
+	Stores *pdapi.StoresInfo
+}
+
+// implement type Object interface
+func (c ClusterMembers) GetObjectKind() schema.ObjectKind {
+	return c.TidbCluster.GetObjectKind()
+}
+
+func (c ClusterMembers) DeepCopyObject() runtime.Object {
+	out := ClusterMembers{
+		Stores: c.Stores,
+	}
+	if c.TidbCluster != nil {
+		out.TidbCluster = c.TidbCluster.DeepCopy()
+	}
+	if c.PodList != nil {
+		out.PodList = c.PodList.DeepCopy()
+	}
+	if c.PVCList != nil {
+		out.PVCList = c.PVCList.DeepCopy()
+	}
+	return out
+}
@@ -18,6 +18,7 @@ import (
 	"io"
 
 	"github.com/pingcap/tidb-operator/pkg/tkctl/cmd/completion"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/cmd/ctl"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/cmd/ctop"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/cmd/debug"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/cmd/get"
@@ -84,6 +85,7 @@ func NewTkcCommand(streams genericclioptions.IOStreams) *cobra.Command {
 			Commands: []*cobra.Command{
 				debug.NewCmdDebug(tkcContext, streams),
 				ctop.NewCmdCtop(tkcContext, streams),
+				ctl.NewCmdCtl(tkcContext, streams),
 			},
 		},
 		{
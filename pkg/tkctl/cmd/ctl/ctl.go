@@ -0,0 +1,368 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/config"
+	"github.com/spf13/cobra"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+	cmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+)
+
+const (
+	ctlLongDesc = `
+		Run pd-ctl, tikv-ctl or tidb-ctl against a member of the tidb cluster.
+
+		The port of the member is forwarded to a local port and the local binary is
+		run against it. If TLS is enabled in the cluster, the client certificate in
+		the 'client-tls' secret and the CA of the kubernetes cluster are passed to
+		the binary automatically.
+
+		The binaries are looked up in the PATH by default, you can get them from
+		the pingcap/tidb-control image or the tidb binary package.
+`
+	ctlExample = `
+		# show the stores of the current tidb cluster (set by tkctl use)
+		tkctl ctl pd -- store
+
+		# run pd-ctl in the interactive mode
+		tkctl ctl pd -- -i
+
+		# show the metrics of the specified tikv
+		tkctl ctl tikv --pod demo-tikv-1 -- metrics
+
+		# show the schema of a database through the status api of tidb
+		tkctl ctl tidb -t another-cluster -- schema in mysql
+`
+	ctlUsage = `expected 'ctl pd|tikv|tidb -t CLUSTER_NAME -- ARGS' for the ctl command or
+using 'tkctl use' to set tidb cluster first.
+`
+
+	clientTLSSecret = "client-tls"
+)
+
+// component describes how to run the ctl binary of a tidb component
+type component struct {
+	binary string
+	port   int
+	// args returns the arguments connecting the binary to the local port
+	args func(scheme string, port int, certs *certFiles) []string
+}
+
+// certFiles contains the paths of the certificates passed to the binaries
+type certFiles struct {
+	ca   string
+	cert string
+	key  string
+}
+
+var components = map[v1alpha1.MemberType]component{
+	v1alpha1.PDMemberType: {
+		binary: "pd-ctl",
+		port:   2379,
+		args: func(scheme string, port int, certs *certFiles) []string {
+			args := []string{"-u", fmt.Sprintf("%s://127.0.0.1:%d", scheme, port)}
+			if certs != nil {
+				args = append(args, "--cacert", certs.ca, "--cert", certs.cert, "--key", certs.key)
+			}
+			return args
+		},
+	},
+	v1alpha1.TiKVMemberType: {
+		binary: "tikv-ctl",
+		port:   20160,
+		args: func(_ string, port int, certs *certFiles) []string {
+			args := []string{"--host", fmt.Sprintf("127.0.0.1:%d", port)}
+			if certs != nil {
+				args = append(args, "--ca-path", certs.ca, "--cert-path", certs.cert, "--key-path", certs.key)
+			}
+			return args
+		},
+	},
+	v1alpha1.TiDBMemberType: {
+		binary: "tidb-ctl",
+		port:   10080,
+		args: func(_ string, port int, _ *certFiles) []string {
+			return []string{"--host", "127.0.0.1", "--port", fmt.Sprintf("%d", port)}
+		},
+	},
+}
+
+// CtlOptions contains the input to the ctl command
+type CtlOptions struct {
+	TidbClusterName string
+	Namespace       string
+	Component       v1alpha1.MemberType
+	PodName         string
+	Binary          string
+	Args            []string
+
+	TcCli      *versioned.Clientset
+	KubeCli    *kubernetes.Clientset
+	RestConfig *rest.Config
+
+	genericclioptions.IOStreams
+}
+
+// NewCtlOptions returns a CtlOptions
+func NewCtlOptions(streams genericclioptions.IOStreams) *CtlOptions {
+	return &CtlOptions{
+		IOStreams: streams,
+	}
+}
+
+// NewCmdCtl creates the ctl command which runs the control tools of the tidb components
+func NewCmdCtl(tkcContext *config.TkcContext, streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewCtlOptions(streams)
+
+	cmd := &cobra.Command{
+		Use:       "ctl pd|tikv|tidb -- ARGS",
+		Short:     "Run pd-ctl, tikv-ctl or tidb-ctl against the tidb cluster",
+		Long:      ctlLongDesc,
+		Example:   ctlExample,
+		ValidArgs: []string{"pd", "tikv", "tidb"},
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(tkcContext, cmd, args))
+			cmdutil.CheckErr(o.Run())
+		},
+	}
+	cmd.Flags().StringVar(&o.PodName, "pod", o.PodName,
+		"The member to connect to, default to the first running member of the component")
+	cmd.Flags().StringVar(&o.Binary, "binary", o.Binary,
+		"Path of the ctl binary, default to pd-ctl, tikv-ctl or tidb-ctl in the PATH")
+
+	return cmd
+}
+
+// Complete populate default values for CtlOptions
+func (o *CtlOptions) Complete(tkcContext *config.TkcContext, cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return cmdutil.UsageErrorf(cmd, ctlUsage)
+	}
+	o.Component = v1alpha1.MemberType(args[0])
+	c, ok := components[o.Component]
+	if !ok {
+		return cmdutil.UsageErrorf(cmd, "unknown component %s, expected one of pd, tikv and tidb", args[0])
+	}
+	o.Args = args[1:]
+	if len(o.Binary) == 0 {
+		o.Binary = c.binary
+	}
+
+	clientConfig, err := tkcContext.ToTkcClientConfig()
+	if err != nil {
+		return err
+	}
+	if tidbClusterName, ok := clientConfig.TidbClusterName(); ok {
+		o.TidbClusterName = tidbClusterName
+	} else {
+		return cmdutil.UsageErrorf(cmd, ctlUsage)
+	}
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return err
+	}
+	o.Namespace = namespace
+
+	restConfig, err := clientConfig.RestConfig()
+	if err != nil {
+		return err
+	}
+	o.RestConfig = restConfig
+	tcCli, err := versioned.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	o.TcCli = tcCli
+	kubeCli, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	o.KubeCli = kubeCli
+
+	return nil
+}
+
+// Run forwards the port of the member and runs the binary against it
+func (o *CtlOptions) Run() error {
+	c := components[o.Component]
+	binary, err := exec.LookPath(o.Binary)
+	if err != nil {
+		return fmt.Errorf("cannot find %s, please install it or specify it by --binary: %v", o.Binary, err)
+	}
+
+	tc, err := o.TcCli.PingcapV1alpha1().TidbClusters(o.Namespace).Get(o.TidbClusterName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	pod, err := o.getPod()
+	if err != nil {
+		return err
+	}
+
+	var certs *certFiles
+	if tc.Spec.EnableTLSCluster {
+		if o.Component == v1alpha1.TiDBMemberType {
+			return fmt.Errorf("tidb-ctl does not support TLS, cannot connect to tidb cluster %s", tc.Name)
+		}
+		dir, err := ioutil.TempDir("", "tkctl-ctl")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		certs, err = o.writeCerts(dir)
+		if err != nil {
+			return err
+		}
+	}
+
+	localPort, err := freePort()
+	if err != nil {
+		return err
+	}
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	if err := o.forwardPort(pod, localPort, c.port, stopCh); err != nil {
+		return err
+	}
+
+	cmd := exec.Command(binary, append(c.args(tc.Scheme(), localPort, certs), o.Args...)...)
+	cmd.Stdin = o.In
+	cmd.Stdout = o.Out
+	cmd.Stderr = o.ErrOut
+	return cmd.Run()
+}
+
+// getPod returns the specified member, or the first running member of the component
+func (o *CtlOptions) getPod() (*v1.Pod, error) {
+	if len(o.PodName) > 0 {
+		pod, err := o.KubeCli.CoreV1().Pods(o.Namespace).Get(o.PodName, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		if pod.Labels[label.InstanceLabelKey] != o.TidbClusterName || pod.Labels[label.ComponentLabelKey] != o.Component.String() {
+			return nil, fmt.Errorf("pod %s is not a %s member of tidb cluster %s", o.PodName, o.Component, o.TidbClusterName)
+		}
+		return pod, nil
+	}
+
+	podList, err := o.KubeCli.CoreV1().Pods(o.Namespace).List(metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s,%s=%s", label.InstanceLabelKey, o.TidbClusterName, label.ComponentLabelKey, o.Component),
+	})
+	if err != nil {
+		return nil, err
+	}
+	pods := podList.Items
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+	for i := range pods {
+		if pods[i].Status.Phase == v1.PodRunning {
+			return &pods[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no running %s member found in tidb cluster %s", o.Component, o.TidbClusterName)
+}
+
+// writeCerts writes the client certificate and the CA to the dir
+func (o *CtlOptions) writeCerts(dir string) (*certFiles, error) {
+	secret, err := o.KubeCli.CoreV1().Secrets(o.Namespace).Get(clientTLSSecret, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the client certificate of tidb cluster %s: %v", o.TidbClusterName, err)
+	}
+	// the certificates of the tidb components are signed by the CA of the kubernetes cluster
+	ca := o.RestConfig.CAData
+	if len(ca) == 0 && len(o.RestConfig.CAFile) > 0 {
+		ca, err = ioutil.ReadFile(o.RestConfig.CAFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(ca) == 0 {
+		return nil, fmt.Errorf("no CA of the kubernetes cluster is found in the kubeconfig")
+	}
+
+	certs := &certFiles{
+		ca:   filepath.Join(dir, "ca.crt"),
+		cert: filepath.Join(dir, "client.crt"),
+		key:  filepath.Join(dir, "client.key"),
+	}
+	files := map[string][]byte{
+		certs.ca:   ca,
+		certs.cert: secret.Data["client.crt"],
+		certs.key:  secret.Data["client.key"],
+	}
+	for path, data := range files {
+		if err := ioutil.WriteFile(path, data, 0600); err != nil {
+			return nil, err
+		}
+	}
+	return certs, nil
+}
+
+// forwardPort forwards the local port to the port of the pod until stopCh is closed
+func (o *CtlOptions) forwardPort(pod *v1.Pod, localPort, podPort int, stopCh chan struct{}) error {
+	transport, upgrader, err := spdy.RoundTripperFor(o.RestConfig)
+	if err != nil {
+		return err
+	}
+	req := o.KubeCli.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.Namespace).
+		Name(pod.Name).
+		SubResource("portforward")
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", req.URL())
+
+	readyCh := make(chan struct{})
+	fw, err := portforward.New(dialer, []string{fmt.Sprintf("%d:%d", localPort, podPort)}, stopCh, readyCh, ioutil.Discard, o.ErrOut)
+	if err != nil {
+		return err
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- fw.ForwardPorts()
+	}()
+	select {
+	case <-readyCh:
+		return nil
+	case err := <-errCh:
+		return fmt.Errorf("failed to forward port %d of pod %s: %v", podPort, pod.Name, err)
+	}
+}
+
+// freePort returns a local port which is not in use
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
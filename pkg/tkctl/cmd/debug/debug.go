@@ -16,6 +16,8 @@ package debug
 import (
 	"fmt"
 
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/label"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/config"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/executor"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/util"
@@ -31,9 +33,14 @@ import (
 
 const (
 	debugExample = `
-	# debug a container in the running pod, the first container will be picked by default
+	# debug a container in the running pod, the container of the tidb component (e.g. tikv) will be picked
+	# by default, or the first container if the pod is not a member of a tidb cluster
 	tkctl debug POD_NAME
 
+	# run pd-ctl or tikv-ctl in the debug container, the pd address of the cluster is set in PD_ADDR
+	tkctl debug demo-tikv-0 -- bash -c 'pd-ctl -u $PD_ADDR -d store'
+	tkctl debug demo-tikv-0 -- tikv-ctl --host 127.0.0.1:20160 metrics
+
 	# specify namespace or container
 	tkctl debug --namespace foo POD_NAME -c CONTAINER_NAME
 
@@ -53,6 +60,7 @@ ends, the agent destroy and cleanup the debug container and exited with code 0,
 in the defer manner if it is not interrupted by user.
 `
 	debugUsage    = "expected 'debug POD_NAME' for the debug command"
+	pdAddrEnv     = "PD_ADDR"
 	defaultImage  = "pingcap/tidb-debug:latest"
 	launcherImage = "pingcap/debug-launcher:latest"
 	launcherName  = "debug-launcher"
@@ -76,6 +84,7 @@ type DebugOptions struct {
 	HostDockerSocket string
 	LauncherImage    string
 	Privileged       bool
+	Env              []string
 
 	KubeCli *kubernetes.Clientset
 
@@ -167,11 +176,11 @@ func (o *DebugOptions) Run() error {
 	}
 	containerName := o.ContainerName
 	if len(containerName) == 0 {
+		containerName = defaultContainerName(pod)
 		if len(pod.Spec.Containers) > 1 {
-			usageString := fmt.Sprintf("Defaulting container name to %s.", pod.Spec.Containers[0].Name)
+			usageString := fmt.Sprintf("Defaulting container name to %s.", containerName)
 			fmt.Fprintf(o.ErrOut, "%s\n\r", usageString)
 		}
-		containerName = pod.Spec.Containers[0].Name
 	}
 
 	nodeName := pod.Spec.NodeName
@@ -180,6 +189,12 @@ func (o *DebugOptions) Run() error {
 		return err
 	}
 
+	// the debug container shares the network namespace of the target container, so the pd service
+	// of the cluster is resolvable in it
+	if clusterName, ok := pod.Labels[label.InstanceLabelKey]; ok {
+		o.Env = append(o.Env, fmt.Sprintf("%s=http://%s:2379", pdAddrEnv, controller.PDMemberName(clusterName)))
+	}
+
 	launcher := o.makeLauncherPod(nodeName, targetContainerID, o.Command)
 	podExecutor := executor.NewPodExecutor(o.KubeCli, launcher, o.RestConfig, o.IOStreams)
 	return podExecutor.Execute()
@@ -197,6 +212,9 @@ func (o *DebugOptions) makeLauncherPod(nodeName, containerID string, command []s
 		"--docker-socket",
 		fmt.Sprintf("unix://%s", util.DockerSocket),
 	}
+	for _, env := range o.Env {
+		launchArgs = append(launchArgs, "--env", env)
+	}
 	if o.Privileged {
 		launchArgs = append(launchArgs, "--privileged")
 	}
@@ -250,3 +268,16 @@ func (o *DebugOptions) getContainerIDByName(pod *v1.Pod, containerName string) (
 
 	return "", fmt.Errorf("cannot find specified container %s", containerName)
 }
+
+// defaultContainerName returns the container of the tidb component in the pod, e.g. the tidb container rather than
+// the slow log tailer, it falls back to the first container if there is no such container
+func defaultContainerName(pod *v1.Pod) string {
+	if component, ok := pod.Labels[label.ComponentLabelKey]; ok {
+		for _, container := range pod.Spec.Containers {
+			if container.Name == component {
+				return container.Name
+			}
+		}
+	}
+	return pod.Spec.Containers[0].Name
+}
@@ -21,7 +21,9 @@ import (
 
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
+	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/config"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/readable"
 	"github.com/spf13/cobra"
@@ -41,8 +43,11 @@ const (
 	getLongDesc = `
 		Get tidb component detail.
 
-		Available components include: all, pd, tidb, tikv, volume
+		Available components include: all, pd, tidb, tikv, volume, tidbcluster
 		You can omit --tidbcluster=<name> option by running 'tkctl use <name>',
+
+		The tidbcluster kind prints all the members of the cluster in one table,
+		combining the cluster status, the pods, the volumes and the stores reported by PD.
 `
 	getExample = `
 		# get PD details 
@@ -62,6 +67,9 @@ const (
 
 		# get all components
 		tkctl get all
+
+		# get the members, their health, store states and volumes of a tidb cluster
+		tkctl get tidbcluster <cluster-name>
 `
 	getUsage = "expect 'get -t=CLUSTER_NAME kind | get -A kind' for get command or set tidb cluster by 'use' first"
 )
@@ -72,6 +80,11 @@ const (
 	kindTiDB   = "tidb"
 	kindVolume = "volume"
 	kindAll    = "all"
+
+	kindTidbCluster      = "tidbcluster"
+	kindTidbClusterShort = "tc"
+
+	pdClientPort = "2379"
 )
 
 // GetOptions contains the input to the list command.
@@ -85,6 +98,7 @@ type GetOptions struct {
 	GetTiKV         bool
 	GetTiDB         bool
 	GetVolume       bool
+	GetTidbCluster  bool
 
 	IsHumanReadablePrinter bool
 	PrintFlags             *readable.PrintFlags
@@ -100,7 +114,7 @@ func NewCmdGet(tkcContext *config.TkcContext, streams genericclioptions.IOStream
 	options := NewGetOptions(streams)
 	cmd := &cobra.Command{
 		Use:     "get",
-		Short:   "get pd|tikv|tidb|volume|all",
+		Short:   "get pd|tikv|tidb|volume|tidbcluster|all",
 		Long:    getLongDesc,
 		Example: getExample,
 		Run: func(cmd *cobra.Command, args []string) {
@@ -137,12 +151,15 @@ func (o *GetOptions) Complete(tkcContext *config.TkcContext, cmd *cobra.Command,
 		return err
 	}
 	o.Namespace = namespace
-	if tcName, ok := clientConfig.TidbClusterName(); ok {
-		o.TidbClusterName = tcName
-	} else if !o.AllClusters {
+	if len(args) < 1 {
 		return cmdutil.UsageErrorf(cmd, getUsage)
 	}
-	if len(args) < 1 {
+	// the name of 'get tidbcluster <name>' is the cluster name
+	if len(args) > 1 && (args[0] == kindTidbCluster || args[0] == kindTidbClusterShort) {
+		o.TidbClusterName = args[1]
+	} else if tcName, ok := clientConfig.TidbClusterName(); ok {
+		o.TidbClusterName = tcName
+	} else if !o.AllClusters {
 		return cmdutil.UsageErrorf(cmd, getUsage)
 	}
 
@@ -183,10 +200,12 @@ func (o *GetOptions) Complete(tkcContext *config.TkcContext, cmd *cobra.Command,
 			o.GetTiDB = true
 		case kindVolume:
 			o.GetVolume = true
+		case kindTidbCluster, kindTidbClusterShort:
+			o.GetTidbCluster = true
 		}
 	}
 
-	if len(args) > 1 {
+	if len(args) > 1 && !o.GetTidbCluster {
 		o.ResourceName = args[1]
 	}
 	return nil
@@ -221,6 +240,10 @@ func (o *GetOptions) Run(tkcContext *config.TkcContext, cmd *cobra.Command, args
 		}
 
 		// TODO: do a big batch or steadily print parts in minor step?
+		if err := o.PrintOutput(&tc, kindTidbCluster, o.GetTidbCluster); err != nil {
+			errs = append(errs, err)
+		}
+
 		if err := o.PrintOutput(&tc, kindPD, o.GetPD); err != nil {
 			errs = append(errs, err)
 		}
@@ -331,10 +354,60 @@ func (o *GetOptions) PrintOutput(tc *v1alpha1.TidbCluster, resourceType string,
 		}
 
 		return printer.PrintObj(volumeList, o.Out)
+	case kindTidbCluster:
+		if !o.IsHumanReadablePrinter {
+			tc.GetObjectKind().SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind("TidbCluster"))
+			return o.printGeneric([]runtime.Object{tc})
+		}
+
+		listOptions := metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s=%s", label.InstanceLabelKey, tc.Name),
+		}
+		podList, err := o.kubeCli.CoreV1().Pods(tc.Namespace).List(listOptions)
+		if err != nil {
+			return err
+		}
+		pvcList, err := o.kubeCli.CoreV1().PersistentVolumeClaims(tc.Namespace).List(listOptions)
+		if err != nil {
+			return err
+		}
+		members := alias.ClusterMembers{
+			TidbCluster: tc,
+			PodList:     podList,
+			PVCList:     pvcList,
+		}
+		// the stores are fetched from PD by the service proxy of the apiserver,
+		// the members are printed without them if PD is unreachable
+		stores, err := o.getStores(tc)
+		if err != nil {
+			fmt.Fprintf(o.ErrOut, "failed to get the stores of tidb cluster %s/%s from PD: %v\n", tc.Namespace, tc.Name, err)
+		} else {
+			members.Stores = stores
+		}
+
+		printer, err := o.PrintFlags.ToPrinter(false, false)
+		if err != nil {
+			return err
+		}
+		return printer.PrintObj(&members, o.Out)
 	}
 	return fmt.Errorf("Unknow resource type %s", resourceType)
 }
 
+func (o *GetOptions) getStores(tc *v1alpha1.TidbCluster) (*pdapi.StoresInfo, error) {
+	data, err := o.kubeCli.CoreV1().Services(tc.Namespace).
+		ProxyGet("http", controller.PDMemberName(tc.Name), pdClientPort, "/pd/api/v1/stores", nil).
+		DoRaw()
+	if err != nil {
+		return nil, err
+	}
+	stores := &pdapi.StoresInfo{}
+	if err := json.Unmarshal(data, stores); err != nil {
+		return nil, err
+	}
+	return stores, nil
+}
+
 func (o *GetOptions) printGeneric(objs []runtime.Object) error {
 	printer, err := o.PrintFlags.ToPrinter(false, false)
 	if err != nil {
@@ -20,7 +20,7 @@ import (
 	"path/filepath"
 	"sync"
 
-	"github.com/golang/glog"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	"gopkg.in/yaml.v2"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/genericclioptions/resource"
@@ -98,11 +98,11 @@ func (c *TkcContext) ToTkcClientConfig() (*TkcClientConfig, error) {
 	// try loading tidb cluster config
 	tcConfigFile, err := tcConfigLocation()
 	if err != nil {
-		glog.V(4).Info("Error getting tidb cluster config file location")
+		log.V(4).Info("Error getting tidb cluster config file location")
 	} else {
 		tcConfig, err := LoadFile(tcConfigFile)
 		if err != nil {
-			glog.V(4).Info("Error reading tidb cluster config file")
+			log.V(4).Info("Error reading tidb cluster config file")
 			c.TidbClusterConfig = &TidbClusterConfig{}
 		} else {
 			c.TidbClusterConfig = tcConfig
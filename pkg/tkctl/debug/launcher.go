@@ -50,6 +50,7 @@ type Launcher struct {
 	ctx               context.Context
 
 	privileged bool
+	env        []string
 
 	client *dockerclient.Client
 }
@@ -79,6 +80,8 @@ func NewLauncherCmd(streams IOStreams) *cobra.Command {
 		"debug container image")
 	cmd.Flags().StringVar(&launcher.dockerSocket, "docker-socket", launcher.dockerSocket,
 		"docker socket to bind")
+	cmd.Flags().StringArrayVar(&launcher.env, "env", launcher.env,
+		"environment variables (KEY=VALUE) of the debug container")
 	cmd.Flags().BoolVar(&launcher.privileged, "privileged", launcher.privileged,
 		"whether launch container in privileged mode (full container capabilities)")
 	return cmd
@@ -134,6 +137,7 @@ func (l *Launcher) createContainer(command []string) (*container.ContainerCreate
 	config := &container.Config{
 		Entrypoint: strslice.StrSlice(command),
 		Image:      l.image,
+		Env:        l.env,
 		Tty:        true,
 		OpenStdin:  true,
 		StdinOnce:  true,
@@ -16,9 +16,11 @@ package readable
 import (
 	"fmt"
 	"github.com/pingcap/tidb-operator/pkg/label"
+	"sort"
 	"time"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/alias"
 	"k8s.io/api/core/v1"
 	apiv1 "k8s.io/api/core/v1"
@@ -102,6 +104,23 @@ func AddHandlers(h printers.PrintHandler) {
 	}
 	h.TableHandler(volumeColumns, printVolume)
 	h.TableHandler(volumeColumns, printVolumeList)
+	clusterMemberColumns := []metav1beta1.TableColumnDefinition{
+		{Name: "Component", Type: "string", Description: "The component of the member"},
+		{Name: "Name", Type: "string", Format: "name", Description: metav1.ObjectMeta{}.SwaggerDoc()["name"]},
+		{Name: "Ready", Type: "string", Description: "The aggregate readiness state of this pod for accepting traffic."},
+		{Name: "Status", Type: "string", Description: "The aggregate status of the containers in this pod."},
+		{Name: "Health", Type: "string", Description: "The health of PD and TiDB members, the store state of TiKV members"},
+		{Name: "StoreID", Type: "string", Description: "TiKV StoreID"},
+		{Name: "Leaders", Type: "string", Description: "The leader count of the TiKV store reported by PD"},
+		{Name: "Regions", Type: "string", Description: "The region count of the TiKV store reported by PD"},
+		{Name: "Store Usage", Type: "string", Description: "The used and total size of the TiKV store reported by PD"},
+		{Name: "PVC", Type: "string", Description: "The persistent volume claim of the member"},
+		{Name: "PVC Capacity", Type: "string", Description: "The capacity of the persistent volume claim"},
+		{Name: "Age", Type: "string", Description: metav1.ObjectMeta{}.SwaggerDoc()["creationTimestamp"]},
+		{Name: "IP", Type: "string", Priority: 1, Description: apiv1.PodStatus{}.SwaggerDoc()["podIP"]},
+		{Name: "Node", Type: "string", Priority: 1, Description: apiv1.PodSpec{}.SwaggerDoc()["nodeName"]},
+	}
+	h.TableHandler(clusterMemberColumns, printClusterMembers)
 }
 
 func printTidbClusterList(tcs *v1alpha1.TidbClusterList, options printers.PrintOptions) ([]metav1beta1.TableRow, error) {
@@ -260,6 +279,144 @@ func printVolume(volume *v1.PersistentVolume, options printers.PrintOptions) ([]
 	return []metav1beta1.TableRow{row}, nil
 }
 
+// printClusterMembers prints the PD, TiKV and TiDB members of a tidb cluster in one table
+func printClusterMembers(members *alias.ClusterMembers, options printers.PrintOptions) ([]metav1beta1.TableRow, error) {
+	tc := members.TidbCluster
+
+	pvcs := map[string]*v1.PersistentVolumeClaim{}
+	if members.PVCList != nil {
+		for i := range members.PVCList.Items {
+			pvc := &members.PVCList.Items[i]
+			pvcs[pvc.Name] = pvc
+		}
+	}
+	stores := map[string]*pdapi.StoreInfo{}
+	if members.Stores != nil {
+		for _, store := range members.Stores.Stores {
+			if store.Store != nil && store.Store.Store != nil {
+				stores[fmt.Sprintf("%d", store.Store.Id)] = store
+			}
+		}
+	}
+
+	pods := make([]*v1.Pod, 0, len(members.PodList.Items))
+	for i := range members.PodList.Items {
+		pods = append(pods, &members.PodList.Items[i])
+	}
+	componentOrder := map[string]int{label.PDLabelVal: 0, label.TiKVLabelVal: 1, label.TiDBLabelVal: 2}
+	sort.SliceStable(pods, func(i, j int) bool {
+		ci, cj := pods[i].Labels[label.ComponentLabelKey], pods[j].Labels[label.ComponentLabelKey]
+		if ci != cj {
+			return componentOrder[ci] < componentOrder[cj]
+		}
+		return pods[i].Name < pods[j].Name
+	})
+
+	rows := make([]metav1beta1.TableRow, 0, len(pods))
+	for _, pod := range pods {
+		component := pod.Labels[label.ComponentLabelKey]
+		if _, ok := componentOrder[component]; !ok {
+			continue
+		}
+		columns := basicPodColumns(pod)
+
+		health := unset
+		storeID, leaders, regions, usage := unset, unset, unset, unset
+		switch component {
+		case label.PDLabelVal:
+			if member, ok := tc.Status.PD.Members[pod.Name]; ok {
+				health = readableHealth(member.Health)
+			}
+		case label.TiDBLabelVal:
+			if member, ok := tc.Status.TiDB.Members[pod.Name]; ok {
+				health = readableHealth(member.Health)
+			}
+		case label.TiKVLabelVal:
+			if id := pod.Labels[label.StoreIDLabelKey]; len(id) > 0 {
+				storeID = id
+				if store, ok := tc.Status.TiKV.Stores[id]; ok {
+					health = store.State
+				}
+				if store, ok := stores[id]; ok && store.Status != nil {
+					health = store.Store.StateName
+					leaders = fmt.Sprintf("%d", store.Status.LeaderCount)
+					regions = fmt.Sprintf("%d", store.Status.RegionCount)
+					usage = readableUsage(uint64(store.Status.Capacity), uint64(store.Status.Available))
+				}
+			}
+		}
+
+		pvcName, pvcCapacity := unset, unset
+		for _, vol := range pod.Spec.Volumes {
+			if vol.PersistentVolumeClaim == nil {
+				continue
+			}
+			pvcName = vol.PersistentVolumeClaim.ClaimName
+			if pvc, ok := pvcs[pvcName]; ok {
+				if capacity, ok := pvc.Status.Capacity[v1.ResourceStorage]; ok {
+					pvcCapacity = capacity.String()
+				}
+			}
+			break
+		}
+
+		row := metav1beta1.TableRow{
+			Object: runtime.RawExtension{Object: pod},
+		}
+		row.Cells = append(row.Cells,
+			component,
+			columns.Name,
+			columns.Ready,
+			columns.Reason,
+			health,
+			storeID,
+			leaders,
+			regions,
+			usage,
+			pvcName,
+			pvcCapacity,
+			columns.Age)
+		if options.Wide {
+			row.Cells = append(row.Cells, columns.PodIP, columns.NodeName)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func readableHealth(healthy bool) string {
+	if healthy {
+		return "Healthy"
+	}
+	return "Unhealthy"
+}
+
+// readableUsage returns the used and total size of a store, e.g. 12Gi/100Gi
+func readableUsage(capacity, available uint64) string {
+	if capacity == 0 {
+		return unset
+	}
+	used := capacity - available
+	if available > capacity {
+		used = 0
+	}
+	return fmt.Sprintf("%s/%s", readableBytes(used), readableBytes(capacity))
+}
+
+// readableBytes returns the size in binary units with one decimal, e.g. 1.5Gi
+func readableBytes(size uint64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d", size)
+	}
+	div, exp := uint64(unit), 0
+	for n := size / unit; n >= unit && exp < 4; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%si", float64(size)/float64(div), "KMGTP"[exp:exp+1])
+}
+
 // basicPodColumns calculates common columns for PD/TiKV/TiDB pods
 func basicPodColumns(pod *v1.Pod) *PodBasicColumns {
 	restarts := 0
@@ -0,0 +1,125 @@
+// Copyright 2019. PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package readable
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	"github.com/pingcap/tidb-operator/pkg/tkctl/alias"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/printers"
+)
+
+func TestReadableBytes(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(readableBytes(512)).To(Equal("512"))
+	g.Expect(readableBytes(1536)).To(Equal("1.5Ki"))
+	g.Expect(readableBytes(3 << 30)).To(Equal("3.0Gi"))
+	g.Expect(readableUsage(0, 0)).To(Equal(unset))
+	g.Expect(readableUsage(100<<30, 75<<30)).To(Equal("25.0Gi/100.0Gi"))
+}
+
+func TestPrintClusterMembers(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	newPod := func(name, component string, labels map[string]string) v1.Pod {
+		pod := v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{label.ComponentLabelKey: component},
+			},
+			Spec: v1.PodSpec{
+				Volumes: []v1.Volume{
+					{
+						Name: component,
+						VolumeSource: v1.VolumeSource{
+							PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: component + "-" + name},
+						},
+					},
+				},
+			},
+		}
+		for k, v := range labels {
+			pod.Labels[k] = v
+		}
+		return pod
+	}
+
+	members := &alias.ClusterMembers{
+		TidbCluster: &v1alpha1.TidbCluster{
+			Status: v1alpha1.TidbClusterStatus{
+				PD: v1alpha1.PDStatus{
+					Members: map[string]v1alpha1.PDMember{"demo-pd-0": {Health: true}},
+				},
+				TiKV: v1alpha1.TiKVStatus{
+					Stores: map[string]v1alpha1.TiKVStore{"1": {ID: "1", State: v1alpha1.TiKVStateDown}},
+				},
+			},
+		},
+		PodList: &v1.PodList{
+			Items: []v1.Pod{
+				newPod("demo-tikv-0", label.TiKVLabelVal, map[string]string{label.StoreIDLabelKey: "1"}),
+				newPod("demo-discovery", "discovery", nil),
+				newPod("demo-pd-0", label.PDLabelVal, nil),
+			},
+		},
+		PVCList: &v1.PersistentVolumeClaimList{
+			Items: []v1.PersistentVolumeClaim{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "tikv-demo-tikv-0"},
+					Status: v1.PersistentVolumeClaimStatus{
+						Capacity: v1.ResourceList{v1.ResourceStorage: resource.MustParse("100Gi")},
+					},
+				},
+			},
+		},
+	}
+
+	rows, err := printClusterMembers(members, printers.PrintOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(rows).To(HaveLen(2))
+	g.Expect(rows[0].Cells[0:2]).To(Equal([]interface{}{label.PDLabelVal, "demo-pd-0"}))
+	g.Expect(rows[0].Cells[4]).To(Equal("Healthy"))
+	g.Expect(rows[0].Cells[9:11]).To(Equal([]interface{}{"pd-demo-pd-0", unset}))
+	g.Expect(rows[1].Cells[0:2]).To(Equal([]interface{}{label.TiKVLabelVal, "demo-tikv-0"}))
+	g.Expect(rows[1].Cells[4:9]).To(Equal([]interface{}{v1alpha1.TiKVStateDown, "1", unset, unset, unset}))
+	g.Expect(rows[1].Cells[9:11]).To(Equal([]interface{}{"tikv-demo-tikv-0", "100Gi"}))
+
+	// the store state reported by PD overrides the one in the status
+	members.Stores = &pdapi.StoresInfo{
+		Stores: []*pdapi.StoreInfo{
+			{
+				Store: &pdapi.MetaStore{Store: &metapb.Store{Id: 1}, StateName: v1alpha1.TiKVStateUp},
+				Status: &pdapi.StoreStatus{
+					Capacity:    100 << 30,
+					Available:   75 << 30,
+					LeaderCount: 10,
+					RegionCount: 30,
+				},
+			},
+		},
+	}
+	rows, err = printClusterMembers(members, printers.PrintOptions{Wide: true})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(rows[1].Cells[4:9]).To(Equal([]interface{}{v1alpha1.TiKVStateUp, "1", "10", "30", "25.0Gi/100.0Gi"}))
+	g.Expect(rows[1].Cells).To(HaveLen(14))
+}
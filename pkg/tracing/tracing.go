@@ -0,0 +1,173 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing traces the phases of the reconcile loops, and the calls to PD
+// and the writes to the API server made in them.
+//
+// The spans are created by the opentracing API and reported by the jaeger
+// client, they are not instrumented by OpenTelemetry nor exported by OTLP. The
+// OpenTelemetry Go SDK and its OTLP exporters require a newer Go, grpc and
+// protobuf than the ones pinned by the client-go and PD dependencies of the
+// operator, so OpenTelemetry can only be adopted after these are upgraded. Until
+// then the spans can be forwarded to an OTLP backend by the OpenTelemetry
+// Collector with its jaeger receiver.
+package tracing
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	jaegercfg "github.com/uber/jaeger-client-go/config"
+)
+
+// Init sets the global tracer reporting the spans to the jaeger agent or
+// collector configured by the JAEGER_* environment variables, e.g.
+// JAEGER_AGENT_HOST, JAEGER_ENDPOINT and JAEGER_SAMPLER_TYPE. The tracer is a
+// no-op one if Init is not called, so the spans cost nothing.
+func Init(serviceName string) (io.Closer, error) {
+	cfg, err := jaegercfg.FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	cfg.ServiceName = serviceName
+	tracer, closer, err := cfg.NewTracer()
+	if err != nil {
+		return nil, err
+	}
+	opentracing.SetGlobalTracer(tracer)
+	return closer, nil
+}
+
+// activeSpans keeps the span of the running phase of the sync of each object,
+// keyed by its namespace and name, so that the calls made in the phase are traced
+// as its children without passing the span through the managers. An object is
+// synced by one worker at a time, so there is one running phase of it at most.
+var activeSpans = struct {
+	sync.Mutex
+	spans map[string]opentracing.Span
+}{spans: map[string]opentracing.Span{}}
+
+func spanKey(ns, name string) string {
+	return fmt.Sprintf("%s/%s", ns, name)
+}
+
+func setActiveSpan(key string, span opentracing.Span) {
+	activeSpans.Lock()
+	defer activeSpans.Unlock()
+	if span == nil {
+		delete(activeSpans.spans, key)
+		return
+	}
+	activeSpans.spans[key] = span
+}
+
+func getActiveSpan(key string) opentracing.Span {
+	activeSpans.Lock()
+	defer activeSpans.Unlock()
+	return activeSpans.spans[key]
+}
+
+// syncSpan is the root span of a sync, it is the active span of the object
+// until it is finished
+type syncSpan struct {
+	opentracing.Span
+	key string
+}
+
+func (s *syncSpan) Finish() {
+	setActiveSpan(s.key, nil)
+	s.Span.Finish()
+}
+
+// StartSync starts the root span of a sync of the object
+func StartSync(operation, ns, name string) opentracing.Span {
+	span := opentracing.StartSpan(operation)
+	span.SetTag("namespace", ns)
+	span.SetTag("name", name)
+	key := spanKey(ns, name)
+	setActiveSpan(key, span)
+	return &syncSpan{Span: span, key: key}
+}
+
+// Phase runs the phase of a sync in a child span of the parent, the error
+// returned by the phase is recorded on the span
+func Phase(parent opentracing.Span, name string, fn func() error) error {
+	span := opentracing.StartSpan(name, opentracing.ChildOf(parent.Context()))
+	defer span.Finish()
+	if s, ok := parent.(*syncSpan); ok {
+		// the calls made in the phase are traced as its children
+		setActiveSpan(s.key, span)
+		defer setActiveSpan(s.key, s.Span)
+	}
+	err := fn()
+	finishWithError(span, err)
+	return err
+}
+
+// StartCall starts the span of a call to the API server or PD made for the object
+// in the running phase of its sync, the span is a no-op one if the object is not
+// being synced. The span is finished by FinishCall.
+func StartCall(ns, name, operation string) opentracing.Span {
+	parent := getActiveSpan(spanKey(ns, name))
+	if parent == nil {
+		return opentracing.NoopTracer{}.StartSpan(operation)
+	}
+	return opentracing.StartSpan(operation, opentracing.ChildOf(parent.Context()))
+}
+
+// FinishCall finishes the span of a call, the error of the call is recorded on the span
+func FinishCall(span opentracing.Span, err error) {
+	finishWithError(span, err)
+	span.Finish()
+}
+
+func finishWithError(span opentracing.Span, err error) {
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogKV("error", err.Error())
+	}
+}
+
+// roundTripper traces the HTTP requests made for an object by StartCall
+type roundTripper struct {
+	ns, name string
+	rt       http.RoundTripper
+}
+
+// NewRoundTripper returns a http.RoundTripper which traces the requests made by rt
+// for the object ns/name, e.g. the calls to PD of a tidb cluster
+func NewRoundTripper(ns, name string, rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &roundTripper{ns: ns, name: name, rt: rt}
+}
+
+func (t *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	span := StartCall(t.ns, t.name, fmt.Sprintf("%s %s", req.Method, req.URL.Path))
+	ext.HTTPMethod.Set(span, req.Method)
+	ext.HTTPUrl.Set(span, req.URL.String())
+	res, err := t.rt.RoundTrip(req)
+	if err == nil {
+		ext.HTTPStatusCode.Set(span, uint16(res.StatusCode))
+		if res.StatusCode >= http.StatusBadRequest {
+			ext.Error.Set(span, true)
+		}
+	}
+	FinishCall(span, err)
+	return res, err
+}
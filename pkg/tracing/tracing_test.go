@@ -0,0 +1,90 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+func TestSyncSpanTree(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tracer := mocktracer.New()
+	opentracing.SetGlobalTracer(tracer)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: NewRoundTripper("ns", "demo", nil)}
+
+	span := StartSync("SyncTidbCluster", "ns", "demo")
+	err := Phase(span, "PD", func() error {
+		res, err := client.Get(server.URL + "/pd/api/v1/health")
+		g.Expect(err).NotTo(HaveOccurred())
+		res.Body.Close()
+		call := StartCall("ns", "demo", "UpdateStatefulSet")
+		FinishCall(call, errors.New("conflict"))
+		return nil
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	err = Phase(span, "TiKV", func() error {
+		FinishCall(StartCall("ns", "demo", "CreateService"), nil)
+		return errors.New("failed")
+	})
+	g.Expect(err).To(HaveOccurred())
+	span.Finish()
+
+	// the calls made for other objects or outside of a sync are not traced
+	FinishCall(StartCall("ns", "other", "CreateService"), nil)
+	FinishCall(StartCall("ns", "demo", "CreateService"), nil)
+
+	spans := map[string]*mocktracer.MockSpan{}
+	for _, s := range tracer.FinishedSpans() {
+		spans[s.OperationName] = s
+	}
+	g.Expect(spans).To(HaveLen(6))
+	root := spans["SyncTidbCluster"]
+	g.Expect(root).NotTo(BeNil())
+	g.Expect(root.ParentID).To(BeZero())
+
+	expectChild := func(child, parent string) *mocktracer.MockSpan {
+		g.Expect(spans).To(HaveKey(child))
+		g.Expect(spans[child].ParentID).To(Equal(spans[parent].SpanContext.SpanID))
+		return spans[child]
+	}
+	expectChild("PD", "SyncTidbCluster")
+	tikv := expectChild("TiKV", "SyncTidbCluster")
+	g.Expect(tikv.Tag("error")).To(Equal(true))
+
+	pdCall := expectChild("GET /pd/api/v1/health", "PD")
+	g.Expect(pdCall.Tag("http.method")).To(Equal("GET"))
+	g.Expect(pdCall.Tag("http.status_code")).To(Equal(uint16(http.StatusServiceUnavailable)))
+	g.Expect(pdCall.Tag("error")).To(Equal(true))
+
+	update := expectChild("UpdateStatefulSet", "PD")
+	g.Expect(update.Tag("error")).To(Equal(true))
+	create := expectChild("CreateService", "TiKV")
+	g.Expect(create.Tag("error")).To(BeNil())
+
+	g.Expect(getActiveSpan(spanKey("ns", "demo"))).To(BeNil())
+}
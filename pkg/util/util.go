@@ -18,8 +18,8 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 )
@@ -43,14 +43,14 @@ func ResourceRequirement(spec v1alpha1.ContainerSpec, defaultRequests ...corev1.
 		}
 		if spec.Requests.CPU != "" {
 			if q, err := resource.ParseQuantity(spec.Requests.CPU); err != nil {
-				glog.Errorf("failed to parse CPU resource %s to quantity: %v", spec.Requests.CPU, err)
+				log.Errorf("failed to parse CPU resource %s to quantity: %v", spec.Requests.CPU, err)
 			} else {
 				rr.Requests[corev1.ResourceCPU] = q
 			}
 		}
 		if spec.Requests.Memory != "" {
 			if q, err := resource.ParseQuantity(spec.Requests.Memory); err != nil {
-				glog.Errorf("failed to parse memory resource %s to quantity: %v", spec.Requests.Memory, err)
+				log.Errorf("failed to parse memory resource %s to quantity: %v", spec.Requests.Memory, err)
 			} else {
 				rr.Requests[corev1.ResourceMemory] = q
 			}
@@ -62,14 +62,14 @@ func ResourceRequirement(spec v1alpha1.ContainerSpec, defaultRequests ...corev1.
 		}
 		if spec.Limits.CPU != "" {
 			if q, err := resource.ParseQuantity(spec.Limits.CPU); err != nil {
-				glog.Errorf("failed to parse CPU resource %s to quantity: %v", spec.Limits.CPU, err)
+				log.Errorf("failed to parse CPU resource %s to quantity: %v", spec.Limits.CPU, err)
 			} else {
 				rr.Limits[corev1.ResourceCPU] = q
 			}
 		}
 		if spec.Limits.Memory != "" {
 			if q, err := resource.ParseQuantity(spec.Limits.Memory); err != nil {
-				glog.Errorf("failed to parse memory resource %s to quantity: %v", spec.Limits.Memory, err)
+				log.Errorf("failed to parse memory resource %s to quantity: %v", spec.Limits.Memory, err)
 			} else {
 				rr.Limits[corev1.ResourceMemory] = q
 			}
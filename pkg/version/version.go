@@ -17,7 +17,7 @@ import (
 	"fmt"
 	"runtime"
 
-	"github.com/golang/glog"
+	"github.com/pingcap/tidb-operator/pkg/log"
 )
 
 var (
@@ -35,8 +35,8 @@ func PrintVersionInfo() {
 
 // LogVersionInfo print version info at startup
 func LogVersionInfo() {
-	glog.Infof("Welcome to TiDB Operator.")
-	glog.Infof("TiDB Operator Version: %#v", Get())
+	log.Infof("Welcome to TiDB Operator.")
+	log.Infof("TiDB Operator Version: %#v", Get())
 }
 
 // Get returns the overall codebase version. It's for detecting
@@ -19,7 +19,7 @@ import (
 	"io/ioutil"
 	"net/http"
 
-	"github.com/golang/glog"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	"github.com/pingcap/tidb-operator/pkg/webhook/statefulset"
 	"github.com/pingcap/tidb-operator/pkg/webhook/util"
 	"k8s.io/api/admission/v1beta1"
@@ -33,10 +33,10 @@ func marshalAndWrite(response v1beta1.AdmissionReview, w http.ResponseWriter) {
 
 	respBytes, err := json.Marshal(response)
 	if err != nil {
-		glog.Errorf("%v", err)
+		log.Errorf("%v", err)
 	}
 	if _, err := w.Write(respBytes); err != nil {
-		glog.Errorf("%v", err)
+		log.Errorf("%v", err)
 	}
 
 }
@@ -18,13 +18,13 @@ import (
 	"fmt"
 	"strconv"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	"github.com/pingcap/tidb-operator/pkg/webhook/util"
 	"k8s.io/api/admission/v1beta1"
-	apps "k8s.io/api/apps/v1beta1"
+	apps "k8s.io/api/apps/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/rest"
@@ -39,29 +39,46 @@ func init() {
 	deserializer = util.GetCodec()
 }
 
+// statefulSetVersions are the versions of the apps group the StatefulSets are
+// admitted in, apps/v1beta1 and apps/v1beta2 are still served by the clusters
+// older than Kubernetes 1.16. The fields checked here are the same in all the
+// versions, so the object is decoded as apps/v1 whatever the request version is.
+var statefulSetVersions = []string{"v1", "v1beta2", "v1beta1"}
+
+func isStatefulSetResource(resource metav1.GroupVersionResource) bool {
+	if resource.Group != apps.GroupName || resource.Resource != "statefulsets" {
+		return false
+	}
+	for _, version := range statefulSetVersions {
+		if resource.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
 func AdmitStatefulSets(ar v1beta1.AdmissionReview) *v1beta1.AdmissionResponse {
 
 	name := ar.Request.Name
 	namespace := ar.Request.Namespace
-	glog.V(4).Infof("admit statefulsets [%s/%s]", namespace, name)
+	log.V(4).Infof("admit statefulsets [%s/%s]", namespace, name)
 
-	setResource := metav1.GroupVersionResource{Group: "apps", Version: "v1beta1", Resource: "statefulsets"}
-	if ar.Request.Resource != setResource {
-		err := fmt.Errorf("expect resource to be %s instead of %s", setResource, ar.Request.Resource)
-		glog.Errorf("%v", err)
+	if !isStatefulSetResource(ar.Request.Resource) {
+		err := fmt.Errorf("expect resource to be statefulsets of %v instead of %s", statefulSetVersions, ar.Request.Resource)
+		log.Errorf("%v", err)
 		return util.ARFail(err)
 	}
 
 	if versionCli == nil {
 		cfg, err := rest.InClusterConfig()
 		if err != nil {
-			glog.Errorf("statefulset %s/%s, get k8s cluster config failed, err: %v", namespace, name, err)
+			log.Errorf("statefulset %s/%s, get k8s cluster config failed, err: %v", namespace, name, err)
 			return util.ARFail(err)
 		}
 
 		versionCli, err = versioned.NewForConfig(cfg)
 		if err != nil {
-			glog.Errorf("statefulset %s/%s, create Clientset failed, err: %v", namespace, name, err)
+			log.Errorf("statefulset %s/%s, create Clientset failed, err: %v", namespace, name, err)
 			return util.ARFail(err)
 		}
 	}
@@ -69,7 +86,7 @@ func AdmitStatefulSets(ar v1beta1.AdmissionReview) *v1beta1.AdmissionResponse {
 	raw := ar.Request.OldObject.Raw
 	set := apps.StatefulSet{}
 	if _, _, err := deserializer.Decode(raw, nil, &set); err != nil {
-		glog.Errorf("statefulset %s/%s, decode request failed, err: %v", namespace, name, err)
+		log.Errorf("statefulset %s/%s, decode request failed, err: %v", namespace, name, err)
 		return util.ARFail(err)
 	}
 
@@ -84,14 +101,14 @@ func AdmitStatefulSets(ar v1beta1.AdmissionReview) *v1beta1.AdmissionResponse {
 	if controllerRef == nil || controllerRef.Kind != controller.ControllerKind.Kind {
 		// In this case, we can't tell if this statefulset is controlled by tidb-operator,
 		// so we don't block this statefulset upgrade, return directly.
-		glog.Warningf("statefulset %s/%s has tidb or tikv component label but doesn't have owner reference or the owner reference is not TidbCluster", namespace, name)
+		log.Warningf("statefulset %s/%s has tidb or tikv component label but doesn't have owner reference or the owner reference is not TidbCluster", namespace, name)
 		return util.ARSuccess()
 	}
 
 	tcName := controllerRef.Name
 	tc, err := versionCli.PingcapV1alpha1().TidbClusters(namespace).Get(tcName, metav1.GetOptions{})
 	if err != nil {
-		glog.Errorf("get tidbcluster %s/%s failed, statefulset %s, err %v", namespace, tcName, name, err)
+		log.Errorf("get tidbcluster %s/%s failed, statefulset %s, err %v", namespace, tcName, name, err)
 		return util.ARFail(err)
 	}
 
@@ -107,15 +124,15 @@ func AdmitStatefulSets(ar v1beta1.AdmissionReview) *v1beta1.AdmissionResponse {
 
 	partition, err := strconv.ParseInt(partitionStr, 10, 32)
 	if err != nil {
-		glog.Errorf("statefulset %s/%s, convert partition str %s to int failed, err: %v", namespace, name, partitionStr, err)
+		log.Errorf("statefulset %s/%s, convert partition str %s to int failed, err: %v", namespace, name, partitionStr, err)
 		return util.ARFail(err)
 	}
 
 	setPartition := *set.Spec.UpdateStrategy.RollingUpdate.Partition
 	if setPartition > 0 && setPartition <= int32(partition) {
-		glog.V(4).Infof("statefulset %s/%s has been protect by partition %s annotations", namespace, name, partitionStr)
+		log.V(4).Infof("statefulset %s/%s has been protect by partition %s annotations", namespace, name, partitionStr)
 		return util.ARFail(errors.New("protect by partition annotation"))
 	}
-	glog.Infof("admit statefulset %s/%s update partition to %d, protect partition is %d", namespace, name, setPartition, partition)
+	log.Infof("admit statefulset %s/%s update partition to %d, protect partition is %d", namespace, name, setPartition, partition)
 	return util.ARSuccess()
 }
@@ -0,0 +1,97 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statefulset
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsStatefulSetResource(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name     string
+		resource metav1.GroupVersionResource
+		expect   bool
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+		g.Expect(isStatefulSetResource(test.resource)).To(Equal(test.expect))
+	}
+
+	tests := []testcase{
+		{
+			name:     "apps/v1",
+			resource: metav1.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"},
+			expect:   true,
+		},
+		{
+			name:     "apps/v1beta2",
+			resource: metav1.GroupVersionResource{Group: "apps", Version: "v1beta2", Resource: "statefulsets"},
+			expect:   true,
+		},
+		{
+			name:     "apps/v1beta1",
+			resource: metav1.GroupVersionResource{Group: "apps", Version: "v1beta1", Resource: "statefulsets"},
+			expect:   true,
+		},
+		{
+			name:     "deployments",
+			resource: metav1.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			expect:   false,
+		},
+		{
+			name:     "unknown version",
+			resource: metav1.GroupVersionResource{Group: "apps", Version: "v2", Resource: "statefulsets"},
+			expect:   false,
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func TestDecodeStatefulSetOfOldVersion(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	partition := int32(2)
+	raw, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "apps/v1beta1",
+		"kind":       "StatefulSet",
+		"metadata": map[string]interface{}{
+			"name":   "demo-tikv",
+			"labels": map[string]string{"app.kubernetes.io/component": "tikv"},
+		},
+		"spec": map[string]interface{}{
+			"updateStrategy": map[string]interface{}{
+				"type":          "RollingUpdate",
+				"rollingUpdate": map[string]interface{}{"partition": partition},
+			},
+		},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	set := apps.StatefulSet{}
+	_, _, err = deserializer.Decode(raw, nil, &set)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(set.Name).To(Equal("demo-tikv"))
+	g.Expect(set.Labels).To(HaveKeyWithValue("app.kubernetes.io/component", "tikv"))
+	g.Expect(*set.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(partition))
+}
@@ -16,8 +16,8 @@ package webhook
 import (
 	"net/http"
 
-	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
+	"github.com/pingcap/tidb-operator/pkg/log"
 	"github.com/pingcap/tidb-operator/pkg/webhook/route"
 	"github.com/pingcap/tidb-operator/pkg/webhook/util"
 	"k8s.io/client-go/kubernetes"
@@ -39,7 +39,7 @@ func NewWebHookServer(kubecli kubernetes.Interface, cli versioned.Interface, cer
 	sCert, err := util.ConfigTLS(certFile, keyFile)
 
 	if err != nil {
-		glog.Fatalf("failed to create scert file %v", err)
+		log.Fatalf("failed to create scert file %v", err)
 	}
 
 	server := &http.Server{
@@ -41,14 +41,15 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/label"
 	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	"github.com/pingcap/tidb-operator/tests/notify"
 	"github.com/pingcap/tidb-operator/tests/pkg/apimachinery"
 	"github.com/pingcap/tidb-operator/tests/pkg/blockwriter"
 	"github.com/pingcap/tidb-operator/tests/pkg/metrics"
 	"github.com/pingcap/tidb-operator/tests/pkg/util"
 	"github.com/pingcap/tidb-operator/tests/pkg/webhook"
-	"github.com/pingcap/tidb-operator/tests/slack"
+	"github.com/pingcap/tidb-operator/tests/pkg/workload"
 	admissionV1beta1 "k8s.io/api/admissionregistration/v1beta1"
-	"k8s.io/api/apps/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -117,7 +118,10 @@ type OperatorActions interface {
 	CleanOperatorOrDie(info *OperatorConfig)
 	UpgradeOperator(info *OperatorConfig) error
 	UpgradeOperatorOrDie(info *OperatorConfig)
+	CheckOperatorUpgrade(from, to *OperatorConfig, clusters []*TidbClusterConfig) error
+	CheckOperatorUpgradeOrDie(from, to *OperatorConfig, clusters []*TidbClusterConfig)
 	DumpAllLogs(info *OperatorConfig, clusterInfos []*TidbClusterConfig) error
+	UploadLogBundle(info *OperatorConfig, clusterInfos []*TidbClusterConfig) (string, error)
 	DeployTidbCluster(info *TidbClusterConfig) error
 	DeployTidbClusterOrDie(info *TidbClusterConfig)
 	CleanTidbCluster(info *TidbClusterConfig) error
@@ -127,6 +131,8 @@ type OperatorActions interface {
 	BeginInsertDataTo(info *TidbClusterConfig) error
 	BeginInsertDataToOrDie(info *TidbClusterConfig)
 	StopInsertDataTo(info *TidbClusterConfig)
+	CheckTablesConsistency(info *TidbClusterConfig) error
+	CheckTablesConsistencyOrDie(info *TidbClusterConfig)
 	ScaleTidbCluster(info *TidbClusterConfig) error
 	ScaleTidbClusterOrDie(info *TidbClusterConfig)
 	CheckScaleInSafely(info *TidbClusterConfig) error
@@ -149,6 +155,9 @@ type OperatorActions interface {
 	RestoreIncrementalFiles(from *DrainerConfig, to *TidbClusterConfig, stopTSO int64) error
 	ForceDeploy(info *TidbClusterConfig) error
 	CreateSecret(info *TidbClusterConfig) error
+	CreateTLSCerts(info *TidbClusterConfig) error
+	SetupTLSClient(info *OperatorConfig) error
+	SetupTLSClientOrDie(info *OperatorConfig)
 	GetPodUIDMap(info *TidbClusterConfig) (map[string]types.UID, error)
 	GetNodeMap(info *TidbClusterConfig, component string) (map[string][]string, error)
 	TruncateSSTFileThenCheckFailover(info *TidbClusterConfig, tikvFailoverPeriod time.Duration) error
@@ -159,6 +168,8 @@ type OperatorActions interface {
 	CheckFailoverOrDie(clusters []*TidbClusterConfig, faultNode string)
 	CheckRecover(cluster *TidbClusterConfig) (bool, error)
 	CheckRecoverOrDie(clusters []*TidbClusterConfig)
+	CheckRegionHealth(info *TidbClusterConfig) (bool, error)
+	CheckRegionHealthOrDie(clusters []*TidbClusterConfig)
 	CheckK8sAvailable(excludeNodes map[string]string, excludePods map[string]*corev1.Pod) error
 	CheckK8sAvailableOrDie(excludeNodes map[string]string, excludePods map[string]*corev1.Pod)
 	CheckOperatorAvailable(operatorConfig *OperatorConfig) error
@@ -171,6 +182,7 @@ type OperatorActions interface {
 	CheckKubeProxyDownOrDie(operatorConfig *OperatorConfig, clusters []*TidbClusterConfig)
 	CheckKubeSchedulerDownOrDie(operatorConfig *OperatorConfig, clusters []*TidbClusterConfig)
 	CheckKubeControllerManagerDownOrDie(operatorConfig *OperatorConfig, clusters []*TidbClusterConfig)
+	CheckDNSDownOrDie(operatorConfig *OperatorConfig, clusters []*TidbClusterConfig, period time.Duration)
 	RegisterWebHookAndService(context *apimachinery.CertContext, info *OperatorConfig) error
 	RegisterWebHookAndServiceOrDie(context *apimachinery.CertContext, info *OperatorConfig)
 	CleanWebHookAndService(info *OperatorConfig) error
@@ -181,6 +193,8 @@ type OperatorActions interface {
 	BackupRestoreOrDie(from, to *TidbClusterConfig)
 	BackupAndRestoreToMultipleClusters(source *TidbClusterConfig, targets []BackupTarget) error
 	BackupAndRestoreToMultipleClustersOrDie(source *TidbClusterConfig, targets []BackupTarget)
+	BackupAndRestoreCrossNamespace(from, to *TidbClusterConfig) error
+	BackupAndRestoreCrossNamespaceOrDie(from, to *TidbClusterConfig)
 	LabelNodes() error
 	LabelNodesOrDie()
 	CheckDisasterTolerance(info *TidbClusterConfig) error
@@ -194,6 +208,13 @@ type OperatorActions interface {
 	SetPartitionAnnotation(tcName string, nameSpace string, ordinal int) error
 	CheckManualPauseTiDB(info *TidbClusterConfig) error
 	CheckManualPauseTiDBOrDie(info *TidbClusterConfig)
+	RunSysbench(info *TidbClusterConfig) (*workload.SysbenchResult, error)
+	RunSysbenchOrDie(info *TidbClusterConfig) *workload.SysbenchResult
+	RunYCSB(info *TidbClusterConfig) error
+	RunYCSBOrDie(info *TidbClusterConfig)
+	RunTPCC(info *TidbClusterConfig) error
+	RunTPCCOrDie(info *TidbClusterConfig)
+	MetricsTargets(info *OperatorConfig, clusters []*TidbClusterConfig) []metrics.Target
 }
 
 type operatorActions struct {
@@ -235,6 +256,9 @@ type OperatorConfig struct {
 	Context            *apimachinery.CertContext
 	ImagePullPolicy    corev1.PullPolicy
 	TestMode           bool
+	// TLSClientSecretName is the secret of the client certificate the
+	// operator connects to the TLS clusters with
+	TLSClientSecretName string
 }
 
 type TidbClusterConfig struct {
@@ -269,7 +293,8 @@ type TidbClusterConfig struct {
 	PDLogLevel          string
 
 	BlockWriteConfig blockwriter.Config
-	GrafanaClient    *metrics.Client
+	EnableTLSCluster bool
+	GrafanaClient    *metrics.Client `json:"-"`
 	TopologyKey      string
 
 	pumpConfig    []string
@@ -318,6 +343,7 @@ func (tc *TidbClusterConfig) TidbClusterHelmSetString(m map[string]string) strin
 		"pd.preStartScript":       tc.PDPreStartScript,
 		"tikv.preStartScript":     tc.TiKVPreStartScript,
 		"tidb.preStartScript":     tc.TiDBPreStartScript,
+		"enableTLSCluster":        strconv.FormatBool(tc.EnableTLSCluster),
 	}
 
 	for k, v := range tc.Resources {
@@ -355,6 +381,9 @@ func (oi *OperatorConfig) OperatorHelmSetString(m map[string]string) string {
 	if len(oi.SchedulerFeatures) > 0 {
 		set["scheduler.features"] = fmt.Sprintf("{%s}", strings.Join(oi.SchedulerFeatures, ","))
 	}
+	if oi.TLSClientSecretName != "" {
+		set["controllerManager.tlsClientSecretName"] = oi.TLSClientSecretName
+	}
 
 	arr := make([]string, 0, len(set))
 	for k, v := range set {
@@ -428,7 +457,7 @@ func (oa *operatorActions) DeployOperator(info *OperatorConfig) error {
 
 func (oa *operatorActions) DeployOperatorOrDie(info *OperatorConfig) {
 	if err := oa.DeployOperator(info); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -451,7 +480,7 @@ func (oa *operatorActions) CleanOperator(info *OperatorConfig) error {
 
 func (oa *operatorActions) CleanOperatorOrDie(info *OperatorConfig) {
 	if err := oa.CleanOperator(info); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -474,7 +503,7 @@ func (oa *operatorActions) UpgradeOperator(info *OperatorConfig) error {
 
 func (oa *operatorActions) UpgradeOperatorOrDie(info *OperatorConfig) {
 	if err := oa.UpgradeOperator(info); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -503,6 +532,11 @@ func (oa *operatorActions) DeployTidbCluster(info *TidbClusterConfig) error {
 	if err != nil {
 		return fmt.Errorf("failed to create secret of cluster [%s]: %v", info.ClusterName, err)
 	}
+	if info.EnableTLSCluster {
+		if err := oa.CreateTLSCerts(info); err != nil {
+			return err
+		}
+	}
 
 	cmd := fmt.Sprintf("helm install %s  --name %s --namespace %s --set-string %s",
 		oa.tidbClusterChartPath(info.OperatorTag), info.ClusterName, info.Namespace, info.TidbClusterHelmSetString(nil))
@@ -528,7 +562,7 @@ func (oa *operatorActions) DeployTidbCluster(info *TidbClusterConfig) error {
 
 func (oa *operatorActions) DeployTidbClusterOrDie(info *TidbClusterConfig) {
 	if err := oa.DeployTidbCluster(info); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -693,7 +727,7 @@ func (oa *operatorActions) CleanTidbCluster(info *TidbClusterConfig) error {
 
 func (oa *operatorActions) CleanTidbClusterOrDie(info *TidbClusterConfig) {
 	if err := oa.CleanTidbCluster(info); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -719,7 +753,7 @@ func (oa *operatorActions) GetTidbMemberAssignedNodes(info *TidbClusterConfig) (
 func (oa *operatorActions) GetTidbMemberAssignedNodesOrDie(info *TidbClusterConfig) map[string]string {
 	result, err := oa.GetTidbMemberAssignedNodes(info)
 	if err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 	return result
 }
@@ -741,7 +775,7 @@ func (oa *operatorActions) CheckTidbMemberAssignedNodes(info *TidbClusterConfig,
 
 func (oa *operatorActions) CheckTidbMemberAssignedNodesOrDie(info *TidbClusterConfig, oldAssignedNodes map[string]string) {
 	if err := oa.CheckTidbMemberAssignedNodes(info, oldAssignedNodes); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -828,7 +862,7 @@ func (oa *operatorActions) CheckTidbClusterStatus(info *TidbClusterConfig) error
 
 func (oa *operatorActions) CheckTidbClusterStatusOrDie(info *TidbClusterConfig) {
 	if err := oa.CheckTidbClusterStatus(info); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -852,7 +886,7 @@ func (oa *operatorActions) BeginInsertDataTo(info *TidbClusterConfig) error {
 func (oa *operatorActions) BeginInsertDataToOrDie(info *TidbClusterConfig) {
 	err := oa.BeginInsertDataTo(info)
 	if err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -865,6 +899,125 @@ func (oa *operatorActions) StopInsertDataTo(info *TidbClusterConfig) {
 	info.blockWriter.Stop()
 }
 
+func (oa *operatorActions) RunSysbench(info *TidbClusterConfig) (*workload.SysbenchResult, error) {
+	if oa.cfg.Sysbench == nil {
+		return nil, fmt.Errorf("sysbench is not configured")
+	}
+	oa.EmitEvent(info, "RunSysbench")
+
+	db, err := util.OpenDB(getDSN(info.Namespace, info.ClusterName, "test", info.Password), 1)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	if _, err := db.Exec("CREATE DATABASE IF NOT EXISTS sbtest"); err != nil {
+		return nil, fmt.Errorf("failed to create database sbtest for cluster %s: %v", info.FullName(), err)
+	}
+
+	host := fmt.Sprintf("%s-tidb.%s", info.ClusterName, info.Namespace)
+	sb := workload.NewSysbench(*oa.cfg.Sysbench, host, 4000, "root", info.Password, "sbtest")
+	if err := sb.Prepare(); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := sb.Cleanup(); err != nil {
+			glog.Errorf("failed to clean up sysbench of cluster %s: %v", info.FullName(), err)
+		}
+	}()
+
+	result, err := sb.Run()
+	if err != nil {
+		return nil, err
+	}
+	glog.Infof("sysbench result of cluster %s: %s", info.FullName(), result)
+
+	return result, nil
+}
+
+func (oa *operatorActions) RunSysbenchOrDie(info *TidbClusterConfig) *workload.SysbenchResult {
+	result, err := oa.RunSysbench(info)
+	if err != nil {
+		notify.NotifyAndPanic(err)
+	}
+	return result
+}
+
+func (oa *operatorActions) RunYCSB(info *TidbClusterConfig) error {
+	if oa.cfg.YCSB == nil {
+		return fmt.Errorf("ycsb is not configured")
+	}
+	oa.EmitEvent(info, fmt.Sprintf("RunYCSB: workloads: %v", oa.cfg.YCSB.Workloads))
+
+	var y *workload.YCSB
+	if oa.cfg.YCSB.DB == workload.YCSBDBRawKV {
+		y = workload.NewRawKVYCSB(*oa.cfg.YCSB, fmt.Sprintf("%s-pd.%s:2379", info.ClusterName, info.Namespace))
+	} else {
+		db, err := util.OpenDB(getDSN(info.Namespace, info.ClusterName, "test", info.Password), 1)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		if _, err := db.Exec("CREATE DATABASE IF NOT EXISTS ycsb"); err != nil {
+			return fmt.Errorf("failed to create database ycsb for cluster %s: %v", info.FullName(), err)
+		}
+		y = workload.NewYCSB(*oa.cfg.YCSB, fmt.Sprintf("%s-tidb.%s", info.ClusterName, info.Namespace), 4000, "root", info.Password, "ycsb")
+	}
+	if err := y.Verify(); err != nil {
+		return err
+	}
+
+	if err := y.Load(); err != nil {
+		return err
+	}
+	_, err := y.Run()
+	return err
+}
+
+func (oa *operatorActions) RunYCSBOrDie(info *TidbClusterConfig) {
+	if err := oa.RunYCSB(info); err != nil {
+		notify.NotifyAndPanic(err)
+	}
+}
+
+func (oa *operatorActions) RunTPCC(info *TidbClusterConfig) error {
+	if oa.cfg.TPCC == nil {
+		return fmt.Errorf("tpcc is not configured")
+	}
+	oa.EmitEvent(info, fmt.Sprintf("RunTPCC: warehouses: %d", oa.cfg.TPCC.Warehouses))
+
+	db, err := util.OpenDB(getDSN(info.Namespace, info.ClusterName, "test", info.Password), 1)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if _, err := db.Exec("CREATE DATABASE IF NOT EXISTS tpcc"); err != nil {
+		return fmt.Errorf("failed to create database tpcc for cluster %s: %v", info.FullName(), err)
+	}
+
+	t := workload.NewTPCC(*oa.cfg.TPCC, fmt.Sprintf("%s-tidb.%s", info.ClusterName, info.Namespace), 4000, "root", info.Password, "tpcc")
+	if err := t.Cleanup(); err != nil {
+		return err
+	}
+	if err := t.Prepare(); err != nil {
+		return err
+	}
+	if _, err := t.Run(); err != nil {
+		return err
+	}
+	if err := t.Check(); err != nil {
+		return fmt.Errorf("tpcc consistency check of cluster %s failed: %v", info.FullName(), err)
+	}
+	oa.EmitEvent(info, "TPCCConsistencyChecked")
+
+	return nil
+}
+
+func (oa *operatorActions) RunTPCCOrDie(info *TidbClusterConfig) {
+	if err := oa.RunTPCC(info); err != nil {
+		notify.NotifyAndPanic(err)
+	}
+}
+
 func (oa *operatorActions) manifestPath(tag string) string {
 	return filepath.Join(oa.cfg.ManifestDir, tag)
 }
@@ -907,7 +1060,7 @@ func (oa *operatorActions) ScaleTidbCluster(info *TidbClusterConfig) error {
 
 func (oa *operatorActions) ScaleTidbClusterOrDie(info *TidbClusterConfig) {
 	if err := oa.ScaleTidbCluster(info); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -920,7 +1073,7 @@ func (oa *operatorActions) CheckScaleInSafely(info *TidbClusterConfig) error {
 		}
 
 		tikvSetName := controller.TiKVMemberName(info.ClusterName)
-		tikvSet, err := oa.kubeCli.AppsV1beta1().StatefulSets(info.Namespace).Get(tikvSetName, metav1.GetOptions{})
+		tikvSet, err := oa.kubeCli.AppsV1().StatefulSets(info.Namespace).Get(tikvSetName, metav1.GetOptions{})
 		if err != nil {
 			glog.Infof("failed to get tikvSet statefulset: [%s], error: %v", tikvSetName, err)
 			return false, nil
@@ -997,7 +1150,7 @@ func (oa *operatorActions) UpgradeTidbCluster(info *TidbClusterConfig) error {
 
 func (oa *operatorActions) UpgradeTidbClusterOrDie(info *TidbClusterConfig) {
 	if err := oa.UpgradeTidbCluster(info); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -1075,7 +1228,7 @@ func (oa *operatorActions) CheckUpgrade(ctx context.Context, info *TidbClusterCo
 
 func (oa *operatorActions) CheckUpgradeOrDie(ctx context.Context, info *TidbClusterConfig) {
 	if err := oa.CheckUpgrade(ctx, info); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -1109,7 +1262,7 @@ func (oa *operatorActions) pdMembersReadyFn(tc *v1alpha1.TidbCluster) (bool, err
 	ns := tc.GetNamespace()
 	pdSetName := controller.PDMemberName(tcName)
 
-	pdSet, err := oa.kubeCli.AppsV1beta1().StatefulSets(ns).Get(pdSetName, metav1.GetOptions{})
+	pdSet, err := oa.kubeCli.AppsV1().StatefulSets(ns).Get(pdSetName, metav1.GetOptions{})
 	if err != nil {
 		glog.Errorf("failed to get statefulset: %s/%s, %v", ns, pdSetName, err)
 		return false, nil
@@ -1174,7 +1327,7 @@ func (oa *operatorActions) tikvMembersReadyFn(tc *v1alpha1.TidbCluster) (bool, e
 	ns := tc.GetNamespace()
 	tikvSetName := controller.TiKVMemberName(tcName)
 
-	tikvSet, err := oa.kubeCli.AppsV1beta1().StatefulSets(ns).Get(tikvSetName, metav1.GetOptions{})
+	tikvSet, err := oa.kubeCli.AppsV1().StatefulSets(ns).Get(tikvSetName, metav1.GetOptions{})
 	if err != nil {
 		glog.Errorf("failed to get statefulset: %s/%s, %v", ns, tikvSetName, err)
 		return false, nil
@@ -1233,7 +1386,7 @@ func (oa *operatorActions) tidbMembersReadyFn(tc *v1alpha1.TidbCluster) (bool, e
 	ns := tc.GetNamespace()
 	tidbSetName := controller.TiDBMemberName(tcName)
 
-	tidbSet, err := oa.kubeCli.AppsV1beta1().StatefulSets(ns).Get(tidbSetName, metav1.GetOptions{})
+	tidbSet, err := oa.kubeCli.AppsV1().StatefulSets(ns).Get(tidbSetName, metav1.GetOptions{})
 	if err != nil {
 		glog.Errorf("failed to get statefulset: %s/%s, %v", ns, tidbSetName, err)
 		return false, nil
@@ -2599,7 +2752,7 @@ func strPtr(s string) *string { return &s }
 
 func (oa *operatorActions) RegisterWebHookAndServiceOrDie(context *apimachinery.CertContext, info *OperatorConfig) {
 	if err := oa.RegisterWebHookAndService(context, info); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -2660,7 +2813,7 @@ func (oa *operatorActions) CleanWebHookAndService(info *OperatorConfig) error {
 func (oa *operatorActions) CleanWebHookAndServiceOrDie(info *OperatorConfig) {
 	err := oa.CleanWebHookAndService(info)
 	if err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -2779,7 +2932,7 @@ func (oa *operatorActions) EventWorker() {
 			client, err := metrics.NewClient(grafanaURL, grafanaUsername, grafanaPassword)
 			if err != nil {
 				// If parse grafana URL failed, this error cannot be recovered by retrying, so send error msg and panic
-				slack.NotifyAndPanic(fmt.Errorf("failed to parse grafana URL so can't new grafana client: %s, %v", grafanaURL, err))
+				notify.NotifyAndPanic(fmt.Errorf("failed to parse grafana URL so can't new grafana client: %s, %v", grafanaURL, err))
 			}
 
 			anno := metrics.Annotation{
@@ -2818,7 +2971,7 @@ func (oa *operatorActions) getHelmUpgradeClusterCmd(info *TidbClusterConfig, set
 func (oa *operatorActions) CheckManualPauseTiDB(info *TidbClusterConfig) error {
 
 	var tc *v1alpha1.TidbCluster
-	var tidbSet *v1beta1.StatefulSet
+	var tidbSet *appsv1.StatefulSet
 	var err error
 	ns := info.Namespace
 
@@ -2842,7 +2995,7 @@ func (oa *operatorActions) CheckManualPauseTiDB(info *TidbClusterConfig) error {
 			return false, nil
 		}
 
-		if tidbPod.Labels[v1beta1.ControllerRevisionHashLabelKey] == tc.Status.TiDB.StatefulSet.UpdateRevision &&
+		if tidbPod.Labels[appsv1.ControllerRevisionHashLabelKey] == tc.Status.TiDB.StatefulSet.UpdateRevision &&
 			tc.Status.TiDB.Phase == v1alpha1.UpgradePhase {
 			if member, ok := tc.Status.TiDB.Members[tidbPod.Name]; !ok || !member.Health {
 				glog.Infof("wait for tidb pod [%s/%s] ready member health %t ok %t", ns, podName, member.Health, ok)
@@ -2864,7 +3017,7 @@ func (oa *operatorActions) CheckManualPauseTiDB(info *TidbClusterConfig) error {
 	time.Sleep(30 * time.Second)
 
 	tidbSetName := controller.TiDBMemberName(info.ClusterName)
-	if tidbSet, err = oa.kubeCli.AppsV1beta1().StatefulSets(ns).Get(tidbSetName, metav1.GetOptions{}); err != nil {
+	if tidbSet, err = oa.kubeCli.AppsV1().StatefulSets(ns).Get(tidbSetName, metav1.GetOptions{}); err != nil {
 		return fmt.Errorf("failed to get statefulset: [%s/%s], %v", ns, tidbSetName, err)
 	}
 
@@ -2884,7 +3037,7 @@ func (oa *operatorActions) CheckManualPauseTiDBOrDie(info *TidbClusterConfig) {
 	// add annotation to pause statefulset upgrade process and check
 	err := oa.CheckManualPauseTiDB(info)
 	if err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -2902,7 +3055,7 @@ func StartValidatingAdmissionWebhookServerOrDie(context *apimachinery.CertContex
 		},
 	}
 	if err := server.ListenAndServeTLS("", ""); err != nil {
-		sendErr := slack.SendErrMsg(err.Error())
+		sendErr := notify.SendErrMsg(err.Error())
 		if sendErr != nil {
 			glog.Error(sendErr)
 		}
@@ -10,8 +10,8 @@ import (
 
 	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/tkctl/util"
+	"github.com/pingcap/tidb-operator/tests/notify"
 	sql_util "github.com/pingcap/tidb-operator/tests/pkg/util"
-	"github.com/pingcap/tidb-operator/tests/slack"
 	"golang.org/x/sync/errgroup"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -181,7 +181,7 @@ func (oa *operatorActions) BackupAndRestoreToMultipleClusters(source *TidbCluste
 
 func (oa *operatorActions) BackupAndRestoreToMultipleClustersOrDie(source *TidbClusterConfig, targets []BackupTarget) {
 	if err := oa.BackupAndRestoreToMultipleClusters(source, targets); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -198,7 +198,7 @@ func (oa *operatorActions) BackupRestore(from, to *TidbClusterConfig) error {
 
 func (oa *operatorActions) BackupRestoreOrDie(from, to *TidbClusterConfig) {
 	if err := oa.BackupRestore(from, to); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -263,7 +263,7 @@ func (oa *operatorActions) DeployDrainer(info *DrainerConfig, source *TidbCluste
 
 func (oa *operatorActions) DeployDrainerOrDie(info *DrainerConfig, source *TidbClusterConfig) {
 	if err := oa.DeployDrainer(info, source); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
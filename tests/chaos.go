@@ -0,0 +1,365 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/pingcap/tidb-operator/tests/notify"
+	"gopkg.in/yaml.v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
+)
+
+const (
+	chaosMeshReleaseName = "chaos-mesh"
+	chaosMeshNamespace   = "chaos-testing"
+	chaosMeshRepoURL     = "https://charts.chaos-mesh.org"
+	chaosMeshAPIVersion  = "chaos-mesh.org/v1alpha1"
+)
+
+// ChaosActions installs Chaos Mesh and declares the chaos experiments,
+// the faults supported by Chaos Mesh don't have to be implemented in fault-trigger.
+type ChaosActions interface {
+	InstallChaosMesh() error
+	InstallChaosMeshOrDie()
+	UninstallChaosMesh() error
+	UninstallChaosMeshOrDie()
+	CreatePodChaos(chaos *PodChaos) error
+	CreatePodChaosOrDie(chaos *PodChaos)
+	CreateNetworkChaos(chaos *NetworkChaos) error
+	CreateNetworkChaosOrDie(chaos *NetworkChaos)
+	CreateIOChaos(chaos *IOChaos) error
+	CreateIOChaosOrDie(chaos *IOChaos)
+	DeleteChaos(chaos Chaos) error
+	DeleteChaosOrDie(chaos Chaos)
+}
+
+func NewChaosActions(kubeCli kubernetes.Interface, cfg *Config) ChaosActions {
+	return &chaosActions{
+		kubeCli: kubeCli,
+		cfg:     cfg,
+	}
+}
+
+type chaosActions struct {
+	kubeCli kubernetes.Interface
+	cfg     *Config
+}
+
+var _ = ChaosActions(&chaosActions{})
+
+// Chaos is a chaos experiment of Chaos Mesh
+type Chaos interface {
+	// Kind returns the kind of the chaos object
+	Kind() string
+	// Meta returns the namespace and name of the chaos object
+	Meta() (string, string)
+	// Spec returns the spec of the chaos object
+	Spec() map[string]interface{}
+}
+
+// ChaosSelector selects the pods a chaos experiment is injected into
+type ChaosSelector struct {
+	Namespaces     []string            `yaml:"namespaces,omitempty"`
+	LabelSelectors map[string]string   `yaml:"labelSelectors,omitempty"`
+	Pods           map[string][]string `yaml:"pods,omitempty"`
+}
+
+// ChaosTarget defines the pods and how many of them are selected
+type ChaosTarget struct {
+	Selector ChaosSelector `yaml:"selector"`
+	// Mode is one of one, all, fixed, fixed-percent and random-max-percent
+	Mode string `yaml:"mode"`
+	// Value is the number or percentage of the pods for the fixed modes
+	Value string `yaml:"value,omitempty"`
+}
+
+// PodChaos kills pods or makes pods unavailable
+type PodChaos struct {
+	Namespace string
+	Name      string
+	// Action is one of pod-kill, pod-failure and container-kill
+	Action string
+	Target ChaosTarget
+	// ContainerNames are the containers killed by the container-kill action
+	ContainerNames []string
+	// Duration is how long the pod-failure lasts, e.g. 30s
+	Duration string
+}
+
+func (c *PodChaos) Kind() string { return "PodChaos" }
+
+func (c *PodChaos) Meta() (string, string) { return c.Namespace, c.Name }
+
+func (c *PodChaos) Spec() map[string]interface{} {
+	spec := targetSpec(c.Target)
+	spec["action"] = c.Action
+	if len(c.ContainerNames) > 0 {
+		spec["containerNames"] = c.ContainerNames
+	}
+	if c.Duration != "" {
+		spec["duration"] = c.Duration
+	}
+	return spec
+}
+
+// NetworkChaos injects network faults between pods
+type NetworkChaos struct {
+	Namespace string
+	Name      string
+	// Action is one of delay, loss, duplicate, corrupt and partition
+	Action string
+	Target ChaosTarget
+	// Peer is the other side of the network fault, all the traffic is
+	// affected if it is nil
+	Peer *ChaosTarget
+	// Direction is one of to, from and both
+	Direction string
+	// Latency and Jitter of the delay action, e.g. 100ms
+	Latency string
+	Jitter  string
+	// Loss is the packet loss percentage of the loss action, e.g. 25
+	Loss     string
+	Duration string
+}
+
+func (c *NetworkChaos) Kind() string { return "NetworkChaos" }
+
+func (c *NetworkChaos) Meta() (string, string) { return c.Namespace, c.Name }
+
+func (c *NetworkChaos) Spec() map[string]interface{} {
+	spec := targetSpec(c.Target)
+	spec["action"] = c.Action
+	if c.Peer != nil {
+		spec["target"] = targetSpec(*c.Peer)
+	}
+	if c.Direction != "" {
+		spec["direction"] = c.Direction
+	}
+	switch c.Action {
+	case "delay":
+		spec["delay"] = map[string]interface{}{"latency": c.Latency, "jitter": c.Jitter}
+	case "loss":
+		spec["loss"] = map[string]interface{}{"loss": c.Loss}
+	}
+	if c.Duration != "" {
+		spec["duration"] = c.Duration
+	}
+	return spec
+}
+
+// IOChaos injects IO faults into the file system of a volume
+type IOChaos struct {
+	Namespace string
+	Name      string
+	// Action is one of latency and fault
+	Action string
+	Target ChaosTarget
+	// VolumePath is the mount path of the volume in the container
+	VolumePath string
+	// Path is the glob of the affected files, all the files if empty
+	Path string
+	// Delay of the latency action, e.g. 100ms
+	Delay string
+	// Errno returned by the fault action, e.g. 5 for EIO
+	Errno int
+	// Percent is the percentage of the affected IO operations
+	Percent  int
+	Duration string
+}
+
+func (c *IOChaos) Kind() string { return "IOChaos" }
+
+func (c *IOChaos) Meta() (string, string) { return c.Namespace, c.Name }
+
+func (c *IOChaos) Spec() map[string]interface{} {
+	spec := targetSpec(c.Target)
+	spec["action"] = c.Action
+	spec["volumePath"] = c.VolumePath
+	if c.Path != "" {
+		spec["path"] = c.Path
+	}
+	switch c.Action {
+	case "latency":
+		spec["delay"] = c.Delay
+	case "fault":
+		spec["errno"] = c.Errno
+	}
+	if c.Percent > 0 {
+		spec["percent"] = c.Percent
+	}
+	if c.Duration != "" {
+		spec["duration"] = c.Duration
+	}
+	return spec
+}
+
+func targetSpec(target ChaosTarget) map[string]interface{} {
+	spec := map[string]interface{}{
+		"selector": target.Selector,
+		"mode":     target.Mode,
+	}
+	if target.Value != "" {
+		spec["value"] = target.Value
+	}
+	return spec
+}
+
+func (ca *chaosActions) InstallChaosMesh() error {
+	glog.Infof("installing chaos-mesh %s", ca.cfg.ChaosMeshVersion)
+
+	cmd := fmt.Sprintf("helm repo add chaos-mesh %s && helm repo update && "+
+		"helm install chaos-mesh/chaos-mesh --name %s --namespace %s --version %s",
+		chaosMeshRepoURL, chaosMeshReleaseName, chaosMeshNamespace, ca.cfg.ChaosMeshVersion)
+	glog.Info(cmd)
+
+	res, err := exec.Command("/bin/sh", "-c", cmd).CombinedOutput()
+	if err != nil && !strings.Contains(string(res), "already exists") {
+		return fmt.Errorf("failed to install chaos-mesh: %v, %s", err, string(res))
+	}
+
+	return wait.PollImmediate(DefaultPollInterval, DefaultPollTimeout, func() (bool, error) {
+		pods, err := ca.kubeCli.CoreV1().Pods(chaosMeshNamespace).List(metav1.ListOptions{})
+		if err != nil {
+			glog.Errorf("failed to list chaos-mesh pods: %v", err)
+			return false, nil
+		}
+		if len(pods.Items) == 0 {
+			return false, nil
+		}
+		for _, pod := range pods.Items {
+			if !podutil.IsPodReady(&pod) {
+				glog.Infof("chaos-mesh pod %s is not ready", pod.Name)
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+func (ca *chaosActions) InstallChaosMeshOrDie() {
+	if err := ca.InstallChaosMesh(); err != nil {
+		notify.NotifyAndPanic(err)
+	}
+}
+
+func (ca *chaosActions) UninstallChaosMesh() error {
+	glog.Infof("uninstalling chaos-mesh")
+
+	res, err := exec.Command("helm", "del", "--purge", chaosMeshReleaseName).CombinedOutput()
+	if err != nil && !releaseIsNotFound(err) {
+		return fmt.Errorf("failed to uninstall chaos-mesh: %v, %s", err, string(res))
+	}
+
+	return nil
+}
+
+func (ca *chaosActions) UninstallChaosMeshOrDie() {
+	if err := ca.UninstallChaosMesh(); err != nil {
+		notify.NotifyAndPanic(err)
+	}
+}
+
+func (ca *chaosActions) CreatePodChaos(chaos *PodChaos) error {
+	return ca.applyChaos(chaos)
+}
+
+func (ca *chaosActions) CreatePodChaosOrDie(chaos *PodChaos) {
+	if err := ca.CreatePodChaos(chaos); err != nil {
+		notify.NotifyAndPanic(err)
+	}
+}
+
+func (ca *chaosActions) CreateNetworkChaos(chaos *NetworkChaos) error {
+	return ca.applyChaos(chaos)
+}
+
+func (ca *chaosActions) CreateNetworkChaosOrDie(chaos *NetworkChaos) {
+	if err := ca.CreateNetworkChaos(chaos); err != nil {
+		notify.NotifyAndPanic(err)
+	}
+}
+
+func (ca *chaosActions) CreateIOChaos(chaos *IOChaos) error {
+	return ca.applyChaos(chaos)
+}
+
+func (ca *chaosActions) CreateIOChaosOrDie(chaos *IOChaos) {
+	if err := ca.CreateIOChaos(chaos); err != nil {
+		notify.NotifyAndPanic(err)
+	}
+}
+
+func (ca *chaosActions) DeleteChaos(chaos Chaos) error {
+	ns, name := chaos.Meta()
+	glog.Infof("deleting %s %s/%s", chaos.Kind(), ns, name)
+
+	cmd := fmt.Sprintf("kubectl delete %s %s -n %s --ignore-not-found", chaos.Kind(), name, ns)
+	res, err := exec.Command("/bin/sh", "-c", cmd).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to delete %s %s/%s: %v, %s", chaos.Kind(), ns, name, err, string(res))
+	}
+
+	return nil
+}
+
+func (ca *chaosActions) DeleteChaosOrDie(chaos Chaos) {
+	if err := ca.DeleteChaos(chaos); err != nil {
+		notify.NotifyAndPanic(err)
+	}
+}
+
+func (ca *chaosActions) applyChaos(chaos Chaos) error {
+	ns, name := chaos.Meta()
+	glog.Infof("creating %s %s/%s", chaos.Kind(), ns, name)
+
+	data, err := yaml.Marshal(map[string]interface{}{
+		"apiVersion": chaosMeshAPIVersion,
+		"kind":       chaos.Kind(),
+		"metadata": map[string]interface{}{
+			"namespace": ns,
+			"name":      name,
+		},
+		"spec": chaos.Spec(),
+	})
+	if err != nil {
+		return err
+	}
+
+	file, err := ioutil.TempFile("", "chaos")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	file.Close()
+
+	res, err := exec.Command("kubectl", "apply", "-f", file.Name()).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to create %s %s/%s: %v, %s", chaos.Kind(), ns, name, err, string(res))
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/golang/glog"
+	"github.com/pingcap/tidb-operator/tests/notify"
+	"github.com/pingcap/tidb-operator/tests/pkg/blockwriter"
+)
+
+// Checkpoint records the progress of a long running test: the completed
+// stages and the state of the clusters after them, so an interrupted run
+// can resume from the last completed stage instead of starting over.
+type Checkpoint struct {
+	path string
+
+	Stages []string `json:"stages"`
+	// Clusters are the configs of the clusters keyed by the full names
+	Clusters map[string]*TidbClusterConfig `json:"clusters"`
+	// DeployedClusters are the full names of the deployed clusters in order
+	DeployedClusters []string `json:"deployedClusters"`
+}
+
+// LoadCheckpoint loads the checkpoint saved in the file, an empty checkpoint
+// is returned if the file doesn't exist.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	cp := &Checkpoint{path: path}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %v", path, err)
+	}
+	glog.Infof("loaded checkpoint %s, completed stages: %v", path, cp.Stages)
+
+	return cp, nil
+}
+
+func LoadCheckpointOrDie(path string) *Checkpoint {
+	cp, err := LoadCheckpoint(path)
+	if err != nil {
+		notify.NotifyAndPanic(err)
+	}
+	return cp
+}
+
+// IsEmpty returns whether no stage is completed
+func (cp *Checkpoint) IsEmpty() bool {
+	return len(cp.Stages) == 0
+}
+
+// IsCompleted returns whether the stage is completed
+func (cp *Checkpoint) IsCompleted(stage string) bool {
+	for _, s := range cp.Stages {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// Complete marks the stage completed and saves the state of the clusters
+func (cp *Checkpoint) Complete(stage string, clusters []*TidbClusterConfig, deployed []*TidbClusterConfig) error {
+	cp.Stages = append(cp.Stages, stage)
+	cp.Clusters = make(map[string]*TidbClusterConfig, len(clusters))
+	for _, tc := range clusters {
+		cp.Clusters[tc.FullName()] = tc
+	}
+	cp.DeployedClusters = make([]string, 0, len(deployed))
+	for _, tc := range deployed {
+		cp.DeployedClusters = append(cp.DeployedClusters, tc.FullName())
+	}
+
+	return cp.save()
+}
+
+func (cp *Checkpoint) CompleteOrDie(stage string, clusters []*TidbClusterConfig, deployed []*TidbClusterConfig) {
+	if err := cp.Complete(stage, clusters, deployed); err != nil {
+		notify.NotifyAndPanic(err)
+	}
+}
+
+// Restore restores the saved state into the configs of the clusters and
+// returns the deployed ones, the block writers of the deployed clusters are
+// recreated so the data can be inserted again.
+func (cp *Checkpoint) Restore(clusters []*TidbClusterConfig) []*TidbClusterConfig {
+	byName := make(map[string]*TidbClusterConfig, len(clusters))
+	for _, tc := range clusters {
+		if saved, ok := cp.Clusters[tc.FullName()]; ok {
+			blockWriter, grafanaClient := tc.blockWriter, tc.GrafanaClient
+			*tc = *saved
+			tc.blockWriter, tc.GrafanaClient = blockWriter, grafanaClient
+		}
+		byName[tc.FullName()] = tc
+	}
+
+	deployed := make([]*TidbClusterConfig, 0, len(cp.DeployedClusters))
+	for _, name := range cp.DeployedClusters {
+		tc, ok := byName[name]
+		if !ok {
+			glog.Warningf("deployed cluster %s in checkpoint %s is unknown, skip it", name, cp.path)
+			continue
+		}
+		if tc.blockWriter == nil {
+			tc.blockWriter = blockwriter.NewBlockWriterCase(tc.BlockWriteConfig)
+			tc.blockWriter.ClusterName = tc.ClusterName
+		}
+		deployed = append(deployed, tc)
+	}
+
+	return deployed
+}
+
+// Reset removes the checkpoint, so the next run starts over
+func (cp *Checkpoint) Reset() error {
+	cp.Stages = nil
+	cp.Clusters = nil
+	cp.DeployedClusters = nil
+	if err := os.Remove(cp.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove checkpoint %s: %v", cp.path, err)
+	}
+	return nil
+}
+
+func (cp *Checkpoint) ResetOrDie() {
+	if err := cp.Reset(); err != nil {
+		notify.NotifyAndPanic(err)
+	}
+}
+
+// save writes the checkpoint to a temporary file and renames it, so the
+// checkpoint is never left half written if the test is interrupted
+func (cp *Checkpoint) save() error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := cp.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write checkpoint %s: %v", tmp, err)
+	}
+	if err := os.Rename(tmp, cp.path); err != nil {
+		return fmt.Errorf("failed to save checkpoint %s: %v", cp.path, err)
+	}
+	return nil
+}
@@ -19,27 +19,40 @@ import (
 	"net/http"
 	_ "net/http/pprof"
 	"os"
+	"path/filepath"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/tests"
+	"github.com/pingcap/tidb-operator/tests/notify"
 	"github.com/pingcap/tidb-operator/tests/pkg/apimachinery"
 	"github.com/pingcap/tidb-operator/tests/pkg/client"
-	"github.com/pingcap/tidb-operator/tests/slack"
+	"github.com/pingcap/tidb-operator/tests/pkg/control"
+	"github.com/pingcap/tidb-operator/tests/pkg/metrics"
+	"github.com/pingcap/tidb-operator/tests/pkg/report"
+	"github.com/pingcap/tidb-operator/tests/pkg/workload"
 	"github.com/robfig/cron"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apiserver/pkg/util/logs"
 )
 
+// checkpointFile is the file in the log dir the progress of the run is saved to
+const checkpointFile = "stability-checkpoint.json"
+
 var cfg *tests.Config
 var certCtx *apimachinery.CertContext
 var upgradeVersions []string
 
+// ctrl reports the progress of the runs and steers them, it's served with pprof
+var ctrl = control.New()
+
 func main() {
 	logs.InitLogs()
 	defer logs.FlushLogs()
+	http.Handle("/", ctrl.Handler())
 	go func() {
 		glog.Info(http.ListenAndServe(":6060", nil))
 	}()
@@ -56,17 +69,30 @@ func main() {
 
 	c := cron.New()
 	if err := c.AddFunc("0 0 10 * * *", func() {
-		slack.NotifyAndCompletedf("Succeed %d times in the past 24 hours.", slack.SuccessCount)
-		slack.SuccessCount = 0
+		notify.NotifyAndCompletedf("Succeed %d times in the past 24 hours.", notify.SuccessCount)
+		notify.SuccessCount = 0
 	}); err != nil {
 		panic(err)
 	}
 	go c.Start()
 
+	if cfg.Random != nil {
+		wait.Forever(runRandom, 5*time.Minute)
+		return
+	}
 	wait.Forever(run, 5*time.Minute)
 }
 
 func run() {
+	// the reports are written to the log dir after each case
+	reporter := report.NewReporter(cfg.LogDir, "stability")
+	ctrl.StartRun()
+	defer endRun()
+	defer reporter.Finish()
+	reporter.OnCaseEnd(ctrl.EndCase)
+
+	ctrl.SetCase("prepare")
+	reporter.StartCase("prepare")
 	cli, kubeCli := client.NewCliOrDie()
 
 	ocfg := newOperatorConfig()
@@ -79,6 +105,8 @@ func run() {
 	fileRestoreCluster1 := newTidbClusterConfig("ns1", "file-restore1")
 	directRestoreCluster2 := newTidbClusterConfig("ns2", "restore2")
 	fileRestoreCluster2 := newTidbClusterConfig("ns2", "file-restore2")
+	// the cluster restored from the backup of cluster1 in another namespace
+	crossNsRestoreCluster := newTidbClusterConfig("ns2", "cross-ns-restore1")
 
 	onePDCluster1 := newTidbClusterConfig("ns1", "one-pd-cluster-1")
 	onePDCluster2 := newTidbClusterConfig("ns2", "one-pd-cluster-2")
@@ -93,6 +121,7 @@ func run() {
 		fileRestoreCluster1,
 		directRestoreCluster2,
 		fileRestoreCluster2,
+		crossNsRestoreCluster,
 		onePDCluster1,
 		onePDCluster2,
 	}
@@ -106,192 +135,422 @@ func run() {
 		deployedClusters = append(deployedClusters, cluster)
 	}
 
+	// the checkpoint is saved after each stage, an interrupted run resumes
+	// from the last completed stage if --resume is set
+	checkpoint := tests.LoadCheckpointOrDie(filepath.Join(cfg.LogDir, checkpointFile))
+	if !cfg.Resume {
+		checkpoint.ResetOrDie()
+	}
+	if !checkpoint.IsEmpty() {
+		deployedClusters = checkpoint.Restore(allClusters)
+	}
+	// the changes of the config file are applied to the subsequent stages
+	reloadConfigFn := func() {
+		reloaded, err := cfg.Reload()
+		if err != nil {
+			glog.Errorf("failed to reload config, keep using the current one: %v", err)
+			return
+		}
+		if !reloaded {
+			return
+		}
+		if versions := cfg.GetUpgradeTidbVersions(); len(versions) > 0 {
+			upgradeVersions = versions
+		}
+		// the block writers of the deployed clusters are already running
+		for _, cluster := range allClusters {
+			deployed := false
+			for _, tc := range deployedClusters {
+				if tc == cluster {
+					deployed = true
+					break
+				}
+			}
+			if !deployed {
+				cluster.BlockWriteConfig = cfg.BlockWriter
+			}
+		}
+	}
+	stageFn := func(name string, fn func()) {
+		reloadConfigFn()
+		if checkpoint.IsCompleted(name) {
+			glog.Infof("stage %s is completed, skip it", name)
+			return
+		}
+		// the commands are waited for between the cases
+		reporter.EndCase()
+		if !ctrl.StartCase(name) {
+			return
+		}
+		reporter.StartCase(name)
+		fn()
+		checkpoint.CompleteOrDie(name, allClusters, deployedClusters)
+	}
+
 	fta := tests.NewFaultTriggerAction(cli, kubeCli, cfg)
 	fta.CheckAndRecoverEnvOrDie()
 
 	oa := tests.NewOperatorActions(cli, kubeCli, tests.DefaultPollInterval, cfg, allClusters)
 	oa.CheckK8sAvailableOrDie(nil, nil)
 	oa.LabelNodesOrDie()
+	if cfg.TLSCluster {
+		oa.SetupTLSClientOrDie(ocfg)
+	}
+
+	// the data is verified after the fault scenarios, so the availability
+	// tests are also correctness tests
+	faultStageFn := func(name string, fn func()) {
+		stageFn(name, func() {
+			ctrl.RecordFault(name)
+			fn()
+			for _, cluster := range deployedClusters {
+				oa.CheckTablesConsistencyOrDie(cluster)
+			}
+		})
+	}
 
 	go wait.Forever(oa.EventWorker, 10*time.Second)
 
-	oa.CleanOperatorOrDie(ocfg)
-	oa.DeployOperatorOrDie(ocfg)
+	// the logs are uploaded once on the first failure of the run, the URL
+	// is included in all the failure notifications
+	if cfg.LogUpload.Remote != "" {
+		var once sync.Once
+		var bundleURL string
+		notify.SetFailureHook(func(err error) string {
+			once.Do(func() {
+				url, err := oa.UploadLogBundle(ocfg, allClusters)
+				if err != nil {
+					glog.Errorf("failed to upload the log bundle: %v", err)
+					return
+				}
+				bundleURL = url
+			})
+			if bundleURL == "" {
+				return ""
+			}
+			return fmt.Sprintf("Logs: %s", bundleURL)
+		})
+		defer notify.SetFailureHook(nil)
+	}
 
-	for _, cluster := range allClusters {
-		oa.CleanTidbClusterOrDie(cluster)
+	// the metrics are exported alongside the logs, so the failures can be
+	// correlated with them after the fact
+	if cfg.Metrics.Interval > 0 {
+		stopCh := make(chan struct{})
+		defer close(stopCh)
+		exporter := metrics.NewExporter(filepath.Join(cfg.LogDir, "metrics"), time.Duration(cfg.Metrics.Interval)*time.Second, func() []metrics.Target {
+			return oa.MetricsTargets(ocfg, allClusters)
+		})
+		go exporter.Run(stopCh)
+	}
+
+	stageFn("deploy operator", func() {
+		oa.CleanOperatorOrDie(ocfg)
+		oa.DeployOperatorOrDie(ocfg)
+
+		for _, cluster := range allClusters {
+			oa.CleanTidbClusterOrDie(cluster)
+		}
+	})
+
+	// the data inserted before the interruption is kept, go on inserting
+	for _, cluster := range deployedClusters {
+		go oa.BeginInsertDataToOrDie(cluster)
 	}
 
-	caseFn := func(clusters []*tests.TidbClusterConfig, onePDClsuter *tests.TidbClusterConfig, backupTargets []tests.BackupTarget, upgradeVersion string) {
+	caseFn := func(name string, clusters []*tests.TidbClusterConfig, onePDClsuter *tests.TidbClusterConfig, backupTargets []tests.BackupTarget, upgradeVersionFn func() string) {
 		// check env
-		fta.CheckAndRecoverEnvOrDie()
-		oa.CheckK8sAvailableOrDie(nil, nil)
+		stageFn(name+"/check env", func() {
+			fta.CheckAndRecoverEnvOrDie()
+			oa.CheckK8sAvailableOrDie(nil, nil)
+		})
 
 		// deploy and clean the one-pd-cluster
-		oa.DeployTidbClusterOrDie(onePDClsuter)
-		oa.CheckTidbClusterStatusOrDie(onePDClsuter)
-		oa.CleanTidbClusterOrDie(onePDClsuter)
+		stageFn(name+"/deploy and clean the one-pd-cluster", func() {
+			oa.DeployTidbClusterOrDie(onePDClsuter)
+			oa.CheckTidbClusterStatusOrDie(onePDClsuter)
+			oa.CleanTidbClusterOrDie(onePDClsuter)
+		})
 
 		// deploy
-		for _, cluster := range clusters {
-			oa.DeployTidbClusterOrDie(cluster)
-			addDeployedClusterFn(cluster)
-		}
-		for _, cluster := range clusters {
-			oa.CheckTidbClusterStatusOrDie(cluster)
-			oa.CheckDisasterToleranceOrDie(cluster)
-			go oa.BeginInsertDataToOrDie(cluster)
-		}
+		stageFn(name+"/deploy", func() {
+			for _, cluster := range clusters {
+				oa.DeployTidbClusterOrDie(cluster)
+				addDeployedClusterFn(cluster)
+			}
+			for _, cluster := range clusters {
+				oa.CheckTidbClusterStatusOrDie(cluster)
+				oa.CheckDisasterToleranceOrDie(cluster)
+				go oa.BeginInsertDataToOrDie(cluster)
+				if cfg.YCSB != nil {
+					go oa.RunYCSBOrDie(cluster)
+				}
+				if cfg.TPCC != nil {
+					go oa.RunTPCCOrDie(cluster)
+				}
+			}
+		})
 
 		// scale out
-		for _, cluster := range clusters {
-			cluster.ScaleTiDB(3).ScaleTiKV(5).ScalePD(5)
-			oa.ScaleTidbClusterOrDie(cluster)
-		}
-		for _, cluster := range clusters {
-			oa.CheckTidbClusterStatusOrDie(cluster)
-			oa.CheckDisasterToleranceOrDie(cluster)
-		}
+		stageFn(name+"/scale out", func() {
+			for _, cluster := range clusters {
+				cluster.ScaleTiDB(3).ScaleTiKV(5).ScalePD(5)
+				oa.ScaleTidbClusterOrDie(cluster)
+			}
+			for _, cluster := range clusters {
+				oa.CheckTidbClusterStatusOrDie(cluster)
+				oa.CheckDisasterToleranceOrDie(cluster)
+			}
+			oa.CheckRegionHealthOrDie(clusters)
+		})
 
 		// scale in
-		for _, cluster := range clusters {
-			cluster.ScaleTiDB(2).ScaleTiKV(3).ScalePD(3)
-			oa.ScaleTidbClusterOrDie(cluster)
-		}
-		for _, cluster := range clusters {
-			oa.CheckTidbClusterStatusOrDie(cluster)
-			oa.CheckDisasterToleranceOrDie(cluster)
-		}
+		stageFn(name+"/scale in", func() {
+			for _, cluster := range clusters {
+				cluster.ScaleTiDB(2).ScaleTiKV(3).ScalePD(3)
+				oa.ScaleTidbClusterOrDie(cluster)
+			}
+			for _, cluster := range clusters {
+				oa.CheckTidbClusterStatusOrDie(cluster)
+				oa.CheckDisasterToleranceOrDie(cluster)
+			}
+			oa.CheckRegionHealthOrDie(clusters)
+		})
 
-		// upgrade
-		oa.RegisterWebHookAndServiceOrDie(certCtx, ocfg)
 		ctx, cancel := context.WithCancel(context.Background())
-		for idx, cluster := range clusters {
-			assignedNodes := oa.GetTidbMemberAssignedNodesOrDie(cluster)
-			cluster.UpgradeAll(upgradeVersion)
-			oa.UpgradeTidbClusterOrDie(cluster)
-			oa.CheckUpgradeOrDie(ctx, cluster)
-			if idx == 0 {
-				oa.CheckManualPauseTiDBOrDie(cluster)
+		defer cancel()
+
+		// upgrade
+		stageFn(name+"/upgrade", func() {
+			oa.RegisterWebHookAndServiceOrDie(certCtx, ocfg)
+			upgradeVersion := upgradeVersionFn()
+			for idx, cluster := range clusters {
+				var baseline *workload.SysbenchResult
+				if cfg.Sysbench != nil {
+					baseline = oa.RunSysbenchOrDie(cluster)
+				}
+				assignedNodes := oa.GetTidbMemberAssignedNodesOrDie(cluster)
+				cluster.UpgradeAll(upgradeVersion)
+				oa.UpgradeTidbClusterOrDie(cluster)
+				oa.CheckUpgradeOrDie(ctx, cluster)
+				if idx == 0 {
+					oa.CheckManualPauseTiDBOrDie(cluster)
+				}
+				oa.CheckTidbClusterStatusOrDie(cluster)
+				oa.CheckTidbMemberAssignedNodesOrDie(cluster, assignedNodes)
+				if cfg.Sysbench != nil {
+					result := oa.RunSysbenchOrDie(cluster)
+					if err := workload.CheckTPSRegression(baseline, result, cfg.Sysbench.MaxTPSDropPercent); err != nil {
+						notify.NotifyAndPanic(fmt.Errorf("cluster %s upgraded to %s: %v", cluster.FullName(), upgradeVersion, err))
+					}
+				}
 			}
-			oa.CheckTidbClusterStatusOrDie(cluster)
-			oa.CheckTidbMemberAssignedNodesOrDie(cluster, assignedNodes)
-		}
+		})
 
 		// configuration change
-		for _, cluster := range clusters {
-			cluster.EnableConfigMapRollout = true
-
-			// bad conf
-			cluster.TiDBPreStartScript = strconv.Quote("exit 1")
-			cluster.TiKVPreStartScript = strconv.Quote("exit 1")
-			cluster.PDPreStartScript = strconv.Quote("exit 1")
-			oa.UpgradeTidbClusterOrDie(cluster)
-			time.Sleep(30 * time.Second)
-			oa.CheckTidbClustersAvailableOrDie([]*tests.TidbClusterConfig{cluster})
-			// rollback conf
-			cluster.PDPreStartScript = strconv.Quote("")
-			cluster.TiKVPreStartScript = strconv.Quote("")
-			cluster.TiDBPreStartScript = strconv.Quote("")
-			oa.UpgradeTidbClusterOrDie(cluster)
-			// wait upgrade complete
-			oa.CheckUpgradeOrDie(ctx, cluster)
-			oa.CheckTidbClusterStatusOrDie(cluster)
-
-			cluster.UpdatePdMaxReplicas(cfg.PDMaxReplicas).
-				UpdateTiKVGrpcConcurrency(cfg.TiKVGrpcConcurrency).
-				UpdateTiDBTokenLimit(cfg.TiDBTokenLimit)
-			oa.UpgradeTidbClusterOrDie(cluster)
-			// wait upgrade complete
-			oa.CheckUpgradeOrDie(ctx, cluster)
-			oa.CheckTidbClusterStatusOrDie(cluster)
-		}
-		cancel()
-		oa.CleanWebHookAndServiceOrDie(ocfg)
+		stageFn(name+"/configuration change", func() {
+			for _, cluster := range clusters {
+				cluster.EnableConfigMapRollout = true
+
+				// bad conf
+				cluster.TiDBPreStartScript = strconv.Quote("exit 1")
+				cluster.TiKVPreStartScript = strconv.Quote("exit 1")
+				cluster.PDPreStartScript = strconv.Quote("exit 1")
+				oa.UpgradeTidbClusterOrDie(cluster)
+				time.Sleep(30 * time.Second)
+				oa.CheckTidbClustersAvailableOrDie([]*tests.TidbClusterConfig{cluster})
+				// rollback conf
+				cluster.PDPreStartScript = strconv.Quote("")
+				cluster.TiKVPreStartScript = strconv.Quote("")
+				cluster.TiDBPreStartScript = strconv.Quote("")
+				oa.UpgradeTidbClusterOrDie(cluster)
+				// wait upgrade complete
+				oa.CheckUpgradeOrDie(ctx, cluster)
+				oa.CheckTidbClusterStatusOrDie(cluster)
+
+				cluster.UpdatePdMaxReplicas(cfg.PDMaxReplicas).
+					UpdateTiKVGrpcConcurrency(cfg.TiKVGrpcConcurrency).
+					UpdateTiDBTokenLimit(cfg.TiDBTokenLimit)
+				oa.UpgradeTidbClusterOrDie(cluster)
+				// wait upgrade complete
+				oa.CheckUpgradeOrDie(ctx, cluster)
+				oa.CheckTidbClusterStatusOrDie(cluster)
+			}
+			cancel()
+			oa.CleanWebHookAndServiceOrDie(ocfg)
 
-		for _, cluster := range clusters {
-			oa.CheckDataRegionDisasterToleranceOrDie(cluster)
-		}
+			for _, cluster := range clusters {
+				oa.CheckDataRegionDisasterToleranceOrDie(cluster)
+			}
+		})
 
 		// backup and restore
-		for i := range backupTargets {
-			oa.DeployTidbClusterOrDie(backupTargets[i].TargetCluster)
-			addDeployedClusterFn(backupTargets[i].TargetCluster)
-			oa.CheckTidbClusterStatusOrDie(backupTargets[i].TargetCluster)
-		}
-		oa.BackupAndRestoreToMultipleClustersOrDie(clusters[0], backupTargets)
+		stageFn(name+"/backup and restore", func() {
+			for i := range backupTargets {
+				oa.DeployTidbClusterOrDie(backupTargets[i].TargetCluster)
+				addDeployedClusterFn(backupTargets[i].TargetCluster)
+				oa.CheckTidbClusterStatusOrDie(backupTargets[i].TargetCluster)
+			}
+			oa.BackupAndRestoreToMultipleClustersOrDie(clusters[0], backupTargets)
+		})
 
 		// delete operator
-		oa.CleanOperatorOrDie(ocfg)
-		oa.CheckOperatorDownOrDie(deployedClusters)
-		oa.DeployOperatorOrDie(ocfg)
+		stageFn(name+"/delete operator", func() {
+			oa.CleanOperatorOrDie(ocfg)
+			oa.CheckOperatorDownOrDie(deployedClusters)
+			oa.DeployOperatorOrDie(ocfg)
+		})
 
 		// stop node
-		physicalNode, node, faultTime := fta.StopNodeOrDie()
-		oa.EmitEvent(nil, fmt.Sprintf("StopNode: %s on %s", node, physicalNode))
-		oa.CheckFailoverPendingOrDie(deployedClusters, node, &faultTime)
-		oa.CheckFailoverOrDie(deployedClusters, node)
-		time.Sleep(3 * time.Minute)
-		fta.StartNodeOrDie(physicalNode, node)
-		oa.EmitEvent(nil, fmt.Sprintf("StartNode: %s on %s", node, physicalNode))
-		oa.CheckRecoverOrDie(deployedClusters)
-		for _, cluster := range deployedClusters {
-			oa.CheckTidbClusterStatusOrDie(cluster)
-		}
+		faultStageFn(name+"/stop node", func() {
+			physicalNode, node, faultTime := fta.StopNodeOrDie()
+			oa.EmitEvent(nil, fmt.Sprintf("StopNode: %s on %s", node, physicalNode))
+			oa.CheckFailoverPendingOrDie(deployedClusters, node, &faultTime)
+			oa.CheckFailoverOrDie(deployedClusters, node)
+			time.Sleep(3 * time.Minute)
+			fta.StartNodeOrDie(physicalNode, node)
+			oa.EmitEvent(nil, fmt.Sprintf("StartNode: %s on %s", node, physicalNode))
+			oa.CheckRecoverOrDie(deployedClusters)
+			for _, cluster := range deployedClusters {
+				oa.CheckTidbClusterStatusOrDie(cluster)
+			}
+			oa.CheckRegionHealthOrDie(deployedClusters)
+		})
 
 		// truncate tikv sst file
-		oa.TruncateSSTFileThenCheckFailoverOrDie(clusters[0], 5*time.Minute)
+		faultStageFn(name+"/truncate tikv sst file", func() {
+			oa.TruncateSSTFileThenCheckFailoverOrDie(clusters[0], 5*time.Minute)
+		})
 
 		// stop one etcd
-		faultEtcd := tests.SelectNode(cfg.ETCDs)
-		fta.StopETCDOrDie(faultEtcd)
-		defer fta.StartETCDOrDie(faultEtcd)
-		time.Sleep(3 * time.Minute)
-		oa.CheckEtcdDownOrDie(ocfg, deployedClusters, faultEtcd)
-		fta.StartETCDOrDie(faultEtcd)
+		faultStageFn(name+"/stop one etcd", func() {
+			faultEtcd := tests.SelectNode(cfg.ETCDs)
+			fta.StopETCDOrDie(faultEtcd)
+			defer fta.StartETCDOrDie(faultEtcd)
+			time.Sleep(3 * time.Minute)
+			oa.CheckEtcdDownOrDie(ocfg, deployedClusters, faultEtcd)
+			fta.StartETCDOrDie(faultEtcd)
+		})
 
 		// stop all etcds
-		fta.StopETCDOrDie()
-		time.Sleep(10 * time.Minute)
-		fta.StartETCDOrDie()
-		oa.CheckEtcdDownOrDie(ocfg, deployedClusters, "")
+		faultStageFn(name+"/stop all etcds", func() {
+			fta.StopETCDOrDie()
+			time.Sleep(10 * time.Minute)
+			fta.StartETCDOrDie()
+			oa.CheckEtcdDownOrDie(ocfg, deployedClusters, "")
+		})
 
 		// stop all kubelets
-		fta.StopKubeletOrDie()
-		time.Sleep(10 * time.Minute)
-		fta.StartKubeletOrDie()
-		oa.CheckKubeletDownOrDie(ocfg, deployedClusters, "")
+		faultStageFn(name+"/stop all kubelets", func() {
+			fta.StopKubeletOrDie()
+			time.Sleep(10 * time.Minute)
+			fta.StartKubeletOrDie()
+			oa.CheckKubeletDownOrDie(ocfg, deployedClusters, "")
+		})
 
 		// stop all kube-proxy and k8s/operator/tidbcluster is available
-		fta.StopKubeProxyOrDie()
-		oa.CheckKubeProxyDownOrDie(ocfg, clusters)
-		fta.StartKubeProxyOrDie()
+		faultStageFn(name+"/stop all kube-proxy", func() {
+			fta.StopKubeProxyOrDie()
+			oa.CheckKubeProxyDownOrDie(ocfg, clusters)
+			fta.StartKubeProxyOrDie()
+		})
+
+		// stop the kube-proxy of one node, the clusters are still available
+		// through the stale service rules of the node
+		faultStageFn(name+"/stop one kube-proxy", func() {
+			faultNode := tests.SelectNode(cfg.Nodes)
+			fta.StopNodeKubeProxyOrDie(faultNode)
+			defer fta.StartNodeKubeProxyOrDie(faultNode)
+			oa.EmitEvent(nil, fmt.Sprintf("StopKubeProxy: %s", faultNode))
+			oa.CheckKubeProxyDownOrDie(ocfg, clusters)
+			fta.StartNodeKubeProxyOrDie(faultNode)
+			oa.EmitEvent(nil, fmt.Sprintf("StartKubeProxy: %s", faultNode))
+		})
 
 		// stop all kube-scheduler pods
-		for _, physicalNode := range cfg.APIServers {
-			for _, vNode := range physicalNode.Nodes {
-				fta.StopKubeSchedulerOrDie(vNode)
+		faultStageFn(name+"/stop all kube-scheduler pods", func() {
+			for _, physicalNode := range cfg.APIServers {
+				for _, vNode := range physicalNode.Nodes {
+					fta.StopKubeSchedulerOrDie(vNode)
+				}
 			}
-		}
-		oa.CheckKubeSchedulerDownOrDie(ocfg, clusters)
-		for _, physicalNode := range cfg.APIServers {
-			for _, vNode := range physicalNode.Nodes {
-				fta.StartKubeSchedulerOrDie(vNode)
+			oa.CheckKubeSchedulerDownOrDie(ocfg, clusters)
+			for _, physicalNode := range cfg.APIServers {
+				for _, vNode := range physicalNode.Nodes {
+					fta.StartKubeSchedulerOrDie(vNode)
+				}
 			}
-		}
+		})
+
+		// scale out when all kube-scheduler pods are stopped, the new pods are
+		// pending until the schedulers are started
+		faultStageFn(name+"/scale out when kube-scheduler is down", func() {
+			for _, physicalNode := range cfg.APIServers {
+				for _, vNode := range physicalNode.Nodes {
+					fta.StopKubeSchedulerOrDie(vNode)
+				}
+			}
+			for _, cluster := range clusters {
+				cluster.ScaleTiDB(3)
+				oa.ScaleTidbClusterOrDie(cluster)
+			}
+			oa.CheckKubeSchedulerDownOrDie(ocfg, clusters)
+			for _, physicalNode := range cfg.APIServers {
+				for _, vNode := range physicalNode.Nodes {
+					fta.StartKubeSchedulerOrDie(vNode)
+				}
+			}
+			for _, cluster := range clusters {
+				oa.CheckTidbClusterStatusOrDie(cluster)
+				cluster.ScaleTiDB(2)
+				oa.ScaleTidbClusterOrDie(cluster)
+			}
+			for _, cluster := range clusters {
+				oa.CheckTidbClusterStatusOrDie(cluster)
+			}
+		})
 
 		// stop all kube-controller-manager pods
-		for _, physicalNode := range cfg.APIServers {
-			for _, vNode := range physicalNode.Nodes {
-				fta.StopKubeControllerManagerOrDie(vNode)
+		faultStageFn(name+"/stop all kube-controller-manager pods", func() {
+			for _, physicalNode := range cfg.APIServers {
+				for _, vNode := range physicalNode.Nodes {
+					fta.StopKubeControllerManagerOrDie(vNode)
+				}
 			}
-		}
-		oa.CheckKubeControllerManagerDownOrDie(ocfg, clusters)
-		for _, physicalNode := range cfg.APIServers {
-			for _, vNode := range physicalNode.Nodes {
-				fta.StartKubeControllerManagerOrDie(vNode)
+			oa.CheckKubeControllerManagerDownOrDie(ocfg, clusters)
+			for _, physicalNode := range cfg.APIServers {
+				for _, vNode := range physicalNode.Nodes {
+					fta.StartKubeControllerManagerOrDie(vNode)
+				}
 			}
-		}
+		})
+
+		// stop all coredns pods, the running clusters are not affected and
+		// the peers are discovered again after the DNS is back
+		faultStageFn(name+"/stop coredns", func() {
+			fta.StopCoreDNSOrDie()
+			defer fta.StartCoreDNSOrDie()
+			oa.CheckDNSDownOrDie(ocfg, clusters, 5*time.Minute)
+			fta.StartCoreDNSOrDie()
+			for _, cluster := range clusters {
+				oa.CheckTidbClusterStatusOrDie(cluster)
+			}
+		})
+
+		// the DNS queries of one node flap
+		faultStageFn(name+"/dns failure flaps on one node", func() {
+			faultNode := tests.SelectNode(cfg.Nodes)
+			defer fta.StopDNSFailureOrDie(faultNode)
+			for i := 0; i < 3; i++ {
+				fta.StartDNSFailureOrDie(time.Minute, faultNode)
+				oa.EmitEvent(nil, fmt.Sprintf("StartDNSFailure: %s", faultNode))
+				oa.CheckDNSDownOrDie(ocfg, clusters, time.Minute)
+				time.Sleep(time.Minute)
+			}
+			for _, cluster := range clusters {
+				oa.CheckTidbClusterStatusOrDie(cluster)
+			}
+		})
 	}
 
 	// before operator upgrade
@@ -311,22 +570,45 @@ func run() {
 			IncrementalType: tests.DbTypeFile,
 		},
 	}
-	caseFn(preUpgrade, onePDCluster1, backupTargets, upgradeVersions[0])
+	caseFn("pre-upgrade", preUpgrade, onePDCluster1, backupTargets, func() string {
+		return upgradeVersions[0]
+	})
+
+	// back up and restore by the custom resources across the namespaces
+	if cfg.BackupStorage != nil {
+		stageFn("cross-namespace backup and restore", func() {
+			oa.DeployTidbClusterOrDie(crossNsRestoreCluster)
+			addDeployedClusterFn(crossNsRestoreCluster)
+			oa.CheckTidbClusterStatusOrDie(crossNsRestoreCluster)
+			oa.BackupAndRestoreCrossNamespaceOrDie(cluster1, crossNsRestoreCluster)
+		})
+	}
 
 	// after operator upgrade
 	if cfg.UpgradeOperatorImage != "" && cfg.UpgradeOperatorTag != "" {
+		upgradeOcfg := *ocfg
+		upgradeOcfg.Image = cfg.UpgradeOperatorImage
+		upgradeOcfg.Tag = cfg.UpgradeOperatorTag
+		stageFn("upgrade and downgrade operator", func() {
+			oa.CheckOperatorUpgradeOrDie(ocfg, &upgradeOcfg, preUpgrade)
+		})
+
 		ocfg.Image = cfg.UpgradeOperatorImage
 		ocfg.Tag = cfg.UpgradeOperatorTag
-		oa.UpgradeOperatorOrDie(ocfg)
-		time.Sleep(5 * time.Minute)
+		stageFn("upgrade operator", func() {
+			oa.UpgradeOperatorOrDie(ocfg)
+			time.Sleep(5 * time.Minute)
+		})
 		postUpgrade := []*tests.TidbClusterConfig{
 			cluster3,
 			cluster1,
 			cluster2,
 		}
-		v := upgradeVersions[0]
-		if len(upgradeVersions) == 2 {
-			v = upgradeVersions[1]
+		v := func() string {
+			if len(upgradeVersions) == 2 {
+				return upgradeVersions[1]
+			}
+			return upgradeVersions[0]
 		}
 		postUpgradeBackupTargets := []tests.BackupTarget{
 			{
@@ -341,19 +623,46 @@ func run() {
 			},
 		}
 		// caseFn(postUpgrade, restoreCluster2, tidbUpgradeVersion)
-		caseFn(postUpgrade, onePDCluster2, postUpgradeBackupTargets, v)
+		caseFn("post-upgrade", postUpgrade, onePDCluster2, postUpgradeBackupTargets, v)
+	}
+
+	// deploy and exercise many clusters concurrently
+	if cfg.Scale != nil && cfg.Scale.Clusters > 0 {
+		stageFn("scale", func() {
+			scaleClusters := make([]*tests.TidbClusterConfig, 0, cfg.Scale.Clusters)
+			for i := 0; i < cfg.Scale.Clusters; i++ {
+				cluster := newTidbClusterConfig("scale", fmt.Sprintf("scale-%d", i))
+				cluster.Monitor = false
+				scaleClusters = append(scaleClusters, cluster)
+			}
+			tests.NewClusterScheduler(cfg.Scale).RunOrDie(scaleClusters, tests.ScaleCase(oa, kubeCli))
+		})
 	}
 
+	ctrl.SetCase("cleanup")
+	reporter.StartCase("cleanup")
 	for _, cluster := range allClusters {
 		oa.StopInsertDataTo(cluster)
 	}
+	// the run is finished, the next one starts over
+	checkpoint.ResetOrDie()
 
-	slack.SuccessCount++
+	notify.SuccessCount++
 	glog.Infof("################## Stability test finished at: %v\n\n\n\n", time.Now().Format(time.RFC3339))
 }
 
+// endRun records the result of the run, the run aborted by the abort command
+// is recovered so the next run starts after the interval of wait.Forever
+func endRun() {
+	e := recover()
+	ctrl.EndRun(e)
+	if e != nil && e != control.ErrAborted {
+		panic(e)
+	}
+}
+
 func newOperatorConfig() *tests.OperatorConfig {
-	return &tests.OperatorConfig{
+	ocfg := &tests.OperatorConfig{
 		Namespace:      "pingcap",
 		ReleaseName:    "operator",
 		Image:          cfg.OperatorImage,
@@ -369,6 +678,10 @@ func newOperatorConfig() *tests.OperatorConfig {
 		ImagePullPolicy:    v1.PullAlways,
 		TestMode:           true,
 	}
+	if cfg.TLSCluster {
+		ocfg.TLSClientSecretName = "operator-client-tls"
+	}
+	return ocfg
 }
 
 func newTidbClusterConfig(ns, clusterName string) *tests.TidbClusterConfig {
@@ -413,5 +726,6 @@ func newTidbClusterConfig(ns, clusterName string) *tests.TidbClusterConfig {
 		BlockWriteConfig: cfg.BlockWriter,
 		TopologyKey:      topologyKey,
 		ClusterVersion:   tidbVersion,
+		EnableTLSCluster: cfg.TLSCluster,
 	}
 }
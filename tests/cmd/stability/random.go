@@ -0,0 +1,207 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pingcap/tidb-operator/tests"
+	"github.com/pingcap/tidb-operator/tests/notify"
+	"github.com/pingcap/tidb-operator/tests/pkg/client"
+	"github.com/pingcap/tidb-operator/tests/pkg/report"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// runRandom runs the actions selected randomly from a weighted pool instead
+// of the scripted sequence, the seed is recorded in the case names and the
+// logs, so a failed run can be reproduced by setting the seed in the config.
+func runRandom() {
+	reporter := report.NewReporter(cfg.LogDir, "stability-random")
+	ctrl.StartRun()
+	defer endRun()
+	defer reporter.Finish()
+	reporter.OnCaseEnd(ctrl.EndCase)
+
+	ctrl.SetCase("prepare")
+	reporter.StartCase("prepare")
+	cli, kubeCli := client.NewCliOrDie()
+
+	ocfg := newOperatorConfig()
+	clusters := []*tests.TidbClusterConfig{
+		newTidbClusterConfig("ns1", "random1"),
+		newTidbClusterConfig("ns2", "random2"),
+	}
+	restoreCluster := newTidbClusterConfig("ns1", "random-restore")
+	allClusters := append([]*tests.TidbClusterConfig{restoreCluster}, clusters...)
+
+	fta := tests.NewFaultTriggerAction(cli, kubeCli, cfg)
+	fta.CheckAndRecoverEnvOrDie()
+
+	oa := tests.NewOperatorActions(cli, kubeCli, tests.DefaultPollInterval, cfg, allClusters)
+	oa.CheckK8sAvailableOrDie(nil, nil)
+	oa.LabelNodesOrDie()
+
+	go wait.Forever(oa.EventWorker, 10*time.Second)
+
+	oa.CleanOperatorOrDie(ocfg)
+	oa.DeployOperatorOrDie(ocfg)
+	for _, cluster := range allClusters {
+		oa.CleanTidbClusterOrDie(cluster)
+	}
+	for _, cluster := range clusters {
+		oa.DeployTidbClusterOrDie(cluster)
+	}
+	for _, cluster := range clusters {
+		oa.CheckTidbClusterStatusOrDie(cluster)
+		go oa.BeginInsertDataToOrDie(cluster)
+	}
+
+	checkFn := func() {
+		for _, cluster := range clusters {
+			oa.CheckTidbClusterStatusOrDie(cluster)
+			oa.CheckTablesConsistencyOrDie(cluster)
+		}
+	}
+	// versionIdx is the number of the upgrade versions skipped by each cluster
+	versionIdx := map[*tests.TidbClusterConfig]int{}
+	pickFn := func(rnd *rand.Rand) *tests.TidbClusterConfig {
+		return clusters[rnd.Intn(len(clusters))]
+	}
+
+	actions := []tests.RandomAction{
+		{
+			Name:   "scale",
+			Weight: 4,
+			Run: func(rnd *rand.Rand) {
+				cluster := pickFn(rnd)
+				cluster.ScaleTiKV(uint(3 + rnd.Intn(3))).ScaleTiDB(uint(1 + rnd.Intn(3))).ScalePD(uint(3 + 2*rnd.Intn(2)))
+				oa.ScaleTidbClusterOrDie(cluster)
+				checkFn()
+			},
+		},
+		{
+			Name:   "upgrade",
+			Weight: 1,
+			Run: func(rnd *rand.Rand) {
+				cluster := pickFn(rnd)
+				// the clusters are only upgraded to the later versions
+				next := upgradeVersions[versionIdx[cluster]:]
+				if len(next) == 0 {
+					glog.Infof("cluster %s is already upgraded to the latest version", cluster.FullName())
+					return
+				}
+				n := rnd.Intn(len(next))
+				versionIdx[cluster] += n + 1
+				cluster.UpgradeAll(next[n])
+				oa.UpgradeTidbClusterOrDie(cluster)
+				oa.CheckUpgradeOrDie(context.Background(), cluster)
+				checkFn()
+			},
+		},
+		{
+			Name:   "backup",
+			Weight: 1,
+			Run: func(rnd *rand.Rand) {
+				cluster := pickFn(rnd)
+				oa.CleanTidbClusterOrDie(restoreCluster)
+				oa.DeployTidbClusterOrDie(restoreCluster)
+				oa.CheckTidbClusterStatusOrDie(restoreCluster)
+				oa.BackupRestoreOrDie(cluster, restoreCluster)
+				oa.CleanTidbClusterOrDie(restoreCluster)
+			},
+		},
+		{
+			Name:   "delete operator",
+			Weight: 1,
+			Run: func(rnd *rand.Rand) {
+				oa.CleanOperatorOrDie(ocfg)
+				oa.CheckOperatorDownOrDie(clusters)
+				oa.DeployOperatorOrDie(ocfg)
+				checkFn()
+			},
+		},
+		{
+			// the node is selected by the fault trigger actions, it's not
+			// reproduced by the seed
+			Name:   "stop node",
+			Weight: 2,
+			Run: func(rnd *rand.Rand) {
+				physicalNode, node, faultTime := fta.StopNodeOrDie()
+				oa.EmitEvent(nil, fmt.Sprintf("StopNode: %s on %s", node, physicalNode))
+				oa.CheckFailoverPendingOrDie(clusters, node, &faultTime)
+				oa.CheckFailoverOrDie(clusters, node)
+				time.Sleep(time.Duration(1+rnd.Intn(5)) * time.Minute)
+				fta.StartNodeOrDie(physicalNode, node)
+				oa.EmitEvent(nil, fmt.Sprintf("StartNode: %s on %s", node, physicalNode))
+				oa.CheckRecoverOrDie(clusters)
+				checkFn()
+			},
+		},
+		{
+			Name:   "stop one etcd",
+			Weight: 2,
+			Run: func(rnd *rand.Rand) {
+				if len(cfg.ETCDs) == 0 || len(cfg.ETCDs[0].Nodes) == 0 {
+					glog.Infof("no etcd is configured, skip it")
+					return
+				}
+				etcds := cfg.ETCDs[rnd.Intn(len(cfg.ETCDs))].Nodes
+				faultEtcd := etcds[rnd.Intn(len(etcds))]
+				fta.StopETCDOrDie(faultEtcd)
+				defer fta.StartETCDOrDie(faultEtcd)
+				time.Sleep(time.Duration(1+rnd.Intn(5)) * time.Minute)
+				oa.CheckEtcdDownOrDie(ocfg, clusters, faultEtcd)
+				fta.StartETCDOrDie(faultEtcd)
+				checkFn()
+			},
+		},
+		{
+			Name:   "truncate tikv sst file",
+			Weight: 1,
+			Run: func(rnd *rand.Rand) {
+				oa.TruncateSSTFileThenCheckFailoverOrDie(pickFn(rnd), 5*time.Minute)
+				checkFn()
+			},
+		},
+	}
+
+	scheduler, err := tests.NewRandomScheduler(cfg.Random, actions)
+	if err != nil {
+		notify.NotifyAndPanic(err)
+	}
+	scheduler.Run(cfg.Random.Steps, func(step int, name string) bool {
+		caseName := fmt.Sprintf("seed %d/step %d: %s", scheduler.Seed(), step, name)
+		reporter.EndCase()
+		if !ctrl.StartCase(caseName) {
+			return false
+		}
+		reporter.StartCase(caseName)
+		ctrl.RecordFault(caseName)
+		return true
+	})
+
+	ctrl.SetCase("cleanup")
+	reporter.StartCase("cleanup")
+	for _, cluster := range clusters {
+		oa.StopInsertDataTo(cluster)
+	}
+
+	notify.SuccessCount++
+	glog.Infof("################## Random stability test with seed %d finished at: %v\n\n\n\n",
+		scheduler.Seed(), time.Now().Format(time.RFC3339))
+}
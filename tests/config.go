@@ -1,30 +1,42 @@
 package tests
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"strings"
 
-	"github.com/pingcap/tidb-operator/tests/slack"
+	"github.com/pingcap/tidb-operator/tests/notify"
 
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
 	"github.com/pingcap/tidb-operator/tests/pkg/blockwriter"
+	"github.com/pingcap/tidb-operator/tests/pkg/client"
+	"github.com/pingcap/tidb-operator/tests/pkg/workload"
 
 	"github.com/golang/glog"
 	"gopkg.in/yaml.v2"
+	"k8s.io/client-go/kubernetes"
 )
 
 const (
-	defaultTableNum    int = 64
-	defaultConcurrency     = 128
-	defaultBatchSize       = 100
-	defaultRawSize         = 100
+	defaultTableNum        int = 64
+	defaultConcurrency         = 128
+	defaultBatchSize           = 100
+	defaultRawSize             = 100
+	defaultReadConcurrency     = 4
+	defaultCheckInterval       = 300
 )
 
 // Config defines the config of operator tests
 type Config struct {
 	configFile string
+	// base is the config before the config file is applied, the config file
+	// is reloaded on top of it
+	base *Config
+	// configData is the content of the config file applied
+	configData []byte
 
 	TidbVersions         string  `yaml:"tidb_versions" json:"tidb_versions"`
 	OperatorTag          string  `yaml:"operator_tag" json:"operator_tag"`
@@ -33,12 +45,18 @@ type Config struct {
 	UpgradeOperatorImage string  `yaml:"upgrade_operator_image" json:"upgrade_operator_image"`
 	LogDir               string  `yaml:"log_dir" json:"log_dir"`
 	FaultTriggerPort     int     `yaml:"fault_trigger_port" json:"fault_trigger_port"`
+	ChaosMeshVersion     string  `yaml:"chaos_mesh_version" json:"chaos_mesh_version"`
 	Nodes                []Nodes `yaml:"nodes" json:"nodes"`
 	ETCDs                []Nodes `yaml:"etcds" json:"etcds"`
 	APIServers           []Nodes `yaml:"apiservers" json:"apiservers"`
 	CertFile             string
 	KeyFile              string
 
+	// Clusters are the extra kubernetes clusters exercised by the scenarios
+	// spanning multiple clusters, e.g. cross-cluster DR drills. The nodes,
+	// etcds and apiservers above belong to the cluster the tests run in.
+	Clusters []KubeCluster `yaml:"clusters" json:"clusters"`
+
 	PDMaxReplicas       int `yaml:"pd_max_replicas" json:"pd_max_replicas"`
 	TiKVGrpcConcurrency int `yaml:"tikv_grpc_concurrency" json:"tikv_grpc_concurrency"`
 	TiDBTokenLimit      int `yaml:"tidb_token_limit" json:"tidb_token_limit"`
@@ -46,9 +64,45 @@ type Config struct {
 	// old versions of reparo does not support idempotent incremental recover, so we lock the version explicitly
 	AdditionalDrainerVersion string `yaml:"file_drainer_version" json:"file_drainer_version"`
 
+	// TLSCluster deploys the clusters with TLS enabled between the components,
+	// the certificates are signed by the CA of the kubernetes cluster
+	TLSCluster bool `yaml:"tls_cluster" json:"tls_cluster"`
+
+	// Resume resumes the interrupted run from the checkpoint saved in the log dir
+	Resume bool `yaml:"resume" json:"resume"`
+
+	// Notify defines the notifiers of the test results
+	Notify notify.Config `yaml:"notify" json:"notify"`
+	// LogUpload defines where the log bundles are uploaded to on failures
+	LogUpload LogUploadConfig `yaml:"log_upload" json:"log_upload"`
+	// BackupStorage is the S3 compatible storage the backups taken by the
+	// Backup custom resources are stored in, the cases backing up and restoring
+	// by the custom resources are skipped if it's not set
+	BackupStorage *BackupStorageConfig `yaml:"backup_storage,omitempty" json:"backup_storage,omitempty"`
+
 	// Block writer
 	BlockWriter blockwriter.Config `yaml:"block_writer,omitempty"`
 
+	// Sysbench is the sysbench workload run before and after the upgrades
+	// to check the performance regression, it is skipped if not set
+	Sysbench *workload.SysbenchConfig `yaml:"sysbench,omitempty" json:"sysbench,omitempty"`
+	// YCSB is the YCSB workload run along with the block writer, it is skipped if not set
+	YCSB *workload.YCSBConfig `yaml:"ycsb,omitempty" json:"ycsb,omitempty"`
+	// TPCC is the TPC-C workload run along with the block writer, the
+	// consistency check is done when it finishes, it is skipped if not set
+	TPCC *workload.TPCCConfig `yaml:"tpcc,omitempty" json:"tpcc,omitempty"`
+
+	// Scale defines the clusters deployed and exercised concurrently to find
+	// the scalability regressions of the operator, it is skipped if not set
+	Scale *ScaleConfig `yaml:"scale,omitempty" json:"scale,omitempty"`
+	// Random runs the randomly selected actions instead of the scripted
+	// sequence if it's set
+	Random *RandomConfig `yaml:"random,omitempty" json:"random,omitempty"`
+
+	// Metrics defines how the metrics of the operator and the clusters are
+	// exported to the log dir during the tests
+	Metrics MetricsConfig `yaml:"metrics" json:"metrics"`
+
 	// For local test
 	OperatorRepoUrl string `yaml:"operator_repo_url" json:"operator_repo_url"`
 	OperatorRepoDir string `yaml:"operator_repo_dir" json:"operator_repo_dir"`
@@ -58,12 +112,52 @@ type Config struct {
 	ManifestDir string `yaml:"manifest_dir" json:"manifest_dir"`
 }
 
+// LogUploadConfig defines the object storage the log bundles are uploaded to by rclone
+type LogUploadConfig struct {
+	// Remote is the rclone path of the bundles, e.g. s3:bucket/stability,
+	// the bundles are not uploaded if it's empty
+	Remote string `yaml:"remote" json:"remote"`
+	// URLPrefix is the URL the remote path is accessed by, the URLs of the
+	// bundles are included in the failure notifications
+	URLPrefix string `yaml:"url_prefix" json:"url_prefix"`
+}
+
+// BackupStorageConfig defines the S3 compatible bucket and credentials of the backups
+type BackupStorageConfig struct {
+	// Provider is the S3 compatible storage provider, e.g. aws, minio or ceph
+	Provider string `yaml:"provider" json:"provider"`
+	Region   string `yaml:"region" json:"region"`
+	// Endpoint is the endpoint of the storage, the AWS S3 endpoint of the region is used if it's empty
+	Endpoint  string `yaml:"endpoint" json:"endpoint"`
+	Bucket    string `yaml:"bucket" json:"bucket"`
+	Prefix    string `yaml:"prefix" json:"prefix"`
+	AccessKey string `yaml:"access_key" json:"access_key"`
+	SecretKey string `yaml:"secret_key" json:"secret_key"`
+}
+
 // Nodes defines a series of nodes that belong to the same physical node.
 type Nodes struct {
 	PhysicalNode string   `yaml:"physical_node" json:"physical_node"`
 	Nodes        []string `yaml:"nodes" json:"nodes"`
 }
 
+// KubeCluster defines a kubernetes cluster accessed by a context of a kubeconfig.
+type KubeCluster struct {
+	Name string `yaml:"name" json:"name"`
+	// Kubeconfig is the path of the kubeconfig, defaults to the one the tests run with.
+	Kubeconfig string `yaml:"kubeconfig" json:"kubeconfig"`
+	// Context is the context of the kubeconfig, defaults to the current context.
+	Context    string  `yaml:"context" json:"context"`
+	Nodes      []Nodes `yaml:"nodes" json:"nodes"`
+	ETCDs      []Nodes `yaml:"etcds" json:"etcds"`
+	APIServers []Nodes `yaml:"apiservers" json:"apiservers"`
+}
+
+// NewCliOrDie creates the clients of the kubernetes cluster.
+func (kc *KubeCluster) NewCliOrDie() (versioned.Interface, kubernetes.Interface) {
+	return client.NewCliForContextOrDie(kc.Kubeconfig, kc.Context)
+}
+
 // NewConfig creates a new config.
 func NewConfig() (*Config, error) {
 	cfg := &Config{
@@ -78,11 +172,21 @@ func NewConfig() (*Config, error) {
 			Concurrency: defaultConcurrency,
 			BatchSize:   defaultBatchSize,
 			RawSize:     defaultRawSize,
+
+			ReadConcurrency: defaultReadConcurrency,
+			CheckInterval:   defaultCheckInterval,
+		},
+
+		Metrics: MetricsConfig{
+			Interval: defaultMetricsInterval,
 		},
 	}
 	flag.StringVar(&cfg.configFile, "config", "", "Config file")
 	flag.StringVar(&cfg.LogDir, "log-dir", "/logDir", "log directory")
 	flag.IntVar(&cfg.FaultTriggerPort, "fault-trigger-port", 23332, "the http port of fault trigger service")
+	flag.StringVar(&cfg.ChaosMeshVersion, "chaos-mesh-version", "v2.0.0", "the chart version of chaos-mesh")
+	flag.BoolVar(&cfg.Resume, "resume", false, "resume the interrupted run from the checkpoint saved in the log dir")
+	flag.BoolVar(&cfg.TLSCluster, "tls-cluster", false, "deploy the clusters with TLS enabled between the components")
 	flag.StringVar(&cfg.TidbVersions, "tidb-versions", "v3.0.0,v3.0.1,v3.0.2", "tidb versions")
 	flag.StringVar(&cfg.OperatorTag, "operator-tag", "master", "operator tag used to choose charts")
 	flag.StringVar(&cfg.OperatorImage, "operator-image", "pingcap/tidb-operator:latest", "operator image")
@@ -91,7 +195,7 @@ func NewConfig() (*Config, error) {
 	flag.StringVar(&cfg.OperatorRepoDir, "operator-repo-dir", "/tidb-operator", "local directory to which tidb-operator cloned")
 	flag.StringVar(&cfg.OperatorRepoUrl, "operator-repo-url", "https://github.com/pingcap/tidb-operator.git", "tidb-operator repo url used")
 	flag.StringVar(&cfg.ChartDir, "chart-dir", "", "chart dir")
-	flag.StringVar(&slack.WebhookURL, "slack-webhook-url", "", "slack webhook url")
+	flag.StringVar(&cfg.Notify.Slack.WebhookURL, "slack-webhook-url", "", "slack webhook url")
 	flag.Parse()
 
 	operatorRepo, err := ioutil.TempDir("", "tidb-operator")
@@ -120,10 +224,10 @@ func NewConfig() (*Config, error) {
 func ParseConfigOrDie() *Config {
 	cfg, err := NewConfig()
 	if err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 	if err := cfg.Parse(); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 
 	glog.Infof("using config: %+v", cfg)
@@ -135,6 +239,8 @@ func (c *Config) Parse() error {
 	// Parse first to get config file
 	flag.Parse()
 
+	base := *c
+	c.base = &base
 	if c.configFile != "" {
 		if err := c.configFromFile(c.configFile); err != nil {
 			return err
@@ -144,16 +250,92 @@ func (c *Config) Parse() error {
 	// Parse again to replace with command line options.
 	flag.Parse()
 
+	notify.SetNotifier(notify.New(c.Notify))
+
+	return c.validate()
+}
+
+// Reload reloads the config file if its content changed since it was applied
+// last time, the command line options still take precedence. It returns
+// whether the config is reloaded, the config is left unchanged on errors.
+// It's not safe to reload the config while it's read by others, the callers
+// should reload it between the test stages.
+func (c *Config) Reload() (bool, error) {
+	if c.configFile == "" {
+		return false, nil
+	}
+	data, err := ioutil.ReadFile(c.configFile)
+	if err != nil {
+		return false, err
+	}
+	if bytes.Equal(data, c.configData) {
+		return false, nil
+	}
+
+	next := *c.base
+	if err := yaml.Unmarshal(data, &next); err != nil {
+		return false, err
+	}
+	if err := next.validate(); err != nil {
+		return false, err
+	}
+
+	next.base = c.base
+	next.configData = data
+	*c = next
+	flag.Parse()
+	notify.SetNotifier(notify.New(c.Notify))
+	glog.Infof("config %s is reloaded: %+v", c.configFile, c)
+
+	return true, nil
+}
+
+func (c *Config) validate() error {
+	names := map[string]bool{}
+	for _, cluster := range c.Clusters {
+		if cluster.Name == "" {
+			return fmt.Errorf("the name of cluster with context %s is empty", cluster.Context)
+		}
+		if names[cluster.Name] {
+			return fmt.Errorf("cluster %s is duplicated", cluster.Name)
+		}
+		names[cluster.Name] = true
+	}
+
 	return nil
 }
 
+// GetKubeCluster returns the kubernetes cluster with the name.
+func (c *Config) GetKubeCluster(name string) (*KubeCluster, error) {
+	for i := range c.Clusters {
+		if c.Clusters[i].Name == name {
+			return &c.Clusters[i], nil
+		}
+	}
+	return nil, fmt.Errorf("cluster %s is not found in config", name)
+}
+
+// GetKubeClusterOrDie returns the kubernetes cluster with the name.
+func (c *Config) GetKubeClusterOrDie(name string) *KubeCluster {
+	cluster, err := c.GetKubeCluster(name)
+	if err != nil {
+		notify.NotifyAndPanic(err)
+	}
+
+	return cluster
+}
+
 func (c *Config) configFromFile(path string) error {
 	data, err := ioutil.ReadFile(path)
 	if err != nil {
 		return err
 	}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return err
+	}
+	c.configData = data
 
-	return yaml.Unmarshal(data, c)
+	return nil
 }
 
 func (c *Config) GetTiDBVersion() (string, error) {
@@ -168,7 +350,7 @@ func (c *Config) GetTiDBVersion() (string, error) {
 func (c *Config) GetTiDBVersionOrDie() string {
 	v, err := c.GetTiDBVersion()
 	if err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 
 	return v
@@ -183,7 +365,7 @@ func (c *Config) GetUpgradeTidbVersions() []string {
 func (c *Config) GetUpgradeTidbVersionsOrDie() []string {
 	versions := c.GetUpgradeTidbVersions()
 	if len(versions) < 1 {
-		slack.NotifyAndPanic(fmt.Errorf("upgrade tidb verions is empty"))
+		notify.NotifyAndPanic(fmt.Errorf("upgrade tidb verions is empty"))
 	}
 
 	return versions
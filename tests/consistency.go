@@ -0,0 +1,138 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/golang/glog"
+	"github.com/pingcap/tidb-operator/tests/notify"
+	"github.com/pingcap/tidb-operator/tests/pkg/util"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// errCodeAdminCheckTable is the error code TiDB returns if the data and
+	// the indexes of a table are inconsistent
+	errCodeAdminCheckTable = 8003
+
+	checkTablesTimeout = 30 * time.Minute
+)
+
+// inconsistencyError is returned if the data of a cluster is inconsistent,
+// it's not retried
+type inconsistencyError struct {
+	err error
+}
+
+func (e *inconsistencyError) Error() string {
+	return e.err.Error()
+}
+
+// CheckTablesConsistency runs ADMIN CHECK TABLE on all the user tables of
+// the cluster, e.g. the tables of the block writer and the workloads, and
+// verifies the checksums of the block writer tables. The failures of the
+// queries are retried as the cluster may be recovering from the faults.
+func (oa *operatorActions) CheckTablesConsistency(info *TidbClusterConfig) error {
+	glog.Infof("checking the consistency of the tables of cluster %s", info.FullName())
+
+	db, err := util.OpenDB(getDSN(info.Namespace, info.ClusterName, "", info.Password), 1)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var lastErr error
+	err = wait.PollImmediate(DefaultPollInterval, checkTablesTimeout, func() (bool, error) {
+		if err := oa.checkTables(db, info); err != nil {
+			if _, ok := err.(*inconsistencyError); ok {
+				return false, err
+			}
+			glog.Warningf("failed to check the tables of cluster %s: %v", info.FullName(), err)
+			lastErr = err
+			return false, nil
+		}
+		return true, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("failed to check the tables of cluster %s: %v", info.FullName(), lastErr)
+	}
+	if err != nil {
+		return fmt.Errorf("the tables of cluster %s are inconsistent: %v", info.FullName(), err)
+	}
+
+	glog.Infof("the tables of cluster %s are consistent", info.FullName())
+	return nil
+}
+
+func (oa *operatorActions) CheckTablesConsistencyOrDie(info *TidbClusterConfig) {
+	if err := oa.CheckTablesConsistency(info); err != nil {
+		notify.NotifyAndPanic(err)
+	}
+}
+
+func (oa *operatorActions) checkTables(db *sql.DB, info *TidbClusterConfig) error {
+	tables, err := userTables(db)
+	if err != nil {
+		return err
+	}
+
+	for _, table := range tables {
+		stmt := fmt.Sprintf("ADMIN CHECK TABLE %s", table)
+		if _, err := db.Exec(stmt); err != nil {
+			if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == errCodeAdminCheckTable {
+				return &inconsistencyError{fmt.Errorf("exec sql [%s] failed: %v", stmt, err)}
+			}
+			return fmt.Errorf("exec sql [%s] failed: %v", stmt, err)
+		}
+	}
+
+	// the checksums are verified against the rows the block writer wrote
+	if info.blockWriter != nil {
+		testDB, err := util.OpenDB(getDSN(info.Namespace, info.ClusterName, "test", info.Password), 1)
+		if err != nil {
+			return err
+		}
+		defer testDB.Close()
+		if err := info.blockWriter.Check(testDB); err != nil {
+			return &inconsistencyError{err}
+		}
+	}
+
+	return nil
+}
+
+// userTables returns the full names of the base tables of the user databases
+func userTables(db *sql.DB) ([]string, error) {
+	rows, err := db.Query("SELECT TABLE_SCHEMA, TABLE_NAME FROM INFORMATION_SCHEMA.TABLES " +
+		"WHERE TABLE_TYPE = 'BASE TABLE' AND TABLE_SCHEMA NOT IN ('mysql', 'INFORMATION_SCHEMA', 'PERFORMANCE_SCHEMA')")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var schema, name string
+		if err := rows.Scan(&schema, &name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, fmt.Sprintf("`%s`.`%s`", schema, name))
+	}
+
+	return tables, rows.Err()
+}
@@ -0,0 +1,189 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/backup/constants"
+	"github.com/pingcap/tidb-operator/tests/notify"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// BackupAndRestoreCrossNamespace backs up the cluster by a Backup and restores
+// it into the other cluster, which is usually in another namespace, by a
+// Restore referring to the Backup, then checks the data of the clusters are
+// the same. The inserts to the source cluster are paused during the case.
+func (oa *operatorActions) BackupAndRestoreCrossNamespace(from, to *TidbClusterConfig) error {
+	storage := oa.cfg.BackupStorage
+	if storage == nil {
+		return fmt.Errorf("backup storage is not configured")
+	}
+	backupName := fmt.Sprintf("%s-cross-ns", from.ClusterName)
+	restoreName := fmt.Sprintf("%s-cross-ns", to.ClusterName)
+	secretName := fmt.Sprintf("%s-s3-secret", backupName)
+
+	// the backup and restore of the last run are deleted, the data of the
+	// backup is cleaned by the clean policy
+	if err := oa.deleteRestoreAndWait(to.Namespace, restoreName); err != nil {
+		return err
+	}
+	if err := oa.deleteBackupAndWait(from.Namespace, backupName); err != nil {
+		return err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: from.Namespace,
+		},
+		Data: map[string][]byte{
+			constants.S3AccessKey: []byte(storage.AccessKey),
+			constants.S3SecretKey: []byte(storage.SecretKey),
+		},
+		Type: corev1.SecretTypeOpaque,
+	}
+	if _, err := oa.kubeCli.CoreV1().Secrets(from.Namespace).Create(secret); err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create secret %s/%s: %v", from.Namespace, secretName, err)
+	}
+
+	oa.StopInsertDataTo(from)
+	defer func() {
+		go oa.BeginInsertDataToOrDie(from)
+	}()
+	glog.Infof("wait on-going inserts to be drained for 60 seconds")
+	time.Sleep(60 * time.Second)
+
+	oa.EmitEvent(from, fmt.Sprintf("CrossNamespaceBackup: target: %s", to.FullName()))
+	backup := &v1alpha1.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backupName,
+			Namespace: from.Namespace,
+		},
+		Spec: v1alpha1.BackupSpec{
+			Cluster:        from.ClusterName,
+			TidbSecretName: from.BackupSecretName,
+			Type:           v1alpha1.BackupTypeFull,
+			StorageType:    v1alpha1.BackupStorageTypeS3,
+			StorageProvider: v1alpha1.StorageProvider{
+				S3: &v1alpha1.S3StorageProvider{
+					Provider: storage.Provider,
+					Region:   storage.Region,
+					Endpoint: storage.Endpoint,
+					Bucket:   storage.Bucket,
+					Prefix:   path.Join(storage.Prefix, from.Namespace, from.ClusterName),
+					// the storages with custom endpoints, e.g. MinIO and
+					// Ceph RGW, are usually accessed by path style
+					ForcePathStyle: storage.Endpoint != "",
+					SecretName:     secretName,
+				},
+			},
+			StorageClassName: from.StorageClassName,
+			StorageSize:      "10Gi",
+			CleanPolicy:      v1alpha1.CleanPolicyTypeDelete,
+		},
+	}
+	if _, err := oa.cli.PingcapV1alpha1().Backups(from.Namespace).Create(backup); err != nil {
+		return fmt.Errorf("failed to create backup %s/%s: %v", from.Namespace, backupName, err)
+	}
+	if err := wait.Poll(oa.pollInterval, BackupAndRestorePollTimeOut, func() (bool, error) {
+		backup, err := oa.cli.PingcapV1alpha1().Backups(from.Namespace).Get(backupName, metav1.GetOptions{})
+		if err != nil {
+			glog.Errorf("failed to get backup %s/%s: %v", from.Namespace, backupName, err)
+			return false, nil
+		}
+		if v1alpha1.IsBackupFailed(backup) {
+			return false, fmt.Errorf("backup %s/%s failed", from.Namespace, backupName)
+		}
+		return v1alpha1.IsBackupComplete(backup), nil
+	}); err != nil {
+		return fmt.Errorf("failed to wait for backup %s/%s to complete: %v", from.Namespace, backupName, err)
+	}
+
+	oa.EmitEvent(to, fmt.Sprintf("CrossNamespaceRestore: source: %s", from.FullName()))
+	restore := &v1alpha1.Restore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      restoreName,
+			Namespace: to.Namespace,
+		},
+		Spec: v1alpha1.RestoreSpec{
+			Cluster:          to.ClusterName,
+			Backup:           backupName,
+			BackupNamespace:  from.Namespace,
+			TidbSecretName:   to.BackupSecretName,
+			StorageClassName: to.StorageClassName,
+			StorageSize:      "10Gi",
+		},
+	}
+	if _, err := oa.cli.PingcapV1alpha1().Restores(to.Namespace).Create(restore); err != nil {
+		return fmt.Errorf("failed to create restore %s/%s: %v", to.Namespace, restoreName, err)
+	}
+	if err := wait.Poll(oa.pollInterval, BackupAndRestorePollTimeOut, func() (bool, error) {
+		restore, err := oa.cli.PingcapV1alpha1().Restores(to.Namespace).Get(restoreName, metav1.GetOptions{})
+		if err != nil {
+			glog.Errorf("failed to get restore %s/%s: %v", to.Namespace, restoreName, err)
+			return false, nil
+		}
+		if _, condition := v1alpha1.GetRestoreCondition(&restore.Status, v1alpha1.RestoreFailed); condition != nil && condition.Status == corev1.ConditionTrue {
+			return false, fmt.Errorf("restore %s/%s failed: %s", to.Namespace, restoreName, condition.Message)
+		}
+		return v1alpha1.IsRestoreComplete(restore), nil
+	}); err != nil {
+		return fmt.Errorf("failed to wait for restore %s/%s to complete: %v", to.Namespace, restoreName, err)
+	}
+
+	return oa.CheckDataConsistency(from, to, 10*time.Minute)
+}
+
+func (oa *operatorActions) BackupAndRestoreCrossNamespaceOrDie(from, to *TidbClusterConfig) {
+	if err := oa.BackupAndRestoreCrossNamespace(from, to); err != nil {
+		notify.NotifyAndPanic(err)
+	}
+}
+
+func (oa *operatorActions) deleteBackupAndWait(ns, name string) error {
+	err := oa.cli.PingcapV1alpha1().Backups(ns).Delete(name, &metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete backup %s/%s: %v", ns, name, err)
+	}
+	// the backup is kept by the finalizer until its data is cleaned
+	return wait.Poll(oa.pollInterval, DefaultPollTimeout, func() (bool, error) {
+		_, err := oa.cli.PingcapV1alpha1().Backups(ns).Get(name, metav1.GetOptions{})
+		return errors.IsNotFound(err), nil
+	})
+}
+
+func (oa *operatorActions) deleteRestoreAndWait(ns, name string) error {
+	err := oa.cli.PingcapV1alpha1().Restores(ns).Delete(name, &metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete restore %s/%s: %v", ns, name, err)
+	}
+	return wait.Poll(oa.pollInterval, DefaultPollTimeout, func() (bool, error) {
+		_, err := oa.cli.PingcapV1alpha1().Restores(ns).Get(name, metav1.GetOptions{})
+		return errors.IsNotFound(err), nil
+	})
+}
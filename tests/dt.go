@@ -17,7 +17,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"net/http"
 	"strconv"
 	"time"
 
@@ -25,7 +24,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/tidb-operator/pkg/label"
-	"github.com/pingcap/tidb-operator/tests/slack"
+	"github.com/pingcap/tidb-operator/tests/notify"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -93,7 +92,7 @@ func (oa *operatorActions) LabelNodes() error {
 func (oa *operatorActions) LabelNodesOrDie() {
 	err := oa.LabelNodes()
 	if err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -155,22 +154,23 @@ func (oa *operatorActions) checkPodsDisasterTolerance(allPods []corev1.Pod) erro
 func (oa *operatorActions) CheckDisasterToleranceOrDie(cluster *TidbClusterConfig) {
 	err := oa.CheckDisasterTolerance(cluster)
 	if err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
 func (oa *operatorActions) CheckDataRegionDisasterToleranceOrDie(cluster *TidbClusterConfig) {
 	err := oa.CheckDataRegionDisasterTolerance(cluster)
 	if err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
 func (oa *operatorActions) CheckDataRegionDisasterTolerance(cluster *TidbClusterConfig) error {
-	pdClient := http.Client{
-		Timeout: 10 * time.Second,
+	pdClient, scheme, err := newHTTPClient(cluster.EnableTLSCluster, 10*time.Second)
+	if err != nil {
+		return err
 	}
-	url := fmt.Sprintf("http://%s-pd.%s:2379/pd/api/v1/regions", cluster.ClusterName, cluster.Namespace)
+	url := fmt.Sprintf("%s://%s-pd.%s:2379/pd/api/v1/regions", scheme, cluster.ClusterName, cluster.Namespace)
 	resp, err := pdClient.Get(url)
 	if err != nil {
 		return err
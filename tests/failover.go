@@ -14,9 +14,9 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/label"
 	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	"github.com/pingcap/tidb-operator/tests/notify"
 	"github.com/pingcap/tidb-operator/tests/pkg/client"
 	"github.com/pingcap/tidb-operator/tests/pkg/ops"
-	"github.com/pingcap/tidb-operator/tests/slack"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -137,7 +137,7 @@ func (oa *operatorActions) TruncateSSTFileThenCheckFailover(info *TidbClusterCon
 
 func (oa *operatorActions) TruncateSSTFileThenCheckFailoverOrDie(info *TidbClusterConfig, tikvFailoverPeriod time.Duration) {
 	if err := oa.TruncateSSTFileThenCheckFailover(info, tikvFailoverPeriod); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -212,7 +212,7 @@ func (oa *operatorActions) CheckFailoverPendingOrDie(clusters []*TidbClusterConf
 		}
 		return true, nil
 	}); err != nil {
-		slack.NotifyAndPanic(fmt.Errorf("failed to check failover pending"))
+		notify.NotifyAndPanic(fmt.Errorf("failed to check failover pending"))
 	}
 }
 
@@ -293,7 +293,7 @@ func (oa *operatorActions) CheckFailoverOrDie(clusters []*TidbClusterConfig, fau
 		}
 		return true, nil
 	}); err != nil {
-		slack.NotifyAndPanic(fmt.Errorf("failed to check failover"))
+		notify.NotifyAndPanic(fmt.Errorf("failed to check failover"))
 	}
 }
 
@@ -343,7 +343,7 @@ func (oa *operatorActions) CheckRecoverOrDie(clusters []*TidbClusterConfig) {
 		}
 		return true, nil
 	}); err != nil {
-		slack.NotifyAndPanic(fmt.Errorf("failed to check recover"))
+		notify.NotifyAndPanic(fmt.Errorf("failed to check recover"))
 	}
 }
 
@@ -550,7 +550,7 @@ func (oa *operatorActions) CheckKubeSchedulerDownOrDie(operatorConfig *OperatorC
 	glog.Infof("verify kube-scheduler is not avaiavble")
 
 	if err := waitForComponentStatus(oa.kubeCli, "scheduler", corev1.ComponentHealthy, corev1.ConditionFalse); err != nil {
-		slack.NotifyAndPanic(fmt.Errorf("failed to stop kube-scheduler: %v", err))
+		notify.NotifyAndPanic(fmt.Errorf("failed to stop kube-scheduler: %v", err))
 	}
 
 	glog.Infof("checking operator/tidbCluster status when kube-scheduler is not available")
@@ -575,7 +575,7 @@ func (oa *operatorActions) CheckKubeControllerManagerDownOrDie(operatorConfig *O
 	glog.Infof("verify kube-controller-manager is not avaiavble")
 
 	if err := waitForComponentStatus(oa.kubeCli, "controller-manager", corev1.ComponentHealthy, corev1.ConditionFalse); err != nil {
-		slack.NotifyAndPanic(fmt.Errorf("failed to stop kube-controller-manager: %v", err))
+		notify.NotifyAndPanic(fmt.Errorf("failed to stop kube-controller-manager: %v", err))
 	}
 
 	glog.Infof("checking operator/tidbCluster status when kube-controller-manager is not available")
@@ -596,12 +596,59 @@ func (oa *operatorActions) CheckKubeControllerManagerDownOrDie(operatorConfig *O
 	})
 }
 
+// CheckDNSDownOrDie checks that the pods of the clusters are kept running and
+// not recreated by the operator during the period the DNS is unavailable. The
+// clusters are not accessed by sql, which resolves the address of tidb by DNS.
+func (oa *operatorActions) CheckDNSDownOrDie(operatorConfig *OperatorConfig, clusters []*TidbClusterConfig, period time.Duration) {
+	glog.Infof("checking operator/tidbCluster status when dns is not available")
+
+	podUIDs := map[string]map[string]types.UID{}
+	for _, cluster := range clusters {
+		uids, err := oa.GetPodUIDMap(cluster)
+		if err != nil {
+			notify.NotifyAndPanic(fmt.Errorf("failed to get pods of cluster %s: %v", cluster.FullName(), err))
+		}
+		podUIDs[cluster.FullName()] = uids
+	}
+
+	KeepOrDie(3*time.Second, period, func() error {
+		err := oa.CheckOperatorAvailable(operatorConfig)
+		if err != nil {
+			return err
+		}
+		glog.V(4).Infof("tidb operator is available.")
+
+		for _, cluster := range clusters {
+			selector, err := label.New().Instance(cluster.ClusterName).Selector()
+			if err != nil {
+				return err
+			}
+			pods, err := oa.kubeCli.CoreV1().Pods(cluster.Namespace).List(metav1.ListOptions{LabelSelector: selector.String()})
+			if err != nil {
+				glog.Errorf("failed to list pods of cluster %s: %v", cluster.FullName(), err)
+				continue
+			}
+			for _, pod := range pods.Items {
+				uid, ok := podUIDs[cluster.FullName()][pod.GetName()]
+				if !ok || uid != pod.GetUID() {
+					return fmt.Errorf("pod %s/%s is recreated when dns is not available", pod.GetNamespace(), pod.GetName())
+				}
+				if pod.Status.Phase != corev1.PodRunning {
+					return fmt.Errorf("pod %s/%s is %s when dns is not available", pod.GetNamespace(), pod.GetName(), pod.Status.Phase)
+				}
+			}
+		}
+		glog.V(4).Infof("all cluster pods are running.")
+		return nil
+	})
+}
+
 func (oa *operatorActions) CheckOneApiserverDownOrDie(operatorConfig *OperatorConfig, clusters []*TidbClusterConfig, faultNode string) {
 	glog.Infof("check k8s/operator/tidbCluster status when one apiserver down")
 	affectedPods := map[string]*corev1.Pod{}
 	apiserverPod, err := GetKubeApiserverPod(oa.kubeCli, faultNode)
 	if err != nil {
-		slack.NotifyAndPanic(fmt.Errorf("can't find apiserver in k8s cluster"))
+		notify.NotifyAndPanic(fmt.Errorf("can't find apiserver in k8s cluster"))
 	}
 	if apiserverPod != nil {
 		affectedPods[apiserverPod.GetName()] = apiserverPod
@@ -609,7 +656,7 @@ func (oa *operatorActions) CheckOneApiserverDownOrDie(operatorConfig *OperatorCo
 
 	controllerPod, err := GetKubeControllerManagerPod(oa.kubeCli, faultNode)
 	if err != nil {
-		slack.NotifyAndPanic(fmt.Errorf("can't find kube-controller-manager in k8s cluster"))
+		notify.NotifyAndPanic(fmt.Errorf("can't find kube-controller-manager in k8s cluster"))
 	}
 	if controllerPod != nil {
 		affectedPods[controllerPod.GetName()] = controllerPod
@@ -617,7 +664,7 @@ func (oa *operatorActions) CheckOneApiserverDownOrDie(operatorConfig *OperatorCo
 
 	schedulerPod, err := GetKubeSchedulerPod(oa.kubeCli, faultNode)
 	if err != nil {
-		slack.NotifyAndPanic(fmt.Errorf("can't find kube-scheduler in k8s cluster"))
+		notify.NotifyAndPanic(fmt.Errorf("can't find kube-scheduler in k8s cluster"))
 	}
 	if schedulerPod != nil {
 		affectedPods[schedulerPod.GetName()] = schedulerPod
@@ -625,7 +672,7 @@ func (oa *operatorActions) CheckOneApiserverDownOrDie(operatorConfig *OperatorCo
 
 	dnsPod, err := GetKubeDNSPod(oa.kubeCli, faultNode)
 	if err != nil {
-		slack.NotifyAndPanic(fmt.Errorf("can't find kube-dns in k8s cluster"))
+		notify.NotifyAndPanic(fmt.Errorf("can't find kube-dns in k8s cluster"))
 	}
 	if dnsPod != nil {
 		affectedPods[dnsPod.GetName()] = dnsPod
@@ -633,7 +680,7 @@ func (oa *operatorActions) CheckOneApiserverDownOrDie(operatorConfig *OperatorCo
 
 	proxyPod, err := GetKubeProxyPod(oa.kubeCli, faultNode)
 	if err != nil {
-		slack.NotifyAndPanic(fmt.Errorf("can't find kube-proxy in k8s cluster"))
+		notify.NotifyAndPanic(fmt.Errorf("can't find kube-proxy in k8s cluster"))
 	}
 	if proxyPod != nil {
 		affectedPods[dnsPod.GetName()] = proxyPod
@@ -673,7 +720,7 @@ func (oa *operatorActions) CheckOperatorDownOrDie(clusters []*TidbClusterConfig)
 
 func (oa *operatorActions) CheckK8sAvailableOrDie(excludeNodes map[string]string, excludePods map[string]*corev1.Pod) {
 	if err := oa.CheckK8sAvailable(excludeNodes, excludePods); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -763,7 +810,7 @@ func (oa *operatorActions) CheckTidbClustersAvailable(infos []*TidbClusterConfig
 
 func (oa *operatorActions) CheckTidbClustersAvailableOrDie(infos []*TidbClusterConfig) {
 	if err := oa.CheckTidbClustersAvailable(infos); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -4,13 +4,15 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 
-	"github.com/pingcap/tidb-operator/tests/slack"
+	"github.com/pingcap/tidb-operator/tests/notify"
 
 	"github.com/golang/glog"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
+	"github.com/pingcap/tidb-operator/pkg/label"
 	"github.com/pingcap/tidb-operator/pkg/pdapi"
 	"github.com/pingcap/tidb-operator/tests/pkg/fault-trigger/client"
 	"github.com/pingcap/tidb-operator/tests/pkg/fault-trigger/manager"
@@ -25,6 +27,10 @@ import (
 const (
 	startAction = "start"
 	stopAction  = "stop"
+
+	coreDNSDeployment = "coredns"
+	// coreDNSReplicasAnnotation records the replicas of CoreDNS before it is stopped
+	coreDNSReplicasAnnotation = "pingcap.com/fault-trigger-replicas"
 )
 
 type FaultTriggerActions interface {
@@ -58,6 +64,22 @@ type FaultTriggerActions interface {
 	StopKubeProxyOrDie()
 	StartKubeProxy() error
 	StartKubeProxyOrDie()
+	StopNodeKubeProxy(node string) error
+	StopNodeKubeProxyOrDie(node string)
+	StartNodeKubeProxy(node string) error
+	StartNodeKubeProxyOrDie(node string)
+	StopCoreDNS() error
+	StopCoreDNSOrDie()
+	StartCoreDNS() error
+	StartCoreDNSOrDie()
+	StartDNSFailure(duration time.Duration, nodes ...string) error
+	StartDNSFailureOrDie(duration time.Duration, nodes ...string)
+	StopDNSFailure(nodes ...string) error
+	StopDNSFailureOrDie(nodes ...string)
+	StartNetworkShaping(shaping manager.NetworkShaping, nodes ...string) error
+	StartNetworkShapingOrDie(shaping manager.NetworkShaping, nodes ...string)
+	StopNetworkShaping(nodes ...string) error
+	StopNetworkShapingOrDie(nodes ...string)
 	// TODO: support more faults
 	// DiskCorruption(node string) error
 	// NetworkPartition(fromNode, toNode string) error
@@ -127,6 +149,26 @@ func (fa *faultTriggerActions) CheckAndRecoverEnv() error {
 	if err != nil {
 		return err
 	}
+	for _, node := range allK8sNodes {
+		err := fa.StartNodeKubeProxy(node)
+		if err != nil {
+			return err
+		}
+	}
+	glog.Infof("ensure the DNS is available")
+	err = fa.StartCoreDNS()
+	if err != nil {
+		return err
+	}
+	err = fa.StopDNSFailure()
+	if err != nil {
+		return err
+	}
+	glog.Infof("ensure all network links are not shaped")
+	err = fa.StopNetworkShaping()
+	if err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -163,6 +205,11 @@ func (fa *faultTriggerActions) StopNode() (string, string, time.Time, error) {
 	}
 
 	glog.Infof("node %s on physical node %s is stopped", node, physicalNode)
+
+	// confirm the node failure, so that the operator can force delete the pods stuck on it
+	if err := fa.setNodeFailureConfirmed(node, true); err != nil {
+		return "", "", now, err
+	}
 	return physicalNode, node, now, nil
 }
 
@@ -172,12 +219,16 @@ func (fa *faultTriggerActions) StopNodeOrDie() (string, string, time.Time) {
 	var err error
 	var now time.Time
 	if pn, n, now, err = fa.StopNode(); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 	return pn, n, now
 }
 
 func (fa *faultTriggerActions) StartNode(physicalNode string, node string) error {
+	if err := fa.setNodeFailureConfirmed(node, false); err != nil {
+		return err
+	}
+
 	faultCli := client.NewClient(client.Config{
 		Addr: fa.genFaultTriggerAddr(physicalNode),
 	})
@@ -207,10 +258,34 @@ func (fa *faultTriggerActions) StartNode(physicalNode string, node string) error
 
 func (fa *faultTriggerActions) StartNodeOrDie(physicalNode string, node string) {
 	if err := fa.StartNode(physicalNode, node); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
+func (fa *faultTriggerActions) setNodeFailureConfirmed(node string, confirmed bool) error {
+	k8sNode, err := fa.kubeCli.CoreV1().Nodes().Get(node, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, exist := k8sNode.Annotations[label.AnnNodeFailureConfirmed]
+	if exist == confirmed {
+		return nil
+	}
+	if confirmed {
+		if k8sNode.Annotations == nil {
+			k8sNode.Annotations = map[string]string{}
+		}
+		k8sNode.Annotations[label.AnnNodeFailureConfirmed] = "true"
+	} else {
+		delete(k8sNode.Annotations, label.AnnNodeFailureConfirmed)
+	}
+	_, err = fa.kubeCli.CoreV1().Nodes().Update(k8sNode)
+	return err
+}
+
 func (fa *faultTriggerActions) getAllKubeProxyPods() ([]v1.Pod, error) {
 	selector := labels.Set{"k8s-app": "kube-proxy"}.AsSelector()
 	podList, err := fa.kubeCli.CoreV1().Pods(metav1.NamespaceSystem).List(metav1.ListOptions{
@@ -269,7 +344,7 @@ func (fa *faultTriggerActions) StopKubeProxy() error {
 
 func (fa *faultTriggerActions) StopKubeProxyOrDie() {
 	if err := fa.StopKubeProxy(); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -313,7 +388,246 @@ func (fa *faultTriggerActions) StartKubeProxy() error {
 
 func (fa *faultTriggerActions) StartKubeProxyOrDie() {
 	if err := fa.StartKubeProxy(); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
+	}
+}
+
+// StopNodeKubeProxy stops the kube-proxy on the node by the fault-trigger,
+// the service rules of the node are kept as they are until it is started.
+func (fa *faultTriggerActions) StopNodeKubeProxy(node string) error {
+	return fa.serviceAction(node, manager.KubeProxyService, stopAction)
+}
+
+func (fa *faultTriggerActions) StopNodeKubeProxyOrDie(node string) {
+	if err := fa.StopNodeKubeProxy(node); err != nil {
+		notify.NotifyAndPanic(err)
+	}
+}
+
+// StartNodeKubeProxy starts the kube-proxy on the node stopped by StopNodeKubeProxy.
+func (fa *faultTriggerActions) StartNodeKubeProxy(node string) error {
+	return fa.serviceAction(node, manager.KubeProxyService, startAction)
+}
+
+func (fa *faultTriggerActions) StartNodeKubeProxyOrDie(node string) {
+	if err := fa.StartNodeKubeProxy(node); err != nil {
+		notify.NotifyAndPanic(err)
+	}
+}
+
+// StopCoreDNS scales the CoreDNS deployment to zero, the replicas are
+// recorded in its annotation so that StartCoreDNS can restore them.
+func (fa *faultTriggerActions) StopCoreDNS() error {
+	glog.Infof("stopping all coredns pods")
+	deploy, err := fa.kubeCli.AppsV1().Deployments(metav1.NamespaceSystem).Get(coreDNSDeployment, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if _, ok := deploy.Annotations[coreDNSReplicasAnnotation]; !ok {
+		replicas := int32(1)
+		if deploy.Spec.Replicas != nil {
+			replicas = *deploy.Spec.Replicas
+		}
+		if deploy.Annotations == nil {
+			deploy.Annotations = map[string]string{}
+		}
+		deploy.Annotations[coreDNSReplicasAnnotation] = strconv.Itoa(int(replicas))
+		zero := int32(0)
+		deploy.Spec.Replicas = &zero
+		if _, err = fa.kubeCli.AppsV1().Deployments(metav1.NamespaceSystem).Update(deploy); err != nil {
+			return err
+		}
+	}
+	err = wait.PollImmediate(PodPollInterval, PodTimeout, func() (bool, error) {
+		pods, err := fa.getAllCoreDNSPods()
+		if err != nil {
+			return false, nil
+		}
+		return len(pods) == 0, nil
+	})
+	if err != nil {
+		return err
+	}
+	glog.Infof("coredns pods are stopped")
+	return nil
+}
+
+func (fa *faultTriggerActions) StopCoreDNSOrDie() {
+	if err := fa.StopCoreDNS(); err != nil {
+		notify.NotifyAndPanic(err)
+	}
+}
+
+// StartCoreDNS restores the replicas of the CoreDNS deployment stopped by StopCoreDNS.
+func (fa *faultTriggerActions) StartCoreDNS() error {
+	deploy, err := fa.kubeCli.AppsV1().Deployments(metav1.NamespaceSystem).Get(coreDNSDeployment, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	value, ok := deploy.Annotations[coreDNSReplicasAnnotation]
+	if !ok {
+		return nil
+	}
+	glog.Infof("starting all coredns pods")
+	replicas, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("invalid annotation %s=%s of deployment %s, %v", coreDNSReplicasAnnotation, value, coreDNSDeployment, err)
+	}
+	r := int32(replicas)
+	deploy.Spec.Replicas = &r
+	delete(deploy.Annotations, coreDNSReplicasAnnotation)
+	if _, err = fa.kubeCli.AppsV1().Deployments(metav1.NamespaceSystem).Update(deploy); err != nil {
+		return err
+	}
+	err = wait.PollImmediate(PodPollInterval, PodTimeout, func() (bool, error) {
+		deploy, err := fa.kubeCli.AppsV1().Deployments(metav1.NamespaceSystem).Get(coreDNSDeployment, metav1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+		return deploy.Status.AvailableReplicas == r, nil
+	})
+	if err != nil {
+		return err
+	}
+	glog.Infof("coredns pods are started")
+	return nil
+}
+
+func (fa *faultTriggerActions) StartCoreDNSOrDie() {
+	if err := fa.StartCoreDNS(); err != nil {
+		notify.NotifyAndPanic(err)
+	}
+}
+
+func (fa *faultTriggerActions) getAllCoreDNSPods() ([]v1.Pod, error) {
+	selector := labels.Set{"k8s-app": "kube-dns"}.AsSelector()
+	podList, err := fa.kubeCli.CoreV1().Pods(metav1.NamespaceSystem).List(metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return podList.Items, nil
+}
+
+// StartDNSFailure drops the DNS queries of the nodes and the pods on them,
+// they are recovered by the fault-trigger after the duration.
+// If the `nodes` is empty, the DNS queries of all nodes are dropped.
+func (fa *faultTriggerActions) StartDNSFailure(duration time.Duration, nodes ...string) error {
+	if len(nodes) == 0 {
+		nodes = getAllK8sNodes(fa.cfg)
+	}
+
+	for _, node := range nodes {
+		faultCli := client.NewClient(client.Config{
+			Addr: fa.genFaultTriggerAddr(node),
+		})
+		if err := faultCli.StartDNSFailure(&manager.DNSFailure{
+			Duration: int(duration.Seconds()),
+		}); err != nil {
+			glog.Errorf("failed to start dns failure on node %s: %v", node, err)
+			return err
+		}
+		glog.Infof("dns failure is started on node %s for %s", node, duration)
+	}
+
+	return nil
+}
+
+func (fa *faultTriggerActions) StartDNSFailureOrDie(duration time.Duration, nodes ...string) {
+	if err := fa.StartDNSFailure(duration, nodes...); err != nil {
+		notify.NotifyAndPanic(err)
+	}
+}
+
+// StopDNSFailure recovers the DNS queries of the nodes.
+// If the `nodes` is empty, the DNS queries of all nodes are recovered.
+func (fa *faultTriggerActions) StopDNSFailure(nodes ...string) error {
+	if len(nodes) == 0 {
+		nodes = getAllK8sNodes(fa.cfg)
+	}
+
+	for _, node := range nodes {
+		faultCli := client.NewClient(client.Config{
+			Addr: fa.genFaultTriggerAddr(node),
+		})
+		if err := faultCli.StopDNSFailure(); err != nil {
+			glog.Errorf("failed to stop dns failure on node %s: %v", node, err)
+			return err
+		}
+		glog.Infof("dns failure is stopped on node %s", node)
+	}
+
+	return nil
+}
+
+func (fa *faultTriggerActions) StopDNSFailureOrDie(nodes ...string) {
+	if err := fa.StopDNSFailure(nodes...); err != nil {
+		notify.NotifyAndPanic(err)
+	}
+}
+
+// StartNetworkShaping degrades the links between every pair of the nodes in
+// both directions by the shaping, the targets of the shaping are ignored. The
+// links are recovered by the fault-trigger after the duration of the shaping.
+func (fa *faultTriggerActions) StartNetworkShaping(shaping manager.NetworkShaping, nodes ...string) error {
+	if len(nodes) < 2 {
+		return fmt.Errorf("at least two nodes are required to shape the links between them, got %v", nodes)
+	}
+
+	for i, node := range nodes {
+		shaping.Targets = nil
+		for j, target := range nodes {
+			if i != j {
+				shaping.Targets = append(shaping.Targets, target)
+			}
+		}
+		faultCli := client.NewClient(client.Config{
+			Addr: fa.genFaultTriggerAddr(node),
+		})
+		if err := faultCli.StartNetworkShaping(&shaping); err != nil {
+			glog.Errorf("failed to start network shaping from node %s to %v: %v", node, shaping.Targets, err)
+			return err
+		}
+		glog.Infof("network shaping is started from node %s to %v", node, shaping.Targets)
+	}
+
+	return nil
+}
+
+func (fa *faultTriggerActions) StartNetworkShapingOrDie(shaping manager.NetworkShaping, nodes ...string) {
+	if err := fa.StartNetworkShaping(shaping, nodes...); err != nil {
+		notify.NotifyAndPanic(err)
+	}
+}
+
+// StopNetworkShaping recovers the links of the nodes.
+// If the `nodes` is empty, the links of all nodes are recovered.
+func (fa *faultTriggerActions) StopNetworkShaping(nodes ...string) error {
+	if len(nodes) == 0 {
+		nodes = getAllK8sNodes(fa.cfg)
+	}
+
+	for _, node := range nodes {
+		faultCli := client.NewClient(client.Config{
+			Addr: fa.genFaultTriggerAddr(node),
+		})
+		if err := faultCli.StopNetworkShaping(""); err != nil {
+			glog.Errorf("failed to stop network shaping on node %s: %v", node, err)
+			return err
+		}
+		glog.Infof("network shaping is stopped on node %s", node)
+	}
+
+	return nil
+}
+
+func (fa *faultTriggerActions) StopNetworkShapingOrDie(nodes ...string) {
+	if err := fa.StopNetworkShaping(nodes...); err != nil {
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -338,7 +652,7 @@ func (fa *faultTriggerActions) StopETCD(nodes ...string) error {
 func (fa *faultTriggerActions) StopETCDOrDie(nodes ...string) {
 	glog.Infof("stopping %v etcds", nodes)
 	if err := fa.StopETCD(nodes...); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -362,7 +676,7 @@ func (fa *faultTriggerActions) StopKubelet(nodes ...string) error {
 func (fa *faultTriggerActions) StopKubeletOrDie(nodes ...string) {
 	glog.Infof("stopping %v kubelets", nodes)
 	if err := fa.StopKubelet(nodes...); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -385,7 +699,7 @@ func (fa *faultTriggerActions) StartKubelet(nodes ...string) error {
 
 func (fa *faultTriggerActions) StartKubeletOrDie(nodes ...string) {
 	if err := fa.StartKubelet(nodes...); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -404,7 +718,7 @@ func (fa *faultTriggerActions) StartETCD(nodes ...string) error {
 		go func(n string) {
 			defer wg.Done()
 			if err := fa.serviceAction(n, manager.ETCDService, startAction); err != nil {
-				slack.NotifyAndPanic(fmt.Errorf("failed to start %s etcd, %v", n, err))
+				notify.NotifyAndPanic(fmt.Errorf("failed to start %s etcd, %v", n, err))
 			}
 		}(node)
 	}
@@ -415,7 +729,7 @@ func (fa *faultTriggerActions) StartETCD(nodes ...string) error {
 
 func (fa *faultTriggerActions) StartETCDOrDie(nodes ...string) {
 	if err := fa.StartETCD(nodes...); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -427,7 +741,7 @@ func (fa *faultTriggerActions) StopKubeScheduler(node string) error {
 // StopKubeScheduler stops the kube-scheduler service or dies.
 func (fa *faultTriggerActions) StopKubeSchedulerOrDie(node string) {
 	if err := fa.serviceAction(node, manager.KubeSchedulerService, stopAction); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -439,7 +753,7 @@ func (fa *faultTriggerActions) StartKubeScheduler(node string) error {
 // StartKubeScheduler starts the kube-scheduler service or dies
 func (fa *faultTriggerActions) StartKubeSchedulerOrDie(node string) {
 	if err := fa.serviceAction(node, manager.KubeSchedulerService, startAction); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -451,7 +765,7 @@ func (fa *faultTriggerActions) StopKubeControllerManager(node string) error {
 // StopKubeControllerManager stops the kube-controller-manager service or dies
 func (fa *faultTriggerActions) StopKubeControllerManagerOrDie(node string) {
 	if err := fa.serviceAction(node, manager.KubeControllerManagerService, stopAction); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -463,7 +777,7 @@ func (fa *faultTriggerActions) StartKubeControllerManager(node string) error {
 // StartKubeControllerManager starts the kube-controller-manager service or dies.
 func (fa *faultTriggerActions) StartKubeControllerManagerOrDie(node string) {
 	if err := fa.serviceAction(node, manager.KubeControllerManagerService, startAction); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -474,7 +788,7 @@ func (fa *faultTriggerActions) StopKubeAPIServer(node string) error {
 
 func (fa *faultTriggerActions) StopKubeAPIServerOrDie(node string) {
 	if err := fa.StopKubeAPIServer(node); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -485,7 +799,7 @@ func (fa *faultTriggerActions) StartKubeAPIServer(node string) error {
 
 func (fa *faultTriggerActions) StartKubeAPIServerOrDie(node string) {
 	if err := fa.StartKubeAPIServer(node); err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 }
 
@@ -506,6 +820,8 @@ func (fa *faultTriggerActions) serviceAction(node string, serverName string, act
 			err = faultCli.StartKubeControllerManager()
 		case manager.KubeAPIServerService:
 			err = faultCli.StartKubeAPIServer()
+		case manager.KubeProxyService:
+			err = faultCli.StartKubeProxy()
 		case manager.ETCDService:
 			err = faultCli.StartETCD()
 		default:
@@ -522,6 +838,8 @@ func (fa *faultTriggerActions) serviceAction(node string, serverName string, act
 			err = faultCli.StopKubeControllerManager()
 		case manager.KubeAPIServerService:
 			err = faultCli.StopKubeAPIServer()
+		case manager.KubeProxyService:
+			err = faultCli.StopKubeProxy()
 		case manager.ETCDService:
 			err = faultCli.StopETCD()
 		default:
@@ -6,7 +6,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/golang/glog"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -27,8 +30,10 @@ func (oa *operatorActions) DumpAllLogs(operatorInfo *OperatorConfig, testCluster
 	}
 	defer resourceLogFile.Close()
 	resourceWriter := bufio.NewWriter(resourceLogFile)
+	defer resourceWriter.Flush()
 	dumpLog("kubectl get po -owide -n kube-system", resourceWriter)
 	dumpLog(fmt.Sprintf("kubectl get po -owide -n %s", operatorInfo.Namespace), resourceWriter)
+	dumpLog(fmt.Sprintf("kubectl get events -n %s", operatorInfo.Namespace), resourceWriter)
 	dumpLog("kubectl get pv", resourceWriter)
 	dumpLog("kubectl get pv -oyaml", resourceWriter)
 	dumpedNamespace := map[string]bool{}
@@ -36,6 +41,7 @@ func (oa *operatorActions) DumpAllLogs(operatorInfo *OperatorConfig, testCluster
 		if _, exist := dumpedNamespace[testCluster.Namespace]; !exist {
 			dumpLog(fmt.Sprintf("kubectl get po,pvc,svc,cm,cronjobs,jobs,statefulsets,tidbclusters -owide -n %s", testCluster.Namespace), resourceWriter)
 			dumpLog(fmt.Sprintf("kubectl get po,pvc,svc,cm,cronjobs,jobs,statefulsets,tidbclusters -n %s -oyaml", testCluster.Namespace), resourceWriter)
+			dumpLog(fmt.Sprintf("kubectl get events -n %s", testCluster.Namespace), resourceWriter)
 			dumpedNamespace[testCluster.Namespace] = true
 		}
 	}
@@ -73,6 +79,32 @@ func (oa *operatorActions) DumpAllLogs(operatorInfo *OperatorConfig, testCluster
 	return nil
 }
 
+// UploadLogBundle dumps the logs, events and resources of the operator and
+// the clusters, then uploads them as a tarball to the object storage by
+// rclone and returns the URL of the tarball
+func (oa *operatorActions) UploadLogBundle(operatorInfo *OperatorConfig, testClusters []*TidbClusterConfig) (string, error) {
+	upload := oa.cfg.LogUpload
+	if upload.Remote == "" {
+		return "", fmt.Errorf("the remote of the log bundles is not configured")
+	}
+
+	if err := oa.DumpAllLogs(operatorInfo, testClusters); err != nil {
+		glog.Warningf("failed to dump all logs, upload the dumped ones: %v", err)
+	}
+
+	name := fmt.Sprintf("operator-stability-%s.tar.gz", time.Now().UTC().Format("20060102T150405Z"))
+	bundle := filepath.Join(os.TempDir(), name)
+	defer os.Remove(bundle)
+	cmd := fmt.Sprintf("tar -czf %s -C %s operator-stability && rclone copyto %s %s/%s",
+		bundle, oa.cfg.LogDir, bundle, strings.TrimSuffix(upload.Remote, "/"), name)
+	glog.Info(cmd)
+	if res, err := exec.Command("/bin/sh", "-c", cmd).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("failed to upload the log bundle: %v, %s", err, string(res))
+	}
+
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(upload.URLPrefix, "/"), name), nil
+}
+
 func dumpPod(logPath string, pod *corev1.Pod) error {
 	logFile, err := os.Create(filepath.Join(logPath, fmt.Sprintf("%s-%s.log", pod.Name, pod.Namespace)))
 	if err != nil {
@@ -0,0 +1,83 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/pingcap/tidb-operator/tests/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// operatorMetricsPort is the port the controller-manager serves /metrics on
+	operatorMetricsPort = 6060
+
+	defaultMetricsInterval = 300
+	defaultMetricsMatch    = `{job="tidb-cluster"}`
+)
+
+// MetricsConfig defines how the metrics are exported during the tests
+type MetricsConfig struct {
+	// Interval is the interval in seconds between the snapshots, the
+	// metrics are not exported if it's 0
+	Interval int `yaml:"interval" json:"interval"`
+	// Match are the series selectors of the metrics federated from the
+	// Prometheus of the clusters
+	Match []string `yaml:"match" json:"match"`
+}
+
+// MetricsTargets returns the controller-manager pods of the operator and the
+// Prometheus of the monitored clusters as the targets of the metrics exporter
+func (oa *operatorActions) MetricsTargets(info *OperatorConfig, clusters []*TidbClusterConfig) []metrics.Target {
+	targets := make([]metrics.Target, 0)
+
+	pods, err := oa.kubeCli.CoreV1().Pods(info.Namespace).List(metav1.ListOptions{
+		LabelSelector: "app.kubernetes.io/component=controller-manager",
+	})
+	if err != nil {
+		glog.Warningf("failed to list the controller-manager pods: %v", err)
+	} else {
+		for _, pod := range pods.Items {
+			if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+				continue
+			}
+			targets = append(targets, metrics.Target{
+				Name: "operator-" + pod.Name,
+				URL:  fmt.Sprintf("http://%s:%d/metrics", pod.Status.PodIP, operatorMetricsPort),
+			})
+		}
+	}
+
+	match := oa.cfg.Metrics.Match
+	if len(match) == 0 {
+		match = []string{defaultMetricsMatch}
+	}
+	query := url.Values{"match[]": match}.Encode()
+	for _, tc := range clusters {
+		if !tc.Monitor {
+			continue
+		}
+		targets = append(targets, metrics.Target{
+			Name: strings.Replace(tc.FullName(), "/", "-", -1),
+			URL:  fmt.Sprintf("http://%s-prometheus.%s:9090/federate?%s", tc.ClusterName, tc.Namespace, query),
+		})
+	}
+
+	return targets
+}
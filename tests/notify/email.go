@@ -0,0 +1,71 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+)
+
+// EmailConfig defines the SMTP server and the recipients of the emails
+type EmailConfig struct {
+	Host     string   `yaml:"host" json:"host"`
+	Port     int      `yaml:"port" json:"port"`
+	Username string   `yaml:"username" json:"username"`
+	Password string   `yaml:"password" json:"password"`
+	From     string   `yaml:"from" json:"from"`
+	To       []string `yaml:"to" json:"to"`
+}
+
+type emailNotifier struct {
+	cfg EmailConfig
+}
+
+// NewEmailNotifier returns a notifier sending the messages by SMTP
+func NewEmailNotifier(cfg EmailConfig) Notifier {
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	if cfg.From == "" {
+		cfg.From = cfg.Username
+	}
+	return &emailNotifier{cfg: cfg}
+}
+
+func (en *emailNotifier) SendErrMsg(msg string) error {
+	return en.send("operator stability test failed", msg)
+}
+
+func (en *emailNotifier) SendGoodMsg(msg string) error {
+	return en.send("operator stability test succeeded", msg)
+}
+
+func (en *emailNotifier) SendWarnMsg(msg string) error {
+	return en.send("operator stability test happen warning", msg)
+}
+
+func (en *emailNotifier) send(subject, msg string) error {
+	var auth smtp.Auth
+	if en.cfg.Username != "" {
+		auth = smtp.PlainAuth("", en.cfg.Username, en.cfg.Password, en.cfg.Host)
+	}
+	addr := fmt.Sprintf("%s:%d", en.cfg.Host, en.cfg.Port)
+	return smtp.SendMail(addr, auth, en.cfg.From, en.cfg.To, buildEmail(en.cfg.From, en.cfg.To, subject, msg))
+}
+
+func buildEmail(from string, to []string, subject, msg string) []byte {
+	return []byte(fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		from, strings.Join(to, ", "), subject, msg))
+}
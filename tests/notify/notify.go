@@ -0,0 +1,159 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+var (
+	SuccessCount int
+
+	lock     sync.RWMutex
+	notifier Notifier = multiNotifier{}
+	// failureHook is called before a failure is notified
+	failureHook func(err error) string
+)
+
+// Notifier sends the messages of the stability tests to the maintainers
+type Notifier interface {
+	// SendErrMsg notifies that the tests failed
+	SendErrMsg(msg string) error
+	// SendGoodMsg notifies that the tests succeeded
+	SendGoodMsg(msg string) error
+	// SendWarnMsg notifies a warning happened in the tests
+	SendWarnMsg(msg string) error
+}
+
+// Config defines the notifiers, the messages are sent by all the configured notifiers
+type Config struct {
+	Slack     SlackConfig     `yaml:"slack" json:"slack"`
+	Webhook   WebhookConfig   `yaml:"webhook" json:"webhook"`
+	Email     EmailConfig     `yaml:"email" json:"email"`
+	PagerDuty PagerDutyConfig `yaml:"pagerduty" json:"pagerduty"`
+}
+
+// New returns a notifier sending the messages by all the configured notifiers
+func New(cfg Config) Notifier {
+	var ns multiNotifier
+	if cfg.Slack.WebhookURL != "" {
+		ns = append(ns, NewSlackNotifier(cfg.Slack))
+	}
+	if cfg.Webhook.URL != "" {
+		ns = append(ns, NewWebhookNotifier(cfg.Webhook))
+	}
+	if cfg.Email.Host != "" && len(cfg.Email.To) > 0 {
+		ns = append(ns, NewEmailNotifier(cfg.Email))
+	}
+	if cfg.PagerDuty.RoutingKey != "" {
+		ns = append(ns, NewPagerDutyNotifier(cfg.PagerDuty))
+	}
+	return ns
+}
+
+// SetNotifier sets the notifier used by the package level functions
+func SetNotifier(n Notifier) {
+	lock.Lock()
+	defer lock.Unlock()
+	notifier = n
+}
+
+// SetFailureHook sets the function called before a failure is notified, the
+// text it returns is appended to the failure message, e.g. the URL of the
+// log bundle collected on the failure
+func SetFailureHook(fn func(err error) string) {
+	lock.Lock()
+	defer lock.Unlock()
+	failureHook = fn
+}
+
+func getNotifier() Notifier {
+	lock.RLock()
+	defer lock.RUnlock()
+	return notifier
+}
+
+type multiNotifier []Notifier
+
+func (ns multiNotifier) SendErrMsg(msg string) error {
+	return ns.send(func(n Notifier) error { return n.SendErrMsg(msg) })
+}
+
+func (ns multiNotifier) SendGoodMsg(msg string) error {
+	return ns.send(func(n Notifier) error { return n.SendGoodMsg(msg) })
+}
+
+func (ns multiNotifier) SendWarnMsg(msg string) error {
+	return ns.send(func(n Notifier) error { return n.SendWarnMsg(msg) })
+}
+
+func (ns multiNotifier) send(fn func(n Notifier) error) error {
+	var errs []error
+	for _, n := range ns {
+		if err := fn(n); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func SendErrMsg(msg string) error {
+	return getNotifier().SendErrMsg(msg)
+}
+
+func SendGoodMsg(msg string) error {
+	return getNotifier().SendGoodMsg(msg)
+}
+
+func SendWarnMsg(msg string) error {
+	return getNotifier().SendWarnMsg(msg)
+}
+
+func NotifyAndPanic(err error) {
+	sendErr := SendErrMsg(failureMsg(err))
+	if sendErr != nil {
+		glog.Warningf("failed to notify the massage: %v,error: %v", err, sendErr)
+	}
+	time.Sleep(3 * time.Second)
+	panic(err)
+}
+
+func failureMsg(err error) string {
+	msg := fmt.Sprintf("Succeed %d times, then failed: %s", SuccessCount, err.Error())
+
+	lock.RLock()
+	hook := failureHook
+	lock.RUnlock()
+	if hook != nil {
+		if extra := hook(err); extra != "" {
+			msg = fmt.Sprintf("%s\n%s", msg, extra)
+		}
+	}
+
+	return msg
+}
+
+func NotifyAndCompletedf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	sendErr := SendGoodMsg(msg)
+	if sendErr != nil {
+		glog.Warningf("failed to notify the massage: %s,error: %v", msg, sendErr)
+	}
+	glog.Infof(msg)
+}
@@ -0,0 +1,89 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestNew(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(New(Config{})).To(HaveLen(0))
+	g.Expect(New(Config{
+		Slack:     SlackConfig{WebhookURL: "http://slack"},
+		Email:     EmailConfig{Host: "smtp.example.com"},
+		PagerDuty: PagerDutyConfig{RoutingKey: "key"},
+	})).To(HaveLen(2))
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var msg WebhookMessage
+	var auth string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&msg)
+	}))
+	defer ts.Close()
+
+	n := New(Config{Webhook: WebhookConfig{URL: ts.URL, Headers: map[string]string{"Authorization": "Bearer token"}}})
+	g.Expect(n.SendErrMsg("tikv failover failed")).NotTo(HaveOccurred())
+	g.Expect(msg.Level).To(Equal(levelError))
+	g.Expect(msg.Text).To(Equal("tikv failover failed"))
+	g.Expect(auth).To(Equal("Bearer token"))
+}
+
+func TestPagerDutyNotifier(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var events []pagerDutyEvent
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event pagerDutyEvent
+		json.NewDecoder(r.Body).Decode(&event)
+		events = append(events, event)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	n := NewPagerDutyNotifier(PagerDutyConfig{RoutingKey: "key", URL: ts.URL})
+	g.Expect(n.SendGoodMsg("succeeded")).NotTo(HaveOccurred())
+	g.Expect(n.SendErrMsg("failed")).NotTo(HaveOccurred())
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0].RoutingKey).To(Equal("key"))
+	g.Expect(events[0].Payload.Severity).To(Equal("critical"))
+	g.Expect(events[0].Payload.Summary).To(Equal("failed"))
+}
+
+func TestFailureMsg(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	err := errors.New("tikv failover failed")
+	g.Expect(failureMsg(err)).To(Equal("Succeed 0 times, then failed: tikv failover failed"))
+
+	SetFailureHook(func(err error) string {
+		return fmt.Sprintf("logs of [%v]: http://logs/bundle.tar.gz", err)
+	})
+	defer SetFailureHook(nil)
+	g.Expect(failureMsg(err)).To(Equal("Succeed 0 times, then failed: tikv failover failed\n" +
+		"logs of [tikv failover failed]: http://logs/bundle.tar.gz"))
+}
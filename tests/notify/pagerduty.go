@@ -0,0 +1,83 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import "os"
+
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDutyConfig defines the integration of the PagerDuty Events API v2
+type PagerDutyConfig struct {
+	RoutingKey string `yaml:"routing_key" json:"routing_key"`
+	// URL is the events API, defaults to the one of PagerDuty
+	URL string `yaml:"url" json:"url"`
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string           `json:"routing_key"`
+	EventAction string           `json:"event_action"`
+	Payload     pagerDutyPayload `json:"payload"`
+}
+
+type pagerDutyPayload struct {
+	Summary  string `json:"summary"`
+	Source   string `json:"source"`
+	Severity string `json:"severity"`
+}
+
+type pagerDutyNotifier struct {
+	cfg PagerDutyConfig
+}
+
+// NewPagerDutyNotifier returns a notifier triggering PagerDuty incidents,
+// only the failures and warnings are sent
+func NewPagerDutyNotifier(cfg PagerDutyConfig) Notifier {
+	if cfg.URL == "" {
+		cfg.URL = pagerDutyEventsURL
+	}
+	return &pagerDutyNotifier{cfg: cfg}
+}
+
+func (pn *pagerDutyNotifier) SendErrMsg(msg string) error {
+	return pn.trigger("critical", msg)
+}
+
+func (pn *pagerDutyNotifier) SendGoodMsg(msg string) error {
+	// nobody needs to be paged for the success
+	return nil
+}
+
+func (pn *pagerDutyNotifier) SendWarnMsg(msg string) error {
+	return pn.trigger("warning", msg)
+}
+
+func (pn *pagerDutyNotifier) trigger(severity, msg string) error {
+	source, _ := os.Hostname()
+	if source == "" {
+		source = "operator-stability-test"
+	}
+	// the summary of PagerDuty is limited to 1024 characters
+	if len(msg) > 1024 {
+		msg = msg[:1024]
+	}
+	return postJSON(pn.cfg.URL, nil, pagerDutyEvent{
+		RoutingKey:  pn.cfg.RoutingKey,
+		EventAction: "trigger",
+		Payload: pagerDutyPayload{
+			Summary:  msg,
+			Source:   source,
+			Severity: severity,
+		},
+	})
+}
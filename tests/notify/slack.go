@@ -1,20 +1,39 @@
-package slack
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
 
 import (
 	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"time"
-
-	"github.com/golang/glog"
 )
 
-var (
-	Channel      string
-	WebhookURL   string
-	SuccessCount int
-)
+// SlackConfig defines the incoming webhook of slack
+type SlackConfig struct {
+	WebhookURL string `yaml:"webhook_url" json:"webhook_url"`
+	Channel    string `yaml:"channel" json:"channel"`
+}
+
+type slackNotifier struct {
+	cfg SlackConfig
+}
+
+// NewSlackNotifier returns a notifier sending the messages to slack
+func NewSlackNotifier(cfg SlackConfig) Notifier {
+	return &slackNotifier{cfg: cfg}
+}
 
 type Field struct {
 	Title string `json:"title"`
@@ -88,38 +107,38 @@ func Send(webhookURL string, proxy string, payload Payload) error {
 	return nil
 }
 
-func SendErrMsg(msg string) error {
+func (sn *slackNotifier) SendErrMsg(msg string) error {
 	attachment := Attachment{
 		Title: "operator stability test failed",
 		Color: "fatal",
 	}
 	payload := Payload{
 		Username:    "operator-test",
-		Channel:     Channel,
+		Channel:     sn.cfg.Channel,
 		Text:        msg,
 		IconEmoji:   ":ghost:",
 		Attachments: []Attachment{attachment},
 	}
-	err := Send(WebhookURL, "", payload)
+	err := Send(sn.cfg.WebhookURL, "", payload)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func SendGoodMsg(msg string) error {
+func (sn *slackNotifier) SendGoodMsg(msg string) error {
 	attachment := Attachment{
 		Title: "operator stability test succeeded",
 		Color: "good",
 	}
 	payload := Payload{
 		Username:    "operator-test",
-		Channel:     Channel,
+		Channel:     sn.cfg.Channel,
 		Text:        msg,
 		IconEmoji:   ":sun_with_face:",
 		Attachments: []Attachment{attachment},
 	}
-	err := Send(WebhookURL, "", payload)
+	err := Send(sn.cfg.WebhookURL, "", payload)
 	if err != nil {
 		return err
 	}
@@ -127,39 +146,21 @@ func SendGoodMsg(msg string) error {
 	return nil
 }
 
-func SendWarnMsg(msg string) error {
+func (sn *slackNotifier) SendWarnMsg(msg string) error {
 	attachment := Attachment{
 		Title: "operator stability test happen warning",
 		Color: "warning",
 	}
 	payload := Payload{
 		Username:    "operator-test",
-		Channel:     Channel,
+		Channel:     sn.cfg.Channel,
 		Text:        msg,
 		IconEmoji:   ":imp:",
 		Attachments: []Attachment{attachment},
 	}
-	err := Send(WebhookURL, "", payload)
+	err := Send(sn.cfg.WebhookURL, "", payload)
 	if err != nil {
 		return err
 	}
 	return nil
 }
-
-func NotifyAndPanic(err error) {
-	sendErr := SendErrMsg(fmt.Sprintf("Succeed %d times, then failed: %s", SuccessCount, err.Error()))
-	if sendErr != nil {
-		glog.Warningf("failed to notify slack[%s] the massage: %v,error: %v", WebhookURL, err, sendErr)
-	}
-	time.Sleep(3 * time.Second)
-	panic(err)
-}
-
-func NotifyAndCompletedf(format string, args ...interface{}) {
-	msg := fmt.Sprintf(format, args...)
-	sendErr := SendGoodMsg(msg)
-	if sendErr != nil {
-		glog.Warningf("failed to notify slack[%s] the massage: %s,error: %v", WebhookURL, msg, sendErr)
-	}
-	glog.Infof(msg)
-}
@@ -0,0 +1,100 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	levelError   = "error"
+	levelGood    = "good"
+	levelWarning = "warning"
+)
+
+// WebhookConfig defines a generic webhook the messages are posted to
+type WebhookConfig struct {
+	URL string `yaml:"url" json:"url"`
+	// Headers are the extra headers of the requests, e.g. Authorization
+	Headers map[string]string `yaml:"headers" json:"headers"`
+}
+
+// WebhookMessage is the JSON body posted to the generic webhook
+type WebhookMessage struct {
+	// Level is one of error, good and warning
+	Level     string `json:"level"`
+	Title     string `json:"title"`
+	Text      string `json:"text"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+type webhookNotifier struct {
+	cfg WebhookConfig
+}
+
+// NewWebhookNotifier returns a notifier posting the messages to a generic webhook
+func NewWebhookNotifier(cfg WebhookConfig) Notifier {
+	return &webhookNotifier{cfg: cfg}
+}
+
+func (wn *webhookNotifier) SendErrMsg(msg string) error {
+	return wn.send(levelError, "operator stability test failed", msg)
+}
+
+func (wn *webhookNotifier) SendGoodMsg(msg string) error {
+	return wn.send(levelGood, "operator stability test succeeded", msg)
+}
+
+func (wn *webhookNotifier) SendWarnMsg(msg string) error {
+	return wn.send(levelWarning, "operator stability test happen warning", msg)
+}
+
+func (wn *webhookNotifier) send(level, title, msg string) error {
+	return postJSON(wn.cfg.URL, wn.cfg.Headers, WebhookMessage{
+		Level:     level,
+		Title:     title,
+		Text:      msg,
+		Timestamp: time.Now().Unix(),
+	})
+}
+
+func postJSON(url string, headers map[string]string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("error posting msg to %s, status: %v", url, resp.Status)
+	}
+	return nil
+}
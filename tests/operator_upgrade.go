@@ -0,0 +1,159 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/tests/notify"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// operatorRolloutTimeout is how long the operator deployments are given to
+// run the new image after the helm upgrade
+const operatorRolloutTimeout = 5 * time.Minute
+
+// clusterSnapshot records the revisions of the statefulsets and the UIDs of
+// the pods of a cluster, a statefulset is rolled if any of them changes
+type clusterSnapshot struct {
+	revisions map[string]string
+	podUIDs   map[string]types.UID
+}
+
+// CheckOperatorUpgrade upgrades the operator from one release to the other,
+// checks the existing clusters keep reconciling without rolling any of their
+// statefulsets, then downgrades the operator back and checks them again
+func (oa *operatorActions) CheckOperatorUpgrade(from, to *OperatorConfig, clusters []*TidbClusterConfig) error {
+	for _, step := range []struct {
+		name string
+		info *OperatorConfig
+	}{
+		{"upgrade", to},
+		{"downgrade", from},
+	} {
+		glog.Infof("%s tidb-operator to %s:%s", step.name, step.info.Image, step.info.Tag)
+		snapshots := map[string]*clusterSnapshot{}
+		for _, cluster := range clusters {
+			snapshot, err := oa.getClusterSnapshot(cluster)
+			if err != nil {
+				return err
+			}
+			snapshots[cluster.FullName()] = snapshot
+		}
+
+		if err := oa.UpgradeOperator(step.info); err != nil {
+			return err
+		}
+		if err := oa.checkOperatorRolledOut(step.info); err != nil {
+			return fmt.Errorf("failed to %s operator: %v", step.name, err)
+		}
+
+		for _, cluster := range clusters {
+			if err := oa.CheckTidbClusterStatus(cluster); err != nil {
+				return fmt.Errorf("cluster: [%s] is not reconciled after the operator %s: %v", cluster.FullName(), step.name, err)
+			}
+		}
+		// the statefulsets are rolled by the syncs after the upgrade if the
+		// new operator renders them differently, so they are watched for a
+		// period rather than checked once
+		if err := wait.Poll(oa.pollInterval, period, func() (bool, error) {
+			for _, cluster := range clusters {
+				snapshot, err := oa.getClusterSnapshot(cluster)
+				if err != nil {
+					glog.Errorf("failed to get the snapshot of cluster: [%s], error: %v", cluster.FullName(), err)
+					return false, nil
+				}
+				if err := snapshots[cluster.FullName()].diff(snapshot); err != nil {
+					return false, fmt.Errorf("cluster: [%s] is rolled by the operator %s: %v", cluster.FullName(), step.name, err)
+				}
+			}
+			return false, nil
+		}); err != wait.ErrWaitTimeout {
+			return err
+		}
+	}
+	return nil
+}
+
+func (oa *operatorActions) CheckOperatorUpgradeOrDie(from, to *OperatorConfig, clusters []*TidbClusterConfig) {
+	if err := oa.CheckOperatorUpgrade(from, to, clusters); err != nil {
+		notify.NotifyAndPanic(err)
+	}
+}
+
+// checkOperatorRolledOut waits for the operator deployments to be available
+// and run the image of the release
+func (oa *operatorActions) checkOperatorRolledOut(info *OperatorConfig) error {
+	return wait.Poll(10*time.Second, operatorRolloutTimeout, func() (bool, error) {
+		deploy, err := oa.kubeCli.AppsV1().Deployments(info.Namespace).Get(tidbControllerName, metav1.GetOptions{})
+		if err != nil {
+			glog.Errorf("failed to get deployment: %s, error: %v", tidbControllerName, err)
+			return false, nil
+		}
+		if deploy.Status.ObservedGeneration < deploy.Generation {
+			return false, nil
+		}
+		replicas := *deploy.Spec.Replicas
+		if deploy.Status.UpdatedReplicas != replicas || deploy.Status.AvailableReplicas != replicas ||
+			deploy.Status.Replicas != replicas {
+			glog.Infof("deployment: %s is rolling out, continue to wait", tidbControllerName)
+			return false, nil
+		}
+		for _, c := range deploy.Spec.Template.Spec.Containers {
+			if c.Image != info.Image {
+				glog.Infof("deployment: %s runs image %s rather than %s, continue to wait", tidbControllerName, c.Image, info.Image)
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+func (oa *operatorActions) getClusterSnapshot(info *TidbClusterConfig) (*clusterSnapshot, error) {
+	snapshot := &clusterSnapshot{revisions: map[string]string{}}
+	for _, setName := range []string{
+		controller.PDMemberName(info.ClusterName),
+		controller.TiKVMemberName(info.ClusterName),
+		controller.TiDBMemberName(info.ClusterName),
+	} {
+		set, err := oa.kubeCli.AppsV1().StatefulSets(info.Namespace).Get(setName, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		snapshot.revisions[setName] = set.Status.UpdateRevision
+	}
+	podUIDs, err := oa.GetPodUIDMap(info)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.podUIDs = podUIDs
+	return snapshot, nil
+}
+
+// diff returns an error describing the rolled statefulsets or recreated pods
+func (s *clusterSnapshot) diff(other *clusterSnapshot) error {
+	if !reflect.DeepEqual(s.revisions, other.revisions) {
+		return fmt.Errorf("the statefulset revisions are changed from %v to %v", s.revisions, other.revisions)
+	}
+	if !reflect.DeepEqual(s.podUIDs, other.podUIDs) {
+		return fmt.Errorf("the pods are recreated, uids are changed from %v to %v", s.podUIDs, other.podUIDs)
+	}
+	return nil
+}
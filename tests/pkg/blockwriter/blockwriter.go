@@ -39,6 +39,10 @@ type BlockWriterCase struct {
 	isRunning uint32
 	isInit    uint32
 	stopChan  chan struct{}
+	errChan   chan error
+
+	// committed is the number of rows written successfully to each table
+	committed []int64
 
 	cfg         Config
 	ClusterName string
@@ -52,6 +56,23 @@ type Config struct {
 	Concurrency int `yaml:"concurrency" json:"concurrency"`
 	BatchSize   int `yaml:"batch_size" json:"batch_size"`
 	RawSize     int `yaml:"raw_size" json:"raw_size"`
+	// ReadConcurrency is the number of workers running point and range reads
+	ReadConcurrency int `yaml:"read_concurrency" json:"read_concurrency"`
+	// CheckInterval is the interval in seconds to verify the written rows, 0 disables the check
+	CheckInterval int `yaml:"check_interval" json:"check_interval"`
+	// Columns are the extra columns of the tables besides the raw_bytes
+	Columns []Column `yaml:"columns,omitempty" json:"columns,omitempty"`
+	// Indexes are the secondary indexes of the tables
+	Indexes []Index `yaml:"indexes,omitempty" json:"indexes,omitempty"`
+	// RowSizes are the row size distributions assigned to the tables in turn,
+	// all the rows are of RawSize if empty
+	RowSizes []RowSize `yaml:"row_sizes,omitempty" json:"row_sizes,omitempty"`
+}
+
+type query struct {
+	table int
+	rows  int
+	sql   string
 }
 
 type blockWriter struct {
@@ -65,11 +86,13 @@ func NewBlockWriterCase(cfg Config) *BlockWriterCase {
 	c := &BlockWriterCase{
 		cfg:      cfg,
 		stopChan: make(chan struct{}, 1),
+		errChan:  make(chan error, 1),
 	}
 
 	if c.cfg.TableNum < 1 {
 		c.cfg.TableNum = 1
 	}
+	c.committed = make([]int64, c.cfg.TableNum)
 	c.initBlocks()
 
 	return c
@@ -94,7 +117,7 @@ func (c *BlockWriterCase) newBlockWriter() *blockWriter {
 	}
 }
 
-func (c *BlockWriterCase) generateQuery(ctx context.Context, queryChan chan []string, wg *sync.WaitGroup) {
+func (c *BlockWriterCase) generateQuery(ctx context.Context, queryChan chan []query, wg *sync.WaitGroup) {
 	defer func() {
 		glog.Infof("[%s] [%s] [action: generate Query] stopped", c, c.ClusterName)
 		wg.Done()
@@ -102,22 +125,20 @@ func (c *BlockWriterCase) generateQuery(ctx context.Context, queryChan chan []st
 
 	for {
 		tableN := rand.Intn(c.cfg.TableNum)
-		var index string
-		if tableN > 0 {
-			index = fmt.Sprintf("%d", tableN)
-		}
 
-		var querys []string
+		var querys []query
 		for i := 0; i < 100; i++ {
 			values := make([]string, c.cfg.BatchSize)
 			for i := 0; i < c.cfg.BatchSize; i++ {
-				blockData := util.RandString(c.cfg.RawSize)
-				values[i] = fmt.Sprintf("('%s')", blockData)
+				values[i] = c.rowValues(tableN)
 			}
 
-			querys = append(querys, fmt.Sprintf(
-				"INSERT INTO block_writer%s(raw_bytes) VALUES %s",
-				index, strings.Join(values, ",")))
+			querys = append(querys, query{
+				table: tableN,
+				rows:  c.cfg.BatchSize,
+				sql: fmt.Sprintf("INSERT INTO %s(%s) VALUES %s",
+					tableName(tableN), c.insertColumns(), strings.Join(values, ",")),
+			})
 		}
 
 		select {
@@ -142,7 +163,7 @@ func (bw *blockWriter) batchExecute(db *sql.DB, query string) error {
 	return nil
 }
 
-func (bw *blockWriter) run(ctx context.Context, db *sql.DB, queryChan chan []string) {
+func (bw *blockWriter) run(ctx context.Context, db *sql.DB, queryChan chan []query, committed []int64) {
 	defer glog.Infof("run stopped")
 	for {
 		select {
@@ -162,11 +183,12 @@ func (bw *blockWriter) run(ctx context.Context, db *sql.DB, queryChan chan []str
 			case <-ctx.Done():
 				return
 			default:
-				if err := bw.batchExecute(db, query); err != nil {
+				if err := bw.batchExecute(db, query.sql); err != nil {
 					glog.V(4).Info(err)
 					time.Sleep(5 * time.Second)
 					continue
 				}
+				atomic.AddInt64(&committed[query.table], int64(query.rows))
 			}
 		}
 	}
@@ -181,19 +203,12 @@ func (c *BlockWriterCase) initialize(db *sql.DB) error {
 	}()
 
 	for i := 0; i < c.cfg.TableNum; i++ {
-		var s string
-		if i > 0 {
-			s = fmt.Sprintf("%d", i)
+		tmt, err := c.createTableStmt(i)
+		if err != nil {
+			return err
 		}
 
-		tmt := fmt.Sprintf("CREATE TABLE IF NOT EXISTS block_writer%s %s", s, `
-	(
-      id BIGINT NOT NULL AUTO_INCREMENT,
-      raw_bytes BLOB NOT NULL,
-      PRIMARY KEY (id)
-)`)
-
-		err := wait.PollImmediate(5*time.Second, 30*time.Second, func() (bool, error) {
+		err = wait.PollImmediate(5*time.Second, 30*time.Second, func() (bool, error) {
 			_, err := db.Exec(tmt)
 			if err != nil {
 				glog.Warningf("[%s] exec sql [%s] failed, err: %v, retry...", c, tmt, err)
@@ -238,19 +253,36 @@ func (c *BlockWriterCase) Start(db *sql.DB) error {
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	queryChan := make(chan []string, queryChanSize)
+	queryChan := make(chan []query, queryChanSize)
 
 	for i := 0; i < c.cfg.Concurrency; i++ {
 		wg.Add(1)
 		go func(i int) {
 			defer wg.Done()
-			c.bws[i].run(ctx, db, queryChan)
+			c.bws[i].run(ctx, db, queryChan, c.committed)
 		}(i)
 	}
 
 	wg.Add(1)
 	go c.generateQuery(ctx, queryChan, &wg)
 
+	for i := 0; i < c.cfg.ReadConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.read(ctx, db)
+		}()
+	}
+
+	if c.cfg.CheckInterval > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.checkPeriodically(ctx, db)
+		}()
+	}
+
+	var err error
 loop:
 	for {
 		select {
@@ -258,6 +290,10 @@ loop:
 			glog.Infof("[%s] stoping...", c)
 			cancel()
 			break loop
+		case err = <-c.errChan:
+			glog.Errorf("[%s] [%s] stoping for inconsistent data, err: %v", c, c.ClusterName, err)
+			cancel()
+			break loop
 		default:
 			util.Sleep(context.Background(), 2*time.Second)
 		}
@@ -266,7 +302,7 @@ loop:
 	wg.Wait()
 	close(queryChan)
 
-	return nil
+	return err
 }
 
 // Stop stops cases
@@ -274,6 +310,13 @@ func (c *BlockWriterCase) Stop() {
 	c.stopChan <- struct{}{}
 }
 
+func tableName(n int) string {
+	if n > 0 {
+		return fmt.Sprintf("block_writer%d", n)
+	}
+	return "block_writer"
+}
+
 // String implements fmt.Stringer interface.
 func (c *BlockWriterCase) String() string {
 	return "block_writer"
@@ -0,0 +1,143 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blockwriter
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/crc32"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pingcap/tidb-operator/tests/pkg/util"
+)
+
+const rangeReadSize = 100
+
+// read runs point and range reads and verifies the checksum of each row read
+func (c *BlockWriterCase) read(ctx context.Context, db *sql.DB) {
+	defer glog.Infof("[%s] [%s] [action: read] stopped", c, c.ClusterName)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		table := tableName(rand.Intn(c.cfg.TableNum))
+		var maxID sql.NullInt64
+		if err := db.QueryRow(fmt.Sprintf("SELECT MAX(id) FROM %s", table)).Scan(&maxID); err != nil || !maxID.Valid {
+			glog.V(4).Infof("[%s] [%s] query max id of %s failed, err: %v", c, c.ClusterName, table, err)
+			util.Sleep(ctx, 5*time.Second)
+			continue
+		}
+
+		id := rand.Int63n(maxID.Int64) + 1
+		stmts := []string{
+			fmt.Sprintf("SELECT id, raw_bytes, raw_crc FROM %s WHERE id = %d", table, id),
+			fmt.Sprintf("SELECT id, raw_bytes, raw_crc FROM %s WHERE id >= %d LIMIT %d", table, id, rangeReadSize),
+		}
+		for _, stmt := range stmts {
+			if err := c.readRows(db, table, stmt); err != nil {
+				c.reportError(err)
+				return
+			}
+		}
+	}
+}
+
+// readRows returns an error only if a row read is corrupted, the failures
+// of the query are ignored as they are expected during the faults
+func (c *BlockWriterCase) readRows(db *sql.DB, table string, stmt string) error {
+	rows, err := db.Query(stmt)
+	if err != nil {
+		glog.V(4).Infof("exec sql [%s] failed, err: %v", stmt, err)
+		return nil
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var raw []byte
+		var crc uint32
+		if err := rows.Scan(&id, &raw, &crc); err != nil {
+			glog.V(4).Infof("scan the result of sql [%s] failed, err: %v", stmt, err)
+			return nil
+		}
+		if crc32.ChecksumIEEE(raw) != crc {
+			return fmt.Errorf("the checksum of row %d of %s mismatches", id, table)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		glog.V(4).Infof("read the result of sql [%s] failed, err: %v", stmt, err)
+	}
+
+	return nil
+}
+
+func (c *BlockWriterCase) checkPeriodically(ctx context.Context, db *sql.DB) {
+	defer glog.Infof("[%s] [%s] [action: check] stopped", c, c.ClusterName)
+
+	for {
+		util.Sleep(ctx, time.Duration(c.cfg.CheckInterval)*time.Second)
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		if err := c.Check(db); err != nil {
+			c.reportError(err)
+			return
+		}
+	}
+}
+
+// Check verifies that no row written successfully is lost and that the
+// checksums of all the rows match, the failures of the queries are ignored.
+func (c *BlockWriterCase) Check(db *sql.DB) error {
+	for i := 0; i < c.cfg.TableNum; i++ {
+		table := tableName(i)
+		// load the committed count before the query, so the rows counted
+		// are never less than it
+		committed := atomic.LoadInt64(&c.committed[i])
+
+		var count, corrupted int64
+		stmt := fmt.Sprintf("SELECT COUNT(*), COUNT(IF(CRC32(raw_bytes) <> raw_crc, 1, NULL)) FROM %s", table)
+		if err := db.QueryRow(stmt).Scan(&count, &corrupted); err != nil {
+			glog.Warningf("[%s] [%s] exec sql [%s] failed, err: %v", c, c.ClusterName, stmt, err)
+			continue
+		}
+		if count < committed {
+			return fmt.Errorf("%s has %d rows, but %d rows are written successfully", table, count, committed)
+		}
+		if corrupted > 0 {
+			return fmt.Errorf("the checksums of %d rows of %s mismatch", corrupted, table)
+		}
+		glog.V(4).Infof("[%s] [%s] %s has %d rows, %d rows are written successfully", c, c.ClusterName, table, count, committed)
+	}
+
+	return nil
+}
+
+func (c *BlockWriterCase) reportError(err error) {
+	select {
+	case c.errChan <- err:
+	default:
+	}
+}
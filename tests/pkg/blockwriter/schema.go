@@ -0,0 +1,165 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blockwriter
+
+import (
+	"fmt"
+	"hash/crc32"
+	"math/rand"
+	"strings"
+
+	"github.com/pingcap/tidb-operator/tests/pkg/util"
+)
+
+const (
+	ColumnTypeVarchar = "varchar"
+	ColumnTypeBlob    = "blob"
+	ColumnTypeDecimal = "decimal"
+	ColumnTypeJSON    = "json"
+
+	defaultVarcharSize = 255
+)
+
+// Column defines an extra column of the tables besides id, raw_bytes and raw_crc
+type Column struct {
+	Name string `yaml:"name" json:"name"`
+	// Type is one of varchar, blob, decimal and json
+	Type string `yaml:"type" json:"type"`
+	// Size is the max length of a varchar column, defaults to 255
+	Size int `yaml:"size" json:"size"`
+}
+
+// Index defines a secondary index of the tables
+type Index struct {
+	Name    string   `yaml:"name" json:"name"`
+	Columns []string `yaml:"columns" json:"columns"`
+	Unique  bool     `yaml:"unique" json:"unique"`
+}
+
+// RowSize defines the range of the raw_bytes size of the rows of a table,
+// the size of each row is distributed uniformly in [Min, Max]
+type RowSize struct {
+	Min int `yaml:"min" json:"min"`
+	Max int `yaml:"max" json:"max"`
+}
+
+func (col Column) definition() (string, error) {
+	switch col.Type {
+	case ColumnTypeVarchar:
+		return fmt.Sprintf("%s VARCHAR(%d) NOT NULL", col.Name, col.varcharSize()), nil
+	case ColumnTypeBlob:
+		return fmt.Sprintf("%s BLOB NOT NULL", col.Name), nil
+	case ColumnTypeDecimal:
+		return fmt.Sprintf("%s DECIMAL(20, 4) NOT NULL", col.Name), nil
+	case ColumnTypeJSON:
+		return fmt.Sprintf("%s JSON NOT NULL", col.Name), nil
+	default:
+		return "", fmt.Errorf("unsupported type %s of column %s", col.Type, col.Name)
+	}
+}
+
+func (col Column) varcharSize() int {
+	if col.Size > 0 {
+		return col.Size
+	}
+	return defaultVarcharSize
+}
+
+// value generates a random value of the column, rowSize is the size of the
+// raw_bytes of the row which the varchar and blob values follow
+func (col Column) value(rowSize int) string {
+	switch col.Type {
+	case ColumnTypeVarchar:
+		size := rowSize
+		if size > col.varcharSize() {
+			size = col.varcharSize()
+		}
+		return fmt.Sprintf("'%s'", util.RandString(size))
+	case ColumnTypeBlob:
+		return fmt.Sprintf("'%s'", util.RandString(rowSize))
+	case ColumnTypeDecimal:
+		return fmt.Sprintf("%d.%04d", rand.Int63n(1e15), rand.Intn(1e4))
+	case ColumnTypeJSON:
+		return fmt.Sprintf(`'{"id": %d, "name": "%s", "tags": ["%s", "%s"]}'`,
+			rand.Int63(), util.RandString(16), util.RandString(8), util.RandString(8))
+	default:
+		return "NULL"
+	}
+}
+
+func (idx Index) definition() string {
+	if idx.Unique {
+		return fmt.Sprintf("UNIQUE INDEX %s (%s)", idx.Name, strings.Join(idx.Columns, ", "))
+	}
+	return fmt.Sprintf("INDEX %s (%s)", idx.Name, strings.Join(idx.Columns, ", "))
+}
+
+// createTableStmt returns the DDL of the nth table
+func (c *BlockWriterCase) createTableStmt(n int) (string, error) {
+	defs := []string{
+		"id BIGINT NOT NULL AUTO_INCREMENT",
+		"raw_bytes BLOB NOT NULL",
+		"raw_crc BIGINT UNSIGNED NOT NULL",
+	}
+	for _, col := range c.cfg.Columns {
+		def, err := col.definition()
+		if err != nil {
+			return "", err
+		}
+		defs = append(defs, def)
+	}
+	defs = append(defs, "PRIMARY KEY (id)")
+	for _, idx := range c.cfg.Indexes {
+		defs = append(defs, idx.definition())
+	}
+
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n  %s\n)", tableName(n), strings.Join(defs, ",\n  ")), nil
+}
+
+// insertColumns returns the column list of the INSERT statements
+func (c *BlockWriterCase) insertColumns() string {
+	cols := []string{"raw_bytes", "raw_crc"}
+	for _, col := range c.cfg.Columns {
+		cols = append(cols, col.Name)
+	}
+	return strings.Join(cols, ", ")
+}
+
+// rowValues generates the values of a row of the nth table
+func (c *BlockWriterCase) rowValues(n int) string {
+	size := c.rowSize(n)
+	blockData := util.RandString(size)
+	values := []string{
+		fmt.Sprintf("'%s'", blockData),
+		fmt.Sprintf("%d", crc32.ChecksumIEEE([]byte(blockData))),
+	}
+	for _, col := range c.cfg.Columns {
+		values = append(values, col.value(size))
+	}
+	return fmt.Sprintf("(%s)", strings.Join(values, ", "))
+}
+
+// rowSize returns the raw_bytes size of a row of the nth table, the row size
+// distributions are assigned to the tables in turn
+func (c *BlockWriterCase) rowSize(n int) int {
+	if len(c.cfg.RowSizes) == 0 {
+		return c.cfg.RawSize
+	}
+
+	rs := c.cfg.RowSizes[n%len(c.cfg.RowSizes)]
+	if rs.Max <= rs.Min {
+		return rs.Min
+	}
+	return rs.Min + rand.Intn(rs.Max-rs.Min+1)
+}
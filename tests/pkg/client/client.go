@@ -6,7 +6,7 @@ import (
 	"os"
 	"time"
 
-	"github.com/pingcap/tidb-operator/tests/slack"
+	"github.com/pingcap/tidb-operator/tests/notify"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
@@ -32,7 +32,7 @@ func init() {
 func NewCliOrDie() (versioned.Interface, kubernetes.Interface) {
 	cfg, err := GetConfig()
 	if err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 
 	return buildClientsOrDie(cfg)
@@ -55,6 +55,36 @@ func GetConfig() (*rest.Config, error) {
 	return nil, fmt.Errorf("could not locate a kubeconfig")
 }
 
+// NewCliForContextOrDie creates the clients of the cluster of a context in the
+// kubeconfig, the kubeconfig and context the tests run with are used if empty.
+func NewCliForContextOrDie(kubeconfig, context string) (versioned.Interface, kubernetes.Interface) {
+	cfg, err := GetConfigForContext(kubeconfig, context)
+	if err != nil {
+		notify.NotifyAndPanic(err)
+	}
+
+	return buildClientsOrDie(cfg)
+}
+
+func GetConfigForContext(kubeconfig, context string) (*rest.Config, error) {
+	if kubeconfig == "" && context == "" {
+		return GetConfig()
+	}
+	if kubeconfig == "" {
+		kubeconfig = kubeconfigPath
+	}
+	if kubeconfig == "" {
+		kubeconfig = os.Getenv("KUBECONFIG")
+	}
+	if kubeconfig == "" {
+		return nil, fmt.Errorf("could not locate a kubeconfig for context %s", context)
+	}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfig},
+		&clientcmd.ConfigOverrides{CurrentContext: context},
+	).ClientConfig()
+}
+
 type Client interface {
 	kubernetes.Interface
 	PingcapV1alpha1() v1alpha1.PingcapV1alpha1Interface
@@ -67,7 +97,7 @@ func Union(kube kubernetes.Interface, tidb versioned.Interface) Client {
 func NewOrDie() Client {
 	cfg, err := clientcmd.BuildConfigFromFlags(masterUrl, kubeconfigPath)
 	if err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 	return Union(kubernetes.NewForConfigOrDie(cfg), versioned.NewForConfigOrDie(cfg))
 }
@@ -98,12 +128,12 @@ func buildClientsOrDie(cfg *rest.Config) (versioned.Interface, kubernetes.Interf
 	cfg.Timeout = 30 * time.Second
 	cli, err := versioned.NewForConfig(cfg)
 	if err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 
 	kubeCli, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 
 	return cli, kubeCli
@@ -0,0 +1,380 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package control reports the progress of the running stability tests and
+// steers them by HTTP, so the multi-day runs can be inspected and controlled
+// without access to the logs.
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pingcap/tidb-operator/tests/pkg/report"
+)
+
+const (
+	// ResultSkipped is the result of the cases skipped by the skip command
+	ResultSkipped = "skipped"
+	// ResultAborted is the result of the runs aborted by the abort command
+	ResultAborted = "aborted"
+
+	maxCases  = 200
+	maxFaults = 50
+	maxRuns   = 100
+)
+
+// ErrAborted is the panic value the run is aborted with, it should be
+// recovered by the caller of the run
+var ErrAborted = errors.New("aborted by the abort command")
+
+// Fault is a fault injected by the tests
+type Fault struct {
+	Time        time.Time `json:"time"`
+	Case        string    `json:"case"`
+	Description string    `json:"description"`
+}
+
+// Run is the result of a run of the tests
+type Run struct {
+	ID      int       `json:"id"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Result  string    `json:"result"`
+	Failure string    `json:"failure,omitempty"`
+}
+
+// Status is the progress of the tests
+type Status struct {
+	Run         int           `json:"run"`
+	RunStart    time.Time     `json:"runStart"`
+	RunElapsed  string        `json:"runElapsed"`
+	Case        string        `json:"case"`
+	CaseStart   time.Time     `json:"caseStart"`
+	CaseElapsed string        `json:"caseElapsed"`
+	Paused      bool          `json:"paused"`
+	SkipNext    bool          `json:"skipNext"`
+	Aborting    bool          `json:"aborting"`
+	Faults      []Fault       `json:"faults"`
+	Cases       []report.Case `json:"cases"`
+	Runs        []Run         `json:"runs"`
+}
+
+// Controller tracks the progress of the tests and holds the commands, the
+// commands take effect when the next case starts, the running case is never
+// interrupted
+type Controller struct {
+	lock      sync.Mutex
+	cond      *sync.Cond
+	run       int
+	runStart  time.Time
+	current   string
+	caseStart time.Time
+	paused    bool
+	skipNext  bool
+	aborting  bool
+	faults    []Fault
+	cases     []report.Case
+	runs      []Run
+	now       func() time.Time
+}
+
+// New returns a Controller
+func New() *Controller {
+	c := &Controller{now: time.Now}
+	c.cond = sync.NewCond(&c.lock)
+	return c
+}
+
+// StartRun starts a new run, the abort command of the last run is cleared
+func (c *Controller) StartRun() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.run++
+	c.runStart = c.now()
+	c.current = ""
+	c.aborting = false
+}
+
+// EndRun records the result of the run by the value recovered from it
+func (c *Controller) EndRun(recovered interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	run := Run{ID: c.run, Start: c.runStart, End: c.now(), Result: report.ResultPassed}
+	if recovered == ErrAborted {
+		run.Result = ResultAborted
+	} else if recovered != nil {
+		run.Result = report.ResultFailed
+		run.Failure = fmt.Sprint(recovered)
+	}
+	c.runs = appendRun(c.runs, run)
+	c.current = ""
+}
+
+// StartCase is called before a case starts, it blocks while the tests are
+// paused, returns false if the case should be skipped, and panics with
+// ErrAborted if the run is aborted
+func (c *Controller) StartCase(name string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.paused && !c.aborting {
+		glog.Infof("the tests are paused before case %s", name)
+	}
+	for c.paused && !c.aborting {
+		c.cond.Wait()
+	}
+	if c.aborting {
+		glog.Infof("the run is aborted before case %s", name)
+		panic(ErrAborted)
+	}
+	if c.skipNext {
+		c.skipNext = false
+		glog.Infof("case %s is skipped", name)
+		c.cases = appendCase(c.cases, report.Case{Name: name, Start: c.now(), Result: ResultSkipped})
+		return false
+	}
+	c.current = name
+	c.caseStart = c.now()
+	return true
+}
+
+// SetCase records the started case without checking the commands, it's for
+// the cases which can't be paused or skipped, e.g. the preparation of the run
+func (c *Controller) SetCase(name string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.current = name
+	c.caseStart = c.now()
+}
+
+// EndCase records the result of an ended case
+func (c *Controller) EndCase(result report.Case) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.cases = appendCase(c.cases, result)
+	if c.current == result.Name {
+		c.current = ""
+	}
+}
+
+// RecordFault records a fault injected by the current case
+func (c *Controller) RecordFault(description string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.faults = append(c.faults, Fault{Time: c.now(), Case: c.current, Description: description})
+	if len(c.faults) > maxFaults {
+		c.faults = c.faults[len(c.faults)-maxFaults:]
+	}
+}
+
+// Pause pauses the tests before the next case starts
+func (c *Controller) Pause() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.paused = true
+}
+
+// Resume resumes the paused tests
+func (c *Controller) Resume() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.paused = false
+	c.cond.Broadcast()
+}
+
+// Skip skips the next case
+func (c *Controller) Skip() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.skipNext = true
+}
+
+// Abort aborts the run before the next case starts, the paused run is
+// aborted immediately
+func (c *Controller) Abort() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.aborting = true
+	c.cond.Broadcast()
+}
+
+// Status returns the progress of the tests
+func (c *Controller) Status() Status {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.now()
+	status := Status{
+		Run:      c.run,
+		RunStart: c.runStart,
+		Case:     c.current,
+		Paused:   c.paused,
+		SkipNext: c.skipNext,
+		Aborting: c.aborting,
+		Faults:   append([]Fault{}, c.faults...),
+		Cases:    append([]report.Case{}, c.cases...),
+		Runs:     append([]Run{}, c.runs...),
+	}
+	if c.run > 0 {
+		status.RunElapsed = now.Sub(c.runStart).Round(time.Second).String()
+	}
+	if c.current != "" {
+		status.CaseStart = c.caseStart
+		status.CaseElapsed = now.Sub(c.caseStart).Round(time.Second).String()
+	}
+	return status
+}
+
+// Handler returns the HTTP handler of the dashboard and the API:
+//
+//	GET  /             the dashboard
+//	GET  /api/status   the progress of the tests
+//	POST /api/pause    pause the tests before the next case
+//	POST /api/resume   resume the paused tests
+//	POST /api/skip     skip the next case
+//	POST /api/abort    abort the run before the next case
+func (c *Controller) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, dashboardHTML)
+	})
+	mux.HandleFunc("/api/status", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(c.Status()); err != nil {
+			glog.Errorf("failed to write the status: %v", err)
+		}
+	})
+	for path, command := range map[string]func(){
+		"/api/pause":  c.Pause,
+		"/api/resume": c.Resume,
+		"/api/skip":   c.Skip,
+		"/api/abort":  c.Abort,
+	} {
+		path, command := path, command
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			glog.Infof("received command %s from %s", path, r.RemoteAddr)
+			command()
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+	return mux
+}
+
+func appendCase(cases []report.Case, c report.Case) []report.Case {
+	cases = append(cases, c)
+	if len(cases) > maxCases {
+		cases = cases[len(cases)-maxCases:]
+	}
+	return cases
+}
+
+func appendRun(runs []Run, run Run) []Run {
+	runs = append(runs, run)
+	if len(runs) > maxRuns {
+		runs = runs[len(runs)-maxRuns:]
+	}
+	return runs
+}
+
+const dashboardHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>tidb-operator stability</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+td, th { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+.passed { color: green; } .failed { color: red; } .skipped, .aborted { color: gray; }
+</style>
+</head>
+<body>
+<h1>tidb-operator stability</h1>
+<p id="progress"></p>
+<p>
+<button onclick="command('pause')">Pause</button>
+<button onclick="command('resume')">Resume</button>
+<button onclick="command('skip')">Skip next case</button>
+<button onclick="if (confirm('Abort the run?')) command('abort')">Abort run</button>
+</p>
+<h2>Recent faults</h2>
+<table id="faults"></table>
+<h2>Cases</h2>
+<table id="cases"></table>
+<h2>Runs</h2>
+<table id="runs"></table>
+<script>
+function esc(s) {
+  return String(s === undefined ? '' : s).replace(/[&<>"]/g, function (c) {
+    return {'&': '&amp;', '<': '&lt;', '>': '&gt;', '"': '&quot;'}[c];
+  });
+}
+function rows(id, header, items, row) {
+  var html = '<tr>' + header.map(function (h) { return '<th>' + h + '</th>'; }).join('') + '</tr>';
+  items.slice().reverse().forEach(function (item) { html += '<tr>' + row(item) + '</tr>'; });
+  document.getElementById(id).innerHTML = html;
+}
+function command(name) {
+  fetch('api/' + name, {method: 'POST'}).then(refresh);
+}
+function refresh() {
+  fetch('api/status').then(function (res) { return res.json(); }).then(function (s) {
+    var flags = [];
+    if (s.paused) flags.push('paused');
+    if (s.skipNext) flags.push('skipping the next case');
+    if (s.aborting) flags.push('aborting');
+    document.getElementById('progress').innerHTML = 'Run ' + s.run + ' (' + esc(s.runElapsed) + '), case ' +
+      esc(s.case || '-') + ' (' + esc(s.caseElapsed) + ')' + (flags.length ? ', ' + flags.join(', ') : '');
+    rows('faults', ['Time', 'Case', 'Fault'], s.faults || [], function (f) {
+      return '<td>' + esc(f.time) + '</td><td>' + esc(f.case) + '</td><td>' + esc(f.description) + '</td>';
+    });
+    rows('cases', ['Case', 'Start', 'Duration (s)', 'Result', 'Failure'], s.cases || [], function (c) {
+      return '<td>' + esc(c.name) + '</td><td>' + esc(c.start) + '</td><td>' + (c.duration / 1e9).toFixed(0) +
+        '</td><td class="' + esc(c.result) + '">' + esc(c.result) + '</td><td>' + esc(c.failure) + '</td>';
+    });
+    rows('runs', ['Run', 'Start', 'End', 'Result', 'Failure'], s.runs || [], function (r) {
+      return '<td>' + r.id + '</td><td>' + esc(r.start) + '</td><td>' + esc(r.end) +
+        '</td><td class="' + esc(r.result) + '">' + esc(r.result) + '</td><td>' + esc(r.failure) + '</td>';
+    });
+  });
+}
+refresh();
+setInterval(refresh, 10000);
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,113 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package control
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/tests/pkg/report"
+)
+
+func TestControllerSkipAndAbort(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c := New()
+	c.StartRun()
+	g.Expect(c.StartCase("deploy")).To(BeTrue())
+	c.RecordFault("stop node")
+	c.EndCase(report.Case{Name: "deploy", Result: report.ResultPassed})
+
+	c.Skip()
+	g.Expect(c.StartCase("scale out")).To(BeFalse())
+	g.Expect(c.StartCase("scale in")).To(BeTrue())
+
+	c.Abort()
+	func() {
+		defer func() {
+			e := recover()
+			c.EndRun(e)
+			g.Expect(e).To(Equal(ErrAborted))
+		}()
+		c.StartCase("upgrade")
+	}()
+
+	status := c.Status()
+	g.Expect(status.Faults).To(HaveLen(1))
+	g.Expect(status.Faults[0].Case).To(Equal("deploy"))
+	g.Expect(status.Cases).To(HaveLen(2))
+	g.Expect(status.Cases[1].Name).To(Equal("scale out"))
+	g.Expect(status.Cases[1].Result).To(Equal(ResultSkipped))
+	g.Expect(status.Runs).To(HaveLen(1))
+	g.Expect(status.Runs[0].Result).To(Equal(ResultAborted))
+
+	// the abort command only applies to the run it's sent to
+	c.StartRun()
+	g.Expect(c.StartCase("deploy")).To(BeTrue())
+	c.EndRun(nil)
+	g.Expect(c.Status().Runs[1].Result).To(Equal(report.ResultPassed))
+}
+
+func TestControllerPause(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c := New()
+	c.StartRun()
+	c.Pause()
+
+	started := make(chan bool)
+	go func() {
+		started <- c.StartCase("deploy")
+	}()
+	g.Consistently(started, 100*time.Millisecond).ShouldNot(Receive())
+
+	c.Resume()
+	g.Eventually(started).Should(Receive(BeTrue()))
+	g.Expect(c.Status().Case).To(Equal("deploy"))
+}
+
+func TestHandler(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c := New()
+	c.StartRun()
+	c.StartCase("deploy")
+	server := httptest.NewServer(c.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/pause")
+	g.Expect(err).NotTo(HaveOccurred())
+	resp.Body.Close()
+	g.Expect(resp.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+
+	for _, command := range []string{"pause", "skip"} {
+		resp, err := http.Post(server.URL+"/api/"+command, "", nil)
+		g.Expect(err).NotTo(HaveOccurred())
+		resp.Body.Close()
+		g.Expect(resp.StatusCode).To(Equal(http.StatusNoContent))
+	}
+
+	resp, err = http.Get(server.URL + "/api/status")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer resp.Body.Close()
+	var status Status
+	g.Expect(json.NewDecoder(resp.Body).Decode(&status)).To(Succeed())
+	g.Expect(status.Case).To(Equal("deploy"))
+	g.Expect(status.Paused).To(BeTrue())
+	g.Expect(status.SkipNext).To(BeTrue())
+}
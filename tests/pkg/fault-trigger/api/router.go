@@ -51,5 +51,26 @@ func (s *Server) newService() *restful.WebService {
 	ws.Route(ws.POST(fmt.Sprintf("/%s/start", manager.KubeControllerManagerService)).To(s.startKubeControllerManager))
 	ws.Route(ws.POST(fmt.Sprintf("/%s/stop", manager.KubeControllerManagerService)).To(s.stopKubeControllerManager))
 
+	ws.Route(ws.POST(fmt.Sprintf("/%s/start", manager.KubeProxyService)).To(s.startKubeProxy))
+	ws.Route(ws.POST(fmt.Sprintf("/%s/stop", manager.KubeProxyService)).To(s.stopKubeProxy))
+
+	ws.Route(ws.POST("/disk/throttle/start").To(s.startDiskThrottle))
+	ws.Route(ws.POST("/disk/throttle/stop").To(s.stopDiskThrottle))
+
+	ws.Route(ws.POST("/disk/fill/start").To(s.startDiskFill))
+	ws.Route(ws.POST("/disk/fill/stop").To(s.stopDiskFill))
+
+	ws.Route(ws.POST("/stress/start").To(s.startStress))
+	ws.Route(ws.POST("/stress/stop").To(s.stopStress))
+
+	ws.Route(ws.POST("/clock/skew/start").To(s.startClockSkew))
+	ws.Route(ws.POST("/clock/skew/stop").To(s.stopClockSkew))
+
+	ws.Route(ws.POST("/dns/failure/start").To(s.startDNSFailure))
+	ws.Route(ws.POST("/dns/failure/stop").To(s.stopDNSFailure))
+
+	ws.Route(ws.POST("/network/shaping/start").To(s.startNetworkShaping))
+	ws.Route(ws.POST("/network/shaping/stop").To(s.stopNetworkShaping))
+
 	return ws
 }
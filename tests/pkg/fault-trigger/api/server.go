@@ -153,6 +153,89 @@ func (s *Server) stopKubeControllerManager(req *restful.Request, resp *restful.R
 	s.action(req, resp, s.mgr.StopKubeControllerManager, "stopKubeControllerManager")
 }
 
+func (s *Server) startKubeProxy(req *restful.Request, resp *restful.Response) {
+	s.action(req, resp, s.mgr.StartKubeProxy, "startKubeProxy")
+}
+
+func (s *Server) stopKubeProxy(req *restful.Request, resp *restful.Response) {
+	s.action(req, resp, s.mgr.StopKubeProxy, "stopKubeProxy")
+}
+
+func (s *Server) startDiskThrottle(req *restful.Request, resp *restful.Response) {
+	throttle := &manager.DiskThrottle{}
+	s.entityAction(req, resp, throttle, func() error {
+		return s.mgr.StartDiskThrottle(throttle)
+	}, "startDiskThrottle")
+}
+
+func (s *Server) stopDiskThrottle(req *restful.Request, resp *restful.Response) {
+	throttle := &manager.DiskThrottle{}
+	s.entityAction(req, resp, throttle, func() error {
+		return s.mgr.StopDiskThrottle(throttle.Device)
+	}, "stopDiskThrottle")
+}
+
+func (s *Server) startDiskFill(req *restful.Request, resp *restful.Response) {
+	fill := &manager.DiskFill{}
+	s.entityAction(req, resp, fill, func() error {
+		return s.mgr.StartDiskFill(fill)
+	}, "startDiskFill")
+}
+
+func (s *Server) stopDiskFill(req *restful.Request, resp *restful.Response) {
+	fill := &manager.DiskFill{}
+	s.entityAction(req, resp, fill, func() error {
+		return s.mgr.StopDiskFill(fill.Path)
+	}, "stopDiskFill")
+}
+
+func (s *Server) startStress(req *restful.Request, resp *restful.Response) {
+	stress := &manager.Stress{}
+	s.entityAction(req, resp, stress, func() error {
+		return s.mgr.StartStress(stress)
+	}, "startStress")
+}
+
+func (s *Server) stopStress(req *restful.Request, resp *restful.Response) {
+	s.action(req, resp, s.mgr.StopStress, "stopStress")
+}
+
+func (s *Server) startClockSkew(req *restful.Request, resp *restful.Response) {
+	skew := &manager.ClockSkew{}
+	s.entityAction(req, resp, skew, func() error {
+		return s.mgr.StartClockSkew(skew)
+	}, "startClockSkew")
+}
+
+func (s *Server) stopClockSkew(req *restful.Request, resp *restful.Response) {
+	s.action(req, resp, s.mgr.StopClockSkew, "stopClockSkew")
+}
+
+func (s *Server) startDNSFailure(req *restful.Request, resp *restful.Response) {
+	failure := &manager.DNSFailure{}
+	s.entityAction(req, resp, failure, func() error {
+		return s.mgr.StartDNSFailure(failure)
+	}, "startDNSFailure")
+}
+
+func (s *Server) stopDNSFailure(req *restful.Request, resp *restful.Response) {
+	s.action(req, resp, s.mgr.StopDNSFailure, "stopDNSFailure")
+}
+
+func (s *Server) startNetworkShaping(req *restful.Request, resp *restful.Response) {
+	shaping := &manager.NetworkShaping{}
+	s.entityAction(req, resp, shaping, func() error {
+		return s.mgr.StartNetworkShaping(shaping)
+	}, "startNetworkShaping")
+}
+
+func (s *Server) stopNetworkShaping(req *restful.Request, resp *restful.Response) {
+	shaping := &manager.NetworkShaping{}
+	s.entityAction(req, resp, shaping, func() error {
+		return s.mgr.StopNetworkShaping(shaping.Device)
+	}, "stopNetworkShaping")
+}
+
 func (s *Server) action(
 	req *restful.Request,
 	resp *restful.Response,
@@ -177,6 +260,27 @@ func (s *Server) action(
 	}
 }
 
+// entityAction reads the request body into entity before invoking fn
+func (s *Server) entityAction(
+	req *restful.Request,
+	resp *restful.Response,
+	entity interface{},
+	fn func() error,
+	method string,
+) {
+	if err := req.ReadEntity(entity); err != nil {
+		res := newResponse(method)
+		res.message(fmt.Sprintf("failed to read request body, error: %v", err)).
+			statusCode(http.StatusBadRequest)
+		if err = resp.WriteEntity(res); err != nil {
+			glog.Errorf("failed to response, methods: %s, error: %v", method, err)
+		}
+		return
+	}
+
+	s.action(req, resp, fn, method)
+}
+
 func (s *Server) vmAction(
 	req *restful.Request,
 	resp *restful.Response,
@@ -41,6 +41,35 @@ type Client interface {
 	StartKubeControllerManager() error
 	// StopKubeControllerManager stops the kube-controller-manager service
 	StopKubeControllerManager() error
+	// StartKubeProxy resumes the kube-proxy on the node
+	StartKubeProxy() error
+	// StopKubeProxy stops the kube-proxy on the node
+	StopKubeProxy() error
+	// StartDiskThrottle throttles the IOPS of a disk device of the pods
+	StartDiskThrottle(throttle *manager.DiskThrottle) error
+	// StopDiskThrottle removes the IOPS throttling of a disk device
+	StopDiskThrottle(device string) error
+	// StartDiskFill fills the disk of a path to the target used percentage
+	StartDiskFill(fill *manager.DiskFill) error
+	// StopDiskFill releases the space filled on the disk of a path
+	StopDiskFill(path string) error
+	// StartStress starts the CPU and memory stress on the node
+	StartStress(stress *manager.Stress) error
+	// StopStress stops the CPU and memory stress on the node
+	StopStress() error
+	// StartClockSkew shifts the clock of the node by an offset
+	StartClockSkew(skew *manager.ClockSkew) error
+	// StopClockSkew restores the clock of the node by NTP
+	StopClockSkew() error
+	// StartDNSFailure drops the DNS queries of the node for a duration
+	StartDNSFailure(failure *manager.DNSFailure) error
+	// StopDNSFailure recovers the DNS queries of the node
+	StopDNSFailure() error
+	// StartNetworkShaping degrades the links from the node to the target nodes
+	StartNetworkShaping(shaping *manager.NetworkShaping) error
+	// StopNetworkShaping recovers the links of a network device, the empty
+	// device means the one shaped by StartNetworkShaping
+	StopNetworkShaping(device string) error
 }
 
 // client is used to communicate with the fault-trigger
@@ -230,6 +259,14 @@ func (c *client) StopKubeControllerManager() error {
 	return c.stopService(manager.KubeControllerManagerService)
 }
 
+func (c *client) StartKubeProxy() error {
+	return c.startService(manager.KubeProxyService)
+}
+
+func (c *client) StopKubeProxy() error {
+	return c.stopService(manager.KubeProxyService)
+}
+
 func (c *client) startService(serviceName string) error {
 	url := util.GenURL(fmt.Sprintf("%s%s/%s/start", c.cfg.Addr, api.APIPrefix, serviceName))
 	if _, err := c.post(url, nil); err != nil {
@@ -249,3 +286,93 @@ func (c *client) stopService(serviceName string) error {
 
 	return nil
 }
+
+func (c *client) StartDiskThrottle(throttle *manager.DiskThrottle) error {
+	if err := throttle.Verify(); err != nil {
+		return err
+	}
+
+	return c.postEntity("disk/throttle/start", throttle)
+}
+
+func (c *client) StopDiskThrottle(device string) error {
+	return c.postEntity("disk/throttle/stop", &manager.DiskThrottle{Device: device})
+}
+
+func (c *client) StartDiskFill(fill *manager.DiskFill) error {
+	if err := fill.Verify(); err != nil {
+		return err
+	}
+
+	return c.postEntity("disk/fill/start", fill)
+}
+
+func (c *client) StopDiskFill(path string) error {
+	return c.postEntity("disk/fill/stop", &manager.DiskFill{Path: path})
+}
+
+func (c *client) StartStress(stress *manager.Stress) error {
+	if err := stress.Verify(); err != nil {
+		return err
+	}
+
+	return c.postEntity("stress/start", stress)
+}
+
+func (c *client) StopStress() error {
+	return c.postEntity("stress/stop", nil)
+}
+
+func (c *client) StartClockSkew(skew *manager.ClockSkew) error {
+	if err := skew.Verify(); err != nil {
+		return err
+	}
+
+	return c.postEntity("clock/skew/start", skew)
+}
+
+func (c *client) StopClockSkew() error {
+	return c.postEntity("clock/skew/stop", nil)
+}
+
+func (c *client) StartDNSFailure(failure *manager.DNSFailure) error {
+	if err := failure.Verify(); err != nil {
+		return err
+	}
+
+	return c.postEntity("dns/failure/start", failure)
+}
+
+func (c *client) StopDNSFailure() error {
+	return c.postEntity("dns/failure/stop", nil)
+}
+
+func (c *client) StartNetworkShaping(shaping *manager.NetworkShaping) error {
+	if err := shaping.Verify(); err != nil {
+		return err
+	}
+
+	return c.postEntity("network/shaping/start", shaping)
+}
+
+func (c *client) StopNetworkShaping(device string) error {
+	return c.postEntity("network/shaping/stop", &manager.NetworkShaping{Device: device})
+}
+
+func (c *client) postEntity(path string, entity interface{}) error {
+	var data []byte
+	if entity != nil {
+		var err error
+		if data, err = json.Marshal(entity); err != nil {
+			return err
+		}
+	}
+
+	url := util.GenURL(fmt.Sprintf("%s%s/%s", c.cfg.Addr, api.APIPrefix, path))
+	if _, err := c.post(url, data); err != nil {
+		glog.Errorf("failed to post %s: %v", url, err)
+		return err
+	}
+
+	return nil
+}
@@ -136,3 +136,220 @@ func TestStartAndStopService(t *testing.T) {
 	err = cli.stopService(manager.ETCDService)
 	g.Expect(err).NotTo(HaveOccurred())
 }
+
+func TestControlPlaneServices(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	resp := &api.Response{
+		Action:     "startKubeProxy",
+		StatusCode: 200,
+		Message:    "OK",
+	}
+
+	var paths []string
+	respJSON, _ := json.Marshal(resp)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		fmt.Fprintln(w, string(respJSON))
+	}))
+	defer ts.Close()
+
+	cli := NewClient(Config{
+		Addr: ts.URL,
+	})
+
+	g.Expect(cli.StopKubeProxy()).To(Succeed())
+	g.Expect(cli.StartKubeProxy()).To(Succeed())
+	g.Expect(cli.StopKubeScheduler()).To(Succeed())
+	g.Expect(cli.StartKubeScheduler()).To(Succeed())
+	g.Expect(cli.StopKubeControllerManager()).To(Succeed())
+	g.Expect(cli.StartKubeControllerManager()).To(Succeed())
+	g.Expect(paths).To(Equal([]string{
+		api.APIPrefix + "/kube-proxy/stop",
+		api.APIPrefix + "/kube-proxy/start",
+		api.APIPrefix + "/kube-scheduler/stop",
+		api.APIPrefix + "/kube-scheduler/start",
+		api.APIPrefix + "/kube-controller-manager/stop",
+		api.APIPrefix + "/kube-controller-manager/start",
+	}))
+}
+
+func TestDiskFaults(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	resp := &api.Response{
+		Action:     "startDiskFill",
+		StatusCode: 200,
+		Message:    "OK",
+	}
+
+	var fill manager.DiskFill
+	respJSON, _ := json.Marshal(resp)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&fill)
+		fmt.Fprintln(w, string(respJSON))
+	}))
+	defer ts.Close()
+
+	cli := &client{
+		cfg: Config{
+			Addr: ts.URL,
+		},
+		httpCli: http.DefaultClient,
+	}
+
+	err := cli.StartDiskFill(&manager.DiskFill{Path: "/mnt/disks", Percent: 95})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(fill).To(Equal(manager.DiskFill{Path: "/mnt/disks", Percent: 95}))
+
+	err = cli.StartDiskFill(&manager.DiskFill{Path: "/mnt/disks", Percent: 101})
+	g.Expect(err).To(HaveOccurred())
+
+	err = cli.StopDiskFill("/mnt/disks")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	err = cli.StartDiskThrottle(&manager.DiskThrottle{Device: "/dev/sdb", WriteIOPS: 10})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	err = cli.StartDiskThrottle(&manager.DiskThrottle{WriteIOPS: 10})
+	g.Expect(err).To(HaveOccurred())
+
+	err = cli.StopDiskThrottle("/dev/sdb")
+	g.Expect(err).NotTo(HaveOccurred())
+}
+
+func TestStress(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	resp := &api.Response{
+		Action:     "startStress",
+		StatusCode: 200,
+		Message:    "OK",
+	}
+
+	respJSON, _ := json.Marshal(resp)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, string(respJSON))
+	}))
+	defer ts.Close()
+
+	cli := &client{
+		cfg: Config{
+			Addr: ts.URL,
+		},
+		httpCli: http.DefaultClient,
+	}
+
+	err := cli.StartStress(&manager.Stress{CPULoad: 90, Duration: 60})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	err = cli.StartStress(&manager.Stress{CPULoad: 90})
+	g.Expect(err).To(HaveOccurred())
+
+	err = cli.StopStress()
+	g.Expect(err).NotTo(HaveOccurred())
+}
+
+func TestClockSkew(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	resp := &api.Response{
+		Action:     "startClockSkew",
+		StatusCode: 200,
+		Message:    "OK",
+	}
+
+	respJSON, _ := json.Marshal(resp)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, string(respJSON))
+	}))
+	defer ts.Close()
+
+	cli := &client{
+		cfg: Config{
+			Addr: ts.URL,
+		},
+		httpCli: http.DefaultClient,
+	}
+
+	err := cli.StartClockSkew(&manager.ClockSkew{Offset: "-30s"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	err = cli.StartClockSkew(&manager.ClockSkew{Offset: "30"})
+	g.Expect(err).To(HaveOccurred())
+
+	err = cli.StopClockSkew()
+	g.Expect(err).NotTo(HaveOccurred())
+}
+
+func TestDNSFailure(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	resp := &api.Response{
+		Action:     "startDNSFailure",
+		StatusCode: 200,
+		Message:    "OK",
+	}
+
+	var failure manager.DNSFailure
+	respJSON, _ := json.Marshal(resp)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&failure)
+		fmt.Fprintln(w, string(respJSON))
+	}))
+	defer ts.Close()
+
+	cli := &client{
+		cfg: Config{
+			Addr: ts.URL,
+		},
+		httpCli: http.DefaultClient,
+	}
+
+	err := cli.StartDNSFailure(&manager.DNSFailure{Duration: 60})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(failure).To(Equal(manager.DNSFailure{Duration: 60}))
+
+	err = cli.StartDNSFailure(&manager.DNSFailure{})
+	g.Expect(err).To(HaveOccurred())
+
+	err = cli.StopDNSFailure()
+	g.Expect(err).NotTo(HaveOccurred())
+}
+
+func TestNetworkShaping(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	resp := &api.Response{
+		Action:     "startNetworkShaping",
+		StatusCode: 200,
+		Message:    "OK",
+	}
+
+	var shaping manager.NetworkShaping
+	respJSON, _ := json.Marshal(resp)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&shaping)
+		fmt.Fprintln(w, string(respJSON))
+	}))
+	defer ts.Close()
+
+	cli := &client{
+		cfg: Config{
+			Addr: ts.URL,
+		},
+		httpCli: http.DefaultClient,
+	}
+
+	expect := manager.NetworkShaping{Targets: []string{"10.0.0.2"}, Latency: "100ms", Loss: 5, Duration: 60}
+	err := cli.StartNetworkShaping(&expect)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(shaping).To(Equal(expect))
+
+	err = cli.StartNetworkShaping(&manager.NetworkShaping{Targets: []string{"10.0.0.2"}, Duration: 60})
+	g.Expect(err).To(HaveOccurred())
+
+	err = cli.StopNetworkShaping("eth0")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(shaping.Device).To(Equal("eth0"))
+}
@@ -0,0 +1,68 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// StartClockSkew disables the NTP synchronization and shifts the clock of the node by the offset
+func (m *Manager) StartClockSkew(skew *ClockSkew) error {
+	if err := skew.Verify(); err != nil {
+		return err
+	}
+	offset, _ := time.ParseDuration(skew.Offset)
+
+	// the NTP service would correct the clock soon if it is kept running
+	if err := execShell("timedatectl set-ntp false"); err != nil {
+		return err
+	}
+	if err := execShell(clockSkewShell(time.Now(), offset)); err != nil {
+		return err
+	}
+
+	glog.Infof("the clock is shifted by %s", offset)
+
+	return nil
+}
+
+// StopClockSkew enables the NTP synchronization to restore the clock of the node
+func (m *Manager) StopClockSkew() error {
+	if err := execShell("timedatectl set-ntp true"); err != nil {
+		return err
+	}
+
+	glog.Infof("the clock is restored by NTP")
+
+	return nil
+}
+
+func clockSkewShell(now time.Time, offset time.Duration) string {
+	skewed := now.Add(offset)
+	return fmt.Sprintf("date -s @%d.%09d", skewed.Unix(), skewed.Nanosecond())
+}
+
+func execShell(shell string) error {
+	output, err := exec.Command("/bin/sh", "-c", shell).CombinedOutput()
+	if err != nil {
+		glog.Errorf("exec: [%s] failed, output: %s, error: %v", shell, string(output), err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestClockSkewShell(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	now := time.Unix(1000, 500)
+	g.Expect(clockSkewShell(now, 30*time.Second)).To(Equal("date -s @1030.000000500"))
+	g.Expect(clockSkewShell(now, -time.Minute)).To(Equal("date -s @940.000000500"))
+
+	g.Expect((&ClockSkew{Offset: "-500ms"}).Verify()).NotTo(HaveOccurred())
+	g.Expect((&ClockSkew{Offset: "0s"}).Verify()).To(HaveOccurred())
+	g.Expect((&ClockSkew{Offset: "30"}).Verify()).To(HaveOccurred())
+}
@@ -0,0 +1,135 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+
+	"github.com/golang/glog"
+)
+
+const (
+	// kubepodsBlkioCgroup is the blkio cgroup of all the pods on the node
+	kubepodsBlkioCgroup = "/sys/fs/cgroup/blkio/kubepods"
+	// diskFillFile is the file created in the data directory to fill the disk
+	diskFillFile = "fault-trigger-disk-fill"
+)
+
+// StartDiskThrottle throttles the IO of the pods on the device
+func (m *Manager) StartDiskThrottle(throttle *DiskThrottle) error {
+	if err := throttle.Verify(); err != nil {
+		return err
+	}
+
+	return setBlkioThrottle(throttle.Device, throttle.ReadIOPS, throttle.WriteIOPS)
+}
+
+// StopDiskThrottle removes the IO throttling of the device
+func (m *Manager) StopDiskThrottle(device string) error {
+	if len(device) == 0 {
+		return errors.New("device must be provided")
+	}
+
+	return setBlkioThrottle(device, 0, 0)
+}
+
+func setBlkioThrottle(device string, readIOPS int, writeIOPS int) error {
+	var stat syscall.Stat_t
+	if err := syscall.Stat(device, &stat); err != nil {
+		return fmt.Errorf("failed to stat device %s, error: %v", device, err)
+	}
+	if stat.Mode&syscall.S_IFMT != syscall.S_IFBLK {
+		return fmt.Errorf("%s is not a block device", device)
+	}
+	major, minor := (stat.Rdev>>8)&0xfff, (stat.Rdev&0xff)|((stat.Rdev>>12)&0xfff00)
+
+	// writing 0 to the limit removes the rule of the device
+	for file, iops := range map[string]int{
+		"blkio.throttle.read_iops_device":  readIOPS,
+		"blkio.throttle.write_iops_device": writeIOPS,
+	} {
+		shell := fmt.Sprintf("echo '%d:%d %d' > %s", major, minor, iops, filepath.Join(kubepodsBlkioCgroup, file))
+		output, err := exec.Command("/bin/sh", "-c", shell).CombinedOutput()
+		if err != nil {
+			glog.Errorf("exec: [%s] failed, output: %s, error: %v", shell, string(output), err)
+			return err
+		}
+	}
+
+	glog.Infof("the iops of device %s is throttled to read %d write %d", device, readIOPS, writeIOPS)
+
+	return nil
+}
+
+// StartDiskFill fills the disk of the path until the used percentage reaches the target
+func (m *Manager) StartDiskFill(fill *DiskFill) error {
+	if err := fill.Verify(); err != nil {
+		return err
+	}
+
+	// remove the former fill so that the target is based on the real usage
+	if err := m.StopDiskFill(fill.Path); err != nil {
+		return err
+	}
+
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(fill.Path, &stat); err != nil {
+		return fmt.Errorf("failed to statfs %s, error: %v", fill.Path, err)
+	}
+	size := diskFillSize(stat.Blocks*uint64(stat.Bsize), stat.Bavail*uint64(stat.Bsize), fill.Percent)
+	if size == 0 {
+		glog.Infof("the disk of %s is already used more than %d%%", fill.Path, fill.Percent)
+		return nil
+	}
+
+	shell := fmt.Sprintf("fallocate -l %d %s", size, filepath.Join(fill.Path, diskFillFile))
+	output, err := exec.Command("/bin/sh", "-c", shell).CombinedOutput()
+	if err != nil {
+		glog.Errorf("exec: [%s] failed, output: %s, error: %v", shell, string(output), err)
+		return err
+	}
+
+	glog.Infof("the disk of %s is filled to %d%%", fill.Path, fill.Percent)
+
+	return nil
+}
+
+// StopDiskFill removes the file which fills the disk of the path
+func (m *Manager) StopDiskFill(path string) error {
+	if len(path) == 0 {
+		return errors.New("path must be provided")
+	}
+
+	if err := os.Remove(filepath.Join(path, diskFillFile)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// diskFillSize returns the size to allocate to make the used percentage of the disk reach the target
+func diskFillSize(total uint64, available uint64, percent int) uint64 {
+	target := total * uint64(percent) / 100
+	used := total - available
+	if used >= target {
+		return 0
+	}
+
+	return target - used
+}
@@ -0,0 +1,28 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestDiskFillSize(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(diskFillSize(1000, 800, 90)).To(Equal(uint64(700)))
+	g.Expect(diskFillSize(1000, 50, 90)).To(Equal(uint64(0)))
+	g.Expect(diskFillSize(1000, 0, 100)).To(Equal(uint64(0)))
+}
@@ -0,0 +1,85 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+const dnsFailureComment = "fault-trigger-dns-failure"
+
+// StartDNSFailure drops the DNS queries sent from the node and forwarded
+// from the pods on it, they are recovered after the duration
+func (m *Manager) StartDNSFailure(failure *DNSFailure) error {
+	if err := failure.Verify(); err != nil {
+		return err
+	}
+
+	for _, rule := range dnsFailureRules() {
+		if err := execShell(fmt.Sprintf("iptables -C %s 2>/dev/null || iptables -I %s", rule, rule)); err != nil {
+			return err
+		}
+	}
+
+	duration := time.Duration(failure.Duration) * time.Second
+	m.Lock()
+	if m.dnsFailureTimer != nil {
+		m.dnsFailureTimer.Stop()
+	}
+	m.dnsFailureTimer = time.AfterFunc(duration, func() {
+		if err := m.StopDNSFailure(); err != nil {
+			glog.Errorf("failed to stop the DNS failure, error: %v", err)
+		}
+	})
+	m.Unlock()
+
+	glog.Infof("the DNS queries are dropped for %s", duration)
+
+	return nil
+}
+
+// StopDNSFailure removes the rules dropping the DNS queries
+func (m *Manager) StopDNSFailure() error {
+	m.Lock()
+	if m.dnsFailureTimer != nil {
+		m.dnsFailureTimer.Stop()
+		m.dnsFailureTimer = nil
+	}
+	m.Unlock()
+
+	for _, rule := range dnsFailureRules() {
+		if err := execShell(fmt.Sprintf("while iptables -C %s 2>/dev/null; do iptables -D %s; done", rule, rule)); err != nil {
+			return err
+		}
+	}
+
+	glog.Infof("the DNS queries are recovered")
+
+	return nil
+}
+
+// dnsFailureRules returns the iptables rules dropping the DNS queries, the
+// queries of the pods go through the FORWARD chain of the node
+func dnsFailureRules() []string {
+	var rules []string
+	for _, chain := range []string{"OUTPUT", "FORWARD"} {
+		for _, protocol := range []string{"udp", "tcp"} {
+			rules = append(rules, fmt.Sprintf("%s -p %s --dport 53 -m comment --comment %s -j DROP", chain, protocol, dnsFailureComment))
+		}
+	}
+	return rules
+}
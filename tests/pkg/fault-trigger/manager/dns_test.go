@@ -0,0 +1,34 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestDNSFailureRules(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(dnsFailureRules()).To(Equal([]string{
+		"OUTPUT -p udp --dport 53 -m comment --comment fault-trigger-dns-failure -j DROP",
+		"OUTPUT -p tcp --dport 53 -m comment --comment fault-trigger-dns-failure -j DROP",
+		"FORWARD -p udp --dport 53 -m comment --comment fault-trigger-dns-failure -j DROP",
+		"FORWARD -p tcp --dport 53 -m comment --comment fault-trigger-dns-failure -j DROP",
+	}))
+
+	g.Expect((&DNSFailure{Duration: 60}).Verify()).NotTo(HaveOccurred())
+	g.Expect((&DNSFailure{}).Verify()).To(HaveOccurred())
+}
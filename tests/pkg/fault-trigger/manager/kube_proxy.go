@@ -0,0 +1,54 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+)
+
+const (
+	KubeProxyService = "kube-proxy"
+)
+
+// StartKubeProxy resumes the kube-proxy container on the node
+func (m *Manager) StartKubeProxy() error {
+	if err := execShell(kubeProxyShell("paused", "unpause")); err != nil {
+		return err
+	}
+
+	glog.Infof("%s is started", KubeProxyService)
+
+	return nil
+}
+
+// StopKubeProxy stops the kube-proxy on the node. The container of the
+// kube-proxy DaemonSet would be restarted by kubelet soon if it is killed,
+// so it is paused instead, which freezes the service rules of the node.
+func (m *Manager) StopKubeProxy() error {
+	if err := execShell(kubeProxyShell("running", "pause")); err != nil {
+		return err
+	}
+
+	glog.Infof("%s is stopped", KubeProxyService)
+
+	return nil
+}
+
+// kubeProxyShell runs the docker command on the kube-proxy containers in status
+func kubeProxyShell(status string, command string) string {
+	return fmt.Sprintf("docker ps -q --filter label=io.kubernetes.container.name=%s --filter status=%s | xargs -r docker %s",
+		KubeProxyService, status, command)
+}
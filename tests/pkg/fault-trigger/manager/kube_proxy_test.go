@@ -0,0 +1,29 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestKubeProxyShell(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(kubeProxyShell("running", "pause")).To(Equal(
+		"docker ps -q --filter label=io.kubernetes.container.name=kube-proxy --filter status=running | xargs -r docker pause"))
+	g.Expect(kubeProxyShell("paused", "unpause")).To(Equal(
+		"docker ps -q --filter label=io.kubernetes.container.name=kube-proxy --filter status=paused | xargs -r docker unpause"))
+}
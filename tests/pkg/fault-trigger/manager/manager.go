@@ -16,12 +16,19 @@ package manager
 import (
 	"fmt"
 	"sync"
+	"time"
 )
 
 // Manager to manager fault trigger
 type Manager struct {
 	sync.RWMutex
 	vmCache map[string]string
+	// dnsFailureTimer stops the running DNS failure after its duration
+	dnsFailureTimer *time.Timer
+	// networkShapingTimer stops the running network shaping of
+	// networkShapingDevice after its duration
+	networkShapingTimer  *time.Timer
+	networkShapingDevice string
 }
 
 // NewManager returns a manager instance
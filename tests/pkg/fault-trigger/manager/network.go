@@ -0,0 +1,169 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// shapedBand is the band of the prio qdisc the packets to the targets are
+// classified into, the default priomap only uses the first three bands
+const shapedBand = 4
+
+// StartNetworkShaping degrades the links from the node to the targets by tc,
+// the running shaping of the node is replaced. The links are recovered after
+// the duration.
+func (m *Manager) StartNetworkShaping(shaping *NetworkShaping) error {
+	if err := shaping.Verify(); err != nil {
+		return err
+	}
+
+	device := shaping.Device
+	if device == "" {
+		output, err := exec.Command("ip", "route", "get", shaping.Targets[0]).CombinedOutput()
+		if err != nil {
+			glog.Errorf("exec: [ip route get %s] failed, output: %s, error: %v", shaping.Targets[0], string(output), err)
+			return err
+		}
+		if device, err = parseRouteDevice(string(output)); err != nil {
+			return err
+		}
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	if m.networkShapingTimer != nil {
+		m.networkShapingTimer.Stop()
+		m.networkShapingTimer = nil
+	}
+	if m.networkShapingDevice != "" {
+		if err := execShell(networkShapingStopShell(m.networkShapingDevice)); err != nil {
+			return err
+		}
+	}
+	// the shaping may be left by the previous fault-trigger process
+	if err := execShell(networkShapingStopShell(device)); err != nil {
+		return err
+	}
+	m.networkShapingDevice = device
+	for _, shell := range networkShapingShells(device, shaping) {
+		if err := execShell(shell); err != nil {
+			execShell(networkShapingStopShell(device))
+			m.networkShapingDevice = ""
+			return err
+		}
+	}
+
+	duration := time.Duration(shaping.Duration) * time.Second
+	var timer *time.Timer
+	timer = time.AfterFunc(duration, func() {
+		m.RLock()
+		replaced := m.networkShapingTimer != timer
+		m.RUnlock()
+		if replaced {
+			return
+		}
+		if err := m.StopNetworkShaping(device); err != nil {
+			glog.Errorf("failed to stop the network shaping of %s, error: %v", device, err)
+		}
+	})
+	m.networkShapingTimer = timer
+
+	glog.Infof("the links from %s to %v are shaped for %s", device, shaping.Targets, duration)
+
+	return nil
+}
+
+// StopNetworkShaping removes the shaping of the device, it defaults to the
+// device shaped by StartNetworkShaping
+func (m *Manager) StopNetworkShaping(device string) error {
+	m.Lock()
+	defer m.Unlock()
+	if device == "" {
+		device = m.networkShapingDevice
+	}
+	if device == "" {
+		glog.Infof("the network shaping had been stopped before")
+		return nil
+	}
+	if device == m.networkShapingDevice {
+		if m.networkShapingTimer != nil {
+			m.networkShapingTimer.Stop()
+			m.networkShapingTimer = nil
+		}
+		m.networkShapingDevice = ""
+	}
+
+	if err := execShell(networkShapingStopShell(device)); err != nil {
+		return err
+	}
+
+	glog.Infof("the network shaping of %s is stopped", device)
+
+	return nil
+}
+
+// networkShapingShells returns the tc commands classifying the packets to the
+// targets into a band of the prio qdisc, which is degraded by netem
+func networkShapingShells(device string, shaping *NetworkShaping) []string {
+	shells := []string{
+		fmt.Sprintf("tc qdisc add dev %s root handle 1: prio bands %d priomap 1 2 2 2 1 2 0 0 1 1 1 1 1 1 1 1", device, shapedBand),
+	}
+
+	netem := []string{"netem"}
+	if shaping.Latency != "" {
+		latency, _ := time.ParseDuration(shaping.Latency)
+		netem = append(netem, "delay", fmt.Sprintf("%dus", latency/time.Microsecond))
+		if shaping.Jitter != "" {
+			jitter, _ := time.ParseDuration(shaping.Jitter)
+			netem = append(netem, fmt.Sprintf("%dus", jitter/time.Microsecond))
+		}
+	}
+	if shaping.Loss > 0 {
+		netem = append(netem, "loss", fmt.Sprintf("%g%%", shaping.Loss))
+	}
+	if shaping.Bandwidth != "" {
+		netem = append(netem, "rate", shaping.Bandwidth)
+	}
+	shells = append(shells, fmt.Sprintf("tc qdisc add dev %s parent 1:%d handle %d0: %s", device, shapedBand, shapedBand, strings.Join(netem, " ")))
+
+	for _, target := range shaping.Targets {
+		shells = append(shells, fmt.Sprintf("tc filter add dev %s parent 1:0 protocol ip prio %d u32 match ip dst %s/32 flowid 1:%d",
+			device, shapedBand, target, shapedBand))
+	}
+	return shells
+}
+
+// networkShapingStopShell deletes the root qdisc of the device, it's restored
+// to the default one by the kernel
+func networkShapingStopShell(device string) string {
+	return fmt.Sprintf("tc qdisc del dev %s root 2>/dev/null || true", device)
+}
+
+// parseRouteDevice parses the device from the output of `ip route get`, e.g.
+// "10.0.0.2 dev eth0 src 10.0.0.1 uid 0"
+func parseRouteDevice(output string) (string, error) {
+	fields := strings.Fields(output)
+	for i := 0; i < len(fields)-1; i++ {
+		if fields[i] == "dev" {
+			return fields[i+1], nil
+		}
+	}
+	return "", fmt.Errorf("can't find the device in route %q", output)
+}
@@ -0,0 +1,79 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestNetworkShapingShells(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	shaping := &NetworkShaping{
+		Targets:   []string{"10.0.0.2", "10.0.0.3"},
+		Bandwidth: "10mbit",
+		Latency:   "100ms",
+		Jitter:    "1.5ms",
+		Loss:      0.5,
+		Duration:  60,
+	}
+	g.Expect(shaping.Verify()).NotTo(HaveOccurred())
+	g.Expect(networkShapingShells("eth0", shaping)).To(Equal([]string{
+		"tc qdisc add dev eth0 root handle 1: prio bands 4 priomap 1 2 2 2 1 2 0 0 1 1 1 1 1 1 1 1",
+		"tc qdisc add dev eth0 parent 1:4 handle 40: netem delay 100000us 1500us loss 0.5% rate 10mbit",
+		"tc filter add dev eth0 parent 1:0 protocol ip prio 4 u32 match ip dst 10.0.0.2/32 flowid 1:4",
+		"tc filter add dev eth0 parent 1:0 protocol ip prio 4 u32 match ip dst 10.0.0.3/32 flowid 1:4",
+	}))
+
+	shaping = &NetworkShaping{Targets: []string{"10.0.0.2"}, Loss: 10, Duration: 60}
+	g.Expect(shaping.Verify()).NotTo(HaveOccurred())
+	g.Expect(networkShapingShells("eth1", shaping)[1]).To(Equal("tc qdisc add dev eth1 parent 1:4 handle 40: netem loss 10%"))
+
+	g.Expect(networkShapingStopShell("eth0")).To(Equal("tc qdisc del dev eth0 root 2>/dev/null || true"))
+}
+
+func TestNetworkShapingVerify(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	invalids := []NetworkShaping{
+		{Loss: 10, Duration: 60},
+		{Targets: []string{"node1"}, Loss: 10, Duration: 60},
+		{Targets: []string{"10.0.0.2"}, Duration: 60},
+		{Targets: []string{"10.0.0.2"}, Bandwidth: "10MB", Duration: 60},
+		{Targets: []string{"10.0.0.2"}, Latency: "100", Duration: 60},
+		{Targets: []string{"10.0.0.2"}, Loss: 10, Jitter: "10ms", Duration: 60},
+		{Targets: []string{"10.0.0.2"}, Loss: 101, Duration: 60},
+		{Targets: []string{"10.0.0.2"}, Loss: 10},
+	}
+	for i := range invalids {
+		g.Expect(invalids[i].Verify()).To(HaveOccurred(), "%+v", invalids[i])
+	}
+}
+
+func TestParseRouteDevice(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	device, err := parseRouteDevice("10.0.0.2 dev eth0 src 10.0.0.1 uid 0 \n    cache \n")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(device).To(Equal("eth0"))
+
+	device, err = parseRouteDevice("10.0.0.2 via 10.0.0.254 dev ens3 src 10.0.0.1")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(device).To(Equal("ens3"))
+
+	_, err = parseRouteDevice("RTNETLINK answers: Network is unreachable")
+	g.Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,80 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+const stressBin = "stress-ng"
+
+// StartStress starts stress-ng in the background, it exits by itself after the duration
+func (m *Manager) StartStress(stress *Stress) error {
+	if err := stress.Verify(); err != nil {
+		return err
+	}
+
+	args := stressArgs(stress)
+	cmd := exec.Command(stressBin, args...)
+	if err := cmd.Start(); err != nil {
+		glog.Errorf("exec: [%s %s] failed, error: %v", stressBin, strings.Join(args, " "), err)
+		return err
+	}
+	go func() {
+		if err := cmd.Wait(); err != nil {
+			glog.Warningf("exec: [%s %s] exited, error: %v", stressBin, strings.Join(args, " "), err)
+		}
+	}()
+
+	glog.Infof("%s is started with args: %s", stressBin, strings.Join(args, " "))
+
+	return nil
+}
+
+// StopStress kills all the running stress-ng processes
+func (m *Manager) StopStress() error {
+	shell := fmt.Sprintf("pkill %s || true", stressBin)
+	output, err := exec.Command("/bin/sh", "-c", shell).CombinedOutput()
+	if err != nil {
+		glog.Errorf("exec: [%s] failed, output: %s, error: %v", shell, string(output), err)
+		return err
+	}
+
+	glog.Infof("%s is stopped", stressBin)
+
+	return nil
+}
+
+func stressArgs(stress *Stress) []string {
+	var args []string
+	if stress.CPULoad > 0 {
+		args = append(args,
+			"--cpu", fmt.Sprintf("%d", stress.CPUWorkers),
+			"--cpu-load", fmt.Sprintf("%d", stress.CPULoad))
+	}
+	if stress.MemoryWorkers > 0 {
+		// the bytes of vm workers are divided among all the workers
+		args = append(args,
+			"--vm", fmt.Sprintf("%d", stress.MemoryWorkers),
+			"--vm-bytes", fmt.Sprintf("%d%%", stress.MemoryPercent),
+			"--vm-keep")
+	}
+	args = append(args, "--timeout", fmt.Sprintf("%ds", stress.Duration))
+
+	return args
+}
@@ -0,0 +1,38 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestStressArgs(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	args := stressArgs(&Stress{CPULoad: 80, Duration: 60})
+	g.Expect(args).To(Equal([]string{"--cpu", "0", "--cpu-load", "80", "--timeout", "60s"}))
+
+	args = stressArgs(&Stress{CPUWorkers: 2, CPULoad: 50, MemoryWorkers: 4, MemoryPercent: 90, Duration: 30})
+	g.Expect(args).To(Equal([]string{
+		"--cpu", "2", "--cpu-load", "50",
+		"--vm", "4", "--vm-bytes", "90%", "--vm-keep",
+		"--timeout", "30s",
+	}))
+
+	g.Expect((&Stress{Duration: 30}).Verify()).To(HaveOccurred())
+	g.Expect((&Stress{MemoryWorkers: 1, Duration: 30}).Verify()).To(HaveOccurred())
+	g.Expect((&Stress{CPULoad: 100}).Verify()).To(HaveOccurred())
+}
@@ -13,7 +13,13 @@
 
 package manager
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"time"
+)
 
 // VM defines the descriptive information of a virtual machine
 type VM struct {
@@ -32,3 +38,173 @@ func (v *VM) Verify() error {
 
 	return nil
 }
+
+// DiskThrottle defines the IO throttling of a disk device, the IO requests of
+// the pods on the node are delayed once the IOPS limit is reached
+type DiskThrottle struct {
+	// Device is the block device, e.g. /dev/sdb
+	Device string `json:"device"`
+	// ReadIOPS and WriteIOPS are the IOPS limits of the device, 0 means no limit
+	ReadIOPS  int `json:"read_iops"`
+	WriteIOPS int `json:"write_iops"`
+}
+
+func (d *DiskThrottle) Verify() error {
+	if len(d.Device) == 0 {
+		return errors.New("device must be provided")
+	}
+	if d.ReadIOPS < 0 || d.WriteIOPS < 0 {
+		return errors.New("iops must not be negative")
+	}
+
+	return nil
+}
+
+// DiskFill defines the fill of the disk which a data directory is on
+type DiskFill struct {
+	// Path is the data directory, e.g. the mount path of the local PVs
+	Path string `json:"path"`
+	// Percent is the target used percentage of the disk, from 1 to 100
+	Percent int `json:"percent"`
+}
+
+func (d *DiskFill) Verify() error {
+	if len(d.Path) == 0 {
+		return errors.New("path must be provided")
+	}
+	if d.Percent <= 0 || d.Percent > 100 {
+		return errors.New("percent must be between 1 and 100")
+	}
+
+	return nil
+}
+
+// Stress defines the CPU and memory pressure made by stress-ng on a node
+type Stress struct {
+	// CPUWorkers is the number of workers spinning on the CPU, 0 means
+	// one worker per online CPU
+	CPUWorkers int `json:"cpu_workers"`
+	// CPULoad is the load percentage of each CPU worker, 0 means no CPU stress
+	CPULoad int `json:"cpu_load"`
+	// MemoryWorkers is the number of workers allocating memory, 0 means no memory stress
+	MemoryWorkers int `json:"memory_workers"`
+	// MemoryPercent is the percentage of the available memory allocated
+	// by all the memory workers
+	MemoryPercent int `json:"memory_percent"`
+	// Duration is the seconds the stress lasts for
+	Duration int `json:"duration"`
+}
+
+func (s *Stress) Verify() error {
+	if s.CPULoad == 0 && s.MemoryWorkers == 0 {
+		return errors.New("cpu_load or memory_workers must be provided")
+	}
+	if s.CPUWorkers < 0 || s.MemoryWorkers < 0 {
+		return errors.New("workers must not be negative")
+	}
+	if s.CPULoad < 0 || s.CPULoad > 100 {
+		return errors.New("cpu_load must be between 0 and 100")
+	}
+	if s.MemoryWorkers > 0 && (s.MemoryPercent <= 0 || s.MemoryPercent > 100) {
+		return errors.New("memory_percent must be between 1 and 100")
+	}
+	if s.Duration <= 0 {
+		return errors.New("duration must be provided")
+	}
+
+	return nil
+}
+
+// ClockSkew defines the offset the clock of a node is shifted by
+type ClockSkew struct {
+	// Offset is a duration string, e.g. "30s" or "-5m"
+	Offset string `json:"offset"`
+}
+
+func (c *ClockSkew) Verify() error {
+	offset, err := time.ParseDuration(c.Offset)
+	if err != nil {
+		return fmt.Errorf("invalid offset %q, error: %v", c.Offset, err)
+	}
+	if offset == 0 {
+		return errors.New("offset must not be zero")
+	}
+
+	return nil
+}
+
+// DNSFailure defines the blackhole of the DNS queries sent from a node and
+// the pods on it
+type DNSFailure struct {
+	// Duration is the seconds the failure lasts for, the DNS queries are
+	// recovered by themselves after it
+	Duration int `json:"duration"`
+}
+
+func (d *DNSFailure) Verify() error {
+	if d.Duration <= 0 {
+		return errors.New("duration must be provided")
+	}
+
+	return nil
+}
+
+// bandwidthRegexp matches the rates supported by tc, e.g. 100kbit or 10mbit
+var bandwidthRegexp = regexp.MustCompile(`^[0-9]+(bit|kbit|mbit|gbit)$`)
+
+// NetworkShaping defines the degradation of the links from a node to the
+// target nodes by tc, the other links of the node are not affected
+type NetworkShaping struct {
+	// Device is the network interface, e.g. eth0, defaults to the one
+	// routing to the first target
+	Device string `json:"device"`
+	// Targets are the IPs of the nodes whose links are degraded
+	Targets []string `json:"targets"`
+	// Bandwidth is the rate limit of the links, e.g. 10mbit, empty means no limit
+	Bandwidth string `json:"bandwidth"`
+	// Latency is the delay added to the packets, e.g. 100ms, and Jitter
+	// is the random variation of the delay
+	Latency string `json:"latency"`
+	Jitter  string `json:"jitter"`
+	// Loss is the percentage of the packets dropped, from 0 to 100
+	Loss float64 `json:"loss"`
+	// Duration is the seconds the shaping lasts for, the links are
+	// recovered by themselves after it
+	Duration int `json:"duration"`
+}
+
+func (n *NetworkShaping) Verify() error {
+	if len(n.Targets) == 0 {
+		return errors.New("targets must be provided")
+	}
+	for _, target := range n.Targets {
+		if net.ParseIP(target) == nil {
+			return fmt.Errorf("invalid target ip %q", target)
+		}
+	}
+	if n.Bandwidth == "" && n.Latency == "" && n.Loss == 0 {
+		return errors.New("bandwidth, latency or loss must be provided")
+	}
+	if n.Bandwidth != "" && !bandwidthRegexp.MatchString(n.Bandwidth) {
+		return fmt.Errorf("invalid bandwidth %q, e.g. 10mbit", n.Bandwidth)
+	}
+	for _, d := range []string{n.Latency, n.Jitter} {
+		if d == "" {
+			continue
+		}
+		if duration, err := time.ParseDuration(d); err != nil || duration < 0 {
+			return fmt.Errorf("invalid latency or jitter %q", d)
+		}
+	}
+	if n.Jitter != "" && n.Latency == "" {
+		return errors.New("jitter must be provided with latency")
+	}
+	if n.Loss < 0 || n.Loss > 100 {
+		return errors.New("loss must be between 0 and 100")
+	}
+	if n.Duration <= 0 {
+		return errors.New("duration must be provided")
+	}
+
+	return nil
+}
@@ -24,7 +24,7 @@ import (
 	"os"
 	"path"
 
-	"github.com/pingcap/tidb-operator/tests/slack"
+	"github.com/pingcap/tidb-operator/tests/notify"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -138,7 +138,7 @@ func init() {
 	l, err := net.Listen("tcp", fmt.Sprintf(":%d", metricPort))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "listening port %d failed, %v", metricPort, err)
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 
 	mux.Handle("/metrics", promhttp.Handler())
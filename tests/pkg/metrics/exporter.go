@@ -0,0 +1,98 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Target is an endpoint serving metrics in the Prometheus text format
+type Target struct {
+	// Name is the directory the snapshots of the target are written to
+	Name string
+	URL  string
+}
+
+// Exporter scrapes the targets periodically and writes the snapshots to
+// files, so the failures of a test can be correlated with the metrics after
+// the fact
+type Exporter struct {
+	dir      string
+	interval time.Duration
+	// targets returns the current targets, which change as the clusters
+	// are deployed and deleted
+	targets func() []Target
+	client  *http.Client
+}
+
+// NewExporter returns an Exporter writing the snapshots to dir
+func NewExporter(dir string, interval time.Duration, targets func() []Target) *Exporter {
+	return &Exporter{
+		dir:      dir,
+		interval: interval,
+		targets:  targets,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Run snapshots the targets every interval until stopCh is closed
+func (e *Exporter) Run(stopCh <-chan struct{}) {
+	wait.Until(e.Snapshot, e.interval, stopCh)
+}
+
+// Snapshot scrapes all the targets once, the failures are only logged as
+// the targets may be down during the faults
+func (e *Exporter) Snapshot() {
+	now := time.Now()
+	for _, target := range e.targets() {
+		if err := e.snapshot(target, now); err != nil {
+			glog.Warningf("failed to snapshot the metrics of %s: %v", target.Name, err)
+		}
+	}
+}
+
+func (e *Exporter) snapshot(target Target, now time.Time) error {
+	resp, err := e.client.Get(target.URL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("get %s: %s", target.URL, resp.Status)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(e.dir, target.Name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, SnapshotFileName(now)), data, 0644)
+}
+
+// SnapshotFileName returns the name of the snapshot file taken at t, the
+// names sort by time
+func SnapshotFileName(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z") + ".prom"
+}
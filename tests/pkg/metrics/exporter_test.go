@@ -0,0 +1,69 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+)
+
+func TestExporterSnapshot(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metrics" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, "up 1\n")
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "metrics")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	e := NewExporter(dir, time.Minute, func() []Target {
+		return []Target{
+			{Name: "operator", URL: server.URL + "/metrics"},
+			{Name: "missing", URL: server.URL + "/missing"},
+		}
+	})
+	e.Snapshot()
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, "operator"))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(files).To(gomega.HaveLen(1))
+	data, err := ioutil.ReadFile(filepath.Join(dir, "operator", files[0].Name()))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(data)).To(gomega.Equal("up 1\n"))
+
+	_, err = os.Stat(filepath.Join(dir, "missing"))
+	g.Expect(os.IsNotExist(err)).To(gomega.BeTrue())
+}
+
+func TestSnapshotFileName(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	ts := time.Date(2019, 8, 1, 10, 4, 5, 0, time.UTC)
+	g.Expect(SnapshotFileName(ts)).To(gomega.Equal("20190801T100405Z.prom"))
+}
@@ -0,0 +1,207 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+const (
+	ResultPassed = "passed"
+	ResultFailed = "failed"
+)
+
+// Case is the result of a test case
+type Case struct {
+	Name     string        `json:"name"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Result   string        `json:"result"`
+	Failure  string        `json:"failure,omitempty"`
+}
+
+// Reporter records the results of the test cases run one by one and writes
+// them as JUnit XML and JSON reports, so CI systems can display them
+type Reporter struct {
+	lock    sync.Mutex
+	dir     string
+	suite   string
+	start   time.Time
+	cases   []*Case
+	current *Case
+	// onCaseEnd is called with the result of each ended case
+	onCaseEnd func(Case)
+}
+
+// NewReporter returns a Reporter writing the reports of the suite to dir
+func NewReporter(dir, suite string) *Reporter {
+	return &Reporter{
+		dir:   dir,
+		suite: suite,
+		start: time.Now(),
+	}
+}
+
+// StartCase marks the current case passed and starts a new one
+func (r *Reporter) StartCase(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.endCurrent(ResultPassed, "")
+	r.current = &Case{Name: name, Start: time.Now()}
+	glog.Infof("[%s] case %s started", r.suite, name)
+}
+
+// EndCase marks the current case passed, the time until the next case is
+// not counted into any case
+func (r *Reporter) EndCase() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.endCurrent(ResultPassed, "")
+}
+
+// Finish ends the current case and writes the reports, it must be deferred
+// directly: a panic is recovered to mark the current case failed, then the
+// panic continues.
+func (r *Reporter) Finish() {
+	e := recover()
+
+	r.lock.Lock()
+	if e != nil {
+		r.endCurrent(ResultFailed, fmt.Sprint(e))
+	} else {
+		r.endCurrent(ResultPassed, "")
+	}
+	r.lock.Unlock()
+
+	if e != nil {
+		panic(e)
+	}
+}
+
+// OnCaseEnd sets the function called with the result of each ended case
+func (r *Reporter) OnCaseEnd(fn func(Case)) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.onCaseEnd = fn
+}
+
+// Cases returns the results of the ended cases
+func (r *Reporter) Cases() []Case {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	cases := make([]Case, 0, len(r.cases))
+	for _, c := range r.cases {
+		cases = append(cases, *c)
+	}
+	return cases
+}
+
+func (r *Reporter) endCurrent(result, failure string) {
+	if r.current == nil {
+		return
+	}
+	r.current.Duration = time.Since(r.current.Start)
+	r.current.Result = result
+	r.current.Failure = failure
+	r.cases = append(r.cases, r.current)
+	glog.Infof("[%s] case %s %s in %v", r.suite, r.current.Name, result, r.current.Duration)
+	if r.onCaseEnd != nil {
+		r.onCaseEnd(*r.current)
+	}
+	r.current = nil
+
+	// write the reports after each case, so they survive the crash of the tests
+	if err := r.write(); err != nil {
+		glog.Errorf("failed to write the reports of %s to %s: %v", r.suite, r.dir, err)
+	}
+}
+
+func (r *Reporter) write() error {
+	data, err := json.MarshalIndent(r.cases, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(r.dir, r.suite+"-report.json"), data, 0644); err != nil {
+		return err
+	}
+
+	data, err = xml.MarshalIndent(r.junit(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(r.dir, r.suite+"-report.xml"), append([]byte(xml.Header), data...), 0644)
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Content string `xml:",chardata"`
+}
+
+func (r *Reporter) junit() *junitTestSuites {
+	suite := junitTestSuite{
+		Name:      r.suite,
+		Tests:     len(r.cases),
+		Time:      seconds(time.Since(r.start)),
+		Timestamp: r.start.Format(time.RFC3339),
+	}
+	for _, c := range r.cases {
+		tc := junitTestCase{
+			Name:      c.Name,
+			ClassName: r.suite,
+			Time:      seconds(c.Duration),
+		}
+		if c.Result == ResultFailed {
+			suite.Failures++
+			tc.Failure = &junitFailure{Message: c.Failure, Content: c.Failure}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	return &junitTestSuites{Suites: []junitTestSuite{suite}}
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
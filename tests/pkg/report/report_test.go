@@ -0,0 +1,68 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestReporter(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "report")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	r := NewReporter(dir, "stability")
+	func() {
+		defer func() {
+			g.Expect(recover()).To(Equal("tikv failover failed"))
+		}()
+		defer r.Finish()
+
+		r.StartCase("deploy")
+		r.StartCase("failover")
+		panic("tikv failover failed")
+	}()
+
+	cases := r.Cases()
+	g.Expect(cases).To(HaveLen(2))
+	g.Expect(cases[0].Name).To(Equal("deploy"))
+	g.Expect(cases[0].Result).To(Equal(ResultPassed))
+	g.Expect(cases[1].Name).To(Equal("failover"))
+	g.Expect(cases[1].Result).To(Equal(ResultFailed))
+	g.Expect(cases[1].Failure).To(Equal("tikv failover failed"))
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "stability-report.json"))
+	g.Expect(err).NotTo(HaveOccurred())
+	var jsonCases []Case
+	g.Expect(json.Unmarshal(data, &jsonCases)).To(Succeed())
+	g.Expect(jsonCases).To(HaveLen(2))
+
+	data, err = ioutil.ReadFile(filepath.Join(dir, "stability-report.xml"))
+	g.Expect(err).NotTo(HaveOccurred())
+	var suites junitTestSuites
+	g.Expect(xml.Unmarshal(data, &suites)).To(Succeed())
+	g.Expect(suites.Suites).To(HaveLen(1))
+	g.Expect(suites.Suites[0].Tests).To(Equal(2))
+	g.Expect(suites.Suites[0].Failures).To(Equal(1))
+	g.Expect(suites.Suites[0].Cases[1].Failure.Message).To(Equal("tikv failover failed"))
+}
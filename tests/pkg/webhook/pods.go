@@ -7,7 +7,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/pingcap/tidb-operator/tests/slack"
+	"github.com/pingcap/tidb-operator/tests/notify"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -80,7 +80,7 @@ func admitPods(ar v1beta1.AdmissionReview) *v1beta1.AdmissionResponse {
 			time.Sleep(10 * time.Second)
 			err := fmt.Errorf("tidb is ddl owner, can't be deleted namespace %s name %s", namespace, name)
 			glog.Error(err)
-			sendErr := slack.SendErrMsg(err.Error())
+			sendErr := notify.SendErrMsg(err.Error())
 			if sendErr != nil {
 				glog.Error(sendErr)
 			}
@@ -101,7 +101,7 @@ func admitPods(ar v1beta1.AdmissionReview) *v1beta1.AdmissionResponse {
 			time.Sleep(10 * time.Second)
 			err := fmt.Errorf("pd is leader, can't be deleted namespace %s name %s", namespace, name)
 			glog.Error(err)
-			sendErr := slack.SendErrMsg(err.Error())
+			sendErr := notify.SendErrMsg(err.Error())
 			if sendErr != nil {
 				glog.Error(sendErr)
 			}
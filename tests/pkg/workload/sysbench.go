@@ -0,0 +1,193 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+const (
+	defaultSysbenchTest      = "oltp_read_write"
+	defaultSysbenchTables    = 16
+	defaultSysbenchTableSize = 10000
+	defaultSysbenchThreads   = 16
+	defaultSysbenchTime      = 300
+)
+
+// SysbenchConfig defines the config of the sysbench workload
+type SysbenchConfig struct {
+	// Test is the lua test of sysbench, e.g. oltp_read_write, oltp_point_select
+	Test      string `yaml:"test" json:"test"`
+	Tables    int    `yaml:"tables" json:"tables"`
+	TableSize int    `yaml:"table_size" json:"table_size"`
+	Threads   int    `yaml:"threads" json:"threads"`
+	// Time is the seconds each run lasts for
+	Time int `yaml:"time" json:"time"`
+	// MaxTPSDropPercent is the max percentage the TPS is allowed to drop
+	// compared with the baseline, 0 means the regression is not checked
+	MaxTPSDropPercent int `yaml:"max_tps_drop_percent" json:"max_tps_drop_percent"`
+}
+
+// SysbenchResult is the statistics of a sysbench run
+type SysbenchResult struct {
+	TPS float64
+	QPS float64
+	// the latencies are in milliseconds
+	AvgLatency float64
+	P95Latency float64
+}
+
+func (r *SysbenchResult) String() string {
+	return fmt.Sprintf("tps: %.2f, qps: %.2f, avg latency: %.2fms, 95th percentile latency: %.2fms",
+		r.TPS, r.QPS, r.AvgLatency, r.P95Latency)
+}
+
+// Sysbench runs the sysbench workload against a TiDB service
+type Sysbench struct {
+	cfg      SysbenchConfig
+	host     string
+	port     int
+	user     string
+	password string
+	db       string
+}
+
+// NewSysbench returns a Sysbench
+func NewSysbench(cfg SysbenchConfig, host string, port int, user, password, db string) *Sysbench {
+	if cfg.Test == "" {
+		cfg.Test = defaultSysbenchTest
+	}
+	if cfg.Tables <= 0 {
+		cfg.Tables = defaultSysbenchTables
+	}
+	if cfg.TableSize <= 0 {
+		cfg.TableSize = defaultSysbenchTableSize
+	}
+	if cfg.Threads <= 0 {
+		cfg.Threads = defaultSysbenchThreads
+	}
+	if cfg.Time <= 0 {
+		cfg.Time = defaultSysbenchTime
+	}
+	return &Sysbench{
+		cfg:      cfg,
+		host:     host,
+		port:     port,
+		user:     user,
+		password: password,
+		db:       db,
+	}
+}
+
+// Prepare creates the tables and loads the data
+func (s *Sysbench) Prepare() error {
+	_, err := s.exec("prepare")
+	return err
+}
+
+// Run runs the workload and returns the statistics
+func (s *Sysbench) Run() (*SysbenchResult, error) {
+	output, err := s.exec("run")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseSysbenchOutput(output)
+}
+
+// Cleanup drops the tables
+func (s *Sysbench) Cleanup() error {
+	_, err := s.exec("cleanup")
+	return err
+}
+
+func (s *Sysbench) exec(command string) (string, error) {
+	args := []string{
+		s.cfg.Test,
+		"--db-driver=mysql",
+		fmt.Sprintf("--mysql-host=%s", s.host),
+		fmt.Sprintf("--mysql-port=%d", s.port),
+		fmt.Sprintf("--mysql-user=%s", s.user),
+		fmt.Sprintf("--mysql-password=%s", s.password),
+		fmt.Sprintf("--mysql-db=%s", s.db),
+		fmt.Sprintf("--tables=%d", s.cfg.Tables),
+		fmt.Sprintf("--table-size=%d", s.cfg.TableSize),
+		fmt.Sprintf("--threads=%d", s.cfg.Threads),
+		fmt.Sprintf("--time=%d", s.cfg.Time),
+		"--report-interval=60",
+		command,
+	}
+	glog.Infof("sysbench %s %s against %s:%d", s.cfg.Test, command, s.host, s.port)
+
+	output, err := exec.Command("sysbench", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to sysbench %s %s: %v, %s", s.cfg.Test, command, err, string(output))
+	}
+
+	return string(output), nil
+}
+
+var (
+	sysbenchTPSRe        = regexp.MustCompile(`transactions:\s+\d+\s+\(([\d.]+) per sec\.\)`)
+	sysbenchQPSRe        = regexp.MustCompile(`queries:\s+\d+\s+\(([\d.]+) per sec\.\)`)
+	sysbenchAvgLatencyRe = regexp.MustCompile(`avg:\s+([\d.]+)`)
+	sysbenchP95LatencyRe = regexp.MustCompile(`95th percentile:\s+([\d.]+)`)
+)
+
+func parseSysbenchOutput(output string) (*SysbenchResult, error) {
+	result := &SysbenchResult{}
+	for _, item := range []struct {
+		re    *regexp.Regexp
+		value *float64
+	}{
+		{sysbenchTPSRe, &result.TPS},
+		{sysbenchQPSRe, &result.QPS},
+		{sysbenchAvgLatencyRe, &result.AvgLatency},
+		{sysbenchP95LatencyRe, &result.P95Latency},
+	} {
+		match := item.re.FindStringSubmatch(output)
+		if match == nil {
+			return nil, fmt.Errorf("failed to find %s in sysbench output: %s", item.re, strings.TrimSpace(output))
+		}
+		value, err := strconv.ParseFloat(match[1], 64)
+		if err != nil {
+			return nil, err
+		}
+		*item.value = value
+	}
+
+	return result, nil
+}
+
+// CheckTPSRegression returns an error if the TPS of the result drops more
+// than maxDropPercent compared with the baseline
+func CheckTPSRegression(baseline, result *SysbenchResult, maxDropPercent int) error {
+	if maxDropPercent <= 0 || baseline.TPS == 0 {
+		return nil
+	}
+
+	drop := (baseline.TPS - result.TPS) / baseline.TPS * 100
+	if drop > float64(maxDropPercent) {
+		return fmt.Errorf("tps drops %.2f%% from %.2f to %.2f, more than %d%%",
+			drop, baseline.TPS, result.TPS, maxDropPercent)
+	}
+
+	return nil
+}
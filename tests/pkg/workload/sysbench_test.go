@@ -0,0 +1,64 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParseSysbenchOutput(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	output := `
+SQL statistics:
+    queries performed:
+        read:                            345058
+        write:                           98588
+        other:                           49294
+        total:                           492940
+    transactions:                        24647  (82.12 per sec.)
+    queries:                             492940 (1642.40 per sec.)
+    ignored errors:                      0      (0.00 per sec.)
+    reconnects:                          0      (0.00 per sec.)
+
+Latency (ms):
+         min:                                   76.90
+         avg:                                  194.73
+         max:                                  952.28
+         95th percentile:                      277.21
+         sum:                              4799498.61
+`
+	result, err := parseSysbenchOutput(output)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(&SysbenchResult{
+		TPS:        82.12,
+		QPS:        1642.40,
+		AvgLatency: 194.73,
+		P95Latency: 277.21,
+	}))
+
+	_, err = parseSysbenchOutput("FATAL: unable to connect to MySQL server")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestCheckTPSRegression(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	baseline := &SysbenchResult{TPS: 100}
+	g.Expect(CheckTPSRegression(baseline, &SysbenchResult{TPS: 85}, 20)).NotTo(HaveOccurred())
+	g.Expect(CheckTPSRegression(baseline, &SysbenchResult{TPS: 75}, 20)).To(HaveOccurred())
+	g.Expect(CheckTPSRegression(baseline, &SysbenchResult{TPS: 10}, 0)).NotTo(HaveOccurred())
+}
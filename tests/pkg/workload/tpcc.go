@@ -0,0 +1,150 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+
+	"github.com/golang/glog"
+)
+
+const (
+	defaultTPCCWarehouses = 10
+	defaultTPCCThreads    = 16
+	defaultTPCCDuration   = "1h"
+)
+
+// TPCCConfig defines the config of the TPC-C workload run by go-tpc
+type TPCCConfig struct {
+	Warehouses int `yaml:"warehouses" json:"warehouses"`
+	Threads    int `yaml:"threads" json:"threads"`
+	// Duration is how long the workload runs, e.g. 2h
+	Duration string `yaml:"duration" json:"duration"`
+}
+
+// TPCCResult is the statistics of a TPC-C run
+type TPCCResult struct {
+	// TpmC is the new order transactions per minute
+	TpmC float64
+	// TPM is the transactions per minute of each transaction type
+	TPM map[string]float64
+}
+
+// TPCC runs the TPC-C workload against a TiDB service
+type TPCC struct {
+	cfg      TPCCConfig
+	host     string
+	port     int
+	user     string
+	password string
+	db       string
+}
+
+// NewTPCC returns a TPCC
+func NewTPCC(cfg TPCCConfig, host string, port int, user, password, db string) *TPCC {
+	if cfg.Warehouses <= 0 {
+		cfg.Warehouses = defaultTPCCWarehouses
+	}
+	if cfg.Threads <= 0 {
+		cfg.Threads = defaultTPCCThreads
+	}
+	if cfg.Duration == "" {
+		cfg.Duration = defaultTPCCDuration
+	}
+	return &TPCC{
+		cfg:      cfg,
+		host:     host,
+		port:     port,
+		user:     user,
+		password: password,
+		db:       db,
+	}
+}
+
+// Prepare creates the tables and loads the warehouses
+func (t *TPCC) Prepare() error {
+	_, err := t.exec("prepare")
+	return err
+}
+
+// Run runs the transactions for the duration and returns the statistics
+func (t *TPCC) Run() (*TPCCResult, error) {
+	output, err := t.exec("run", "--time", t.cfg.Duration)
+	if err != nil {
+		return nil, err
+	}
+
+	result := parseTPCCOutput(output)
+	glog.Infof("tpcc result of %s:%d: tpmC: %.2f, tpm: %v", t.host, t.port, result.TpmC, result.TPM)
+	return result, nil
+}
+
+// Check runs the consistency check of TPC-C, an error is returned if the
+// data violates any consistency condition
+func (t *TPCC) Check() error {
+	_, err := t.exec("check")
+	return err
+}
+
+// Cleanup drops the tables
+func (t *TPCC) Cleanup() error {
+	_, err := t.exec("cleanup")
+	return err
+}
+
+func (t *TPCC) exec(command string, extraArgs ...string) (string, error) {
+	args := []string{
+		"tpcc",
+		"-H", t.host,
+		"-P", strconv.Itoa(t.port),
+		"-U", t.user,
+		"-p", t.password,
+		"-D", t.db,
+		"-T", strconv.Itoa(t.cfg.Threads),
+		"--warehouses", strconv.Itoa(t.cfg.Warehouses),
+		command,
+	}
+	args = append(args, extraArgs...)
+	glog.Infof("go-tpc tpcc %s against %s:%d", command, t.host, t.port)
+
+	output, err := exec.Command("go-tpc", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to go-tpc tpcc %s: %v, %s", command, err, string(output))
+	}
+
+	return string(output), nil
+}
+
+var (
+	tpccSummaryRe = regexp.MustCompile(`\[Summary\] (\w+) - Takes\(s\): [\d.]+, Count: \d+, TPM: ([\d.]+)`)
+	tpccTpmCRe    = regexp.MustCompile(`tpmC: ([\d.]+)`)
+)
+
+func parseTPCCOutput(output string) *TPCCResult {
+	result := &TPCCResult{TPM: map[string]float64{}}
+	for _, match := range tpccSummaryRe.FindAllStringSubmatch(output, -1) {
+		tpm, _ := strconv.ParseFloat(match[2], 64)
+		result.TPM[match[1]] = tpm
+	}
+
+	if match := tpccTpmCRe.FindStringSubmatch(output); match != nil {
+		result.TpmC, _ = strconv.ParseFloat(match[1], 64)
+	} else {
+		result.TpmC = result.TPM["NEW_ORDER"]
+	}
+	return result
+}
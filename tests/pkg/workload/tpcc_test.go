@@ -0,0 +1,42 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParseTPCCOutput(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	output := `
+[Current] NEW_ORDER - Takes(s): 10.0, Count: 1002, TPM: 6012.0, Sum(ms): 30123, Avg(ms): 30, 90th(ms): 48, 99th(ms): 80, 99.9th(ms): 112
+Finished
+[Summary] DELIVERY - Takes(s): 600.0, Count: 4123, TPM: 412.3, Sum(ms): 412345, Avg(ms): 100, 90th(ms): 160, 99th(ms): 256, 99.9th(ms): 512
+[Summary] NEW_ORDER - Takes(s): 600.0, Count: 46012, TPM: 4601.2, Sum(ms): 1380360, Avg(ms): 30, 90th(ms): 48, 99th(ms): 80, 99.9th(ms): 112
+[Summary] PAYMENT - Takes(s): 600.0, Count: 44011, TPM: 4401.1, Sum(ms): 880220, Avg(ms): 20, 90th(ms): 32, 99th(ms): 64, 99.9th(ms): 96
+`
+	result := parseTPCCOutput(output)
+	g.Expect(result.TpmC).To(Equal(4601.2))
+	g.Expect(result.TPM).To(Equal(map[string]float64{
+		"DELIVERY":  412.3,
+		"NEW_ORDER": 4601.2,
+		"PAYMENT":   4401.1,
+	}))
+
+	result = parseTPCCOutput(output + "tpmC: 4598.7, efficiency: 3576.2%\n")
+	g.Expect(result.TpmC).To(Equal(4598.7))
+}
@@ -0,0 +1,211 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+const (
+	// YCSBDBMySQL applies the load through the MySQL protocol of TiDB
+	YCSBDBMySQL = "mysql"
+	// YCSBDBRawKV applies the load to TiKV by the raw kv client
+	YCSBDBRawKV = "rawkv"
+
+	defaultYCSBRecordCount    = 100000
+	defaultYCSBOperationCount = 1000000
+	defaultYCSBThreads        = 32
+)
+
+// ycsbWorkloads are the properties of the core workloads of YCSB
+var ycsbWorkloads = map[string]map[string]string{
+	// update heavy
+	"a": {"readproportion": "0.5", "updateproportion": "0.5", "requestdistribution": "zipfian"},
+	// read mostly
+	"b": {"readproportion": "0.95", "updateproportion": "0.05", "requestdistribution": "zipfian"},
+	// read only
+	"c": {"readproportion": "1", "requestdistribution": "zipfian"},
+	// read latest
+	"d": {"readproportion": "0.95", "insertproportion": "0.05", "requestdistribution": "latest"},
+	// short ranges
+	"e": {"scanproportion": "0.95", "insertproportion": "0.05", "requestdistribution": "zipfian", "maxscanlength": "100"},
+	// read-modify-write
+	"f": {"readproportion": "0.5", "readmodifywriteproportion": "0.5", "requestdistribution": "zipfian"},
+}
+
+// YCSBConfig defines the config of the YCSB workload run by go-ycsb
+type YCSBConfig struct {
+	// Workloads are the letters of the core workloads run in turn, e.g. [a, b, f]
+	Workloads      []string `yaml:"workloads" json:"workloads"`
+	RecordCount    int      `yaml:"record_count" json:"record_count"`
+	OperationCount int      `yaml:"operation_count" json:"operation_count"`
+	Threads        int      `yaml:"threads" json:"threads"`
+	// DB is mysql or rawkv, defaults to mysql
+	DB string `yaml:"db" json:"db"`
+}
+
+// YCSBOpResult is the statistics of an operation of a YCSB run
+type YCSBOpResult struct {
+	Count int64
+	OPS   float64
+	// the latencies are in microseconds
+	AvgLatency int64
+	P99Latency int64
+}
+
+// YCSB runs the YCSB workloads against a TiDB cluster
+type YCSB struct {
+	cfg YCSBConfig
+	// props are the connection properties of the db
+	props map[string]string
+}
+
+// NewYCSB returns a YCSB applying the load through TiDB
+func NewYCSB(cfg YCSBConfig, tidbHost string, tidbPort int, user, password, db string) *YCSB {
+	cfg.DB = YCSBDBMySQL
+	return newYCSB(cfg, map[string]string{
+		"mysql.host":     tidbHost,
+		"mysql.port":     strconv.Itoa(tidbPort),
+		"mysql.user":     user,
+		"mysql.password": password,
+		"mysql.db":       db,
+	})
+}
+
+// NewRawKVYCSB returns a YCSB applying the load to TiKV by the raw kv client
+func NewRawKVYCSB(cfg YCSBConfig, pdAddr string) *YCSB {
+	cfg.DB = YCSBDBRawKV
+	return newYCSB(cfg, map[string]string{
+		"tikv.pd":   pdAddr,
+		"tikv.type": "raw",
+	})
+}
+
+func newYCSB(cfg YCSBConfig, props map[string]string) *YCSB {
+	if len(cfg.Workloads) == 0 {
+		cfg.Workloads = []string{"a"}
+	}
+	if cfg.RecordCount <= 0 {
+		cfg.RecordCount = defaultYCSBRecordCount
+	}
+	if cfg.OperationCount <= 0 {
+		cfg.OperationCount = defaultYCSBOperationCount
+	}
+	if cfg.Threads <= 0 {
+		cfg.Threads = defaultYCSBThreads
+	}
+	return &YCSB{cfg: cfg, props: props}
+}
+
+// Verify checks the workload letters
+func (y *YCSB) Verify() error {
+	for _, w := range y.cfg.Workloads {
+		if _, ok := ycsbWorkloads[strings.ToLower(w)]; !ok {
+			return fmt.Errorf("unknown ycsb workload %s", w)
+		}
+	}
+	return nil
+}
+
+// Load loads the records
+func (y *YCSB) Load() error {
+	// the records are shared by all the workloads
+	_, err := y.exec("load", y.cfg.Workloads[0])
+	return err
+}
+
+// Run runs the workloads in turn and returns the statistics of each workload
+func (y *YCSB) Run() (map[string]map[string]YCSBOpResult, error) {
+	results := map[string]map[string]YCSBOpResult{}
+	for _, w := range y.cfg.Workloads {
+		output, err := y.exec("run", w)
+		if err != nil {
+			return nil, err
+		}
+		results[w] = parseYCSBOutput(output)
+		glog.Infof("ycsb workload %s result: %+v", w, results[w])
+	}
+	return results, nil
+}
+
+func (y *YCSB) exec(command string, workload string) (string, error) {
+	props := map[string]string{
+		"recordcount":    strconv.Itoa(y.cfg.RecordCount),
+		"operationcount": strconv.Itoa(y.cfg.OperationCount),
+		"threadcount":    strconv.Itoa(y.cfg.Threads),
+		"workload":       "core",
+	}
+	for k, v := range ycsbWorkloads[strings.ToLower(workload)] {
+		props[k] = v
+	}
+	for k, v := range y.props {
+		props[k] = v
+	}
+
+	db := "tikv"
+	if y.cfg.DB == YCSBDBMySQL {
+		db = "mysql"
+	}
+	args := append([]string{command, db}, ycsbPropArgs(props)...)
+	glog.Infof("go-ycsb %s %s workload %s", command, y.cfg.DB, workload)
+
+	output, err := exec.Command("go-ycsb", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to go-ycsb %s workload %s: %v, %s", command, workload, err, string(output))
+	}
+
+	return string(output), nil
+}
+
+func ycsbPropArgs(props map[string]string) []string {
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var args []string
+	for _, k := range keys {
+		args = append(args, "-p", fmt.Sprintf("%s=%s", k, props[k]))
+	}
+	return args
+}
+
+var ycsbOpRe = regexp.MustCompile(`(?m)^(\w+)\s+- Takes\(s\): [\d.]+, Count: (\d+), OPS: ([\d.]+), Avg\(us\): (\d+),.*?99th\(us\): (\d+)`)
+
+// parseYCSBOutput parses the statistics of each operation, go-ycsb reports
+// the statistics periodically and the last one is the summary
+func parseYCSBOutput(output string) map[string]YCSBOpResult {
+	results := map[string]YCSBOpResult{}
+	for _, match := range ycsbOpRe.FindAllStringSubmatch(output, -1) {
+		count, _ := strconv.ParseInt(match[2], 10, 64)
+		ops, _ := strconv.ParseFloat(match[3], 64)
+		avg, _ := strconv.ParseInt(match[4], 10, 64)
+		p99, _ := strconv.ParseInt(match[5], 10, 64)
+		results[match[1]] = YCSBOpResult{
+			Count:      count,
+			OPS:        ops,
+			AvgLatency: avg,
+			P99Latency: p99,
+		}
+	}
+	return results
+}
@@ -0,0 +1,44 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParseYCSBOutput(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	output := `
+READ   - Takes(s): 10.0, Count: 4980, OPS: 498.1, Avg(us): 1523, Min(us): 512, Max(us): 30211, 99th(us): 9000, 99.9th(us): 23000, 99.99th(us): 30000
+UPDATE - Takes(s): 10.0, Count: 5010, OPS: 501.2, Avg(us): 3102, Min(us): 1012, Max(us): 50211, 99th(us): 15000, 99.9th(us): 45000, 99.99th(us): 50000
+Run finished, takes 20.1s
+READ   - Takes(s): 20.0, Count: 10020, OPS: 501.0, Avg(us): 1498, Min(us): 488, Max(us): 30211, 99th(us): 8000, 99.9th(us): 23000, 99.99th(us): 30000
+UPDATE - Takes(s): 20.0, Count: 9980, OPS: 499.0, Avg(us): 3087, Min(us): 999, Max(us): 50211, 99th(us): 14000, 99.9th(us): 45000, 99.99th(us): 50000
+`
+	results := parseYCSBOutput(output)
+	g.Expect(results).To(Equal(map[string]YCSBOpResult{
+		"READ":   {Count: 10020, OPS: 501.0, AvgLatency: 1498, P99Latency: 8000},
+		"UPDATE": {Count: 9980, OPS: 499.0, AvgLatency: 3087, P99Latency: 14000},
+	}))
+}
+
+func TestYCSBVerify(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(NewRawKVYCSB(YCSBConfig{Workloads: []string{"a", "F"}}, "pd:2379").Verify()).NotTo(HaveOccurred())
+	g.Expect(NewRawKVYCSB(YCSBConfig{Workloads: []string{"g"}}, "pd:2379").Verify()).To(HaveOccurred())
+}
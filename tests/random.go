@@ -0,0 +1,119 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// RandomConfig defines the randomized runs, in which the faults, the targets
+// and the operator actions are selected randomly instead of the scripted
+// sequence
+type RandomConfig struct {
+	// Seed of the random selections, a run is reproduced by the same seed,
+	// a seed is generated if it's 0
+	Seed int64 `yaml:"seed" json:"seed"`
+	// Steps is the number of the actions run in a run
+	Steps int `yaml:"steps" json:"steps"`
+	// Weights overrides the weights of the actions by name, an action is
+	// disabled if its weight is 0
+	Weights map[string]int `yaml:"weights" json:"weights"`
+}
+
+// RandomAction is an action of the weighted pool, the random source is
+// passed to select the targets reproducibly
+type RandomAction struct {
+	Name   string
+	Weight int
+	Run    func(rnd *rand.Rand)
+}
+
+// RandomScheduler selects the actions from a weighted pool
+type RandomScheduler struct {
+	seed    int64
+	rnd     *rand.Rand
+	actions []RandomAction
+	total   int
+}
+
+// NewRandomScheduler returns a scheduler selecting from the actions with the
+// weights overridden by the config
+func NewRandomScheduler(cfg *RandomConfig, actions []RandomAction) (*RandomScheduler, error) {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	s := &RandomScheduler{
+		seed: seed,
+		rnd:  rand.New(rand.NewSource(seed)),
+	}
+	for _, action := range actions {
+		if w, ok := cfg.Weights[action.Name]; ok {
+			action.Weight = w
+		}
+		if action.Weight < 0 {
+			return nil, fmt.Errorf("the weight of action %s is negative", action.Name)
+		}
+		if action.Weight == 0 {
+			continue
+		}
+		s.actions = append(s.actions, action)
+		s.total += action.Weight
+	}
+	if s.total == 0 {
+		return nil, fmt.Errorf("no action is enabled")
+	}
+
+	return s, nil
+}
+
+// Seed returns the seed the run is reproduced by
+func (s *RandomScheduler) Seed() int64 {
+	return s.seed
+}
+
+// Next selects an action randomly by the weights
+func (s *RandomScheduler) Next() RandomAction {
+	n := s.rnd.Intn(s.total)
+	for _, action := range s.actions {
+		if n < action.Weight {
+			return action
+		}
+		n -= action.Weight
+	}
+	// unreachable, the weights sum to total
+	return s.actions[len(s.actions)-1]
+}
+
+// Run runs the number of steps of the selected actions, before is called
+// before each step with the step number and the action name, and the step is
+// skipped if it returns false. The skipped steps don't draw from the random
+// source, so the rest of the run can't be replayed by the seed.
+func (s *RandomScheduler) Run(steps int, before func(step int, name string) bool) {
+	glog.Infof("running %d random steps with seed %d", steps, s.seed)
+	for i := 0; i < steps; i++ {
+		action := s.Next()
+		if !before(i, action.Name) {
+			glog.Infof("random step %d (seed %d): %s is skipped", i, s.seed, action.Name)
+			continue
+		}
+		glog.Infof("random step %d (seed %d): %s", i, s.seed, action.Name)
+		action.Run(s.rnd)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	"github.com/pingcap/tidb-operator/tests/notify"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// RegionHealthTimeout is how long the regions are given to be balanced and healthy
+	// after the scaling and failover cases, PD schedules the regions slowly by default
+	RegionHealthTimeout time.Duration = 30 * time.Minute
+	// regionBalanceTolerance is the tolerated difference of the leader and region counts
+	// of the stores, relative to the average count
+	regionBalanceTolerance = 0.2
+	// regionBalanceSlack is the tolerated difference of the counts of a small cluster,
+	// e.g. one which only has the few regions of the system tables
+	regionBalanceSlack = 10
+)
+
+// CheckRegionHealth checks that the leaders and regions are balanced across the
+// up stores, and no region has pending, down or missing peers
+func (oa *operatorActions) CheckRegionHealth(info *TidbClusterConfig) (bool, error) {
+	tc, err := oa.cli.PingcapV1alpha1().TidbClusters(info.Namespace).Get(info.ClusterName, metav1.GetOptions{})
+	if err != nil {
+		glog.Errorf("failed to get tidbcluster: [%s], error: %v", info.FullName(), err)
+		return false, nil
+	}
+	pdClient := oa.pdControl.GetPDClient(pdapi.Namespace(tc.GetNamespace()), tc.GetName(), tc.Spec.EnableTLSCluster)
+
+	for _, check := range []pdapi.RegionCheck{pdapi.RegionCheckPendingPeer, pdapi.RegionCheckDownPeer, pdapi.RegionCheckMissPeer} {
+		regions, err := pdClient.GetRegionsByCheck(check)
+		if err != nil {
+			glog.Errorf("failed to get the %s regions of cluster: [%s], error: %v", check, info.FullName(), err)
+			return false, nil
+		}
+		if regions.Count > 0 {
+			glog.Infof("cluster: [%s] has %d %s regions, continue to wait", info.FullName(), regions.Count, check)
+			return false, nil
+		}
+	}
+
+	stores, err := pdClient.GetStores()
+	if err != nil {
+		glog.Errorf("failed to get the stores of cluster: [%s], error: %v", info.FullName(), err)
+		return false, nil
+	}
+	var leaderCounts, regionCounts []int
+	for _, store := range stores.Stores {
+		if store.Store == nil || store.Status == nil || store.Store.StateName != metapb.StoreState_Up.String() {
+			continue
+		}
+		leaderCounts = append(leaderCounts, store.Status.LeaderCount)
+		regionCounts = append(regionCounts, store.Status.RegionCount)
+	}
+	if len(leaderCounts) == 0 {
+		glog.Infof("cluster: [%s] has no up stores, continue to wait", info.FullName())
+		return false, nil
+	}
+	if !balanced(leaderCounts) {
+		glog.Infof("cluster: [%s]'s leaders are not balanced: %v, continue to wait", info.FullName(), leaderCounts)
+		return false, nil
+	}
+	if !balanced(regionCounts) {
+		glog.Infof("cluster: [%s]'s regions are not balanced: %v, continue to wait", info.FullName(), regionCounts)
+		return false, nil
+	}
+	return true, nil
+}
+
+func (oa *operatorActions) CheckRegionHealthOrDie(clusters []*TidbClusterConfig) {
+	for _, cluster := range clusters {
+		cluster := cluster
+		if err := wait.Poll(oa.pollInterval, RegionHealthTimeout, func() (bool, error) {
+			return oa.CheckRegionHealth(cluster)
+		}); err != nil {
+			notify.NotifyAndPanic(fmt.Errorf("cluster: [%s]'s regions are not balanced and healthy in %s", cluster.FullName(), RegionHealthTimeout))
+		}
+	}
+}
+
+// balanced returns whether the counts differ within the tolerance
+func balanced(counts []int) bool {
+	min, max, sum := counts[0], counts[0], 0
+	for _, count := range counts {
+		if count < min {
+			min = count
+		}
+		if count > max {
+			max = count
+		}
+		sum += count
+	}
+	average := float64(sum) / float64(len(counts))
+	return float64(max-min) <= average*regionBalanceTolerance+regionBalanceSlack
+}
@@ -0,0 +1,198 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pingcap/tidb-operator/pkg/label"
+	"github.com/pingcap/tidb-operator/tests/notify"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ScaleConfig defines the clusters deployed and exercised concurrently, so
+// the scalability regressions of the operator show up
+type ScaleConfig struct {
+	// Clusters is the number of the clusters
+	Clusters int `yaml:"clusters" json:"clusters"`
+	// Parallelism is the max number of the clusters exercised at the same time
+	Parallelism int `yaml:"parallelism" json:"parallelism"`
+	// FaultBudget is the max number of the faults injected at the same time
+	// across all the clusters
+	FaultBudget int `yaml:"fault_budget" json:"fault_budget"`
+}
+
+// ClusterCase is a case run against a cluster, the faults must be injected
+// within the fault budget
+type ClusterCase func(info *TidbClusterConfig, budget *FaultBudget) error
+
+// FaultBudget limits the faults injected at the same time, so the concurrent
+// cases don't break the clusters more than the operator is expected to handle
+type FaultBudget struct {
+	tokens chan struct{}
+}
+
+func NewFaultBudget(size int) *FaultBudget {
+	if size < 1 {
+		size = 1
+	}
+	return &FaultBudget{tokens: make(chan struct{}, size)}
+}
+
+// Inject runs fault within the budget, it blocks until the budget is available
+func (fb *FaultBudget) Inject(fault func() error) error {
+	fb.tokens <- struct{}{}
+	defer func() { <-fb.tokens }()
+	return fault()
+}
+
+// ClusterScheduler runs a case against many clusters concurrently with
+// bounded parallelism
+type ClusterScheduler struct {
+	parallelism int
+	budget      *FaultBudget
+}
+
+func NewClusterScheduler(cfg *ScaleConfig) *ClusterScheduler {
+	parallelism := cfg.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	return &ClusterScheduler{
+		parallelism: parallelism,
+		budget:      NewFaultBudget(cfg.FaultBudget),
+	}
+}
+
+// Run runs the case against all the clusters in a goroutine per cluster and
+// waits for them, the errors of all the clusters are returned. The panics of
+// the OrDie actions called by the case are recovered as errors, so one
+// cluster doesn't stop the others.
+func (s *ClusterScheduler) Run(clusters []*TidbClusterConfig, fn ClusterCase) error {
+	sem := make(chan struct{}, s.parallelism)
+	errs := make([]error, len(clusters))
+
+	var wg sync.WaitGroup
+	for i, info := range clusters {
+		i, info := i, info
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			defer func() {
+				if e := recover(); e != nil {
+					errs[i] = fmt.Errorf("cluster %s panicked: %v", info.FullName(), e)
+				}
+			}()
+
+			start := time.Now()
+			if err := fn(info, s.budget); err != nil {
+				errs[i] = fmt.Errorf("cluster %s: %v", info.FullName(), err)
+				return
+			}
+			glog.Infof("cluster %s finished in %v", info.FullName(), time.Since(start))
+		}()
+	}
+	wg.Wait()
+
+	return utilerrors.NewAggregate(errs)
+}
+
+func (s *ClusterScheduler) RunOrDie(clusters []*TidbClusterConfig, fn ClusterCase) {
+	if err := s.Run(clusters, fn); err != nil {
+		notify.NotifyAndPanic(err)
+	}
+}
+
+// ScaleCase deploys the cluster, scales it out and in, kills a tikv pod
+// within the fault budget and cleans the cluster, the time spent by the
+// operator on each step is logged.
+func ScaleCase(oa OperatorActions, kubeCli kubernetes.Interface) ClusterCase {
+	return func(info *TidbClusterConfig, budget *FaultBudget) error {
+		step := func(name string, fn func() error) error {
+			start := time.Now()
+			if err := fn(); err != nil {
+				return fmt.Errorf("%s failed: %v", name, err)
+			}
+			glog.Infof("cluster %s: %s took %v", info.FullName(), name, time.Since(start))
+			return nil
+		}
+
+		if err := step("deploy", func() error {
+			if err := oa.DeployTidbCluster(info); err != nil {
+				return err
+			}
+			return oa.CheckTidbClusterStatus(info)
+		}); err != nil {
+			return err
+		}
+
+		if err := step("scale out", func() error {
+			info.ScaleTiKV(4).ScaleTiDB(2)
+			if err := oa.ScaleTidbCluster(info); err != nil {
+				return err
+			}
+			return oa.CheckTidbClusterStatus(info)
+		}); err != nil {
+			return err
+		}
+
+		if err := step("kill tikv", func() error {
+			return budget.Inject(func() error {
+				if err := killOneTiKVPod(kubeCli, info); err != nil {
+					return err
+				}
+				return oa.CheckTidbClusterStatus(info)
+			})
+		}); err != nil {
+			return err
+		}
+
+		if err := step("scale in", func() error {
+			info.ScaleTiKV(3).ScaleTiDB(1)
+			if err := oa.ScaleTidbCluster(info); err != nil {
+				return err
+			}
+			return oa.CheckTidbClusterStatus(info)
+		}); err != nil {
+			return err
+		}
+
+		return step("clean", func() error {
+			return oa.CleanTidbCluster(info)
+		})
+	}
+}
+
+func killOneTiKVPod(kubeCli kubernetes.Interface, info *TidbClusterConfig) error {
+	selector := label.New().Instance(info.ClusterName).TiKV().String()
+	pods, err := kubeCli.CoreV1().Pods(info.Namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return err
+	}
+	if len(pods.Items) == 0 {
+		return fmt.Errorf("no tikv pod of cluster %s is found", info.FullName())
+	}
+
+	pod := pods.Items[rand.Intn(len(pods.Items))]
+	glog.Infof("killing tikv pod %s/%s", pod.Namespace, pod.Name)
+	return kubeCli.CoreV1().Pods(pod.Namespace).Delete(pod.Name, &metav1.DeleteOptions{})
+}
@@ -0,0 +1,240 @@
+// Copyright 2019 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"crypto/tls"
+	"crypto/x509/pkix"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap.com/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/httputil"
+	"github.com/pingcap/tidb-operator/tests/notify"
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/cert"
+)
+
+const (
+	// TLSClientSecretName is the secret of the client certificate in the
+	// namespace of a TLS cluster, it's read by the discovery, the monitor and tkctl
+	TLSClientSecretName = "client-tls"
+	// tlsClientCertDir is where the PD clients of the operator and the tests
+	// read the client certificate
+	tlsClientCertDir = "/var/lib/tls"
+	// csrTimeout is how long the certificate signing requests are given to be signed
+	csrTimeout = 2 * time.Minute
+)
+
+// CreateTLSCerts creates the secrets of the server certificates of the
+// components and the client certificate of a TLS cluster. The certificates
+// are signed by the CA of the kubernetes cluster, which is trusted by the
+// components, and the existing secrets are kept.
+func (oa *operatorActions) CreateTLSCerts(info *TidbClusterConfig) error {
+	ns := info.Namespace
+	for component, setName := range map[string]string{
+		v1alpha1.PDMemberType.String():   controller.PDMemberName(info.ClusterName),
+		v1alpha1.TiKVMemberType.String(): controller.TiKVMemberName(info.ClusterName),
+		v1alpha1.TiDBMemberType.String(): controller.TiDBMemberName(info.ClusterName),
+	} {
+		if err := oa.createTLSSecret(ns, setName, component, memberHosts(ns, setName)); err != nil {
+			return fmt.Errorf("failed to create the %s certificate of cluster [%s]: %v", component, info.FullName(), err)
+		}
+	}
+	if err := oa.createTLSSecret(ns, TLSClientSecretName, "client", nil); err != nil {
+		return fmt.Errorf("failed to create the client certificate of cluster [%s]: %v", info.FullName(), err)
+	}
+	return nil
+}
+
+// SetupTLSClient creates the client certificate mounted by the operator and
+// writes it to where the PD clients of the tests read it from
+func (oa *operatorActions) SetupTLSClient(info *OperatorConfig) error {
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: info.Namespace,
+		},
+	}
+	if _, err := oa.kubeCli.CoreV1().Namespaces().Create(namespace); err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create namespace[%s]:%v", info.Namespace, err)
+	}
+	if err := oa.createTLSSecret(info.Namespace, info.TLSClientSecretName, "client", nil); err != nil {
+		return fmt.Errorf("failed to create the client certificate of the operator: %v", err)
+	}
+
+	secret, err := oa.kubeCli.CoreV1().Secrets(info.Namespace).Get(info.TLSClientSecretName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(tlsClientCertDir, 0755); err != nil {
+		return err
+	}
+	for _, key := range []string{"client.crt", "client.key"} {
+		if err := ioutil.WriteFile(filepath.Join(tlsClientCertDir, key), secret.Data[key], 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (oa *operatorActions) SetupTLSClientOrDie(info *OperatorConfig) {
+	if err := oa.SetupTLSClient(info); err != nil {
+		notify.NotifyAndPanic(err)
+	}
+}
+
+// createTLSSecret creates the secret of a certificate with the name as the
+// common name, the certificate and key are stored in <name>.crt and <name>.key
+func (oa *operatorActions) createTLSSecret(ns, secretName, name string, hosts []string) error {
+	_, err := oa.kubeCli.CoreV1().Secrets(ns).Get(secretName, metav1.GetOptions{})
+	if err == nil {
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return err
+	}
+
+	certPEM, keyPEM, err := oa.signCert(fmt.Sprintf("%s-%s", ns, secretName), name, hosts)
+	if err != nil {
+		return err
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: ns,
+		},
+		Data: map[string][]byte{
+			name + ".crt": certPEM,
+			name + ".key": keyPEM,
+		},
+		Type: corev1.SecretTypeOpaque,
+	}
+	_, err = oa.kubeCli.CoreV1().Secrets(ns).Create(secret)
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	glog.Infof("created the certificate secret %s/%s", ns, secretName)
+	return nil
+}
+
+// signCert requests the certificate by a certificate signing request, which
+// is approved by the tests and signed by the kubernetes cluster
+func (oa *operatorActions) signCert(csrName, commonName string, hosts []string) ([]byte, []byte, error) {
+	key, err := cert.NewPrivateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	var dnsNames []string
+	var ips []net.IP
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			ips = append(ips, ip)
+		} else {
+			dnsNames = append(dnsNames, host)
+		}
+	}
+	request, err := cert.MakeCSR(key, &pkix.Name{CommonName: commonName}, dnsNames, ips)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	csrClient := oa.kubeCli.CertificatesV1beta1().CertificateSigningRequests()
+	// the request of the last run is stale, its key is lost
+	if err := csrClient.Delete(csrName, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		return nil, nil, err
+	}
+	csr, err := csrClient.Create(&certificatesv1beta1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: csrName,
+		},
+		Spec: certificatesv1beta1.CertificateSigningRequestSpec{
+			Request: request,
+			Usages: []certificatesv1beta1.KeyUsage{
+				certificatesv1beta1.UsageDigitalSignature,
+				certificatesv1beta1.UsageKeyEncipherment,
+				certificatesv1beta1.UsageServerAuth,
+				certificatesv1beta1.UsageClientAuth,
+			},
+		},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer csrClient.Delete(csrName, &metav1.DeleteOptions{})
+
+	csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1beta1.CertificateSigningRequestCondition{
+		Type:    certificatesv1beta1.CertificateApproved,
+		Reason:  "StabilityTestApprove",
+		Message: "approved by the stability tests",
+	})
+	if _, err := csrClient.UpdateApproval(csr); err != nil {
+		return nil, nil, err
+	}
+
+	var certPEM []byte
+	if err := wait.Poll(5*time.Second, csrTimeout, func() (bool, error) {
+		csr, err := csrClient.Get(csrName, metav1.GetOptions{})
+		if err != nil {
+			glog.Errorf("failed to get csr %s: %v", csrName, err)
+			return false, nil
+		}
+		certPEM = csr.Status.Certificate
+		return len(certPEM) > 0, nil
+	}); err != nil {
+		return nil, nil, fmt.Errorf("csr %s is not signed in %s, is the signer of kube-controller-manager enabled? %v", csrName, csrTimeout, err)
+	}
+	return certPEM, cert.EncodePrivateKeyPEM(key), nil
+}
+
+// memberHosts returns the hosts the members of a component are accessed by,
+// i.e. the service, the peer service and the pods behind the peer service
+func memberHosts(ns, setName string) []string {
+	hosts := []string{"localhost", "127.0.0.1"}
+	for _, svc := range []string{setName, setName + "-peer", "*." + setName + "-peer"} {
+		hosts = append(hosts, svc, fmt.Sprintf("%s.%s", svc, ns), fmt.Sprintf("%s.%s.svc", svc, ns))
+	}
+	return hosts
+}
+
+// newHTTPClient returns the client and the scheme of the HTTP APIs of a
+// cluster, the client certificate is used if TLS is enabled
+func newHTTPClient(tlsEnabled bool, timeout time.Duration) (*http.Client, string, error) {
+	if !tlsEnabled {
+		return &http.Client{Timeout: timeout}, "http", nil
+	}
+	rootCAs, clientCert, err := httputil.ReadCerts()
+	if err != nil {
+		return nil, "", err
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				RootCAs:      rootCAs,
+				Certificates: []tls.Certificate{clientCert},
+			},
+		},
+	}, "https", nil
+}
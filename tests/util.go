@@ -20,7 +20,7 @@ import (
 	"text/template"
 	"time"
 
-	"github.com/pingcap/tidb-operator/tests/slack"
+	"github.com/pingcap/tidb-operator/tests/notify"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -39,7 +39,7 @@ func KeepOrDie(interval time.Duration, period time.Duration, fun func() error) {
 		}
 		err := fun()
 		if err != nil {
-			slack.NotifyAndPanic(err)
+			notify.NotifyAndPanic(err)
 		}
 		time.Sleep(interval)
 	}
@@ -192,23 +192,23 @@ type BinLogInfo struct {
 func GetSubValuesOrDie(clusterName, namespace, topologyKey string, pdConfig []string, tikvConfig []string, tidbConfig []string, pumpConfig []string, drainerConfig []string) string {
 	temp, err := template.New("dt-affinity").Parse(affinityTemp)
 	if err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 
 	pdbuff := new(bytes.Buffer)
 	err = temp.Execute(pdbuff, &AffinityInfo{ClusterName: clusterName, Kind: "pd", Weight: 50, Namespace: namespace, TopologyKey: topologyKey, Config: pdConfig})
 	if err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 	tikvbuff := new(bytes.Buffer)
 	err = temp.Execute(tikvbuff, &AffinityInfo{ClusterName: clusterName, Kind: "tikv", Weight: 50, Namespace: namespace, TopologyKey: topologyKey, Config: tikvConfig})
 	if err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 	tidbbuff := new(bytes.Buffer)
 	err = temp.Execute(tidbbuff, &AffinityInfo{ClusterName: clusterName, Kind: "tidb", Weight: 50, Namespace: namespace, TopologyKey: topologyKey, Config: tidbConfig})
 	if err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 	subValues := fmt.Sprintf("%s%s%s", pdbuff.String(), tikvbuff.String(), tidbbuff.String())
 
@@ -218,12 +218,12 @@ func GetSubValuesOrDie(clusterName, namespace, topologyKey string, pdConfig []st
 
 	btemp, err := template.New("binlog").Parse(binlogTemp)
 	if err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 	binlogbuff := new(bytes.Buffer)
 	err = btemp.Execute(binlogbuff, &BinLogInfo{PumpConfig: pumpConfig, DrainerConfig: drainerConfig, Namespace: namespace, TopologyKey: topologyKey})
 	if err != nil {
-		slack.NotifyAndPanic(err)
+		notify.NotifyAndPanic(err)
 	}
 	subValues = fmt.Sprintf("%s%s", subValues, binlogbuff.String())
 	return subValues
@@ -231,30 +231,30 @@ func GetSubValuesOrDie(clusterName, namespace, topologyKey string, pdConfig []st
 
 func GetDrainerSubValuesOrDie(info *DrainerConfig) string {
 	if info == nil {
-		slack.NotifyAndPanic(fmt.Errorf("Cannot get drainer sub values, the drainer config is nil"))
+		notify.NotifyAndPanic(fmt.Errorf("Cannot get drainer sub values, the drainer config is nil"))
 	}
 	buff := new(bytes.Buffer)
 	switch info.DbType {
 	case DbTypeFile:
 		temp, err := template.New("file-drainer").Parse(fileDrainerConfigTemp)
 		if err != nil {
-			slack.NotifyAndPanic(err)
+			notify.NotifyAndPanic(err)
 		}
 		if err := temp.Execute(buff, &info); err != nil {
-			slack.NotifyAndPanic(err)
+			notify.NotifyAndPanic(err)
 		}
 	case DbTypeTiDB:
 		fallthrough
 	case DbTypeMySQL:
 		temp, err := template.New("sql-drainer").Parse(sqlDrainerConfigTemp)
 		if err != nil {
-			slack.NotifyAndPanic(err)
+			notify.NotifyAndPanic(err)
 		}
 		if err := temp.Execute(buff, &info); err != nil {
-			slack.NotifyAndPanic(err)
+			notify.NotifyAndPanic(err)
 		}
 	default:
-		slack.NotifyAndPanic(fmt.Errorf("db-type %s has not been suppored yet", info.DbType))
+		notify.NotifyAndPanic(fmt.Errorf("db-type %s has not been suppored yet", info.DbType))
 	}
 	return buff.String()
 }